@@ -0,0 +1,153 @@
+package selenosis
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/selenium"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+//AdmissionRequest is new session request passed to admission hooks, hooks may change requested capabilities
+//and browser template the pod is built from
+type AdmissionRequest struct {
+	Tenant       string
+	Owner        string
+	Capabilities *selenium.Capabilities
+	Template     *platform.BrowserSpec
+}
+
+//AdmissionHook is invoked before browser pod is created in order hooks are configured, hook returning
+//error rejects the session with its message
+type AdmissionHook interface {
+	Name() string
+	Admit(req *AdmissionRequest) error
+}
+
+//admissionRejected is error of session rejected by admission hook
+type admissionRejected struct {
+	hook string
+	err  error
+}
+
+func (e *admissionRejected) Error() string {
+	return fmt.Sprintf("session rejected by %s admission hook: %v", e.hook, e.err)
+}
+
+//admit runs admission hooks over the request, the first rejection stops the chain
+func (app *App) admit(req *AdmissionRequest) error {
+	for _, hook := range app.admissionHooks {
+		if err := hook.Admit(req); err != nil {
+			return &admissionRejected{hook: hook.Name(), err: err}
+		}
+	}
+	return nil
+}
+
+//BannedImages rejects sessions of browsers, sidecars of which included, using images matching any of
+//patterns, * matches any characters and ? single one, e.g. "*:latest" or "registry.example.com/*"
+func BannedImages(patterns []string) AdmissionHook {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		expr := strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(regexp.QuoteMeta(pattern))
+		compiled = append(compiled, regexp.MustCompile("^"+expr+"$"))
+	}
+	return &bannedImages{patterns: compiled}
+}
+
+type bannedImages struct {
+	patterns []*regexp.Regexp
+}
+
+func (h *bannedImages) Name() string {
+	return "banned-images"
+}
+
+func (h *bannedImages) Admit(req *AdmissionRequest) error {
+	images := []string{req.Template.Image}
+	for _, sidecar := range req.Template.Spec.Sidecars {
+		images = append(images, sidecar.Image)
+	}
+	for _, image := range images {
+		for _, pattern := range h.patterns {
+			if pattern.MatchString(image) {
+				return fmt.Errorf("image %s is banned", image)
+			}
+		}
+	}
+	return nil
+}
+
+//ResourceCeiling lowers cpu and memory requests and limits of browser and proxy containers exceeding
+//the ceiling down to it, so misconfigured browser can't claim a whole node
+func ResourceCeiling(ceiling apiv1.ResourceList) AdmissionHook {
+	return &resourceCeiling{ceiling: ceiling}
+}
+
+type resourceCeiling struct {
+	ceiling apiv1.ResourceList
+}
+
+func (h *resourceCeiling) Name() string {
+	return "resource-ceiling"
+}
+
+func (h *resourceCeiling) Admit(req *AdmissionRequest) error {
+	req.Template.Spec.Resources = h.lower(req.Template.Spec.Resources)
+	req.Template.Spec.ProxyResources = h.lower(req.Template.Spec.ProxyResources)
+	return nil
+}
+
+//lower returns copy of resources capped by the ceiling, resources of template are shared with browsers config
+func (h *resourceCeiling) lower(resources apiv1.ResourceRequirements) apiv1.ResourceRequirements {
+	result := *resources.DeepCopy()
+	for _, list := range []apiv1.ResourceList{result.Requests, result.Limits} {
+		for name, max := range h.ceiling {
+			if value, ok := list[name]; ok && value.Cmp(max) > 0 {
+				list[name] = max.DeepCopy()
+			}
+		}
+	}
+	return result
+}
+
+//CostCenterLabel labels browser pod with cost center of the session, value is taken from capability label
+//of the same name, tenant of the session otherwise, so pod costs can be split by cost allocation tools
+func CostCenterLabel(label string) (AdmissionHook, error) {
+	if errs := validation.IsQualifiedName(label); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid cost center label %s: %v", label, errs)
+	}
+	return &costCenterLabel{label: label}, nil
+}
+
+type costCenterLabel struct {
+	label string
+}
+
+func (h *costCenterLabel) Name() string {
+	return "cost-center"
+}
+
+func (h *costCenterLabel) Admit(req *AdmissionRequest) error {
+	value := req.Capabilities.Labels[h.label]
+	if value == "" {
+		value = req.Tenant
+	}
+	if value == "" {
+		return nil
+	}
+	if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+		return fmt.Errorf("invalid cost center %s: %v", value, errs)
+	}
+
+	labels := make(map[string]string, len(req.Template.Meta.Labels)+1)
+	for k, v := range req.Template.Meta.Labels {
+		labels[k] = v
+	}
+	labels[h.label] = value
+	req.Template.Meta.Labels = labels
+	return nil
+}
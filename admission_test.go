@@ -0,0 +1,164 @@
+package selenosis
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/selenium"
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestAdmissionHooks(t *testing.T) {
+	banned := BannedImages([]string{"*:latest", "docker.io/*"})
+	ceiling, err := platform.ParseResourceList("500m", "")
+	assert.NilError(t, err)
+	costCenter, err := CostCenterLabel("cost-center")
+	assert.NilError(t, err)
+
+	tests := map[string]struct {
+		hook     AdmissionHook
+		tenant   string
+		caps     selenium.Capabilities
+		template platform.BrowserSpec
+		verify   func(t *testing.T, template platform.BrowserSpec)
+		err      string
+	}{
+		"Verify browser image matching pattern is banned": {
+			hook:     banned,
+			template: platform.BrowserSpec{Image: "selenoid/vnc:latest"},
+			err:      "image selenoid/vnc:latest is banned",
+		},
+		"Verify sidecar image matching pattern is banned": {
+			hook: banned,
+			template: platform.BrowserSpec{
+				Image: "selenoid/vnc:chrome_86.0",
+				Spec:  platform.Spec{Sidecars: []apiv1.Container{{Name: "proxy", Image: "docker.io/envoy"}}},
+			},
+			err: "image docker.io/envoy is banned",
+		},
+		"Verify image not matching patterns is admitted": {
+			hook:     banned,
+			template: platform.BrowserSpec{Image: "selenoid/vnc:chrome_86.0"},
+		},
+		"Verify resources above ceiling are lowered": {
+			hook: ResourceCeiling(ceiling),
+			template: platform.BrowserSpec{Spec: platform.Spec{Resources: apiv1.ResourceRequirements{
+				Requests: apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("250m")},
+				Limits:   apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("2"), apiv1.ResourceMemory: resource.MustParse("4Gi")},
+			}}},
+			verify: func(t *testing.T, template platform.BrowserSpec) {
+				resources := template.Spec.Resources
+				assert.Equal(t, "250m", resources.Requests.Cpu().String())
+				assert.Equal(t, "500m", resources.Limits.Cpu().String())
+				assert.Equal(t, "4Gi", resources.Limits.Memory().String())
+			},
+		},
+		"Verify cost center is taken from capability label": {
+			hook:     costCenter,
+			tenant:   "team-a",
+			caps:     selenium.Capabilities{Labels: map[string]string{"cost-center": "cc-42"}},
+			template: platform.BrowserSpec{Meta: platform.Meta{Labels: map[string]string{"app": "browser"}}},
+			verify: func(t *testing.T, template platform.BrowserSpec) {
+				assert.DeepEqual(t, map[string]string{"app": "browser", "cost-center": "cc-42"}, template.Meta.Labels)
+			},
+		},
+		"Verify cost center defaults to tenant": {
+			hook:   costCenter,
+			tenant: "team-a",
+			verify: func(t *testing.T, template platform.BrowserSpec) {
+				assert.DeepEqual(t, map[string]string{"cost-center": "team-a"}, template.Meta.Labels)
+			},
+		},
+		"Verify invalid cost center is rejected": {
+			hook: costCenter,
+			caps: selenium.Capabilities{Labels: map[string]string{"cost-center": "cc 42"}},
+			err:  "invalid cost center cc 42: [a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')]",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		labels := test.template.Meta.Labels
+		template := test.template
+		err := test.hook.Admit(&AdmissionRequest{Tenant: test.tenant, Capabilities: &test.caps, Template: &template})
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+		if test.verify != nil {
+			test.verify(t, template)
+		}
+		assert.DeepEqual(t, labels, test.template.Meta.Labels)
+	}
+}
+
+func TestAdmissionHooksInvalid(t *testing.T) {
+	_, err := CostCenterLabel("cost center")
+	assert.Assert(t, err != nil)
+}
+
+type admissionMock struct {
+	err error
+}
+
+func (h *admissionMock) Name() string {
+	return "mock"
+}
+
+func (h *admissionMock) Admit(req *AdmissionRequest) error {
+	req.Template.Image = "registry.example.com/chrome:86.0"
+	req.Capabilities.TestName = "admitted"
+	return h.err
+}
+
+func TestNewSessionAdmission(t *testing.T) {
+	tests := map[string]struct {
+		hook     AdmissionHook
+		code     int
+		image    string
+		testName string
+		created  int
+	}{
+		"Verify session is created with template and capabilities changed by hook": {
+			hook:     &admissionMock{},
+			code:     http.StatusInternalServerError,
+			image:    "registry.example.com/chrome:86.0",
+			testName: "admitted",
+			created:  1,
+		},
+		"Verify session rejected by hook is not created": {
+			hook: &admissionMock{err: errors.New("not allowed")},
+			code: http.StatusForbidden,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		client := &PlatformMock{err: errors.New("failed to create pod")}
+		app := initApp(client)
+		app.sessionRetryCount = 1
+		app.admissionHooks = []AdmissionHook{test.hook}
+
+		req, err := http.NewRequest(http.MethodPost, session, bytes.NewReader([]byte(`{"capabilities":{"alwaysMatch":{"browserName":"chrome","browserVersion":"86.0"}}}`)))
+		assert.NilError(t, err)
+		rr := httptest.NewRecorder()
+		app.HandleSession(rr, req)
+
+		assert.Equal(t, test.code, rr.Code)
+		assert.Equal(t, test.created, client.created)
+		assert.Equal(t, test.image, client.layout.Template.Image)
+		assert.Equal(t, test.testName, client.layout.RequestedCapabilities.TestName)
+		if test.created == 0 {
+			assert.Assert(t, bytes.Contains(rr.Body.Bytes(), []byte("session rejected by mock admission hook: not allowed")))
+		}
+	}
+}
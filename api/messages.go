@@ -0,0 +1,384 @@
+package api
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+//CreateSessionRequest ...
+type CreateSessionRequest struct {
+	BrowserName      string
+	BrowserVersion   string
+	PlatformName     string
+	CapabilitiesJSON string
+}
+
+//CreateSessionResponse ...
+type CreateSessionResponse struct {
+	Session          *Session
+	CapabilitiesJSON string
+}
+
+//DeleteSessionRequest ...
+type DeleteSessionRequest struct {
+	SessionID string
+}
+
+//DeleteSessionResponse ...
+type DeleteSessionResponse struct{}
+
+//ListSessionsRequest ...
+type ListSessionsRequest struct{}
+
+//ListSessionsResponse ...
+type ListSessionsResponse struct {
+	Sessions []*Session
+}
+
+//WatchSessionsRequest ...
+type WatchSessionsRequest struct{}
+
+//EventType is type of session registry change
+type EventType int32
+
+const (
+	//Added session is new to the registry
+	Added EventType = 1
+	//Updated session changed its status
+	Updated EventType = 2
+	//Deleted session is gone from the registry
+	Deleted EventType = 3
+)
+
+//SessionEvent ...
+type SessionEvent struct {
+	Type    EventType
+	Session *Session
+}
+
+//Session ...
+type Session struct {
+	SessionID      string
+	BrowserName    string
+	BrowserVersion string
+	Status         string
+	Tenant         string
+	RunID          string
+	Relay          bool
+	Started        time.Time
+	LastActivity   time.Time
+	Commands       int64
+	Labels         map[string]string
+}
+
+//Marshal ...
+func (m *CreateSessionRequest) Marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, m.BrowserName)
+	b = appendString(b, 2, m.BrowserVersion)
+	b = appendString(b, 3, m.PlatformName)
+	return appendString(b, 4, m.CapabilitiesJSON)
+}
+
+//Unmarshal ...
+func (m *CreateSessionRequest) Unmarshal(b []byte) error {
+	return unmarshal(b, func(f field) error {
+		switch f.num {
+		case 1:
+			m.BrowserName = string(f.bytes)
+		case 2:
+			m.BrowserVersion = string(f.bytes)
+		case 3:
+			m.PlatformName = string(f.bytes)
+		case 4:
+			m.CapabilitiesJSON = string(f.bytes)
+		}
+		return nil
+	})
+}
+
+//Marshal ...
+func (m *CreateSessionResponse) Marshal() []byte {
+	var b []byte
+	if m.Session != nil {
+		b = appendMessage(b, 1, m.Session.Marshal())
+	}
+	return appendString(b, 2, m.CapabilitiesJSON)
+}
+
+//Unmarshal ...
+func (m *CreateSessionResponse) Unmarshal(b []byte) error {
+	return unmarshal(b, func(f field) error {
+		switch f.num {
+		case 1:
+			m.Session = &Session{}
+			return m.Session.Unmarshal(f.bytes)
+		case 2:
+			m.CapabilitiesJSON = string(f.bytes)
+		}
+		return nil
+	})
+}
+
+//Marshal ...
+func (m *DeleteSessionRequest) Marshal() []byte {
+	return appendString(nil, 1, m.SessionID)
+}
+
+//Unmarshal ...
+func (m *DeleteSessionRequest) Unmarshal(b []byte) error {
+	return unmarshal(b, func(f field) error {
+		if f.num == 1 {
+			m.SessionID = string(f.bytes)
+		}
+		return nil
+	})
+}
+
+//Marshal ...
+func (m *DeleteSessionResponse) Marshal() []byte {
+	return nil
+}
+
+//Unmarshal ...
+func (m *DeleteSessionResponse) Unmarshal(b []byte) error {
+	return unmarshal(b, func(field) error { return nil })
+}
+
+//Marshal ...
+func (m *ListSessionsRequest) Marshal() []byte {
+	return nil
+}
+
+//Unmarshal ...
+func (m *ListSessionsRequest) Unmarshal(b []byte) error {
+	return unmarshal(b, func(field) error { return nil })
+}
+
+//Marshal ...
+func (m *ListSessionsResponse) Marshal() []byte {
+	var b []byte
+	for _, s := range m.Sessions {
+		b = appendMessage(b, 1, s.Marshal())
+	}
+	return b
+}
+
+//Unmarshal ...
+func (m *ListSessionsResponse) Unmarshal(b []byte) error {
+	return unmarshal(b, func(f field) error {
+		if f.num != 1 {
+			return nil
+		}
+		s := &Session{}
+		if err := s.Unmarshal(f.bytes); err != nil {
+			return err
+		}
+		m.Sessions = append(m.Sessions, s)
+		return nil
+	})
+}
+
+//Marshal ...
+func (m *WatchSessionsRequest) Marshal() []byte {
+	return nil
+}
+
+//Unmarshal ...
+func (m *WatchSessionsRequest) Unmarshal(b []byte) error {
+	return unmarshal(b, func(field) error { return nil })
+}
+
+//Marshal ...
+func (m *SessionEvent) Marshal() []byte {
+	b := appendVarint(nil, 1, uint64(m.Type))
+	if m.Session != nil {
+		b = appendMessage(b, 2, m.Session.Marshal())
+	}
+	return b
+}
+
+//Unmarshal ...
+func (m *SessionEvent) Unmarshal(b []byte) error {
+	return unmarshal(b, func(f field) error {
+		switch f.num {
+		case 1:
+			m.Type = EventType(f.varint)
+		case 2:
+			m.Session = &Session{}
+			return m.Session.Unmarshal(f.bytes)
+		}
+		return nil
+	})
+}
+
+//Marshal ...
+func (m *Session) Marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, m.SessionID)
+	b = appendString(b, 2, m.BrowserName)
+	b = appendString(b, 3, m.BrowserVersion)
+	b = appendString(b, 4, m.Status)
+	b = appendString(b, 5, m.Tenant)
+	b = appendString(b, 6, m.RunID)
+	if m.Relay {
+		b = appendVarint(b, 7, 1)
+	}
+	b = appendTimestamp(b, 8, m.Started)
+	b = appendTimestamp(b, 9, m.LastActivity)
+	b = appendVarint(b, 10, uint64(m.Commands))
+
+	keys := make([]string, 0, len(m.Labels))
+	for k := range m.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		entry := appendString(nil, 1, k)
+		entry = appendString(entry, 2, m.Labels[k])
+		b = appendMessage(b, 11, entry)
+	}
+	return b
+}
+
+//Unmarshal ...
+func (m *Session) Unmarshal(b []byte) error {
+	return unmarshal(b, func(f field) error {
+		var err error
+		switch f.num {
+		case 1:
+			m.SessionID = string(f.bytes)
+		case 2:
+			m.BrowserName = string(f.bytes)
+		case 3:
+			m.BrowserVersion = string(f.bytes)
+		case 4:
+			m.Status = string(f.bytes)
+		case 5:
+			m.Tenant = string(f.bytes)
+		case 6:
+			m.RunID = string(f.bytes)
+		case 7:
+			m.Relay = protowire.DecodeBool(f.varint)
+		case 8:
+			m.Started, err = unmarshalTimestamp(f.bytes)
+		case 9:
+			m.LastActivity, err = unmarshalTimestamp(f.bytes)
+		case 10:
+			m.Commands = int64(f.varint)
+		case 11:
+			var k, v string
+			err = unmarshal(f.bytes, func(f field) error {
+				switch f.num {
+				case 1:
+					k = string(f.bytes)
+				case 2:
+					v = string(f.bytes)
+				}
+				return nil
+			})
+			if m.Labels == nil {
+				m.Labels = make(map[string]string)
+			}
+			m.Labels[k] = v
+		}
+		return err
+	})
+}
+
+//field is decoded field of protobuf message, value is kept in varint for varint fields and in bytes for
+//length delimited ones
+type field struct {
+	num    protowire.Number
+	typ    protowire.Type
+	varint uint64
+	bytes  []byte
+}
+
+//unmarshal decodes protobuf message b calling fn for every varint and length delimited field, other
+//wire types are skipped as no message of the service has them
+func unmarshal(b []byte, fn func(field) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		f := field{num: num, typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			f.varint, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			f.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if typ != protowire.VarintType && typ != protowire.BytesType {
+			continue
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendMessage(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+//appendTimestamp encodes t as google.protobuf.Timestamp, zero time is not encoded
+func appendTimestamp(b []byte, num protowire.Number, t time.Time) []byte {
+	if t.IsZero() {
+		return b
+	}
+	ts := appendVarint(nil, 1, uint64(t.Unix()))
+	ts = appendVarint(ts, 2, uint64(t.Nanosecond()))
+	return appendMessage(b, num, ts)
+}
+
+func unmarshalTimestamp(b []byte) (time.Time, error) {
+	var seconds, nanos int64
+	err := unmarshal(b, func(f field) error {
+		switch f.num {
+		case 1:
+			seconds = int64(f.varint)
+		case 2:
+			nanos = int64(int32(f.varint))
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if nanos < 0 || nanos >= int64(time.Second) {
+		return time.Time{}, errors.New("invalid timestamp nanos")
+	}
+	return time.Unix(seconds, nanos), nil
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+//ServiceName is full name of the gRPC service, methods are called at /selenosis.v1.Selenosis/{method}
+const ServiceName = "selenosis.v1.Selenosis"
+
+//maxMessageSize limits size of request message
+const maxMessageSize = 4 << 20
+
+//Code is gRPC status code
+type Code uint32
+
+const (
+	//OK ...
+	OK Code = 0
+	//Canceled ...
+	Canceled Code = 1
+	//Unknown ...
+	Unknown Code = 2
+	//InvalidArgument ...
+	InvalidArgument Code = 3
+	//DeadlineExceeded ...
+	DeadlineExceeded Code = 4
+	//NotFound ...
+	NotFound Code = 5
+	//PermissionDenied ...
+	PermissionDenied Code = 7
+	//ResourceExhausted ...
+	ResourceExhausted Code = 8
+	//Unimplemented ...
+	Unimplemented Code = 12
+	//Internal ...
+	Internal Code = 13
+	//Unavailable ...
+	Unavailable Code = 14
+	//Unauthenticated ...
+	Unauthenticated Code = 16
+)
+
+//Status is error returned to gRPC client with status code
+type Status struct {
+	Code    Code
+	Message string
+}
+
+func (s *Status) Error() string {
+	return fmt.Sprintf("rpc error: code = %d desc = %s", s.Code, s.Message)
+}
+
+//Errorf returns error with gRPC status code
+func Errorf(code Code, format string, args ...interface{}) error {
+	return &Status{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+//Service implements methods of selenosis.v1.Selenosis service, request r carries context and
+//metadata of the call
+type Service interface {
+	CreateSession(r *http.Request, req *CreateSessionRequest) (*CreateSessionResponse, error)
+	DeleteSession(r *http.Request, req *DeleteSessionRequest) (*DeleteSessionResponse, error)
+	ListSessions(r *http.Request, req *ListSessionsRequest) (*ListSessionsResponse, error)
+	WatchSessions(r *http.Request, req *WatchSessionsRequest, send func(*SessionEvent) error) error
+}
+
+//Server serves Service with gRPC protocol over HTTP/2, messages are not compressed
+type Server struct {
+	service Service
+}
+
+//NewServer ...
+func NewServer(service Service) *Server {
+	return &Server{service: service}
+}
+
+//IsGRPC reports if request is gRPC call
+func IsGRPC(r *http.Request) bool {
+	return r.Method == http.MethodPost && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+//ServeHTTP ...
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/grpc")
+	w.WriteHeader(http.StatusOK)
+
+	st := &Status{Code: OK}
+	if err := s.call(w, r); err != nil && !errors.As(err, &st) {
+		st = &Status{Code: Unknown, Message: err.Error()}
+	}
+	w.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(int(st.Code)))
+	if st.Message != "" {
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", encodeGrpcMessage(st.Message))
+	}
+}
+
+func (s *Server) call(w http.ResponseWriter, r *http.Request) error {
+	if encoding := r.Header.Get("Grpc-Encoding"); encoding != "" && encoding != "identity" {
+		return Errorf(Unimplemented, "message encoding %s is not supported", encoding)
+	}
+	method := strings.TrimPrefix(r.URL.Path, "/"+ServiceName+"/")
+	if method == r.URL.Path {
+		return Errorf(Unimplemented, "unknown service %s", strings.TrimPrefix(r.URL.Path, "/"))
+	}
+
+	data, err := ReadMessage(r.Body)
+	if err != nil {
+		return err
+	}
+
+	switch method {
+	case "CreateSession":
+		req := &CreateSessionRequest{}
+		if err := req.Unmarshal(data); err != nil {
+			return Errorf(Internal, "failed to parse request: %v", err)
+		}
+		resp, err := s.service.CreateSession(r, req)
+		if err != nil {
+			return err
+		}
+		return WriteMessage(w, resp.Marshal())
+	case "DeleteSession":
+		req := &DeleteSessionRequest{}
+		if err := req.Unmarshal(data); err != nil {
+			return Errorf(Internal, "failed to parse request: %v", err)
+		}
+		resp, err := s.service.DeleteSession(r, req)
+		if err != nil {
+			return err
+		}
+		return WriteMessage(w, resp.Marshal())
+	case "ListSessions":
+		req := &ListSessionsRequest{}
+		if err := req.Unmarshal(data); err != nil {
+			return Errorf(Internal, "failed to parse request: %v", err)
+		}
+		resp, err := s.service.ListSessions(r, req)
+		if err != nil {
+			return err
+		}
+		return WriteMessage(w, resp.Marshal())
+	case "WatchSessions":
+		req := &WatchSessionsRequest{}
+		if err := req.Unmarshal(data); err != nil {
+			return Errorf(Internal, "failed to parse request: %v", err)
+		}
+		return s.service.WatchSessions(r, req, func(event *SessionEvent) error {
+			return WriteMessage(w, event.Marshal())
+		})
+	}
+	return Errorf(Unimplemented, "unknown method %s", method)
+}
+
+//ReadMessage reads length prefixed message of gRPC stream
+func ReadMessage(r io.Reader) ([]byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, Errorf(Internal, "failed to read message: %v", err)
+	}
+	if header[0] != 0 {
+		return nil, Errorf(Unimplemented, "compressed messages are not supported")
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > maxMessageSize {
+		return nil, Errorf(ResourceExhausted, "message size %d exceeds %d", size, maxMessageSize)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, Errorf(Internal, "failed to read message: %v", err)
+	}
+	return data, nil
+}
+
+//WriteMessage writes length prefixed message to gRPC stream, message is flushed to client right away
+func WriteMessage(w io.Writer, data []byte) error {
+	var header [5]byte
+	binary.BigEndian.PutUint32(header[1:], uint32(len(data)))
+	if _, err := w.Write(append(header[:], data...)); err != nil {
+		return err
+	}
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+//encodeGrpcMessage percent encodes status message as gRPC requires for grpc-message trailer
+func encodeGrpcMessage(msg string) string {
+	var b strings.Builder
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if c >= ' ' && c <= '~' && c != '%' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+type serviceMock struct {
+	err    error
+	events []*SessionEvent
+}
+
+func (s *serviceMock) CreateSession(r *http.Request, req *CreateSessionRequest) (*CreateSessionResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &CreateSessionResponse{
+		Session:          &Session{SessionID: "sessionID", BrowserName: req.BrowserName, BrowserVersion: req.BrowserVersion},
+		CapabilitiesJSON: req.CapabilitiesJSON,
+	}, nil
+}
+
+func (s *serviceMock) DeleteSession(r *http.Request, req *DeleteSessionRequest) (*DeleteSessionResponse, error) {
+	return &DeleteSessionResponse{}, s.err
+}
+
+func (s *serviceMock) ListSessions(r *http.Request, req *ListSessionsRequest) (*ListSessionsResponse, error) {
+	return &ListSessionsResponse{}, s.err
+}
+
+func (s *serviceMock) WatchSessions(r *http.Request, req *WatchSessionsRequest, send func(*SessionEvent) error) error {
+	for _, event := range s.events {
+		if err := send(event); err != nil {
+			return err
+		}
+	}
+	return s.err
+}
+
+func call(t *testing.T, service Service, method string, msg []byte) (*http.Response, [][]byte) {
+	body := &bytes.Buffer{}
+	WriteMessage(body, msg)
+	req := httptest.NewRequest(http.MethodPost, "/"+ServiceName+"/"+method, body)
+	req.Header.Set("Content-Type", "application/grpc")
+
+	rec := httptest.NewRecorder()
+	NewServer(service).ServeHTTP(rec, req)
+
+	res := rec.Result()
+	var messages [][]byte
+	for {
+		data, err := ReadMessage(res.Body)
+		if err != nil {
+			break
+		}
+		messages = append(messages, data)
+	}
+	return res, messages
+}
+
+func TestMessages(t *testing.T) {
+	started := time.Unix(1600000000, 500)
+	tests := map[string]struct {
+		msg interface {
+			Marshal() []byte
+			Unmarshal([]byte) error
+		}
+		empty interface {
+			Marshal() []byte
+			Unmarshal([]byte) error
+		}
+	}{
+		"Verify create session request": {
+			msg:   &CreateSessionRequest{BrowserName: "chrome", BrowserVersion: "85.0", PlatformName: "linux", CapabilitiesJSON: `{"enableVNC":true}`},
+			empty: &CreateSessionRequest{},
+		},
+		"Verify session event": {
+			msg: &SessionEvent{Type: Deleted, Session: &Session{
+				SessionID:    "sessionID",
+				Status:       "Running",
+				Relay:        true,
+				Started:      started,
+				LastActivity: started.Add(time.Minute),
+				Commands:     12,
+				Labels:       map[string]string{"tenant": "team-a", "runId": "nightly"},
+			}},
+			empty: &SessionEvent{},
+		},
+		"Verify list sessions response": {
+			msg:   &ListSessionsResponse{Sessions: []*Session{{SessionID: "first"}, {SessionID: "second"}}},
+			empty: &ListSessionsResponse{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		err := test.empty.Unmarshal(test.msg.Marshal())
+		assert.NilError(t, err)
+		assert.DeepEqual(t, test.msg, test.empty)
+	}
+}
+
+func TestUnmarshalError(t *testing.T) {
+	err := (&Session{}).Unmarshal([]byte{0x0a, 0x05, 'a'})
+	assert.Assert(t, err != nil)
+}
+
+func TestServeHTTP(t *testing.T) {
+	tests := map[string]struct {
+		service  *serviceMock
+		method   string
+		msg      []byte
+		status   string
+		message  string
+		messages int
+	}{
+		"Verify unary call": {
+			service:  &serviceMock{},
+			method:   "CreateSession",
+			msg:      (&CreateSessionRequest{BrowserName: "chrome"}).Marshal(),
+			status:   "0",
+			messages: 1,
+		},
+		"Verify status of failed call": {
+			service: &serviceMock{err: Errorf(NotFound, "unknown session %s", "100%")},
+			method:  "DeleteSession",
+			msg:     (&DeleteSessionRequest{SessionID: "sessionID"}).Marshal(),
+			status:  "5",
+			message: "unknown session 100%25",
+		},
+		"Verify status of unexpected error": {
+			service: &serviceMock{err: errors.New("failed")},
+			method:  "ListSessions",
+			status:  "2",
+			message: "failed",
+		},
+		"Verify streaming call": {
+			service: &serviceMock{events: []*SessionEvent{
+				{Type: Added, Session: &Session{SessionID: "first"}},
+				{Type: Deleted, Session: &Session{SessionID: "first"}},
+			}},
+			method:   "WatchSessions",
+			status:   "0",
+			messages: 2,
+		},
+		"Verify unknown method": {
+			service: &serviceMock{},
+			method:  "Unknown",
+			status:  "12",
+			message: "unknown method Unknown",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		res, messages := call(t, test.service, test.method, test.msg)
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "application/grpc", res.Header.Get("Content-Type"))
+		assert.Equal(t, test.status, res.Trailer.Get("Grpc-Status"))
+		assert.Equal(t, test.message, res.Trailer.Get("Grpc-Message"))
+		assert.Equal(t, test.messages, len(messages))
+	}
+}
+
+func TestServeHTTPCreateSession(t *testing.T) {
+	_, messages := call(t, &serviceMock{}, "CreateSession", (&CreateSessionRequest{
+		BrowserName:      "chrome",
+		BrowserVersion:   "85.0",
+		CapabilitiesJSON: `{"enableVNC":true}`,
+	}).Marshal())
+	assert.Equal(t, 1, len(messages))
+
+	resp := &CreateSessionResponse{}
+	assert.NilError(t, resp.Unmarshal(messages[0]))
+	assert.Equal(t, "sessionID", resp.Session.SessionID)
+	assert.Equal(t, "chrome", resp.Session.BrowserName)
+	assert.Equal(t, "85.0", resp.Session.BrowserVersion)
+	assert.Equal(t, `{"enableVNC":true}`, resp.CapabilitiesJSON)
+}
+
+func TestIsGRPC(t *testing.T) {
+	tests := map[string]struct {
+		method      string
+		contentType string
+		grpc        bool
+	}{
+		"Verify gRPC call": {
+			method:      http.MethodPost,
+			contentType: "application/grpc",
+			grpc:        true,
+		},
+		"Verify gRPC call with codec": {
+			method:      http.MethodPost,
+			contentType: "application/grpc+proto",
+			grpc:        true,
+		},
+		"Verify WebDriver request": {
+			method:      http.MethodPost,
+			contentType: "application/json",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		req := httptest.NewRequest(test.method, "/", nil)
+		req.Header.Set("Content-Type", test.contentType)
+		assert.Equal(t, test.grpc, IsGRPC(req))
+	}
+}
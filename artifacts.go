@@ -0,0 +1,225 @@
+package selenosis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/alcounit/selenosis/artifacts"
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/tools"
+	"github.com/alcounit/selenosis/webhook"
+)
+
+var deleteArtifact = artifacts.Delete
+
+type artifactReport struct {
+	Tenant    string `json:"tenant"`
+	SessionID string `json:"sessionId"`
+	Kind      string `json:"kind"`
+	URL       string `json:"url"`
+	Size      int64  `json:"size"`
+}
+
+type artifactQuota struct {
+	Tenant    string `json:"tenant"`
+	UsedBytes int64  `json:"usedBytes"`
+	MaxBytes  int64  `json:"maxBytes,omitempty"`
+	Count     int    `json:"count"`
+}
+
+//HandleArtifact records artifact uploaded by session sidecar and rotates oldest artifacts of the tenant if configured
+func (app *App) HandleArtifact(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	logger := app.requestLogger(r, "")
+
+	var report artifactReport
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		logger.WithField("time_elapsed", tools.TimeElapsed(start)).Errorf("failed to parse request: %v", err)
+		tools.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if report.URL == "" || report.Size < 0 {
+		tools.JSONError(w, "artifact url and size are required", http.StatusBadRequest)
+		return
+	}
+
+	policy, ok := app.artifactsPolicy(report.Tenant)
+	if !ok {
+		tools.JSONError(w, fmt.Sprintf("unknown tenant %s", report.Tenant), http.StatusBadRequest)
+		return
+	}
+
+	err := app.client.Artifacts().Add(report.Tenant, platform.Artifact{
+		SessionID: report.SessionID,
+		Kind:      report.Kind,
+		URL:       report.URL,
+		Size:      report.Size,
+		Created:   time.Now(),
+	})
+	if err != nil {
+		logger.WithField("time_elapsed", tools.TimeElapsed(start)).Errorf("failed to record artifact: %v", err)
+		tools.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if policy.MaxBytes > 0 && policy.OverLimit == platform.RotateOverLimit {
+		if err := app.rotateArtifacts(r.Context(), report.Tenant, policy); err != nil {
+			logger.WithField("time_elapsed", tools.TimeElapsed(start)).Errorf("failed to rotate artifacts: %v", err)
+		}
+	}
+
+	logger.WithField("time_elapsed", tools.TimeElapsed(start)).Infof("artifact %s recorded, size: %d", report.URL, report.Size)
+	if report.Kind == "videos" {
+		event := webhook.Event{Type: webhook.VideoUploaded, SessionID: report.SessionID, Tenant: report.Tenant, URL: report.URL}
+		if service, ok := app.stats.Sessions().Get(report.SessionID); ok {
+			event = sessionNotification(webhook.VideoUploaded, service)
+			event.Tenant, event.URL = report.Tenant, report.URL
+		}
+		app.notifier.Notify(event)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(report)
+}
+
+//artifactsPolicy returns artifact settings of the tenant, empty name is used for sessions without tenant
+func (app *App) artifactsPolicy(name string) (platform.Artifacts, bool) {
+	if name == "" {
+		return app.defaultArtifacts(), true
+	}
+	if app.tenants == nil {
+		return platform.Artifacts{}, false
+	}
+	tenant, ok := app.tenants.Get(name)
+	if !ok {
+		return platform.Artifacts{}, false
+	}
+	return app.artifactsOf(tenant), true
+}
+
+//artifactQuota returns artifact storage usage of the tenant
+func (app *App) artifactQuota(name string) (artifactQuota, error) {
+	policy, _ := app.artifactsPolicy(name)
+	list, err := app.client.Artifacts().List(name)
+	if err != nil {
+		return artifactQuota{}, err
+	}
+	quota := artifactQuota{
+		Tenant:   name,
+		MaxBytes: policy.MaxBytes,
+		Count:    len(list),
+	}
+	for _, artifact := range list {
+		quota.UsedBytes += artifact.Size
+	}
+	return quota, nil
+}
+
+//artifactQuotas returns artifact storage usage of all tenants
+func (app *App) artifactQuotas() []artifactQuota {
+	names := []string{""}
+	if app.tenants != nil {
+		for _, tenant := range app.tenants.List() {
+			names = append(names, tenant.Name)
+		}
+	}
+
+	var quotas []artifactQuota
+	for _, name := range names {
+		quota, err := app.artifactQuota(name)
+		if err != nil {
+			app.logger.Warnf("failed to get artifacts of tenant %s: %v", name, err)
+			continue
+		}
+		if quota.Count == 0 && quota.MaxBytes == 0 {
+			continue
+		}
+		quotas = append(quotas, quota)
+	}
+	return quotas
+}
+
+//artifactsExceeded reports if new video recordings of the tenant should be rejected
+func (app *App) artifactsExceeded(name string, policy platform.Artifacts) (bool, error) {
+	if policy.MaxBytes <= 0 || policy.OverLimit == platform.RotateOverLimit {
+		return false, nil
+	}
+	quota, err := app.artifactQuota(name)
+	if err != nil {
+		return false, err
+	}
+	return quota.UsedBytes >= policy.MaxBytes, nil
+}
+
+//rotateArtifacts deletes oldest artifacts of the tenant until usage fits the cap, the newest artifact is always kept
+func (app *App) rotateArtifacts(ctx context.Context, tenant string, policy platform.Artifacts) error {
+	list, err := app.client.Artifacts().List(tenant)
+	if err != nil {
+		return err
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Created.Before(list[j].Created)
+	})
+
+	var used int64
+	for _, artifact := range list {
+		used += artifact.Size
+	}
+
+	var removed []string
+	deleter := app.artifactDeleter(tenant, policy)
+	for i := 0; i < len(list)-1 && used > policy.MaxBytes; i++ {
+		artifact := list[i]
+		if err := deleter.delete(ctx, artifact); err != nil {
+			app.logger.Warnf("failed to delete artifact %s: %v", artifact.URL, err)
+			continue
+		}
+		app.logger.Infof("artifact %s of tenant %q rotated", artifact.URL, tenant)
+		used -= artifact.Size
+		removed = append(removed, artifact.URL)
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+	return app.client.Artifacts().Remove(tenant, removed)
+}
+
+//artifactDeleter removes artifact objects from storage, credentials secret of each destination is read once
+type artifactDeleter struct {
+	app         *App
+	tenant      string
+	policy      platform.Artifacts
+	credentials map[string]map[string]string
+}
+
+func (app *App) artifactDeleter(tenant string, policy platform.Artifacts) *artifactDeleter {
+	return &artifactDeleter{
+		app:         app,
+		tenant:      tenant,
+		policy:      policy,
+		credentials: make(map[string]map[string]string),
+	}
+}
+
+func (d *artifactDeleter) delete(ctx context.Context, artifact platform.Artifact) error {
+	secret := ""
+	if dst, ok := d.policy.Destination(artifact.Kind); ok {
+		secret = dst.CredentialsSecret
+	}
+	if _, ok := d.credentials[secret]; !ok && secret != "" {
+		creds, err := d.app.client.Artifacts().Credentials(d.tenant, secret)
+		if err != nil {
+			return err
+		}
+		d.credentials[secret] = creds
+	}
+	return deleteArtifact(ctx, artifact.URL, d.credentials[secret])
+}
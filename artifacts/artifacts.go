@@ -0,0 +1,144 @@
+package artifacts
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+//Credential keys expected in object storage credentials secret
+const (
+	AccessKeyID     = "AWS_ACCESS_KEY_ID"
+	SecretAccessKey = "AWS_SECRET_ACCESS_KEY"
+	Region          = "AWS_REGION"
+	Endpoint        = "AWS_ENDPOINT"
+)
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+//Delete removes artifact object, supported schemes are s3://, gs:// (HMAC keys) and file://
+func Delete(ctx context.Context, rawURL string, credentials map[string]string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse artifact url: %v", err)
+	}
+
+	switch u.Scheme {
+	case "file":
+		if err := os.Remove(u.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove file: %v", err)
+		}
+		return nil
+	case "s3", "gs":
+		req, err := objectRequest(ctx, http.MethodDelete, u, credentials)
+		if err != nil {
+			return err
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= http.StatusMultipleChoices && resp.StatusCode != http.StatusNotFound {
+			body, _ := ioutil.ReadAll(resp.Body)
+			return fmt.Errorf("object storage responded with %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported artifact url scheme %s", u.Scheme)
+}
+
+//objectRequest returns path-style request to S3 compatible API signed with AWS signature version 4
+func objectRequest(ctx context.Context, method string, u *url.URL, credentials map[string]string) (*http.Request, error) {
+	if credentials[AccessKeyID] == "" || credentials[SecretAccessKey] == "" {
+		return nil, fmt.Errorf("object storage credentials are not set")
+	}
+
+	endpoint, region := "https://s3.amazonaws.com", "us-east-1"
+	if u.Scheme == "gs" {
+		endpoint, region = "https://storage.googleapis.com", "auto"
+	}
+	if v := credentials[Region]; v != "" {
+		region = v
+		if u.Scheme == "s3" {
+			endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+		}
+	}
+	if v := credentials[Endpoint]; v != "" {
+		endpoint = strings.TrimSuffix(v, "/")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint+"/"+u.Host+u.Path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	sign(req, credentials[AccessKeyID], credentials[SecretAccessKey], region, time.Now().UTC())
+	return req, nil
+}
+
+//sign adds AWS signature version 4 to request without body
+func sign(req *http.Request, accessKey, secretKey, region string, t time.Time) {
+	const service = "s3"
+	payloadHash := hash("")
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hash(canonicalRequest)}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKey, scope, signedHeaders, signature))
+}
+
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
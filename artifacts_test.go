@@ -0,0 +1,173 @@
+package selenosis
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"gotest.tools/assert"
+)
+
+func TestHandleArtifact(t *testing.T) {
+	now := time.Now()
+	tests := map[string]struct {
+		artifacts  platform.Artifacts
+		recorded   []platform.Artifact
+		reqBody    string
+		statusCode int
+		urls       []string
+		deleted    []string
+	}{
+		"Verify artifact recorded": {
+			reqBody:    `{"sessionId":"chrome-85-0-1","kind":"videos","url":"s3://bucket/1.mp4","size":10}`,
+			statusCode: http.StatusCreated,
+			urls:       []string{"s3://bucket/1.mp4"},
+		},
+		"Verify artifact of unknown tenant is rejected": {
+			reqBody:    `{"tenant":"unknown","url":"s3://bucket/1.mp4","size":10}`,
+			statusCode: http.StatusBadRequest,
+		},
+		"Verify artifact without url is rejected": {
+			reqBody:    `{"sessionId":"chrome-85-0-1","size":10}`,
+			statusCode: http.StatusBadRequest,
+		},
+		"Verify oldest artifacts rotated when cap exceeded": {
+			artifacts: platform.Artifacts{
+				ArtifactDestination: platform.ArtifactDestination{URL: "s3://bucket"},
+				MaxBytes:            25,
+				OverLimit:           platform.RotateOverLimit,
+			},
+			recorded: []platform.Artifact{
+				{URL: "s3://bucket/2.mp4", Size: 10, Created: now.Add(-time.Minute)},
+				{URL: "s3://bucket/1.mp4", Size: 10, Created: now.Add(-2 * time.Minute)},
+			},
+			reqBody:    `{"sessionId":"chrome-85-0-3","kind":"videos","url":"s3://bucket/3.mp4","size":10}`,
+			statusCode: http.StatusCreated,
+			urls:       []string{"s3://bucket/2.mp4", "s3://bucket/3.mp4"},
+			deleted:    []string{"s3://bucket/1.mp4"},
+		},
+		"Verify artifacts are kept when policy is reject": {
+			artifacts: platform.Artifacts{
+				MaxBytes: 15,
+			},
+			recorded: []platform.Artifact{
+				{URL: "s3://bucket/1.mp4", Size: 10, Created: now.Add(-time.Minute)},
+			},
+			reqBody:    `{"sessionId":"chrome-85-0-2","kind":"videos","url":"s3://bucket/2.mp4","size":10}`,
+			statusCode: http.StatusCreated,
+			urls:       []string{"s3://bucket/1.mp4", "s3://bucket/2.mp4"},
+		},
+	}
+
+	defer func(d func(context.Context, string, map[string]string) error) { deleteArtifact = d }(deleteArtifact)
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		var deleted []string
+		deleteArtifact = func(_ context.Context, url string, _ map[string]string) error {
+			deleted = append(deleted, url)
+			return nil
+		}
+
+		p := &PlatformMock{artifacts: map[string][]platform.Artifact{"": test.recorded}}
+		app := initApp(p)
+		app.artifacts = test.artifacts
+
+		req, err := http.NewRequest(http.MethodPost, "/artifacts", bytes.NewReader([]byte(test.reqBody)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		app.HandleArtifact(rr, req)
+
+		assert.Equal(t, test.statusCode, rr.Code)
+
+		var urls []string
+		for _, artifact := range p.artifacts[""] {
+			urls = append(urls, artifact.URL)
+		}
+		if test.statusCode == http.StatusCreated {
+			assert.DeepEqual(t, test.urls, urls)
+		}
+		assert.DeepEqual(t, test.deleted, deleted)
+	}
+}
+
+func TestNewSessionArtifactsQuota(t *testing.T) {
+	tests := map[string]struct {
+		artifacts  platform.Artifacts
+		reqBody    string
+		statusCode int
+		respBody   string
+	}{
+		"Verify video recording rejected when artifact storage cap is exceeded": {
+			artifacts:  platform.Artifacts{MaxBytes: 10},
+			reqBody:    `{"desiredCapabilities":{"browserName":"chrome","enableVideo":true}}`,
+			statusCode: http.StatusForbidden,
+			respBody:   `{"value":{"error":"session not created","message":"artifact storage quota exceeded, video recording is not allowed","stacktrace":""}}`,
+		},
+		"Verify video recording allowed when artifacts are rotated": {
+			artifacts:  platform.Artifacts{MaxBytes: 10, OverLimit: platform.RotateOverLimit},
+			reqBody:    `{"desiredCapabilities":{"browserName":"chrome","enableVideo":true}}`,
+			statusCode: http.StatusInternalServerError,
+		},
+		"Verify session without video allowed when artifact storage cap is exceeded": {
+			artifacts:  platform.Artifacts{MaxBytes: 10},
+			reqBody:    `{"desiredCapabilities":{"browserName":"chrome"}}`,
+			statusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		p := &PlatformMock{
+			err: errors.New("failed to create pod"),
+			artifacts: map[string][]platform.Artifact{
+				"": {{URL: "s3://bucket/1.mp4", Size: 20}},
+			},
+		}
+		app := initApp(p)
+		app.artifacts = test.artifacts
+
+		req, err := http.NewRequest(http.MethodPost, session, bytes.NewReader([]byte(test.reqBody)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		app.HandleSession(rr, req)
+
+		assert.Equal(t, test.statusCode, rr.Code)
+		if test.respBody != "" {
+			assert.Equal(t, test.respBody, string(bytes.TrimSpace(rr.Body.Bytes())))
+		}
+	}
+}
+
+func TestHandleQuotaArtifacts(t *testing.T) {
+	p := &PlatformMock{
+		artifacts: map[string][]platform.Artifact{
+			"": {{URL: "s3://bucket/1.mp4", Size: 20}, {URL: "s3://bucket/2.mp4", Size: 5}},
+		},
+	}
+	app := initApp(p)
+	app.artifacts = platform.Artifacts{MaxBytes: 100}
+
+	req, err := http.NewRequest(http.MethodGet, "/quota", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	app.HandleQuota(rr, req)
+
+	assert.Equal(t, `{"name":"test","totalLimit":10,"sessionLimit":0,"active":0,"pending":0,"artifacts":[{"tenant":"","usedBytes":25,"maxBytes":100,"count":2}]}`, string(bytes.TrimSpace(rr.Body.Bytes())))
+}
@@ -0,0 +1,31 @@
+#!/bin/sh
+# Downloads third party UI bundles embedded into selenosis binary. Versions are pinned in VERSION files,
+# checksums of downloaded files are recorded in SHA256SUMS on first download and verified afterwards,
+# so changed upstream content fails the build instead of being shipped.
+set -e
+
+cd "$(dirname "$0")"
+
+fetch() {
+	url=$1
+	file=$2
+	if [ ! -f "$file" ]; then
+		wget -q -O "$file" "$url"
+	fi
+}
+
+fetch "https://cdn.redoc.ly/redoc/v$(cat redoc/VERSION)/bundles/redoc.standalone.js" redoc/redoc.standalone.js
+
+# noVNC modules import each other and pako by relative paths, so core and vendor/pako are unpacked as is
+novnc=$(cat novnc/VERSION)
+if [ ! -f novnc/core/rfb.js ]; then
+	fetch "https://github.com/novnc/noVNC/archive/refs/tags/v$novnc.tar.gz" novnc.tar.gz
+	tar -xzf novnc.tar.gz -C novnc --strip-components=1 "noVNC-$novnc/core" "noVNC-$novnc/vendor/pako"
+	rm novnc.tar.gz
+fi
+
+if [ -f SHA256SUMS ]; then
+	sha256sum -c SHA256SUMS
+else
+	find . -type f -not -name VERSION -not -name SHA256SUMS -not -name '*.sh' -not -name '*.md' | sort | xargs sha256sum > SHA256SUMS
+fi
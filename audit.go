@@ -0,0 +1,46 @@
+package selenosis
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/alcounit/selenosis/audit"
+	"github.com/alcounit/selenosis/platform"
+)
+
+//sessionEvent returns audit event of the session, browser, tenant and run are taken from requested capabilities
+func sessionEvent(eventType audit.EventType, service platform.Service) audit.Event {
+	return audit.Event{
+		Type:      eventType,
+		SessionID: service.SessionID,
+		Tenant:    service.Labels["tenant"],
+		Browser:   service.Labels["browserName"],
+		Version:   service.Labels["browserVersion"],
+		RunID:     service.Labels["runId"],
+	}
+}
+
+//auditRequest logs event with user and address of the request
+func (app *App) auditRequest(r *http.Request, event audit.Event) {
+	event.User, _, _ = r.BasicAuth()
+	if principal, err := app.principal(r); err == nil && principal.Name != "" {
+		event.User = principal.Name
+	}
+	event.RemoteAddr = r.RemoteAddr
+	app.auditor.Log(event)
+}
+
+//auditSessionRequest logs event of the session stored in cache, only session id is logged for unknown sessions
+func (app *App) auditSessionRequest(r *http.Request, eventType audit.EventType, sessionID, message string) {
+	event := audit.Event{Type: eventType, SessionID: sessionID}
+	if service, ok := app.stats.Sessions().Get(sessionID); ok {
+		event = sessionEvent(eventType, service)
+	}
+	event.Message = message
+	app.auditRequest(r, event)
+}
+
+//isDeleteSession reports if request is WebDriver delete session command
+func isDeleteSession(r *http.Request, sessionID string) bool {
+	return r.Method == http.MethodDelete && strings.HasSuffix(strings.TrimSuffix(r.URL.Path, "/"), "/session/"+sessionID)
+}
@@ -0,0 +1,223 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/alcounit/selenosis/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+//EventType ...
+type EventType string
+
+const (
+	//SessionCreated is emitted when browser session is started
+	SessionCreated EventType = "session.created"
+	//SessionRejected is emitted when new session request is refused or browser failed to start
+	SessionRejected EventType = "session.rejected"
+	//SessionDeleteRequested is emitted when client or admin asks to delete session
+	SessionDeleteRequested EventType = "session.delete"
+	//SessionTerminated is emitted when browser pod of the session is gone
+	SessionTerminated EventType = "session.terminated"
+	//SessionDebugged is emitted when debug container is attached to browser of the session
+	SessionDebugged EventType = "session.debug"
+	//SessionExtended is emitted when idle countdown of the session is restarted on request
+	SessionExtended EventType = "session.extend"
+	//DataPurged is emitted when stored data of the tenant is deleted on request
+	DataPurged EventType = "data.purged"
+)
+
+//Event describes session activity shipped to audit sinks
+type Event struct {
+	Type       EventType `json:"type"`
+	Time       time.Time `json:"time"`
+	SessionID  string    `json:"sessionId,omitempty"`
+	Tenant     string    `json:"tenant,omitempty"`
+	User       string    `json:"user,omitempty"`
+	RemoteAddr string    `json:"remoteAddr,omitempty"`
+	Browser    string    `json:"browser,omitempty"`
+	Version    string    `json:"version,omitempty"`
+	RunID      string    `json:"runId,omitempty"`
+	Message    string    `json:"message,omitempty"`
+}
+
+//Sink delivers audit events to external system
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, event Event) error
+	Close() error
+}
+
+var (
+	queueSize    = 1024
+	sendTimeout  = 10 * time.Second
+	retryBackoff = time.Second
+	maxBackoff   = 30 * time.Second
+)
+
+const (
+	droppedRejected = "rejected"
+	droppedClosed   = "closed"
+)
+
+//PermanentError is failure which repeats on every attempt, e.g. event rejected by the sink, such event
+//is dropped without retries
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+//Permanent marks err as not worth retrying
+func Permanent(err error) error {
+	return &PermanentError{Err: err}
+}
+
+//Auditor ships events to sinks, every sink has own queue drained by single worker,
+//failed event is retried before next one is sent so events of a session are never reordered.
+//Unavailable sink applies backpressure: failed event is retried until it is sent and Log waits for room in
+//full queue. Only events rejected by the sink and events left when auditor is closed are dropped
+type Auditor struct {
+	logger  *log.Logger
+	workers []*worker
+	mu      sync.RWMutex
+	closed  bool
+	closing chan struct{}
+	once    sync.Once
+}
+
+//New returns auditor, events are dropped if no sinks set
+func New(logger *log.Logger, sinks ...Sink) *Auditor {
+	a := &Auditor{logger: logger, closing: make(chan struct{})}
+	for _, sink := range sinks {
+		ctx, cancel := context.WithCancel(context.Background())
+		w := &worker{
+			sink:   sink,
+			queue:  make(chan Event, queueSize),
+			done:   make(chan struct{}),
+			ctx:    ctx,
+			cancel: cancel,
+			logger: logger.WithField("component", "audit").WithField("sink", sink.Name()),
+		}
+		go w.run()
+		a.workers = append(a.workers, w)
+	}
+	return a
+}
+
+//Log queues event to every sink, when sink queue is full Log waits until the worker frees room in it or
+//auditor is closed
+func (a *Auditor) Log(event Event) {
+	if a == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for _, w := range a.workers {
+		if a.closed {
+			w.drop(droppedClosed)
+			continue
+		}
+		select {
+		case w.queue <- event:
+			continue
+		default:
+		}
+		w.logger.Warnf("queue is full, waiting to queue %s event of session %s", event.Type, event.SessionID)
+		select {
+		case w.queue <- event:
+		case <-a.closing:
+			w.drop(droppedClosed)
+		}
+	}
+}
+
+//Close flushes queued events and closes sinks, events not sent before context is done are dropped. Sink is
+//closed only after its worker is stopped
+func (a *Auditor) Close(ctx context.Context) {
+	if a == nil {
+		return
+	}
+	//unblock Log calls waiting for room in full queues before taking the lock they hold
+	a.once.Do(func() { close(a.closing) })
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return
+	}
+	a.closed = true
+	a.mu.Unlock()
+	for _, w := range a.workers {
+		close(w.queue)
+		select {
+		case <-w.done:
+		case <-ctx.Done():
+			w.logger.Warnf("%d events were not sent", len(w.queue))
+			w.cancel()
+			<-w.done
+		}
+		w.cancel()
+		if err := w.sink.Close(); err != nil {
+			w.logger.Errorf("failed to close sink: %v", err)
+		}
+	}
+}
+
+type worker struct {
+	sink   Sink
+	queue  chan Event
+	done   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	logger *log.Entry
+}
+
+func (w *worker) drop(reason string) {
+	metrics.AuditDropped.WithLabelValues(w.sink.Name(), reason).Inc()
+}
+
+func (w *worker) run() {
+	defer close(w.done)
+	for event := range w.queue {
+		if w.ctx.Err() != nil {
+			w.drop(droppedClosed)
+			continue
+		}
+		backoff := retryBackoff
+		for {
+			ctx, cancel := context.WithTimeout(w.ctx, sendTimeout)
+			err := w.sink.Send(ctx, event)
+			cancel()
+			if err == nil {
+				break
+			}
+			var permanent *PermanentError
+			if errors.As(err, &permanent) {
+				w.logger.Errorf("%s event of session %s rejected, event dropped: %v", event.Type, event.SessionID, err)
+				w.drop(droppedRejected)
+				break
+			}
+			w.logger.Errorf("failed to send %s event of session %s, retrying in %s: %v", event.Type, event.SessionID, backoff, err)
+			select {
+			case <-time.After(backoff):
+			case <-w.ctx.Done():
+			}
+			if w.ctx.Err() != nil {
+				w.logger.Errorf("auditor is closed, %s event of session %s dropped", event.Type, event.SessionID)
+				w.drop(droppedClosed)
+				break
+			}
+			if backoff *= 2; backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+	}
+}
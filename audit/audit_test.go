@@ -0,0 +1,331 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+)
+
+type sinkMock struct {
+	mu       sync.Mutex
+	failures int
+	failing  string
+	err      error
+	events   []string
+}
+
+func (s *sinkMock) Name() string {
+	return "mock"
+}
+
+func (s *sinkMock) Send(_ context.Context, event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("sink is not available")
+	}
+	if event.SessionID == s.failing {
+		return s.err
+	}
+	s.events = append(s.events, event.SessionID)
+	return nil
+}
+
+func (s *sinkMock) Close() error {
+	return nil
+}
+
+func TestAuditorOrdering(t *testing.T) {
+	defer func(backoff time.Duration) { retryBackoff = backoff }(retryBackoff)
+	retryBackoff = time.Millisecond
+
+	tests := map[string]struct {
+		failures int
+	}{
+		"Verify events are sent in order": {},
+		"Verify events are sent in order when sink fails": {
+			failures: 3,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		sink := &sinkMock{failures: test.failures}
+		auditor := New(logrus.New(), sink)
+
+		var expected []string
+		for i := 0; i < 10; i++ {
+			id := fmt.Sprintf("chrome-85-0-%d", i)
+			expected = append(expected, id)
+			auditor.Log(Event{Type: SessionCreated, SessionID: id})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		auditor.Close(ctx)
+		cancel()
+
+		assert.DeepEqual(t, expected, sink.events)
+
+		auditor.Log(Event{Type: SessionCreated, SessionID: "chrome-85-0-closed"})
+	}
+}
+
+func TestAuditorDropsRejectedEvent(t *testing.T) {
+	defer func(backoff, max time.Duration) { retryBackoff, maxBackoff = backoff, max }(retryBackoff, maxBackoff)
+	retryBackoff, maxBackoff = time.Millisecond, time.Millisecond
+
+	tests := map[string]struct {
+		failing  string
+		failures int
+		events   []string
+	}{
+		"Verify event rejected by sink is dropped": {
+			failing: "chrome-85-0-1",
+			events:  []string{"chrome-85-0-0", "chrome-85-0-2"},
+		},
+		"Verify failed event is retried until it is sent": {
+			failures: 20,
+			events:   []string{"chrome-85-0-0", "chrome-85-0-1", "chrome-85-0-2"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		sink := &sinkMock{failing: test.failing, err: Permanent(errors.New("rest proxy responded with 422: invalid payload")), failures: test.failures}
+		auditor := New(logrus.New(), sink)
+		dropped := testutil.ToFloat64(metrics.AuditDropped.WithLabelValues("mock", droppedRejected))
+		for i := 0; i < 3; i++ {
+			auditor.Log(Event{Type: SessionCreated, SessionID: fmt.Sprintf("chrome-85-0-%d", i)})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		auditor.Close(ctx)
+		cancel()
+
+		assert.DeepEqual(t, test.events, sink.events)
+		assert.Equal(t, float64(3-len(test.events)), testutil.ToFloat64(metrics.AuditDropped.WithLabelValues("mock", droppedRejected))-dropped)
+	}
+}
+
+type blockingSink struct {
+	sinkMock
+	sending chan struct{}
+	closed  chan struct{}
+	sent    bool
+}
+
+func (s *blockingSink) Send(ctx context.Context, _ Event) error {
+	s.sending <- struct{}{}
+	<-ctx.Done()
+	select {
+	case <-s.closed:
+		s.sent = true
+	default:
+	}
+	return ctx.Err()
+}
+
+func (s *blockingSink) Close() error {
+	close(s.closed)
+	return nil
+}
+
+func TestAuditorBackpressure(t *testing.T) {
+	defer func(size int) { queueSize = size }(queueSize)
+	queueSize = 1
+
+	sink := &blockingSink{sending: make(chan struct{}, 10), closed: make(chan struct{})}
+	auditor := New(logrus.New(), sink)
+	dropped := testutil.ToFloat64(metrics.AuditDropped.WithLabelValues("mock", droppedClosed))
+
+	auditor.Log(Event{Type: SessionCreated, SessionID: "chrome-85-0-0"})
+	<-sink.sending
+	auditor.Log(Event{Type: SessionCreated, SessionID: "chrome-85-0-1"})
+
+	logged := make(chan struct{})
+	go func() {
+		auditor.Log(Event{Type: SessionCreated, SessionID: "chrome-85-0-2"})
+		close(logged)
+	}()
+	select {
+	case <-logged:
+		t.Fatal("event is queued to full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	auditor.Close(ctx)
+	cancel()
+	<-logged
+
+	assert.Assert(t, !sink.sent, "sink is closed while event is sent")
+	assert.Equal(t, 3.0, testutil.ToFloat64(metrics.AuditDropped.WithLabelValues("mock", droppedClosed))-dropped)
+}
+
+func TestSyslog(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer l.Close()
+
+	received := make(chan string)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			size, err := r.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(size))
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(r, msg); err != nil {
+				return
+			}
+			received <- string(msg)
+		}
+	}()
+
+	sink, err := NewSyslog("tcp://"+l.Addr().String()+"?facility=10", "")
+	assert.NilError(t, err)
+	sink.hostname = "selenosis-0"
+	defer sink.Close()
+
+	tests := map[string]struct {
+		event  Event
+		prefix string
+	}{
+		"Verify created event is sent with notice severity": {
+			event:  Event{Type: SessionCreated, Time: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), SessionID: "chrome-85-0-1"},
+			prefix: "<85>1 2021-01-01T00:00:00.000000Z selenosis-0 selenosis ",
+		},
+		"Verify rejected event is sent with warning severity": {
+			event:  Event{Type: SessionRejected, Time: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Message: "invalid credentials"},
+			prefix: "<84>1 2021-01-01T00:00:00.000000Z selenosis-0 selenosis ",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		err := sink.Send(context.Background(), test.event)
+		assert.NilError(t, err)
+
+		msg := <-received
+		assert.Assert(t, strings.HasPrefix(msg, test.prefix), msg)
+
+		body, _ := json.Marshal(test.event)
+		assert.Assert(t, strings.HasSuffix(msg, fmt.Sprintf(" %s - %s", test.event.Type, body)), msg)
+	}
+}
+
+func TestNewSyslogErrors(t *testing.T) {
+	tests := map[string]struct {
+		url string
+		err string
+	}{
+		"Verify unsupported transport": {
+			url: "http://siem:514",
+			err: "unsupported syslog transport http",
+		},
+		"Verify invalid facility": {
+			url: "tcp://siem:514?facility=24",
+			err: "invalid syslog facility 24",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		_, err := NewSyslog(test.url, "")
+		assert.Error(t, err, test.err)
+	}
+}
+
+func TestKafka(t *testing.T) {
+	tests := map[string]struct {
+		status    int
+		response  string
+		err       string
+		permanent bool
+	}{
+		"Verify event is produced": {
+			response: `{"offsets":[{"partition":0,"offset":1}]}`,
+		},
+		"Verify record error is returned": {
+			response: `{"offsets":[{"partition":null,"offset":null,"error_code":50003,"error":"broker not available"}]}`,
+			err:      "record not produced: broker not available",
+		},
+		"Verify rejected record is permanent error": {
+			response:  `{"offsets":[{"partition":null,"offset":null,"error_code":42201,"error":"invalid record"}]}`,
+			err:       "record not produced: invalid record",
+			permanent: true,
+		},
+		"Verify unknown topic is permanent error": {
+			status:    http.StatusNotFound,
+			response:  `{"error_code":40401,"message":"Topic not found."}`,
+			err:       `rest proxy responded with 404: {"error_code":40401,"message":"Topic not found."}`,
+			permanent: true,
+		},
+		"Verify throttled request is retried": {
+			status:   http.StatusTooManyRequests,
+			response: `{"error_code":42901,"message":"Too many requests."}`,
+			err:      `rest proxy responded with 429: {"error_code":42901,"message":"Too many requests."}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		var path, contentType, body string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path, contentType = r.URL.Path, r.Header.Get("Content-Type")
+			b, _ := ioutil.ReadAll(r.Body)
+			body = string(b)
+			if test.status != 0 {
+				w.WriteHeader(test.status)
+			}
+			w.Write([]byte(test.response))
+		}))
+
+		sink, err := NewKafka(srv.URL+"/", "selenosis-audit")
+		assert.NilError(t, err)
+
+		err = sink.Send(context.Background(), Event{Type: SessionTerminated, Time: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), SessionID: "chrome-85-0-1"})
+		srv.Close()
+
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			var permanent *PermanentError
+			assert.Equal(t, test.permanent, errors.As(err, &permanent))
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, "/topics/selenosis-audit", path)
+		assert.Equal(t, "application/vnd.kafka.json.v2+json", contentType)
+		assert.Equal(t, `{"records":[{"key":"chrome-85-0-1","value":{"type":"session.terminated","time":"2021-01-01T00:00:00Z","sessionId":"chrome-85-0-1"}}]}`, body)
+	}
+}
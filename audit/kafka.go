@@ -0,0 +1,105 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+//Kafka produces events to Kafka topic through REST proxy (v2 API), session id is used as record key,
+//so events of a session land in the same partition and keep their order
+type Kafka struct {
+	url        string
+	httpClient *http.Client
+}
+
+//NewKafka returns sink producing to topic of REST proxy available at url
+func NewKafka(url, topic string) (*Kafka, error) {
+	if url == "" || topic == "" {
+		return nil, fmt.Errorf("kafka rest proxy url and topic are required")
+	}
+	return &Kafka{
+		url:        strings.TrimSuffix(url, "/") + "/topics/" + topic,
+		httpClient: http.DefaultClient,
+	}, nil
+}
+
+//Name ...
+func (k *Kafka) Name() string {
+	return "kafka"
+}
+
+type kafkaRecord struct {
+	Key   string `json:"key,omitempty"`
+	Value Event  `json:"value"`
+}
+
+//Send ...
+func (k *Kafka) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(struct {
+		Records []kafkaRecord `json:"records"`
+	}{
+		Records: []kafkaRecord{{Key: event.SessionID, Value: event}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := k.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		err := fmt.Errorf("rest proxy responded with %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+		if rejected(resp.StatusCode) {
+			return Permanent(err)
+		}
+		return err
+	}
+
+	var result struct {
+		Offsets []struct {
+			ErrorCode *int   `json:"error_code"`
+			Error     string `json:"error"`
+		} `json:"offsets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to read rest proxy response: %v", err)
+	}
+	for _, offset := range result.Offsets {
+		if offset.ErrorCode != nil {
+			err := fmt.Errorf("record not produced: %s", offset.Error)
+			if *offset.ErrorCode >= 40000 && *offset.ErrorCode < 50000 {
+				return Permanent(err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+//rejected reports if rest proxy refused the record itself, e.g. unknown topic or invalid payload, sending
+//it again fails the same way. Timeouts and throttling are retried
+func rejected(code int) bool {
+	return code >= http.StatusBadRequest && code < http.StatusInternalServerError &&
+		code != http.StatusRequestTimeout && code != http.StatusTooManyRequests
+}
+
+//Close ...
+func (k *Kafka) Close() error {
+	return nil
+}
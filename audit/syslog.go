@@ -0,0 +1,164 @@
+package audit
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+)
+
+const (
+	facilityLocal0  = 16
+	severityWarning = 4
+	severityNotice  = 5
+)
+
+//Syslog sends events as RFC5424 messages, tcp and tls transports use octet counting framing (RFC5425),
+//udp transport sends one message per datagram
+type Syslog struct {
+	network   string
+	address   string
+	facility  int
+	hostname  string
+	tlsConfig *tls.Config
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+//NewSyslog returns syslog sink for url like tls://siem:6514, tcp://siem:514 or udp://siem:514,
+//facility can be set with facility query parameter, local0 is used by default
+func NewSyslog(rawURL, caFile string) (*Syslog, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse syslog url: %v", err)
+	}
+
+	s := &Syslog{
+		network:  u.Scheme,
+		address:  u.Host,
+		facility: facilityLocal0,
+	}
+
+	switch u.Scheme {
+	case "tcp", "udp":
+	case "tls":
+		s.network = "tcp"
+		s.tlsConfig = &tls.Config{ServerName: u.Hostname()}
+		if caFile != "" {
+			ca, err := ioutil.ReadFile(caFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read syslog ca: %v", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(ca) {
+				return nil, fmt.Errorf("no certificates found in %s", caFile)
+			}
+			s.tlsConfig.RootCAs = pool
+		}
+	default:
+		return nil, fmt.Errorf("unsupported syslog transport %s", u.Scheme)
+	}
+
+	if v := u.Query().Get("facility"); v != "" {
+		facility, err := strconv.Atoi(v)
+		if err != nil || facility < 0 || facility > 23 {
+			return nil, fmt.Errorf("invalid syslog facility %s", v)
+		}
+		s.facility = facility
+	}
+
+	s.hostname, _ = os.Hostname()
+	if s.hostname == "" {
+		s.hostname = "-"
+	}
+	return s, nil
+}
+
+//Name ...
+func (s *Syslog) Name() string {
+	return "syslog"
+}
+
+//Send writes event to syslog connection, connection is reestablished on next call if write failed
+func (s *Syslog) Send(ctx context.Context, event Event) error {
+	msg, err := s.format(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		conn, err := s.dial(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to connect to syslog: %v", err)
+		}
+		s.conn = conn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		s.conn.SetWriteDeadline(deadline)
+	}
+
+	frame := msg
+	if s.network == "tcp" {
+		frame = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	}
+	if _, err := s.conn.Write(frame); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return fmt.Errorf("failed to write to syslog: %v", err)
+	}
+	return nil
+}
+
+//Close ...
+func (s *Syslog) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *Syslog) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{}
+	if s.tlsConfig != nil {
+		return (&tls.Dialer{NetDialer: dialer, Config: s.tlsConfig}).DialContext(ctx, s.network, s.address)
+	}
+	return dialer.DialContext(ctx, s.network, s.address)
+}
+
+//format returns RFC5424 message, event type is used as MSGID and JSON encoded event as MSG
+func (s *Syslog) format(event Event) ([]byte, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %v", err)
+	}
+
+	severity := severityNotice
+	if event.Type == SessionRejected {
+		severity = severityWarning
+	}
+
+	header := fmt.Sprintf("<%d>1 %s %s selenosis %d %s - ",
+		s.facility*8+severity,
+		event.Time.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		s.hostname,
+		os.Getpid(),
+		event.Type,
+	)
+	return append([]byte(header), body...), nil
+}
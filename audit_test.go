@@ -0,0 +1,112 @@
+package selenosis
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/audit"
+	"github.com/alcounit/selenosis/platform"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+)
+
+type auditSinkMock struct {
+	mu     sync.Mutex
+	events []audit.Event
+}
+
+func (s *auditSinkMock) Name() string {
+	return "mock"
+}
+
+func (s *auditSinkMock) Send(_ context.Context, event audit.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event.Time = time.Time{}
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *auditSinkMock) Close() error {
+	return nil
+}
+
+func TestAuditEvents(t *testing.T) {
+	tests := map[string]struct {
+		method  string
+		url     string
+		reqBody string
+		handler func(*App) http.HandlerFunc
+		event   audit.Event
+	}{
+		"Verify rejected session is audited": {
+			method:  http.MethodPost,
+			url:     session,
+			reqBody: `{"desiredCapabilities":{"browserName":"chrome","runId":"build-42"}}`,
+			handler: func(app *App) http.HandlerFunc { return app.HandleSession },
+			event: audit.Event{
+				Type:       audit.SessionRejected,
+				User:       "acme",
+				RemoteAddr: "192.0.2.1:1234",
+				Browser:    "chrome",
+				Version:    "68.0",
+				RunID:      "build-42",
+				Message:    "failed to start browser: failed to create pod",
+			},
+		},
+		"Verify session delete is audited": {
+			method: http.MethodDelete,
+			url:    "/wd/hub/session/chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491",
+			handler: func(app *App) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					app.stats.Sessions().Put("chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491", platform.Service{
+						SessionID: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491",
+						Labels:    map[string]string{"browserName": "chrome", "browserVersion": "85.0", "tenant": "contractors"},
+					})
+					r = mux.SetURLVars(r, map[string]string{"sessionId": "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491"})
+					app.HandleProxy(w, r)
+				}
+			},
+			event: audit.Event{
+				Type:       audit.SessionDeleteRequested,
+				SessionID:  "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491",
+				Tenant:     "contractors",
+				User:       "acme",
+				RemoteAddr: "192.0.2.1:1234",
+				Browser:    "chrome",
+				Version:    "85.0",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		sink := &auditSinkMock{}
+		app := initApp(&PlatformMock{err: errors.New("failed to create pod")})
+		app.auditor = audit.New(logrus.New(), sink)
+
+		req, err := http.NewRequest(test.method, test.url, bytes.NewReader([]byte(test.reqBody)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.SetBasicAuth("acme", "secret")
+		req.RemoteAddr = "192.0.2.1:1234"
+
+		rr := httptest.NewRecorder()
+		test.handler(app)(rr, req)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		app.auditor.Close(ctx)
+		cancel()
+
+		assert.DeepEqual(t, []audit.Event{test.event}, sink.events)
+	}
+}
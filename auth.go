@@ -0,0 +1,102 @@
+package selenosis
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/alcounit/selenosis/auth"
+	"github.com/alcounit/selenosis/tools"
+	"github.com/gorilla/mux"
+)
+
+//ownerLabel keeps name of principal created the session
+const ownerLabel = "owner"
+
+//principal returns authenticated caller of the API, anonymous principal is returned when authentication is
+//disabled. Users of tenants authenticate with their tenant credentials as well
+func (app *App) principal(r *http.Request) (auth.Principal, error) {
+	if app.auth == nil {
+		return auth.Principal{}, nil
+	}
+	if user, password, ok := r.BasicAuth(); ok && app.tenants != nil {
+		if _, ok := app.tenants.Authenticate(user, password); ok {
+			return app.auth.Principal(user), nil
+		}
+	}
+	return app.auth.Authenticate(r)
+}
+
+//authorizeSession checks that caller may manage the session, sessions are managed by their owners and admins.
+//Status code of the response is returned with error
+func (app *App) authorizeSession(r *http.Request, sessionID string) (int, error) {
+	if app.auth == nil {
+		return http.StatusOK, nil
+	}
+	principal, err := app.principal(r)
+	if err != nil {
+		return http.StatusUnauthorized, err
+	}
+	if principal.Admin {
+		return http.StatusOK, nil
+	}
+	if service, ok := app.stats.Sessions().Get(sessionID); ok && service.Labels[ownerLabel] == principal.Name {
+		return http.StatusOK, nil
+	}
+	//archives of deleted sessions stay available to their owners
+	if har, ok := app.stats.HARs().Get(sessionID); ok && har.Owner != "" && har.Owner == principal.Name {
+		return http.StatusOK, nil
+	}
+	//stranded and lost sessions are retried by their owners
+	if session, ok := app.stats.Stranded().Get(sessionID); ok && session.Labels[ownerLabel] != "" && session.Labels[ownerLabel] == principal.Name {
+		return http.StatusOK, nil
+	}
+	return http.StatusForbidden, fmt.Errorf("session %s is not owned by %s", sessionID, principal.Name)
+}
+
+//SessionOwner passes requests of session owners and admins to next handler, session is taken from
+//sessionId path variable
+func (app *App) SessionOwner(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if code, err := app.authorizeSession(r, mux.Vars(r)["sessionId"]); err != nil {
+			authError(w, err, code)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+//Authenticated passes requests of any authenticated principal to next handler
+func (app *App) Authenticated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := app.principal(r); err != nil {
+			authError(w, err, http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+//AdminOnly passes requests of admins to next handler
+func (app *App) AdminOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if app.auth != nil {
+			principal, err := app.principal(r)
+			if err != nil {
+				authError(w, err, http.StatusUnauthorized)
+				return
+			}
+			if !principal.Admin {
+				authError(w, fmt.Errorf("%s is not admin", principal.Name), http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func authError(w http.ResponseWriter, err error, code int) {
+	if code == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="selenosis"`)
+	}
+	tools.JSONError(w, err.Error(), code)
+}
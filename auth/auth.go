@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+var (
+	//ErrNoCredentials is returned for requests without credentials
+	ErrNoCredentials = errors.New("credentials are not provided")
+	//ErrInvalidCredentials is returned for requests with unknown or expired credentials
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+//Principal is authenticated caller of the API
+type Principal struct {
+	Name  string
+	Admin bool
+}
+
+//Config describes credentials accepted by selenosis. Tokens map principal names to static bearer tokens,
+//users map user names to basic auth passwords, admins are names of principals allowed to manage any session
+type Config struct {
+	Tokens map[string]string `yaml:"tokens" json:"tokens"`
+	Users  map[string]string `yaml:"users" json:"users"`
+	Admins []string          `yaml:"admins" json:"admins"`
+	OIDC   *OIDCConfig       `yaml:"oidc,omitempty" json:"oidc,omitempty"`
+}
+
+//OIDCConfig describes identity provider issuing bearer tokens, principal name is taken from
+//username claim, sub by default
+type OIDCConfig struct {
+	Issuer        string `yaml:"issuer" json:"issuer"`
+	Audience      string `yaml:"audience" json:"audience"`
+	UsernameClaim string `yaml:"usernameClaim,omitempty" json:"usernameClaim,omitempty"`
+}
+
+//Load returns parsed auth config from JSON or YAML file
+func Load(configFile string) (Config, error) {
+	content, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read config: read error: %v", err)
+	}
+
+	var config Config
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), 1000)
+	if err := decoder.Decode(&config); err != nil {
+		return Config{}, fmt.Errorf("failed to read config: parse error: %v", err)
+	}
+	if err := config.Validate(); err != nil {
+		return Config{}, fmt.Errorf("failed to read config: %v", err)
+	}
+	return config, nil
+}
+
+//Validate ...
+func (c Config) Validate() error {
+	if len(c.Tokens) == 0 && len(c.Users) == 0 && c.OIDC == nil {
+		return errors.New("no tokens, users or oidc provider set")
+	}
+	for name, token := range c.Tokens {
+		if token == "" {
+			return fmt.Errorf("token of %s is empty", name)
+		}
+	}
+	for user, password := range c.Users {
+		if password == "" {
+			return fmt.Errorf("password of %s is empty", user)
+		}
+	}
+	if c.OIDC != nil && (c.OIDC.Issuer == "" || c.OIDC.Audience == "") {
+		return errors.New("oidc issuer and audience should be set")
+	}
+	return nil
+}
+
+//Authenticator authenticates requests with basic auth, static bearer tokens or bearer tokens of oidc provider
+type Authenticator struct {
+	configFile  string
+	lock        sync.RWMutex
+	credentials *credentials
+}
+
+//credentials are replaced as a whole on reload, so every request is authenticated against a single config
+type credentials struct {
+	config Config
+	admins map[string]bool
+	oidc   *verifier
+}
+
+func newCredentials(config Config) *credentials {
+	admins := make(map[string]bool)
+	for _, name := range config.Admins {
+		admins[name] = true
+	}
+	c := &credentials{config: config, admins: admins}
+	if config.OIDC != nil {
+		c.oidc = newVerifier(*config.OIDC)
+	}
+	return c
+}
+
+//New ...
+func New(config Config) *Authenticator {
+	return &Authenticator{credentials: newCredentials(config)}
+}
+
+//NewFromFile returns authenticator of JSON or YAML config file, config is reread on Reload
+func NewFromFile(configFile string) (*Authenticator, error) {
+	config, err := Load(configFile)
+	if err != nil {
+		return nil, err
+	}
+	a := New(config)
+	a.configFile = configFile
+	return a, nil
+}
+
+//Reload rereads config file, invalid config keeps current credentials in use
+func (a *Authenticator) Reload() error {
+	if a.configFile == "" {
+		return nil
+	}
+	config, err := Load(a.configFile)
+	if err != nil {
+		return err
+	}
+	credentials := newCredentials(config)
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.credentials = credentials
+	return nil
+}
+
+func (a *Authenticator) current() *credentials {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return a.credentials
+}
+
+//Principal returns principal of name authenticated by other means, e.g. by tenant credentials
+func (a *Authenticator) Principal(name string) Principal {
+	return a.current().principal(name)
+}
+
+func (c *credentials) principal(name string) Principal {
+	return Principal{Name: name, Admin: c.admins[name]}
+}
+
+//Authenticate returns principal of the request. Bearer token is read from Authorization header or from
+//access_token query parameter, as browsers can't set headers of websocket requests
+func (a *Authenticator) Authenticate(r *http.Request) (Principal, error) {
+	c := a.current()
+	if user, password, ok := r.BasicAuth(); ok {
+		expected, ok := c.config.Users[user]
+		if !ok || subtle.ConstantTimeCompare([]byte(expected), []byte(password)) != 1 {
+			return Principal{}, ErrInvalidCredentials
+		}
+		return c.principal(user), nil
+	}
+
+	token := bearerToken(r)
+	if token == "" {
+		return Principal{}, ErrNoCredentials
+	}
+	for name, expected := range c.config.Tokens {
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(token)) == 1 {
+			return c.principal(name), nil
+		}
+	}
+	if c.oidc == nil {
+		return Principal{}, ErrInvalidCredentials
+	}
+	name, err := c.oidc.verify(token)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	return c.principal(name), nil
+}
+
+func bearerToken(r *http.Request) string {
+	const prefix = "bearer "
+	if header := r.Header.Get("Authorization"); len(header) > len(prefix) && strings.EqualFold(header[:len(prefix)], prefix) {
+		return strings.TrimSpace(header[len(prefix):])
+	}
+	return r.URL.Query().Get("access_token")
+}
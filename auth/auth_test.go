@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestAuthenticate(t *testing.T) {
+	authenticator := New(Config{
+		Tokens: map[string]string{"ci": "ci-token", "ops": "ops-token"},
+		Users:  map[string]string{"alice": "secret"},
+		Admins: []string{"ops"},
+	})
+
+	tests := map[string]struct {
+		request   func(r *http.Request)
+		principal Principal
+		err       error
+	}{
+		"Verify request without credentials is rejected": {
+			request: func(r *http.Request) {},
+			err:     ErrNoCredentials,
+		},
+		"Verify user is authenticated with basic auth": {
+			request:   func(r *http.Request) { r.SetBasicAuth("alice", "secret") },
+			principal: Principal{Name: "alice"},
+		},
+		"Verify user with wrong password is rejected": {
+			request: func(r *http.Request) { r.SetBasicAuth("alice", "wrong") },
+			err:     ErrInvalidCredentials,
+		},
+		"Verify static token is authenticated": {
+			request:   func(r *http.Request) { r.Header.Set("Authorization", "Bearer ci-token") },
+			principal: Principal{Name: "ci"},
+		},
+		"Verify admin token is authenticated": {
+			request:   func(r *http.Request) { r.Header.Set("Authorization", "bearer ops-token") },
+			principal: Principal{Name: "ops", Admin: true},
+		},
+		"Verify token is read from query": {
+			request: func(r *http.Request) {
+				q := r.URL.Query()
+				q.Set("access_token", "ci-token")
+				r.URL.RawQuery = q.Encode()
+			},
+			principal: Principal{Name: "ci"},
+		},
+		"Verify unknown token is rejected": {
+			request: func(r *http.Request) { r.Header.Set("Authorization", "Bearer unknown") },
+			err:     ErrInvalidCredentials,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		req := httptest.NewRequest(http.MethodGet, "/wd/hub/session", nil)
+		test.request(req)
+
+		principal, err := authenticator.Authenticate(req)
+		assert.Assert(t, errors.Is(err, test.err), "unexpected error %v", err)
+		assert.DeepEqual(t, test.principal, principal)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		err    string
+	}{
+		"Verify config is loaded": {
+			config: `
+tokens:
+  ci: ci-token
+users:
+  alice: secret
+admins:
+- alice
+oidc:
+  issuer: https://accounts.example.com
+  audience: selenosis
+`,
+		},
+		"Verify empty config is rejected": {
+			config: `admins: [alice]`,
+			err:    "failed to read config: no tokens, users or oidc provider set",
+		},
+		"Verify empty token is rejected": {
+			config: `{"tokens": {"ci": ""}}`,
+			err:    "failed to read config: token of ci is empty",
+		},
+		"Verify oidc provider without audience is rejected": {
+			config: `{"oidc": {"issuer": "https://accounts.example.com"}}`,
+			err:    "failed to read config: oidc issuer and audience should be set",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		tmp, err := ioutil.TempFile("", "auth")
+		assert.NilError(t, err)
+		defer os.Remove(tmp.Name())
+		tmp.WriteString(test.config)
+		tmp.Close()
+
+		config, err := Load(tmp.Name())
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, "ci-token", config.Tokens["ci"])
+		assert.Equal(t, "selenosis", config.OIDC.Audience)
+	}
+}
+
+func TestReload(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		token  string
+		err    string
+		name   string
+	}{
+		"Verify reloaded token is accepted": {
+			config: `{"tokens": {"qa": "qa-token"}}`,
+			token:  "qa-token",
+			name:   "qa",
+		},
+		"Verify removed token is rejected after reload": {
+			config: `{"tokens": {"qa": "qa-token"}}`,
+			token:  "ci-token",
+		},
+		"Verify invalid config keeps current tokens": {
+			config: `{"tokens": {"qa": ""}}`,
+			token:  "ci-token",
+			err:    "failed to read config: token of qa is empty",
+			name:   "ci",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		tmp, err := ioutil.TempFile("", "auth")
+		assert.NilError(t, err)
+		defer os.Remove(tmp.Name())
+		tmp.WriteString(`{"tokens": {"ci": "ci-token"}}`)
+		tmp.Close()
+
+		authenticator, err := NewFromFile(tmp.Name())
+		assert.NilError(t, err)
+
+		assert.NilError(t, ioutil.WriteFile(tmp.Name(), []byte(test.config), 0644))
+		err = authenticator.Reload()
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+		} else {
+			assert.NilError(t, err)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+test.token)
+		principal, err := authenticator.Authenticate(req)
+		if test.name == "" {
+			assert.Equal(t, ErrInvalidCredentials, err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, test.name, principal.Name)
+	}
+}
@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+//keysRefreshInterval limits how often keys of the provider are fetched when token is signed by unknown key
+var keysRefreshInterval = time.Minute
+
+//clockSkew is tolerated difference between clocks of selenosis and the provider
+var clockSkew = 30 * time.Second
+
+//verifier checks RS256 signed id tokens of oidc provider, signing keys are discovered from
+//openid configuration of the issuer and cached
+type verifier struct {
+	config  OIDCConfig
+	client  *http.Client
+	lock    sync.Mutex
+	keys    map[string]*rsa.PublicKey
+	fetched time.Time
+}
+
+func newVerifier(config OIDCConfig) *verifier {
+	if config.UsernameClaim == "" {
+		config.UsernameClaim = "sub"
+	}
+	return &verifier{config: config, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+//verify returns principal name of valid token
+func (v *verifier) verify(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return "", fmt.Errorf("malformed token header: %v", err)
+	}
+	if header.Alg != "RS256" {
+		return "", fmt.Errorf("unsupported token algorithm %s", header.Alg)
+	}
+
+	key, err := v.key(header.Kid)
+	if err != nil {
+		return "", err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("malformed token signature: %v", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return "", errors.New("invalid token signature")
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", fmt.Errorf("malformed token claims: %v", err)
+	}
+	if iss, _ := claims["iss"].(string); iss != v.config.Issuer {
+		return "", fmt.Errorf("unexpected token issuer %s", iss)
+	}
+	if !hasAudience(claims["aud"], v.config.Audience) {
+		return "", errors.New("token is issued for other audience")
+	}
+	now := time.Now()
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.After(time.Unix(int64(exp), 0).Add(clockSkew)) {
+		return "", errors.New("token is expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(clockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return "", errors.New("token is not valid yet")
+	}
+
+	name, _ := claims[v.config.UsernameClaim].(string)
+	if name == "" {
+		return "", fmt.Errorf("token has no %s claim", v.config.UsernameClaim)
+	}
+	return name, nil
+}
+
+//key returns signing key of the provider, keys are refetched when key id is unknown
+func (v *verifier) key(kid string) (*rsa.PublicKey, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(v.fetched) < keysRefreshInterval {
+		return nil, fmt.Errorf("unknown token key %s", kid)
+	}
+
+	keys, err := v.fetchKeys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get keys of oidc provider: %v", err)
+	}
+	v.keys, v.fetched = keys, time.Now()
+
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown token key %s", kid)
+}
+
+func (v *verifier) fetchKeys() (map[string]*rsa.PublicKey, error) {
+	var discovery struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := v.get(strings.TrimSuffix(v.config.Issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, err
+	}
+	if discovery.JWKSURI == "" {
+		return nil, errors.New("jwks_uri is not set")
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := v.get(discovery.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	return keys, nil
+}
+
+func (v *verifier) get(url string, value interface{}) error {
+	resp, err := v.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d of %s", resp.StatusCode, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(value)
+}
+
+func decodeSegment(segment string, value interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
+
+func hasAudience(aud interface{}, audience string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func signToken(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		assert.NilError(t, err)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	payload := encode(map[string]string{"alg": "RS256", "kid": kid}) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(payload))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	assert.NilError(t, err)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestOIDC(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NilError(t, err)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NilError(t, err)
+
+	var issuer string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": issuer, "jwks_uri": issuer + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "key",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+	issuer = s.URL
+
+	authenticator := New(Config{
+		OIDC:   &OIDCConfig{Issuer: issuer, Audience: "selenosis", UsernameClaim: "email"},
+		Admins: []string{"ops@example.com"},
+	})
+	claims := func(update map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss":   issuer,
+			"aud":   []string{"selenosis", "other"},
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"sub":   "1234",
+			"email": "alice@example.com",
+		}
+		for k, v := range update {
+			c[k] = v
+		}
+		return c
+	}
+
+	tests := map[string]struct {
+		token     string
+		principal Principal
+		err       string
+	}{
+		"Verify valid token is authenticated": {
+			token:     signToken(t, key, "key", claims(nil)),
+			principal: Principal{Name: "alice@example.com"},
+		},
+		"Verify admin is authenticated": {
+			token:     signToken(t, key, "key", claims(map[string]interface{}{"email": "ops@example.com", "aud": "selenosis"})),
+			principal: Principal{Name: "ops@example.com", Admin: true},
+		},
+		"Verify token of other audience is rejected": {
+			token: signToken(t, key, "key", claims(map[string]interface{}{"aud": "other"})),
+			err:   "invalid credentials: token is issued for other audience",
+		},
+		"Verify token of other issuer is rejected": {
+			token: signToken(t, key, "key", claims(map[string]interface{}{"iss": "https://other.example.com"})),
+			err:   "invalid credentials: unexpected token issuer https://other.example.com",
+		},
+		"Verify expired token is rejected": {
+			token: signToken(t, key, "key", claims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})),
+			err:   "invalid credentials: token is expired",
+		},
+		"Verify token signed by other key is rejected": {
+			token: signToken(t, other, "key", claims(nil)),
+			err:   "invalid credentials: invalid token signature",
+		},
+		"Verify token of unknown key is rejected": {
+			token: signToken(t, key, "unknown", claims(nil)),
+			err:   "invalid credentials: unknown token key unknown",
+		},
+		"Verify token without username claim is rejected": {
+			token: signToken(t, key, "key", claims(map[string]interface{}{"email": ""})),
+			err:   "invalid credentials: token has no email claim",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		req := httptest.NewRequest(http.MethodGet, "/wd/hub/session", nil)
+		req.Header.Set("Authorization", "Bearer "+test.token)
+
+		principal, err := authenticator.Authenticate(req)
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			assert.Assert(t, errors.Is(err, ErrInvalidCredentials))
+			continue
+		}
+		assert.NilError(t, err)
+		assert.DeepEqual(t, test.principal, principal)
+	}
+}
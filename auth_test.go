@@ -0,0 +1,323 @@
+package selenosis
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"testing"
+
+	"github.com/alcounit/selenosis/auth"
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/storage"
+	"github.com/gorilla/mux"
+	"gotest.tools/assert"
+)
+
+func initAuthApp(p *PlatformMock) *App {
+	app := initApp(p)
+	app.auth = auth.New(auth.Config{
+		Tokens: map[string]string{"ci": "ci-token", "qa": "qa-token", "ops": "ops-token"},
+		Admins: []string{"ops"},
+	})
+	return app
+}
+
+func TestNewSessionOwner(t *testing.T) {
+	tests := map[string]struct {
+		token    string
+		respCode int
+		owner    string
+	}{
+		"Verify session is not created without credentials": {
+			respCode: http.StatusUnauthorized,
+		},
+		"Verify session is not created with unknown token": {
+			token:    "unknown",
+			respCode: http.StatusUnauthorized,
+		},
+		"Verify owner of the session is recorded": {
+			token:    "ci-token",
+			respCode: http.StatusOK,
+			owner:    "ci",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/wd/hub/session", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"value":{"sessionId":"sessionID","capabilities":{}}}`))
+		})
+		s := httptest.NewServer(mux)
+		defer s.Close()
+
+		u, _ := url.Parse(s.URL)
+		p := &PlatformMock{service: platform.Service{SessionID: "sessionID", CancelFunc: func() {}, URL: u}}
+		app := initAuthApp(p)
+
+		req := httptest.NewRequest(http.MethodPost, session, bytes.NewReader([]byte(`{"desiredCapabilities":{"browserName":"chrome"}}`)))
+		if test.token != "" {
+			req.Header.Set("Authorization", "Bearer "+test.token)
+		}
+		rr := httptest.NewRecorder()
+		app.HandleSession(rr, req)
+
+		assert.Equal(t, test.respCode, rr.Code)
+		assert.Equal(t, test.owner, p.layout.Owner)
+	}
+}
+
+func TestSessionOwner(t *testing.T) {
+	tests := map[string]struct {
+		disabled bool
+		token    string
+		respCode int
+	}{
+		"Verify owner has access to session": {
+			token:    "ci-token",
+			respCode: http.StatusOK,
+		},
+		"Verify admin has access to session": {
+			token:    "ops-token",
+			respCode: http.StatusOK,
+		},
+		"Verify other principal has no access to session": {
+			token:    "qa-token",
+			respCode: http.StatusForbidden,
+		},
+		"Verify anonymous request is rejected": {
+			respCode: http.StatusUnauthorized,
+		},
+		"Verify session is accessible without authentication": {
+			disabled: true,
+			respCode: http.StatusOK,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initAuthApp(&PlatformMock{})
+		if test.disabled {
+			app.auth = nil
+		}
+		app.stats.Sessions().Put("sessionID", platform.Service{SessionID: "sessionID", Labels: map[string]string{ownerLabel: "ci"}})
+
+		req := httptest.NewRequest(http.MethodGet, "/logs/sessionID", nil)
+		req = mux.SetURLVars(req, map[string]string{"sessionId": "sessionID"})
+		if test.token != "" {
+			req.Header.Set("Authorization", "Bearer "+test.token)
+		}
+		rr := httptest.NewRecorder()
+		app.SessionOwner(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rr, req)
+
+		assert.Equal(t, test.respCode, rr.Code)
+	}
+}
+
+func TestCommandOfOtherOwner(t *testing.T) {
+	const sessionID = "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491"
+
+	tests := map[string]struct {
+		method string
+		path   string
+		body   string
+	}{
+		"Verify other principal can't delete session": {
+			method: http.MethodDelete,
+			path:   "/wd/hub/session/" + sessionID,
+		},
+		"Verify other principal can't take screenshot of session": {
+			method: http.MethodGet,
+			path:   "/wd/hub/session/" + sessionID + "/screenshot",
+		},
+		"Verify other principal can't execute script in session": {
+			method: http.MethodPost,
+			path:   "/wd/hub/session/" + sessionID + "/execute/sync",
+			body:   `{"script":"return document.cookie","args":[]}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		p := &PlatformMock{}
+		app := initAuthApp(p)
+		app.stats.Sessions().Put(sessionID, platform.Service{SessionID: sessionID, Labels: map[string]string{ownerLabel: "ci"}})
+
+		req := httptest.NewRequest(test.method, test.path, bytes.NewBufferString(test.body))
+		req = mux.SetURLVars(req, map[string]string{"sessionId": sessionID})
+		req.Header.Set("Authorization", "Bearer qa-token")
+		rr := httptest.NewRecorder()
+		app.HandleProxy(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Equal(t, `{"value":{"error":"unknown error","message":"session `+sessionID+` is not owned by qa","stacktrace":""}}`, string(bytes.TrimSpace(rr.Body.Bytes())))
+		assert.Assert(t, p.deleted == nil)
+	}
+}
+
+func TestRetrySessionOfOtherOwner(t *testing.T) {
+	tests := map[string]struct {
+		token    string
+		respCode int
+	}{
+		"Verify owner can retry lost session": {
+			token:    "ci-token",
+			respCode: http.StatusOK,
+		},
+		"Verify other principal can't retry lost session": {
+			token:    "qa-token",
+			respCode: http.StatusForbidden,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initAuthApp(&PlatformMock{})
+		app.stats.Stranded().Put("sessionID", storage.StrandedSession{
+			Service: platform.Service{SessionID: "sessionID", Labels: map[string]string{ownerLabel: "ci"}},
+			Lost:    "Evicted",
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/sessions/sessionID/retry", nil)
+		req = mux.SetURLVars(req, map[string]string{"sessionId": "sessionID"})
+		req.Header.Set("Authorization", "Bearer "+test.token)
+		rr := httptest.NewRecorder()
+		app.SessionOwner(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rr, req)
+
+		assert.Equal(t, test.respCode, rr.Code)
+	}
+}
+
+func TestDeleteRunOfOtherOwner(t *testing.T) {
+	tests := map[string]struct {
+		token    string
+		respCode int
+		deleted  []string
+	}{
+		"Verify owner of all sessions deletes run": {
+			token:    "ci-token",
+			respCode: http.StatusOK,
+			deleted:  []string{"chrome-85-0-1", "chrome-85-0-2"},
+		},
+		"Verify run with session of other owner is not deleted": {
+			token:    "qa-token",
+			respCode: http.StatusForbidden,
+		},
+		"Verify admin deletes run": {
+			token:    "ops-token",
+			respCode: http.StatusOK,
+			deleted:  []string{"chrome-85-0-1", "chrome-85-0-2"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		client := &PlatformMock{}
+		app := initAuthApp(client)
+		for _, id := range []string{"chrome-85-0-1", "chrome-85-0-2"} {
+			app.stats.Sessions().Put(id, platform.Service{SessionID: id, Labels: map[string]string{"runId": "build-42", ownerLabel: "ci"}})
+		}
+
+		req := httptest.NewRequest(http.MethodDelete, "/runs/build-42", nil)
+		req = mux.SetURLVars(req, map[string]string{"runId": "build-42"})
+		req.Header.Set("Authorization", "Bearer "+test.token)
+		rr := httptest.NewRecorder()
+		app.HandleDeleteRun(rr, req)
+
+		assert.Equal(t, test.respCode, rr.Code)
+		sort.Strings(client.deleted)
+		assert.DeepEqual(t, test.deleted, client.deleted)
+	}
+}
+
+func TestAdminOnly(t *testing.T) {
+	tests := map[string]struct {
+		token    string
+		respCode int
+	}{
+		"Verify admin is allowed": {
+			token:    "ops-token",
+			respCode: http.StatusOK,
+		},
+		"Verify principal which is not admin is forbidden": {
+			token:    "ci-token",
+			respCode: http.StatusForbidden,
+		},
+		"Verify anonymous request is rejected": {
+			respCode: http.StatusUnauthorized,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initAuthApp(&PlatformMock{})
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/reload", nil)
+		if test.token != "" {
+			req.Header.Set("Authorization", "Bearer "+test.token)
+		}
+		rr := httptest.NewRecorder()
+		app.AdminOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rr, req)
+
+		assert.Equal(t, test.respCode, rr.Code)
+	}
+}
+
+func TestAuthenticated(t *testing.T) {
+	tests := map[string]struct {
+		path     string
+		token    string
+		respCode int
+	}{
+		"Verify principal which is not admin is allowed": {
+			token:    "qa-token",
+			respCode: http.StatusOK,
+		},
+		"Verify principal which is not admin is allowed to read OpenAPI specification": {
+			path:     "/openapi.json",
+			token:    "qa-token",
+			respCode: http.StatusOK,
+		},
+		"Verify anonymous request of OpenAPI specification is rejected": {
+			path:     "/openapi.json",
+			respCode: http.StatusUnauthorized,
+		},
+		"Verify request with unknown token is rejected": {
+			token:    "unknown",
+			respCode: http.StatusUnauthorized,
+		},
+		"Verify anonymous request is rejected": {
+			respCode: http.StatusUnauthorized,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initAuthApp(&PlatformMock{})
+
+		handler := http.HandlerFunc(app.HandleSessions)
+		if test.path == "/openapi.json" {
+			handler = app.HandleOpenAPI
+		} else {
+			test.path = "/sessions"
+		}
+
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+		if test.token != "" {
+			req.Header.Set("Authorization", "Bearer "+test.token)
+		}
+		rr := httptest.NewRecorder()
+		app.Authenticated(handler).ServeHTTP(rr, req)
+
+		assert.Equal(t, test.respCode, rr.Code)
+	}
+}
@@ -0,0 +1,27 @@
+package selenosis
+
+import (
+	"github.com/alcounit/selenosis/metrics"
+	"github.com/alcounit/selenosis/platform"
+)
+
+//AutoscalingCapacity returns capacity of the replica reported by autoscaling metrics, relayed sessions
+//are left out as they don't need cluster nodes
+func (app *App) AutoscalingCapacity() metrics.Capacity {
+	capacity := metrics.Capacity{
+		Slots:  app.sessionLimit,
+		Queued: app.queue.Len(),
+	}
+	for _, s := range app.stats.Sessions().List() {
+		if s.Relay {
+			continue
+		}
+		switch s.Status {
+		case platform.Running:
+			capacity.Used++
+		case platform.Pending:
+			capacity.Pending++
+		}
+	}
+	return capacity
+}
@@ -0,0 +1,31 @@
+package selenosis
+
+import (
+	"testing"
+
+	"github.com/alcounit/selenosis/metrics"
+	"github.com/alcounit/selenosis/platform"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestAutoscalingCapacity(t *testing.T) {
+	app := initApp(&PlatformMock{})
+	app.sessionLimit = 4
+	app.stats.Sessions().Put("chrome-85-0-running", platform.Service{SessionID: "chrome-85-0-running", Status: platform.Running})
+	app.stats.Sessions().Put("chrome-85-0-pending", platform.Service{SessionID: "chrome-85-0-pending", Status: platform.Pending})
+	app.stats.Sessions().Put("chrome-85-0-relayed", platform.Service{SessionID: "chrome-85-0-relayed", Status: platform.Running, Relay: true})
+	app.stats.Sessions().Put("chrome-85-0-failed", platform.Service{SessionID: "chrome-85-0-failed", Status: platform.Unknown})
+
+	assert.DeepEqual(t, metrics.Capacity{Slots: 4, Used: 1, Pending: 1}, app.AutoscalingCapacity())
+
+	metrics.SetCapacitySource(func() metrics.Capacity {
+		return metrics.Capacity{Slots: 4, Used: 3, Pending: 1, Queued: 2}
+	})
+	defer metrics.SetCapacitySource(func() metrics.Capacity { return metrics.Capacity{} })
+
+	assert.Equal(t, 3.0, testutil.ToFloat64(metrics.AutoscalingPendingSessions))
+	assert.Equal(t, 4.0, testutil.ToFloat64(metrics.AutoscalingUsedSlots))
+	assert.Equal(t, 4.0, testutil.ToFloat64(metrics.AutoscalingTotalSlots))
+	assert.Equal(t, 1.5, testutil.ToFloat64(metrics.AutoscalingSlotUtilization))
+}
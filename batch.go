@@ -0,0 +1,128 @@
+package selenosis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/alcounit/selenosis/selenium"
+	"github.com/alcounit/selenosis/tools"
+)
+
+//BatchSession is session created by batch request
+type BatchSession struct {
+	SessionID    string          `json:"sessionId"`
+	URL          string          `json:"url"`
+	Capabilities json.RawMessage `json:"capabilities,omitempty"`
+}
+
+//BatchFailure is session of batch request which failed to start
+type BatchFailure struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+	Status  int    `json:"status"`
+}
+
+//BatchResponse lists sessions created by batch request and failures of the rest
+type BatchResponse struct {
+	Created  int            `json:"created"`
+	Failed   int            `json:"failed"`
+	Sessions []BatchSession `json:"sessions"`
+	Failures []BatchFailure `json:"failures"`
+}
+
+//HandleBatchSession creates count sessions with capabilities of the request at once, every session is created by
+//new session handler, so authentication, quotas, queue and rate limits apply to each of them. At most batch
+//workers sessions are started concurrently, sessions which failed to start are reported next to created ones
+func (app *App) HandleBatchSession(w http.ResponseWriter, r *http.Request) {
+	logger := app.requestLogger(r, "")
+
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		tools.JSONError(w, fmt.Sprintf("failed to read request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	var request map[string]json.RawMessage
+	if err := json.Unmarshal(body, &request); err != nil {
+		tools.JSONError(w, fmt.Sprintf("failed to parse request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	var count int
+	if err := json.Unmarshal(request["count"], &count); err != nil || count < 1 || count > app.batchMaxSessions {
+		tools.JSONError(w, fmt.Sprintf("count should be between 1 and %d", app.batchMaxSessions), http.StatusBadRequest)
+		return
+	}
+	delete(request, "count")
+	body, _ = json.Marshal(request)
+
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	responses := make([]*handlerResponse, count)
+	workers := make(chan struct{}, app.batchWorkers)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			responses[i] = callHandler(r, http.MethodPost, "/wd/hub/session", body, nil, app.HandleSession)
+		}(i)
+	}
+	wg.Wait()
+
+	result := BatchResponse{Sessions: []BatchSession{}, Failures: []BatchFailure{}}
+	for _, resp := range responses {
+		sessionID, capabilities, err := newSessionResponse(resp.body.Bytes())
+		if resp.code >= http.StatusBadRequest || err != nil || sessionID == "" {
+			result.Failures = append(result.Failures, resp.failure())
+			continue
+		}
+		result.Sessions = append(result.Sessions, BatchSession{
+			SessionID:    sessionID,
+			URL:          fmt.Sprintf("%s://%s/wd/hub/session/%s", scheme, r.Host, sessionID),
+			Capabilities: capabilities,
+		})
+	}
+	result.Created, result.Failed = len(result.Sessions), len(result.Failures)
+	logger.Infof("batch sessions created: %d, failed: %d", result.Created, result.Failed)
+
+	code := http.StatusOK
+	if result.Created == 0 {
+		code = result.Failures[0].Status
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(result)
+}
+
+//failure returns WebDriver error of failed new session response
+func (h *handlerResponse) failure() BatchFailure {
+	var msg struct {
+		Value struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		} `json:"value"`
+	}
+	json.Unmarshal(h.body.Bytes(), &msg)
+	failure := BatchFailure{Error: msg.Value.Error, Message: msg.Value.Message, Status: h.code}
+	if failure.Status < http.StatusBadRequest {
+		failure.Status = http.StatusBadGateway
+		failure.Message = "failed to read browser response"
+	}
+	if failure.Error == "" {
+		failure.Error = selenium.ErrSessionNotCreated
+	}
+	if failure.Message == "" {
+		failure.Message = http.StatusText(failure.Status)
+	}
+	return failure
+}
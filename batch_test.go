@@ -0,0 +1,104 @@
+package selenosis
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alcounit/selenosis/platform"
+	"gotest.tools/assert"
+)
+
+func TestHandleBatchSession(t *testing.T) {
+	tests := map[string]struct {
+		body     string
+		code     int
+		created  int
+		failed   int
+		requests int32
+		message  string
+	}{
+		"Verify batch creates requested number of sessions": {
+			body:     `{"count": 3, "capabilities": {"alwaysMatch": {"browserName": "chrome", "browserVersion": "68.0"}}}`,
+			code:     http.StatusOK,
+			created:  3,
+			requests: 3,
+		},
+		"Verify batch reports failed sessions": {
+			body:   `{"count": 2, "capabilities": {"alwaysMatch": {"browserName": "unknown"}}}`,
+			code:   http.StatusInternalServerError,
+			failed: 2,
+		},
+		"Verify batch without count is rejected": {
+			body:    `{"capabilities": {"alwaysMatch": {"browserName": "chrome"}}}`,
+			code:    http.StatusBadRequest,
+			message: "count should be between 1 and 5",
+		},
+		"Verify batch above max sessions is rejected": {
+			body:    `{"count": 6, "capabilities": {"alwaysMatch": {"browserName": "chrome"}}}`,
+			code:    http.StatusBadRequest,
+			message: "count should be between 1 and 5",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		var requests int32
+		mux := http.NewServeMux()
+		mux.HandleFunc("/wd/hub/session", func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"value":{"sessionId":"sessionID","capabilities":{"browserName":"chrome"}}}`))
+		})
+		s := httptest.NewServer(mux)
+		defer s.Close()
+
+		u, _ := url.Parse(s.URL)
+		app := initApp(&PlatformMock{
+			service: platform.Service{
+				SessionID:  "sessionID",
+				CancelFunc: func() {},
+				URL:        u,
+			},
+		})
+		app.batchWorkers, app.batchMaxSessions = 2, 5
+
+		req := httptest.NewRequest(http.MethodPost, "/sessions/batch", bytes.NewBufferString(test.body))
+		req.Host = "selenosis:4444"
+		rec := httptest.NewRecorder()
+		app.HandleBatchSession(rec, req)
+
+		assert.Equal(t, test.code, rec.Code)
+		if test.message != "" {
+			var msg struct {
+				Value struct {
+					Message string `json:"message"`
+				} `json:"value"`
+			}
+			assert.NilError(t, json.Unmarshal(rec.Body.Bytes(), &msg))
+			assert.Equal(t, test.message, msg.Value.Message)
+			continue
+		}
+
+		var resp BatchResponse
+		assert.NilError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, test.created, resp.Created)
+		assert.Equal(t, test.failed, resp.Failed)
+		assert.Equal(t, test.created, len(resp.Sessions))
+		assert.Equal(t, test.failed, len(resp.Failures))
+		assert.Equal(t, test.requests, atomic.LoadInt32(&requests))
+		for _, session := range resp.Sessions {
+			assert.Equal(t, "sessionID", session.SessionID)
+			assert.Equal(t, "http://selenosis:4444/wd/hub/session/sessionID", session.URL)
+		}
+		for _, failure := range resp.Failures {
+			assert.Equal(t, http.StatusInternalServerError, failure.Status)
+			assert.Assert(t, failure.Message != "")
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package selenosis
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"time"
+
+	"github.com/alcounit/selenosis/selenium"
+	"github.com/alcounit/selenosis/tools"
+	"github.com/gorilla/mux"
+)
+
+//bidiPath is path of WebDriver BiDi websocket relative to session path
+const bidiPath = "/se/bidi"
+
+//HandleBiDi proxies WebDriver BiDi websocket of the session to browser pod, relayed sessions
+//are proxied to their WebDriver server
+func (app *App) HandleBiDi(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := mux.Vars(r)["sessionId"]
+	if !ok || !isValidSession(sessionID) {
+		app.logger.WithField("request", fmt.Sprintf("%s %s", r.Method, r.URL.Path)).Errorf("%s is not valid session id", sessionID)
+		webDriverError(w, selenium.ErrInvalidSessionID, "session id not found", http.StatusNotFound)
+		return
+	}
+
+	logger := app.requestLogger(r, sessionID)
+
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		logger.Error("bidi request is not a websocket upgrade")
+		webDriverError(w, selenium.ErrInvalidArgument, "websocket upgrade required", http.StatusBadRequest)
+		return
+	}
+
+	app.recordActivity(sessionID, time.Now())
+
+	relayed, isRelayed := app.relayedSession(sessionID)
+	(&httputil.ReverseProxy{
+		Transport: transport,
+		Director: func(r *http.Request) {
+			r.URL.Scheme = "http"
+			r.Host = app.sessionHost(sessionID, app.sidecarPort)
+			r.URL.Host = r.Host
+			r.Header.Set("X-Forwarded-Selenosis", app.selenosisHost)
+			if isRelayed {
+				r.URL = relayURL(relayed, r.URL)
+				r.Host = r.URL.Host
+				r.Header.Del("Authorization")
+				if user := relayed.URL.User; user != nil {
+					password, _ := user.Password()
+					r.SetBasicAuth(user.Username(), password)
+				}
+			}
+			logger.Info("proxying bidi")
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logger.Errorf("bidi proxying error: %v", err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}).ServeHTTP(w, r)
+}
+
+//HandleDevTools proxies Chrome DevTools Protocol websocket of the session to browser pod, relayed
+//sessions have no pod to proxy CDP to
+func (app *App) HandleDevTools(w http.ResponseWriter, r *http.Request) {
+	sessionID := mux.Vars(r)["sessionId"]
+	if _, ok := app.relayedSession(sessionID); ok {
+		tools.JSONError(w, fmt.Sprintf("devtools is not available for relayed session %s", sessionID), http.StatusNotFound)
+		return
+	}
+	if isValidSession(sessionID) {
+		app.recordActivity(sessionID, time.Now())
+	}
+	app.HandleReverseProxy(w, r)
+}
+
+//rewriteWebSocketURL points webSocketUrl and se:cdp capabilities of new session response to selenosis,
+//so BiDi and CDP clients connect through selenosis instead of unreachable browser address
+func rewriteWebSocketURL(r *http.Request, msg map[string]interface{}) {
+	value, ok := msg["value"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	sessionID, ok := value["sessionId"].(string)
+	if !ok {
+		return
+	}
+	caps, ok := value["capabilities"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	scheme := "ws"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "wss"
+	}
+	if _, ok := caps["webSocketUrl"].(string); ok {
+		caps["webSocketUrl"] = fmt.Sprintf("%s://%s%s/%s%s", scheme, r.Host, strings.TrimSuffix(r.URL.Path, "/"), sessionID, bidiPath)
+	}
+	if _, ok := caps["se:cdp"].(string); ok {
+		caps["se:cdp"] = fmt.Sprintf("%s://%s/devtools/%s", scheme, r.Host, sessionID)
+	}
+}
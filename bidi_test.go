@@ -0,0 +1,136 @@
+package selenosis
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/gorilla/mux"
+	"golang.org/x/net/websocket"
+	"gotest.tools/assert"
+)
+
+func TestHandleBiDi(t *testing.T) {
+	const sessionID = "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491"
+
+	var path string
+	browser := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		path = ws.Request().URL.Path
+		var message string
+		websocket.Message.Receive(ws, &message)
+		websocket.Message.Send(ws, "echo: "+message)
+	}))
+	defer browser.Close()
+
+	u, _ := url.Parse(browser.URL)
+	_, port, _ := net.SplitHostPort(u.Host)
+
+	app := initApp(&PlatformMock{})
+	app.sidecarPort = port
+	app.stats.Sessions().Put(sessionID, platform.Service{SessionID: sessionID, URL: u, Status: platform.Running})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/wd/hub/session/{sessionId}/se/bidi", app.HandleBiDi).Methods(http.MethodGet)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	ws, err := websocket.Dial("ws"+srv.URL[len("http"):]+"/wd/hub/session/"+sessionID+"/se/bidi", "", srv.URL)
+	assert.NilError(t, err)
+	defer ws.Close()
+
+	assert.NilError(t, websocket.Message.Send(ws, `{"id":1,"method":"session.status","params":{}}`))
+	var reply string
+	assert.NilError(t, websocket.Message.Receive(ws, &reply))
+	assert.Equal(t, `echo: {"id":1,"method":"session.status","params":{}}`, reply)
+	assert.Equal(t, "/wd/hub/session/"+sessionID+"/se/bidi", path)
+
+	resp, err := http.Get(srv.URL + "/wd/hub/session/" + sessionID + "/se/bidi")
+	assert.NilError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestHandleDevToolsRelayed(t *testing.T) {
+	const sessionID = "safari-14-0-de44c3c4-1a35-412b-b526-f5da80214491"
+
+	app := initApp(&PlatformMock{})
+	u, _ := url.Parse("https://hub.example.com/wd/hub/session/0a4a5c4c")
+	app.stats.Sessions().Put(sessionID, platform.Service{SessionID: sessionID, URL: u, Relay: true})
+
+	router := mux.NewRouter()
+	router.PathPrefix("/devtools/{sessionId}").HandlerFunc(app.HandleDevTools)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/devtools/"+sessionID+"/browser", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, `{"code":404,"value":{"message":"devtools is not available for relayed session `+sessionID+`"}}`, strings.TrimSpace(rr.Body.String()))
+}
+
+func TestHandleDevToolsInvalidSession(t *testing.T) {
+	app := initApp(&PlatformMock{})
+
+	router := mux.NewRouter()
+	router.PathPrefix("/devtools/{sessionId}").HandlerFunc(app.HandleDevTools)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/devtools/session/browser", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, `{"value":{"error":"invalid session id","message":"session id not found","stacktrace":""}}`, strings.TrimSpace(rr.Body.String()))
+}
+
+func TestRewriteWebSocketURL(t *testing.T) {
+	tests := map[string]struct {
+		tls      bool
+		msg      map[string]interface{}
+		expected interface{}
+		cdp      interface{}
+	}{
+		"Verify webSocketUrl points to selenosis": {
+			msg: map[string]interface{}{"value": map[string]interface{}{
+				"sessionId":    "chrome-85-0-1",
+				"capabilities": map[string]interface{}{"webSocketUrl": "ws://localhost:4444/session/7d2b/se/bidi"},
+			}},
+			expected: "ws://selenosis:4444/wd/hub/session/chrome-85-0-1/se/bidi",
+		},
+		"Verify webSocketUrl of tls request": {
+			tls: true,
+			msg: map[string]interface{}{"value": map[string]interface{}{
+				"sessionId":    "chrome-85-0-1",
+				"capabilities": map[string]interface{}{"webSocketUrl": "ws://localhost:4444/session/7d2b/se/bidi"},
+			}},
+			expected: "wss://selenosis:4444/wd/hub/session/chrome-85-0-1/se/bidi",
+		},
+		"Verify se:cdp points to selenosis devtools": {
+			msg: map[string]interface{}{"value": map[string]interface{}{
+				"sessionId":    "chrome-85-0-1",
+				"capabilities": map[string]interface{}{"se:cdp": "ws://172.17.0.5:9222/devtools/browser/5c0d"},
+			}},
+			cdp: "ws://selenosis:4444/devtools/chrome-85-0-1",
+		},
+		"Verify response without webSocketUrl is not changed": {
+			msg: map[string]interface{}{"value": map[string]interface{}{
+				"sessionId":    "chrome-85-0-1",
+				"capabilities": map[string]interface{}{},
+			}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		r := httptest.NewRequest(http.MethodPost, "http://selenosis:4444/wd/hub/session", nil)
+		if test.tls {
+			r.TLS = &tls.ConnectionState{}
+		}
+		rewriteWebSocketURL(r, test.msg)
+
+		caps := test.msg["value"].(map[string]interface{})["capabilities"].(map[string]interface{})
+		assert.Equal(t, test.expected, caps["webSocketUrl"])
+		assert.Equal(t, test.cdp, caps["se:cdp"])
+	}
+}
@@ -0,0 +1,60 @@
+package selenosis
+
+import (
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+)
+
+//burstBrowser returns browser relayed to secondary backend when local capacity or queue wait crosses
+//burst thresholds, browsers already served by relay are never bursted
+func (app *App) burstBrowser(browser platform.BrowserSpec) (platform.BrowserSpec, bool) {
+	if app.burstEndpoint == "" || browser.Relay != "" {
+		return browser, false
+	}
+	if !app.overflowed() {
+		return browser, false
+	}
+
+	browser.Relay = app.burstEndpoint
+	browser.Cloud = nil
+	browser.Burst = true
+	return browser, true
+}
+
+//overflowed reports if backend is unhealthy, share of session limit used by local sessions reached
+//burst threshold or the oldest pending local session waits longer than burst queue wait
+func (app *App) overflowed() bool {
+	if app.backendUnhealthy() {
+		return true
+	}
+
+	var used int
+	var oldest time.Time
+	for _, s := range app.stats.Sessions().List() {
+		if s.Relay {
+			continue
+		}
+		switch s.Status {
+		case platform.Running:
+			used++
+		case platform.Pending:
+			used++
+			if oldest.IsZero() || s.Started.Before(oldest) {
+				oldest = s.Started
+			}
+		}
+	}
+
+	if app.burstThreshold > 0 && app.sessionLimit > 0 {
+		if float64(used)/float64(app.sessionLimit) >= app.burstThreshold {
+			return true
+		}
+	}
+	if app.burstQueueWait > 0 && !oldest.IsZero() {
+		if time.Since(oldest) > app.burstQueueWait {
+			return true
+		}
+	}
+	return false
+}
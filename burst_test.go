@@ -0,0 +1,81 @@
+package selenosis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"gotest.tools/assert"
+)
+
+func TestBurstBrowser(t *testing.T) {
+	tests := map[string]struct {
+		threshold float64
+		queueWait time.Duration
+		browser   platform.BrowserSpec
+		services  []platform.Service
+		burst     bool
+	}{
+		"Verify session is not bursted below threshold": {
+			threshold: 0.5,
+			browser:   platform.BrowserSpec{Image: "selenoid/vnc:chrome_85.0"},
+			services: []platform.Service{
+				{SessionID: "chrome-85-0-1", Status: platform.Running},
+			},
+		},
+		"Verify session is bursted when threshold reached": {
+			threshold: 0.5,
+			browser:   platform.BrowserSpec{Image: "selenoid/vnc:chrome_85.0"},
+			services: []platform.Service{
+				{SessionID: "chrome-85-0-1", Status: platform.Running},
+				{SessionID: "chrome-85-0-2", Status: platform.Pending, Started: time.Now()},
+			},
+			burst: true,
+		},
+		"Verify relayed sessions are not counted as local": {
+			threshold: 0.5,
+			browser:   platform.BrowserSpec{Image: "selenoid/vnc:chrome_85.0"},
+			services: []platform.Service{
+				{SessionID: "chrome-85-0-1", Status: platform.Running},
+				{SessionID: "chrome-85-0-2", Status: platform.Running, Relay: true, Burst: true},
+			},
+		},
+		"Verify session is bursted when queue wait exceeded": {
+			queueWait: time.Minute,
+			browser:   platform.BrowserSpec{Image: "selenoid/vnc:chrome_85.0"},
+			services: []platform.Service{
+				{SessionID: "chrome-85-0-1", Status: platform.Pending, Started: time.Now().Add(-2 * time.Minute)},
+			},
+			burst: true,
+		},
+		"Verify relay browser is not bursted": {
+			threshold: 0.1,
+			browser:   platform.BrowserSpec{Relay: "http://mac-mini-1.lab:4444"},
+			services: []platform.Service{
+				{SessionID: "chrome-85-0-1", Status: platform.Running},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		app.sessionLimit = 4
+		app.burstEndpoint = "http://selenosis.secondary:4444/wd/hub"
+		app.burstThreshold = test.threshold
+		app.burstQueueWait = test.queueWait
+		for _, s := range test.services {
+			app.stats.Sessions().Put(s.SessionID, s)
+		}
+
+		browser, burst := app.burstBrowser(test.browser)
+		assert.Equal(t, test.burst, burst)
+		if !test.burst {
+			assert.DeepEqual(t, test.browser, browser)
+			continue
+		}
+		assert.Equal(t, app.burstEndpoint, browser.Relay)
+		assert.Assert(t, browser.Burst)
+	}
+}
@@ -0,0 +1,106 @@
+package selenosis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/tools"
+)
+
+var capacityEventInterval = time.Second
+
+type browserCapacity struct {
+	Used    int `json:"used"`
+	Pending int `json:"pending"`
+	Free    int `json:"free"`
+}
+
+type capacityEvent struct {
+	Total    int                        `json:"total"`
+	Used     int                        `json:"used"`
+	Free     int                        `json:"free"`
+	Queued   int                        `json:"queued"`
+	Browsers map[string]browserCapacity `json:"browsers"`
+}
+
+func (app *App) capacity() capacityEvent {
+	event := capacityEvent{
+		Total:    app.sessionLimit,
+		Browsers: make(map[string]browserCapacity),
+	}
+	for name := range app.browsers.GetBrowserVersions() {
+		event.Browsers[name] = browserCapacity{}
+	}
+
+	for _, s := range app.stats.Sessions().List() {
+		name := s.Labels["browserName"]
+		browser := event.Browsers[name]
+		switch s.Status {
+		case platform.Running:
+			event.Used++
+			browser.Used++
+		case platform.Pending:
+			event.Queued++
+			browser.Pending++
+		default:
+			continue
+		}
+		if name != "" {
+			event.Browsers[name] = browser
+		}
+	}
+
+	event.Free = event.Total - event.Used - event.Queued
+	if event.Free < 0 {
+		event.Free = 0
+	}
+	for name, browser := range event.Browsers {
+		browser.Free = event.Free
+		event.Browsers[name] = browser
+	}
+	return event
+}
+
+//HandleCapacityEvents streams aggregated capacity as server-sent events, event is sent on every change
+func (app *App) HandleCapacityEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		tools.JSONError(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(capacityEventInterval)
+	defer ticker.Stop()
+
+	var last []byte
+	for {
+		data, err := json.Marshal(app.capacity())
+		if err != nil {
+			app.logger.Errorf("failed to marshal capacity event: %v", err)
+			return
+		}
+		if !bytes.Equal(data, last) {
+			if _, err := fmt.Fprintf(w, "event: capacity\ndata: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+			last = data
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package selenosis
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"gotest.tools/assert"
+)
+
+func TestCapacity(t *testing.T) {
+	tests := map[string]struct {
+		services []platform.Service
+		capacity capacityEvent
+	}{
+		"Verify capacity when no session running": {
+			capacity: capacityEvent{
+				Total: 5, Free: 5,
+				Browsers: map[string]browserCapacity{
+					"chrome":  {Free: 5},
+					"firefox": {Free: 5},
+					"opera":   {Free: 5},
+				},
+			},
+		},
+		"Verify capacity with running and pending sessions": {
+			services: []platform.Service{
+				{SessionID: "chrome-85-0-1", Status: platform.Running, Labels: map[string]string{"browserName": "chrome"}},
+				{SessionID: "chrome-85-0-2", Status: platform.Pending, Labels: map[string]string{"browserName": "chrome"}},
+				{SessionID: "firefox-80-0-1", Status: platform.Running, Labels: map[string]string{"browserName": "firefox"}},
+				{SessionID: "firefox-80-0-2", Status: platform.Unknown, Labels: map[string]string{"browserName": "firefox"}},
+			},
+			capacity: capacityEvent{
+				Total: 5, Used: 2, Free: 2, Queued: 1,
+				Browsers: map[string]browserCapacity{
+					"chrome":  {Used: 1, Pending: 1, Free: 2},
+					"firefox": {Used: 1, Free: 2},
+					"opera":   {Free: 2},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		app.sessionLimit = 5
+		for _, s := range test.services {
+			app.stats.Sessions().Put(s.SessionID, s)
+		}
+
+		assert.DeepEqual(t, test.capacity, app.capacity())
+	}
+}
+
+func TestHandleCapacityEvents(t *testing.T) {
+	capacityEventInterval = 10 * time.Millisecond
+
+	app := initApp(&PlatformMock{})
+	app.sessionLimit = 5
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/events/capacity", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		app.HandleCapacityEvents(rr, req)
+		close(done)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	app.stats.Sessions().Put("chrome-85-0-1", platform.Service{SessionID: "chrome-85-0-1", Status: platform.Running, Labels: map[string]string{"browserName": "chrome"}})
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+
+	assert.Equal(t, "text/event-stream", rr.Header().Get("Content-Type"))
+
+	events := strings.Split(strings.TrimSpace(rr.Body.String()), "\n\n")
+	assert.Equal(t, 2, len(events))
+	assert.Assert(t, strings.HasPrefix(events[0], "event: capacity\ndata: {\"total\":5,\"used\":0,\"free\":5,"))
+	assert.Assert(t, strings.HasPrefix(events[1], "event: capacity\ndata: {\"total\":5,\"used\":1,\"free\":4,"))
+}
@@ -0,0 +1,199 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+)
+
+//Session describes browser session returned by selenosis
+type Session struct {
+	SessionID    string            `json:"id"`
+	Labels       map[string]string `json:"labels"`
+	Status       string            `json:"status,omitempty"`
+	Started      time.Time         `json:"started"`
+	Uptime       string            `json:"uptime"`
+	LastActivity *time.Time        `json:"lastActivity,omitempty"`
+	IdleFor      string            `json:"idleFor,omitempty"`
+	Commands     int               `json:"commands,omitempty"`
+	Errors       int               `json:"commandErrors,omitempty"`
+	AvgLatency   string            `json:"avgCommandLatency,omitempty"`
+}
+
+//Status describes selenosis state
+type Status struct {
+	Total    int                 `json:"total"`
+	Active   int                 `json:"active"`
+	Pending  int                 `json:"pending"`
+	Browsers map[string][]string `json:"config,omitempty"`
+	Sessions []Session           `json:"sessions,omitempty"`
+}
+
+//StatusResponse is a response of the /status endpoint
+type StatusResponse struct {
+	Status    int    `json:"status"`
+	Version   string `json:"version"`
+	Error     string `json:"err,omitempty"`
+	Selenosis Status `json:"selenosis,omitempty"`
+}
+
+//Quota describes namespace quota and session usage
+type Quota struct {
+	Name            string           `json:"name"`
+	CurrentMaxLimit int64            `json:"totalLimit"`
+	SessionLimit    int              `json:"sessionLimit"`
+	Active          int              `json:"active"`
+	Pending         int              `json:"pending"`
+	Artifacts       []ArtifactsUsage `json:"artifacts,omitempty"`
+}
+
+//ArtifactsUsage describes artifact storage usage of the tenant, empty tenant stands for sessions without tenant
+type ArtifactsUsage struct {
+	Tenant    string `json:"tenant"`
+	UsedBytes int64  `json:"usedBytes"`
+	MaxBytes  int64  `json:"maxBytes,omitempty"`
+	Count     int    `json:"count"`
+}
+
+//Run describes sessions started with the same runId capability
+type Run struct {
+	RunID         string    `json:"id"`
+	Total         int       `json:"total"`
+	Running       int       `json:"running"`
+	Pending       int       `json:"pending"`
+	Commands      int       `json:"commands"`
+	CommandErrors int       `json:"commandErrors"`
+	Sessions      []Session `json:"sessions"`
+}
+
+//Error is returned when selenosis responds with unexpected status code
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("selenosis responded with %d: %s", e.StatusCode, e.Message)
+}
+
+//Client ...
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+}
+
+//New returns selenosis API client, http.DefaultClient is used if httpClient is nil
+func New(baseURL string, httpClient *http.Client) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse base url: %v", err)
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid base url: %s", baseURL)
+	}
+
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		baseURL:    u,
+		httpClient: httpClient,
+	}, nil
+}
+
+//Status ...
+func (c *Client) Status(ctx context.Context) (StatusResponse, error) {
+	var status StatusResponse
+	err := c.do(ctx, http.MethodGet, "/status", &status)
+	return status, err
+}
+
+//Sessions ...
+func (c *Client) Sessions(ctx context.Context) ([]Session, error) {
+	var sessions []Session
+	err := c.do(ctx, http.MethodGet, "/sessions", &sessions)
+	return sessions, err
+}
+
+//Quota ...
+func (c *Client) Quota(ctx context.Context) (Quota, error) {
+	var quota Quota
+	err := c.do(ctx, http.MethodGet, "/quota", &quota)
+	return quota, err
+}
+
+//DeleteSession ...
+func (c *Client) DeleteSession(ctx context.Context, sessionID string) error {
+	return c.do(ctx, http.MethodDelete, path.Join("/wd/hub/session", sessionID), nil)
+}
+
+//Run ...
+func (c *Client) Run(ctx context.Context, runID string) (Run, error) {
+	var run Run
+	err := c.do(ctx, http.MethodGet, path.Join("/runs", runID), &run)
+	return run, err
+}
+
+//DeleteRun deletes all sessions of the run
+func (c *Client) DeleteRun(ctx context.Context, runID string) error {
+	return c.do(ctx, http.MethodDelete, path.Join("/runs", runID), nil)
+}
+
+//Healthz ...
+func (c *Client) Healthz(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/healthz", nil)
+}
+
+func (c *Client) do(ctx context.Context, method, endpoint string, v interface{}) error {
+	u := *c.baseURL
+	u.Path = path.Join(u.Path, endpoint)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return newError(resp)
+	}
+
+	if v == nil {
+		io.Copy(ioutil.Discard, resp.Body)
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
+
+func newError(resp *http.Response) error {
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	var msg struct {
+		Value struct {
+			Message string `json:"message"`
+		} `json:"value"`
+	}
+	if err := json.Unmarshal(body, &msg); err == nil && msg.Value.Message != "" {
+		return &Error{StatusCode: resp.StatusCode, Message: msg.Value.Message}
+	}
+
+	return &Error{StatusCode: resp.StatusCode, Message: string(body)}
+}
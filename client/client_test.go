@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestNew(t *testing.T) {
+	tests := map[string]struct {
+		baseURL string
+		err     string
+	}{
+		"Verify client created with valid url": {
+			baseURL: "http://selenosis:4444",
+		},
+		"Verify client is not created without scheme": {
+			baseURL: "selenosis:4444",
+			err:     "invalid base url: selenosis:4444",
+		},
+		"Verify client is not created with invalid url": {
+			baseURL: "http://selenosis:port",
+			err:     `failed to parse base url: parse "http://selenosis:port": invalid port ":port" after host`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		_, err := New(test.baseURL, nil)
+		if test.err == "" {
+			assert.NilError(t, err)
+		} else {
+			assert.Error(t, err, test.err)
+		}
+	}
+}
+
+func TestEndpoints(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":200,"version":"v1.0.0","selenosis":{"total":10,"active":1,"pending":0,"config":{"chrome":["85.0"]},"sessions":[{"id":"chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491","labels":{"browserName":"chrome"},"started":"2021-01-01T00:00:00Z","uptime":"1.00s"}]}}`))
+	})
+	mux.HandleFunc("/sessions", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":"chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491","labels":null,"status":"Running","started":"2021-01-01T00:00:00Z","uptime":"1.00s"}]`))
+	})
+	mux.HandleFunc("/quota", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"selenosis-pod-limit","totalLimit":12,"sessionLimit":10,"active":1,"pending":1,"artifacts":[{"tenant":"","usedBytes":25,"maxBytes":100,"count":2}]}`))
+	})
+	mux.HandleFunc("/runs/build-42", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"build-42","total":2,"running":1,"pending":1,"commands":5,"commandErrors":0,"sessions":[]}`))
+	})
+	mux.HandleFunc("/wd/hub/session/", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":400,"value":{"message":"session id not found"}}`))
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	c, err := New(s.URL, nil)
+	assert.NilError(t, err)
+	ctx := context.Background()
+
+	status, err := c.Status(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, "v1.0.0", status.Version)
+	assert.Equal(t, 10, status.Selenosis.Total)
+	assert.Equal(t, 1, len(status.Selenosis.Sessions))
+
+	sessions, err := c.Sessions(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(sessions))
+	assert.Equal(t, "Running", sessions[0].Status)
+
+	quota, err := c.Quota(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, int64(12), quota.CurrentMaxLimit)
+	assert.Equal(t, 10, quota.SessionLimit)
+	assert.DeepEqual(t, []ArtifactsUsage{{UsedBytes: 25, MaxBytes: 100, Count: 2}}, quota.Artifacts)
+
+	run, err := c.Run(ctx, "build-42")
+	assert.NilError(t, err)
+	assert.Equal(t, 2, run.Total)
+	assert.Equal(t, 5, run.Commands)
+
+	err = c.DeleteSession(ctx, "unknown")
+	assert.Error(t, err, "selenosis responded with 400: session id not found")
+
+	err = c.Healthz(ctx)
+	assert.Error(t, err, "selenosis responded with 404: 404 page not found\n")
+}
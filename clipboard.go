@@ -0,0 +1,40 @@
+package selenosis
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"time"
+)
+
+//clipboardPort is port of clipboard server started in browser container of selenoid images, it reads and
+//sets X clipboard of the browser display
+var clipboardPort = "9090"
+
+//HandleClipboard proxies request to clipboard server of browser container, GET returns clipboard content
+//and POST replaces it with request body
+func (app *App) HandleClipboard(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := app.containerSession(w, r, "clipboard is not available for relayed session %s")
+	if !ok {
+		return
+	}
+	app.recordActivity(sessionID, time.Now())
+
+	logger := app.requestLogger(r, sessionID)
+
+	(&httputil.ReverseProxy{
+		Transport: transport,
+		Director: func(r *http.Request) {
+			r.URL.Scheme = "http"
+			r.Host = app.sessionHost(sessionID, clipboardPort)
+			r.URL.Host = r.Host
+			r.URL.Path = "/"
+			r.URL.RawPath = ""
+			r.Header.Set("X-Forwarded-Selenosis", app.selenosisHost)
+			logger.Info("proxying clipboard")
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logger.Errorf("clipboard proxying error: %v", err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}).ServeHTTP(w, r)
+}
@@ -0,0 +1,101 @@
+package selenosis
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/gorilla/mux"
+	"gotest.tools/assert"
+)
+
+func TestHandleClipboard(t *testing.T) {
+	const sessionID = "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491"
+	const relayID = "relay-de44c3c4-1a35-412b-b526-f5da80214491"
+
+	clipboard := "initial"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/", r.URL.Path)
+		if r.Method == http.MethodPost {
+			b, _ := ioutil.ReadAll(r.Body)
+			clipboard = string(b)
+			return
+		}
+		w.Write([]byte(clipboard))
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	defer func(port string) { clipboardPort = port }(clipboardPort)
+	clipboardPort = port
+
+	u, _ := url.Parse(server.URL)
+	app := initApp(&PlatformMock{})
+	app.stats.Sessions().Put(sessionID, platform.Service{SessionID: sessionID, URL: u, Status: platform.Running})
+	app.stats.Sessions().Put(relayID, platform.Service{SessionID: relayID, URL: u, Relay: true})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/clipboard/{sessionId}", app.HandleClipboard).Methods(http.MethodGet, http.MethodPost)
+
+	tests := []struct {
+		name     string
+		method   string
+		session  string
+		body     io.Reader
+		respCode int
+		respBody string
+	}{
+		{
+			name:     "Verify clipboard content is returned",
+			method:   http.MethodGet,
+			session:  sessionID,
+			respCode: http.StatusOK,
+			respBody: "initial",
+		},
+		{
+			name:     "Verify clipboard content is set",
+			method:   http.MethodPost,
+			session:  sessionID,
+			body:     strings.NewReader("copied text"),
+			respCode: http.StatusOK,
+		},
+		{
+			name:     "Verify set clipboard content is returned",
+			method:   http.MethodGet,
+			session:  sessionID,
+			respCode: http.StatusOK,
+			respBody: "copied text",
+		},
+		{
+			name:     "Verify invalid session id is rejected",
+			method:   http.MethodGet,
+			session:  "session",
+			respCode: http.StatusBadRequest,
+			respBody: `{"code":400,"value":{"message":"session id not found"}}`,
+		},
+		{
+			name:     "Verify clipboard of relayed session is not available",
+			method:   http.MethodGet,
+			session:  relayID,
+			respCode: http.StatusNotFound,
+			respBody: `{"code":404,"value":{"message":"clipboard is not available for relayed session ` + relayID + `"}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Logf("TC: %s", test.name)
+
+		req, err := http.NewRequest(test.method, "/clipboard/"+test.session, test.body)
+		assert.NilError(t, err)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, test.respCode, rr.Code)
+		assert.Equal(t, test.respBody, strings.TrimSpace(rr.Body.String()))
+	}
+}
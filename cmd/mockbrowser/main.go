@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/alcounit/selenosis/mockbrowser"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+//Command ...
+func command() *cobra.Command {
+
+	var (
+		address string
+		name    string
+		version string
+		latency time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "mockbrowser",
+		Short: "Lightweight WebDriver mock for selenosis load testing",
+		Run: func(cmd *cobra.Command, args []string) {
+			logger := logrus.New()
+			logger.Infof("starting mock browser %s %s on %s, latency: %s", name, version, address, latency)
+
+			if err := http.ListenAndServe(address, mockbrowser.New(name, version, latency)); err != nil {
+				logger.Fatalf("failed to start mock browser: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&address, "port", ":4444", "port for mock browser")
+	cmd.Flags().StringVar(&name, "browser-name", env("BROWSER_NAME", "chrome"), "browser name reported in session capabilities")
+	cmd.Flags().StringVar(&version, "browser-version", env("BROWSER_VERSION", "mock"), "browser version reported in session capabilities")
+	cmd.Flags().DurationVar(&latency, "latency", envDuration("LATENCY", 0), "delay before every response")
+	cmd.Flags().SortFlags = false
+
+	return cmd
+}
+
+//env returns value of environment variable, browser pods are configured with environment only
+func env(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return def
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return d
+	}
+	return def
+}
+
+func main() {
+	if err := command().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/alcounit/selenosis/loadtest"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+//benchCommand ...
+func benchCommand() *cobra.Command {
+
+	var (
+		cfg        loadtest.BenchConfig
+		jsonOutput bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Run session create/delete cycles at fixed rate and report startup phase percentiles",
+		Run: func(cmd *cobra.Command, args []string) {
+			logger := logrus.New()
+			cfg.URL = strings.TrimSuffix(cfg.URL, "/")
+
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer cancel()
+
+			logger.Infof("starting benchmark against %s, rate: %.2f sessions/s, duration: %s", cfg.URL, cfg.Rate, cfg.Duration)
+			report := loadtest.Bench(ctx, cfg)
+
+			if jsonOutput {
+				json.NewEncoder(os.Stdout).Encode(report)
+			} else {
+				fmt.Print(report)
+			}
+			if report.Failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&cfg.URL, "target", "http://localhost:4444", "selenosis url")
+	cmd.Flags().Float64Var(&cfg.Rate, "rate", 1, "number of session create/delete cycles started per second")
+	cmd.Flags().DurationVar(&cfg.Duration, "duration", time.Minute, "time new cycles are started for")
+	cmd.Flags().StringVar(&cfg.Browser, "browser-name", "chrome", "requested browser name")
+	cmd.Flags().StringVar(&cfg.Version, "browser-version", "", "requested browser version, default version is used if not set")
+	cmd.Flags().DurationVar(&cfg.Timeout, "timeout", 2*time.Minute, "request timeout")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "print report as JSON")
+	cmd.Flags().SortFlags = false
+
+	return cmd
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/alcounit/selenosis/loadtest"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+//loadtestCommand ...
+func loadtestCommand() *cobra.Command {
+
+	var (
+		cfg        loadtest.Config
+		jsonOutput bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "loadtest",
+		Short: "Create synthetic sessions against selenosis and report latency percentiles",
+		Run: func(cmd *cobra.Command, args []string) {
+			logger := logrus.New()
+			cfg.URL = strings.TrimSuffix(cfg.URL, "/")
+
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer cancel()
+
+			logger.Infof("starting load test against %s, sessions: %d, concurrency: %d", cfg.URL, cfg.Sessions, cfg.Concurrency)
+			report := loadtest.Run(ctx, cfg)
+
+			if jsonOutput {
+				json.NewEncoder(os.Stdout).Encode(report)
+			} else {
+				fmt.Print(report)
+			}
+			if report.Failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&cfg.URL, "target", "http://localhost:4444", "selenosis url")
+	cmd.Flags().IntVar(&cfg.Sessions, "sessions", 10, "number of sessions to create")
+	cmd.Flags().IntVar(&cfg.Concurrency, "concurrency", 5, "number of sessions running at once")
+	cmd.Flags().StringVar(&cfg.Browser, "browser-name", "chrome", "requested browser name")
+	cmd.Flags().StringVar(&cfg.Version, "browser-version", "", "requested browser version, default version is used if not set")
+	cmd.Flags().IntVar(&cfg.Commands, "commands", 10, "number of commands sent to every session")
+	cmd.Flags().DurationVar(&cfg.Timeout, "timeout", 2*time.Minute, "request timeout")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "print report as JSON")
+	cmd.Flags().SortFlags = false
+
+	return cmd
+}
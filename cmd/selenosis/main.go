@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -9,16 +11,28 @@ import (
 	"path/filepath"
 	"sync"
 	"syscall"
+	"text/template"
 	"time"
 
 	"github.com/alcounit/selenosis"
+	"github.com/alcounit/selenosis/api"
+	"github.com/alcounit/selenosis/audit"
+	"github.com/alcounit/selenosis/auth"
 	"github.com/alcounit/selenosis/config"
+	"github.com/alcounit/selenosis/leader"
+	"github.com/alcounit/selenosis/metrics"
+	"github.com/alcounit/selenosis/operator"
 	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/webhook"
 	"github.com/fsnotify/fsnotify"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"golang.org/x/net/websocket"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 var buildVersion = "HEAD"
@@ -28,18 +42,117 @@ func command() *cobra.Command {
 
 	var (
 		cfgFile             string
+		browsersConfigMap   string
+		browsersConfigKey   string
+		browserConfigs      bool
+		podPatchesFile      string
 		address             string
 		proxyPort           string
 		namespace           string
 		service             string
 		imagePullSecretName string
 		proxyImage          string
+		windowsProxyImage   string
+		videoImage          string
+		videoEncoding       platform.VideoEncoding
+		videoNameTemplate   string
+		videoOverlay        bool
+		videoUpload         bool
+		videoUploaderImage  string
+		warmupPauseImage    string
+		harImage            string
+		filesImage          string
+		harRetention        time.Duration
+		peers               string
+		debugImage          string
+		tlsCert             string
+		tlsKey              string
+		proxyTLSCert        string
+		proxyTLSKey         string
+		proxyTLSCA          string
+		proxyTLSServerName  string
+		proxyTLSSecret      string
+		proxyFailureLimit   int
+		proxyH2C            bool
+		logFormat           string
+		logLevel            string
+		proxyIdleConns      int
+		proxyIdleTimeout    time.Duration
+		proxyKeepAlive      time.Duration
+		proxyCPURequest     string
+		proxyMemoryRequest  string
+		proxyCPULimit       string
+		proxyMemoryLimit    string
 		sessionRetryCount   int
 		limit               int
 		browserWaitTimeout  time.Duration
+		pendingTimeout      time.Duration
 		sessionWaitTimeout  time.Duration
 		sessionIdleTimeout  time.Duration
+		maxSessionTimeout   time.Duration
 		shutdownTimeout     time.Duration
+		drainTimeout        time.Duration
+		queueSize           int
+		queueWait           time.Duration
+		warmPoolInterval    time.Duration
+		janitorInterval     time.Duration
+		orphanGracePeriod   time.Duration
+		idleReaperTimeout   time.Duration
+		maxExtensions       int
+		maxSessionLifetime  time.Duration
+		sessionJobs         bool
+		jobActiveDeadline   time.Duration
+		jobTTL              time.Duration
+		workspaceRetention  time.Duration
+		enableAPIDocs       bool
+		enableUI            bool
+		enableOperator      bool
+		enableRecords       bool
+		enableKubevirt      bool
+		enableGRPC          bool
+		leaderElection      bool
+		leaderLease         string
+		leaseDuration       time.Duration
+		statusFormat        string
+		ggrRegion           string
+		ggrHost             string
+		bannedImages        []string
+		sessionRateLimit    string
+		sessionRateLimits   []string
+		cpuCeiling          string
+		memoryCeiling       string
+		costCenterLabel     string
+		minCPU              string
+		maxCPU              string
+		minMemory           string
+		maxMemory           string
+		sessionHosts        []string
+		sessionNameservers  []string
+		batchWorkers        int
+		batchMaxSessions    int
+		webhookConfig       webhook.Config
+		tenantsFile         string
+		authFile            string
+		artifactsFile       string
+		quotasFile          string
+		artifacts           platform.Artifacts
+		auditSyslogURL      string
+		auditSyslogCA       string
+		auditKafkaURL       string
+		auditKafkaTopic     string
+		enableChaos         bool
+		chaos               platform.ChaosConfig
+		kubeAPIQPS          float32
+		kubeAPIBurst        int
+		soakInterval        time.Duration
+		soakWindow          int
+		soakAlertGrowth     float64
+		burstEndpoint       string
+		burstThreshold      float64
+		burstQueueWait      time.Duration
+		platformName        string
+		dockerHost          string
+		dockerNetwork       string
 	)
 
 	cmd := &cobra.Command{
@@ -47,7 +160,10 @@ func command() *cobra.Command {
 		Short: "Scallable, stateless selenium grid for Kubernetes cluster",
 		Run: func(cmd *cobra.Command, args []string) {
 
-			logger := logrus.New()
+			logger, err := selenosis.NewLogger(logFormat, logLevel)
+			if err != nil {
+				logrus.Fatalf("invalid logger settings: %v", err)
+			}
 			logger.Infof("starting selenosis %s", buildVersion)
 
 			browsers, err := config.NewBrowsersConfig(cfgFile)
@@ -57,28 +173,363 @@ func command() *cobra.Command {
 
 			logger.Info("browsers config file loaded")
 
+			if podPatchesFile != "" {
+				if err := browsers.LoadPodPatches(podPatchesFile); err != nil {
+					logger.Fatalf("invalid pod patches: %v", err)
+				}
+				logger.Infof("pod patches loaded from %s", podPatchesFile)
+			}
+
 			go runConfigWatcher(logger, cfgFile, browsers)
 
 			logger.Info("config watcher started")
 
-			client, err := platform.NewClient(platform.ClientConfig{
-				Namespace:           namespace,
-				Service:             service,
-				ReadinessTimeout:    browserWaitTimeout,
-				IdleTimeout:         sessionIdleTimeout,
-				ServicePort:         proxyPort,
-				ImagePullSecretName: imagePullSecretName,
-				ProxyImage:          proxyImage,
-			})
+			if browsersConfigMap != "" {
+				if platformName != platform.KubernetesPlatform {
+					logger.Fatalf("browsers configmap is supported by %s platform only", platform.KubernetesPlatform)
+				}
+				if browsersConfigKey == "" {
+					browsersConfigKey = filepath.Base(cfgFile)
+				}
+				configMapClient, err := config.NewConfigMapClient()
+				if err != nil {
+					logger.Fatalf("failed to create configmap client: %v", err)
+				}
+				go config.NewConfigMapWatcher(configMapClient, namespace, browsersConfigMap, browsersConfigKey, 30*time.Second).Run(make(chan struct{}), browsers, func(err error) {
+					if err != nil {
+						logger.Errorf("browsers config reload from configmap %s failed: %v", browsersConfigMap, err)
+						metrics.ConfigReloads.WithLabelValues("browsers", "failed").Inc()
+						return
+					}
+					logger.Infof("browsers config reloaded from configmap %s", browsersConfigMap)
+					metrics.ConfigReloads.WithLabelValues("browsers", "reloaded").Inc()
+				})
+
+				logger.Infof("configmap watcher started, configmap: %s, key: %s", browsersConfigMap, browsersConfigKey)
+			}
 
+			if browserConfigs {
+				if platformName != platform.KubernetesPlatform {
+					logger.Fatalf("browserconfig resources are supported by %s platform only", platform.KubernetesPlatform)
+				}
+				if browsersConfigMap != "" {
+					logger.Fatal("browsers configmap and browserconfig resources can't be used together")
+				}
+				browserConfigClient, err := config.NewBrowserConfigClient()
+				if err != nil {
+					logger.Fatalf("failed to create browserconfig client: %v", err)
+				}
+				go config.NewBrowserConfigWatcher(browserConfigClient, namespace, 30*time.Second).Run(make(chan struct{}), browsers, func(err error) {
+					if err != nil {
+						logger.Errorf("browsers config reload from browserconfig resources failed: %v", err)
+						metrics.ConfigReloads.WithLabelValues("browsers", "failed").Inc()
+						return
+					}
+					logger.Info("browsers config reloaded from browserconfig resources")
+					metrics.ConfigReloads.WithLabelValues("browsers", "reloaded").Inc()
+				})
+
+				logger.Infof("browserconfig watcher started, namespace: %s", namespace)
+			}
+
+			proxyResources, err := resourceRequirements(proxyCPURequest, proxyMemoryRequest, proxyCPULimit, proxyMemoryLimit)
 			if err != nil {
-				logger.Fatalf("failed to create kubernetes client: %v", err)
+				logger.Fatalf("invalid proxy resources: %v", err)
+			}
+
+			if err := videoEncoding.Validate(); err != nil {
+				logger.Fatalf("invalid video encoding settings: %v", err)
+			}
+
+			var videoName *template.Template
+			if videoNameTemplate != "" {
+				videoName, err = platform.ParseVideoName(videoNameTemplate)
+				if err != nil {
+					logger.Fatalf("failed to load video name template: %v", err)
+				}
+			}
+
+			var jobs *platform.SessionJobs
+			if sessionJobs {
+				if platformName != platform.KubernetesPlatform {
+					logger.Fatalf("session jobs are supported by %s platform only", platform.KubernetesPlatform)
+				}
+				jobs = &platform.SessionJobs{ActiveDeadline: jobActiveDeadline, TTLAfterFinished: jobTTL}
+				if jobs.ActiveDeadline == 0 {
+					jobs.ActiveDeadline = maxSessionLifetime
+				}
+				logger.Infof("session jobs enabled, active deadline: %s, ttl after finished: %s", jobs.ActiveDeadline, jobs.TTLAfterFinished)
+			}
+
+			var client platform.Platform
+			switch platformName {
+			case platform.KubernetesPlatform:
+				client, err = platform.NewClient(platform.ClientConfig{
+					Namespace:           namespace,
+					Service:             service,
+					ReadinessTimeout:    browserWaitTimeout,
+					PendingTimeout:      pendingTimeout,
+					IdleTimeout:         sessionIdleTimeout,
+					ServicePort:         proxyPort,
+					ImagePullSecretName: imagePullSecretName,
+					ProxyImage:          proxyImage,
+					WindowsProxyImage:   windowsProxyImage,
+					ProxyResources:      proxyResources,
+					VideoImage:          videoImage,
+					VideoEncoding:       videoEncoding,
+					VideoName:           videoName,
+					VideoOverlay:        videoOverlay,
+					VideoUpload:         videoUpload,
+					VideoUploaderImage:  videoUploaderImage,
+					WarmupPauseImage:    warmupPauseImage,
+					HARImage:            harImage,
+					FilesImage:          filesImage,
+					ProxyTLSSecret:      proxyTLSSecret,
+					SessionJobs:         jobs,
+					QPS:                 kubeAPIQPS,
+					Burst:               kubeAPIBurst,
+				})
+
+				if err != nil {
+					logger.Fatalf("failed to create kubernetes client: %v", err)
+				}
+
+				logger.Info("kubernetes client created")
+			case platform.DockerPlatform:
+				if tenantsFile != "" || enableOperator || enableRecords || proxyTLSCert != "" || enableKubevirt {
+					logger.Fatal("tenants, session operator, session records, proxy mTLS and kubevirt require kubernetes platform")
+				}
+				client, err = platform.NewDocker(platform.DockerConfig{
+					Host:             dockerHost,
+					Network:          dockerNetwork,
+					ServicePort:      proxyPort,
+					ProxyImage:       proxyImage,
+					ReadinessTimeout: browserWaitTimeout,
+					IdleTimeout:      sessionIdleTimeout,
+				})
+
+				if err != nil {
+					logger.Fatalf("failed to create docker client: %v", err)
+				}
+
+				logger.Infof("docker client created, host: %s", dockerHost)
+			default:
+				logger.Fatalf("unknown platform %s, supported: %s, %s", platformName, platform.KubernetesPlatform, platform.DockerPlatform)
+			}
+
+			if proxyH2C && cmd.Flags().Changed("proxy-idle-conns-per-pod") {
+				logger.Fatal("--proxy-idle-conns-per-pod can't be used with --proxy-h2c, commands of a session share one connection per pod")
+			}
+
+			var proxyTLS *tls.Config
+			if proxyTLSCert != "" {
+				if proxyTLSSecret == "" {
+					logger.Fatal("--proxy-tls-secret is required to enable proxy mTLS")
+				}
+				reloader, err := selenosis.NewCertReloader(proxyTLSCert, proxyTLSKey, proxyTLSCA)
+				if err != nil {
+					logger.Fatalf("failed to read proxy client certificate: %v", err)
+				}
+				go reloader.Run(make(chan struct{}), 10*time.Second, certReloaded(logger, "proxy client"))
+				proxyTLS = reloader.ClientConfig(proxyTLSServerName)
+
+				logger.Infof("proxy mTLS enabled, secret: %s", proxyTLSSecret)
 			}
 
-			logger.Info("kubernetes client created")
+			if err := artifacts.Validate(); err != nil {
+				logger.Fatalf("invalid artifacts settings: %v", err)
+			}
+
+			var artifactsConfig *config.ArtifactsConfig
+			if artifactsFile != "" {
+				artifactsConfig, err = config.NewArtifactsConfig(artifactsFile)
+				if err != nil {
+					logger.Fatalf("failed to read artifacts config: %v", err)
+				}
+			}
+
+			var authenticator *auth.Authenticator
+			if authFile != "" {
+				authenticator, err = auth.NewFromFile(authFile)
+				if err != nil {
+					logger.Fatalf("failed to read auth config: %v", err)
+				}
+			}
+
+			var tenants *config.TenantsConfig
+			if tenantsFile != "" {
+				tenants, err = config.NewTenantsConfig(tenantsFile)
+				if err != nil {
+					logger.Fatalf("failed to read tenants config: %v", err)
+				}
+
+				multi := platform.NewTenants(client)
+				for _, tenant := range tenants.List() {
+					tenantClient, err := platform.NewClient(platform.ClientConfig{
+						Namespace:           tenant.Namespace,
+						Service:             service,
+						ReadinessTimeout:    browserWaitTimeout,
+						PendingTimeout:      pendingTimeout,
+						IdleTimeout:         sessionIdleTimeout,
+						ServicePort:         proxyPort,
+						ImagePullSecretName: imagePullSecretName,
+						ProxyImage:          proxyImage,
+						WindowsProxyImage:   windowsProxyImage,
+						ProxyResources:      proxyResources,
+						VideoImage:          videoImage,
+						VideoEncoding:       videoEncoding,
+						VideoName:           videoName,
+						VideoOverlay:        videoOverlay,
+						VideoUpload:         videoUpload,
+						VideoUploaderImage:  videoUploaderImage,
+						HARImage:            harImage,
+						FilesImage:          filesImage,
+						ProxyTLSSecret:      proxyTLSSecret,
+						SessionJobs:         jobs,
+						NamespacedHosts:     true,
+						QPS:                 kubeAPIQPS,
+						Burst:               kubeAPIBurst,
+					})
+					if err != nil {
+						logger.Fatalf("failed to create kubernetes client for tenant %s: %v", tenant.Name, err)
+					}
+					if err := multi.Add(tenant.Name, tenantClient, int64(tenant.SessionLimit)); err != nil {
+						logger.Fatalf("failed to add tenant %s: %v", tenant.Name, err)
+					}
+					logger.Infof("tenant %s added, namespace: %s, session limit: %d", tenant.Name, tenant.Namespace, tenant.SessionLimit)
+				}
+				client = multi
+			}
+
+			var quotas *config.QuotasConfig
+			if quotasFile != "" {
+				quotas, err = config.NewQuotasConfig(quotasFile)
+				if err != nil {
+					logger.Fatalf("failed to read quotas config: %v", err)
+				}
+			}
+
+			var sinks []audit.Sink
+			if auditSyslogURL != "" {
+				sink, err := audit.NewSyslog(auditSyslogURL, auditSyslogCA)
+				if err != nil {
+					logger.Fatalf("failed to create syslog audit sink: %v", err)
+				}
+				sinks = append(sinks, sink)
+			}
+			if auditKafkaURL != "" {
+				sink, err := audit.NewKafka(auditKafkaURL, auditKafkaTopic)
+				if err != nil {
+					logger.Fatalf("failed to create kafka audit sink: %v", err)
+				}
+				sinks = append(sinks, sink)
+			}
+			var auditor *audit.Auditor
+			if len(sinks) > 0 {
+				auditor = audit.New(logger, sinks...)
+				logger.Infof("audit export enabled, sinks: %d", len(sinks))
+			}
+
+			if enableChaos {
+				if err := chaos.Validate(); err != nil {
+					logger.Fatalf("invalid chaos settings: %v", err)
+				}
+				client = platform.NewChaos(client, chaos)
+				logger.Warnf("chaos mode enabled, create error rate: %.2f, watch delay rate: %.2f, delete rate: %.2f", chaos.CreateErrorRate, chaos.WatchDelayRate, chaos.DeleteRate)
+			}
+			if enableKubevirt {
+				dynamic, err := operator.NewDynamicClient()
+				if err != nil {
+					logger.Fatalf("failed to create dynamic client: %v", err)
+				}
+				client = platform.NewVirtualMachines(client, dynamic, platform.VirtualMachinesConfig{
+					Namespace:        namespace,
+					Service:          service,
+					ServicePort:      proxyPort,
+					ReadinessTimeout: browserWaitTimeout,
+				})
+				logger.Info("kubevirt virtual machines enabled")
+			}
+			client = platform.NewRelay(client)
 
 			hostname, _ := os.Hostname()
 
+			if statusFormat != selenosis.SelenosisStatusFormat && statusFormat != selenosis.SelenoidStatusFormat {
+				logger.Fatalf("unknown status format %s, supported: %s, %s", statusFormat, selenosis.SelenosisStatusFormat, selenosis.SelenoidStatusFormat)
+			}
+
+			var rateLimit selenosis.RateLimit
+			if sessionRateLimit != "" {
+				if rateLimit, err = selenosis.ParseRateLimit(sessionRateLimit); err != nil {
+					logger.Fatalf("invalid session rate limit: %v", err)
+				}
+			}
+			rateLimits, err := selenosis.ParseRateLimits(sessionRateLimits)
+			if err != nil {
+				logger.Fatalf("invalid session rate limits: %v", err)
+			}
+
+			var hooks []selenosis.AdmissionHook
+			if len(bannedImages) > 0 {
+				hooks = append(hooks, selenosis.BannedImages(bannedImages))
+			}
+			if cpuCeiling != "" || memoryCeiling != "" {
+				ceiling, err := platform.ParseResourceList(cpuCeiling, memoryCeiling)
+				if err != nil {
+					logger.Fatalf("invalid resource ceiling: %v", err)
+				}
+				hooks = append(hooks, selenosis.ResourceCeiling(ceiling))
+			}
+			if costCenterLabel != "" {
+				hook, err := selenosis.CostCenterLabel(costCenterLabel)
+				if err != nil {
+					logger.Fatalf("invalid cost center label: %v", err)
+				}
+				hooks = append(hooks, hook)
+			}
+			for _, hook := range hooks {
+				logger.Infof("admission hook %s enabled", hook.Name())
+			}
+
+			var bounds platform.ResourceBounds
+			if bounds.Min, err = platform.ParseResourceList(minCPU, minMemory); err != nil {
+				logger.Fatalf("invalid session resources min: %v", err)
+			}
+			if bounds.Max, err = platform.ParseResourceList(maxCPU, maxMemory); err != nil {
+				logger.Fatalf("invalid session resources max: %v", err)
+			}
+			sessionDNS, err := platform.ParseSessionDNS(sessionHosts, sessionNameservers)
+			if err != nil {
+				logger.Fatalf("invalid session dns: %v", err)
+			}
+			if batchWorkers < 1 {
+				logger.Fatalf("invalid batch workers: %d", batchWorkers)
+			}
+
+			var elector selenosis.Leader
+			if leaderElection {
+				if platformName != platform.KubernetesPlatform {
+					logger.Fatalf("leader election is supported by %s platform only", platform.KubernetesPlatform)
+				}
+				leaderClient, err := leader.NewClient()
+				if err != nil {
+					logger.Fatalf("failed to create leader election client: %v", err)
+				}
+				e, err := leader.New(logger, leaderClient, leader.Config{
+					Namespace:     namespace,
+					Name:          leaderLease,
+					Identity:      hostname,
+					LeaseDuration: leaseDuration,
+				})
+				if err != nil {
+					logger.Fatalf("failed to start leader election: %v", err)
+				}
+				go e.Run(make(chan struct{}))
+				elector = e
+
+				logger.Infof("leader election started, lease: %s, identity: %s", leaderLease, hostname)
+			}
+
 			app := selenosis.New(logger, client, browsers, selenosis.Configuration{
 				SelenosisHost:      hostname,
 				ServiceName:        service,
@@ -87,20 +538,141 @@ func command() *cobra.Command {
 				SessionRetryCount:  sessionRetryCount,
 				BrowserWaitTimeout: browserWaitTimeout,
 				SessionIdleTimeout: sessionIdleTimeout,
+				MaxSessionTimeout:  maxSessionTimeout,
 				BuildVersion:       buildVersion,
+				JanitorInterval:    janitorInterval,
+				OrphanGracePeriod:  orphanGracePeriod,
+				IdleReaperTimeout:  idleReaperTimeout,
+				MaxExtensions:      maxExtensions,
+				MaxSessionLifetime: maxSessionLifetime,
+				Webhook:            webhookConfig,
+				Tenants:            tenants,
+				Auth:               authenticator,
+				Quotas:             quotas,
+				Artifacts:          artifacts,
+				ArtifactsConfig:    artifactsConfig,
+				Audit:              auditor,
+				SoakInterval:       soakInterval,
+				SoakWindow:         soakWindow,
+				SoakAlertGrowth:    soakAlertGrowth,
+				BurstEndpoint:      burstEndpoint,
+				BurstThreshold:     burstThreshold,
+				BurstQueueWait:     burstQueueWait,
+				WorkspaceRetention: workspaceRetention,
+				QueueSize:          queueSize,
+				QueueWait:          queueWait,
+				WarmPoolInterval:   warmPoolInterval,
+				Leader:             elector,
+				StatusFormat:       statusFormat,
+				GgrRegion:          ggrRegion,
+				GgrHost:            ggrHost,
+				AdmissionHooks:     hooks,
+				ResourceBounds:     bounds,
+				DebugImage:         debugImage,
+				ProxyFailureLimit:  proxyFailureLimit,
+				ProxyTransport: selenosis.ProxyTransport{
+					H2C:             proxyH2C,
+					IdleConnsPerPod: proxyIdleConns,
+					IdleConnTimeout: proxyIdleTimeout,
+					KeepAlive:       proxyKeepAlive,
+				},
+				ProxyTLS:          proxyTLS,
+				SessionRateLimit:  rateLimit,
+				SessionRateLimits: rateLimits,
+				HARRetention:      harRetention,
+				Peers:             peers,
+				SessionDNS:        sessionDNS,
+				BatchWorkers:      batchWorkers,
+				BatchMaxSessions:  batchMaxSessions,
 			})
+			metrics.SetCapacitySource(app.AutoscalingCapacity)
+
+			go app.RunJanitor(make(chan struct{}))
+			go app.RunSoakMonitor(make(chan struct{}))
+			go app.RunWarmPool(make(chan struct{}))
+
+			if enableOperator || enableRecords {
+				dynamic, err := operator.NewDynamicClient()
+				if err != nil {
+					logger.Fatalf("failed to create dynamic client: %v", err)
+				}
+				if enableOperator {
+					go operator.New(logger, client, browsers, dynamic, operator.Config{
+						Namespace:    namespace,
+						ResyncPeriod: 30 * time.Second,
+					}).Run(make(chan struct{}))
+
+					logger.Info("selenosis session operator started")
+				}
+				if enableRecords {
+					go operator.NewRegistry(logger, client, dynamic, operator.RegistryConfig{
+						Namespace: namespace,
+						Interval:  5 * time.Second,
+					}).Run(make(chan struct{}))
+
+					logger.Info("selenosis session records enabled")
+				}
+			}
 
 			router := mux.NewRouter()
+			if enableGRPC {
+				router.MatcherFunc(func(r *http.Request, _ *mux.RouteMatch) bool {
+					return api.IsGRPC(r)
+				}).Handler(app.GRPC())
+			}
 			router.HandleFunc("/wd/hub/session", app.HandleSession).Methods(http.MethodPost)
-			router.PathPrefix("/wd/hub/session/{sessionId}").HandlerFunc(app.HandleProxy)
+			router.Handle("/wd/hub/session/{sessionId}/se/bidi", app.SessionOwner(app.Inflight(http.HandlerFunc(app.HandleBiDi)))).Methods(http.MethodGet)
+			router.Handle("/wd/hub/session/{sessionId}/playwright", app.SessionOwner(app.Inflight(http.HandlerFunc(app.HandlePlaywright)))).Methods(http.MethodGet)
+			router.PathPrefix("/wd/hub/session/{sessionId}").Handler(app.Inflight(http.HandlerFunc(app.HandleProxy)))
 			router.HandleFunc("/wd/hub/status", app.HandleHubStatus).Methods(http.MethodGet)
-			router.PathPrefix("/vnc/{sessionId}").Handler(websocket.Handler(app.HandleVNC()))
-			router.PathPrefix("/logs/{sessionId}").Handler(websocket.Handler(app.HandleLogs()))
-			router.PathPrefix("/devtools/{sessionId}").HandlerFunc(app.HandleReverseProxy)
-			router.PathPrefix("/download/{sessionId}").HandlerFunc(app.HandleReverseProxy)
-			router.PathPrefix("/clipboard/{sessionId}").HandlerFunc(app.HandleReverseProxy)
-			router.PathPrefix("/status").HandlerFunc(app.HandleStatus)
+			router.HandleFunc("/se/grid/distributor/status", app.HandleGridDistributorStatus).Methods(http.MethodGet)
+			router.PathPrefix("/vnc/{sessionId}").Handler(app.SessionOwner(app.Inflight(http.HandlerFunc(app.HandleVNCWebSocket))))
+			router.PathPrefix("/logs/{sessionId}").Handler(app.SessionOwner(app.Inflight(websocket.Handler(app.HandleLogs()))))
+			router.PathPrefix("/devtools/{sessionId}").Handler(app.SessionOwner(app.Inflight(http.HandlerFunc(app.HandleDevTools))))
+			router.Handle("/download/{sessionId}/", app.SessionOwner(app.Inflight(http.HandlerFunc(app.HandleDownload)))).Methods(http.MethodGet)
+			router.Handle("/download/{sessionId}/{file}", app.SessionOwner(app.Inflight(http.HandlerFunc(app.HandleDownload)))).Methods(http.MethodGet, http.MethodDelete)
+			router.Handle("/download/{sessionId}/{file}", app.SessionOwner(app.Inflight(http.HandlerFunc(app.HandleUpload)))).Methods(http.MethodPost)
+			router.Handle("/clipboard/{sessionId}", app.SessionOwner(app.Inflight(http.HandlerFunc(app.HandleClipboard)))).Methods(http.MethodGet, http.MethodPost)
+			router.Handle("/video/{sessionId}/stream", app.SessionOwner(app.Inflight(http.HandlerFunc(app.HandleVideoStream)))).Methods(http.MethodGet)
+			router.Handle("/video/{sessionId}/stream/{file}", app.SessionOwner(app.Inflight(http.HandlerFunc(app.HandleVideoStream)))).Methods(http.MethodGet)
+			router.Handle("/har/{sessionId}", app.SessionOwner(http.HandlerFunc(app.HandleHAR))).Methods(http.MethodGet)
+			router.PathPrefix("/status").Handler(app.Authenticated(http.HandlerFunc(app.HandleStatus)))
+			router.Handle("/sessions", app.Authenticated(http.HandlerFunc(app.HandleSessions))).Methods(http.MethodGet)
+			router.HandleFunc("/sessions/batch", app.HandleBatchSession).Methods(http.MethodPost)
+			router.Handle("/sessions/{sessionId}/retry", app.SessionOwner(http.HandlerFunc(app.HandleRetrySession))).Methods(http.MethodPost)
+			router.Handle("/sessions/{sessionId}/heartbeat", app.SessionOwner(http.HandlerFunc(app.HandleHeartbeat))).Methods(http.MethodPost)
+			router.Handle("/session/{sessionId}/extend", app.SessionOwner(http.HandlerFunc(app.HandleExtendSession))).Methods(http.MethodPost)
+			router.Handle("/sessions/{sessionId}/extend", app.SessionOwner(http.HandlerFunc(app.HandleExtendSession))).Methods(http.MethodPost)
+			router.HandleFunc("/quota", app.HandleQuota).Methods(http.MethodGet)
+			router.HandleFunc("/ping", app.HandlePing).Methods(http.MethodGet)
+			router.HandleFunc("/ggr/quota", app.HandleGgrQuota).Methods(http.MethodGet)
+			router.Handle("/events", app.Authenticated(http.HandlerFunc(app.HandleSessionEvents))).Methods(http.MethodGet)
+			router.Handle("/events/capacity", app.Authenticated(http.HandlerFunc(app.HandleCapacityEvents))).Methods(http.MethodGet)
+			router.HandleFunc("/runs/{runId}", app.HandleRun).Methods(http.MethodGet)
+			router.HandleFunc("/runs/{runId}", app.HandleDeleteRun).Methods(http.MethodDelete)
+			router.HandleFunc("/artifacts", app.HandleArtifact).Methods(http.MethodPost)
+			router.Handle("/admin/data", app.AdminOnly(http.HandlerFunc(app.HandlePurgeData))).Methods(http.MethodDelete)
+			router.Handle("/admin/reload", app.AdminOnly(http.HandlerFunc(app.HandleReload))).Methods(http.MethodPost)
+			router.Handle("/admin/log-level", app.AdminOnly(http.HandlerFunc(app.HandleLogLevel))).Methods(http.MethodGet, http.MethodPut)
+			router.Handle("/debug/{sessionId}", app.AdminOnly(http.HandlerFunc(app.HandleDebug))).Methods(http.MethodPost)
+			router.Handle("/admin/warmup", app.AdminOnly(http.HandlerFunc(app.HandleImageWarmup))).Methods(http.MethodGet, http.MethodPost)
+			router.Handle("/openapi.json", app.Authenticated(http.HandlerFunc(app.HandleOpenAPI))).Methods(http.MethodGet)
+			if enableAPIDocs {
+				router.Handle("/api-docs", app.Authenticated(http.HandlerFunc(app.HandleAPIDocs))).Methods(http.MethodGet)
+				router.HandleFunc("/api-docs/redoc.standalone.js", app.HandleRedoc).Methods(http.MethodGet)
+			}
+			if enableUI {
+				router.Handle("/ui", app.Authenticated(http.HandlerFunc(app.HandleUI))).Methods(http.MethodGet)
+				router.Handle("/ui/vnc/{sessionId}", app.SessionOwner(http.HandlerFunc(app.HandleUIVNC))).Methods(http.MethodGet)
+				router.Handle("/ui/logs/{sessionId}", app.SessionOwner(http.HandlerFunc(app.HandleUILogs))).Methods(http.MethodGet)
+				router.PathPrefix("/ui/novnc/").HandlerFunc(app.HandleNoVNC).Methods(http.MethodGet)
+			}
+			router.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
 			router.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+				if app.Draining() {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
 				w.WriteHeader(http.StatusOK)
 			}).Methods(http.MethodGet)
 
@@ -108,12 +680,38 @@ func command() *cobra.Command {
 				Addr:    address,
 				Handler: router,
 			}
+			if enableGRPC {
+				//gRPC clients connect with HTTP/2 without TLS
+				srv.Handler = h2c.NewHandler(router, &http2.Server{})
+			}
 
 			stop := make(chan os.Signal, 1)
 			signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
+			hup := make(chan os.Signal, 1)
+			signal.Notify(hup, syscall.SIGHUP)
+			go func() {
+				for range hup {
+					logger.Info("SIGHUP received, reloading config")
+					app.Reload()
+				}
+			}()
+
+			if tlsCert != "" {
+				reloader, err := selenosis.NewCertReloader(tlsCert, tlsKey, "")
+				if err != nil {
+					logger.Fatalf("failed to read certificate: %v", err)
+				}
+				go reloader.Run(make(chan struct{}), 10*time.Second, certReloaded(logger, "server"))
+				srv.TLSConfig = reloader.ServerConfig()
+			}
+
 			e := make(chan error)
 			go func() {
+				if srv.TLSConfig != nil {
+					e <- srv.ListenAndServeTLS("", "")
+					return
+				}
 				e <- srv.ListenAndServe()
 			}()
 
@@ -124,29 +722,148 @@ func command() *cobra.Command {
 				logger.Warn("stopping selenosis")
 			}
 
+			drainCtx, drainCancel := context.WithTimeout(context.Background(), drainTimeout)
+			app.Drain(drainCtx)
+			drainCancel()
+
 			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 			defer cancel()
 
 			if err := srv.Shutdown(ctx); err != nil {
 				logger.Fatalf("failed to stop selenosis: %v", err)
 			}
+			auditor.Close(ctx)
 		},
 	}
 
 	cmd.Flags().StringVar(&address, "port", ":4444", "port for selenosis")
 	cmd.Flags().StringVar(&proxyPort, "proxy-port", "4445", "proxy continer port")
+	cmd.Flags().StringVar(&logFormat, "log-format", "text", "log format: text or json, json lines carry request, session, browser and tenant as separate fields")
+	cmd.Flags().StringVar(&logLevel, "log-level", "info", "log level: panic, fatal, error, warn, info, debug or trace, it can be changed at runtime with /admin/log-level")
 	cmd.Flags().StringVar(&cfgFile, "browsers-config", "./config/browsers.yaml", "browsers config")
+	cmd.Flags().StringVar(&browsersConfigMap, "browsers-configmap", "", "ConfigMap in selenosis namespace browsers config is watched in and reloaded from on every change")
+	cmd.Flags().StringVar(&browsersConfigKey, "browsers-configmap-key", "", "key of browsers config in --browsers-configmap, file name of --browsers-config if not set")
+	cmd.Flags().BoolVar(&browserConfigs, "browsers-crd", false, "load browsers config from BrowserConfig custom resources in selenosis namespace and reload it on every change")
+	cmd.Flags().StringVar(&podPatchesFile, "pod-patches", "", "JSON patches applied to pods of all browsers, JSON or YAML file")
 	cmd.Flags().IntVar(&limit, "browser-limit", 10, "active sessions max limit")
+	cmd.Flags().StringVar(&platformName, "platform", platform.KubernetesPlatform, "platform to run browsers on: kubernetes or docker")
+	cmd.Flags().StringVar(&dockerHost, "docker-host", dockerHostDefault(), "docker daemon address for docker platform, unix or tcp socket")
+	cmd.Flags().StringVar(&dockerNetwork, "docker-network", "", "docker network browser containers are attached to for docker platform, default bridge network if not set")
 	cmd.Flags().StringVar(&namespace, "namespace", "selenosis", "kubernetes namespace")
 	cmd.Flags().StringVar(&service, "service-name", "seleniferous", "kubernetes service name for browsers")
 	cmd.Flags().DurationVar(&browserWaitTimeout, "browser-wait-timeout", 30*time.Second, "time in seconds that a browser will be ready")
+	cmd.Flags().DurationVar(&pendingTimeout, "pending-timeout", 0, "time after which pending browser pod is deleted and session fails with reason reported by kubernetes, 0 disables the watchdog")
 	cmd.Flags().DurationVar(&sessionWaitTimeout, "session-wait-timeout", 60*time.Second, "time in seconds that a session will be ready")
 	cmd.Flags().DurationVar(&sessionIdleTimeout, "session-idle-timeout", 5*time.Minute, "time in seconds that a session will idle")
+	cmd.Flags().DurationVar(&maxSessionTimeout, "max-session-timeout", time.Hour, "max idle timeout session can request with selenosis:options capability, 0 disables the cap")
 	cmd.Flags().IntVar(&sessionRetryCount, "session-retry-count", 3, "session retry count")
+	cmd.Flags().IntVar(&queueSize, "session-queue-size", 0, "number of new session requests waiting for free capacity when session limit is reached, 0 disables the queue")
+	cmd.Flags().DurationVar(&queueWait, "session-queue-wait", 5*time.Minute, "max time new session request waits in queue, 0 waits until client disconnects")
+	cmd.Flags().DurationVar(&warmPoolInterval, "warm-pool-interval", 10*time.Second, "time between refills of browser warm pools, 0 disables warm pools")
 	cmd.Flags().DurationVar(&shutdownTimeout, "graceful-shutdown-timeout", 30*time.Second, "time in seconds  gracefull shutdown timeout")
+	cmd.Flags().DurationVar(&drainTimeout, "drain-timeout", 30*time.Second, "time to keep proxying requests in flight of existing sessions after stop signal, new sessions are rejected meanwhile")
+	cmd.Flags().DurationVar(&janitorInterval, "janitor-interval", time.Minute, "time between orphaned pods cleanups, 0 disables cleanup")
+	cmd.Flags().DurationVar(&orphanGracePeriod, "orphan-grace-period", 5*time.Minute, "time after which not running browser pod is treated as orphaned")
+	cmd.Flags().DurationVar(&maxSessionLifetime, "max-session-lifetime", 0, "age after which browser pod is deleted by janitor regardless of its activity, 0 disables the limit")
+	cmd.Flags().BoolVar(&sessionJobs, "session-jobs", false, "run browser pods as Kubernetes Jobs, failed browser pods are kept for --session-job-ttl")
+	cmd.Flags().DurationVar(&jobActiveDeadline, "session-job-deadline", 0, "active deadline of session jobs, --max-session-lifetime is used if not set")
+	cmd.Flags().DurationVar(&jobTTL, "session-job-ttl", time.Hour, "time finished session jobs and their pods are kept for, zero deletes them right away")
+	cmd.Flags().DurationVar(&idleReaperTimeout, "idle-reaper-timeout", 0, "time without proxied commands or heartbeats after which browser pod is deleted by janitor, 0 disables idle pods cleanup")
+	cmd.Flags().IntVar(&maxExtensions, "max-session-extensions", 5, "number of times idle countdown of a session can be restarted with extend request, 0 doesn't limit extensions")
+	cmd.Flags().IntVar(&proxyFailureLimit, "proxy-failure-limit", 3, "number of consecutive commands which failed to reach browser after which its pod is deleted, failed delete session command deletes the pod right away, 0 disables the cleanup")
+	cmd.Flags().BoolVar(&proxyH2C, "proxy-h2c", false, "proxy WebDriver commands to seleniferous over HTTP/2 without TLS negotiation, commands of a session share one connection")
+	cmd.Flags().IntVar(&proxyIdleConns, "proxy-idle-conns-per-pod", 16, "number of idle connections kept open to each browser pod for proxied commands")
+	cmd.Flags().DurationVar(&proxyIdleTimeout, "proxy-idle-conn-timeout", 90*time.Second, "time idle connection to browser pod is kept open for")
+	cmd.Flags().DurationVar(&proxyKeepAlive, "proxy-keepalive", 30*time.Second, "period of keep-alive probes of connections to browser pods, with --proxy-h2c also period of health check pings of idle connection")
+	cmd.Flags().DurationVar(&workspaceRetention, "workspace-retention", time.Hour, "time shared workspace of a run is kept after the last session of the run is gone")
 	cmd.Flags().StringVar(&imagePullSecretName, "image-pull-secret-name", "", "secret name to private registry")
 	cmd.Flags().StringVar(&proxyImage, "proxy-image", "alcounit/seleniferous:latest", "in case you use private registry replace with image from private registry")
+	cmd.Flags().StringVar(&tlsCert, "tls-cert", "", "certificate file selenosis serves API with over TLS, reloaded once changed")
+	cmd.Flags().StringVar(&tlsKey, "tls-key", "", "private key file of --tls-cert")
+	cmd.Flags().StringVar(&proxyTLSCert, "proxy-tls-cert", "", "client certificate file selenosis connects to proxy containers with over mTLS, reloaded once changed")
+	cmd.Flags().StringVar(&proxyTLSKey, "proxy-tls-key", "", "private key file of --proxy-tls-cert")
+	cmd.Flags().StringVar(&proxyTLSCA, "proxy-tls-ca", "", "CA bundle file proxy certificates are verified with, system roots if not set")
+	cmd.Flags().StringVar(&proxyTLSServerName, "proxy-tls-server-name", "", "name verified in proxy certificates, host of the session by default")
+	cmd.Flags().StringVar(&proxyTLSSecret, "proxy-tls-secret", "", "kubernetes.io/tls secret with proxy certificate and ca.crt mounted to proxy containers")
+	cmd.Flags().StringVar(&windowsProxyImage, "windows-proxy-image", "", "proxy image for browsers with windows platform, windows browsers can't be started if not set")
+	cmd.Flags().StringVar(&videoImage, "video-recorder-image", "selenoid/video-recorder:latest-release", "video recorder image for sessions with enableVideo capability")
+	cmd.Flags().StringVar(&videoNameTemplate, "video-name-template", "", "go template of video name with .SessionID, .BrowserName, .BrowserVersion, .TestName and .Timestamp fields, overridden by videoName capability, <sessionId>.mp4 by default")
+	cmd.Flags().BoolVar(&videoOverlay, "video-overlay", false, "burn test name, browser, session id and timestamp into recordings, can be enabled per session with videoOverlay capability")
+	cmd.Flags().BoolVar(&videoUpload, "upload-video", false, "upload recordings to videos artifact destination after session ends, can be requested per session with uploadVideo capability")
+	cmd.Flags().StringVar(&videoUploaderImage, "video-uploader-image", "amazon/aws-cli:2.2.0", "image of container uploading recordings to object storage")
+	cmd.Flags().StringVar(&debugImage, "debug-image", "nicolaka/netshoot:latest", "image of ephemeral container attached to browser pod by /debug/{sessionId} unless request sets own image")
+	cmd.Flags().StringVar(&harImage, "har-image", "", "image of recording proxy sidecar started for sessions requesting captureHAR, HAR capture is disabled if not set")
+	cmd.Flags().StringVar(&filesImage, "files-image", "curlimages/curl:7.73.0", "image of init container putting files requested with selenosis:options into the browser, image should have sh and curl")
+	cmd.Flags().DurationVar(&harRetention, "har-retention", time.Hour, "time network archive of deleted session is kept, zero disables keeping archives")
+	cmd.Flags().StringVar(&peers, "peers", "", "host:port of headless service resolving to every selenosis replica, purge of network archives is sent to each of them")
+	cmd.Flags().StringVar(&warmupPauseImage, "warmup-pause-image", "registry.k8s.io/pause:3.9", "image keeping pods of image warmup daemonsets running after browser images are pulled")
+	cmd.Flags().StringVar(&videoEncoding.Codec, "video-codec", "libx264", "default video codec: libx264, libx265 or libvpx-vp9, overridden by videoCodec capability")
+	cmd.Flags().StringVar(&videoEncoding.Preset, "video-preset", "", "default video encoding preset, e.g. veryfast, overridden by videoPreset capability")
+	cmd.Flags().IntVar(&videoEncoding.CRF, "video-crf", 0, "default video constant rate factor between 1 and 51, overridden by videoCrf capability, 0 leaves recorder default")
+	cmd.Flags().Uint16Var(&videoEncoding.FrameRate, "video-frame-rate", 0, "default video frame rate, overridden by videoFrameRate capability, 0 leaves recorder default")
+	cmd.Flags().Uint16Var(&videoEncoding.MaxFrameRate, "video-max-frame-rate", 30, "max video frame rate allowed to request with videoFrameRate capability, 0 disables the limit")
+	cmd.Flags().StringVar(&proxyCPURequest, "proxy-cpu-request", "", "cpu request of proxy container, e.g. 100m")
+	cmd.Flags().StringVar(&proxyMemoryRequest, "proxy-memory-request", "", "memory request of proxy container, e.g. 64Mi")
+	cmd.Flags().StringVar(&proxyCPULimit, "proxy-cpu-limit", "", "cpu limit of proxy container")
+	cmd.Flags().StringVar(&proxyMemoryLimit, "proxy-memory-limit", "", "memory limit of proxy container")
+	cmd.Flags().BoolVar(&enableAPIDocs, "enable-api-docs", false, "serve interactive API explorer at /api-docs")
+	cmd.Flags().BoolVar(&enableUI, "enable-ui", false, "serve dashboard of active sessions at /ui")
+	cmd.Flags().BoolVar(&enableOperator, "enable-operator", false, "reconcile SelenosisSession custom resources")
+	cmd.Flags().BoolVar(&enableRecords, "enable-session-records", false, "record running sessions as BrowserSession custom resources")
+	cmd.Flags().BoolVar(&enableKubevirt, "enable-kubevirt", false, "start browsers with vm settings in KubeVirt virtual machines")
+	cmd.Flags().BoolVar(&enableGRPC, "enable-grpc", false, "serve gRPC session API on the same port as HTTP API")
+	cmd.Flags().StringVar(&statusFormat, "status-format", selenosis.SelenosisStatusFormat, "format of /status response: selenosis or selenoid, selenoid format is understood by selenoid-ui and ggr-ui")
+	cmd.Flags().StringVar(&ggrRegion, "ggr-region", "default", "region of selenosis hosts in ggr quota returned by /ggr/quota, e.g. cluster name")
+	cmd.Flags().StringVar(&ggrHost, "ggr-host", "", "host and port ggr sends sessions to, listed in ggr quota returned by /ggr/quota, host of the request by default")
+	cmd.Flags().StringVar(&sessionRateLimit, "session-rate-limit", "", "sessions per second every client may create, rate[:burst] e.g. 1:10, clients are told apart by user, tenant or IP address, not limited if not set")
+	cmd.Flags().StringSliceVar(&sessionRateLimits, "session-rate-limits", nil, "session rate limits of particular clients overriding --session-rate-limit, client=rate[:burst], zero rate doesn't limit the client, flag can be repeated")
+	cmd.Flags().StringSliceVar(&bannedImages, "admission-banned-images", nil, "patterns of browser and sidecar images sessions are rejected for, e.g. *:latest, flag can be repeated")
+	cmd.Flags().StringVar(&cpuCeiling, "admission-cpu-ceiling", "", "max cpu request and limit of browser and proxy containers, higher values of browsers config are lowered to it")
+	cmd.Flags().StringVar(&memoryCeiling, "admission-memory-ceiling", "", "max memory request and limit of browser and proxy containers, higher values of browsers config are lowered to it")
+	cmd.Flags().StringVar(&costCenterLabel, "admission-cost-center-label", "", "pod label set to the same capability label or tenant of the session, e.g. cost-center")
+	cmd.Flags().StringVar(&minCPU, "session-min-cpu", "", "min cpu request and limit sessions can request with selenosis:options capability")
+	cmd.Flags().StringVar(&maxCPU, "session-max-cpu", "", "max cpu request and limit sessions can request with selenosis:options capability")
+	cmd.Flags().StringVar(&minMemory, "session-min-memory", "", "min memory request and limit sessions can request with selenosis:options capability")
+	cmd.Flags().StringVar(&maxMemory, "session-max-memory", "", "max memory request and limit sessions can request with selenosis:options capability")
+	cmd.Flags().StringSliceVar(&sessionHosts, "session-host-aliases", nil, "hostnames sessions can alias or use as search domains with selenosis:options capability, e.g. *.staging.example.com, flag can be repeated")
+	cmd.Flags().StringSliceVar(&sessionNameservers, "session-dns-nameservers", nil, "nameserver ips or CIDRs sessions can use with selenosis:options capability, flag can be repeated")
+	cmd.Flags().IntVar(&batchWorkers, "batch-workers", 10, "max sessions of batch request started concurrently")
+	cmd.Flags().IntVar(&batchMaxSessions, "batch-max-sessions", 100, "max sessions single batch request can create")
+	cmd.Flags().BoolVar(&leaderElection, "leader-election", false, "elect leader replica with Kubernetes Lease, only the leader deletes orphaned pods and refills warm pools")
+	cmd.Flags().StringVar(&leaderLease, "leader-election-lease", "selenosis", "name of Lease used for leader election")
+	cmd.Flags().DurationVar(&leaseDuration, "leader-election-lease-duration", 15*time.Second, "time other replicas wait before taking over lease of vanished leader")
+	cmd.Flags().StringSliceVar(&webhookConfig.URLs, "webhook-url", nil, "endpoints to post session and run events to, flag can be repeated")
+	cmd.Flags().StringVar(&webhookConfig.Secret, "webhook-secret", "", "secret to sign webhook events with HMAC-SHA256")
+	cmd.Flags().IntVar(&webhookConfig.Retries, "webhook-retries", 3, "number of retries of failed webhook event delivery")
+	cmd.Flags().StringVar(&tenantsFile, "tenants-config", "", "tenants config, sessions of authenticated tenants are created in their own namespaces")
+	cmd.Flags().StringVar(&authFile, "auth-config", "", "tokens, users and oidc provider of API authentication, sessions are managed by their owners and admins")
+	cmd.Flags().StringVar(&quotasFile, "quotas-config", "", "session limits per browser, browser version and client")
+	cmd.Flags().StringVar(&artifacts.URL, "artifacts-url", "", "default object storage location for session artifacts, e.g. s3://bucket/prefix")
+	cmd.Flags().StringVar(&artifacts.CredentialsSecret, "artifacts-credentials-secret", "", "secret with object storage credentials for session artifacts")
+	cmd.Flags().Int64Var(&artifacts.MaxBytes, "artifacts-max-bytes", 0, "artifact storage cap per tenant in bytes, 0 disables the cap")
+	cmd.Flags().StringVar(&artifacts.OverLimit, "artifacts-over-limit", platform.RejectOverLimit, "action when artifact storage cap is exceeded: reject video recording or rotate oldest artifacts")
+	cmd.Flags().StringVar(&artifactsFile, "artifacts-config", "", "artifacts settings file reloaded on SIGHUP, replaces artifacts flags")
+	cmd.Flags().StringVar(&auditSyslogURL, "audit-syslog-url", "", "syslog server to ship session audit events to, e.g. tls://siem:6514, tcp://siem:514 or udp://siem:514")
+	cmd.Flags().StringVar(&auditSyslogCA, "audit-syslog-ca", "", "CA certificate file to verify syslog server with, system roots are used if not set")
+	cmd.Flags().StringVar(&auditKafkaURL, "audit-kafka-url", "", "Kafka REST proxy to produce session audit events with")
+	cmd.Flags().StringVar(&auditKafkaTopic, "audit-kafka-topic", "selenosis-audit", "Kafka topic for session audit events")
+	cmd.Flags().BoolVar(&enableChaos, "enable-chaos", false, "inject platform failures, staging only")
+	cmd.Flags().Float64Var(&chaos.CreateErrorRate, "chaos-create-error-rate", 0, "probability of browser pod create failure in chaos mode")
+	cmd.Flags().Float64Var(&chaos.WatchDelayRate, "chaos-watch-delay-rate", 0, "probability of platform event delay in chaos mode")
+	cmd.Flags().DurationVar(&chaos.WatchDelay, "chaos-watch-delay", 5*time.Second, "platform event delay in chaos mode")
+	cmd.Flags().Float64Var(&chaos.DeleteRate, "chaos-delete-rate", 0, "probability of browser pod deletion after start in chaos mode")
+	cmd.Flags().DurationVar(&chaos.DeleteAfter, "chaos-delete-after", 30*time.Second, "max time after start browser pod is deleted within in chaos mode")
+	cmd.Flags().Float32Var(&kubeAPIQPS, "kube-api-qps", 5, "kubernetes api requests per second allowed by client side rate limiter")
+	cmd.Flags().IntVar(&kubeAPIBurst, "kube-api-burst", 10, "kubernetes api requests burst allowed by client side rate limiter")
+	cmd.Flags().DurationVar(&soakInterval, "soak-interval", time.Minute, "time between samples of goroutines, caches, connections and registries, 0 disables sampling")
+	cmd.Flags().IntVar(&soakWindow, "soak-window", 60, "number of samples minimum is taken over when detecting growth")
+	cmd.Flags().Float64Var(&soakAlertGrowth, "soak-alert-growth", 0, "growth ratio of windowed minimum over baseline reported as leak, 0 disables alerts")
+	cmd.Flags().StringVar(&burstEndpoint, "burst-endpoint", "", "secondary WebDriver backend new sessions are relayed to when local capacity is exceeded, e.g. another selenosis")
+	cmd.Flags().Float64Var(&burstThreshold, "burst-threshold", 0, "share of session limit used by local sessions new sessions are bursted at, 0 disables")
+	cmd.Flags().DurationVar(&burstQueueWait, "burst-queue-wait", 0, "wait of the oldest pending local session new sessions are bursted at, 0 disables")
 	cmd.Flags().SortFlags = false
+	cmd.AddCommand(loadtestCommand())
+	cmd.AddCommand(benchCommand())
 
 	return cmd
 }
@@ -200,3 +917,55 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+//resourceRequirements returns container requests and limits, empty values are not set
+func resourceRequirements(cpuRequest, memoryRequest, cpuLimit, memoryLimit string) (apiv1.ResourceRequirements, error) {
+	requests, err := resourceList(cpuRequest, memoryRequest)
+	if err != nil {
+		return apiv1.ResourceRequirements{}, err
+	}
+	limits, err := resourceList(cpuLimit, memoryLimit)
+	if err != nil {
+		return apiv1.ResourceRequirements{}, err
+	}
+	return apiv1.ResourceRequirements{Requests: requests, Limits: limits}, nil
+}
+
+func resourceList(cpu, memory string) (apiv1.ResourceList, error) {
+	var list apiv1.ResourceList
+	for name, value := range map[apiv1.ResourceName]string{apiv1.ResourceCPU: cpu, apiv1.ResourceMemory: memory} {
+		if value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s quantity %s: %v", name, value, err)
+		}
+		if list == nil {
+			list = make(apiv1.ResourceList)
+		}
+		list[name] = quantity
+	}
+	return list, nil
+}
+
+//certReloaded returns callback logging and counting reloads of rotated certificate
+func certReloaded(logger *logrus.Logger, name string) func(error) {
+	return func(err error) {
+		if err != nil {
+			logger.Errorf("%s certificate reload failed: %v", name, err)
+			metrics.ConfigReloads.WithLabelValues("certificates", "failed").Inc()
+			return
+		}
+		logger.Infof("%s certificate reloaded", name)
+		metrics.ConfigReloads.WithLabelValues("certificates", "reloaded").Inc()
+	}
+}
+
+//dockerHostDefault returns DOCKER_HOST like docker cli does, local socket otherwise
+func dockerHostDefault() string {
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		return host
+	}
+	return "unix:///var/run/docker.sock"
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/alcounit/selenosis/platform"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+//ArtifactsConfig keeps default destinations and storage cap of session artifacts
+type ArtifactsConfig struct {
+	configFile string
+	lock       sync.RWMutex
+	artifacts  platform.Artifacts
+}
+
+//NewArtifactsConfig returns parsed artifacts settings from JSON or YAML file
+func NewArtifactsConfig(configFile string) (*ArtifactsConfig, error) {
+	artifacts, err := readArtifacts(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ArtifactsConfig{
+		configFile: configFile,
+		artifacts:  artifacts,
+	}, nil
+}
+
+//Reload rereads artifacts settings, new settings apply to new sessions only
+func (cfg *ArtifactsConfig) Reload() error {
+	artifacts, err := readArtifacts(cfg.configFile)
+	if err != nil {
+		return err
+	}
+
+	cfg.lock.Lock()
+	defer cfg.lock.Unlock()
+	cfg.artifacts = artifacts
+	return nil
+}
+
+//Get ...
+func (cfg *ArtifactsConfig) Get() platform.Artifacts {
+	cfg.lock.RLock()
+	defer cfg.lock.RUnlock()
+
+	return cfg.artifacts
+}
+
+func readArtifacts(configFile string) (platform.Artifacts, error) {
+	content, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return platform.Artifacts{}, fmt.Errorf("failed to read config: read error: %v", err)
+	}
+
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), 1000)
+
+	var artifacts platform.Artifacts
+	if err := decoder.Decode(&artifacts); err != nil {
+		return platform.Artifacts{}, fmt.Errorf("failed to read config: parse error: %v", err)
+	}
+	if artifacts.OverLimit == "" {
+		artifacts.OverLimit = platform.RejectOverLimit
+	}
+	if err := artifacts.Validate(); err != nil {
+		return platform.Artifacts{}, fmt.Errorf("failed to read config: %v", err)
+	}
+	return artifacts, nil
+}
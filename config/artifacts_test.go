@@ -0,0 +1,59 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArtifactsConfigReload(t *testing.T) {
+
+	tests := map[string]struct {
+		data      string
+		err       error
+		artifacts platform.Artifacts
+	}{
+		"verify artifacts settings are reloaded": {
+			data: `---
+url: s3://artifacts/new
+maxBytes: 1024
+videos:
+  url: s3://videos
+`,
+			artifacts: platform.Artifacts{
+				ArtifactDestination: platform.ArtifactDestination{URL: "s3://artifacts/new"},
+				Videos:              &platform.ArtifactDestination{URL: "s3://videos"},
+				MaxBytes:            1024,
+				OverLimit:           platform.RejectOverLimit,
+			},
+		},
+		"verify invalid settings keep current ones": {
+			data: `---
+url: s3://artifacts/new
+overLimit: drop
+`,
+			err: errors.New("failed to read config: unknown over limit action drop"),
+			artifacts: platform.Artifacts{
+				ArtifactDestination: platform.ArtifactDestination{URL: "s3://artifacts"},
+				OverLimit:           platform.RotateOverLimit,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+		f := configfile("url: s3://artifacts\noverLimit: rotate\n", "artifacts.yaml")
+		defer os.Remove(f)
+		cfg, err := NewArtifactsConfig(f)
+		assert.NoError(t, err)
+
+		assert.NoError(t, ioutil.WriteFile(f, []byte(test.data), 0644))
+		err = cfg.Reload()
+		assert.Equal(t, test.err, err)
+		assert.Equal(t, test.artifacts, cfg.Get())
+	}
+}
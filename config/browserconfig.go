@@ -0,0 +1,127 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+//BrowserConfigResource is a BrowserConfig custom resource holding browser layout of browsers config
+var BrowserConfigResource = schema.GroupVersionResource{
+	Group:    "selenosis.io",
+	Version:  "v1alpha1",
+	Resource: "browserconfigs",
+}
+
+//NewBrowserConfigClient returns in cluster dynamic client to watch BrowserConfig resources with
+func NewBrowserConfigClient() (dynamic.Interface, error) {
+	conf, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build cluster config: %v", err)
+	}
+
+	client, err := dynamic.NewForConfig(conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build dynamic client: %v", err)
+	}
+	return client, nil
+}
+
+//BrowserConfigWatcher loads browsers config from BrowserConfig resources of the namespace every time
+//any of them is changed. Every resource is a browser layout, browser is named by spec.name or by the
+//resource name, resource named defaults is inherited by all browsers
+type BrowserConfigWatcher struct {
+	client    dynamic.Interface
+	namespace string
+	resync    time.Duration
+
+	lock sync.Mutex
+	last []byte
+}
+
+//NewBrowserConfigWatcher ...
+func NewBrowserConfigWatcher(client dynamic.Interface, namespace string, resync time.Duration) *BrowserConfigWatcher {
+	return &BrowserConfigWatcher{
+		client:    client,
+		namespace: namespace,
+		resync:    resync,
+	}
+}
+
+//Run loads BrowserConfig resources into browsers config and blocks until stop is closed, onLoad is
+//called with result of every load. Invalid resources are reported and keep previous config in use
+func (w *BrowserConfigWatcher) Run(stop <-chan struct{}, browsers *BrowsersConfig, onLoad func(error)) {
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(w.client, w.resync, w.namespace, nil)
+	informer := factory.ForResource(BrowserConfigResource).Informer()
+
+	load := func() {
+		if !informer.HasSynced() {
+			return
+		}
+		if err := w.load(informer.GetStore().List(), browsers); err != errUnchanged {
+			onLoad(err)
+		}
+	}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(interface{}) {
+			load()
+		},
+		UpdateFunc: func(interface{}, interface{}) {
+			load()
+		},
+		DeleteFunc: func(interface{}) {
+			load()
+		},
+	})
+
+	factory.Start(stop)
+	if cache.WaitForCacheSync(stop, informer.HasSynced) {
+		load()
+	}
+	<-stop
+}
+
+//load applies layouts of resources, resyncs not changing any layout return errUnchanged
+func (w *BrowserConfigWatcher) load(objs []interface{}, browsers *BrowsersConfig) error {
+	layouts := make(map[string]map[string]interface{})
+	for _, obj := range objs {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		spec, _, err := unstructured.NestedMap(u.Object, "spec")
+		if err != nil {
+			return fmt.Errorf("browserconfig %s/%s has invalid spec: %v", u.GetNamespace(), u.GetName(), err)
+		}
+		name := u.GetName()
+		if n, ok := spec["name"].(string); ok && n != "" {
+			name = n
+		}
+		delete(spec, "name")
+		if _, ok := layouts[name]; ok {
+			return fmt.Errorf("browser %s is defined by more than one browserconfig", name)
+		}
+		layouts[name] = spec
+	}
+
+	content, err := json.Marshal(layouts)
+	if err != nil {
+		return fmt.Errorf("failed to encode browserconfigs: %v", err)
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.last != nil && string(w.last) == string(content) {
+		return errUnchanged
+	}
+	w.last = content
+	return browsers.Load(content)
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestBrowserConfigLoad(t *testing.T) {
+	f := configfile(`{"chrome": {"defaultVersion": "85.0", "path": "/", "versions": {"85.0": {"image": "selenoid/vnc:chrome_85.0"}}}}`, "browsers.json")
+	defer os.Remove(f)
+	browsers, err := NewBrowsersConfig(f)
+	assert.Nil(t, err)
+
+	watcher := NewBrowserConfigWatcher(nil, "selenosis", 0)
+	browserConfig := func(name string, spec map[string]interface{}) interface{} {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+		obj.SetNamespace("selenosis")
+		obj.SetName(name)
+		return obj
+	}
+	firefox := browserConfig("firefox", map[string]interface{}{
+		"defaultVersion": "80.0",
+		"path":           "/wd/hub",
+		"versions": map[string]interface{}{
+			"80.0": map[string]interface{}{"image": "selenoid/vnc:firefox_80.0"},
+		},
+	})
+	edge := browserConfig("edge", map[string]interface{}{
+		"name":           "MicrosoftEdge",
+		"defaultVersion": "90.0",
+		"path":           "/",
+		"versions": map[string]interface{}{
+			"90.0": map[string]interface{}{"image": "browsers/edge:90.0"},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		objs     []interface{}
+		err      error
+		browsers []string
+	}{
+		{
+			name:     "verify browsers config is replaced by browserconfigs",
+			objs:     []interface{}{firefox},
+			browsers: []string{"firefox"},
+		},
+		{
+			name:     "verify browser is named by spec name",
+			objs:     []interface{}{firefox, edge},
+			browsers: []string{"MicrosoftEdge", "firefox"},
+		},
+		{
+			name:     "verify not changed browserconfigs are not loaded again",
+			objs:     []interface{}{edge, firefox},
+			err:      errUnchanged,
+			browsers: []string{"MicrosoftEdge", "firefox"},
+		},
+		{
+			name:     "verify browser defined twice keeps previous config",
+			objs:     []interface{}{edge, browserConfig("edge-beta", map[string]interface{}{"name": "MicrosoftEdge"})},
+			err:      errors.New("browser MicrosoftEdge is defined by more than one browserconfig"),
+			browsers: []string{"MicrosoftEdge", "firefox"},
+		},
+		{
+			name:     "verify no browserconfigs keep previous config",
+			err:      errors.New("failed to read config: empty config: <nil>"),
+			browsers: []string{"MicrosoftEdge", "firefox"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Logf("TC: %s", test.name)
+		err := watcher.load(test.objs, browsers)
+		assert.Equal(t, test.err, err)
+
+		var names []string
+		for name := range browsers.GetBrowserVersions() {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		assert.Equal(t, test.browsers, names)
+	}
+
+	_, err = browsers.Find("MicrosoftEdge", "90.0")
+	assert.Nil(t, err)
+}
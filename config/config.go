@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"k8s.io/apimachinery/pkg/util/yaml"
 
@@ -15,8 +17,12 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 )
 
+//defaultsKey is key of layout inherited by every browser of the config, it is not a browser itself
+const defaultsKey = "defaults"
+
 //Layout ...
 type Layout struct {
+	Extends        string                           `yaml:"extends,omitempty" json:"extends,omitempty"`
 	DefaultSpec    platform.Spec                    `yaml:"spec" json:"spec"`
 	Meta           platform.Meta                    `yaml:"meta" json:"meta"`
 	Path           string                           `yaml:"path" json:"path"`
@@ -25,18 +31,34 @@ type Layout struct {
 	Volumes        []apiv1.Volume                   `yaml:"volumes,omitempty" json:"volumes,omitempty"`
 	Capabilities   []apiv1.Capability               `yaml:"kernelCaps,omitempty" json:"kernelCaps,omitempty"`
 	RunAs          platform.RunAsOptions            `yaml:"runAs,omitempty" json:"runAs,omitempty"`
+	Platform       string                           `yaml:"platform,omitempty" json:"platform,omitempty"`
+	Type           string                           `yaml:"type,omitempty" json:"type,omitempty"`
+	VM             *platform.VMSpec                 `yaml:"vm,omitempty" json:"vm,omitempty"`
+	Video          *platform.VideoSpec              `yaml:"video,omitempty" json:"video,omitempty"`
+	Workspace      *platform.WorkspaceSpec          `yaml:"workspace,omitempty" json:"workspace,omitempty"`
+	PodOverlay     map[string]interface{}           `yaml:"podOverlay,omitempty" json:"podOverlay,omitempty"`
+	PodOverride    string                           `yaml:"podOverride,omitempty" json:"podOverride,omitempty"`
+	PodPatches     []platform.PatchOperation        `yaml:"podPatches,omitempty" json:"podPatches,omitempty"`
+	RetryCount     int                              `yaml:"retryCount,omitempty" json:"retryCount,omitempty"`
+	RetryDelay     string                           `yaml:"retryDelay,omitempty" json:"retryDelay,omitempty"`
+	WarmPool       int                              `yaml:"warmPool,omitempty" json:"warmPool,omitempty"`
+	MaxSessions    int                              `yaml:"maxSessions,omitempty" json:"maxSessions,omitempty"`
+	Readiness      *platform.ReadinessSpec          `yaml:"readiness,omitempty" json:"readiness,omitempty"`
 }
 
 //BrowsersConfig ...
 type BrowsersConfig struct {
-	configFile string
+	configFile     string
+	podPatches     []platform.PatchOperation
+	podPatchesFile string
+	content        []byte
 	lock       sync.RWMutex
 	containers map[string]*Layout
 }
 
 //NewBrowsersConfig returns parced browsers config from JSON or YAML file.
 func NewBrowsersConfig(configFile string) (*BrowsersConfig, error) {
-	layouts, err := readConfig(configFile)
+	layouts, err := readConfig(configFile, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config: %v", err)
 	}
@@ -52,20 +74,129 @@ func (cfg *BrowsersConfig) Reload() error {
 	cfg.lock.Lock()
 	defer cfg.lock.Unlock()
 
-	layouts, err := readConfig(cfg.configFile)
+	patches := cfg.podPatches
+	if cfg.podPatchesFile != "" {
+		var err error
+		if patches, err = ReadPodPatches(cfg.podPatchesFile); err != nil {
+			return fmt.Errorf("failed to read pod patches: %v", err)
+		}
+	}
+
+	layouts, err := cfg.read(patches)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %v", err)
+	}
+
+	cfg.podPatches = patches
+	cfg.containers = layouts
+	return nil
+}
+
+//Load replaces browsers config with JSON or YAML content, e.g. taken from ConfigMap. Invalid
+//content keeps current config in use, valid one is used instead of config file by later reloads
+func (cfg *BrowsersConfig) Load(content []byte) error {
+	cfg.lock.Lock()
+	defer cfg.lock.Unlock()
+
+	layouts, err := parseConfig(content, cfg.podPatches)
 	if err != nil {
 		return fmt.Errorf("failed to read config: %v", err)
 	}
 
+	cfg.content = content
 	cfg.containers = layouts
 	return nil
 }
 
+//read parses loaded content or config file if nothing was loaded
+func (cfg *BrowsersConfig) read(podPatches []platform.PatchOperation) (map[string]*Layout, error) {
+	if cfg.content != nil {
+		return parseConfig(cfg.content, podPatches)
+	}
+	return readConfig(cfg.configFile, podPatches)
+}
+
+//SetPodPatches sets JSON patches applied to pods of all browsers before patches of browser,
+//config is reread to validate patches against every browser
+func (cfg *BrowsersConfig) SetPodPatches(patches []platform.PatchOperation) error {
+	cfg.lock.Lock()
+	defer cfg.lock.Unlock()
+
+	layouts, err := cfg.read(patches)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %v", err)
+	}
+
+	cfg.podPatches = patches
+	cfg.containers = layouts
+	return nil
+}
+
+//LoadPodPatches sets JSON patches read from file, file is reread on every reload
+func (cfg *BrowsersConfig) LoadPodPatches(patchesFile string) error {
+	patches, err := ReadPodPatches(patchesFile)
+	if err != nil {
+		return fmt.Errorf("failed to read pod patches: %v", err)
+	}
+	if err := cfg.SetPodPatches(patches); err != nil {
+		return err
+	}
+
+	cfg.lock.Lock()
+	defer cfg.lock.Unlock()
+	cfg.podPatchesFile = patchesFile
+	return nil
+}
+
+//ReadPodPatches returns JSON patch operations from JSON or YAML file
+func ReadPodPatches(patchesFile string) ([]platform.PatchOperation, error) {
+	content, err := ioutil.ReadFile(patchesFile)
+	if err != nil {
+		return nil, fmt.Errorf("read error: %v", err)
+	}
+
+	var patches []platform.PatchOperation
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), 1000)
+	if err := decoder.Decode(&patches); err != nil {
+		return nil, fmt.Errorf("parse error: %v", err)
+	}
+	return patches, nil
+}
+
 //Find return Container if it present in config
 func (cfg *BrowsersConfig) Find(name, version string) (platform.BrowserSpec, error) {
+	return cfg.FindOnPlatform(name, version, "")
+}
+
+//FindOnPlatform returns browser version running on platform requested with platformName capability.
+//Default version is used when version is not requested or unknown, the latest version of the platform
+//if default one runs on other platform
+func (cfg *BrowsersConfig) FindOnPlatform(name, version, platformName string) (platform.BrowserSpec, error) {
 	cfg.lock.Lock()
 	defer cfg.lock.Unlock()
 
+	browser, err := cfg.find(name, version)
+	if err != nil || browser.MatchPlatform(platformName) {
+		return browser, err
+	}
+	if browser.BrowserVersion == version {
+		return platform.BrowserSpec{}, fmt.Errorf("browser %s %s is not available on %s platform", name, version, platformName)
+	}
+
+	var latest string
+	for v, container := range cfg.containers[name].Versions {
+		if container.MatchPlatform(platformName) && (latest == "" || tools.StrToFloat64(v) > tools.StrToFloat64(latest)) {
+			latest = v
+		}
+	}
+	if latest == "" {
+		return platform.BrowserSpec{}, fmt.Errorf("browser %s is not available on %s platform", name, platformName)
+	}
+	return cfg.find(name, latest)
+}
+
+//find returns browser version or default one if version is unknown, lock should be held
+func (cfg *BrowsersConfig) find(name, version string) (platform.BrowserSpec, error) {
 	c, ok := cfg.containers[name]
 	if !ok {
 		return platform.BrowserSpec{}, fmt.Errorf("unknown browser name %s", name)
@@ -90,6 +221,18 @@ func (cfg *BrowsersConfig) Find(name, version string) (platform.BrowserSpec, err
 	return *v, nil
 }
 
+//FindDevice returns Appium browser configured for the device, browsers of other types are not matched
+func (cfg *BrowsersConfig) FindDevice(device, version, platformName string) (platform.BrowserSpec, error) {
+	browser, err := cfg.FindOnPlatform(device, version, platformName)
+	if err != nil {
+		return platform.BrowserSpec{}, err
+	}
+	if browser.Type != platform.AppiumType {
+		return platform.BrowserSpec{}, fmt.Errorf("browser %s is not an appium device", device)
+	}
+	return browser, nil
+}
+
 //GetBrowserVersions ...
 func (cfg *BrowsersConfig) GetBrowserVersions() map[string][]string {
 	cfg.lock.Lock()
@@ -115,31 +258,193 @@ func (cfg *BrowsersConfig) GetBrowserVersions() map[string][]string {
 	return browsers
 }
 
-func readConfig(configFile string) (map[string]*Layout, error) {
+//Browsers returns every browser version of the config sorted by browser name and version
+func (cfg *BrowsersConfig) Browsers() []platform.BrowserSpec {
+	cfg.lock.Lock()
+	defer cfg.lock.Unlock()
+
+	var browsers []platform.BrowserSpec
+	for name, layout := range cfg.containers {
+		for version, v := range layout.Versions {
+			browser := *v
+			browser.BrowserName = name
+			browser.BrowserVersion = version
+			browsers = append(browsers, browser)
+		}
+	}
+	sort.Slice(browsers, func(i, j int) bool {
+		if browsers[i].BrowserName != browsers[j].BrowserName {
+			return browsers[i].BrowserName < browsers[j].BrowserName
+		}
+		return browsers[i].BrowserVersion < browsers[j].BrowserVersion
+	})
+	return browsers
+}
+
+//WarmPools returns browsers having warm pool, relayed and virtual machine browsers have no pods to keep warm
+func (cfg *BrowsersConfig) WarmPools() []platform.BrowserSpec {
+	cfg.lock.Lock()
+	defer cfg.lock.Unlock()
+
+	var browsers []platform.BrowserSpec
+	for name, layout := range cfg.containers {
+		for version, v := range layout.Versions {
+			if v.WarmPool <= 0 || v.Relay != "" || v.VM != nil {
+				continue
+			}
+			browser := *v
+			browser.BrowserName = name
+			browser.BrowserVersion = version
+			browsers = append(browsers, browser)
+		}
+	}
+	sort.Slice(browsers, func(i, j int) bool {
+		if browsers[i].BrowserName != browsers[j].BrowserName {
+			return browsers[i].BrowserName < browsers[j].BrowserName
+		}
+		return browsers[i].BrowserVersion < browsers[j].BrowserVersion
+	})
+	return browsers
+}
+
+//podOverlay returns pod overlay set with podOverlay or with its YAML form podOverride
+func podOverlay(overlay map[string]interface{}, override string) (map[string]interface{}, error) {
+	if strings.TrimSpace(override) == "" {
+		return overlay, nil
+	}
+	if overlay != nil {
+		return nil, fmt.Errorf("podOverlay and podOverride can't be set both")
+	}
+	if err := yaml.NewYAMLOrJSONDecoder(strings.NewReader(override), len(override)).Decode(&overlay); err != nil {
+		return nil, fmt.Errorf("podOverride parse error: %v", err)
+	}
+	return overlay, nil
+}
+
+func readConfig(configFile string, podPatches []platform.PatchOperation) (map[string]*Layout, error) {
 	content, err := ioutil.ReadFile(configFile)
 	if err != nil {
 		return nil, fmt.Errorf("read error: %v", err)
 	}
+	return parseConfig(content, podPatches)
+}
 
+func parseConfig(content []byte, podPatches []platform.PatchOperation) (map[string]*Layout, error) {
 	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), 1000)
 
 	layouts := make(map[string]*Layout)
-	if err := decoder.Decode(&layouts); err != nil {
+	err := decoder.Decode(&layouts)
+	if err != nil {
 		return nil, fmt.Errorf("parse error: %v", err)
 	}
 
+	if err := inheritLayouts(layouts); err != nil {
+		return nil, err
+	}
+
 	if len(layouts) == 0 {
 		return nil, fmt.Errorf("empty config: %v", err)
 	}
 
-	for _, layout := range layouts {
+	for name, layout := range layouts {
 		spec := layout.DefaultSpec
-		for _, container := range layout.Versions {
+		layoutOverlay, err := podOverlay(layout.PodOverlay, layout.PodOverride)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pod overlay of %s: %v", name, err)
+		}
+		for version, container := range layout.Versions {
 			if container.Path == "" {
 				container.Path = layout.Path	
 			}			
-			container.Meta.Annotations = merge(container.Meta.Annotations, layout.Meta.Annotations)
-			container.Meta.Labels = merge(container.Meta.Labels, layout.Meta.Labels)
+			if container.Platform == "" {
+				container.Platform = layout.Platform
+			}
+			if container.Type == "" {
+				container.Type = layout.Type
+			}
+			if container.Cloud != nil {
+				if err := container.Cloud.Validate(); err != nil {
+					return nil, fmt.Errorf("invalid cloud of %s %s: %v", name, version, err)
+				}
+				if container.Relay == "" {
+					container.Relay = container.Cloud.Endpoint()
+				}
+			}
+			if container.VM == nil {
+				container.VM = layout.VM
+			}
+			if container.VM != nil {
+				if err := container.VM.Validate(); err != nil {
+					return nil, fmt.Errorf("invalid vm of %s %s: %v", name, version, err)
+				}
+				if container.Relay != "" {
+					return nil, fmt.Errorf("%s %s can't be relayed and run in vm", name, version)
+				}
+			}
+			if container.Video == nil {
+				container.Video = layout.Video
+			}
+			if container.Workspace == nil {
+				container.Workspace = layout.Workspace
+			}
+			overlay, err := podOverlay(container.PodOverlay, container.PodOverride)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pod overlay of %s %s: %v", name, version, err)
+			}
+			if overlay == nil {
+				overlay = layoutOverlay
+			}
+			container.PodOverlay = overlay
+			container.PodOverride = ""
+			if container.RetryCount == 0 {
+				container.RetryCount = layout.RetryCount
+			}
+			if container.RetryDelay == "" {
+				container.RetryDelay = layout.RetryDelay
+			}
+			if container.WarmPool == 0 {
+				container.WarmPool = layout.WarmPool
+			}
+			if container.MaxSessions == 0 {
+				container.MaxSessions = layout.MaxSessions
+			}
+			if container.Readiness == nil {
+				container.Readiness = layout.Readiness
+			}
+			if container.Readiness != nil {
+				if err := container.Readiness.Validate(); err != nil {
+					return nil, fmt.Errorf("invalid readiness of %s %s: %v", name, version, err)
+				}
+			}
+			if container.WarmPool < 0 {
+				return nil, fmt.Errorf("invalid warm pool size of %s %s: %d", name, version, container.WarmPool)
+			}
+			if container.MaxSessions < 0 {
+				return nil, fmt.Errorf("invalid max sessions of %s %s: %d", name, version, container.MaxSessions)
+			}
+			if container.RetryCount < 0 {
+				return nil, fmt.Errorf("invalid retry count of %s %s: %d", name, version, container.RetryCount)
+			}
+			if container.RetryDelay != "" {
+				if _, err := time.ParseDuration(container.RetryDelay); err != nil {
+					return nil, fmt.Errorf("invalid retry delay of %s %s: %v", name, version, err)
+				}
+			}
+			patches := append([]platform.PatchOperation{}, podPatches...)
+			patches = append(patches, layout.PodPatches...)
+			container.PodPatches = append(patches, container.PodPatches...)
+			switch container.Platform {
+			case "", platform.LinuxPlatform, platform.WindowsPlatform, platform.AndroidPlatform:
+			default:
+				return nil, fmt.Errorf("unknown platform %s of %s %s", container.Platform, name, version)
+			}
+			switch container.Type {
+			case "", platform.AppiumType:
+			default:
+				return nil, fmt.Errorf("unknown type %s of %s %s", container.Type, name, version)
+			}
+			container.Meta.Annotations = merge(container.Meta.Annotations, merge(layout.Meta.Annotations, make(map[string]string)))
+			container.Meta.Labels = merge(container.Meta.Labels, merge(layout.Meta.Labels, make(map[string]string)))
 			container.Volumes = layout.Volumes
 			container.Capabilities = append(container.Capabilities, layout.Capabilities...)
 
@@ -150,11 +455,91 @@ func readConfig(configFile string) (map[string]*Layout, error) {
 			if err := mergo.Merge(&container.RunAs, layout.RunAs); err != nil {
 				return nil, fmt.Errorf("merge error %v", err)
 			}
+
+			if container.Relay == "" && container.VM == nil {
+				if err := platform.ValidateTemplate(*container); err != nil {
+					return nil, fmt.Errorf("invalid pod of %s %s: %v", name, version, err)
+				}
+			}
 		}
 	}
 	return layouts, nil
 }
 
+//inheritLayouts merges layout of extended browser into every browser with extends field, other browsers
+//inherit defaults layout. Fields set by browser are kept, versions and default version are never inherited
+func inheritLayouts(layouts map[string]*Layout) error {
+	defaults, ok := layouts[defaultsKey]
+	if ok {
+		delete(layouts, defaultsKey)
+		if len(defaults.Versions) > 0 || defaults.Extends != "" {
+			return fmt.Errorf("%s can't have versions or extend browser", defaultsKey)
+		}
+	}
+
+	resolved := make(map[string]bool)
+	var resolve func(name string, chain []string) error
+	resolve = func(name string, chain []string) error {
+		if done, ok := resolved[name]; ok {
+			if !done {
+				return fmt.Errorf("cyclic extends of %s: %s", name, strings.Join(append(chain, name), " -> "))
+			}
+			return nil
+		}
+		resolved[name] = false
+
+		layout := layouts[name]
+		parent := defaults
+		if layout.Extends != "" {
+			var ok bool
+			if parent, ok = layouts[layout.Extends]; !ok {
+				return fmt.Errorf("unknown browser %s extended by %s", layout.Extends, name)
+			}
+			if err := resolve(layout.Extends, append(chain, name)); err != nil {
+				return err
+			}
+		}
+		if parent != nil {
+			if err := inherit(layout, *parent); err != nil {
+				return fmt.Errorf("failed to inherit layout of %s: %v", name, err)
+			}
+		}
+		resolved[name] = true
+		return nil
+	}
+
+	names := make([]string, 0, len(layouts))
+	for name := range layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := resolve(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//inherit fills fields of layout not set with fields of parent, meta of parent is copied as version
+//meta is merged into meta of layout later
+func inherit(layout *Layout, parent Layout) error {
+	parent.Extends = ""
+	parent.DefaultVersion = ""
+	parent.Versions = nil
+	parent.Meta = platform.Meta{
+		Labels:      merge(parent.Meta.Labels, make(map[string]string)),
+		Annotations: merge(parent.Meta.Annotations, make(map[string]string)),
+	}
+	if layout.Meta.Labels == nil {
+		layout.Meta.Labels = make(map[string]string)
+	}
+	if layout.Meta.Annotations == nil {
+		layout.Meta.Annotations = make(map[string]string)
+	}
+	return mergo.Merge(layout, parent)
+}
+
 func merge(from, to map[string]string) map[string]string {
 	for k, v := range from {
 		to[k] = v
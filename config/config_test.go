@@ -60,6 +60,67 @@ func TestConfigFileData(t *testing.T) {
 			config: "browsers.yaml",
 			err:    errors.New("failed to read config: empty config: <nil>"),
 		},
+		"verify negative warm pool is not allowed": {
+			data: `---
+chrome:
+  defaultVersion: "85.0"
+  warmPool: -1
+  versions:
+    "85.0":
+      image: selenoid/vnc:chrome_85.0
+`,
+			config: "browsers.yaml",
+			err:    errors.New("failed to read config: invalid warm pool size of chrome 85.0: -1"),
+		},
+		"verify negative max sessions is not allowed": {
+			data: `---
+chrome:
+  defaultVersion: "85.0"
+  versions:
+    "85.0":
+      image: selenoid/vnc:chrome_85.0
+      maxSessions: -2
+`,
+			config: "browsers.yaml",
+			err:    errors.New("failed to read config: invalid max sessions of chrome 85.0: -2"),
+		},
+		"verify unknown browser type is not allowed": {
+			data: `---
+chrome:
+  type: webkit
+  versions:
+    "85.0":
+      image: selenoid/vnc:chrome_85.0
+`,
+			config: "browsers.yaml",
+			err:    errors.New("failed to read config: unknown type webkit of chrome 85.0"),
+		},
+		"verify invalid vm memory is not allowed": {
+			data: `---
+edge:
+  platform: windows
+  vm:
+    memory: lots
+  versions:
+    "18.0":
+      image: acme/edge-vm:18.0
+`,
+			config: "browsers.yaml",
+			err:    errors.New("failed to read config: invalid vm of edge 18.0: invalid memory lots: quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'"),
+		},
+		"verify exec readiness without command is not allowed": {
+			data: `---
+chrome:
+  readiness:
+    type: exec
+    timeout: 2m
+  versions:
+    "85.0":
+      image: selenoid/vnc:chrome_85.0
+`,
+			config: "browsers.yaml",
+			err:    errors.New("failed to read config: invalid readiness of chrome 85.0: command of exec readiness is not set"),
+		},
 	}
 
 	for name, test := range tests {
@@ -231,6 +292,374 @@ func TestConfigSearch(t *testing.T) {
 
 }
 
+func TestConfigFindDevice(t *testing.T) {
+	f := configfile(`---
+chrome:
+  versions:
+    "85.0":
+      image: selenoid/vnc:chrome_85.0
+Pixel_4:
+  type: appium
+  path: /wd/hub
+  defaultVersion: "11.0"
+  versions:
+    "11.0":
+      image: budtmo/docker-android-x86-11.0
+`, "browsers.yaml")
+	defer os.Remove(f)
+	c, err := NewBrowsersConfig(f)
+	assert.Nil(t, err)
+
+	tests := map[string]struct {
+		device  string
+		version string
+		err     error
+	}{
+		"verify appium device is found": {
+			device: "Pixel_4",
+		},
+		"verify appium device version is inherited": {
+			device:  "Pixel_4",
+			version: "11.0",
+		},
+		"verify browser is not matched as device": {
+			device:  "chrome",
+			version: "85.0",
+			err:     errors.New("browser chrome is not an appium device"),
+		},
+		"verify unknown device is not found": {
+			device: "Pixel_5",
+			err:    errors.New("unknown browser name Pixel_5"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+		browser, err := c.FindDevice(test.device, test.version, "")
+		assert.Equal(t, test.err, err)
+		if err == nil {
+			assert.Equal(t, "appium", browser.Type)
+			assert.Equal(t, "/wd/hub", browser.Path)
+		}
+	}
+}
+
+func TestConfigFindOnPlatform(t *testing.T) {
+	f := configfile(`---
+MicrosoftEdge:
+  defaultVersion: "90.0"
+  versions:
+    "18.0":
+      image: browsers/edge:18.0
+      platform: windows
+    "19.0":
+      image: browsers/edge:19.0
+      platform: windows
+    "90.0":
+      image: browsers/edge:90.0
+Pixel_4:
+  type: appium
+  versions:
+    "11.0":
+      image: budtmo/docker-android-x86-11.0
+`, "browsers.yaml")
+	defer os.Remove(f)
+	c, err := NewBrowsersConfig(f)
+	assert.Nil(t, err)
+
+	tests := map[string]struct {
+		browser  string
+		version  string
+		platform string
+		found    string
+		err      error
+	}{
+		"verify any platform matches default version": {
+			browser:  "MicrosoftEdge",
+			platform: "ANY",
+			found:    "90.0",
+		},
+		"verify browser without platform runs on linux": {
+			browser:  "MicrosoftEdge",
+			platform: "linux",
+			found:    "90.0",
+		},
+		"verify latest version of platform is used when default runs on other platform": {
+			browser:  "MicrosoftEdge",
+			platform: "windows",
+			found:    "19.0",
+		},
+		"verify selenium windows platform names are matched": {
+			browser:  "MicrosoftEdge",
+			version:  "18.0",
+			platform: "WIN10",
+			found:    "18.0",
+		},
+		"verify requested version of other platform is not matched": {
+			browser:  "MicrosoftEdge",
+			version:  "18.0",
+			platform: "linux",
+			err:      errors.New("browser MicrosoftEdge 18.0 is not available on linux platform"),
+		},
+		"verify browser without versions of platform is not matched": {
+			browser:  "MicrosoftEdge",
+			platform: "android",
+			err:      errors.New("browser MicrosoftEdge is not available on android platform"),
+		},
+		"verify appium device runs on android": {
+			browser:  "Pixel_4",
+			version:  "11.0",
+			platform: "Android",
+			found:    "11.0",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+		browser, err := c.FindOnPlatform(test.browser, test.version, test.platform)
+		assert.Equal(t, test.err, err)
+		assert.Equal(t, test.found, browser.BrowserVersion)
+	}
+}
+
+func TestConfigAffinity(t *testing.T) {
+	f := configfile(`---
+chrome:
+  defaultVersion: "85.0"
+  spec:
+    affinity:
+      podAntiAffinity:
+        preferredDuringSchedulingIgnoredDuringExecution:
+        - weight: 100
+          podAffinityTerm:
+            labelSelector:
+              matchLabels:
+                selenosis.app.type: browser
+            topologyKey: topology.kubernetes.io/zone
+  versions:
+    "85.0":
+      image: selenoid/vnc:chrome_85.0
+    "86.0":
+      image: selenoid/vnc:chrome_86.0
+      spec:
+        affinity:
+          nodeAffinity:
+            requiredDuringSchedulingIgnoredDuringExecution:
+              nodeSelectorTerms:
+              - matchExpressions:
+                - key: cloud.google.com/gke-nodepool
+                  operator: In
+                  values: ["gpu"]
+`, "browsers.yaml")
+	defer os.Remove(f)
+	c, err := NewBrowsersConfig(f)
+	assert.Nil(t, err)
+
+	tests := map[string]struct {
+		version  string
+		nodePool bool
+	}{
+		"verify browser pod anti affinity is inherited": {
+			version: "85.0",
+		},
+		"verify version node affinity is merged with browser pod anti affinity": {
+			version:  "86.0",
+			nodePool: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+		browser, err := c.Find("chrome", test.version)
+		assert.Nil(t, err)
+		affinity := browser.Spec.Affinity
+		assert.Equal(t, "topology.kubernetes.io/zone", affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm.TopologyKey)
+		assert.Equal(t, test.nodePool, affinity.NodeAffinity != nil)
+	}
+}
+
+func TestConfigInheritance(t *testing.T) {
+	f := configfile(`---
+defaults:
+  path: /
+  meta:
+    labels:
+      team: qa
+  spec:
+    resources:
+      limits:
+        cpu: "1"
+        memory: 2Gi
+    nodeSelector:
+      pool: browsers
+    tolerations:
+    - key: browsers
+      operator: Exists
+    env:
+    - name: TZ
+      value: UTC
+chrome:
+  defaultVersion: "85.0"
+  spec:
+    resources:
+      limits:
+        memory: 4Gi
+  versions:
+    "85.0":
+      image: selenoid/vnc:chrome_85.0
+    "86.0":
+      image: selenoid/vnc:chrome_86.0
+      meta:
+        labels:
+          beta: "true"
+chrome-beta:
+  extends: chrome
+  path: /wd/hub
+  versions:
+    "87.0":
+      image: selenoid/vnc:chrome_87.0
+firefox:
+  spec:
+    env:
+    - name: LANG
+      value: de_DE.UTF-8
+  versions:
+    "82.0":
+      image: selenoid/vnc:firefox_82.0
+`, "browsers.yaml")
+	defer os.Remove(f)
+	c, err := NewBrowsersConfig(f)
+	assert.Nil(t, err)
+
+	tests := map[string]struct {
+		name    string
+		version string
+		path    string
+		memory  string
+		env     string
+		labels  map[string]string
+	}{
+		"verify defaults are merged into browser version": {
+			name:    "chrome",
+			version: "85.0",
+			path:    "/",
+			memory:  "4Gi",
+			env:     "TZ",
+			labels:  map[string]string{"team": "qa"},
+		},
+		"verify version labels are not shared with other versions": {
+			name:    "chrome",
+			version: "86.0",
+			path:    "/",
+			memory:  "4Gi",
+			env:     "TZ",
+			labels:  map[string]string{"team": "qa", "beta": "true"},
+		},
+		"verify browser extends layout of other browser": {
+			name:    "chrome-beta",
+			version: "87.0",
+			path:    "/wd/hub",
+			memory:  "4Gi",
+			env:     "TZ",
+			labels:  map[string]string{"team": "qa"},
+		},
+		"verify browser env replaces env of defaults": {
+			name:    "firefox",
+			version: "82.0",
+			path:    "/",
+			memory:  "2Gi",
+			env:     "LANG",
+			labels:  map[string]string{"team": "qa"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+		browser, err := c.Find(test.name, test.version)
+		assert.Nil(t, err)
+		assert.Equal(t, test.path, browser.Path)
+		assert.Equal(t, "1", browser.Spec.Resources.Limits.Cpu().String())
+		assert.Equal(t, test.memory, browser.Spec.Resources.Limits.Memory().String())
+		assert.Equal(t, map[string]string{"pool": "browsers"}, browser.Spec.NodeSelector)
+		assert.Equal(t, "browsers", browser.Spec.Tolerations[0].Key)
+		assert.Equal(t, test.env, browser.Spec.EnvVars[0].Name)
+		assert.Equal(t, test.labels, browser.Meta.Labels)
+	}
+
+	_, ok := c.GetBrowserVersions()["defaults"]
+	assert.False(t, ok)
+	_, err = c.Find("chrome-beta", "85.0")
+	assert.Equal(t, errors.New("unknown browser version 85.0"), err)
+}
+
+func TestConfigPodOverride(t *testing.T) {
+	tests := map[string]struct {
+		data    string
+		overlay map[string]interface{}
+		err     error
+	}{
+		"verify browser pod override is parsed into overlay of versions": {
+			data: `{"chrome": {"defaultVersion": "85.0", "podOverride": "spec:\n  priorityClassName: browsers\n", "versions": {"85.0": {"image": "selenoid/vnc:chrome_85.0"}}}}`,
+			overlay: map[string]interface{}{
+				"spec": map[string]interface{}{"priorityClassName": "browsers"},
+			},
+		},
+		"verify version pod override replaces browser overlay": {
+			data: `{"chrome": {"defaultVersion": "85.0", "podOverlay": {"spec": {"priorityClassName": "browsers"}}, "versions": {"85.0": {"image": "selenoid/vnc:chrome_85.0", "podOverride": "spec:\n  hostname: chrome\n"}}}}`,
+			overlay: map[string]interface{}{
+				"spec": map[string]interface{}{"hostname": "chrome"},
+			},
+		},
+		"verify pod overlay and override can't be set both": {
+			data: `{"chrome": {"defaultVersion": "85.0", "podOverlay": {"spec": {"priorityClassName": "browsers"}}, "podOverride": "spec:\n  hostname: chrome\n", "versions": {"85.0": {"image": "selenoid/vnc:chrome_85.0"}}}}`,
+			err:  errors.New("failed to read config: invalid pod overlay of chrome: podOverlay and podOverride can't be set both"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+		f := configfile(test.data, "browsers.json")
+		c, err := NewBrowsersConfig(f)
+		os.Remove(f)
+		assert.Equal(t, test.err, err)
+		if err != nil {
+			continue
+		}
+		browser, err := c.Find("chrome", "85.0")
+		assert.Nil(t, err)
+		assert.Equal(t, test.overlay, browser.PodOverlay)
+		assert.Equal(t, "", browser.PodOverride)
+	}
+}
+
+func TestConfigInheritanceErrors(t *testing.T) {
+	tests := map[string]struct {
+		data string
+		err  error
+	}{
+		"verify unknown extended browser is not allowed": {
+			data: `{"chrome": {"extends": "chromium", "versions": {"85.0": {"image": "selenoid/vnc:chrome_85.0"}}}}`,
+			err:  errors.New("failed to read config: unknown browser chromium extended by chrome"),
+		},
+		"verify cyclic extends is not allowed": {
+			data: `{"chrome": {"extends": "opera", "versions": {}}, "opera": {"extends": "chrome", "versions": {}}}`,
+			err:  errors.New("failed to read config: cyclic extends of chrome: chrome -> opera -> chrome"),
+		},
+		"verify defaults with versions are not allowed": {
+			data: `{"defaults": {"versions": {"85.0": {"image": "selenoid/vnc:chrome_85.0"}}}}`,
+			err:  errors.New("failed to read config: defaults can't have versions or extend browser"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+		f := configfile(test.data, "browsers.json")
+		_, err := NewBrowsersConfig(f)
+		os.Remove(f)
+		assert.Equal(t, test.err, err)
+	}
+}
+
 func TestMapMerge(t *testing.T) {
 	tests := map[string]struct {
 		from     map[string]string
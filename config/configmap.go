@@ -0,0 +1,97 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+//NewConfigMapClient returns in cluster client to watch ConfigMaps with
+func NewConfigMapClient() (kubernetes.Interface, error) {
+	conf, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build cluster config: %v", err)
+	}
+
+	client, err := kubernetes.NewForConfig(conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build client: %v", err)
+	}
+	return client, nil
+}
+
+//ConfigMapWatcher loads browsers config from ConfigMap key every time the key is changed, so
+//updates are applied without waiting for kubelet to sync mounted ConfigMap and without restart
+type ConfigMapWatcher struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+	key       string
+	resync    time.Duration
+	last      []byte
+}
+
+//NewConfigMapWatcher ...
+func NewConfigMapWatcher(client kubernetes.Interface, namespace, name, key string, resync time.Duration) *ConfigMapWatcher {
+	return &ConfigMapWatcher{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+		key:       key,
+		resync:    resync,
+	}
+}
+
+//Run loads ConfigMap content into browsers config and blocks until stop is closed, onLoad is
+//called with result of every load. Invalid content is reported and keeps previous config in use
+func (w *ConfigMapWatcher) Run(stop <-chan struct{}, browsers *BrowsersConfig, onLoad func(error)) {
+	factory := informers.NewSharedInformerFactoryWithOptions(w.client, w.resync,
+		informers.WithNamespace(w.namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", w.name).String()
+		}),
+	)
+	informer := factory.Core().V1().ConfigMaps().Informer()
+
+	load := func(obj interface{}) {
+		cm, ok := obj.(*apiv1.ConfigMap)
+		if !ok {
+			return
+		}
+		if err := w.load(cm, browsers); err != errUnchanged {
+			onLoad(err)
+		}
+	}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: load,
+		UpdateFunc: func(_ interface{}, obj interface{}) {
+			load(obj)
+		},
+	})
+
+	factory.Start(stop)
+	<-stop
+}
+
+var errUnchanged = fmt.Errorf("config is not changed")
+
+//load applies content of watched key, resyncs and changes of other keys return errUnchanged
+func (w *ConfigMapWatcher) load(cm *apiv1.ConfigMap, browsers *BrowsersConfig) error {
+	content, ok := cm.Data[w.key]
+	if !ok {
+		return fmt.Errorf("configmap %s/%s has no key %s", cm.Namespace, cm.Name, w.key)
+	}
+	if w.last != nil && bytes.Equal(w.last, []byte(content)) {
+		return errUnchanged
+	}
+	w.last = []byte(content)
+	return browsers.Load([]byte(content))
+}
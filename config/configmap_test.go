@@ -0,0 +1,76 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConfigMapLoad(t *testing.T) {
+	chrome := `{"chrome": {"defaultVersion": "85.0", "path": "/", "versions": {"85.0": {"image": "selenoid/vnc:chrome_85.0"}}}}`
+	firefox := `{"firefox": {"defaultVersion": "80.0", "path": "/wd/hub", "versions": {"80.0": {"image": "selenoid/vnc:firefox_80.0"}}}}`
+
+	f := configfile(chrome, "browsers.json")
+	defer os.Remove(f)
+	browsers, err := NewBrowsersConfig(f)
+	assert.Nil(t, err)
+
+	watcher := NewConfigMapWatcher(nil, "selenosis", "selenosis-config", "browsers.json", 0)
+	configMap := func(data map[string]string) *apiv1.ConfigMap {
+		return &apiv1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "selenosis-config", Namespace: "selenosis"},
+			Data:       data,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		data     map[string]string
+		err      error
+		browsers []string
+	}{
+		{
+			name:     "verify browsers config is replaced by configmap content",
+			data:     map[string]string{"browsers.json": firefox},
+			browsers: []string{"firefox"},
+		},
+		{
+			name:     "verify not changed content is not loaded again",
+			data:     map[string]string{"browsers.json": firefox, "other": "value"},
+			err:      errUnchanged,
+			browsers: []string{"firefox"},
+		},
+		{
+			name:     "verify invalid content keeps previous config",
+			data:     map[string]string{"browsers.json": `{}`},
+			err:      errors.New("failed to read config: empty config: <nil>"),
+			browsers: []string{"firefox"},
+		},
+		{
+			name:     "verify missing key keeps previous config",
+			data:     map[string]string{"other": "value"},
+			err:      errors.New("configmap selenosis/selenosis-config has no key browsers.json"),
+			browsers: []string{"firefox"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Logf("TC: %s", test.name)
+		err := watcher.load(configMap(test.data), browsers)
+		assert.Equal(t, test.err, err)
+
+		var names []string
+		for name := range browsers.GetBrowserVersions() {
+			names = append(names, name)
+		}
+		assert.Equal(t, test.browsers, names)
+	}
+
+	assert.Nil(t, browsers.Reload())
+	_, err = browsers.Find("firefox", "80.0")
+	assert.Nil(t, err, "reload should reparse loaded content instead of config file")
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+//Quotas limits number of sessions per browser, browser version and client, zero limit means no limit
+type Quotas struct {
+	Browsers map[string]BrowserQuota `yaml:"browsers" json:"browsers"`
+	Clients  ClientQuotas            `yaml:"clients" json:"clients"`
+}
+
+//BrowserQuota limits sessions of all versions of the browser and of its single versions
+type BrowserQuota struct {
+	Limit    int            `yaml:"limit" json:"limit"`
+	Versions map[string]int `yaml:"versions" json:"versions"`
+}
+
+//ClientQuotas limits sessions of every client, client is identified by header value or by
+//authenticated user when header is not set
+type ClientQuotas struct {
+	Header  string         `yaml:"header" json:"header"`
+	Default int            `yaml:"default" json:"default"`
+	Limits  map[string]int `yaml:"limits" json:"limits"`
+}
+
+//Limit returns session limit of the client
+func (c ClientQuotas) Limit(client string) int {
+	if limit, ok := c.Limits[client]; ok {
+		return limit
+	}
+	return c.Default
+}
+
+//QuotasConfig ...
+type QuotasConfig struct {
+	configFile string
+	lock       sync.RWMutex
+	quotas     Quotas
+}
+
+//NewQuotasConfig returns parsed quotas config from JSON or YAML file.
+func NewQuotasConfig(configFile string) (*QuotasConfig, error) {
+	quotas, err := readQuotas(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QuotasConfig{
+		configFile: configFile,
+		quotas:     quotas,
+	}, nil
+}
+
+//Reload rereads quotas, new limits apply to new sessions only
+func (cfg *QuotasConfig) Reload() error {
+	quotas, err := readQuotas(cfg.configFile)
+	if err != nil {
+		return err
+	}
+
+	cfg.lock.Lock()
+	defer cfg.lock.Unlock()
+	cfg.quotas = quotas
+	return nil
+}
+
+//Get ...
+func (cfg *QuotasConfig) Get() Quotas {
+	cfg.lock.RLock()
+	defer cfg.lock.RUnlock()
+
+	return cfg.quotas
+}
+
+func readQuotas(configFile string) (Quotas, error) {
+	content, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return Quotas{}, fmt.Errorf("failed to read config: read error: %v", err)
+	}
+
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), 1000)
+
+	var quotas Quotas
+	if err := decoder.Decode(&quotas); err != nil {
+		return Quotas{}, fmt.Errorf("failed to read config: parse error: %v", err)
+	}
+
+	if len(quotas.Browsers) == 0 && quotas.Clients.Default == 0 && len(quotas.Clients.Limits) == 0 {
+		return Quotas{}, fmt.Errorf("failed to read config: empty config")
+	}
+
+	for name, browser := range quotas.Browsers {
+		if browser.Limit < 0 {
+			return Quotas{}, fmt.Errorf("browser %s: limit should not be negative", name)
+		}
+		for version, limit := range browser.Versions {
+			if limit < 0 {
+				return Quotas{}, fmt.Errorf("browser %s: version %s: limit should not be negative", name, version)
+			}
+		}
+	}
+	if quotas.Clients.Default < 0 {
+		return Quotas{}, fmt.Errorf("clients: default limit should not be negative")
+	}
+	for client, limit := range quotas.Clients.Limits {
+		if limit < 0 {
+			return Quotas{}, fmt.Errorf("client %s: limit should not be negative", client)
+		}
+	}
+	return quotas, nil
+}
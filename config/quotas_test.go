@@ -0,0 +1,77 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotasConfigData(t *testing.T) {
+
+	tests := map[string]struct {
+		data string
+		err  error
+	}{
+		"verify valid quotas config": {
+			data: `---
+browsers:
+  chrome:
+    limit: 10
+    versions:
+      "85.0": 2
+clients:
+  header: X-Auth-User
+  default: 3
+  limits:
+    nightly: 20
+`,
+		},
+		"verify empty quotas config is not allowed": {
+			data: `---`,
+			err:  errors.New("failed to read config: empty config"),
+		},
+		"verify negative browser limit is not allowed": {
+			data: `---
+browsers:
+  chrome:
+    limit: -1
+`,
+			err: errors.New("browser chrome: limit should not be negative"),
+		},
+		"verify negative version limit is not allowed": {
+			data: `---
+browsers:
+  chrome:
+    versions:
+      "85.0": -1
+`,
+			err: errors.New("browser chrome: version 85.0: limit should not be negative"),
+		},
+		"verify negative client limit is not allowed": {
+			data: `---
+clients:
+  limits:
+    nightly: -1
+`,
+			err: errors.New("client nightly: limit should not be negative"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+		f := configfile(test.data, "quotas.yaml")
+		defer os.Remove(f)
+		_, err := NewQuotasConfig(f)
+		assert.Equal(t, test.err, err)
+	}
+}
+
+func TestClientQuotasLimit(t *testing.T) {
+	clients := ClientQuotas{Default: 3, Limits: map[string]int{"nightly": 20, "unlimited": 0}}
+
+	assert.Equal(t, 20, clients.Limit("nightly"))
+	assert.Equal(t, 0, clients.Limit("unlimited"))
+	assert.Equal(t, 3, clients.Limit("alice"))
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"sync"
+
+	"github.com/alcounit/selenosis/platform"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+//Tenant describes team which sessions are isolated in dedicated namespace
+type Tenant struct {
+	Name         string             `yaml:"-" json:"-"`
+	Namespace    string             `yaml:"namespace" json:"namespace"`
+	SessionLimit int                `yaml:"sessionLimit" json:"sessionLimit"`
+	Users        map[string]string  `yaml:"users" json:"users"`
+	Artifacts    platform.Artifacts `yaml:"artifacts,omitempty" json:"artifacts,omitempty"`
+}
+
+//TenantsConfig ...
+type TenantsConfig struct {
+	configFile string
+	lock       sync.RWMutex
+	tenants    map[string]*Tenant
+	users      map[string]*Tenant
+}
+
+//NewTenantsConfig returns parced tenants config from JSON or YAML file.
+func NewTenantsConfig(configFile string) (*TenantsConfig, error) {
+	tenants, users, err := readTenants(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TenantsConfig{
+		configFile: configFile,
+		tenants:    tenants,
+		users:      users,
+	}, nil
+}
+
+//Reload rereads users, session limits and artifacts of tenants, tenants are created with
+//their namespaces on start so adding, removing tenants or changing namespaces needs restart
+func (cfg *TenantsConfig) Reload() error {
+	tenants, users, err := readTenants(cfg.configFile)
+	if err != nil {
+		return err
+	}
+
+	cfg.lock.Lock()
+	defer cfg.lock.Unlock()
+
+	for name, tenant := range tenants {
+		current, ok := cfg.tenants[name]
+		if !ok {
+			return fmt.Errorf("tenant %s: adding tenants requires restart", name)
+		}
+		if current.Namespace != tenant.Namespace {
+			return fmt.Errorf("tenant %s: changing namespace requires restart", name)
+		}
+	}
+	for name := range cfg.tenants {
+		if _, ok := tenants[name]; !ok {
+			return fmt.Errorf("tenant %s: removing tenants requires restart", name)
+		}
+	}
+
+	cfg.tenants = tenants
+	cfg.users = users
+	return nil
+}
+
+func readTenants(configFile string) (map[string]*Tenant, map[string]*Tenant, error) {
+	content, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config: read error: %v", err)
+	}
+
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), 1000)
+
+	tenants := make(map[string]*Tenant)
+	if err := decoder.Decode(&tenants); err != nil {
+		return nil, nil, fmt.Errorf("failed to read config: parse error: %v", err)
+	}
+
+	if len(tenants) == 0 {
+		return nil, nil, fmt.Errorf("failed to read config: empty config")
+	}
+
+	users := make(map[string]*Tenant)
+	namespaces := make(map[string]*Tenant)
+	for name, tenant := range tenants {
+		tenant.Name = name
+		if name == "default" {
+			return nil, nil, fmt.Errorf("tenant %s: name is reserved for sessions without tenant", name)
+		}
+		if tenant.Namespace == "" {
+			return nil, nil, fmt.Errorf("tenant %s: namespace is not set", name)
+		}
+		if t, ok := namespaces[tenant.Namespace]; ok {
+			return nil, nil, fmt.Errorf("tenant %s: namespace %s already belongs to tenant %s", name, tenant.Namespace, t.Name)
+		}
+		namespaces[tenant.Namespace] = tenant
+		if tenant.SessionLimit <= 0 {
+			return nil, nil, fmt.Errorf("tenant %s: session limit should be greater than 0", name)
+		}
+		if err := tenant.Artifacts.Validate(); err != nil {
+			return nil, nil, fmt.Errorf("tenant %s: %v", name, err)
+		}
+		for user := range tenant.Users {
+			if t, ok := users[user]; ok {
+				return nil, nil, fmt.Errorf("tenant %s: user %s already belongs to tenant %s", name, user, t.Name)
+			}
+			users[user] = tenant
+		}
+	}
+	return tenants, users, nil
+}
+
+//Authenticate returns tenant of the user if credentials are valid
+func (cfg *TenantsConfig) Authenticate(user, password string) (Tenant, bool) {
+	cfg.lock.RLock()
+	defer cfg.lock.RUnlock()
+
+	tenant, ok := cfg.users[user]
+	if !ok {
+		return Tenant{}, false
+	}
+	if subtle.ConstantTimeCompare([]byte(tenant.Users[user]), []byte(password)) != 1 {
+		return Tenant{}, false
+	}
+	return *tenant, true
+}
+
+//Get ...
+func (cfg *TenantsConfig) Get(name string) (Tenant, bool) {
+	cfg.lock.RLock()
+	defer cfg.lock.RUnlock()
+
+	tenant, ok := cfg.tenants[name]
+	if !ok {
+		return Tenant{}, false
+	}
+	return *tenant, true
+}
+
+//ByNamespace returns tenant owning the namespace
+func (cfg *TenantsConfig) ByNamespace(namespace string) (Tenant, bool) {
+	cfg.lock.RLock()
+	defer cfg.lock.RUnlock()
+
+	for _, tenant := range cfg.tenants {
+		if tenant.Namespace == namespace {
+			return *tenant, true
+		}
+	}
+	return Tenant{}, false
+}
+
+//List returns tenants sorted by name
+func (cfg *TenantsConfig) List() []Tenant {
+	cfg.lock.RLock()
+	defer cfg.lock.RUnlock()
+
+	tenants := make([]Tenant, 0, len(cfg.tenants))
+	for _, tenant := range cfg.tenants {
+		tenants = append(tenants, *tenant)
+	}
+	sort.Slice(tenants, func(i, j int) bool {
+		return tenants[i].Name < tenants[j].Name
+	})
+	return tenants
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantsConfigData(t *testing.T) {
+
+	tests := map[string]struct {
+		data string
+		err  error
+	}{
+		"verify valid tenants config": {
+			data: `---
+contractors:
+  namespace: selenosis-contractors
+  sessionLimit: 5
+  users:
+    acme: secret
+`,
+		},
+		"verify empty tenants config is not allowed": {
+			data: `---`,
+			err:  errors.New("failed to read config: empty config"),
+		},
+		"verify tenant without namespace is not allowed": {
+			data: `---
+contractors:
+  sessionLimit: 5
+`,
+			err: errors.New("tenant contractors: namespace is not set"),
+		},
+		"verify tenant without session limit is not allowed": {
+			data: `---
+contractors:
+  namespace: selenosis-contractors
+`,
+			err: errors.New("tenant contractors: session limit should be greater than 0"),
+		},
+		"verify reserved tenant name is not allowed": {
+			data: `---
+default:
+  namespace: selenosis-default
+  sessionLimit: 5
+`,
+			err: errors.New("tenant default: name is reserved for sessions without tenant"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+		f := configfile(test.data, "tenants.yaml")
+		defer os.Remove(f)
+		_, err := NewTenantsConfig(f)
+		assert.Equal(t, test.err, err)
+	}
+}
+
+func TestTenantsAuthenticate(t *testing.T) {
+
+	tests := map[string]struct {
+		user     string
+		password string
+		tenant   string
+		ok       bool
+	}{
+		"verify user authenticated": {
+			user:     "acme",
+			password: "secret",
+			tenant:   "contractors",
+			ok:       true,
+		},
+		"verify user with wrong password is not authenticated": {
+			user:     "acme",
+			password: "wrong",
+		},
+		"verify unknown user is not authenticated": {
+			user:     "unknown",
+			password: "secret",
+		},
+	}
+
+	f := configfile(`---
+contractors:
+  namespace: selenosis-contractors
+  sessionLimit: 5
+  users:
+    acme: secret
+  artifacts:
+    url: s3://contractors/selenosis
+    credentialsSecret: contractors-s3
+    videos:
+      url: s3://contractors-videos
+qa:
+  namespace: selenosis-qa
+  sessionLimit: 10
+`, "tenants.yaml")
+	defer os.Remove(f)
+
+	cfg, err := NewTenantsConfig(f)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(cfg.List()))
+
+	contractors, ok := cfg.Get("contractors")
+	assert.True(t, ok)
+	assert.Equal(t, "s3://contractors/selenosis", contractors.Artifacts.URL)
+	assert.Equal(t, "contractors-s3", contractors.Artifacts.CredentialsSecret)
+	assert.Equal(t, "s3://contractors-videos", contractors.Artifacts.Videos.URL)
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+		tenant, ok := cfg.Authenticate(test.user, test.password)
+		assert.Equal(t, test.ok, ok)
+		assert.Equal(t, test.tenant, tenant.Name)
+	}
+}
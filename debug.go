@@ -0,0 +1,60 @@
+package selenosis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/alcounit/selenosis/audit"
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/tools"
+	"github.com/gorilla/mux"
+)
+
+//HandleDebug attaches ephemeral debug container to browser pod of the session, so network and DNS of live
+//session can be inspected, --debug-image is used unless request sets own image and command
+func (app *App) HandleDebug(w http.ResponseWriter, r *http.Request) {
+	sessionID := mux.Vars(r)["sessionId"]
+
+	debugger, ok := app.client.(platform.Debugger)
+	if !ok {
+		tools.JSONError(w, platform.ErrDebugNotSupported.Error(), http.StatusNotImplemented)
+		return
+	}
+	if _, ok := app.stats.Sessions().Get(sessionID); !ok {
+		tools.JSONError(w, fmt.Sprintf("unknown session %s", sessionID), http.StatusNotFound)
+		return
+	}
+
+	var spec platform.DebugSpec
+	if err := json.NewDecoder(r.Body).Decode(&spec); err != nil && err != io.EOF {
+		tools.JSONError(w, fmt.Sprintf("invalid debug container: %v", err), http.StatusBadRequest)
+		return
+	}
+	if spec.Image == "" {
+		spec.Image = app.debugImage
+	}
+
+	logger := app.logger.WithField("session_id", sessionID)
+	container, err := debugger.Debug(sessionID, spec)
+	switch {
+	case err == platform.ErrDebugNotSupported:
+		tools.JSONError(w, err.Error(), http.StatusNotImplemented)
+		return
+	case err == platform.ErrSessionNotFound:
+		tools.JSONError(w, fmt.Sprintf("unknown session %s", sessionID), http.StatusNotFound)
+		return
+	case err != nil:
+		logger.Errorf("failed to attach debug container: %v", err)
+		tools.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Infof("debug container %s attached, image: %s", container.Name, container.Image)
+	app.auditSessionRequest(r, audit.SessionDebugged, sessionID, fmt.Sprintf("debug container %s of image %s", container.Name, container.Image))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(container)
+}
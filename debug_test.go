@@ -0,0 +1,106 @@
+package selenosis
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/gorilla/mux"
+	"gotest.tools/assert"
+)
+
+type debuggerMock struct {
+	*PlatformMock
+	err  error
+	spec platform.DebugSpec
+}
+
+func (p *debuggerMock) Debug(sessionID string, spec platform.DebugSpec) (platform.DebugContainer, error) {
+	p.spec = spec
+	if p.err != nil {
+		return platform.DebugContainer{}, p.err
+	}
+	return platform.DebugContainer{Name: "debug-1", Image: spec.Image, Pod: sessionID, Namespace: "selenosis"}, nil
+}
+
+func TestHandleDebug(t *testing.T) {
+	tests := map[string]struct {
+		client    platform.Platform
+		sessionID string
+		body      io.Reader
+		respCode  int
+		respBody  string
+		spec      platform.DebugSpec
+	}{
+		"Verify debug container of default image is attached": {
+			client:    &debuggerMock{PlatformMock: &PlatformMock{}},
+			sessionID: "chrome-85-0-known",
+			body:      http.NoBody,
+			respCode:  http.StatusCreated,
+			respBody:  `{"name":"debug-1","image":"nicolaka/netshoot:latest","pod":"chrome-85-0-known","namespace":"selenosis","attach":""}`,
+			spec:      platform.DebugSpec{Image: "nicolaka/netshoot:latest"},
+		},
+		"Verify debug container of requested image and command is attached": {
+			client:    &debuggerMock{PlatformMock: &PlatformMock{}},
+			sessionID: "chrome-85-0-known",
+			body:      strings.NewReader(`{"image":"busybox","command":["sh"]}`),
+			respCode:  http.StatusCreated,
+			respBody:  `{"name":"debug-1","image":"busybox","pod":"chrome-85-0-known","namespace":"selenosis","attach":""}`,
+			spec:      platform.DebugSpec{Image: "busybox", Command: []string{"sh"}},
+		},
+		"Verify debug of unknown session is rejected": {
+			client:    &debuggerMock{PlatformMock: &PlatformMock{}},
+			sessionID: "chrome-85-0-unknown",
+			body:      http.NoBody,
+			respCode:  http.StatusNotFound,
+			respBody:  `{"code":404,"value":{"message":"unknown session chrome-85-0-unknown"}}`,
+		},
+		"Verify invalid debug container is rejected": {
+			client:    &debuggerMock{PlatformMock: &PlatformMock{}},
+			sessionID: "chrome-85-0-known",
+			body:      strings.NewReader(`{"image":`),
+			respCode:  http.StatusBadRequest,
+			respBody:  `{"code":400,"value":{"message":"invalid debug container: unexpected EOF"}}`,
+		},
+		"Verify debug platform error is returned": {
+			client:    &debuggerMock{PlatformMock: &PlatformMock{}, err: errors.New("pod is Pending, debug container needs running pod")},
+			sessionID: "chrome-85-0-known",
+			body:      http.NoBody,
+			respCode:  http.StatusInternalServerError,
+			respBody:  `{"code":500,"value":{"message":"pod is Pending, debug container needs running pod"}}`,
+			spec:      platform.DebugSpec{Image: "nicolaka/netshoot:latest"},
+		},
+		"Verify debug is not supported by platform without pods": {
+			client:    &PlatformMock{},
+			sessionID: "chrome-85-0-known",
+			body:      http.NoBody,
+			respCode:  http.StatusNotImplemented,
+			respBody:  `{"code":501,"value":{"message":"debug containers are not supported by the platform"}}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		app.client = test.client
+		app.debugImage = "nicolaka/netshoot:latest"
+		app.stats.Sessions().Put("chrome-85-0-known", platform.Service{SessionID: "chrome-85-0-known"})
+
+		req := httptest.NewRequest(http.MethodPost, "/debug/"+test.sessionID, test.body)
+		req = mux.SetURLVars(req, map[string]string{"sessionId": test.sessionID})
+		rr := httptest.NewRecorder()
+		app.HandleDebug(rr, req)
+
+		assert.Equal(t, test.respCode, rr.Code)
+		assert.Equal(t, test.respBody, string(bytes.TrimSpace(rr.Body.Bytes())))
+		if debugger, ok := test.client.(*debuggerMock); ok {
+			assert.DeepEqual(t, test.spec, debugger.spec)
+		}
+	}
+}
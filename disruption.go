@@ -0,0 +1,52 @@
+package selenosis
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alcounit/selenosis/metrics"
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/storage"
+	"github.com/alcounit/selenosis/webhook"
+	"github.com/sirupsen/logrus"
+)
+
+//recordLostSession marks running session whose browser pod was disrupted as lost, the next command of the session
+//gets invalid session id error with the reason and the session can be retried like stranded one. Sessions which
+//were not running yet are left to browser start retries, every session is recorded once. Every replica records lost
+//session to answer its commands, only replica with notify set counts and notifies it
+func recordLostSession(logger *logrus.Logger, stats *storage.Storage, notifier *webhook.Notifier, previous, service platform.Service, notify bool) {
+	if service.Disruption == "" || service.Relay || previous.Status != platform.Running {
+		return
+	}
+	if session, ok := stats.Stranded().Get(service.SessionID); ok && session.Lost != "" {
+		return
+	}
+
+	stats.Stranded().Put(service.SessionID, storage.StrandedSession{Service: service, Since: time.Now(), Lost: service.Disruption})
+	if !notify {
+		return
+	}
+	metrics.SessionsLost.WithLabelValues(service.Labels["browserName"], service.Disruption).Inc()
+	event := sessionNotification(webhook.SessionLost, service)
+	event.Message = lostMessage(service.SessionID, service.Disruption)
+	notifier.Notify(event)
+	logger.WithFields(logrus.Fields{
+		"session_id": service.SessionID,
+		"browser":    service.Labels["browserName"],
+		"tenant":     service.Labels["tenant"],
+	}).Warnf("session lost: browser pod disrupted: %s", service.Disruption)
+}
+
+//lostSession returns reason browser pod of the session was lost
+func (app *App) lostSession(sessionID string) (string, bool) {
+	session, ok := app.stats.Stranded().Get(sessionID)
+	if !ok || session.Lost == "" {
+		return "", false
+	}
+	return session.Lost, true
+}
+
+func lostMessage(sessionID, reason string) string {
+	return fmt.Sprintf("session %s was lost, browser pod was disrupted: %s, retry the session with POST /sessions/%s/retry", sessionID, reason, sessionID)
+}
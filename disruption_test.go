@@ -0,0 +1,154 @@
+package selenosis
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/storage"
+	"github.com/alcounit/selenosis/webhook"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+)
+
+func TestRecordLostSession(t *testing.T) {
+	const sessionID = "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491"
+
+	tests := map[string]struct {
+		previous platform.Service
+		service  platform.Service
+		follower bool
+		recorded bool
+		lost     string
+	}{
+		"Verify evicted running session is recorded as lost": {
+			previous: platform.Service{SessionID: sessionID, Status: platform.Running},
+			service:  platform.Service{SessionID: sessionID, Status: platform.Unknown, Disruption: "Evicted"},
+			recorded: true,
+			lost:     "Evicted",
+		},
+		"Verify drained running session is recorded as lost": {
+			previous: platform.Service{SessionID: sessionID, Status: platform.Running},
+			service:  platform.Service{SessionID: sessionID, Status: platform.Running, Disruption: "EvictionByEvictionAPI"},
+			recorded: true,
+			lost:     "EvictionByEvictionAPI",
+		},
+		"Verify follower records lost session without notification": {
+			previous: platform.Service{SessionID: sessionID, Status: platform.Running},
+			service:  platform.Service{SessionID: sessionID, Status: platform.Unknown, Disruption: "Evicted"},
+			follower: true,
+			recorded: true,
+			lost:     "Evicted",
+		},
+		"Verify disrupted pending session is left to browser start retries": {
+			previous: platform.Service{SessionID: sessionID, Status: platform.Pending},
+			service:  platform.Service{SessionID: sessionID, Status: platform.Unknown, Disruption: "Evicted"},
+		},
+		"Verify deleted running session is not lost": {
+			previous: platform.Service{SessionID: sessionID, Status: platform.Running},
+			service:  platform.Service{SessionID: sessionID, Status: platform.Running},
+		},
+		"Verify relayed session is not lost": {
+			previous: platform.Service{SessionID: sessionID, Status: platform.Running, Relay: true},
+			service:  platform.Service{SessionID: sessionID, Status: platform.Running, Relay: true, Disruption: "Evicted"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		events := make(chan webhook.Event, 2)
+		hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var event webhook.Event
+			json.NewDecoder(r.Body).Decode(&event)
+			events <- event
+		}))
+		defer hook.Close()
+
+		logger := &logrus.Logger{}
+		stats := storage.New()
+		notifier := webhook.New(logger, webhook.Config{URLs: []string{hook.URL}})
+
+		recordLostSession(logger, stats, notifier, test.previous, test.service, !test.follower)
+		recordLostSession(logger, stats, notifier, test.service, test.service, !test.follower)
+
+		session, ok := stats.Stranded().Get(sessionID)
+		assert.Equal(t, test.recorded, ok)
+		assert.Equal(t, test.lost, session.Lost)
+		if !test.recorded {
+			continue
+		}
+		if test.follower {
+			select {
+			case event := <-events:
+				t.Fatalf("follower notified lost session: %v", event)
+			case <-time.After(100 * time.Millisecond):
+			}
+			continue
+		}
+
+		select {
+		case event := <-events:
+			assert.Equal(t, webhook.SessionLost, event.Type)
+			assert.Equal(t, sessionID, event.SessionID)
+			assert.Equal(t, lostMessage(sessionID, test.lost), event.Message)
+		case <-time.After(time.Second):
+			t.Fatal("webhook was not notified")
+		}
+		select {
+		case event := <-events:
+			t.Fatalf("lost session notified twice: %v", event)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func TestHandleProxyLostSession(t *testing.T) {
+	const sessionID = "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491"
+
+	tests := map[string]struct {
+		method   string
+		path     string
+		respCode int
+		respBody string
+		deleted  []string
+	}{
+		"Verify command of lost session gets invalid session id error": {
+			method:   http.MethodPost,
+			path:     "/wd/hub/session/" + sessionID + "/url",
+			respCode: http.StatusNotFound,
+			respBody: `{"value":{"error":"invalid session id","message":"session ` + sessionID + ` was lost, browser pod was disrupted: Evicted, retry the session with POST /sessions/` + sessionID + `/retry","stacktrace":""}}`,
+		},
+		"Verify lost session is deleted": {
+			method:   http.MethodDelete,
+			path:     "/wd/hub/session/" + sessionID,
+			respCode: http.StatusOK,
+			respBody: `{"value":null}`,
+			deleted:  []string{sessionID},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		mock := &PlatformMock{}
+		app := initApp(mock)
+		app.stats.Stranded().Put(sessionID, storage.StrandedSession{Service: platform.Service{SessionID: sessionID}, Since: time.Now(), Lost: "Evicted"})
+
+		req := httptest.NewRequest(test.method, test.path, http.NoBody)
+		req = mux.SetURLVars(req, map[string]string{"sessionId": sessionID})
+		rr := httptest.NewRecorder()
+		app.HandleProxy(rr, req)
+
+		assert.Equal(t, test.respCode, rr.Code)
+		assert.Equal(t, test.respBody, string(bytes.TrimSpace(rr.Body.Bytes())))
+		assert.DeepEqual(t, test.deleted, mock.deleted)
+		_, lost := app.lostSession(sessionID)
+		assert.Equal(t, test.deleted == nil, lost)
+	}
+}
@@ -0,0 +1,143 @@
+package selenosis
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/alcounit/selenosis/tools"
+	"github.com/gorilla/mux"
+)
+
+//fileserverPort is port of file server started in browser container of selenoid images, it serves
+//downloads directory of the browser
+var fileserverPort = "8080"
+
+//maxUploadSize limits size of file uploaded to browser container
+var maxUploadSize int64 = 64 << 20
+
+//HandleDownload proxies request to file server of browser container, GET of session root lists downloaded
+//files, GET of a file returns its content and DELETE removes it
+func (app *App) HandleDownload(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := app.containerSession(w, r, "downloads are not available for relayed session %s")
+	if !ok {
+		return
+	}
+	app.recordActivity(sessionID, time.Now())
+
+	logger := app.requestLogger(r, sessionID)
+
+	file := mux.Vars(r)["file"]
+	(&httputil.ReverseProxy{
+		Transport: transport,
+		Director: func(r *http.Request) {
+			r.URL.Scheme = "http"
+			r.Host = app.sessionHost(sessionID, fileserverPort)
+			r.URL.Host = r.Host
+			r.URL.Path = "/" + file
+			r.URL.RawPath = ""
+			r.Header.Set("X-Forwarded-Selenosis", app.selenosisHost)
+			logger.Info("proxying download")
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logger.Errorf("download proxying error: %v", err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}).ServeHTTP(w, r)
+}
+
+//HandleUpload sends request body to browser container as file with the name from request path, file is passed
+//to WebDriver file endpoint of the session through sidecar and WebDriver response with path of the file is returned
+func (app *App) HandleUpload(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := app.containerSession(w, r, "uploads are not available for relayed session %s")
+	if !ok {
+		return
+	}
+	app.recordActivity(sessionID, time.Now())
+
+	logger := app.requestLogger(r, sessionID)
+
+	file := path.Base(mux.Vars(r)["file"])
+	if file == "." || file == "/" {
+		tools.JSONError(w, "file name is required", http.StatusBadRequest)
+		return
+	}
+
+	content, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxUploadSize))
+	if err != nil {
+		logger.Errorf("failed to read uploaded file: %v", err)
+		tools.JSONError(w, fmt.Sprintf("failed to read file: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	body, err := zipFile(file, content)
+	if err != nil {
+		logger.Errorf("failed to pack uploaded file: %v", err)
+		tools.JSONError(w, fmt.Sprintf("failed to pack file: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	u := &url.URL{
+		Scheme: "http",
+		Host:   app.sessionHost(sessionID, app.sidecarPort),
+		Path:   fmt.Sprintf("/wd/hub/session/%s/file", sessionID),
+	}
+	req, _ := http.NewRequestWithContext(r.Context(), http.MethodPost, u.String(), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-Selenosis", app.selenosisHost)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		logger.Errorf("upload error: %v", err)
+		tools.JSONError(w, fmt.Sprintf("failed to upload file: %v", err), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	logger.Infof("file %s uploaded, %d bytes", file, len(content))
+	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+//containerSession returns valid session id of request to browser container, relayed sessions have no browser
+//container and are rejected with unavailable message
+func (app *App) containerSession(w http.ResponseWriter, r *http.Request, unavailable string) (string, bool) {
+	sessionID, ok := mux.Vars(r)["sessionId"]
+	if !ok || !isValidSession(sessionID) {
+		app.logger.WithField("request", fmt.Sprintf("%s %s", r.Method, r.URL.Path)).Errorf("%s is not valid session id", sessionID)
+		tools.JSONError(w, "session id not found", http.StatusBadRequest)
+		return "", false
+	}
+	if _, ok := app.relayedSession(sessionID); ok {
+		tools.JSONError(w, fmt.Sprintf(unavailable, sessionID), http.StatusNotFound)
+		return "", false
+	}
+	return sessionID, true
+}
+
+//zipFile returns WebDriver file upload request body, file is passed as base64 encoded zip archive
+func zipFile(name string, content []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	archive := zip.NewWriter(buf)
+	f, err := archive.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(content); err != nil {
+		return nil, err
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(map[string]string{"file": base64.StdEncoding.EncodeToString(buf.Bytes())})
+}
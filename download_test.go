@@ -0,0 +1,157 @@
+package selenosis
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/gorilla/mux"
+	"gotest.tools/assert"
+)
+
+func TestHandleDownload(t *testing.T) {
+	const sessionID = "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491"
+
+	fileserver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/":
+			w.Write([]byte(`["report.pdf"]`))
+		case r.Method == http.MethodGet && r.URL.Path == "/report.pdf":
+			w.Write([]byte("%PDF"))
+		case r.Method == http.MethodDelete && r.URL.Path == "/report.pdf":
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer fileserver.Close()
+	_, port, _ := net.SplitHostPort(fileserver.Listener.Addr().String())
+	defer func(port string) { fileserverPort = port }(fileserverPort)
+	fileserverPort = port
+
+	u, _ := url.Parse(fileserver.URL)
+	app := initApp(&PlatformMock{})
+	app.stats.Sessions().Put(sessionID, platform.Service{SessionID: sessionID, URL: u, Status: platform.Running})
+	app.stats.Sessions().Put("relay-de44c3c4-1a35-412b-b526-f5da80214491", platform.Service{SessionID: "relay-de44c3c4-1a35-412b-b526-f5da80214491", URL: u, Relay: true})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/download/{sessionId}/", app.HandleDownload).Methods(http.MethodGet)
+	router.HandleFunc("/download/{sessionId}/{file}", app.HandleDownload).Methods(http.MethodGet, http.MethodDelete)
+
+	tests := map[string]struct {
+		method   string
+		path     string
+		respCode int
+		respBody string
+	}{
+		"Verify downloaded files are listed": {
+			method:   http.MethodGet,
+			path:     "/download/" + sessionID + "/",
+			respCode: http.StatusOK,
+			respBody: `["report.pdf"]`,
+		},
+		"Verify downloaded file is returned": {
+			method:   http.MethodGet,
+			path:     "/download/" + sessionID + "/report.pdf",
+			respCode: http.StatusOK,
+			respBody: "%PDF",
+		},
+		"Verify downloaded file is deleted": {
+			method:   http.MethodDelete,
+			path:     "/download/" + sessionID + "/report.pdf",
+			respCode: http.StatusOK,
+		},
+		"Verify missing file is not found": {
+			method:   http.MethodGet,
+			path:     "/download/" + sessionID + "/missing.pdf",
+			respCode: http.StatusNotFound,
+		},
+		"Verify invalid session id is rejected": {
+			method:   http.MethodGet,
+			path:     "/download/session/report.pdf",
+			respCode: http.StatusBadRequest,
+			respBody: `{"code":400,"value":{"message":"session id not found"}}`,
+		},
+		"Verify downloads of relayed session are not available": {
+			method:   http.MethodGet,
+			path:     "/download/relay-de44c3c4-1a35-412b-b526-f5da80214491/report.pdf",
+			respCode: http.StatusNotFound,
+			respBody: `{"code":404,"value":{"message":"downloads are not available for relayed session relay-de44c3c4-1a35-412b-b526-f5da80214491"}}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		req, err := http.NewRequest(test.method, test.path, nil)
+		assert.NilError(t, err)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, test.respCode, rr.Code)
+		assert.Equal(t, test.respBody, strings.TrimSpace(rr.Body.String()))
+	}
+}
+
+func TestHandleUpload(t *testing.T) {
+	const sessionID = "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491"
+
+	var uploaded map[string][]byte
+	sidecar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/wd/hub/session/"+sessionID+"/file", r.URL.Path)
+		var body struct {
+			File string `json:"file"`
+		}
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&body))
+		content, err := base64.StdEncoding.DecodeString(body.File)
+		assert.NilError(t, err)
+		archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+		assert.NilError(t, err)
+
+		uploaded = make(map[string][]byte)
+		for _, f := range archive.File {
+			rc, err := f.Open()
+			assert.NilError(t, err)
+			uploaded[f.Name], _ = ioutil.ReadAll(rc)
+			rc.Close()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"value":"/tmp/upload123/data.csv"}`))
+	}))
+	defer sidecar.Close()
+	u, _ := url.Parse(sidecar.URL)
+
+	app := initApp(&PlatformMock{})
+	_, app.sidecarPort, _ = net.SplitHostPort(u.Host)
+	app.stats.Sessions().Put(sessionID, platform.Service{SessionID: sessionID, URL: u, Status: platform.Running})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/download/{sessionId}/{file}", app.HandleUpload).Methods(http.MethodPost)
+
+	req, err := http.NewRequest(http.MethodPost, "/download/"+sessionID+"/data.csv", strings.NewReader("a,b\n1,2\n"))
+	assert.NilError(t, err)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"value":"/tmp/upload123/data.csv"}`, rr.Body.String())
+	assert.DeepEqual(t, map[string][]byte{"data.csv": []byte("a,b\n1,2\n")}, uploaded)
+
+	maxUploadSize = 4
+	defer func() { maxUploadSize = 64 << 20 }()
+	req, err = http.NewRequest(http.MethodPost, "/download/"+sessionID+"/data.csv", strings.NewReader("a,b\n1,2\n"))
+	assert.NilError(t, err)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, `{"code":400,"value":{"message":"failed to read file: http: request body too large"}}`, strings.TrimSpace(rr.Body.String()))
+}
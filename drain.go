@@ -0,0 +1,109 @@
+package selenosis
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alcounit/selenosis/webhook"
+	"github.com/gorilla/mux"
+)
+
+//drainCheckInterval is how often sessions with requests in flight are checked while draining
+var drainCheckInterval = time.Second
+
+//inflightSessions counts requests of sessions proxied by this replica
+type inflightSessions struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func newInflightSessions() *inflightSessions {
+	return &inflightSessions{counts: make(map[string]int)}
+}
+
+//add counts request of the session until returned func is called
+func (s *inflightSessions) add(sessionID string) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts[sessionID]++
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.counts[sessionID]--; s.counts[sessionID] <= 0 {
+			delete(s.counts, sessionID)
+		}
+	}
+}
+
+//Len returns number of sessions with requests in flight
+func (s *inflightSessions) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.counts)
+}
+
+//Inflight counts requests proxied to session browser while next handler serves them, session is taken
+//from sessionId path variable
+func (app *App) Inflight(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		done := app.inflight.add(mux.Vars(r)["sessionId"])
+		defer done()
+		next.ServeHTTP(w, r)
+	})
+}
+
+//Drain stops accepting new sessions and keeps proxying requests in flight, e.g. WebSocket connections, until no
+//session has requests in flight on this replica or ctx is done. Sessions of the registry are kept by their browser
+//pods and proxied by other replicas, registry is announced to webhook first so HA peers can take sessions over.
+//Drain returns number of sessions with requests left
+func (app *App) Drain(ctx context.Context) int {
+	logger := app.logger.WithField("component", "drain")
+	if !atomic.CompareAndSwapInt32(&app.draining, 0, 1) {
+		return app.inflight.Len()
+	}
+
+	sessions := make([]string, 0)
+	for sessionID := range app.stats.Sessions().List() {
+		sessions = append(sessions, sessionID)
+	}
+	sort.Strings(sessions)
+	logger.Warnf("draining selenosis, sessions: %d", len(sessions))
+
+	if app.notifier.Enabled() {
+		err := app.notifier.Send(ctx, webhook.Event{
+			Type:     webhook.SelenosisDraining,
+			Time:     time.Now(),
+			Message:  fmt.Sprintf("selenosis %s is shutting down", app.selenosisHost),
+			Sessions: sessions,
+		})
+		if err != nil {
+			logger.Errorf("failed to announce session registry: %v", err)
+		}
+	}
+
+	ticker := time.NewTicker(drainCheckInterval)
+	defer ticker.Stop()
+	for {
+		left := app.inflight.Len()
+		if left == 0 {
+			logger.Info("all requests in flight are finished")
+			return 0
+		}
+		select {
+		case <-ctx.Done():
+			logger.Warnf("drain deadline exceeded, sessions left: %d", left)
+			return left
+		case <-ticker.C:
+		}
+	}
+}
+
+//Draining ...
+func (app *App) Draining() bool {
+	return atomic.LoadInt32(&app.draining) == 1
+}
@@ -0,0 +1,119 @@
+package selenosis
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/config"
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/webhook"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+)
+
+func TestDrain(t *testing.T) {
+	drainCheckInterval = 10 * time.Millisecond
+
+	tests := map[string]struct {
+		inflight bool
+		finish   bool
+		timeout  time.Duration
+		left     int
+	}{
+		"Verify drain ends when requests in flight are finished": {
+			inflight: true,
+			finish:   true,
+			timeout:  time.Second,
+		},
+		"Verify drain ends on deadline": {
+			inflight: true,
+			timeout:  50 * time.Millisecond,
+			left:     2,
+		},
+		"Verify drain does not wait for sessions without requests in flight": {
+			timeout: time.Second,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		events := make(chan webhook.Event, 1)
+		hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var event webhook.Event
+			json.NewDecoder(r.Body).Decode(&event)
+			events <- event
+		}))
+
+		browsers, err := config.NewBrowsersConfig("config/browsers.yaml")
+		assert.NilError(t, err)
+		app := New(&logrus.Logger{}, &PlatformMock{}, browsers, Configuration{
+			SelenosisHost:      "hostname",
+			SidecarPort:        "4445",
+			BrowserWaitTimeout: time.Second,
+			Webhook:            webhook.Config{URLs: []string{hook.URL}},
+		})
+		var done []func()
+		for _, id := range []string{"chrome-85-0-2", "chrome-85-0-1"} {
+			app.stats.Sessions().Put(id, platform.Service{SessionID: id, Status: platform.Running})
+			if test.inflight {
+				done = append(done, app.inflight.add(id))
+			}
+		}
+		if test.finish {
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				for _, d := range done {
+					d()
+				}
+			}()
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), test.timeout)
+		left := app.Drain(ctx)
+		cancel()
+		hook.Close()
+
+		assert.Equal(t, test.left, left)
+		assert.Assert(t, app.Draining())
+
+		event := <-events
+		assert.Equal(t, webhook.SelenosisDraining, event.Type)
+		assert.DeepEqual(t, []string{"chrome-85-0-1", "chrome-85-0-2"}, event.Sessions)
+
+		rr := httptest.NewRecorder()
+		app.HandleSession(rr, httptest.NewRequest(http.MethodPost, "/wd/hub/session", bytes.NewReader([]byte(`{"desiredCapabilities":{"browserName":"chrome"}}`))))
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	}
+}
+
+func TestInflight(t *testing.T) {
+	app := initApp(&PlatformMock{})
+
+	served := make(chan struct{})
+	release := make(chan struct{})
+	handler := app.Inflight(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(served)
+		<-release
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/vnc/chrome-85-0-1", http.NoBody)
+	req = mux.SetURLVars(req, map[string]string{"sessionId": "chrome-85-0-1"})
+	finished := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		close(finished)
+	}()
+
+	<-served
+	assert.Equal(t, 1, app.inflight.Len())
+	close(release)
+	<-finished
+	assert.Equal(t, 0, app.inflight.Len())
+}
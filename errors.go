@@ -0,0 +1,19 @@
+package selenosis
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/alcounit/selenosis/selenium"
+)
+
+//webDriverError writes W3C WebDriver error response, so client bindings raise exception of the error code
+func webDriverError(w http.ResponseWriter, code, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(
+		map[string]interface{}{
+			"value": selenium.Error{Error: code, Message: message},
+		},
+	)
+}
@@ -0,0 +1,131 @@
+package selenosis
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/tools"
+)
+
+//sessionEventKeepalive is how often idle session events stream is written to, so proxies don't close it
+var sessionEventKeepalive = 15 * time.Second
+
+//sessionEventBuffer is how many session events subscriber may fall behind before it is disconnected
+const sessionEventBuffer = 64
+
+//sessionEvents fans session events of the pod informer out to subscribers
+type sessionEvents struct {
+	lock        sync.Mutex
+	subscribers map[chan platform.Event]struct{}
+}
+
+func newSessionEvents() *sessionEvents {
+	return &sessionEvents{subscribers: make(map[chan platform.Event]struct{})}
+}
+
+func (e *sessionEvents) subscribe() chan platform.Event {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	ch := make(chan platform.Event, sessionEventBuffer)
+	e.subscribers[ch] = struct{}{}
+	return ch
+}
+
+func (e *sessionEvents) unsubscribe(ch chan platform.Event) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if _, ok := e.subscribers[ch]; ok {
+		delete(e.subscribers, ch)
+		close(ch)
+	}
+}
+
+//publish sends event to every subscriber without blocking, subscriber which fell behind is disconnected
+//and gets current sessions again when it reconnects
+func (e *sessionEvents) publish(event platform.Event) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	for ch := range e.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(e.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+//HandleSessionEvents streams session lifecycle as server-sent events, current sessions are sent as added
+//on connect, then every added, updated and deleted session is sent as the pod informer reports it
+func (app *App) HandleSessionEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		tools.JSONError(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := app.sessionEvents.subscribe()
+	defer app.sessionEvents.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var id int
+	send := func(eventType platform.EventType, service platform.Service) error {
+		data, err := json.Marshal(app.sessionInfo(service))
+		if err != nil {
+			return fmt.Errorf("failed to marshal session event: %v", err)
+		}
+		id++
+		if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, strings.ToLower(string(eventType)), data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	var sessions []platform.Service
+	for _, service := range app.stats.Sessions().List() {
+		sessions = append(sessions, service)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Started.Before(sessions[j].Started)
+	})
+	for _, service := range sessions {
+		if err := send(platform.Added, service); err != nil {
+			return
+		}
+	}
+
+	keepalive := time.NewTicker(sessionEventKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+			if err := send(event.Type, event.PlatformObject.(platform.Service)); err != nil {
+				app.logger.Warnf("session events stream closed: %v", err)
+				return
+			}
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package selenosis
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"gotest.tools/assert"
+)
+
+func TestHandleSessionEvents(t *testing.T) {
+	app := initApp(&PlatformMock{})
+	started := time.Now()
+	app.stats.Sessions().Put("chrome-85-0-1", platform.Service{SessionID: "chrome-85-0-1", Status: platform.Running, Started: started})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/events", nil)
+	assert.NilError(t, err)
+
+	rr := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		app.HandleSessionEvents(rr, req)
+		close(done)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	app.sessionEvents.publish(platform.Event{Type: platform.Added, PlatformObject: platform.Service{SessionID: "firefox-45-0-1", Status: platform.Pending, Started: started}})
+	app.sessionEvents.publish(platform.Event{Type: platform.Updated, PlatformObject: platform.Service{SessionID: "firefox-45-0-1", Status: platform.Running, Started: started}})
+	app.sessionEvents.publish(platform.Event{Type: platform.Deleted, PlatformObject: platform.Service{SessionID: "chrome-85-0-1", Status: platform.Running, Started: started}})
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+
+	assert.Equal(t, "text/event-stream", rr.Header().Get("Content-Type"))
+
+	events := strings.Split(strings.TrimSpace(rr.Body.String()), "\n\n")
+	assert.Equal(t, 4, len(events))
+	assert.Assert(t, strings.HasPrefix(events[0], "id: 1\nevent: added\ndata: {\"id\":\"chrome-85-0-1\""), events[0])
+	assert.Assert(t, strings.HasPrefix(events[1], "id: 2\nevent: added\ndata: {\"id\":\"firefox-45-0-1\""), events[1])
+	assert.Assert(t, strings.Contains(events[1], "\"status\":\"Pending\""))
+	assert.Assert(t, strings.HasPrefix(events[2], "id: 3\nevent: updated\ndata: {\"id\":\"firefox-45-0-1\""), events[2])
+	assert.Assert(t, strings.Contains(events[2], "\"status\":\"Running\""))
+	assert.Assert(t, strings.HasPrefix(events[3], "id: 4\nevent: deleted\ndata: {\"id\":\"chrome-85-0-1\""), events[3])
+	assert.Equal(t, 0, len(app.sessionEvents.subscribers))
+}
+
+func TestSessionEventsSlowSubscriber(t *testing.T) {
+	events := newSessionEvents()
+	slow := events.subscribe()
+	for i := 0; i <= sessionEventBuffer; i++ {
+		events.publish(platform.Event{Type: platform.Added, PlatformObject: platform.Service{}})
+	}
+
+	received := 0
+	for range slow {
+		received++
+	}
+	assert.Equal(t, sessionEventBuffer, received)
+	assert.Equal(t, 0, len(events.subscribers))
+	events.unsubscribe(slow)
+}
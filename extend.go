@@ -0,0 +1,55 @@
+package selenosis
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/alcounit/selenosis/audit"
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/tools"
+	"github.com/gorilla/mux"
+)
+
+//HandleExtendSession restarts idle countdown of the session, e.g. of manual debugging over VNC which sends no
+//commands. Extension is recorded on the browser, so seleniferous sidecar and idle reaper of every replica see it,
+//session can be extended at most max session extensions times
+func (app *App) HandleExtendSession(w http.ResponseWriter, r *http.Request) {
+	sessionID := mux.Vars(r)["sessionId"]
+
+	extender, ok := app.client.(platform.Extender)
+	if !ok {
+		tools.JSONError(w, platform.ErrExtendNotSupported.Error(), http.StatusNotImplemented)
+		return
+	}
+	if _, ok := app.stats.Sessions().Get(sessionID); !ok {
+		tools.JSONError(w, fmt.Sprintf("unknown session %s", sessionID), http.StatusNotFound)
+		return
+	}
+
+	logger := app.logger.WithField("session_id", sessionID)
+	extension, err := extender.Extend(sessionID, app.maxExtensions)
+	switch {
+	case err == platform.ErrExtendNotSupported:
+		tools.JSONError(w, err.Error(), http.StatusNotImplemented)
+		return
+	case err == platform.ErrSessionNotFound:
+		tools.JSONError(w, fmt.Sprintf("unknown session %s", sessionID), http.StatusNotFound)
+		return
+	case err == platform.ErrExtensionsExhausted:
+		tools.JSONError(w, fmt.Sprintf("session was already extended %d times", app.maxExtensions), http.StatusConflict)
+		return
+	case err != nil:
+		logger.Errorf("failed to extend session: %v", err)
+		tools.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	app.recordActivity(sessionID, time.Now())
+
+	logger.Infof("session extended %d times", extension.Extensions)
+	app.auditSessionRequest(r, audit.SessionExtended, sessionID, fmt.Sprintf("extension %d", extension.Extensions))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(extension)
+}
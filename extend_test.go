@@ -0,0 +1,92 @@
+package selenosis
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/gorilla/mux"
+	"gotest.tools/assert"
+)
+
+type extenderMock struct {
+	*PlatformMock
+	err error
+	max int
+}
+
+func (p *extenderMock) Extend(sessionID string, max int) (platform.SessionExtension, error) {
+	p.max = max
+	if p.err != nil {
+		return platform.SessionExtension{}, p.err
+	}
+	return platform.SessionExtension{SessionID: sessionID, Extensions: 2, ExtendedAt: time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)}, nil
+}
+
+func TestHandleExtendSession(t *testing.T) {
+	tests := map[string]struct {
+		client    platform.Platform
+		sessionID string
+		respCode  int
+		respBody  string
+		activity  bool
+	}{
+		"Verify session is extended": {
+			client:    &extenderMock{PlatformMock: &PlatformMock{}},
+			sessionID: "chrome-85-0-known",
+			respCode:  http.StatusOK,
+			respBody:  `{"sessionId":"chrome-85-0-known","extensions":2,"extendedAt":"2020-10-01T12:00:00Z"}`,
+			activity:  true,
+		},
+		"Verify extension of unknown session is rejected": {
+			client:    &extenderMock{PlatformMock: &PlatformMock{}},
+			sessionID: "chrome-85-0-unknown",
+			respCode:  http.StatusNotFound,
+			respBody:  `{"code":404,"value":{"message":"unknown session chrome-85-0-unknown"}}`,
+		},
+		"Verify extension above max extensions is rejected": {
+			client:    &extenderMock{PlatformMock: &PlatformMock{}, err: platform.ErrExtensionsExhausted},
+			sessionID: "chrome-85-0-known",
+			respCode:  http.StatusConflict,
+			respBody:  `{"code":409,"value":{"message":"session was already extended 3 times"}}`,
+		},
+		"Verify extension platform error is returned": {
+			client:    &extenderMock{PlatformMock: &PlatformMock{}, err: errors.New("session extension is not available for relayed sessions")},
+			sessionID: "chrome-85-0-known",
+			respCode:  http.StatusInternalServerError,
+			respBody:  `{"code":500,"value":{"message":"session extension is not available for relayed sessions"}}`,
+		},
+		"Verify extension is not supported by platform without pods": {
+			client:    &PlatformMock{},
+			sessionID: "chrome-85-0-known",
+			respCode:  http.StatusNotImplemented,
+			respBody:  `{"code":501,"value":{"message":"session extension is not supported by the platform"}}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		app.client = test.client
+		app.maxExtensions = 3
+		app.stats.Sessions().Put("chrome-85-0-known", platform.Service{SessionID: "chrome-85-0-known"})
+
+		req := httptest.NewRequest(http.MethodPost, "/session/"+test.sessionID+"/extend", http.NoBody)
+		req = mux.SetURLVars(req, map[string]string{"sessionId": test.sessionID})
+		rr := httptest.NewRecorder()
+		app.HandleExtendSession(rr, req)
+
+		assert.Equal(t, test.respCode, rr.Code)
+		assert.Equal(t, test.respBody, string(bytes.TrimSpace(rr.Body.Bytes())))
+		_, ok := app.stats.Activity().Get(test.sessionID)
+		assert.Equal(t, test.activity, ok)
+		if extender, ok := test.client.(*extenderMock); ok && test.respCode != http.StatusNotFound {
+			assert.Equal(t, 3, extender.max)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package selenosis
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+//driverOptions are vendor capabilities holding launch arguments of the browser by browser name
+var driverOptions = map[string]string{
+	"chrome":        "goog:chromeOptions",
+	"msedge":        "ms:edgeOptions",
+	"microsoftedge": "ms:edgeOptions",
+	"firefox":       "moz:firefoxOptions",
+}
+
+//validateEnv checks browser environment requested with selenosis:options capability
+func validateEnv(env []string) error {
+	for _, v := range env {
+		if kv := strings.SplitN(v, "=", 2); len(kv) != 2 || kv[0] == "" {
+			return fmt.Errorf("invalid env %s, NAME=value is expected", v)
+		}
+	}
+	return nil
+}
+
+//injectArgs adds arguments requested with selenosis:options capability to driver options of the browser in
+//new session request. Arguments go to alwaysMatch unless driver options are set in firstMatch, then every
+//firstMatch entry gets them. Legacy desiredCapabilities are updated as well
+func injectArgs(body []byte, browserName string, args []string) ([]byte, error) {
+	if len(args) == 0 {
+		return body, nil
+	}
+	return updateDriverOptions(body, browserName, func(options map[string]interface{}) {
+		existing, _ := options["args"].([]interface{})
+		for _, arg := range args {
+			existing = append(existing, arg)
+		}
+		options["args"] = existing
+	})
+}
+
+//updateDriverOptions calls update with driver options of the browser in new session request, request of
+//browser without known driver options is returned as is
+func updateDriverOptions(body []byte, browserName string, update func(options map[string]interface{})) ([]byte, error) {
+	key, ok := driverOptions[strings.ToLower(browserName)]
+	if !ok {
+		return body, nil
+	}
+
+	var request map[string]interface{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+
+	if w3c, ok := request["capabilities"].(map[string]interface{}); ok {
+		alwaysMatch, _ := w3c["alwaysMatch"].(map[string]interface{})
+		var targets []map[string]interface{}
+		set := false
+		if entries, ok := w3c["firstMatch"].([]interface{}); ok {
+			for _, entry := range entries {
+				if caps, ok := entry.(map[string]interface{}); ok {
+					targets = append(targets, caps)
+					_, has := caps[key]
+					set = set || has
+				}
+			}
+		}
+		if _, ok := alwaysMatch[key]; ok || !set {
+			if alwaysMatch == nil {
+				alwaysMatch = make(map[string]interface{})
+				w3c["alwaysMatch"] = alwaysMatch
+			}
+			targets = []map[string]interface{}{alwaysMatch}
+		}
+		for _, caps := range targets {
+			update(driverOptionsOf(caps, key))
+		}
+	}
+
+	if desired, ok := request["desiredCapabilities"].(map[string]interface{}); ok {
+		update(driverOptionsOf(desired, key))
+	}
+
+	return json.Marshal(request)
+}
+
+func driverOptionsOf(caps map[string]interface{}, key string) map[string]interface{} {
+	options, ok := caps[key].(map[string]interface{})
+	if !ok {
+		options = make(map[string]interface{})
+		caps[key] = options
+	}
+	return options
+}
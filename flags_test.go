@@ -0,0 +1,123 @@
+package selenosis
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/alcounit/selenosis/platform"
+	"gotest.tools/assert"
+)
+
+func TestInjectArgs(t *testing.T) {
+	tests := map[string]struct {
+		body        string
+		browserName string
+		args        []string
+		result      string
+	}{
+		"Verify args are added to alwaysMatch": {
+			body:        `{"capabilities":{"alwaysMatch":{"browserName":"chrome"}}}`,
+			browserName: "chrome",
+			args:        []string{"--lang=de"},
+			result:      `{"capabilities":{"alwaysMatch":{"browserName":"chrome","goog:chromeOptions":{"args":["--lang=de"]}}}}`,
+		},
+		"Verify args are appended to driver options": {
+			body:        `{"capabilities":{"alwaysMatch":{"browserName":"firefox","moz:firefoxOptions":{"args":["-headless"],"prefs":{"intl.accept_languages":"de"}}}}}`,
+			browserName: "firefox",
+			args:        []string{"-private"},
+			result:      `{"capabilities":{"alwaysMatch":{"browserName":"firefox","moz:firefoxOptions":{"args":["-headless","-private"],"prefs":{"intl.accept_languages":"de"}}}}}`,
+		},
+		"Verify args are added to firstMatch with driver options": {
+			body:        `{"capabilities":{"firstMatch":[{"browserName":"MicrosoftEdge","ms:edgeOptions":{}},{"browserName":"MicrosoftEdge"}]}}`,
+			browserName: "MicrosoftEdge",
+			args:        []string{"--lang=de"},
+			result:      `{"capabilities":{"firstMatch":[{"browserName":"MicrosoftEdge","ms:edgeOptions":{"args":["--lang=de"]}},{"browserName":"MicrosoftEdge","ms:edgeOptions":{"args":["--lang=de"]}}]}}`,
+		},
+		"Verify args are added to legacy capabilities": {
+			body:        `{"desiredCapabilities":{"browserName":"chrome"}}`,
+			browserName: "chrome",
+			args:        []string{"--lang=de"},
+			result:      `{"desiredCapabilities":{"browserName":"chrome","goog:chromeOptions":{"args":["--lang=de"]}}}`,
+		},
+		"Verify request of unknown browser is not changed": {
+			body:        `{"capabilities":{"alwaysMatch":{"browserName":"opera"}}}`,
+			browserName: "opera",
+			args:        []string{"--lang=de"},
+			result:      `{"capabilities":{"alwaysMatch":{"browserName":"opera"}}}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		result, err := injectArgs([]byte(test.body), test.browserName, test.args)
+		assert.NilError(t, err)
+		assert.Equal(t, test.result, string(result))
+	}
+}
+
+func TestValidateEnv(t *testing.T) {
+	tests := map[string]struct {
+		env []string
+		err string
+	}{
+		"Verify env is valid": {
+			env: []string{"LANG=de_DE.UTF-8", "EMPTY="},
+		},
+		"Verify env without value is rejected": {
+			env: []string{"LANG"},
+			err: "invalid env LANG, NAME=value is expected",
+		},
+		"Verify env without name is rejected": {
+			env: []string{"=de_DE.UTF-8"},
+			err: "invalid env =de_DE.UTF-8, NAME=value is expected",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		err := validateEnv(test.env)
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+	}
+}
+
+func TestNewSessionBrowserArgs(t *testing.T) {
+	var received []byte
+	mux := http.NewServeMux()
+	mux.HandleFunc("/wd/hub/session", func(w http.ResponseWriter, r *http.Request) {
+		received, _ = ioutil.ReadAll(r.Body)
+		w.Write([]byte(`{"value":{"sessionId":"sessionID","capabilities":{}}}`))
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	u, _ := url.Parse(s.URL)
+	app := initApp(&PlatformMock{
+		service: platform.Service{SessionID: "sessionID", CancelFunc: func() {}, URL: u},
+	})
+
+	rr := httptest.NewRecorder()
+	app.HandleSession(rr, httptest.NewRequest(http.MethodPost, session, bytes.NewReader([]byte(`{"capabilities":{"alwaysMatch":{"browserName":"chrome","selenosis:options":{"args":["--lang=de"]}}}}`))))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"capabilities":{"alwaysMatch":{"browserName":"chrome","goog:chromeOptions":{"args":["--lang=de"]},"selenosis:options":{"args":["--lang=de"]}}}}`, string(received))
+}
+
+func TestNewSessionInvalidEnv(t *testing.T) {
+	app := initApp(&PlatformMock{})
+
+	rr := httptest.NewRecorder()
+	app.HandleSession(rr, httptest.NewRequest(http.MethodPost, session, bytes.NewReader([]byte(`{"desiredCapabilities":{"browserName":"chrome","selenosis:options":{"env":["LANG"]}}}`))))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, `{"value":{"error":"invalid argument","message":"invalid env LANG, NAME=value is expected","stacktrace":""}}`, string(bytes.TrimSpace(rr.Body.Bytes())))
+}
@@ -0,0 +1,121 @@
+package selenosis
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alcounit/selenosis/tools"
+)
+
+//ggrQuota is Ggr quota of selenosis installation, Ggr quota file of a user lists such fragments of
+//every cluster, see https://aerokube.com/ggr/latest/#_creating_users_file
+type ggrQuota struct {
+	XMLName  xml.Name     `xml:"urn:config.gridrouter.qatools.ru browsers" json:"-"`
+	Browsers []ggrBrowser `xml:"browser" json:"browsers"`
+}
+
+type ggrBrowser struct {
+	Name           string       `xml:"name,attr" json:"name"`
+	DefaultVersion string       `xml:"defaultVersion,attr" json:"defaultVersion"`
+	Versions       []ggrVersion `xml:"version" json:"versions"`
+}
+
+type ggrVersion struct {
+	Number  string      `xml:"number,attr" json:"number"`
+	Regions []ggrRegion `xml:"region" json:"regions"`
+}
+
+type ggrRegion struct {
+	Name  string    `xml:"name,attr" json:"name"`
+	Hosts []ggrHost `xml:"host" json:"hosts"`
+}
+
+type ggrHost struct {
+	Name  string `xml:"name,attr" json:"name"`
+	Port  int    `xml:"port,attr" json:"port"`
+	Count int    `xml:"count,attr" json:"count"`
+}
+
+type pingInfo struct {
+	Uptime  string `json:"uptime"`
+	Version string `json:"version"`
+}
+
+//HandlePing answers health checks the way selenoid does, draining replica answers with 503 so
+//Ggr and load balancers stop sending new sessions to it
+func (app *App) HandlePing(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if app.Draining() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(pingInfo{
+		Uptime:  tools.TimeElapsed(app.startTime),
+		Version: app.buildVersion,
+	})
+}
+
+//HandleGgrQuota returns configured browsers as Ggr quota of the installation, every version is served
+//by single host of --ggr-region with session limit as its weight. Host is --ggr-host or host of the
+//request. Quota is returned as XML unless JSON is requested with format parameter or Accept header
+func (app *App) HandleGgrQuota(w http.ResponseWriter, r *http.Request) {
+	host, port, err := app.ggrHost(r)
+	if err != nil {
+		tools.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	quota := ggrQuota{}
+	for name, versions := range app.browsers.GetBrowserVersions() {
+		browser := ggrBrowser{Name: name}
+		if spec, err := app.browsers.Find(name, ""); err == nil {
+			browser.DefaultVersion = spec.BrowserVersion
+		}
+		for _, version := range versions {
+			browser.Versions = append(browser.Versions, ggrVersion{
+				Number: version,
+				Regions: []ggrRegion{{
+					Name:  app.ggrRegion,
+					Hosts: []ggrHost{{Name: host, Port: port, Count: app.sessionLimit}},
+				}},
+			})
+		}
+		quota.Browsers = append(quota.Browsers, browser)
+	}
+	sort.Slice(quota.Browsers, func(i, j int) bool {
+		return quota.Browsers[i].Name < quota.Browsers[j].Name
+	})
+
+	if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(quota)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write([]byte(xml.Header))
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	encoder.Encode(quota)
+}
+
+//ggrHost returns host name and port Ggr should send sessions to, port defaults to 4444 when host has no port
+func (app *App) ggrHost(r *http.Request) (string, int, error) {
+	hostport := app.ggrHostPort
+	if hostport == "" {
+		hostport = r.Host
+	}
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return hostport, 4444, nil
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port of host %s", hostport)
+	}
+	return host, p, nil
+}
@@ -0,0 +1,113 @@
+package selenosis
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestHandlePing(t *testing.T) {
+	tests := map[string]struct {
+		draining bool
+		respCode int
+	}{
+		"Verify ping of serving replica": {
+			respCode: http.StatusOK,
+		},
+		"Verify ping of draining replica": {
+			draining: true,
+			respCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		app.buildVersion = "v1.0.0"
+		if test.draining {
+			app.draining = 1
+		}
+
+		rr := httptest.NewRecorder()
+		app.HandlePing(rr, httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+		assert.Equal(t, test.respCode, rr.Code)
+		var info pingInfo
+		assert.NilError(t, json.Unmarshal(rr.Body.Bytes(), &info))
+		assert.Equal(t, "v1.0.0", info.Version)
+		assert.Assert(t, info.Uptime != "")
+	}
+}
+
+func TestHandleGgrQuota(t *testing.T) {
+	tests := map[string]struct {
+		host     string
+		url      string
+		accept   string
+		respCode int
+		respBody string
+	}{
+		"Verify quota is returned as ggr xml": {
+			host:     "selenosis.example.com:443",
+			url:      "/ggr/quota",
+			respCode: http.StatusOK,
+			respBody: `<?xml version="1.0" encoding="UTF-8"?>
+<browsers xmlns="urn:config.gridrouter.qatools.ru">
+  <browser name="chrome" defaultVersion="68.0">
+    <version number="68.0">
+      <region name="eu-west">
+        <host name="selenosis.example.com" port="443" count="10"></host>
+      </region>
+    </version>
+    <version number="86.0">
+      <region name="eu-west">
+        <host name="selenosis.example.com" port="443" count="10"></host>
+      </region>
+    </version>
+  </browser>`,
+		},
+		"Verify quota is returned as json": {
+			host:     "selenosis.example.com:443",
+			url:      "/ggr/quota?format=json",
+			respCode: http.StatusOK,
+			respBody: `{"browsers":[{"name":"chrome","defaultVersion":"68.0","versions":[{"number":"68.0","regions":[{"name":"eu-west","hosts":[{"name":"selenosis.example.com","port":443,"count":10}]}]}`,
+		},
+		"Verify quota host defaults to request host": {
+			url:      "/ggr/quota",
+			accept:   "application/json",
+			respCode: http.StatusOK,
+			respBody: `{"browsers":[{"name":"chrome","defaultVersion":"68.0","versions":[{"number":"68.0","regions":[{"name":"eu-west","hosts":[{"name":"selenosis","port":4444,"count":10}]}]}`,
+		},
+		"Verify invalid host port is rejected": {
+			host:     "selenosis:https",
+			url:      "/ggr/quota",
+			respCode: http.StatusBadRequest,
+			respBody: `{"code":400,"value":{"message":"invalid port of host selenosis:https"}}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		app.sessionLimit = 10
+		app.ggrRegion = "eu-west"
+		app.ggrHostPort = test.host
+
+		req := httptest.NewRequest(http.MethodGet, test.url, nil)
+		req.Host = "selenosis"
+		if test.accept != "" {
+			req.Header.Set("Accept", test.accept)
+		}
+		rr := httptest.NewRecorder()
+		app.HandleGgrQuota(rr, req)
+
+		assert.Equal(t, test.respCode, rr.Code)
+		assert.Assert(t, strings.HasPrefix(rr.Body.String(), test.respBody), rr.Body.String())
+	}
+}
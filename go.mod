@@ -7,10 +7,12 @@ require (
 	github.com/google/uuid v1.2.0
 	github.com/gorilla/mux v1.8.0
 	github.com/imdario/mergo v0.3.12
+	github.com/prometheus/client_golang v1.11.0
 	github.com/sirupsen/logrus v1.8.1
 	github.com/spf13/cobra v1.1.3
 	github.com/stretchr/testify v1.7.0
 	golang.org/x/net v0.0.0-20210525063256-abc453219eb5
+	google.golang.org/protobuf v1.26.0-rc.1
 	gotest.tools v2.2.0+incompatible
 	k8s.io/api v0.19.3
 	k8s.io/apimachinery v0.19.3
@@ -0,0 +1,143 @@
+package selenosis
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/google/uuid"
+)
+
+//gridHeartbeatPeriod is reported as heartbeat period of selenosis node in milliseconds
+const gridHeartbeatPeriod = 60000
+
+type gridStatus struct {
+	Ready   bool       `json:"ready"`
+	Message string     `json:"message"`
+	Nodes   []gridNode `json:"nodes"`
+}
+
+type gridNode struct {
+	ID              string            `json:"id"`
+	URI             string            `json:"uri"`
+	MaxSessions     int               `json:"maxSessions"`
+	OSInfo          map[string]string `json:"osInfo"`
+	HeartbeatPeriod int64             `json:"heartbeatPeriod"`
+	Availability    string            `json:"availability"`
+	Version         string            `json:"version"`
+	Slots           []gridSlot        `json:"slots"`
+}
+
+type gridSlotID struct {
+	HostID string `json:"hostId"`
+	ID     string `json:"id"`
+}
+
+type gridSlot struct {
+	ID          gridSlotID        `json:"id"`
+	LastStarted time.Time         `json:"lastStarted"`
+	Session     *gridSession      `json:"session"`
+	Stereotype  map[string]string `json:"stereotype"`
+}
+
+type gridSession struct {
+	SessionID    string            `json:"sessionId"`
+	Start        time.Time         `json:"start"`
+	Stereotype   map[string]string `json:"stereotype"`
+	Capabilities map[string]string `json:"capabilities"`
+	URI          string            `json:"uri"`
+}
+
+//gridStatus maps selenosis to Selenium Grid 4 status with single node having session limit slots,
+//sessions take slots with stereotype of their browser, free slots have empty stereotype as any
+//configured browser can take them
+func (app *App) gridStatus(r *http.Request) gridStatus {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	uri := fmt.Sprintf("%s://%s", scheme, r.Host)
+	nodeID := uuid.NewSHA1(uuid.NameSpaceURL, []byte(app.selenosisHost)).String()
+
+	var sessions []platform.Service
+	for _, s := range app.stats.Sessions().List() {
+		if s.Status == platform.Running || s.Status == platform.Pending {
+			sessions = append(sessions, s)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Started.Before(sessions[j].Started)
+	})
+
+	slots := make([]gridSlot, 0, app.sessionLimit)
+	used := 0
+	for _, s := range sessions {
+		if !s.Relay {
+			used++
+		}
+		stereotype := map[string]string{
+			"browserName":    s.Labels["browserName"],
+			"browserVersion": s.Labels["browserVersion"],
+		}
+		slots = append(slots, gridSlot{
+			ID:          gridSlotID{HostID: nodeID, ID: uuid.NewSHA1(uuid.NameSpaceURL, []byte(s.SessionID)).String()},
+			LastStarted: s.Started.UTC(),
+			Session: &gridSession{
+				SessionID:    s.SessionID,
+				Start:        s.Started.UTC(),
+				Stereotype:   stereotype,
+				Capabilities: s.Labels,
+				URI:          uri,
+			},
+			Stereotype: stereotype,
+		})
+	}
+	for i := used; i < app.sessionLimit; i++ {
+		slots = append(slots, gridSlot{
+			ID:          gridSlotID{HostID: nodeID, ID: uuid.NewSHA1(uuid.NameSpaceURL, []byte(fmt.Sprintf("%s/%d", app.selenosisHost, i))).String()},
+			LastStarted: time.Unix(0, 0).UTC(),
+			Stereotype:  map[string]string{},
+		})
+	}
+
+	availability := "UP"
+	if app.Draining() {
+		availability = "DRAINING"
+	}
+	ready := !app.Draining() && !app.backendUnhealthy()
+	message := "Selenium Grid ready."
+	if !ready {
+		message = "Selenium Grid not ready."
+	}
+
+	return gridStatus{
+		Ready:   ready,
+		Message: message,
+		Nodes: []gridNode{{
+			ID:              nodeID,
+			URI:             uri,
+			MaxSessions:     app.sessionLimit,
+			OSInfo:          map[string]string{"arch": runtime.GOARCH, "name": runtime.GOOS, "version": ""},
+			HeartbeatPeriod: gridHeartbeatPeriod,
+			Availability:    availability,
+			Version:         app.buildVersion,
+			Slots:           slots,
+		}},
+	}
+}
+
+//HandleGridDistributorStatus returns nodes of Selenium Grid 4 distributor status
+func (app *App) HandleGridDistributorStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(
+		map[string]interface{}{
+			"value": map[string]interface{}{
+				"nodes": app.gridStatus(r).Nodes,
+			},
+		})
+}
@@ -0,0 +1,95 @@
+package selenosis
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"gotest.tools/assert"
+)
+
+func TestGridStatus(t *testing.T) {
+	tests := map[string]struct {
+		sessions     []platform.Service
+		draining     bool
+		ready        bool
+		availability string
+		busy         []string
+		free         int
+	}{
+		"Verify sessions take slots of node": {
+			sessions: []platform.Service{
+				{SessionID: "firefox-47-0-1", Status: platform.Running, Started: time.Unix(20, 0), Labels: map[string]string{"browserName": "firefox", "browserVersion": "47.0"}},
+				{SessionID: "chrome-86-0-1", Status: platform.Pending, Started: time.Unix(10, 0), Labels: map[string]string{"browserName": "chrome", "browserVersion": "86.0"}},
+				{SessionID: "chrome-86-0-2", Status: platform.Unknown, Started: time.Unix(30, 0)},
+			},
+			ready:        true,
+			availability: "UP",
+			busy:         []string{"chrome-86-0-1", "firefox-47-0-1"},
+			free:         1,
+		},
+		"Verify relayed sessions don't take local slots": {
+			sessions: []platform.Service{
+				{SessionID: "chrome-86-0-1", Status: platform.Running, Relay: true},
+			},
+			ready:        true,
+			availability: "UP",
+			busy:         []string{"chrome-86-0-1"},
+			free:         3,
+		},
+		"Verify draining node is not ready": {
+			draining:     true,
+			availability: "DRAINING",
+			free:         3,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		app.sessionLimit = 3
+		for _, s := range test.sessions {
+			app.stats.Sessions().Put(s.SessionID, s)
+		}
+		if test.draining {
+			app.draining = 1
+		}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/se/grid/distributor/status", nil)
+		req.Header.Set("X-Forwarded-Proto", "https")
+		app.HandleGridDistributorStatus(rr, req)
+
+		var resp struct {
+			Value struct {
+				Nodes []gridNode `json:"nodes"`
+			} `json:"value"`
+		}
+		assert.NilError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		assert.Equal(t, 1, len(resp.Value.Nodes))
+
+		node := resp.Value.Nodes[0]
+		assert.Equal(t, "https://example.com", node.URI)
+		assert.Equal(t, 3, node.MaxSessions)
+		assert.Equal(t, test.availability, node.Availability)
+
+		var busy []string
+		free := 0
+		for _, slot := range node.Slots {
+			assert.Equal(t, node.ID, slot.ID.HostID)
+			if slot.Session == nil {
+				free++
+				continue
+			}
+			busy = append(busy, slot.Session.SessionID)
+			assert.Equal(t, slot.Stereotype["browserName"], slot.Session.Capabilities["browserName"])
+		}
+		assert.DeepEqual(t, test.busy, busy)
+		assert.Equal(t, test.free, free)
+		assert.Equal(t, test.ready, app.gridStatus(req).Ready)
+	}
+}
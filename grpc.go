@@ -0,0 +1,274 @@
+package selenosis
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/alcounit/selenosis/api"
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/selenium"
+	"github.com/gorilla/mux"
+)
+
+//sessionWatchInterval is how often session registry is checked for changes streamed to gRPC watchers
+var sessionWatchInterval = time.Second
+
+//GRPC returns gRPC server of the app, calls are served by the same handlers HTTP API uses, so quotas,
+//queue, tenants and audit apply to them as well
+func (app *App) GRPC() *api.Server {
+	return api.NewServer(&grpcService{app: app})
+}
+
+type grpcService struct {
+	app *App
+}
+
+//CreateSession ...
+func (s *grpcService) CreateSession(r *http.Request, req *api.CreateSessionRequest) (*api.CreateSessionResponse, error) {
+	caps := make(map[string]interface{})
+	if req.CapabilitiesJSON != "" {
+		if err := json.Unmarshal([]byte(req.CapabilitiesJSON), &caps); err != nil {
+			return nil, api.Errorf(api.InvalidArgument, "failed to parse capabilities: %v", err)
+		}
+	}
+	for k, v := range map[string]string{"browserName": req.BrowserName, "browserVersion": req.BrowserVersion, "platformName": req.PlatformName} {
+		if v != "" {
+			caps[k] = v
+		}
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"capabilities": map[string]interface{}{"alwaysMatch": caps},
+	})
+
+	resp := s.call(r, http.MethodPost, "/wd/hub/session", body, nil, s.app.HandleSession)
+	if err := resp.err(); err != nil {
+		return nil, err
+	}
+
+	sessionID, capabilities, err := newSessionResponse(resp.body.Bytes())
+	if err != nil {
+		return nil, api.Errorf(api.Internal, "failed to read browser response: %v", err)
+	}
+
+	session := &api.Session{
+		SessionID:      sessionID,
+		BrowserName:    req.BrowserName,
+		BrowserVersion: req.BrowserVersion,
+		Status:         string(platform.Running),
+		Started:        time.Now(),
+	}
+	if service, ok := s.app.stats.Sessions().Get(sessionID); ok {
+		session = s.app.grpcSession(service)
+	}
+	return &api.CreateSessionResponse{Session: session, CapabilitiesJSON: string(capabilities)}, nil
+}
+
+//newSessionResponse returns session id and capabilities of W3C or legacy new session response
+func newSessionResponse(body []byte) (string, json.RawMessage, error) {
+	var msg struct {
+		SessionID string `json:"sessionId"`
+		Value     struct {
+			SessionID    string          `json:"sessionId"`
+			Capabilities json.RawMessage `json:"capabilities"`
+		} `json:"value"`
+	}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return "", nil, err
+	}
+	if msg.Value.SessionID != "" {
+		return msg.Value.SessionID, msg.Value.Capabilities, nil
+	}
+	var value json.RawMessage
+	json.Unmarshal(body, &struct {
+		Value *json.RawMessage `json:"value"`
+	}{&value})
+	return msg.SessionID, value, nil
+}
+
+//DeleteSession ...
+func (s *grpcService) DeleteSession(r *http.Request, req *api.DeleteSessionRequest) (*api.DeleteSessionResponse, error) {
+	resp := s.call(r, http.MethodDelete, "/wd/hub/session/"+req.SessionID, nil, map[string]string{"sessionId": req.SessionID}, s.app.HandleProxy)
+	if err := resp.err(); err != nil {
+		return nil, err
+	}
+	return &api.DeleteSessionResponse{}, nil
+}
+
+//ListSessions ...
+func (s *grpcService) ListSessions(r *http.Request, req *api.ListSessionsRequest) (*api.ListSessionsResponse, error) {
+	if _, err := s.app.principal(r); err != nil {
+		return nil, api.Errorf(api.Unauthenticated, "%v", err)
+	}
+	sessions := s.app.grpcSessions()
+	list := make([]*api.Session, 0, len(sessions))
+	for _, session := range sessions {
+		list = append(list, session)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Started.Before(list[j].Started)
+	})
+	return &api.ListSessionsResponse{Sessions: list}, nil
+}
+
+//WatchSessions sends current sessions as added and then changes of the registry found every
+//sessionWatchInterval until client cancels the call
+func (s *grpcService) WatchSessions(r *http.Request, req *api.WatchSessionsRequest, send func(*api.SessionEvent) error) error {
+	if _, err := s.app.principal(r); err != nil {
+		return api.Errorf(api.Unauthenticated, "%v", err)
+	}
+	ticker := time.NewTicker(sessionWatchInterval)
+	defer ticker.Stop()
+
+	known := make(map[string]*api.Session)
+	for {
+		current := s.app.grpcSessions()
+		var events []*api.SessionEvent
+		for id, session := range current {
+			last, ok := known[id]
+			switch {
+			case !ok:
+				events = append(events, &api.SessionEvent{Type: api.Added, Session: session})
+			case last.Status != session.Status:
+				events = append(events, &api.SessionEvent{Type: api.Updated, Session: session})
+			}
+		}
+		for id, session := range known {
+			if _, ok := current[id]; !ok {
+				events = append(events, &api.SessionEvent{Type: api.Deleted, Session: session})
+			}
+		}
+		sort.SliceStable(events, func(i, j int) bool {
+			return events[i].Session.Started.Before(events[j].Session.Started)
+		})
+		for _, event := range events {
+			if err := send(event); err != nil {
+				return err
+			}
+		}
+		known = current
+
+		select {
+		case <-r.Context().Done():
+			return api.Errorf(api.Canceled, "%v", r.Context().Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+//grpcSessions returns sessions of the registry by session id
+func (app *App) grpcSessions() map[string]*api.Session {
+	sessions := make(map[string]*api.Session)
+	for id, service := range app.stats.Sessions().List() {
+		sessions[id] = app.grpcSession(service)
+	}
+	return sessions
+}
+
+func (app *App) grpcSession(service platform.Service) *api.Session {
+	info := app.sessionInfo(service)
+	session := &api.Session{
+		SessionID:      service.SessionID,
+		BrowserName:    service.Labels["browserName"],
+		BrowserVersion: service.Labels["browserVersion"],
+		Status:         string(service.Status),
+		Tenant:         service.Labels["tenant"],
+		RunID:          service.Labels[runIDKey],
+		Relay:          service.Relay,
+		Started:        service.Started,
+		Commands:       int64(info.Commands),
+		Labels:         service.Labels,
+	}
+	if info.LastActivity != nil {
+		session.LastActivity = *info.LastActivity
+	}
+	return session
+}
+
+//call runs HTTP handler with request made from gRPC call, metadata of the call is passed as request
+//headers, so tenants authenticate the same way
+func (s *grpcService) call(r *http.Request, method, path string, body []byte, vars map[string]string, handler http.HandlerFunc) *handlerResponse {
+	return callHandler(r, method, path, body, vars, handler)
+}
+
+//callHandler runs HTTP handler with request made from request r, headers of r are passed to the handler
+func callHandler(r *http.Request, method, path string, body []byte, vars map[string]string, handler http.HandlerFunc) *handlerResponse {
+	req, _ := http.NewRequestWithContext(r.Context(), method, path, bytes.NewReader(body))
+	req.Header = r.Header.Clone()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Del("Content-Length")
+	req.Host = r.Host
+	req.RemoteAddr = r.RemoteAddr
+	if vars != nil {
+		req = mux.SetURLVars(req, vars)
+	}
+
+	resp := &handlerResponse{header: make(http.Header), code: http.StatusOK}
+	handler(resp, req)
+	return resp
+}
+
+//handlerResponse keeps response of HTTP handler called by gRPC service
+type handlerResponse struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (h *handlerResponse) Header() http.Header {
+	return h.header
+}
+
+func (h *handlerResponse) Write(b []byte) (int, error) {
+	return h.body.Write(b)
+}
+
+func (h *handlerResponse) WriteHeader(code int) {
+	h.code = code
+}
+
+//err returns gRPC status of failed response, message is taken from WebDriver or selenosis error
+func (h *handlerResponse) err() error {
+	if h.code < http.StatusBadRequest {
+		return nil
+	}
+	var msg struct {
+		Value struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		} `json:"value"`
+	}
+	json.Unmarshal(h.body.Bytes(), &msg)
+	message := msg.Value.Message
+	if message == "" {
+		message = http.StatusText(h.code)
+	}
+
+	code := api.Unknown
+	switch h.code {
+	case http.StatusBadRequest:
+		code = api.InvalidArgument
+	case http.StatusUnauthorized:
+		code = api.Unauthenticated
+	case http.StatusForbidden:
+		code = api.PermissionDenied
+	case http.StatusNotFound:
+		code = api.NotFound
+	case http.StatusTooManyRequests:
+		code = api.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		code = api.Unavailable
+	case http.StatusGatewayTimeout:
+		code = api.DeadlineExceeded
+	default:
+		if h.code >= http.StatusInternalServerError {
+			code = api.Internal
+		}
+	}
+	if msg.Value.Error == selenium.ErrTimeout {
+		code = api.DeadlineExceeded
+	}
+	return api.Errorf(code, "%s", message)
+}
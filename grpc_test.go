@@ -0,0 +1,160 @@
+package selenosis
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/api"
+	"github.com/alcounit/selenosis/platform"
+	"gotest.tools/assert"
+)
+
+func grpcCall(app *App, ctx context.Context, method string, msg []byte) (*http.Response, [][]byte) {
+	body := &bytes.Buffer{}
+	api.WriteMessage(body, msg)
+	req := httptest.NewRequest(http.MethodPost, "/"+api.ServiceName+"/"+method, body).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/grpc")
+
+	rec := httptest.NewRecorder()
+	app.GRPC().ServeHTTP(rec, req)
+
+	res := rec.Result()
+	var messages [][]byte
+	for {
+		data, err := api.ReadMessage(res.Body)
+		if err != nil {
+			break
+		}
+		messages = append(messages, data)
+	}
+	return res, messages
+}
+
+func TestGRPCCreateSession(t *testing.T) {
+	tests := map[string]struct {
+		req     *api.CreateSessionRequest
+		status  string
+		message string
+	}{
+		"Verify session created with gRPC call": {
+			req:    &api.CreateSessionRequest{BrowserName: "chrome", BrowserVersion: "68.0"},
+			status: "0",
+		},
+		"Verify gRPC call with unknown browser": {
+			req:    &api.CreateSessionRequest{BrowserName: "unknown"},
+			status: "13",
+		},
+		"Verify gRPC call with invalid capabilities": {
+			req:     &api.CreateSessionRequest{BrowserName: "chrome", CapabilitiesJSON: "{"},
+			status:  "3",
+			message: "failed to parse capabilities: unexpected end of JSON input",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/wd/hub/session", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"value":{"sessionId":"sessionID","capabilities":{"browserName":"chrome"}}}`))
+		})
+		s := httptest.NewServer(mux)
+		defer s.Close()
+
+		u, _ := url.Parse(s.URL)
+		app := initApp(&PlatformMock{
+			service: platform.Service{
+				SessionID:  "sessionID",
+				CancelFunc: func() {},
+				URL:        u,
+			},
+		})
+
+		res, messages := grpcCall(app, context.Background(), "CreateSession", test.req.Marshal())
+
+		assert.Equal(t, test.status, res.Trailer.Get("Grpc-Status"))
+		if test.message != "" {
+			assert.Equal(t, test.message, res.Trailer.Get("Grpc-Message"))
+		}
+		if test.status != "0" {
+			continue
+		}
+		assert.Equal(t, 1, len(messages))
+
+		resp := &api.CreateSessionResponse{}
+		assert.NilError(t, resp.Unmarshal(messages[0]))
+		assert.Equal(t, "sessionID", resp.Session.SessionID)
+		assert.Equal(t, "chrome", resp.Session.BrowserName)
+		assert.Equal(t, `{"browserName":"chrome"}`, resp.CapabilitiesJSON)
+	}
+}
+
+func TestGRPCDeleteUnknownSession(t *testing.T) {
+	app := initApp(&PlatformMock{})
+
+	res, _ := grpcCall(app, context.Background(), "DeleteSession", (&api.DeleteSessionRequest{SessionID: "unknown"}).Marshal())
+
+	assert.Equal(t, "5", res.Trailer.Get("Grpc-Status"))
+}
+
+func TestGRPCListSessions(t *testing.T) {
+	app := initApp(&PlatformMock{})
+	started := time.Now()
+	app.stats.Sessions().Put("second", platform.Service{SessionID: "second", Started: started, Status: platform.Running,
+		Labels: map[string]string{"browserName": "firefox", "tenant": "team-a", runIDKey: "nightly"}})
+	app.stats.Sessions().Put("first", platform.Service{SessionID: "first", Started: started.Add(-time.Minute), Status: platform.Pending})
+
+	res, messages := grpcCall(app, context.Background(), "ListSessions", nil)
+
+	assert.Equal(t, "0", res.Trailer.Get("Grpc-Status"))
+	assert.Equal(t, 1, len(messages))
+
+	resp := &api.ListSessionsResponse{}
+	assert.NilError(t, resp.Unmarshal(messages[0]))
+	assert.Equal(t, 2, len(resp.Sessions))
+	assert.Equal(t, "first", resp.Sessions[0].SessionID)
+	assert.Equal(t, string(platform.Pending), resp.Sessions[0].Status)
+	assert.Equal(t, "second", resp.Sessions[1].SessionID)
+	assert.Equal(t, "firefox", resp.Sessions[1].BrowserName)
+	assert.Equal(t, "team-a", resp.Sessions[1].Tenant)
+	assert.Equal(t, "nightly", resp.Sessions[1].RunID)
+}
+
+func TestGRPCWatchSessions(t *testing.T) {
+	interval := sessionWatchInterval
+	sessionWatchInterval = 10 * time.Millisecond
+	defer func() { sessionWatchInterval = interval }()
+
+	app := initApp(&PlatformMock{})
+	app.stats.Sessions().Put("first", platform.Service{SessionID: "first", Status: platform.Pending})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		app.stats.Sessions().Put("first", platform.Service{SessionID: "first", Status: platform.Running})
+		time.Sleep(30 * time.Millisecond)
+		app.stats.Sessions().Delete("first")
+		time.Sleep(30 * time.Millisecond)
+		cancel()
+	}()
+
+	res, messages := grpcCall(app, ctx, "WatchSessions", nil)
+
+	assert.Equal(t, "1", res.Trailer.Get("Grpc-Status"))
+	assert.Equal(t, 3, len(messages))
+
+	var events []api.EventType
+	for _, msg := range messages {
+		event := &api.SessionEvent{}
+		assert.NilError(t, event.Unmarshal(msg))
+		assert.Equal(t, "first", event.Session.SessionID)
+		events = append(events, event.Type)
+	}
+	assert.DeepEqual(t, []api.EventType{api.Added, api.Updated, api.Deleted}, events)
+}
@@ -4,16 +4,22 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httputil"
+	"path"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/alcounit/selenosis/audit"
+	"github.com/alcounit/selenosis/metrics"
 	"github.com/alcounit/selenosis/platform"
 	"github.com/alcounit/selenosis/selenium"
 	"github.com/alcounit/selenosis/tools"
@@ -25,7 +31,9 @@ import (
 )
 
 var (
+	transport  = countingTransport()
 	httpClient = &http.Client{
+		Transport: transport,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
@@ -47,30 +55,92 @@ type Status struct {
 	Total    int                 `json:"total"`
 	Active   int                 `json:"active"`
 	Pending  int                 `json:"pending"`
+	Queued   int                 `json:"queued"`
 	Browsers map[string][]string `json:"config,omitempty"`
 	Sessions []platform.Service  `json:"sessions,omitempty"`
+	Quotas   []quotaUsage        `json:"quotas,omitempty"`
+	Continue string              `json:"continue,omitempty"`
+}
+
+type sessionInfo struct {
+	platform.Service
+	Status       platform.ServiceStatus `json:"status"`
+	LastActivity *time.Time             `json:"lastActivity,omitempty"`
+	IdleFor      string                 `json:"idleFor"`
+	Commands     int                    `json:"commands"`
+	Errors       int                    `json:"commandErrors"`
+	AvgLatency   string                 `json:"avgCommandLatency,omitempty"`
+	Stranded     bool                   `json:"stranded,omitempty"`
+}
+
+type quotaInfo struct {
+	platform.Quota
+	SessionLimit int             `json:"sessionLimit"`
+	Active       int             `json:"active"`
+	Pending      int             `json:"pending"`
+	Artifacts    []artifactQuota `json:"artifacts,omitempty"`
+	Quotas       []quotaUsage    `json:"quotas,omitempty"`
 }
 
 type response struct {
-	Status    int    `json:"status"`
-	Version   string `json:"version"`
-	Error     string `json:"err,omitempty"`
-	Selenosis Status `json:"selenosis,omitempty"`
+	Status    int        `json:"status"`
+	Version   string     `json:"version"`
+	Error     string     `json:"err,omitempty"`
+	Selenosis Status     `json:"selenosis,omitempty"`
+	Value     gridStatus `json:"value"`
 }
 
 // HandleSession ...
 func (app *App) HandleSession(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
-	logger := app.logger.WithFields(logrus.Fields{
-		"request_id": uuid.New(),
-		"request":    fmt.Sprintf("%s %s", r.Method, r.URL.Path),
-	})
+	logger := app.requestLogger(r, "")
 	logger.WithField("time_elapsed", tools.TimeElapsed(start)).Info("session")
 
+	event := audit.Event{Type: audit.SessionRejected}
+	defer func() {
+		app.auditRequest(r, event)
+		app.notifySessionRequest(event)
+	}()
+	reject := func(code, message string, statusCode int) {
+		event.Message = message
+		webDriverError(w, code, message, statusCode)
+	}
+
+	tenant, err := app.tenant(r)
+	if err != nil {
+		logger.WithField("time_elapsed", tools.TimeElapsed(start)).Errorf("failed to authenticate: %v", err)
+		w.Header().Set("WWW-Authenticate", `Basic realm="selenosis"`)
+		reject(selenium.ErrSessionNotCreated, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	event.Tenant = tenant.Name
+
+	principal, err := app.principal(r)
+	if err != nil {
+		logger.WithField("time_elapsed", tools.TimeElapsed(start)).Errorf("failed to authenticate: %v", err)
+		w.Header().Set("WWW-Authenticate", `Basic realm="selenosis"`)
+		reject(selenium.ErrSessionNotCreated, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	limited := rateLimitClient(r, principal, tenant)
+	if ok, retry := app.rateLimiter.allow(limited); !ok {
+		logger.WithField("time_elapsed", tools.TimeElapsed(start)).Warnf("session rate limit of %s exceeded", limited)
+		w.Header().Set("Retry-After", retryAfter(retry))
+		reject(selenium.ErrSessionNotCreated, fmt.Sprintf("session rate limit of %s exceeded, retry in %s", limited, retry.Round(time.Millisecond)), http.StatusTooManyRequests)
+		return
+	}
+
+	if app.Draining() {
+		logger.WithField("time_elapsed", tools.TimeElapsed(start)).Warn("selenosis is shutting down, session rejected")
+		reject(selenium.ErrSessionNotCreated, "selenosis is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		logger.WithField("time_elapsed", tools.TimeElapsed(start)).Errorf("failed to read request body: %v", err)
-		tools.JSONError(w, err.Error(), http.StatusBadRequest)
+		reject(selenium.ErrInvalidArgument, err.Error(), http.StatusBadRequest)
 		return
 	}
 	defer r.Body.Close()
@@ -80,7 +150,7 @@ func (app *App) HandleSession(w http.ResponseWriter, r *http.Request) {
 	err = json.Unmarshal(body, &request)
 	if err != nil {
 		logger.WithField("time_elapsed", tools.TimeElapsed(start)).Errorf("failed to parse request: %v", err)
-		tools.JSONError(w, err.Error(), http.StatusBadRequest)
+		reject(selenium.ErrInvalidArgument, err.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -101,7 +171,15 @@ func (app *App) HandleSession(w http.ResponseWriter, r *http.Request) {
 		mergo.Merge(&caps, *fmc)
 		caps.ValidateCapabilities()
 
-		browser, err = app.browsers.Find(caps.GetBrowserName(), caps.BrowserVersion)
+		browser, err = app.browsers.FindOnPlatform(caps.GetBrowserName(), caps.BrowserVersion, caps.Platform)
+		if caps.AppiumDeviceName != "" {
+			if device, derr := app.browsers.FindDevice(caps.AppiumDeviceName, caps.BrowserVersion, caps.Platform); derr == nil {
+				browser, err = device, nil
+			}
+		}
+		if err == nil {
+			browser, err = browser.ForArch(caps.Architecture)
+		}
 		if err == nil {
 			break
 		}
@@ -109,32 +187,207 @@ func (app *App) HandleSession(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 		logger.WithField("time_elapsed", tools.TimeElapsed(start)).Errorf("requested browser not found: %v", err)
-		tools.JSONError(w, err.Error(), http.StatusBadRequest)
+		reject(selenium.ErrSessionNotCreated, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	event.Browser, event.Version, event.RunID = browser.BrowserName, browser.BrowserVersion, caps.RunID
+
+	tenant, err = app.namespaceTenant(tenant, caps.GetNamespace())
+	if err != nil {
+		logger.WithField("time_elapsed", tools.TimeElapsed(start)).Errorf("failed to select namespace: %v", err)
+		if errors.Is(err, errNamespaceForbidden) {
+			reject(selenium.ErrSessionNotCreated, err.Error(), http.StatusForbidden)
+			return
+		}
+		reject(selenium.ErrInvalidArgument, err.Error(), http.StatusBadRequest)
+		return
+	}
+	event.Tenant = tenant.Name
+	logger = logger.WithFields(logrus.Fields{"browser": browser.BrowserName, "browser_version": browser.BrowserVersion})
+	if tenant.Name != "" {
+		logger = logger.WithField("tenant", tenant.Name)
+	}
+
+	if err := app.admit(&AdmissionRequest{Tenant: tenant.Name, Owner: principal.Name, Capabilities: &caps, Template: &browser}); err != nil {
+		logger.WithField("time_elapsed", tools.TimeElapsed(start)).Warnf("session rejected: %v", err)
+		reject(selenium.ErrSessionNotCreated, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	sessionTimeout, err := app.sessionTimeout(caps)
+	if err != nil {
+		logger.WithField("time_elapsed", tools.TimeElapsed(start)).Errorf("failed to parse session timeout: %v", err)
+		reject(selenium.ErrInvalidArgument, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if sessionTimeout > 0 {
+		logger.WithField("time_elapsed", tools.TimeElapsed(start)).Infof("session idle timeout: %s", sessionTimeout)
+	}
+
+	if err := validateEnv(caps.GetEnv()); err != nil {
+		logger.WithField("time_elapsed", tools.TimeElapsed(start)).Errorf("failed to parse browser env: %v", err)
+		reject(selenium.ErrInvalidArgument, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := platform.RequestedLabels(caps); err != nil {
+		logger.WithField("time_elapsed", tools.TimeElapsed(start)).Errorf("invalid session labels: %v", err)
+		reject(selenium.ErrInvalidArgument, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := app.resourceBounds.Validate(browser, caps); err != nil {
+		logger.WithField("time_elapsed", tools.TimeElapsed(start)).Errorf("invalid session resources: %v", err)
+		reject(selenium.ErrInvalidArgument, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := app.sessionDNS.Validate(caps); err != nil {
+		logger.WithField("time_elapsed", tools.TimeElapsed(start)).Errorf("invalid session dns: %v", err)
+		reject(selenium.ErrInvalidArgument, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	body, err = injectArgs(body, caps.GetBrowserName(), caps.GetArgs())
+	if err != nil {
+		logger.WithField("time_elapsed", tools.TimeElapsed(start)).Errorf("failed to add browser args: %v", err)
+		reject(selenium.ErrInvalidArgument, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	body, err = injectProfile(body, caps, browser)
+	if err != nil {
+		logger.WithField("time_elapsed", tools.TimeElapsed(start)).Errorf("failed to add browser profile: %v", err)
+		reject(selenium.ErrInvalidArgument, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if caps.GetCaptureHAR() {
+		if browser.Relay != "" {
+			reject(selenium.ErrInvalidArgument, "HAR capture is not available for relayed browsers", http.StatusBadRequest)
+			return
+		}
+		body, err = injectHARProxy(body)
+		if err != nil {
+			logger.WithField("time_elapsed", tools.TimeElapsed(start)).Errorf("failed to add HAR proxy: %v", err)
+			reject(selenium.ErrInvalidArgument, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	artifactsPolicy := app.artifactsOf(tenant)
+	if caps.Video {
+		exceeded, err := app.artifactsExceeded(tenant.Name, artifactsPolicy)
+		if err != nil {
+			logger.WithField("time_elapsed", tools.TimeElapsed(start)).Warnf("failed to check artifact storage quota: %v", err)
+		}
+		if exceeded {
+			logger.WithField("time_elapsed", tools.TimeElapsed(start)).Error("artifact storage quota exceeded")
+			reject(selenium.ErrSessionNotCreated, "artifact storage quota exceeded, video recording is not allowed", http.StatusForbidden)
+			return
+		}
+	}
+
+	client := app.quotas.client(r, principal)
+	quota, err := app.quotas.acquire(app.stats.Sessions().List(), browser.BrowserName, browser.BrowserVersion, client, browser.MaxSessions)
+	app.publishQuotaUsage()
+	if err != nil {
+		logger.WithField("time_elapsed", tools.TimeElapsed(start)).Warnf("session rejected: %v", err)
+		event.Message = err.Error()
+		var exceeded *quotaExceeded
+		if errors.As(err, &exceeded) {
+			quotaError(w, exceeded)
+			return
+		}
+		reject(selenium.ErrSessionNotCreated, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	defer func() {
+		quota.release()
+		app.publishQuotaUsage()
+	}()
+
+	if burst, ok := app.burstBrowser(browser); ok {
+		browser = burst
+		logger.WithField("time_elapsed", tools.TimeElapsed(start)).Warnf("local backend is overflowed or unhealthy, bursting session to %s", browser.Relay)
+		defer func() {
+			result := "failed"
+			if event.Type == audit.SessionCreated {
+				result = "created"
+			}
+			metrics.BurstSessions.WithLabelValues(browser.BrowserName, result).Inc()
+		}()
+	}
+
+	slot := &reservation{release: func() {}}
+	if browser.Relay == "" {
+		queued := time.Now()
+		slot, err = app.queue.acquire(r.Context(), app.freeCapacity)
+		if err != nil {
+			logger.WithField("time_elapsed", tools.TimeElapsed(start)).Warnf("session is not queued: %v", err)
+			if err == context.Canceled {
+				event.Message = "client disconnected"
+				return
+			}
+			code := selenium.ErrSessionNotCreated
+			if err == errQueueTimeout {
+				code = selenium.ErrTimeout
+			}
+			reject(code, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		defer slot.release()
+		if waited := time.Since(queued); waited >= queueCheckInterval {
+			logger.WithField("time_elapsed", tools.TimeElapsed(start)).Infof("session waited in queue for %s", waited.Round(time.Millisecond))
+		}
+	}
 
 	logger.WithField("time_elapsed", tools.TimeElapsed(start)).Infof("starting browser from image: %s", browser.Image)
 
 	image := parseImage(browser.Image)
 
+	retries := app.sessionRetryCount
+	if browser.RetryCount > 0 {
+		retries = browser.RetryCount
+	}
+
 	var service platform.Service
 	j := 1
 	for ; ; j++ {
+		sessionID := fmt.Sprintf("%s-%s", image, uuid.New())
+		quota.bind(sessionID)
+		slot.bind(sessionID)
 		service, err = app.client.Service().Create(platform.ServiceSpec{
-			SessionID:             fmt.Sprintf("%s-%s", image, uuid.New()),
+			SessionID:             sessionID,
+			Tenant:                tenant.Name,
+			Artifacts:             artifactsPolicy,
 			RequestedCapabilities: caps,
 			Template:              browser,
+			IdleTimeout:           sessionTimeout,
+			Client:                client,
+			Owner:                 principal.Name,
 		})
 		if err != nil {
 			logger.WithField("time_elapsed", tools.TimeElapsed(start)).Errorf("failed to start browser: %v", err)
-			if j < app.sessionRetryCount {
+			if j < retries {
+				backoff := browser.RetryBackoff(j)
+				logger.WithField("time_elapsed", tools.TimeElapsed(start)).Warnf("browser start retrying in %s: %d/%d", backoff, j, retries)
+				select {
+				case <-time.After(backoff):
+				case <-r.Context().Done():
+					event.Message = "client disconnected"
+					return
+				}
 				continue
 			}
-			tools.JSONError(w, "failed to start browser: "+err.Error(), http.StatusBadRequest)
+			reject(selenium.ErrSessionNotCreated, "failed to start browser: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
 		break
 	}
+	event.SessionID = service.SessionID
+	logger = logger.WithField("session_id", service.SessionID)
 
 	cancel := func() {
 		service.CancelFunc()
@@ -142,13 +395,28 @@ func (app *App) HandleSession(w http.ResponseWriter, r *http.Request) {
 
 	var resp *http.Response
 
-	service.URL.Path = r.URL.Path
+	if service.Relay {
+		service.URL.Path = path.Join(service.URL.Path, "session")
+		if browser.Cloud != nil {
+			body, err = browser.Cloud.Payload(browser, caps, service.URL.User)
+			if err != nil {
+				cancel()
+				logger.WithField("time_elapsed", tools.TimeElapsed(start)).Errorf("failed to translate capabilities: %v", err)
+				reject(selenium.ErrInvalidArgument, "Failed to translate capabilities: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+	} else {
+		service.URL.Path = r.URL.Path
+	}
+	sessionStart := time.Now()
 
 	i := 1
 	for ; ; i++ {
 		req, _ := http.NewRequest(http.MethodPost, service.URL.String(), bytes.NewReader(body))
 		req.Close = true
 		req.Header.Set("X-Forwarded-Selenosis", app.selenosisHost)
+		req.Header.Set(requestIDHeader, r.Header.Get(requestIDHeader))
 		ctx, done := context.WithTimeout(r.Context(), app.browserWaitTimeout)
 		rsp, err := httpClient.Do(req.WithContext(ctx))
 		defer done()
@@ -165,9 +433,10 @@ func (app *App) HandleSession(w http.ResponseWriter, r *http.Request) {
 					continue
 				}
 				logger.WithField("time_elapsed", tools.TimeElapsed(start)).Warn("service is not ready")
-				tools.JSONError(w, "New session attempts retry count exceeded", http.StatusInternalServerError)
+				reject(selenium.ErrTimeout, "New session attempts retry count exceeded", http.StatusInternalServerError)
 			case context.Canceled:
 				logger.WithField("time_elapsed", tools.TimeElapsed(start)).Warn("Client disconnected")
+				event.Message = "client disconnected"
 			}
 			cancel()
 			return
@@ -179,7 +448,7 @@ func (app *App) HandleSession(w http.ResponseWriter, r *http.Request) {
 				logger.WithField("time_elapsed", tools.TimeElapsed(start)).Warnf("session retrying for session failed: %d/%d", i, app.sessionRetryCount)
 				continue
 			}
-			tools.JSONError(w, "New session attempts retry count exceeded", http.StatusInternalServerError)
+			reject(selenium.ErrSessionNotCreated, "New session attempts retry count exceeded", http.StatusInternalServerError)
 			cancel()
 			return
 		}
@@ -196,15 +465,37 @@ func (app *App) HandleSession(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		cancel()
 		logger.WithField("time_elapsed", tools.TimeElapsed(start)).Errorf("unable to read service response: %v", err)
-		tools.JSONError(w, "Failed to read service response", http.StatusInternalServerError)
+		reject(selenium.ErrSessionNotCreated, "Failed to read service response", http.StatusInternalServerError)
 		return
 	}
 
+	if service.Relay && resp.StatusCode < http.StatusBadRequest {
+		if _, err := app.bindRelay(service, msg); err != nil {
+			cancel()
+			logger.WithField("time_elapsed", tools.TimeElapsed(start)).Errorf("failed to bind relayed session: %v", err)
+			reject(selenium.ErrSessionNotCreated, "Failed to bind relayed session: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+	}
+
+	if resp.StatusCode < http.StatusBadRequest {
+		rewriteWebSocketURL(r, msg)
+	}
+
+	phases := append(service.Phases, platform.Phase{Name: "session", Duration: time.Since(sessionStart)})
+	observePhases(phases)
+
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Server-Timing", serverTiming(phases))
 	w.WriteHeader(resp.StatusCode)
 	json.NewEncoder(w).Encode(msg)
 
 	logger.WithField("time_elapsed", tools.TimeElapsed(start)).Infof("browser sessionId: %s", service.SessionID)
+	if resp.StatusCode < http.StatusBadRequest {
+		event.Type = audit.SessionCreated
+	} else {
+		event.Message = fmt.Sprintf("browser responded with %d", resp.StatusCode)
+	}
 
 }
 
@@ -213,56 +504,120 @@ func (app *App) HandleProxy(w http.ResponseWriter, r *http.Request) {
 	sessionID, ok := mux.Vars(r)["sessionId"]
 	if !ok {
 		app.logger.WithField("request", fmt.Sprintf("%s %s", r.Method, r.URL.Path)).Error("session id not found")
-		tools.JSONError(w, "session id not found", http.StatusBadRequest)
+		webDriverError(w, selenium.ErrInvalidSessionID, "session id not found", http.StatusNotFound)
 		return
 	}
 
 	if !isValidSession(sessionID) {
 		app.logger.WithField("request", fmt.Sprintf("%s %s", r.Method, r.URL.Path)).Errorf("%s is not valid session id", sessionID)
-		tools.JSONError(w, "session id not found", http.StatusBadRequest)
+		webDriverError(w, selenium.ErrInvalidSessionID, "session id not found", http.StatusNotFound)
 		return
 	}
 
-	logger := app.logger.WithFields(logrus.Fields{
-		"request_id": uuid.New(),
-		"session_id": sessionID,
-		"request":    fmt.Sprintf("%s %s", r.Method, r.URL.Path),
-	})
+	logger := app.requestLogger(r, sessionID)
+
+	if code, err := app.authorizeSession(r, sessionID); err != nil {
+		logger.Errorf("session request is not allowed: %v", err)
+		if code == http.StatusUnauthorized {
+			w.Header().Set("WWW-Authenticate", `Basic realm="selenosis"`)
+		}
+		webDriverError(w, selenium.ErrUnknown, err.Error(), code)
+		return
+	}
+
+	deleteSession := isDeleteSession(r, sessionID)
+	if deleteSession {
+		app.auditSessionRequest(r, audit.SessionDeleteRequested, sessionID, "")
+	}
+
+	if reason, lost := app.lostSession(sessionID); lost {
+		if deleteSession {
+			logger.Info("lost session deleted")
+			app.stats.Stranded().Delete(sessionID)
+			if err := app.client.Service().Delete(sessionID); err != nil {
+				logger.Debugf("pod of lost session is already gone: %v", err)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{"value": nil})
+			return
+		}
+		logger.Warnf("command of lost session rejected, browser pod was disrupted: %s", reason)
+		webDriverError(w, selenium.ErrInvalidSessionID, lostMessage(sessionID, reason), http.StatusNotFound)
+		return
+	}
+
+	if deleteSession {
+		app.captureHAR(sessionID)
+	}
+
+	start := time.Now()
+	app.recordActivity(sessionID, start)
+
+	failed := false
+	defer func() {
+		duration := time.Since(start)
+		app.stats.Commands().Add(sessionID, duration, failed)
+
+		browser := "unknown"
+		if service, ok := app.stats.Sessions().Get(sessionID); ok && service.Labels["browserName"] != "" {
+			browser = service.Labels["browserName"]
+		}
+		result := "success"
+		if failed {
+			result = "error"
+		}
+		metrics.ProxiedCommands.WithLabelValues(browser, result).Inc()
+		metrics.CommandDuration.WithLabelValues(browser).Observe(duration.Seconds())
+	}()
 
 	r.URL.Scheme = "http"
-	r.Host = sessionID + "." + app.serviceName + ":" + app.sidecarPort
+	r.Host = app.sessionHost(sessionID, app.sidecarPort)
 	r.URL.Host = r.Host
 	r.Header.Set("X-Forwarded-Selenosis", app.selenosisHost)
 
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		logger.Errorf("Body readform err: %v", err)
+	relayed, isRelayed := app.relayedSession(sessionID)
+	if isRelayed {
+		r.URL = relayURL(relayed, r.URL)
+		r.Host = r.URL.Host
+		r.Header.Del("Authorization")
+		if user := relayed.URL.User; user != nil {
+			password, _ := user.Password()
+			r.SetBasicAuth(user.Username(), password)
+		}
 	}
 
+	body := &streamBody{Reader: r.Body}
+
+	var proxyErr error
 	i := 1
 	for ; ; i++ {
 
 		rCopy := r.Clone(r.Context())
-
-		r.Body = ioutil.NopCloser(bytes.NewReader(body))
-		rCopy.Body = ioutil.NopCloser(bytes.NewReader(body))
-		r.ParseForm()
+		rCopy.Body = body
 
 		retryLoop := true
 		revp := (&httputil.ReverseProxy{
+			Transport: app.proxyTransport,
 			Director: func(rCopy *http.Request) {
-				logger.Infof("proxying session -> Body=%v", string(body))
+				logger.Infof("proxying session, content length: %d", rCopy.ContentLength)
 				retryLoop = true
+				proxyErr = nil
+			},
+			ModifyResponse: func(resp *http.Response) error {
+				failed = resp.StatusCode >= http.StatusBadRequest
+				return nil
 			},
 			ErrorHandler: func(w http.ResponseWriter, rCopy *http.Request, err error) {
+				failed = true
 				retryLoop = false
+				proxyErr = err
 				logger.Errorf("proxying session error (%d/%d): %v", i, app.sessionRetryCount, err)
-				if !strings.Contains(err.Error(), "no such host") || i == app.sessionRetryCount {
+				if !strings.Contains(err.Error(), "no such host") || i == app.sessionRetryCount || body.consumed() {
 					retryLoop = true
 					if strings.Contains(err.Error(), "no such host") {
-						tools.JSONError(w, err.Error(), http.StatusBadRequest)
+						webDriverError(w, selenium.ErrInvalidSessionID, err.Error(), http.StatusNotFound)
 					} else {
-						tools.JSONError(w, fmt.Sprintf("proxing session error: %v", err.Error()), http.StatusBadRequest)
+						webDriverError(w, selenium.ErrUnknown, fmt.Sprintf("proxing session error: %v", err.Error()), http.StatusInternalServerError)
 					}
 				}
 			},
@@ -274,18 +629,25 @@ func (app *App) HandleProxy(w http.ResponseWriter, r *http.Request) {
 			break
 		}
 	}
+
+	if proxyErr != nil && r.Context().Err() == nil {
+		app.proxyFailed(sessionID, deleteSession)
+	} else {
+		app.proxyFailures.reset(sessionID)
+	}
+
+	if isRelayed && deleteSession && !failed {
+		app.releaseRelay(sessionID)
+	}
 }
 
 // HandleHubStatus ...
-func (app *App) HandleHubStatus(w http.ResponseWriter, _ *http.Request) {
+func (app *App) HandleHubStatus(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	json.NewEncoder(w).Encode(
 		map[string]interface{}{
-			"value": map[string]interface{}{
-				"message": "selenosis up and running",
-				"ready":   len(app.stats.Sessions().List()),
-			},
+			"value": app.gridStatus(r),
 		})
 }
 
@@ -294,27 +656,24 @@ func (app *App) HandleReverseProxy(w http.ResponseWriter, r *http.Request) {
 	sessionID, ok := mux.Vars(r)["sessionId"]
 	if !ok {
 		app.logger.WithField("request", fmt.Sprintf("%s %s", r.Method, r.URL.Path)).Error("session id not found")
-		tools.JSONError(w, "session id not found", http.StatusBadRequest)
+		webDriverError(w, selenium.ErrInvalidSessionID, "session id not found", http.StatusNotFound)
 		return
 	}
 
 	if !isValidSession(sessionID) {
 		app.logger.WithField("request", fmt.Sprintf("%s %s", r.Method, r.URL.Path)).Errorf("%s is not valid session id", sessionID)
-		tools.JSONError(w, "session id not found", http.StatusBadRequest)
+		webDriverError(w, selenium.ErrInvalidSessionID, "session id not found", http.StatusNotFound)
 		return
 	}
 
-	logger := app.logger.WithFields(logrus.Fields{
-		"request_id": uuid.New(),
-		"session_id": sessionID,
-		"request":    fmt.Sprintf("%s %s", r.Method, r.URL.Path),
-	})
+	logger := app.requestLogger(r, sessionID)
 
 	fragments := strings.Split(r.URL.Path, "/")
 	(&httputil.ReverseProxy{
+		Transport: transport,
 		Director: func(r *http.Request) {
 			r.URL.Scheme = "http"
-			r.Host = sessionID + "." + app.serviceName + ":" + app.sidecarPort
+			r.Host = app.sessionHost(sessionID, app.sidecarPort)
 			r.URL.Host = r.Host
 			r.Header.Set("X-Forwarded-Selenosis", app.selenosisHost)
 			logger.Infof("proxying %s", fragments[1])
@@ -342,12 +701,8 @@ func (app *App) HandleVNC() websocket.Handler {
 			return
 		}
 
-		host := tools.BuildHostPort(sessionID, app.serviceName, "5900")
-		logger := app.logger.WithFields(logrus.Fields{
-			"request_id": uuid.New(),
-			"session_id": sessionID,
-			"request":    fmt.Sprintf("%s %s", wsconn.Request().Method, wsconn.Request().URL.Path),
-		})
+		host := app.sessionHost(sessionID, vncPort)
+		logger := app.requestLogger(wsconn.Request(), sessionID)
 		logger.Infof("vnc request: %s", host)
 
 		conn, err := dialer.DialContext(wsconn.Request().Context(), "tcp", host)
@@ -383,11 +738,7 @@ func (app *App) HandleLogs() websocket.Handler {
 			return
 		}
 
-		logger := app.logger.WithFields(logrus.Fields{
-			"request_id": uuid.New(),
-			"session_id": sessionID,
-			"request":    fmt.Sprintf("%s %s", wsconn.Request().Method, wsconn.Request().URL.Path),
-		})
+		logger := app.requestLogger(wsconn.Request(), sessionID)
 		logger.Infof("stream logs request: %s", fmt.Sprintf("%s.%s", sessionID, app.serviceName))
 
 		conn, err := app.client.Service().Logs(wsconn.Request().Context(), sessionID)
@@ -409,9 +760,14 @@ func (app *App) HandleLogs() websocket.Handler {
 }
 
 // HandleStatus ...
-func (app *App) HandleStatus(w http.ResponseWriter, _ *http.Request) {
+func (app *App) HandleStatus(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	if app.statusFormat == SelenoidStatusFormat {
+		json.NewEncoder(w).Encode(app.selenoidStatus())
+		return
+	}
+
 	var active []platform.Service
 	var pending int
 	for _, s := range app.stats.Sessions().List() {
@@ -424,17 +780,129 @@ func (app *App) HandleStatus(w http.ResponseWriter, _ *http.Request) {
 		}
 	}
 
+	status := Status{
+		Total:    app.sessionLimit,
+		Active:   len(active),
+		Pending:  pending,
+		Queued:   app.queue.Len(),
+		Browsers: app.browsers.GetBrowserVersions(),
+		Sessions: active,
+		Quotas:   app.quotas.usage(app.stats.Sessions().List(), app.browsers.Browsers()),
+	}
+	opts, ok, err := listOptions(r)
+	if err != nil {
+		webDriverError(w, selenium.ErrInvalidArgument, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if ok {
+		list, err := platform.ListSessions(app.client, opts)
+		switch {
+		case err == platform.ErrInvalidContinue:
+			webDriverError(w, selenium.ErrInvalidArgument, err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			webDriverError(w, selenium.ErrUnknown, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for i := range list.Services {
+			list.Services[i].Uptime = tools.TimeElapsed(list.Services[i].Started)
+		}
+		status.Sessions, status.Continue = list.Services, list.Continue
+	}
+
 	json.NewEncoder(w).Encode(
 		response{
-			Status:  http.StatusOK,
-			Version: app.buildVersion,
-			Selenosis: Status{
-				Total:    app.sessionLimit,
-				Active:   len(active),
-				Pending:  pending,
-				Browsers: app.browsers.GetBrowserVersions(),
-				Sessions: active,
-			},
+			Status:    http.StatusOK,
+			Version:   app.buildVersion,
+			Selenosis: status,
+			Value:     app.gridStatus(r),
+		},
+	)
+}
+
+//listOptions returns options of /status sessions list, sessions are listed by platform only when any
+//filter or page is requested
+func listOptions(r *http.Request) (platform.ListOptions, bool, error) {
+	query := r.URL.Query()
+	opts := platform.ListOptions{
+		BrowserName:    query.Get("browserName"),
+		BrowserVersion: query.Get("version"),
+		Labels:         query["label"],
+		Continue:       query.Get("continue"),
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil || n <= 0 {
+			return opts, false, fmt.Errorf("invalid limit %s", limit)
+		}
+		opts.Limit = n
+	}
+	if opts.BrowserName == "" && opts.BrowserVersion == "" && len(opts.Labels) == 0 && opts.Limit == 0 && opts.Continue == "" {
+		return opts, false, nil
+	}
+	if err := opts.Validate(); err != nil {
+		return opts, false, err
+	}
+	return opts, true, nil
+}
+
+func (app *App) sessionInfo(s platform.Service) sessionInfo {
+	s.Uptime = tools.TimeElapsed(s.Started)
+	info := sessionInfo{Service: s, Status: s.Status, IdleFor: tools.TimeElapsed(s.Started)}
+	if last, ok := app.stats.Activity().Get(s.SessionID); ok {
+		info.LastActivity = &last
+		info.IdleFor = tools.TimeElapsed(last)
+	}
+	if stats, ok := app.stats.Commands().Get(s.SessionID); ok && stats.Commands > 0 {
+		info.Commands = stats.Commands
+		info.Errors = stats.Errors
+		info.AvgLatency = fmt.Sprintf("%.3fs", (stats.Duration / time.Duration(stats.Commands)).Seconds())
+	}
+	_, info.Stranded = app.stats.Stranded().Get(s.SessionID)
+	return info
+}
+
+// HandleSessions ...
+func (app *App) HandleSessions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	selectors := r.URL.Query()["label"]
+	sessions := make([]sessionInfo, 0)
+	for _, s := range app.stats.Sessions().List() {
+		if !platform.MatchLabels(s.SessionLabels, selectors) {
+			continue
+		}
+		sessions = append(sessions, app.sessionInfo(s))
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Started.Before(sessions[j].Started)
+	})
+
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// HandleQuota ...
+func (app *App) HandleQuota(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var active, pending int
+	for _, s := range app.stats.Sessions().List() {
+		switch s.Status {
+		case platform.Running:
+			active++
+		case platform.Pending:
+			pending++
+		}
+	}
+
+	json.NewEncoder(w).Encode(
+		quotaInfo{
+			Quota:        app.stats.Quota().Get(),
+			SessionLimit: app.sessionLimit,
+			Active:       active,
+			Pending:      pending,
+			Artifacts:    app.artifactQuotas(),
+			Quotas:       app.quotas.usage(app.stats.Sessions().List(), app.browsers.Browsers()),
 		},
 	)
 }
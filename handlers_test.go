@@ -3,13 +3,19 @@ package selenosis
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"runtime"
+	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/alcounit/selenosis/config"
@@ -26,6 +32,8 @@ const (
 	status    = "/status"
 )
 
+var emptyGridStatus = `{"ready":true,"message":"Selenium Grid ready.","nodes":[{"id":"bb249745-18e2-5f75-8ee2-e38d245b745b","uri":"http://","maxSessions":0,"osInfo":{"arch":"` + runtime.GOARCH + `","name":"` + runtime.GOOS + `","version":""},"heartbeatPeriod":60000,"availability":"UP","version":"","slots":[]}]}`
+
 func TestNewSessionRequestErrors(t *testing.T) {
 	tests := map[string]struct {
 		body     io.Reader
@@ -35,27 +43,37 @@ func TestNewSessionRequestErrors(t *testing.T) {
 		"Verify new session call with body error request": {
 			body:     errReader(0),
 			respCode: http.StatusBadRequest,
-			respBody: `{"code":400,"value":{"message":"test error"}}`,
+			respBody: `{"value":{"error":"invalid argument","message":"test error","stacktrace":""}}`,
 		},
 		"Verify new session call with empty body request": {
 			body:     bytes.NewReader([]byte("")),
 			respCode: http.StatusBadRequest,
-			respBody: `{"code":400,"value":{"message":"unexpected end of JSON input"}}`,
+			respBody: `{"value":{"error":"invalid argument","message":"unexpected end of JSON input","stacktrace":""}}`,
 		},
 		"Verify new session call with empty json body request": {
 			body:     bytes.NewReader([]byte("{}")),
-			respCode: http.StatusBadRequest,
-			respBody: `{"code":400,"value":{"message":"unknown browser name "}}`,
+			respCode: http.StatusInternalServerError,
+			respBody: `{"value":{"error":"session not created","message":"unknown browser name ","stacktrace":""}}`,
 		},
 		"Verify new session call with wrong json body request": {
 			body:     bytes.NewReader([]byte("{{}")),
 			respCode: http.StatusBadRequest,
-			respBody: `{"code":400,"value":{"message":"invalid character '{' looking for beginning of object key string"}}`,
+			respBody: `{"value":{"error":"invalid argument","message":"invalid character '{' looking for beginning of object key string","stacktrace":""}}`,
 		},
 		"Verify new session call with unknown browser name in request": {
 			body:     bytes.NewReader([]byte(`{"capabilities":{"firstMatch":[{"browserName":"amigo", "browserVersion":"9.0"}]}}`)),
+			respCode: http.StatusInternalServerError,
+			respBody: `{"value":{"error":"session not created","message":"unknown browser name amigo","stacktrace":""}}`,
+		},
+		"Verify new session call with reserved label": {
+			body:     bytes.NewReader([]byte(`{"capabilities":{"alwaysMatch":{"browserName":"chrome","browserVersion":"86.0","selenosis:options":{"labels":{"session":"mine"}}}}}`)),
 			respCode: http.StatusBadRequest,
-			respBody: `{"code":400,"value":{"message":"unknown browser name amigo"}}`,
+			respBody: `{"value":{"error":"invalid argument","message":"label session is reserved","stacktrace":""}}`,
+		},
+		"Verify new session call with resources exceeding browser limit": {
+			body:     bytes.NewReader([]byte(`{"capabilities":{"alwaysMatch":{"browserName":"chrome","browserVersion":"86.0","selenosis:options":{"memoryRequest":"2Gi"}}}}`)),
+			respCode: http.StatusBadRequest,
+			respBody: `{"value":{"error":"invalid argument","message":"memory request 2Gi exceeds memory limit 1Gi","stacktrace":""}}`,
 		},
 	}
 
@@ -99,8 +117,8 @@ func TestNewSessionOnPlatformError(t *testing.T) {
 		"Verify new session call when browser not started": {
 			reqBody:  bytes.NewReader([]byte(`{"capabilities":{"firstMatch":[{"browserName":"chrome", "browserVersion":"68.0"}]}}`)),
 			err:      errors.New("failed to create pod"),
-			respCode: http.StatusBadRequest,
-			respBody: `{"code":400,"value":{"message":"failed to create pod"}}`,
+			respCode: http.StatusInternalServerError,
+			respBody: `{"value":{"error":"session not created","message":"failed to start browser: failed to create pod","stacktrace":""}}`,
 		},
 	}
 
@@ -136,6 +154,140 @@ func TestNewSessionOnPlatformError(t *testing.T) {
 
 }
 
+func TestProxyErrors(t *testing.T) {
+	tests := map[string]struct {
+		sessionID string
+		respCode  int
+		respBody  string
+	}{
+		"Verify invalid session id is rejected": {
+			sessionID: "session",
+			respCode:  http.StatusNotFound,
+			respBody:  `{"value":{"error":"invalid session id","message":"session id not found","stacktrace":""}}`,
+		},
+		"Verify unreachable browser is reported": {
+			sessionID: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491",
+			respCode:  http.StatusInternalServerError,
+			respBody:  `{"value":{"error":"unknown error","message":"proxing session error: dial tcp 127.0.0.1:1: connect: connection refused","stacktrace":""}}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		app.sessionRetryCount = 1
+		app.stats.Sessions().Put(test.sessionID, platform.Service{SessionID: test.sessionID, URL: &url.URL{Scheme: "http", Host: "127.0.0.1:1"}})
+		app.sidecarPort = "1"
+
+		router := mux.NewRouter()
+		router.PathPrefix("/wd/hub/session/{sessionId}").HandlerFunc(app.HandleProxy)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/wd/hub/session/"+test.sessionID+"/url", nil))
+
+		assert.Equal(t, test.respCode, rr.Code)
+		assert.Equal(t, test.respBody, strings.TrimSpace(rr.Body.String()))
+	}
+}
+
+func TestNewSessionAppiumDevice(t *testing.T) {
+	tmp, err := ioutil.TempFile("", "browsers.yaml")
+	assert.NilError(t, err)
+	defer os.Remove(tmp.Name())
+	tmp.WriteString(`---
+chrome:
+  defaultVersion: "85.0"
+  versions:
+    "85.0":
+      image: selenoid/vnc:chrome_85.0
+Pixel_4:
+  type: appium
+  defaultVersion: "11.0"
+  versions:
+    "11.0":
+      image: budtmo/docker-android-x86-11.0
+`)
+	tmp.Close()
+	browsers, err := config.NewBrowsersConfig(tmp.Name())
+	assert.NilError(t, err)
+
+	tests := map[string]struct {
+		reqBody string
+		browser string
+		image   string
+	}{
+		"Verify mobile browser is matched by appium device": {
+			reqBody: `{"capabilities":{"alwaysMatch":{"browserName":"chrome","platformName":"Android","appium:deviceName":"Pixel_4"}}}`,
+			browser: "Pixel_4",
+			image:   "budtmo/docker-android-x86-11.0",
+		},
+		"Verify appium device alone is matched": {
+			reqBody: `{"capabilities":{"alwaysMatch":{"platformName":"Android","appium:deviceName":"Pixel_4"}}}`,
+			browser: "Pixel_4",
+			image:   "budtmo/docker-android-x86-11.0",
+		},
+		"Verify browser is matched when device is not configured": {
+			reqBody: `{"capabilities":{"alwaysMatch":{"browserName":"chrome","appium:deviceName":"Pixel_5"}}}`,
+			browser: "chrome",
+			image:   "selenoid/vnc:chrome_85.0",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		client := &PlatformMock{err: errors.New("failed to create pod")}
+		app := initApp(client)
+		app.browsers = browsers
+
+		req, err := http.NewRequest(http.MethodPost, session, bytes.NewReader([]byte(test.reqBody)))
+		assert.NilError(t, err)
+		rr := httptest.NewRecorder()
+		app.HandleSession(rr, req)
+
+		assert.Equal(t, test.browser, client.layout.Template.BrowserName)
+		assert.Equal(t, test.image, client.layout.Template.Image)
+	}
+}
+
+func TestNewSessionRetryBackoff(t *testing.T) {
+
+	tests := map[string]struct {
+		browsers string
+		attempts int
+		waited   time.Duration
+	}{
+		"Verify browser start is retried session retry count times without template settings": {
+			browsers: `{"chrome": {"defaultVersion": "85.0", "path": "/", "versions": {"85.0": {"image": "selenoid/vnc:chrome_85.0"}}}}`,
+			attempts: 2,
+		},
+		"Verify browser start is retried with backoff of browser template": {
+			browsers: `{"chrome": {"defaultVersion": "85.0", "path": "/", "retryDelay": "20ms", "versions": {"85.0": {"image": "selenoid/vnc:chrome_85.0", "retryCount": 3}}}}`,
+			attempts: 3,
+			waited:   60 * time.Millisecond,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		client := &PlatformMock{
+			err: errors.New("pod is not ready after creation: pod exited early with status Failed"),
+		}
+		app := initApp(client)
+		assert.NilError(t, app.browsers.Load([]byte(test.browsers)))
+
+		start := time.Now()
+		rr := httptest.NewRecorder()
+		app.HandleSession(rr, httptest.NewRequest(http.MethodPost, session, bytes.NewReader([]byte(`{"desiredCapabilities":{"browserName":"chrome"}}`))))
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Equal(t, test.attempts, client.created)
+		assert.Assert(t, time.Since(start) >= test.waited)
+	}
+}
+
 func TestNewSessionOnBrowserNetworkError(t *testing.T) {
 
 	tests := map[string]struct {
@@ -146,7 +298,7 @@ func TestNewSessionOnBrowserNetworkError(t *testing.T) {
 		"Verify new session call to browser is not responding": {
 			reqBody:  bytes.NewReader([]byte(`{"capabilities":{"firstMatch":[{"browserName":"chrome", "browserVersion":"68.0"}]}}`)),
 			respCode: http.StatusInternalServerError,
-			respBody: `{"code":500,"value":{"message":"New session attempts retry count exceeded"}}`,
+			respBody: `{"value":{"error":"session not created","message":"New session attempts retry count exceeded","stacktrace":""}}`,
 		},
 	}
 
@@ -259,7 +411,7 @@ func TestNewSessionOnRequestTimeout(t *testing.T) {
 		"Verify new session on cancel request": {
 			reqBody:  bytes.NewReader([]byte(`{"capabilities":{"firstMatch":[{"browserName":"chrome", "browserVersion":"68.0"}]}}`)),
 			respCode: http.StatusInternalServerError,
-			respBody: `{"code":500,"value":{"message":"New session attempts retry count exceeded"}}`,
+			respBody: `{"value":{"error":"timeout","message":"New session attempts retry count exceeded","stacktrace":""}}`,
 		},
 	}
 	for name, test := range tests {
@@ -321,7 +473,7 @@ func TestNewSessionResponseCodeError(t *testing.T) {
 		"Verify new session call to browser response code error": {
 			reqBody:  bytes.NewReader([]byte(`{"capabilities":{"firstMatch":[{"browserName":"chrome", "browserVersion":"68.0"}]}}`)),
 			respCode: http.StatusInternalServerError,
-			respBody: `{"code":500,"value":{"message":"Failed to read service response"}}`,
+			respBody: `{"value":{"error":"session not created","message":"Failed to read service response","stacktrace":""}}`,
 		},
 	}
 
@@ -379,7 +531,7 @@ func TestNewSessionResponseBodyError(t *testing.T) {
 		"Verify new session call to browser response error": {
 			reqBody:  bytes.NewReader([]byte(`{"capabilities":{"firstMatch":[{"browserName":"chrome", "browserVersion":"68.0"}]}}`)),
 			respCode: http.StatusInternalServerError,
-			respBody: `{"code":500,"value":{"message":"Failed to read service response"}}`,
+			respBody: `{"value":{"error":"session not created","message":"Failed to read service response","stacktrace":""}}`,
 		},
 	}
 
@@ -494,7 +646,7 @@ func TestHandleHubStatus(t *testing.T) {
 	}{
 		"Verify hub status when no active session present": {
 			respCode: http.StatusOK,
-			respBody: `{"value":{"message":"selenosis up and running","ready":0}}`,
+			respBody: `{"value":` + emptyGridStatus + `}`,
 			stats:    storage.New(),
 		},
 	}
@@ -536,7 +688,7 @@ func TestHandleStatus(t *testing.T) {
 		respBody string
 	}{
 		"Verify status when no active session running": {
-			respBody: `{"status":200,"version":"","selenosis":{"total":0,"active":0,"pending":0,"config":{"chrome":["68.0","86.0"],"firefox":["45.0","47.0"],"opera":["66.0","71.0"]}}}`,
+			respBody: `{"status":200,"version":"","selenosis":{"total":0,"active":0,"pending":0,"queued":0,"config":{"chrome":["68.0","86.0"],"firefox":["45.0","47.0"],"opera":["66.0","71.0"]}},"value":` + emptyGridStatus + `}`,
 		},
 	}
 
@@ -569,6 +721,389 @@ func TestHandleStatus(t *testing.T) {
 
 }
 
+func TestHandleStatusList(t *testing.T) {
+	tests := map[string]struct {
+		query    string
+		code     int
+		sessions []string
+		cont     string
+		err      string
+	}{
+		"Verify sessions are filtered by browser name and version": {
+			query:    "?browserName=chrome&version=85.0",
+			code:     http.StatusOK,
+			sessions: []string{"chrome-85-0-a", "chrome-85-0-b"},
+		},
+		"Verify sessions are filtered by label": {
+			query:    "?label=build=1234",
+			code:     http.StatusOK,
+			sessions: []string{"chrome-85-0-a"},
+		},
+		"Verify first page of sessions returns continue token": {
+			query:    "?limit=2",
+			code:     http.StatusOK,
+			sessions: []string{"chrome-85-0-a", "chrome-85-0-b"},
+			cont:     "2",
+		},
+		"Verify last page of sessions is listed with continue token": {
+			query:    "?limit=2&continue=2",
+			code:     http.StatusOK,
+			sessions: []string{"firefox-81-0-c"},
+		},
+		"Verify invalid limit is rejected": {
+			query: "?limit=-1",
+			code:  http.StatusBadRequest,
+			err:   "invalid limit -1",
+		},
+		"Verify invalid continue token is rejected": {
+			query: "?continue=abc",
+			code:  http.StatusBadRequest,
+			err:   "invalid or expired continue token",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		client := &PlatformMock{state: platform.PlatformState{Services: []platform.Service{
+			{SessionID: "firefox-81-0-c", Status: platform.Running, Labels: map[string]string{"browserName": "firefox", "browserVersion": "81.0"}},
+			{SessionID: "chrome-85-0-b", Status: platform.Running, Labels: map[string]string{"browserName": "chrome", "browserVersion": "85.0"}},
+			{SessionID: "chrome-85-0-a", Status: platform.Running, Labels: map[string]string{"browserName": "chrome", "browserVersion": "85.0"}, SessionLabels: map[string]string{"build": "1234"}},
+			{SessionID: "chrome-85-0-pending", Status: platform.Pending, Labels: map[string]string{"browserName": "chrome", "browserVersion": "85.0"}},
+		}}}
+		app := initApp(client)
+
+		req, err := http.NewRequest(http.MethodGet, status+test.query, nil)
+		assert.NilError(t, err)
+		rr := httptest.NewRecorder()
+		app.HandleStatus(rr, req)
+
+		assert.Equal(t, test.code, rr.Code)
+		if test.err != "" {
+			assert.Assert(t, bytes.Contains(rr.Body.Bytes(), []byte(test.err)))
+			continue
+		}
+
+		var resp struct {
+			Selenosis struct {
+				Sessions []struct {
+					ID string `json:"id"`
+				} `json:"sessions"`
+				Continue string `json:"continue"`
+			} `json:"selenosis"`
+		}
+		assert.NilError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		ids := make([]string, 0)
+		for _, s := range resp.Selenosis.Sessions {
+			ids = append(ids, s.ID)
+		}
+		assert.DeepEqual(t, test.sessions, ids)
+		assert.Equal(t, test.cont, resp.Selenosis.Continue)
+	}
+}
+
+func TestHandleSessions(t *testing.T) {
+	tests := map[string]struct {
+		respBody string
+	}{
+		"Verify sessions list when no session running": {
+			respBody: `[]`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		req, err := http.NewRequest(http.MethodGet, "/sessions", nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		app.HandleSessions(rr, req)
+
+		res := rr.Result()
+		defer res.Body.Close()
+
+		b, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("could not read response: %v", err)
+		}
+
+		body := string(bytes.TrimSpace(b))
+
+		assert.Equal(t, test.respBody, body)
+	}
+}
+
+func TestHandleSessionsIdle(t *testing.T) {
+	tests := map[string]struct {
+		activity     bool
+		lastActivity bool
+	}{
+		"Verify idle time counted from session start without proxied commands": {
+			activity: false,
+		},
+		"Verify idle time counted from last proxied command": {
+			activity:     true,
+			lastActivity: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		sessionID := "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491"
+		app.stats.Sessions().Put(sessionID, platform.Service{SessionID: sessionID, Started: time.Now().Add(-time.Hour)})
+		if test.activity {
+			app.stats.Activity().Put(sessionID, time.Now())
+		}
+
+		req, err := http.NewRequest(http.MethodGet, "/sessions", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		app.HandleSessions(rr, req)
+
+		var sessions []struct {
+			LastActivity *time.Time `json:"lastActivity"`
+			IdleFor      string     `json:"idleFor"`
+		}
+		err = json.NewDecoder(rr.Body).Decode(&sessions)
+		assert.NilError(t, err)
+		assert.Equal(t, 1, len(sessions))
+		assert.Equal(t, test.lastActivity, sessions[0].LastActivity != nil)
+
+		idle, err := time.ParseDuration(sessions[0].IdleFor)
+		assert.NilError(t, err)
+		assert.Equal(t, test.lastActivity, idle < time.Minute)
+	}
+}
+
+func TestHandleSessionsCommands(t *testing.T) {
+	tests := map[string]struct {
+		durations  []time.Duration
+		failed     []bool
+		commands   int
+		errors     int
+		avgLatency string
+	}{
+		"Verify no command stats without proxied commands": {},
+		"Verify command stats of proxied commands": {
+			durations:  []time.Duration{time.Second, 2 * time.Second, 3 * time.Second},
+			failed:     []bool{false, true, false},
+			commands:   3,
+			errors:     1,
+			avgLatency: "2.000s",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		sessionID := "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491"
+		app.stats.Sessions().Put(sessionID, platform.Service{SessionID: sessionID, Started: time.Now()})
+		for i, d := range test.durations {
+			app.stats.Commands().Add(sessionID, d, test.failed[i])
+		}
+
+		req, err := http.NewRequest(http.MethodGet, "/sessions", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		app.HandleSessions(rr, req)
+
+		var sessions []struct {
+			Commands   int    `json:"commands"`
+			Errors     int    `json:"commandErrors"`
+			AvgLatency string `json:"avgCommandLatency"`
+		}
+		err = json.NewDecoder(rr.Body).Decode(&sessions)
+		assert.NilError(t, err)
+		assert.Equal(t, 1, len(sessions))
+		assert.Equal(t, test.commands, sessions[0].Commands)
+		assert.Equal(t, test.errors, sessions[0].Errors)
+		assert.Equal(t, test.avgLatency, sessions[0].AvgLatency)
+	}
+}
+
+func TestHandleSessionsLabels(t *testing.T) {
+	tests := map[string]struct {
+		query    string
+		sessions []string
+	}{
+		"Verify all sessions are listed without label selector": {
+			sessions: []string{"chrome-85-0-build", "chrome-85-0-ticket", "chrome-85-0-unlabeled"},
+		},
+		"Verify sessions are filtered by label value": {
+			query:    "?label=build=1234",
+			sessions: []string{"chrome-85-0-build"},
+		},
+		"Verify sessions are filtered by label key": {
+			query:    "?label=ticket",
+			sessions: []string{"chrome-85-0-ticket"},
+		},
+		"Verify sessions match every label selector": {
+			query:    "?label=build=1234&label=ticket",
+			sessions: []string{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		now := time.Now()
+		app.stats.Sessions().Put("chrome-85-0-build", platform.Service{SessionID: "chrome-85-0-build", Started: now, SessionLabels: map[string]string{"build": "1234"}})
+		app.stats.Sessions().Put("chrome-85-0-ticket", platform.Service{SessionID: "chrome-85-0-ticket", Started: now.Add(time.Second), SessionLabels: map[string]string{"ticket": "QA-1"}})
+		app.stats.Sessions().Put("chrome-85-0-unlabeled", platform.Service{SessionID: "chrome-85-0-unlabeled", Started: now.Add(2 * time.Second)})
+
+		req, err := http.NewRequest(http.MethodGet, "/sessions"+test.query, nil)
+		assert.NilError(t, err)
+		rr := httptest.NewRecorder()
+		app.HandleSessions(rr, req)
+
+		var sessions []struct {
+			ID string `json:"id"`
+		}
+		assert.NilError(t, json.NewDecoder(rr.Body).Decode(&sessions))
+		ids := make([]string, 0)
+		for _, s := range sessions {
+			ids = append(ids, s.ID)
+		}
+		assert.DeepEqual(t, test.sessions, ids)
+	}
+}
+
+func TestHandleQuota(t *testing.T) {
+	tests := map[string]struct {
+		respBody string
+	}{
+		"Verify quota when no session running": {
+			respBody: `{"name":"test","totalLimit":10,"sessionLimit":0,"active":0,"pending":0}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		req, err := http.NewRequest(http.MethodGet, "/quota", nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		app.HandleQuota(rr, req)
+
+		res := rr.Result()
+		defer res.Body.Close()
+
+		b, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("could not read response: %v", err)
+		}
+
+		body := string(bytes.TrimSpace(b))
+
+		assert.Equal(t, test.respBody, body)
+	}
+}
+
+func TestHandleOpenAPI(t *testing.T) {
+	tests := map[string]struct {
+		respCode int
+		paths    []string
+	}{
+		"Verify openapi spec contains selenosis endpoints": {
+			respCode: http.StatusOK,
+			paths:    []string{"/wd/hub/session", "/wd/hub/status", "/status", "/healthz", "/session/{sessionId}/extend"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		req, err := http.NewRequest(http.MethodGet, "/openapi.json", nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		app.HandleOpenAPI(rr, req)
+
+		res := rr.Result()
+		defer res.Body.Close()
+
+		var spec struct {
+			OpenAPI string                 `json:"openapi"`
+			Paths   map[string]interface{} `json:"paths"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&spec); err != nil {
+			t.Fatalf("could not decode response: %v", err)
+		}
+
+		assert.Equal(t, test.respCode, res.StatusCode)
+		assert.Equal(t, "3.0.3", spec.OpenAPI)
+		for _, path := range test.paths {
+			_, ok := spec.Paths[path]
+			assert.Assert(t, ok, "path %s not found in spec", path)
+		}
+	}
+}
+
+func TestHandleAPIDocs(t *testing.T) {
+	defer func(f fs.FS) { redocFS = f }(redocFS)
+
+	tests := map[string]struct {
+		files    fstest.MapFS
+		respCode int
+		bundle   int
+	}{
+		"Verify API explorer loads vendored Redoc bundle": {
+			files:    fstest.MapFS{redocBundle: &fstest.MapFile{Data: []byte("Redoc.init()")}},
+			respCode: http.StatusOK,
+			bundle:   http.StatusOK,
+		},
+		"Verify API explorer is not served without Redoc bundle": {
+			files:    fstest.MapFS{},
+			respCode: http.StatusServiceUnavailable,
+			bundle:   http.StatusNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		redocFS = test.files
+		app := initApp(&PlatformMock{})
+
+		rr := httptest.NewRecorder()
+		app.HandleAPIDocs(rr, httptest.NewRequest(http.MethodGet, "/api-docs", http.NoBody))
+		assert.Equal(t, test.respCode, rr.Code)
+		if test.respCode == http.StatusOK {
+			assert.Assert(t, strings.Contains(rr.Body.String(), `<script src="/api-docs/redoc.standalone.js"></script>`))
+			assert.Assert(t, !strings.Contains(rr.Body.String(), "https://"))
+			assert.Equal(t, "default-src 'self'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; worker-src blob:", rr.Header().Get("Content-Security-Policy"))
+		}
+
+		rr = httptest.NewRecorder()
+		app.HandleRedoc(rr, httptest.NewRequest(http.MethodGet, "/api-docs/redoc.standalone.js", http.NoBody))
+		assert.Equal(t, test.bundle, rr.Code)
+	}
+}
+
 func initApp(p *PlatformMock) *App {
 	logger := &logrus.Logger{}
 	client := NewPlatformMock(p)
@@ -586,9 +1121,15 @@ func initApp(p *PlatformMock) *App {
 }
 
 type PlatformMock struct {
-	err     error
-	service platform.Service
-	stats   *storage.Storage
+	err       error
+	service   platform.Service
+	stats     *storage.Storage
+	state     platform.PlatformState
+	resources []platform.Resource
+	artifacts map[string][]platform.Artifact
+	deleted   []string
+	created   int
+	layout    platform.ServiceSpec
 }
 
 func NewPlatformMock(f *PlatformMock) platform.Platform {
@@ -599,6 +1140,13 @@ func (p *PlatformMock) Service() platform.ServiceInterface {
 	return &serviceMock{
 		err:     p.err,
 		service: p.service,
+		onCreate: func(layout platform.ServiceSpec) {
+			p.created++
+			p.layout = layout
+		},
+		onDelete: func(name string) {
+			p.deleted = append(p.deleted, name)
+		},
 	}
 }
 
@@ -612,8 +1160,19 @@ func (p *PlatformMock) Quota() platform.QuotaInterface {
 	}
 }
 
+func (p *PlatformMock) Resources() platform.ResourceInterface {
+	return &resourcesMock{p}
+}
+
+func (p *PlatformMock) Artifacts() platform.ArtifactInterface {
+	return &artifactsMock{p}
+}
+
 func (p *PlatformMock) State() (platform.PlatformState, error) {
-	return platform.PlatformState{}, nil
+	if p == nil {
+		return platform.PlatformState{}, nil
+	}
+	return p.state, nil
 }
 
 func (p *PlatformMock) Watch() <-chan platform.Event {
@@ -626,21 +1185,25 @@ func (p *PlatformMock) List() ([]*platform.Service, error) {
 }
 
 type serviceMock struct {
-	err     error
-	service platform.Service
+	err      error
+	service  platform.Service
+	onCreate func(platform.ServiceSpec)
+	onDelete func(string)
 }
 
-func (p *serviceMock) Create(platform.ServiceSpec) (platform.Service, error) {
+func (p *serviceMock) Create(layout platform.ServiceSpec) (platform.Service, error) {
+	p.onCreate(layout)
 	if p.err != nil {
 		return platform.Service{}, p.err
 	}
 	return p.service, nil
 
 }
-func (p *serviceMock) Delete(string) error {
+func (p *serviceMock) Delete(name string) error {
 	if p.err != nil {
 		return p.err
 	}
+	p.onDelete(name)
 	return nil
 }
 
@@ -665,6 +1228,54 @@ func (s *quotaMock) Update(int64) (platform.Quota, error) {
 	return s.quota, nil
 }
 
+type resourcesMock struct {
+	p *PlatformMock
+}
+
+func (r *resourcesMock) List() ([]platform.Resource, error) {
+	return r.p.resources, nil
+}
+
+func (r *resourcesMock) Delete(resource platform.Resource) error {
+	r.p.deleted = append(r.p.deleted, resource.Name)
+	return nil
+}
+
+type artifactsMock struct {
+	p *PlatformMock
+}
+
+func (a *artifactsMock) List(tenant string) ([]platform.Artifact, error) {
+	return a.p.artifacts[tenant], nil
+}
+
+func (a *artifactsMock) Add(tenant string, artifact platform.Artifact) error {
+	if a.p.artifacts == nil {
+		a.p.artifacts = make(map[string][]platform.Artifact)
+	}
+	a.p.artifacts[tenant] = append(a.p.artifacts[tenant], artifact)
+	return nil
+}
+
+func (a *artifactsMock) Remove(tenant string, urls []string) error {
+	var list []platform.Artifact
+	for _, artifact := range a.p.artifacts[tenant] {
+		removed := false
+		for _, u := range urls {
+			removed = removed || artifact.URL == u
+		}
+		if !removed {
+			list = append(list, artifact)
+		}
+	}
+	a.p.artifacts[tenant] = list
+	return nil
+}
+
+func (a *artifactsMock) Credentials(string, string) (map[string]string, error) {
+	return nil, nil
+}
+
 type errReader int
 
 func (errReader) Read(p []byte) (n int, err error) {
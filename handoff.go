@@ -0,0 +1,134 @@
+package selenosis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/alcounit/selenosis/selenium"
+	"github.com/alcounit/selenosis/storage"
+	"github.com/alcounit/selenosis/tools"
+	"github.com/alcounit/selenosis/webhook"
+	"github.com/gorilla/mux"
+)
+
+//strandedRetention is how long session of unhealthy backend can be retried
+const strandedRetention = time.Hour
+
+//backendFailed marks local sessions as stranded when cluster api stops responding, every stranded
+//session is announced by webhook once, backend outage is announced once until backend recovers
+func (app *App) backendFailed(err error) {
+	now := time.Now()
+	for sessionID, service := range app.stats.Sessions().List() {
+		if service.Relay {
+			continue
+		}
+		if _, ok := app.stats.Stranded().Get(sessionID); ok {
+			continue
+		}
+		app.stats.Stranded().Put(sessionID, storage.StrandedSession{Service: service, Since: now})
+		app.notifier.Notify(webhook.Event{
+			Type:      webhook.SessionStranded,
+			RunID:     service.Labels[runIDKey],
+			SessionID: sessionID,
+		})
+	}
+
+	if atomic.CompareAndSwapInt32(&app.unhealthy, 0, 1) {
+		app.logger.WithField("component", "handoff").Errorf("backend is unhealthy, sessions stranded: %d", app.stats.Stranded().Len())
+		app.notifier.Notify(webhook.Event{
+			Type:    webhook.BackendUnhealthy,
+			Message: err.Error(),
+		})
+	}
+}
+
+//backendRecovered forgets stranded sessions which survived the outage, sessions which are gone
+//can be retried until retention expires. Lost session is kept while its failed pod is still there
+func (app *App) backendRecovered(present map[string]struct{}) {
+	for sessionID, session := range app.stats.Stranded().List() {
+		_, alive := present[sessionID]
+		if (alive && session.Lost == "") || time.Since(session.Since) > strandedRetention {
+			app.stats.Stranded().Delete(sessionID)
+		}
+	}
+
+	if atomic.CompareAndSwapInt32(&app.unhealthy, 1, 0) {
+		app.logger.WithField("component", "handoff").Info("backend recovered")
+		app.notifier.Notify(webhook.Event{Type: webhook.BackendRecovered})
+	}
+}
+
+func (app *App) backendUnhealthy() bool {
+	return atomic.LoadInt32(&app.unhealthy) == 1
+}
+
+//retryCapabilities returns new session request with browser, test name and run of stranded session
+func retryCapabilities(session storage.StrandedSession) ([]byte, error) {
+	caps := selenium.Capabilities{
+		BrowserName:       session.Labels["browserName"],
+		W3CBrowserVersion: session.Labels["browserVersion"],
+		TestName:          session.Labels["testName"],
+		RunID:             session.Labels[runIDKey],
+	}
+	return json.Marshal(map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"alwaysMatch": caps,
+		},
+	})
+}
+
+//HandleRetrySession creates new session with capabilities of stranded session, while backend is
+//unhealthy the session is created on secondary backend
+func (app *App) HandleRetrySession(w http.ResponseWriter, r *http.Request) {
+	sessionID := mux.Vars(r)["sessionId"]
+
+	tenant, err := app.tenant(r)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Basic realm="selenosis"`)
+		tools.JSONError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	session, ok := app.stats.Stranded().Get(sessionID)
+	if !ok || session.Labels["tenant"] != tenant.Name {
+		tools.JSONError(w, fmt.Sprintf("session %s is not stranded", sessionID), http.StatusNotFound)
+		return
+	}
+	if app.backendUnhealthy() && app.burstEndpoint == "" {
+		tools.JSONError(w, "no healthy backend to retry session", http.StatusServiceUnavailable)
+		return
+	}
+
+	body, err := retryCapabilities(session)
+	if err != nil {
+		tools.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	req := r.Clone(r.Context())
+	req.URL.Path = "/wd/hub/session"
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	app.HandleSession(sw, req)
+	if sw.status < http.StatusBadRequest {
+		app.stats.Stranded().Delete(sessionID)
+		app.logger.WithField("component", "handoff").Infof("stranded session %s retried", sessionID)
+	}
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,115 @@
+package selenosis
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/config"
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/webhook"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+)
+
+func TestRetryStrandedSession(t *testing.T) {
+	const remoteID = "0a4a5c4c-4f7a-4c36-9a3b-3a4e3d5f5b6c"
+
+	var payload map[string]interface{}
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&payload)
+		w.Write([]byte(`{"value":{"sessionId":"` + remoteID + `","capabilities":{"browserName":"chrome"}}}`))
+	}))
+	defer secondary.Close()
+
+	events := make(chan webhook.EventType, 4)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event webhook.Event
+		json.NewDecoder(r.Body).Decode(&event)
+		events <- event.Type
+	}))
+	defer hook.Close()
+
+	browsers, err := config.NewBrowsersConfig("config/browsers.yaml")
+	assert.NilError(t, err)
+	app := New(&logrus.Logger{}, platform.NewRelay(&PlatformMock{}), browsers, Configuration{
+		SelenosisHost:      "hostname",
+		SidecarPort:        "4445",
+		BrowserWaitTimeout: time.Second,
+		SessionRetryCount:  2,
+		Webhook:            webhook.Config{URLs: []string{hook.URL}},
+		BurstEndpoint:      secondary.URL + "/wd/hub",
+	})
+
+	stranded := platform.Service{
+		SessionID: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491",
+		Status:    platform.Running,
+		Labels:    map[string]string{"browserName": "chrome", "browserVersion": "85.0", "testName": "login", "runId": "nightly-42"},
+	}
+	app.stats.Sessions().Put(stranded.SessionID, stranded)
+
+	app.backendFailed(errors.New("connection refused"))
+	app.backendFailed(errors.New("connection refused"))
+	assert.Assert(t, app.backendUnhealthy())
+	assert.Equal(t, 1, app.stats.Stranded().Len())
+
+	received := map[webhook.EventType]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case event := <-events:
+			received[event] = true
+		case <-time.After(time.Second):
+			t.Fatal("webhook was not notified")
+		}
+	}
+	assert.DeepEqual(t, map[webhook.EventType]bool{webhook.BackendUnhealthy: true, webhook.SessionStranded: true}, received)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/sessions/{sessionId}/retry", app.HandleRetrySession).Methods(http.MethodPost)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/sessions/"+stranded.SessionID+"/retry", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var created struct {
+		Value struct {
+			SessionID string `json:"sessionId"`
+		} `json:"value"`
+	}
+	assert.NilError(t, json.NewDecoder(rr.Body).Decode(&created))
+	service, ok := app.stats.Sessions().Get(created.Value.SessionID)
+	assert.Assert(t, ok)
+	assert.Assert(t, service.Burst)
+	assert.DeepEqual(t, map[string]interface{}{
+		"browserName":    "chrome",
+		"browserVersion": "85.0",
+		"name":           "login",
+		"runId":          "nightly-42",
+	}, payload["capabilities"].(map[string]interface{})["alwaysMatch"])
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/sessions/"+stranded.SessionID+"/retry", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestBackendRecovered(t *testing.T) {
+	app := initApp(&PlatformMock{})
+	for _, id := range []string{"chrome-85-0-1", "chrome-85-0-2"} {
+		app.stats.Sessions().Put(id, platform.Service{SessionID: id, Status: platform.Running})
+	}
+
+	app.backendFailed(errors.New("connection refused"))
+	assert.Equal(t, 2, app.stats.Stranded().Len())
+
+	app.backendRecovered(map[string]struct{}{"chrome-85-0-1": {}})
+	assert.Assert(t, !app.backendUnhealthy())
+
+	_, ok := app.stats.Stranded().Get("chrome-85-0-1")
+	assert.Assert(t, !ok)
+	_, ok = app.stats.Stranded().Get("chrome-85-0-2")
+	assert.Assert(t, ok)
+}
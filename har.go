@@ -0,0 +1,134 @@
+package selenosis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/storage"
+	"github.com/alcounit/selenosis/tools"
+)
+
+//harPort is port recording proxy of browser pod serves network archive on
+var harPort = strconv.Itoa(platform.HARPort)
+
+//harCaptureTimeout limits time archive of deleted session is fetched for
+const harCaptureTimeout = 10 * time.Second
+
+//injectHARProxy points browser of new session request to recording proxy of its pod, proxy intercepts
+//TLS, so its certificates are accepted. Requests setting proxy themselves are rejected
+func injectHARProxy(body []byte) ([]byte, error) {
+	var request map[string]interface{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+
+	var targets []map[string]interface{}
+	if w3c, ok := request["capabilities"].(map[string]interface{}); ok {
+		if entries, ok := w3c["firstMatch"].([]interface{}); ok {
+			for _, entry := range entries {
+				if caps, ok := entry.(map[string]interface{}); ok {
+					if _, ok := caps["proxy"]; ok {
+						return nil, errors.New("captureHAR can't be used together with proxy capability")
+					}
+				}
+			}
+		}
+		alwaysMatch, _ := w3c["alwaysMatch"].(map[string]interface{})
+		if alwaysMatch == nil {
+			alwaysMatch = make(map[string]interface{})
+			w3c["alwaysMatch"] = alwaysMatch
+		}
+		targets = append(targets, alwaysMatch)
+	}
+	if desired, ok := request["desiredCapabilities"].(map[string]interface{}); ok {
+		targets = append(targets, desired)
+	}
+
+	proxy := net.JoinHostPort("127.0.0.1", strconv.Itoa(platform.HARProxyPort))
+	for _, caps := range targets {
+		if _, ok := caps["proxy"]; ok {
+			return nil, errors.New("captureHAR can't be used together with proxy capability")
+		}
+		caps["proxy"] = map[string]interface{}{
+			"proxyType": "manual",
+			"httpProxy": proxy,
+			"sslProxy":  proxy,
+		}
+		caps["acceptInsecureCerts"] = true
+	}
+	return json.Marshal(request)
+}
+
+//HandleHAR returns network archive of session started with captureHAR option, archive of running session
+//is read from its recording proxy, archive of deleted session is kept for HAR retention
+func (app *App) HandleHAR(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := app.containerSession(w, r, "HAR is not available for relayed session %s")
+	if !ok {
+		return
+	}
+
+	logger := app.requestLogger(r, sessionID)
+
+	var content []byte
+	if service, ok := app.stats.Sessions().Get(sessionID); ok && service.HAR {
+		var err error
+		content, err = app.fetchHAR(r.Context(), sessionID)
+		if err != nil {
+			logger.Errorf("failed to get HAR: %v", err)
+			tools.JSONError(w, fmt.Sprintf("failed to get HAR: %v", err), http.StatusBadGateway)
+			return
+		}
+	} else if har, ok := app.stats.HARs().Get(sessionID); ok {
+		content = har.Content
+	} else {
+		tools.JSONError(w, fmt.Sprintf("HAR is not available for session %s", sessionID), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.har"`, sessionID))
+	w.Write(content)
+}
+
+//fetchHAR reads network archive of the session from its recording proxy
+func (app *App) fetchHAR(ctx context.Context, sessionID string) ([]byte, error) {
+	u := fmt.Sprintf("http://%s/har", app.sessionHost(sessionID, harPort))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("recording proxy responded with %s", resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+//captureHAR keeps network archive of the session before its browser is deleted, sessions without
+//recording proxy are skipped
+func (app *App) captureHAR(sessionID string) {
+	service, ok := app.stats.Sessions().Get(sessionID)
+	if !ok || !service.HAR || app.harRetention <= 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), harCaptureTimeout)
+	defer cancel()
+	content, err := app.fetchHAR(ctx, sessionID)
+	if err != nil {
+		app.logger.WithField("session_id", sessionID).Errorf("failed to capture HAR: %v", err)
+		return
+	}
+	app.stats.HARs().Put(sessionID, storage.HAR{Content: content, Owner: service.Labels[ownerLabel], Tenant: service.Labels["tenant"], Captured: time.Now()})
+}
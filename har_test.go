@@ -0,0 +1,132 @@
+package selenosis
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/storage"
+	"github.com/gorilla/mux"
+	"gotest.tools/assert"
+)
+
+func TestInjectHARProxy(t *testing.T) {
+	proxy := map[string]interface{}{"proxyType": "manual", "httpProxy": "127.0.0.1:9090", "sslProxy": "127.0.0.1:9090"}
+
+	tests := map[string]struct {
+		body   string
+		result map[string]interface{}
+		err    string
+	}{
+		"Verify proxy is added to alwaysMatch": {
+			body: `{"capabilities":{"alwaysMatch":{"browserName":"chrome"}}}`,
+			result: map[string]interface{}{"capabilities": map[string]interface{}{
+				"alwaysMatch": map[string]interface{}{"browserName": "chrome", "proxy": proxy, "acceptInsecureCerts": true},
+			}},
+		},
+		"Verify proxy is added to desiredCapabilities": {
+			body: `{"desiredCapabilities":{"browserName":"chrome"}}`,
+			result: map[string]interface{}{
+				"desiredCapabilities": map[string]interface{}{"browserName": "chrome", "proxy": proxy, "acceptInsecureCerts": true},
+			},
+		},
+		"Verify request with proxy is rejected": {
+			body: `{"capabilities":{"firstMatch":[{"proxy":{"proxyType":"system"}}]}}`,
+			err:  "captureHAR can't be used together with proxy capability",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		body, err := injectHARProxy([]byte(test.body))
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+
+		var result map[string]interface{}
+		assert.NilError(t, json.Unmarshal(body, &result))
+		assert.DeepEqual(t, test.result, result)
+	}
+}
+
+func TestHandleHAR(t *testing.T) {
+	const (
+		running  = "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491"
+		finished = "chrome-85-0-f3b2a1c0-1a35-412b-b526-f5da80214491"
+		plain    = "chrome-85-0-a1b2c3d4-1a35-412b-b526-f5da80214491"
+	)
+
+	recorder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/har" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"log":{"entries":[]}}`))
+	}))
+	defer recorder.Close()
+	_, port, _ := net.SplitHostPort(recorder.Listener.Addr().String())
+	defer func(port string) { harPort = port }(harPort)
+	harPort = port
+
+	u, _ := url.Parse(recorder.URL)
+	app := initApp(&PlatformMock{})
+	app.stats.Sessions().Put(running, platform.Service{SessionID: running, URL: u, Status: platform.Running, HAR: true})
+	app.stats.Sessions().Put(plain, platform.Service{SessionID: plain, URL: u, Status: platform.Running})
+	app.stats.HARs().Put(finished, storage.HAR{Content: []byte(`{"log":{"entries":[{}]}}`), Captured: time.Now()})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/har/{sessionId}", app.HandleHAR).Methods(http.MethodGet)
+
+	tests := map[string]struct {
+		sessionID string
+		respCode  int
+		respBody  string
+	}{
+		"Verify HAR of running session is read from recording proxy": {
+			sessionID: running,
+			respCode:  http.StatusOK,
+			respBody:  `{"log":{"entries":[]}}`,
+		},
+		"Verify HAR of deleted session is returned": {
+			sessionID: finished,
+			respCode:  http.StatusOK,
+			respBody:  `{"log":{"entries":[{}]}}`,
+		},
+		"Verify HAR of session without capture is not found": {
+			sessionID: plain,
+			respCode:  http.StatusNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		req := httptest.NewRequest(http.MethodGet, "/har/"+test.sessionID, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		resp := w.Result()
+		assert.Equal(t, test.respCode, resp.StatusCode)
+		if test.respBody != "" {
+			body, _ := ioutil.ReadAll(resp.Body)
+			assert.Equal(t, test.respBody, string(body))
+			assert.Assert(t, strings.Contains(resp.Header.Get("Content-Disposition"), test.sessionID+".har"))
+		}
+	}
+
+	app.harRetention = time.Hour
+	app.captureHAR(running)
+	har, ok := app.stats.HARs().Get(running)
+	assert.Assert(t, ok)
+	assert.Equal(t, `{"log":{"entries":[]}}`, string(har.Content))
+}
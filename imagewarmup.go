@@ -0,0 +1,43 @@
+package selenosis
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/tools"
+)
+
+type imageWarmupResult struct {
+	Warmups []platform.ImageWarmup `json:"warmups"`
+}
+
+//HandleImageWarmup starts pulling images of configured browsers onto every node on POST, so first sessions
+//after config change don't wait for image pull, GET returns how many nodes have pulled them
+func (app *App) HandleImageWarmup(w http.ResponseWriter, r *http.Request) {
+	puller, ok := app.client.(platform.ImagePuller)
+	if !ok {
+		tools.JSONError(w, platform.ErrImageWarmupNotSupported.Error(), http.StatusNotImplemented)
+		return
+	}
+
+	var warmups []platform.ImageWarmup
+	var err error
+	if r.Method == http.MethodPost {
+		warmups, err = puller.WarmupImages(app.browsers.Browsers())
+	} else {
+		warmups, err = puller.ImageWarmups()
+	}
+	switch {
+	case err == platform.ErrImageWarmupNotSupported:
+		tools.JSONError(w, err.Error(), http.StatusNotImplemented)
+		return
+	case err != nil:
+		app.logger.WithField("component", "imageWarmup").Errorf("image warmup failed: %v", err)
+		tools.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(imageWarmupResult{Warmups: warmups})
+}
@@ -0,0 +1,72 @@
+package selenosis
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alcounit/selenosis/platform"
+	"gotest.tools/assert"
+)
+
+type imagePullerMock struct {
+	*PlatformMock
+	templates []platform.BrowserSpec
+}
+
+func (p *imagePullerMock) WarmupImages(templates []platform.BrowserSpec) ([]platform.ImageWarmup, error) {
+	p.templates = templates
+	return p.ImageWarmups()
+}
+
+func (p *imagePullerMock) ImageWarmups() ([]platform.ImageWarmup, error) {
+	return []platform.ImageWarmup{{Name: "selenosis-image-warmup", Images: []string{"selenoid/vnc:chrome_86.0"}, Nodes: 3, Updated: 3, Ready: 2}}, nil
+}
+
+func TestImageWarmup(t *testing.T) {
+	tests := map[string]struct {
+		client     platform.Platform
+		method     string
+		statusCode int
+		respBody   string
+		templates  int
+	}{
+		"Verify images of configured browsers are pulled": {
+			client:     &imagePullerMock{PlatformMock: &PlatformMock{}},
+			method:     http.MethodPost,
+			statusCode: http.StatusOK,
+			respBody:   `{"warmups":[{"name":"selenosis-image-warmup","images":["selenoid/vnc:chrome_86.0"],"nodes":3,"updated":3,"ready":2}]}`,
+			templates:  6,
+		},
+		"Verify image warmup state is returned": {
+			client:     &imagePullerMock{PlatformMock: &PlatformMock{}},
+			method:     http.MethodGet,
+			statusCode: http.StatusOK,
+			respBody:   `{"warmups":[{"name":"selenosis-image-warmup","images":["selenoid/vnc:chrome_86.0"],"nodes":3,"updated":3,"ready":2}]}`,
+		},
+		"Verify image warmup is not supported by platform without nodes": {
+			client:     &PlatformMock{},
+			method:     http.MethodPost,
+			statusCode: http.StatusNotImplemented,
+			respBody:   `{"code":501,"value":{"message":"image warmup is not supported by the platform"}}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		app.client = test.client
+		req, err := http.NewRequest(test.method, "/admin/warmup", nil)
+		assert.NilError(t, err)
+		rr := httptest.NewRecorder()
+		app.HandleImageWarmup(rr, req)
+
+		assert.Equal(t, test.statusCode, rr.Code)
+		assert.Equal(t, test.respBody, string(bytes.TrimSpace(rr.Body.Bytes())))
+		if puller, ok := test.client.(*imagePullerMock); ok {
+			assert.Equal(t, test.templates, len(puller.templates))
+		}
+	}
+}
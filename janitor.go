@@ -0,0 +1,183 @@
+package selenosis
+
+import (
+	"time"
+
+	"github.com/alcounit/selenosis/metrics"
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/tools"
+)
+
+//RunJanitor periodically deletes orphaned browser pods until stop is closed, replicas which are not
+//the leader only sync session registry with the cluster
+func (app *App) RunJanitor(stop <-chan struct{}) {
+	if app.janitorInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(app.janitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			app.reapOrphans()
+		}
+	}
+}
+
+func (app *App) reapOrphans() {
+	logger := app.logger.WithField("component", "janitor")
+
+	state, err := app.client.State()
+	if err != nil {
+		logger.Errorf("failed to get cluster state: %v", err)
+		metrics.JanitorRuns.WithLabelValues("error").Inc()
+		app.backendFailed(err)
+		return
+	}
+
+	leading := app.leading()
+	present := make(map[string]struct{}, len(state.Services))
+	runs := make(map[string]struct{})
+	for _, service := range state.Services {
+		present[service.SessionID] = struct{}{}
+		if runID := service.Labels[runIDKey]; runID != "" {
+			runs[runID] = struct{}{}
+		}
+
+		reason := orphanReason(service, app.orphanGracePeriod)
+		if reason == "" && app.sessionExpired(service) {
+			reason = "lifetime"
+		}
+		if reason == "" && (app.relayIdle(service) || app.sessionIdle(service)) {
+			reason = "idle"
+		}
+		if reason == "" || !leading {
+			continue
+		}
+
+		app.captureHAR(service.SessionID)
+		if err := app.client.Service().Delete(service.SessionID); err != nil {
+			logger.Errorf("failed to delete orphaned pod %s: %v", service.SessionID, err)
+			continue
+		}
+		delete(present, service.SessionID)
+		app.stats.Sessions().Delete(service.SessionID)
+		metrics.OrphansReaped.WithLabelValues(reason).Inc()
+		logger.Warnf("orphaned pod %s deleted, reason: %s, age: %s", service.SessionID, reason, tools.TimeElapsed(service.Started))
+	}
+
+	app.backendRecovered(present)
+	app.proxyFailures.prune(present)
+	app.activityRecords.prune(present)
+	app.stats.HARs().Prune(time.Now().Add(-app.harRetention))
+
+	var stale []string
+	for sessionID := range app.stats.Sessions().List() {
+		if _, ok := present[sessionID]; !ok {
+			stale = append(stale, sessionID)
+		}
+	}
+	registered := make(map[string]struct{})
+	if len(stale) > 0 {
+		//sessions registered after state was taken are not stale, state is taken again before removal
+		current, err := app.client.State()
+		if err != nil {
+			logger.Errorf("failed to get cluster state: %v", err)
+			stale = nil
+		}
+		for _, service := range current.Services {
+			registered[service.SessionID] = struct{}{}
+		}
+	}
+	for _, sessionID := range stale {
+		if _, ok := registered[sessionID]; !ok {
+			app.stats.Sessions().Delete(sessionID)
+			logger.Warnf("stale session %s removed from registry", sessionID)
+		}
+	}
+
+	if !leading {
+		metrics.JanitorRuns.WithLabelValues("success").Inc()
+		return
+	}
+
+	resources, err := app.client.Resources().List()
+	if err != nil {
+		logger.Errorf("failed to list session resources: %v", err)
+		metrics.JanitorRuns.WithLabelValues("error").Inc()
+		return
+	}
+
+	for _, resource := range resources {
+		if resource.RunID != "" {
+			if !app.runWorkspaceExpired(resource.RunID, runs) {
+				continue
+			}
+		} else if _, ok := present[resource.SessionID]; ok {
+			continue
+		} else if time.Since(resource.Created) < app.orphanGracePeriod {
+			//resources are created before pod of the session, pod of starting session may be missing yet
+			continue
+		}
+
+		if err := app.client.Resources().Delete(resource); err != nil {
+			logger.Errorf("failed to delete %s %s: %v", resource.Kind, resource.Name, err)
+			continue
+		}
+		metrics.ResourcesReaped.WithLabelValues(resource.Kind).Inc()
+		if resource.RunID != "" {
+			logger.Warnf("%s %s of run %s deleted", resource.Kind, resource.Name, resource.RunID)
+			continue
+		}
+		logger.Warnf("%s %s of session %s deleted", resource.Kind, resource.Name, resource.SessionID)
+	}
+
+	metrics.JanitorRuns.WithLabelValues("success").Inc()
+}
+
+//runWorkspaceExpired reports if shared workspace of the run is not used by any session for longer than
+//workspace retention, idle time is counted from the first janitor run which found no sessions of the run
+func (app *App) runWorkspaceExpired(runID string, runs map[string]struct{}) bool {
+	if _, ok := runs[runID]; ok {
+		delete(app.idleRuns, runID)
+		return false
+	}
+	since, ok := app.idleRuns[runID]
+	if !ok {
+		app.idleRuns[runID] = time.Now()
+		return false
+	}
+	if time.Since(since) < app.workspaceRetention {
+		return false
+	}
+	delete(app.idleRuns, runID)
+	return true
+}
+
+//orphanReason returns why pod is orphaned, pods stuck in pending state, terminated pods and pods
+//which creator died before browser was ready are orphaned after grace period
+func orphanReason(service platform.Service, grace time.Duration) string {
+	if time.Since(service.Started) < grace {
+		return ""
+	}
+
+	switch service.Status {
+	case platform.Pending:
+		return "pending"
+	case platform.Unknown:
+		return "terminated"
+	}
+	if !service.Deadline.IsZero() && time.Since(service.Deadline) > grace {
+		return "startup"
+	}
+	return ""
+}
+
+//sessionExpired reports if session is older than max session lifetime, relayed sessions included
+func (app *App) sessionExpired(service platform.Service) bool {
+	return app.maxSessionLifetime > 0 && time.Since(service.Started) > app.maxSessionLifetime
+}
@@ -0,0 +1,301 @@
+package selenosis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"gotest.tools/assert"
+)
+
+func TestReapOrphans(t *testing.T) {
+	tests := map[string]struct {
+		services []platform.Service
+		deleted  []string
+	}{
+		"Verify running pod is not deleted": {
+			services: []platform.Service{
+				{SessionID: "chrome-85-0-running", Status: platform.Running, Started: time.Now().Add(-time.Hour)},
+			},
+		},
+		"Verify pending pod within grace period is not deleted": {
+			services: []platform.Service{
+				{SessionID: "chrome-85-0-pending", Status: platform.Pending, Started: time.Now()},
+			},
+		},
+		"Verify pending pod after grace period is deleted": {
+			services: []platform.Service{
+				{SessionID: "chrome-85-0-pending", Status: platform.Pending, Started: time.Now().Add(-time.Hour)},
+				{SessionID: "chrome-85-0-running", Status: platform.Running, Started: time.Now().Add(-time.Hour)},
+			},
+			deleted: []string{"chrome-85-0-pending"},
+		},
+		"Verify terminated pod after grace period is deleted": {
+			services: []platform.Service{
+				{SessionID: "chrome-85-0-failed", Status: platform.Unknown, Started: time.Now().Add(-time.Hour)},
+			},
+			deleted: []string{"chrome-85-0-failed"},
+		},
+		"Verify running pod within startup deadline is not deleted": {
+			services: []platform.Service{
+				{SessionID: "chrome-85-0-starting", Status: platform.Running, Started: time.Now().Add(-time.Hour), Deadline: time.Now().Add(time.Minute)},
+			},
+		},
+		"Verify running pod past startup deadline is deleted": {
+			services: []platform.Service{
+				{SessionID: "chrome-85-0-zombie", Status: platform.Running, Started: time.Now().Add(-time.Hour), Deadline: time.Now().Add(-30 * time.Minute)},
+			},
+			deleted: []string{"chrome-85-0-zombie"},
+		},
+		"Verify pod older than max lifetime is deleted": {
+			services: []platform.Service{
+				{SessionID: "chrome-85-0-old", Status: platform.Running, Started: time.Now().Add(-25 * time.Hour)},
+				{SessionID: "chrome-85-0-running", Status: platform.Running, Started: time.Now().Add(-time.Hour)},
+			},
+			deleted: []string{"chrome-85-0-old"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		client := &PlatformMock{
+			state: platform.PlatformState{Services: test.services},
+		}
+		app := initApp(client)
+		app.orphanGracePeriod = time.Minute
+		app.maxSessionLifetime = 24 * time.Hour
+		app.stats.Sessions().Put("chrome-85-0-stale", platform.Service{SessionID: "chrome-85-0-stale"})
+
+		app.reapOrphans()
+
+		assert.DeepEqual(t, test.deleted, client.deleted)
+		assert.Equal(t, len(test.services)-len(test.deleted), app.stats.Sessions().Len())
+	}
+}
+
+type leaderMock bool
+
+func (l leaderMock) IsLeader() bool {
+	return bool(l)
+}
+
+func TestReapOrphansByLeader(t *testing.T) {
+	tests := map[string]struct {
+		leader  Leader
+		deleted []string
+	}{
+		"Verify single replica deletes orphans": {
+			deleted: []string{"chrome-85-0-failed", "chrome-85-0-failed-har"},
+		},
+		"Verify leader deletes orphans": {
+			leader:  leaderMock(true),
+			deleted: []string{"chrome-85-0-failed", "chrome-85-0-failed-har"},
+		},
+		"Verify follower does not delete orphans": {
+			leader: leaderMock(false),
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		client := &PlatformMock{
+			state: platform.PlatformState{Services: []platform.Service{
+				{SessionID: "chrome-85-0-failed", Status: platform.Unknown, Started: time.Now().Add(-time.Hour)},
+			}},
+			resources: []platform.Resource{
+				{Kind: "Service", Name: "chrome-85-0-failed-har", SessionID: "chrome-85-0-failed"},
+			},
+		}
+		app := initApp(client)
+		app.orphanGracePeriod = time.Minute
+		app.leader = test.leader
+		app.stats.Sessions().Put("chrome-85-0-stale", platform.Service{SessionID: "chrome-85-0-stale"})
+
+		app.reapOrphans()
+
+		assert.DeepEqual(t, test.deleted, client.deleted)
+		_, ok := app.stats.Sessions().Get("chrome-85-0-stale")
+		assert.Assert(t, !ok)
+	}
+}
+
+type stateSequenceMock struct {
+	*PlatformMock
+	states []platform.PlatformState
+}
+
+func (p *stateSequenceMock) State() (platform.PlatformState, error) {
+	state := p.states[0]
+	if len(p.states) > 1 {
+		p.states = p.states[1:]
+	}
+	return state, nil
+}
+
+func TestReapStaleSessions(t *testing.T) {
+	client := &stateSequenceMock{PlatformMock: &PlatformMock{}, states: []platform.PlatformState{
+		{},
+		{Services: []platform.Service{{SessionID: "chrome-85-0-new", Status: platform.Pending, Started: time.Now()}}},
+	}}
+	app := initApp(&PlatformMock{})
+	app.client = client
+	app.stats.Sessions().Put("chrome-85-0-stale", platform.Service{SessionID: "chrome-85-0-stale"})
+	app.stats.Sessions().Put("chrome-85-0-new", platform.Service{SessionID: "chrome-85-0-new"})
+
+	app.reapOrphans()
+
+	_, ok := app.stats.Sessions().Get("chrome-85-0-stale")
+	assert.Assert(t, !ok)
+	_, ok = app.stats.Sessions().Get("chrome-85-0-new")
+	assert.Assert(t, ok)
+}
+
+func TestReapResources(t *testing.T) {
+	tests := map[string]struct {
+		services  []platform.Service
+		resources []platform.Resource
+		deleted   []string
+	}{
+		"Verify resources of running session are not deleted": {
+			services: []platform.Service{
+				{SessionID: "chrome-85-0-running", Status: platform.Running, Started: time.Now()},
+			},
+			resources: []platform.Resource{
+				{Kind: "Secret", Name: "chrome-85-0-running-creds", SessionID: "chrome-85-0-running"},
+			},
+		},
+		"Verify resources without pod are deleted": {
+			services: []platform.Service{
+				{SessionID: "chrome-85-0-running", Status: platform.Running, Started: time.Now()},
+			},
+			resources: []platform.Resource{
+				{Kind: "Secret", Name: "chrome-85-0-running-creds", SessionID: "chrome-85-0-running"},
+				{Kind: "NetworkPolicy", Name: "chrome-85-0-gone", SessionID: "chrome-85-0-gone"},
+			},
+			deleted: []string{"chrome-85-0-gone"},
+		},
+		"Verify resources of starting session are not deleted": {
+			resources: []platform.Resource{
+				{Kind: "Secret", Name: "chrome-85-0-starting-creds", SessionID: "chrome-85-0-starting", Created: time.Now()},
+			},
+		},
+		"Verify resources of orphaned pod are deleted": {
+			services: []platform.Service{
+				{SessionID: "chrome-85-0-failed", Status: platform.Unknown, Started: time.Now().Add(-time.Hour)},
+			},
+			resources: []platform.Resource{
+				{Kind: "Service", Name: "chrome-85-0-failed-har", SessionID: "chrome-85-0-failed"},
+			},
+			deleted: []string{"chrome-85-0-failed", "chrome-85-0-failed-har"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		client := &PlatformMock{
+			state:     platform.PlatformState{Services: test.services},
+			resources: test.resources,
+		}
+		app := initApp(client)
+		app.orphanGracePeriod = time.Minute
+
+		app.reapOrphans()
+
+		assert.DeepEqual(t, test.deleted, client.deleted)
+	}
+}
+
+func TestReapRunWorkspaces(t *testing.T) {
+	client := &PlatformMock{
+		state: platform.PlatformState{Services: []platform.Service{
+			{SessionID: "chrome-85-0-running", Status: platform.Running, Started: time.Now(), Labels: map[string]string{"runId": "nightly-42"}},
+		}},
+		resources: []platform.Resource{
+			{Kind: "PersistentVolumeClaim", Name: "workspace-nightly-42-1b1e9c2d", RunID: "nightly-42"},
+			{Kind: "PersistentVolumeClaim", Name: "workspace-nightly-41-3c5d7e9f", RunID: "nightly-41"},
+		},
+	}
+	app := initApp(client)
+	app.orphanGracePeriod = time.Minute
+	app.workspaceRetention = 50 * time.Millisecond
+
+	app.reapOrphans()
+	assert.Assert(t, client.deleted == nil)
+
+	time.Sleep(100 * time.Millisecond)
+	app.reapOrphans()
+	assert.DeepEqual(t, []string{"workspace-nightly-41-3c5d7e9f"}, client.deleted)
+}
+
+func TestReapIdleSessions(t *testing.T) {
+	tests := map[string]struct {
+		service  platform.Service
+		activity time.Duration
+		deleted  []string
+	}{
+		"Verify session with recent command is not deleted": {
+			service:  platform.Service{SessionID: "chrome-85-0-active", Status: platform.Running, Started: time.Now().Add(-time.Hour)},
+			activity: time.Minute,
+		},
+		"Verify idle session is deleted": {
+			service:  platform.Service{SessionID: "chrome-85-0-idle", Status: platform.Running, Started: time.Now().Add(-time.Hour)},
+			activity: 30 * time.Minute,
+			deleted:  []string{"chrome-85-0-idle"},
+		},
+		"Verify session without commands is deleted": {
+			service: platform.Service{SessionID: "chrome-85-0-idle", Status: platform.Running, Started: time.Now().Add(-time.Hour)},
+			deleted: []string{"chrome-85-0-idle"},
+		},
+		"Verify session with own idle timeout is kept for its timeout": {
+			service: platform.Service{SessionID: "chrome-85-0-long", Status: platform.Running, Started: time.Now().Add(-time.Hour),
+				Labels: map[string]string{sessionTimeoutLabel: "2h0m0s"}},
+			activity: 30 * time.Minute,
+		},
+		"Verify session extended by another replica is not deleted": {
+			service: platform.Service{SessionID: "chrome-85-0-extended", Status: platform.Running, Started: time.Now().Add(-time.Hour),
+				Extended: time.Now().Add(-time.Minute)},
+			activity: 30 * time.Minute,
+		},
+		"Verify session active through another replica is not deleted": {
+			service: platform.Service{SessionID: "chrome-85-0-active", Status: platform.Running, Started: time.Now().Add(-time.Hour),
+				Active: time.Now().Add(-time.Minute)},
+			activity: 30 * time.Minute,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		client := &PlatformMock{
+			state: platform.PlatformState{Services: []platform.Service{test.service}},
+		}
+		app := initApp(client)
+		app.orphanGracePeriod = time.Minute
+		app.idleReaperTimeout = 10 * time.Minute
+		app.startTime = time.Now().Add(-2 * time.Hour)
+		if test.activity > 0 {
+			app.stats.Activity().Put(test.service.SessionID, time.Now().Add(-test.activity))
+		}
+
+		app.reapOrphans()
+
+		assert.DeepEqual(t, test.deleted, client.deleted)
+	}
+}
+
+func TestSessionIdleAfterRestart(t *testing.T) {
+	app := initApp(&PlatformMock{})
+	app.idleReaperTimeout = 10 * time.Minute
+
+	service := platform.Service{SessionID: "chrome-85-0-idle", Status: platform.Running, Started: time.Now().Add(-time.Hour)}
+	assert.Assert(t, !app.sessionIdle(service))
+
+	app.startTime = time.Now().Add(-time.Hour)
+	assert.Assert(t, app.sessionIdle(service))
+
+	app.idleReaperTimeout = 0
+	assert.Assert(t, !app.sessionIdle(service))
+}
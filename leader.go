@@ -0,0 +1,13 @@
+package selenosis
+
+//Leader reports if replica holds leader lease, only the leader deletes orphaned and idle browser pods,
+//session resources and refills warm pools, so replicas don't race doing cluster-wide housekeeping
+type Leader interface {
+	IsLeader() bool
+}
+
+//leading reports if replica should do cluster-wide housekeeping, single replica without leader
+//election always does
+func (app *App) leading() bool {
+	return app.leader == nil || app.leader.IsLeader()
+}
@@ -0,0 +1,127 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/alcounit/selenosis/metrics"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+//Config describes leader lease, renew deadline and retry period default to 2/3 and 2/15 of lease
+//duration, the same proportions Kubernetes components use
+type Config struct {
+	Namespace     string
+	Name          string
+	Identity      string
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+}
+
+//Elector campaigns for Kubernetes Lease, replica holding the lease is the leader and runs cluster-wide
+//housekeeping, other replicas only serve traffic. Replica losing the lease campaigns again
+type Elector struct {
+	logger  *log.Logger
+	elector *leaderelection.LeaderElector
+	leading int32
+}
+
+//NewClient returns Kubernetes client of in-cluster config
+func NewClient() (kubernetes.Interface, error) {
+	conf, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build cluster config: %v", err)
+	}
+
+	client, err := kubernetes.NewForConfig(conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build client: %v", err)
+	}
+	return client, nil
+}
+
+//New ...
+func New(logger *log.Logger, client kubernetes.Interface, cfg Config) (*Elector, error) {
+	e := &Elector{logger: logger}
+	if cfg.RenewDeadline <= 0 {
+		cfg.RenewDeadline = cfg.LeaseDuration * 2 / 3
+	}
+	if cfg.RetryPeriod <= 0 {
+		cfg.RetryPeriod = cfg.LeaseDuration * 2 / 15
+	}
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta:  metav1.ObjectMeta{Name: cfg.Name, Namespace: cfg.Namespace},
+		Client:     client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: cfg.Identity},
+	}
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   cfg.LeaseDuration,
+		RenewDeadline:   cfg.RenewDeadline,
+		RetryPeriod:     cfg.RetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            cfg.Name,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(context.Context) {
+				e.setLeading(true)
+				logger.Infof("leader lease %s acquired by %s", cfg.Name, cfg.Identity)
+			},
+			OnStoppedLeading: func() {
+				e.setLeading(false)
+				logger.Warnf("leader lease %s lost by %s", cfg.Name, cfg.Identity)
+			},
+			OnNewLeader: func(identity string) {
+				if identity != cfg.Identity {
+					logger.Infof("leader lease %s is held by %s", cfg.Name, identity)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create leader elector: %v", err)
+	}
+	e.elector = elector
+	return e, nil
+}
+
+//Run campaigns for the lease until stop is closed, lease is released on stop so other replica takes
+//over without waiting for lease to expire
+func (e *Elector) Run(stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	for {
+		e.elector.Run(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+	}
+}
+
+//IsLeader reports if replica holds the lease
+func (e *Elector) IsLeader() bool {
+	return atomic.LoadInt32(&e.leading) == 1
+}
+
+func (e *Elector) setLeading(leading bool) {
+	var v int32
+	if leading {
+		v = 1
+	}
+	atomic.StoreInt32(&e.leading, v)
+	metrics.Leader.Set(float64(v))
+}
@@ -0,0 +1,55 @@
+package leader
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestElection(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(ioutil.Discard)
+
+	client := fake.NewSimpleClientset()
+	cfg := Config{Namespace: "selenosis", Name: "selenosis", LeaseDuration: time.Second}
+
+	cfg.Identity = "selenosis-0"
+	first, err := New(logger, client, cfg)
+	assert.NilError(t, err)
+	cfg.Identity = "selenosis-1"
+	second, err := New(logger, client, cfg)
+	assert.NilError(t, err)
+
+	stopFirst, stopSecond := make(chan struct{}), make(chan struct{})
+	defer close(stopSecond)
+	go first.Run(stopFirst)
+	assert.Assert(t, eventually(first.IsLeader), "first replica did not acquire lease")
+
+	go second.Run(stopSecond)
+	time.Sleep(500 * time.Millisecond)
+	assert.Assert(t, !second.IsLeader(), "lease is held by both replicas")
+
+	close(stopFirst)
+	assert.Assert(t, eventually(second.IsLeader), "second replica did not take over lease")
+	assert.Assert(t, !first.IsLeader())
+}
+
+func TestInvalidConfig(t *testing.T) {
+	_, err := New(log.New(), fake.NewSimpleClientset(), Config{Namespace: "selenosis", Name: "selenosis", Identity: "selenosis-0"})
+	assert.Assert(t, err != nil)
+}
+
+func eventually(condition func() bool) bool {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
@@ -0,0 +1,250 @@
+package loadtest
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//throttledMetric is selenosis counter of kubernetes api requests delayed by client side rate limiter
+const throttledMetric = "selenosis_apiserver_throttled_requests_total"
+
+//phaseOrder is the order session startup phases are reported in, phases unknown to bench are reported after them
+var phaseOrder = []string{"create", "schedule", "running", "ready", "session", "total"}
+
+//BenchConfig ...
+type BenchConfig struct {
+	URL      string
+	Rate     float64
+	Duration time.Duration
+	Browser  string
+	Version  string
+	Timeout  time.Duration
+}
+
+//BenchReport ...
+type BenchReport struct {
+	Cycles     int                `json:"cycles"`
+	Failed     int                `json:"failed"`
+	Errors     map[string]int     `json:"errors,omitempty"`
+	Duration   time.Duration      `json:"duration"`
+	Throughput float64            `json:"throughput"`
+	Phases     map[string]Latency `json:"phases"`
+	Throttled  *int64             `json:"throttled,omitempty"`
+}
+
+//String returns human readable report
+func (r BenchReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "cycles: %d, failed: %d, duration: %s, throughput: %.2f sessions/s\n",
+		r.Cycles, r.Failed, r.Duration.Round(time.Millisecond), r.Throughput)
+	fmt.Fprintf(&b, "%-10s %8s %10s %10s %10s %10s\n", "phase", "count", "p50", "p95", "p99", "max")
+	for _, name := range sortPhases(r.Phases) {
+		l := r.Phases[name]
+		fmt.Fprintf(&b, "%-10s %8d %10s %10s %10s %10s\n", name, l.Count,
+			l.P50.Round(time.Millisecond), l.P95.Round(time.Millisecond), l.P99.Round(time.Millisecond), l.Max.Round(time.Millisecond))
+	}
+	if r.Throttled != nil {
+		fmt.Fprintf(&b, "apiserver throttled requests: %d\n", *r.Throttled)
+	} else {
+		fmt.Fprintf(&b, "apiserver throttled requests: n/a\n")
+	}
+	errors := make([]string, 0, len(r.Errors))
+	for msg := range r.Errors {
+		errors = append(errors, msg)
+	}
+	sort.Strings(errors)
+	for _, msg := range errors {
+		fmt.Fprintf(&b, "error (%d): %s\n", r.Errors[msg], msg)
+	}
+	return b.String()
+}
+
+type cycle struct {
+	phases map[string]time.Duration
+	err    error
+}
+
+//Bench starts session create/delete cycles at configured rate for configured duration,
+//startup phases are taken from Server-Timing header of new session response
+func Bench(ctx context.Context, cfg BenchConfig) BenchReport {
+	if cfg.Rate <= 0 {
+		cfg.Rate = 1
+	}
+	client := &http.Client{Timeout: cfg.Timeout}
+
+	throttledBefore, throttleErr := scrapeCounter(ctx, client, cfg.URL, throttledMetric)
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	results := make(chan cycle)
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	go func() {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / cfg.Rate))
+		defer ticker.Stop()
+		for {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results <- benchCycle(context.Background(), client, cfg)
+			}()
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				wg.Wait()
+				close(results)
+				return
+			}
+		}
+	}()
+
+	report := BenchReport{Errors: make(map[string]int)}
+	phases := make(map[string][]time.Duration)
+	for res := range results {
+		report.Cycles++
+		if res.err != nil {
+			report.Failed++
+			report.Errors[res.err.Error()]++
+		}
+		for name, d := range res.phases {
+			phases[name] = append(phases[name], d)
+		}
+	}
+	report.Duration = time.Since(start)
+	report.Throughput = float64(report.Cycles-report.Failed) / report.Duration.Seconds()
+	report.Phases = make(map[string]Latency)
+	for name, values := range phases {
+		report.Phases[name] = percentiles(values)
+	}
+
+	if throttleErr == nil {
+		throttledAfter, err := scrapeCounter(context.Background(), client, cfg.URL, throttledMetric)
+		if err == nil {
+			throttled := int64(throttledAfter - throttledBefore)
+			report.Throttled = &throttled
+		}
+	}
+	return report
+}
+
+//benchCycle creates session and deletes it right away, in-flight cycles are not cancelled
+//when benchmark ends so sessions are not left behind
+func benchCycle(ctx context.Context, client *http.Client, cfg BenchConfig) cycle {
+	res := cycle{phases: make(map[string]time.Duration)}
+
+	sessionID, d, header, err := newSession(ctx, client, cfg.URL, cfg.Browser, cfg.Version)
+	if err != nil {
+		res.err = fmt.Errorf("create: %v", err)
+		return res
+	}
+	for name, d := range parseServerTiming(header.Get("Server-Timing")) {
+		res.phases[name] = d
+	}
+	res.phases["total"] = d
+
+	if _, _, err := call(ctx, client, http.MethodDelete, cfg.URL+"/wd/hub/session/"+sessionID, nil, nil); err != nil {
+		res.err = fmt.Errorf("delete: %v", err)
+	}
+	return res
+}
+
+//parseServerTiming parses metric durations of Server-Timing header, metrics without duration are skipped
+func parseServerTiming(header string) map[string]time.Duration {
+	phases := make(map[string]time.Duration)
+	for _, metric := range strings.Split(header, ",") {
+		params := strings.Split(metric, ";")
+		name := strings.TrimSpace(params[0])
+		if name == "" {
+			continue
+		}
+		for _, param := range params[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) != 2 || kv[0] != "dur" {
+				continue
+			}
+			ms, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil {
+				continue
+			}
+			phases[name] = time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+	return phases
+}
+
+//scrapeCounter reads counter value from prometheus text exposition of selenosis, series of all labels are summed up
+func scrapeCounter(ctx context.Context, client *http.Client, url, name string) (float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/metrics", nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("metrics responded with status %d", resp.StatusCode)
+	}
+
+	var value float64
+	found := false
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, name) {
+			continue
+		}
+		series := strings.TrimPrefix(line, name)
+		if series != "" && series[0] != ' ' && series[0] != '{' {
+			continue
+		}
+		fields := strings.Fields(line)
+		v, err := strconv.ParseFloat(fields[len(fields)-1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse %s: %v", name, err)
+		}
+		value += v
+		found = true
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, fmt.Errorf("metric %s not found", name)
+	}
+	return value, nil
+}
+
+//sortPhases orders phases as they happen during session startup
+func sortPhases(phases map[string]Latency) []string {
+	index := make(map[string]int)
+	for i, name := range phaseOrder {
+		index[name] = i
+	}
+	names := make([]string, 0, len(phases))
+	for name := range phases {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, okA := index[names[i]]
+		b, okB := index[names[j]]
+		switch {
+		case okA && okB:
+			return a < b
+		case okA != okB:
+			return okA
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
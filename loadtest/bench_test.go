@@ -0,0 +1,61 @@
+package loadtest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestParseServerTiming(t *testing.T) {
+	tests := map[string]struct {
+		header string
+		phases map[string]time.Duration
+	}{
+		"Verify startup phases parsed": {
+			header: "create;dur=12.5, schedule;dur=100.0, session;dur=2000",
+			phases: map[string]time.Duration{
+				"create":   12500 * time.Microsecond,
+				"schedule": 100 * time.Millisecond,
+				"session":  2 * time.Second,
+			},
+		},
+		"Verify metrics without duration skipped": {
+			header: "cache;desc=miss, create;dur=1",
+			phases: map[string]time.Duration{"create": time.Millisecond},
+		},
+		"Verify empty header": {
+			header: "",
+			phases: map[string]time.Duration{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+		assert.DeepEqual(t, test.phases, parseServerTiming(test.header))
+	}
+}
+
+func TestScrapeCounter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("# HELP selenosis_apiserver_throttled_requests_total help\n" +
+			"selenosis_apiserver_throttled_requests_total 7\n" +
+			"selenosis_apiserver_throttled_requests_total_other 100\n"))
+	}))
+	defer srv.Close()
+
+	value, err := scrapeCounter(context.Background(), srv.Client(), srv.URL, throttledMetric)
+	assert.NilError(t, err)
+	assert.Equal(t, float64(7), value)
+
+	_, err = scrapeCounter(context.Background(), srv.Client(), srv.URL, "selenosis_missing_total")
+	assert.Error(t, err, "metric selenosis_missing_total not found")
+}
+
+func TestSortPhases(t *testing.T) {
+	phases := map[string]Latency{"total": {}, "custom": {}, "create": {}, "ready": {}}
+	assert.DeepEqual(t, []string{"create", "ready", "total", "custom"}, sortPhases(phases))
+}
@@ -0,0 +1,271 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//Config ...
+type Config struct {
+	URL         string
+	Sessions    int
+	Concurrency int
+	Browser     string
+	Version     string
+	Commands    int
+	Timeout     time.Duration
+}
+
+//Latency describes latency percentiles of requests
+type Latency struct {
+	Count int           `json:"count"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P95   time.Duration `json:"p95"`
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"`
+}
+
+//Report ...
+type Report struct {
+	Sessions int                `json:"sessions"`
+	Failed   int                `json:"failed"`
+	Errors   map[string]int     `json:"errors,omitempty"`
+	Duration time.Duration      `json:"duration"`
+	Latency  map[string]Latency `json:"latency"`
+}
+
+//String returns human readable report
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "sessions: %d, failed: %d, duration: %s\n", r.Sessions, r.Failed, r.Duration.Round(time.Millisecond))
+	fmt.Fprintf(&b, "%-10s %8s %10s %10s %10s %10s\n", "request", "count", "p50", "p90", "p99", "max")
+	for _, name := range []string{"create", "command", "delete"} {
+		l := r.Latency[name]
+		fmt.Fprintf(&b, "%-10s %8d %10s %10s %10s %10s\n", name, l.Count,
+			l.P50.Round(time.Millisecond), l.P90.Round(time.Millisecond), l.P99.Round(time.Millisecond), l.Max.Round(time.Millisecond))
+	}
+	errors := make([]string, 0, len(r.Errors))
+	for msg := range r.Errors {
+		errors = append(errors, msg)
+	}
+	sort.Strings(errors)
+	for _, msg := range errors {
+		fmt.Fprintf(&b, "error (%d): %s\n", r.Errors[msg], msg)
+	}
+	return b.String()
+}
+
+type result struct {
+	create   time.Duration
+	commands []time.Duration
+	delete   time.Duration
+	err      error
+}
+
+//Run creates sessions against selenosis, sends commands to every session and deletes it
+func Run(ctx context.Context, cfg Config) Report {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+
+	jobs := make(chan struct{})
+	results := make(chan result)
+	client := &http.Client{Timeout: cfg.Timeout}
+
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				results <- session(ctx, client, cfg)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i := 0; i < cfg.Sessions; i++ {
+			select {
+			case jobs <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	start := time.Now()
+	report := Report{Errors: make(map[string]int)}
+	var create, commands, delete []time.Duration
+	for res := range results {
+		report.Sessions++
+		if res.err != nil {
+			report.Failed++
+			report.Errors[res.err.Error()]++
+		}
+		if res.create > 0 {
+			create = append(create, res.create)
+		}
+		commands = append(commands, res.commands...)
+		if res.delete > 0 {
+			delete = append(delete, res.delete)
+		}
+	}
+	report.Duration = time.Since(start)
+	report.Latency = map[string]Latency{
+		"create":  percentiles(create),
+		"command": percentiles(commands),
+		"delete":  percentiles(delete),
+	}
+	return report
+}
+
+//session runs one synthetic session, latency is recorded for successful requests only
+func session(ctx context.Context, client *http.Client, cfg Config) result {
+	var res result
+
+	sessionID, d, _, err := newSession(ctx, client, cfg.URL, cfg.Browser, cfg.Version)
+	if err != nil {
+		res.err = fmt.Errorf("create: %v", err)
+		return res
+	}
+	res.create = d
+	sessionURL := cfg.URL + "/wd/hub/session/" + sessionID
+
+	for i := 0; i < cfg.Commands; i++ {
+		var d time.Duration
+		var err error
+		if i%2 == 0 {
+			d, _, err = call(ctx, client, http.MethodPost, sessionURL+"/url", []byte(`{"url":"about:blank"}`), nil)
+		} else {
+			d, _, err = call(ctx, client, http.MethodGet, sessionURL+"/title", nil, nil)
+		}
+		if err != nil {
+			res.err = fmt.Errorf("command: %v", err)
+			break
+		}
+		res.commands = append(res.commands, d)
+	}
+
+	d, _, err = call(ctx, client, http.MethodDelete, sessionURL, nil, nil)
+	if err != nil {
+		if res.err == nil {
+			res.err = fmt.Errorf("delete: %v", err)
+		}
+		return res
+	}
+	res.delete = d
+	return res
+}
+
+//newSession requests session with both legacy and W3C capabilities
+func newSession(ctx context.Context, client *http.Client, url, browser, version string) (string, time.Duration, http.Header, error) {
+	caps := map[string]string{"browserName": browser}
+	if version != "" {
+		caps["browserVersion"] = version
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"desiredCapabilities": caps,
+		"capabilities":        map[string]interface{}{"alwaysMatch": caps},
+	})
+
+	var created struct {
+		SessionID string `json:"sessionId"`
+		Value     struct {
+			SessionID string `json:"sessionId"`
+		} `json:"value"`
+	}
+	d, header, err := call(ctx, client, http.MethodPost, url+"/wd/hub/session", body, &created)
+	if err != nil {
+		return "", 0, nil, err
+	}
+
+	sessionID := created.Value.SessionID
+	if sessionID == "" {
+		sessionID = created.SessionID
+	}
+	if sessionID == "" {
+		return "", 0, nil, fmt.Errorf("session id not found in response")
+	}
+	return sessionID, d, header, nil
+}
+
+func call(ctx context.Context, client *http.Client, method, url string, body []byte, v interface{}) (time.Duration, http.Header, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	d := time.Since(start)
+	if err != nil {
+		return 0, nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		var msg struct {
+			Value struct {
+				Message string `json:"message"`
+			} `json:"value"`
+		}
+		json.Unmarshal(data, &msg)
+		return 0, nil, fmt.Errorf("status %d: %s", resp.StatusCode, msg.Value.Message)
+	}
+	if v != nil {
+		if err := json.Unmarshal(data, v); err != nil {
+			return 0, nil, fmt.Errorf("failed to parse response: %v", err)
+		}
+	}
+	return d, resp.Header, nil
+}
+
+//percentiles uses nearest-rank method
+func percentiles(values []time.Duration) Latency {
+	if len(values) == 0 {
+		return Latency{}
+	}
+	sorted := append([]time.Duration{}, values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := func(p float64) time.Duration {
+		i := int(math.Ceil(p*float64(len(sorted)))) - 1
+		if i < 0 {
+			i = 0
+		}
+		return sorted[i]
+	}
+	return Latency{
+		Count: len(sorted),
+		P50:   rank(0.5),
+		P90:   rank(0.9),
+		P95:   rank(0.95),
+		P99:   rank(0.99),
+		Max:   sorted[len(sorted)-1],
+	}
+}
@@ -0,0 +1,76 @@
+package loadtest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/mockbrowser"
+	"gotest.tools/assert"
+)
+
+func TestRun(t *testing.T) {
+	tests := map[string]struct {
+		handler  http.Handler
+		failed   int
+		create   int
+		commands int
+		errors   map[string]int
+	}{
+		"Verify sessions against mock browser": {
+			handler:  mockbrowser.New("chrome", "mock", time.Millisecond),
+			create:   6,
+			commands: 18,
+			errors:   map[string]int{},
+		},
+		"Verify failed sessions reported": {
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(`{"code":500,"value":{"message":"New session attempts retry count exceeded"}}`))
+			}),
+			failed: 6,
+			errors: map[string]int{"create: status 500: New session attempts retry count exceeded": 6},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		srv := httptest.NewServer(test.handler)
+		report := Run(context.Background(), Config{
+			URL:         srv.URL,
+			Sessions:    6,
+			Concurrency: 3,
+			Browser:     "chrome",
+			Commands:    3,
+			Timeout:     time.Second,
+		})
+		srv.Close()
+
+		assert.Equal(t, 6, report.Sessions)
+		assert.Equal(t, test.failed, report.Failed)
+		assert.DeepEqual(t, test.errors, report.Errors)
+		assert.Equal(t, test.create, report.Latency["create"].Count)
+		assert.Equal(t, test.commands, report.Latency["command"].Count)
+		assert.Equal(t, test.create, report.Latency["delete"].Count)
+	}
+}
+
+func TestPercentiles(t *testing.T) {
+	var values []time.Duration
+	for i := 100; i > 0; i-- {
+		values = append(values, time.Duration(i)*time.Millisecond)
+	}
+
+	assert.DeepEqual(t, Latency{
+		Count: 100,
+		P50:   50 * time.Millisecond,
+		P90:   90 * time.Millisecond,
+		P95:   95 * time.Millisecond,
+		P99:   99 * time.Millisecond,
+		Max:   100 * time.Millisecond,
+	}, percentiles(values))
+	assert.DeepEqual(t, Latency{}, percentiles(nil))
+}
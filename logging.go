@@ -0,0 +1,98 @@
+package selenosis
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/alcounit/selenosis/tools"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	//LogFormatText is human readable log format
+	LogFormatText = "text"
+	//LogFormatJSON writes every log line as JSON object, so log collectors index its fields
+	LogFormatJSON = "json"
+
+	//requestIDHeader carries correlation id of the request, it is passed to the browser pod with proxied requests
+	requestIDHeader = "X-Request-Id"
+)
+
+type logLevel struct {
+	Level string `json:"level"`
+}
+
+//NewLogger returns logger writing lines in text or json format at given level
+func NewLogger(format, level string) (*logrus.Logger, error) {
+	logger := logrus.New()
+	switch format {
+	case "", LogFormatText:
+	case LogFormatJSON:
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		return nil, fmt.Errorf("unknown log format %s, supported: %s, %s", format, LogFormatText, LogFormatJSON)
+	}
+
+	if level != "" {
+		lvl, err := logrus.ParseLevel(level)
+		if err != nil {
+			return nil, err
+		}
+		logger.SetLevel(lvl)
+	}
+	return logger, nil
+}
+
+//requestID returns correlation id of the request, id set by client or ingress is kept so the request is traced
+//with the same id across selenosis replicas and seleniferous sidecar
+func requestID(r *http.Request) string {
+	if id := r.Header.Get(requestIDHeader); id != "" {
+		return id
+	}
+	id := uuid.New().String()
+	r.Header.Set(requestIDHeader, id)
+	return id
+}
+
+//requestLogger returns logger of the request, lines of known session also carry its browser and tenant
+func (app *App) requestLogger(r *http.Request, sessionID string) *logrus.Entry {
+	fields := logrus.Fields{
+		"request_id": requestID(r),
+		"request":    fmt.Sprintf("%s %s", r.Method, r.URL.Path),
+	}
+	if sessionID != "" {
+		fields["session_id"] = sessionID
+		if service, ok := app.stats.Sessions().Get(sessionID); ok {
+			for field, label := range map[string]string{"browser": "browserName", "browser_version": "browserVersion", "tenant": "tenant"} {
+				if value := service.Labels[label]; value != "" {
+					fields[field] = value
+				}
+			}
+		}
+	}
+	return app.logger.WithFields(fields)
+}
+
+//HandleLogLevel returns or changes log level at runtime, e.g. to debug a misbehaving replica without restart
+func (app *App) HandleLogLevel(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPut {
+		var request logLevel
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			tools.JSONError(w, fmt.Sprintf("failed to parse request: %v", err), http.StatusBadRequest)
+			return
+		}
+		level, err := logrus.ParseLevel(request.Level)
+		if err != nil {
+			tools.JSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		previous := app.logger.GetLevel()
+		app.logger.SetLevel(level)
+		app.logger.WithField("component", "logging").Infof("log level changed from %s to %s", previous, level)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(logLevel{Level: app.logger.GetLevel().String()})
+}
@@ -0,0 +1,168 @@
+package selenosis
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+)
+
+func TestNewLogger(t *testing.T) {
+	tests := map[string]struct {
+		format string
+		level  string
+		json   bool
+		err    string
+	}{
+		"Verify text logger with default level": {
+			format: LogFormatText,
+		},
+		"Verify json logger": {
+			format: LogFormatJSON,
+			level:  "debug",
+			json:   true,
+		},
+		"Verify unknown log format is rejected": {
+			format: "xml",
+			err:    "unknown log format xml, supported: text, json",
+		},
+		"Verify unknown log level is rejected": {
+			level: "verbose",
+			err:   `not a valid logrus Level: "verbose"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		logger, err := NewLogger(test.format, test.level)
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+		_, ok := logger.Formatter.(*logrus.JSONFormatter)
+		assert.Equal(t, test.json, ok)
+		if test.level != "" {
+			assert.Equal(t, test.level, logger.GetLevel().String())
+		}
+	}
+}
+
+func TestRequestLogger(t *testing.T) {
+	tests := map[string]struct {
+		requestID string
+		sessionID string
+		fields    logrus.Fields
+	}{
+		"Verify request id of the client is kept": {
+			requestID: "ingress-request",
+			fields:    logrus.Fields{"request_id": "ingress-request", "request": "GET /wd/hub/status"},
+		},
+		"Verify session request carries browser and tenant": {
+			requestID: "ingress-request",
+			sessionID: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491",
+			fields: logrus.Fields{
+				"request_id":      "ingress-request",
+				"request":         "GET /wd/hub/status",
+				"session_id":      "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491",
+				"browser":         "chrome",
+				"browser_version": "85.0",
+				"tenant":          "team-a",
+			},
+		},
+		"Verify unknown session carries session id only": {
+			requestID: "ingress-request",
+			sessionID: "firefox-82-0-de44c3c4-1a35-412b-b526-f5da80214491",
+			fields: logrus.Fields{
+				"request_id": "ingress-request",
+				"request":    "GET /wd/hub/status",
+				"session_id": "firefox-82-0-de44c3c4-1a35-412b-b526-f5da80214491",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		app.stats.Sessions().Put("chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491", platform.Service{
+			SessionID: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491",
+			Labels:    map[string]string{"browserName": "chrome", "browserVersion": "85.0", "tenant": "team-a"},
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/wd/hub/status", http.NoBody)
+		req.Header.Set(requestIDHeader, test.requestID)
+		entry := app.requestLogger(req, test.sessionID)
+		assert.DeepEqual(t, test.fields, entry.Data)
+	}
+}
+
+func TestRequestLoggerGeneratesRequestID(t *testing.T) {
+	app := initApp(&PlatformMock{})
+
+	req := httptest.NewRequest(http.MethodGet, "/wd/hub/status", http.NoBody)
+	entry := app.requestLogger(req, "")
+	id := req.Header.Get(requestIDHeader)
+	assert.Assert(t, id != "")
+	assert.Equal(t, id, entry.Data["request_id"])
+}
+
+func TestHandleLogLevel(t *testing.T) {
+	tests := map[string]struct {
+		method   string
+		body     string
+		respCode int
+		respBody string
+		level    logrus.Level
+	}{
+		"Verify current log level is returned": {
+			method:   http.MethodGet,
+			respCode: http.StatusOK,
+			respBody: `{"level":"info"}`,
+			level:    logrus.InfoLevel,
+		},
+		"Verify log level is changed": {
+			method:   http.MethodPut,
+			body:     `{"level":"debug"}`,
+			respCode: http.StatusOK,
+			respBody: `{"level":"debug"}`,
+			level:    logrus.DebugLevel,
+		},
+		"Verify unknown log level is rejected": {
+			method:   http.MethodPut,
+			body:     `{"level":"verbose"}`,
+			respCode: http.StatusBadRequest,
+			respBody: `{"code":400,"value":{"message":"not a valid logrus Level: \"verbose\""}}`,
+			level:    logrus.InfoLevel,
+		},
+		"Verify invalid request is rejected": {
+			method:   http.MethodPut,
+			body:     `level`,
+			respCode: http.StatusBadRequest,
+			respBody: `{"code":400,"value":{"message":"failed to parse request: invalid character 'l' looking for beginning of value"}}`,
+			level:    logrus.InfoLevel,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		app.logger, _ = NewLogger(LogFormatJSON, "info")
+		app.logger.Out = ioutil.Discard
+
+		req := httptest.NewRequest(test.method, "/admin/log-level", bytes.NewBufferString(test.body))
+		rr := httptest.NewRecorder()
+		app.HandleLogLevel(rr, req)
+
+		assert.Equal(t, test.respCode, rr.Code)
+		assert.Equal(t, test.respBody, string(bytes.TrimSpace(rr.Body.Bytes())))
+		assert.Equal(t, test.level, app.logger.GetLevel())
+	}
+}
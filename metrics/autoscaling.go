@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+//Capacity is capacity of selenosis replica reported to autoscalers, relayed sessions are not counted as
+//they don't run on cluster nodes
+type Capacity struct {
+	Slots   int
+	Used    int
+	Pending int
+	Queued  int
+}
+
+var (
+	capacityLock   sync.RWMutex
+	capacitySource = func() Capacity { return Capacity{} }
+)
+
+//SetCapacitySource sets function returning current capacity of the replica, autoscaling metrics are
+//computed by it on every scrape, so they are never stale
+func SetCapacitySource(source func() Capacity) {
+	capacityLock.Lock()
+	defer capacityLock.Unlock()
+	capacitySource = source
+}
+
+func currentCapacity() Capacity {
+	capacityLock.RLock()
+	defer capacityLock.RUnlock()
+	return capacitySource()
+}
+
+//Autoscaling metrics are stable API for KEDA and HPA external metrics, names and meaning of these series
+//are not changed without major version bump
+var (
+	//AutoscalingPendingSessions reports sessions waiting for browser: queued requests and pending pods
+	AutoscalingPendingSessions = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "autoscaling",
+			Name:      "pending_sessions",
+			Help:      "Number of new session requests waiting in queue and browser pods not running yet.",
+		},
+		func() float64 {
+			c := currentCapacity()
+			return float64(c.Queued + c.Pending)
+		},
+	)
+
+	//AutoscalingUsedSlots reports slots taken by running and pending browser pods
+	AutoscalingUsedSlots = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "autoscaling",
+			Name:      "used_slots",
+			Help:      "Number of browser slots taken by running and pending browser pods.",
+		},
+		func() float64 {
+			c := currentCapacity()
+			return float64(c.Used + c.Pending)
+		},
+	)
+
+	//AutoscalingTotalSlots reports session limit of the replica
+	AutoscalingTotalSlots = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "autoscaling",
+			Name:      "total_slots",
+			Help:      "Number of browser slots of the replica, i.e. its session limit.",
+		},
+		func() float64 {
+			return float64(currentCapacity().Slots)
+		},
+	)
+
+	//AutoscalingSlotUtilization reports ratio of used slots and queued requests to total slots
+	AutoscalingSlotUtilization = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "autoscaling",
+			Name:      "slot_utilization",
+			Help:      "Ratio of used browser slots and queued session requests to total slots, above 1 when demand exceeds capacity.",
+		},
+		func() float64 {
+			c := currentCapacity()
+			if c.Slots <= 0 {
+				return 0
+			}
+			return float64(c.Used+c.Pending+c.Queued) / float64(c.Slots)
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(
+		AutoscalingPendingSessions,
+		AutoscalingUsedSlots,
+		AutoscalingTotalSlots,
+		AutoscalingSlotUtilization,
+	)
+}
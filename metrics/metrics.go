@@ -0,0 +1,328 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "selenosis"
+
+var (
+	//OrphansReaped counts browser pods deleted by janitor
+	OrphansReaped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "janitor",
+			Name:      "orphans_reaped_total",
+			Help:      "Number of orphaned browser pods deleted by janitor.",
+		},
+		[]string{"reason"},
+	)
+
+	//ResourcesReaped counts auxiliary objects deleted by janitor
+	ResourcesReaped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "janitor",
+			Name:      "resources_reaped_total",
+			Help:      "Number of auxiliary session objects deleted by janitor after their pod is gone.",
+		},
+		[]string{"kind"},
+	)
+
+	//ProxiedCommands counts WebDriver commands proxied to browsers
+	ProxiedCommands = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "proxy",
+			Name:      "commands_total",
+			Help:      "Number of WebDriver commands proxied to browser sessions.",
+		},
+		[]string{"browser", "result"},
+	)
+
+	//CommandDuration observes WebDriver command latency
+	CommandDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "proxy",
+			Name:      "command_duration_seconds",
+			Help:      "Latency of WebDriver commands proxied to browser sessions.",
+			Buckets:   prometheus.ExponentialBuckets(0.005, 2, 14),
+		},
+		[]string{"browser"},
+	)
+
+	//SessionPhaseDuration observes duration of session startup phases
+	SessionPhaseDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "session",
+			Name:      "phase_duration_seconds",
+			Help:      "Duration of session startup phases: pod create call, scheduling, container start, readiness and browser session creation.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 14),
+		},
+		[]string{"phase"},
+	)
+
+	//SessionsLost counts running sessions whose browser pod was evicted, drained from its node or failed
+	SessionsLost = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "session",
+			Name:      "lost_total",
+			Help:      "Number of running sessions whose browser pod was evicted, drained from its node or failed.",
+		},
+		[]string{"browser", "reason"},
+	)
+
+	//APIThrottled counts kubernetes api requests delayed by client side rate limiter
+	APIThrottled = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "apiserver",
+			Name:      "throttled_requests_total",
+			Help:      "Number of kubernetes api requests delayed by client side rate limiter.",
+		},
+	)
+
+	//APIThrottleDuration counts time kubernetes api requests spent waiting for rate limiter
+	APIThrottleDuration = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "apiserver",
+			Name:      "throttle_seconds_total",
+			Help:      "Time kubernetes api requests spent waiting for client side rate limiter.",
+		},
+	)
+
+	//Goroutines observes number of running goroutines
+	Goroutines = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "soak",
+			Name:      "goroutines",
+			Help:      "Number of goroutines sampled by soak monitor.",
+		},
+	)
+
+	//HeapBytes observes allocated heap
+	HeapBytes = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "soak",
+			Name:      "heap_bytes",
+			Help:      "Bytes of allocated heap objects sampled by soak monitor.",
+		},
+	)
+
+	//InformerCacheObjects observes number of objects in informer caches
+	InformerCacheObjects = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "soak",
+			Name:      "informer_cache_objects",
+			Help:      "Number of objects in kubernetes informer caches sampled by soak monitor.",
+		},
+		[]string{"resource"},
+	)
+
+	//UpstreamConnections observes number of open connections to browser pods
+	UpstreamConnections = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "soak",
+			Name:      "upstream_connections",
+			Help:      "Number of open proxy transport connections to browser pods sampled by soak monitor.",
+		},
+	)
+
+	//RegistryEntries observes number of entries in session registries
+	RegistryEntries = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "soak",
+			Name:      "registry_entries",
+			Help:      "Number of entries in in-memory session registries sampled by soak monitor.",
+		},
+		[]string{"registry"},
+	)
+
+	//GrowthAlerts counts series detected growing by soak monitor
+	GrowthAlerts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "soak",
+			Name:      "growth_alerts_total",
+			Help:      "Number of times soak monitor detected sustained growth of a series.",
+		},
+		[]string{"series"},
+	)
+
+	//JanitorRuns counts janitor reconcile loops
+	JanitorRuns = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "janitor",
+			Name:      "runs_total",
+			Help:      "Number of janitor reconcile loops.",
+		},
+		[]string{"result"},
+	)
+
+	//BurstSessions counts new sessions created on secondary backend
+	BurstSessions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "burst",
+			Name:      "sessions_total",
+			Help:      "Number of new sessions sent to secondary backend because local capacity or queue wait crossed burst threshold.",
+		},
+		[]string{"browser", "result"},
+	)
+
+	//BurstActiveSessions observes number of sessions running on secondary backend
+	BurstActiveSessions = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "burst",
+			Name:      "active_sessions",
+			Help:      "Number of sessions currently running on secondary backend.",
+		},
+	)
+
+	//PendingPodsCancelled counts browser pods deleted by pending watchdog by reason reported by kubernetes
+	PendingPodsCancelled = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "watchdog",
+			Name:      "pending_pods_cancelled_total",
+			Help:      "Number of browser pods deleted because they stayed pending longer than pending timeout.",
+		},
+		[]string{"reason"},
+	)
+
+	//ConfigReloads counts reloads of configs
+	ConfigReloads = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "config",
+			Name:      "reloads_total",
+			Help:      "Number of config reloads, failed reloads keep previous config in use.",
+		},
+		[]string{"config", "result"},
+	)
+
+	//QueuedSessions reports new session requests waiting for free capacity
+	QueuedSessions = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "queue",
+			Name:      "sessions",
+			Help:      "Number of new session requests waiting in queue for free capacity.",
+		},
+	)
+
+	//QueueRejected counts new session requests rejected by session queue
+	QueueRejected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "queue",
+			Name:      "rejected_total",
+			Help:      "Number of new session requests rejected because session queue was full or wait time was exceeded.",
+		},
+		[]string{"reason"},
+	)
+
+	//WarmPoolClaims counts sessions served by warm browser pods
+	WarmPoolClaims = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "warm_pool",
+			Name:      "claims_total",
+			Help:      "Number of new sessions served by pre-started browser pods of warm pool.",
+		},
+		[]string{"browser"},
+	)
+
+	//QuotaRejected counts new session requests rejected because quota was exceeded
+	QuotaRejected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "quota",
+			Name:      "rejected_total",
+			Help:      "Number of new session requests rejected because browser, browser version or client quota was exceeded.",
+		},
+		[]string{"kind"},
+	)
+
+	//AuditDropped counts audit events which were not delivered to sink
+	AuditDropped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "audit",
+			Name:      "dropped_events_total",
+			Help:      "Number of audit events not delivered to sink, because sink rejected them or selenosis was shutting down.",
+		},
+		[]string{"sink", "reason"},
+	)
+
+	//QuotaUsed reports sessions counted against quotas
+	QuotaUsed = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "quota",
+			Name:      "used_sessions",
+			Help:      "Number of running and pending sessions counted against browser, browser version and client quotas.",
+		},
+		[]string{"kind", "name"},
+	)
+
+	//Leader reports if replica holds leader lease
+	Leader = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "leader",
+			Name:      "is_leader",
+			Help:      "Whether this replica holds leader lease and runs cluster-wide cleanup, 1 or 0.",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(
+		OrphansReaped,
+		ResourcesReaped,
+		JanitorRuns,
+		ProxiedCommands,
+		CommandDuration,
+		SessionPhaseDuration,
+		SessionsLost,
+		APIThrottled,
+		APIThrottleDuration,
+		Goroutines,
+		HeapBytes,
+		InformerCacheObjects,
+		UpstreamConnections,
+		RegistryEntries,
+		GrowthAlerts,
+		BurstSessions,
+		BurstActiveSessions,
+		ConfigReloads,
+		PendingPodsCancelled,
+		QueuedSessions,
+		QueueRejected,
+		QuotaRejected,
+		QuotaUsed,
+		AuditDropped,
+		WarmPoolClaims,
+		Leader,
+	)
+}
+
+//Handler returns prometheus metrics handler
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,86 @@
+package mockbrowser
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//Browser is a minimal WebDriver server, it creates sessions, accepts any session command
+//and responds after configured latency, so grid can be load tested without real browsers
+type Browser struct {
+	name     string
+	version  string
+	latency  time.Duration
+	sessions sync.Map
+}
+
+//New ...
+func New(name, version string, latency time.Duration) *Browser {
+	return &Browser{
+		name:    name,
+		version: version,
+		latency: latency,
+	}
+}
+
+//ServeHTTP serves WebDriver requests with or without /wd/hub prefix
+func (b *Browser) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	time.Sleep(b.latency)
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/wd/hub"), "/")
+	fragments := strings.Split(path, "/")
+
+	switch {
+	case path == "status":
+		reply(w, http.StatusOK, map[string]interface{}{"ready": true, "message": "mock browser is ready"})
+	case path == "session" && r.Method == http.MethodPost:
+		b.newSession(w, r)
+	case fragments[0] == "session" && len(fragments) > 1:
+		sessionID := fragments[1]
+		if _, ok := b.sessions.Load(sessionID); !ok {
+			reply(w, http.StatusNotFound, map[string]string{"error": "invalid session id", "message": "session " + sessionID + " not found"})
+			return
+		}
+		if len(fragments) == 2 && r.Method == http.MethodDelete {
+			b.sessions.Delete(sessionID)
+		}
+		if len(fragments) == 3 && fragments[2] == "title" {
+			reply(w, http.StatusOK, "mock browser")
+			return
+		}
+		reply(w, http.StatusOK, nil)
+	default:
+		reply(w, http.StatusNotFound, map[string]string{"error": "unknown command", "message": r.Method + " " + r.URL.Path})
+	}
+}
+
+func (b *Browser) newSession(w http.ResponseWriter, r *http.Request) {
+	var request map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		reply(w, http.StatusBadRequest, map[string]string{"error": "invalid argument", "message": err.Error()})
+		return
+	}
+	defer r.Body.Close()
+
+	sessionID := strings.ReplaceAll(uuid.New().String(), "-", "")
+	b.sessions.Store(sessionID, struct{}{})
+
+	reply(w, http.StatusOK, map[string]interface{}{
+		"sessionId": sessionID,
+		"capabilities": map[string]string{
+			"browserName":    b.name,
+			"browserVersion": b.version,
+		},
+	})
+}
+
+func reply(w http.ResponseWriter, code int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{"value": value})
+}
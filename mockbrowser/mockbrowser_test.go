@@ -0,0 +1,85 @@
+package mockbrowser
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestBrowser(t *testing.T) {
+	b := New("chrome", "mock", 0)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/wd/hub/session", bytes.NewReader([]byte(`{"capabilities":{}}`)))
+	b.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var created struct {
+		Value struct {
+			SessionID    string            `json:"sessionId"`
+			Capabilities map[string]string `json:"capabilities"`
+		} `json:"value"`
+	}
+	assert.NilError(t, json.NewDecoder(rr.Body).Decode(&created))
+	assert.Equal(t, "mock", created.Value.Capabilities["browserVersion"])
+	sessionID := created.Value.SessionID
+
+	tests := map[string]struct {
+		method   string
+		path     string
+		code     int
+		respBody string
+	}{
+		"Verify status": {
+			method:   http.MethodGet,
+			path:     "/status",
+			code:     http.StatusOK,
+			respBody: `{"value":{"message":"mock browser is ready","ready":true}}`,
+		},
+		"Verify session command": {
+			method:   http.MethodPost,
+			path:     "/session/" + sessionID + "/url",
+			code:     http.StatusOK,
+			respBody: `{"value":null}`,
+		},
+		"Verify title command": {
+			method:   http.MethodGet,
+			path:     "/wd/hub/session/" + sessionID + "/title",
+			code:     http.StatusOK,
+			respBody: `{"value":"mock browser"}`,
+		},
+		"Verify unknown session": {
+			method:   http.MethodGet,
+			path:     "/session/unknown/title",
+			code:     http.StatusNotFound,
+			respBody: `{"value":{"error":"invalid session id","message":"session unknown not found"}}`,
+		},
+		"Verify unknown command": {
+			method:   http.MethodGet,
+			path:     "/unknown",
+			code:     http.StatusNotFound,
+			respBody: `{"value":{"error":"unknown command","message":"GET /unknown"}}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		rr := httptest.NewRecorder()
+		b.ServeHTTP(rr, httptest.NewRequest(test.method, test.path, nil))
+		assert.Equal(t, test.code, rr.Code)
+		assert.Equal(t, test.respBody, string(bytes.TrimSpace(rr.Body.Bytes())))
+	}
+
+	rr = httptest.NewRecorder()
+	b.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/session/"+sessionID, nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	b.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/session/"+sessionID+"/title", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -0,0 +1,64 @@
+package selenosis
+
+import (
+	"time"
+
+	"github.com/alcounit/selenosis/audit"
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/storage"
+	"github.com/alcounit/selenosis/webhook"
+)
+
+//sessionNotification returns webhook event of the session
+func sessionNotification(eventType webhook.EventType, service platform.Service) webhook.Event {
+	return webhook.Event{
+		Type:      eventType,
+		SessionID: service.SessionID,
+		Tenant:    service.Labels["tenant"],
+		Browser:   service.Labels["browserName"],
+		Version:   service.Labels["browserVersion"],
+		RunID:     service.Labels["runId"],
+	}
+}
+
+//notifySessionRequest sends webhook of new session request outcome, requests rejected before browser
+//was found are not announced
+func (app *App) notifySessionRequest(event audit.Event) {
+	eventType := webhook.SessionFailed
+	switch {
+	case event.Type == audit.SessionCreated:
+		eventType = webhook.SessionCreated
+	case event.Browser == "":
+		return
+	}
+	app.notifier.Notify(webhook.Event{
+		Type:      eventType,
+		SessionID: event.SessionID,
+		Tenant:    event.Tenant,
+		Browser:   event.Browser,
+		Version:   event.Version,
+		RunID:     event.RunID,
+		Message:   event.Message,
+	})
+}
+
+//notifySessionDeleted sends webhook when browser of the session is gone, session without commands for
+//its idle timeout is reported as timed out
+func notifySessionDeleted(stats *storage.Storage, notifier *webhook.Notifier, service platform.Service, idleTimeout time.Duration) {
+	if !notifier.Enabled() {
+		return
+	}
+	event := sessionNotification(webhook.SessionDeleted, service)
+	last := service.Started
+	if t, ok := stats.Activity().Get(service.SessionID); ok {
+		last = t
+	}
+	if service.Active.After(last) {
+		last = service.Active
+	}
+	if timeout := idleTimeoutOf(service, idleTimeout); timeout > 0 && !last.IsZero() && time.Since(last) >= timeout {
+		event.Type = webhook.SessionTimedOut
+		event.Message = "session was idle for " + timeout.String()
+	}
+	notifier.Notify(event)
+}
@@ -0,0 +1,209 @@
+package selenosis
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/audit"
+	"github.com/alcounit/selenosis/config"
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/webhook"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+)
+
+func TestNotifySessionDeleted(t *testing.T) {
+	tests := map[string]struct {
+		service   platform.Service
+		activity  time.Duration
+		eventType webhook.EventType
+	}{
+		"Verify deleted session is announced": {
+			service:   platform.Service{SessionID: "chrome-85-0-1", Started: time.Now().Add(-time.Hour), Labels: map[string]string{"browserName": "chrome", "runId": "build-42"}},
+			activity:  time.Second,
+			eventType: webhook.SessionDeleted,
+		},
+		"Verify idle session is announced as timed out": {
+			service:   platform.Service{SessionID: "chrome-85-0-2", Started: time.Now().Add(-time.Hour), Labels: map[string]string{"browserName": "chrome", "runId": "build-42"}},
+			activity:  10 * time.Minute,
+			eventType: webhook.SessionTimedOut,
+		},
+		"Verify session timeout replaces default idle timeout": {
+			service:   platform.Service{SessionID: "chrome-85-0-3", Started: time.Now().Add(-time.Hour), Labels: map[string]string{"browserName": "chrome", "runId": "build-42", sessionTimeoutLabel: "30m"}},
+			activity:  10 * time.Minute,
+			eventType: webhook.SessionDeleted,
+		},
+		"Verify session active through another replica is not timed out": {
+			service:   platform.Service{SessionID: "chrome-85-0-5", Started: time.Now().Add(-time.Hour), Active: time.Now().Add(-time.Minute), Labels: map[string]string{"browserName": "chrome", "runId": "build-42"}},
+			activity:  10 * time.Minute,
+			eventType: webhook.SessionDeleted,
+		},
+		"Verify session without commands is timed out since start": {
+			service:   platform.Service{SessionID: "chrome-85-0-4", Started: time.Now().Add(-time.Hour), Labels: map[string]string{"browserName": "chrome", "runId": "build-42"}},
+			eventType: webhook.SessionTimedOut,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		events := make(chan webhook.Event, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var event webhook.Event
+			json.NewDecoder(r.Body).Decode(&event)
+			events <- event
+		}))
+
+		app := initApp(&PlatformMock{})
+		if test.activity > 0 {
+			app.stats.Activity().Put(test.service.SessionID, time.Now().Add(-test.activity))
+		}
+
+		notifySessionDeleted(app.stats, webhook.New(app.logger, webhook.Config{URLs: []string{srv.URL}}), test.service, 5*time.Minute)
+
+		select {
+		case event := <-events:
+			assert.Equal(t, test.eventType, event.Type)
+			assert.Equal(t, test.service.SessionID, event.SessionID)
+			assert.Equal(t, "chrome", event.Browser)
+			assert.Equal(t, "build-42", event.RunID)
+		case <-time.After(time.Second):
+			t.Fatal("event is not sent")
+		}
+		srv.Close()
+	}
+}
+
+type watchMock struct {
+	*PlatformMock
+	events chan platform.Event
+}
+
+func (p *watchMock) Watch() <-chan platform.Event {
+	return p.events
+}
+
+func TestNotifySessionDeletedByLeader(t *testing.T) {
+	tests := map[string]struct {
+		leader   Leader
+		notified bool
+	}{
+		"Verify leader announces deleted session": {
+			leader:   leaderMock(true),
+			notified: true,
+		},
+		"Verify follower does not announce deleted session": {
+			leader: leaderMock(false),
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		events := make(chan webhook.Event, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var event webhook.Event
+			json.NewDecoder(r.Body).Decode(&event)
+			events <- event
+		}))
+
+		client := &watchMock{PlatformMock: &PlatformMock{}, events: make(chan platform.Event)}
+		browsers, _ := config.NewBrowsersConfig("config/browsers.yaml")
+		app := New(&logrus.Logger{}, client, browsers, Configuration{Leader: test.leader, Webhook: webhook.Config{URLs: []string{srv.URL}}})
+
+		service := platform.Service{SessionID: "chrome-85-0-1", Started: time.Now(), Labels: map[string]string{"browserName": "chrome"}}
+		client.events <- platform.Event{Type: platform.Added, PlatformObject: service}
+		client.events <- platform.Event{Type: platform.Deleted, PlatformObject: service}
+		for {
+			if _, ok := app.stats.Sessions().Get(service.SessionID); !ok {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		select {
+		case event := <-events:
+			assert.Assert(t, test.notified)
+			assert.Equal(t, webhook.SessionDeleted, event.Type)
+		case <-time.After(200 * time.Millisecond):
+			assert.Assert(t, !test.notified)
+		}
+		srv.Close()
+	}
+}
+
+func TestNotifySessionRequest(t *testing.T) {
+	tests := map[string]struct {
+		event     audit.Event
+		eventType webhook.EventType
+	}{
+		"Verify created session is announced": {
+			event:     audit.Event{Type: audit.SessionCreated, SessionID: "chrome-85-0-1", Browser: "chrome", Version: "85.0"},
+			eventType: webhook.SessionCreated,
+		},
+		"Verify failed session is announced": {
+			event:     audit.Event{Type: audit.SessionRejected, Browser: "chrome", Version: "85.0", Message: "failed to start browser: pod exited early"},
+			eventType: webhook.SessionFailed,
+		},
+		"Verify request for unknown browser is not announced": {
+			event: audit.Event{Type: audit.SessionRejected, Message: "unknown browser name amigo"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		events := make(chan webhook.Event, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var event webhook.Event
+			json.NewDecoder(r.Body).Decode(&event)
+			events <- event
+		}))
+
+		app := initApp(&PlatformMock{})
+		app.notifier = webhook.New(app.logger, webhook.Config{URLs: []string{srv.URL}})
+		app.notifySessionRequest(test.event)
+
+		select {
+		case event := <-events:
+			assert.Equal(t, test.eventType, event.Type)
+			assert.Equal(t, test.event.SessionID, event.SessionID)
+			assert.Equal(t, test.event.Message, event.Message)
+		case <-time.After(200 * time.Millisecond):
+			assert.Equal(t, webhook.EventType(""), test.eventType)
+		}
+		srv.Close()
+	}
+}
+
+func TestNotifyVideoUploaded(t *testing.T) {
+	events := make(chan webhook.Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event webhook.Event
+		json.NewDecoder(r.Body).Decode(&event)
+		events <- event
+	}))
+	defer srv.Close()
+
+	app := initApp(&PlatformMock{artifacts: map[string][]platform.Artifact{}})
+	app.notifier = webhook.New(app.logger, webhook.Config{URLs: []string{srv.URL}})
+	app.stats.Sessions().Put("chrome-85-0-1", platform.Service{SessionID: "chrome-85-0-1", Labels: map[string]string{"browserName": "chrome", "browserVersion": "85.0"}})
+
+	rr := httptest.NewRecorder()
+	app.HandleArtifact(rr, httptest.NewRequest(http.MethodPost, "/artifacts", strings.NewReader(`{"sessionId":"chrome-85-0-1","kind":"videos","url":"s3://bucket/1.mp4","size":10}`)))
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, webhook.VideoUploaded, event.Type)
+		assert.Equal(t, "chrome-85-0-1", event.SessionID)
+		assert.Equal(t, "chrome", event.Browser)
+		assert.Equal(t, "s3://bucket/1.mp4", event.URL)
+	case <-time.After(time.Second):
+		t.Fatal("event is not sent")
+	}
+}
@@ -0,0 +1,82 @@
+package selenosis
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+
+	"github.com/alcounit/selenosis/tools"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// HandleOpenAPI ...
+func (app *App) HandleOpenAPI(w http.ResponseWriter, _ *http.Request) {
+	var spec map[string]interface{}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		app.logger.Errorf("failed to parse openapi spec: %v", err)
+		tools.JSONError(w, "failed to parse openapi spec", http.StatusInternalServerError)
+		return
+	}
+
+	if info, ok := spec["info"].(map[string]interface{}); ok && app.buildVersion != "" {
+		info["version"] = app.buildVersion
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(spec)
+}
+
+// redocAssets holds Redoc release vendored by assets/vendor.sh, bundle is served from the binary so API explorer
+// runs only scripts of selenosis origin
+//go:embed assets/redoc
+var redocAssets embed.FS
+
+// redocFS is file system Redoc bundle is served from
+var redocFS fs.FS = redocAssets
+
+const redocBundle = "assets/redoc/redoc.standalone.js"
+
+const apiDocsPage = `<!DOCTYPE html>
+<html>
+  <head>
+    <title>selenosis API</title>
+    <meta charset="utf-8"/>
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+    <style>body { margin: 0; padding: 0; }</style>
+  </head>
+  <body>
+    <redoc spec-url="/openapi.json"></redoc>
+    <script src="/api-docs/redoc.standalone.js"></script>
+  </body>
+</html>
+`
+
+// apiDocsPolicy allows scripts of selenosis origin only, Redoc injects styles and runs search in blob worker
+const apiDocsPolicy = "default-src 'self'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; worker-src blob:"
+
+// HandleAPIDocs ...
+func (app *App) HandleAPIDocs(w http.ResponseWriter, _ *http.Request) {
+	if _, err := fs.Stat(redocFS, redocBundle); err != nil {
+		app.logger.Errorf("redoc bundle is not available: %v", err)
+		tools.JSONError(w, "API explorer is not available: Redoc bundle is not vendored into the binary", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", apiDocsPolicy)
+	w.Write([]byte(apiDocsPage))
+}
+
+// HandleRedoc serves Redoc bundle of API explorer
+func (app *App) HandleRedoc(w http.ResponseWriter, _ *http.Request) {
+	bundle, err := fs.ReadFile(redocFS, redocBundle)
+	if err != nil {
+		tools.JSONError(w, "Redoc bundle is not vendored into the binary", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/javascript")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Write(bundle)
+}
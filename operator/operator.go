@@ -0,0 +1,287 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alcounit/selenosis/config"
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/selenium"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+//SessionResource is a SelenosisSession custom resource
+var SessionResource = schema.GroupVersionResource{
+	Group:    "selenosis.io",
+	Version:  "v1alpha1",
+	Resource: "selenosissessions",
+}
+
+//Phase describes SelenosisSession status phase
+type Phase string
+
+const (
+	Pending Phase = "Pending"
+	Running Phase = "Running"
+	Failed  Phase = "Failed"
+)
+
+//SessionSpec describes SelenosisSession spec
+type SessionSpec struct {
+	BrowserName    string                 `json:"browserName"`
+	BrowserVersion string                 `json:"browserVersion,omitempty"`
+	Capabilities   map[string]interface{} `json:"capabilities,omitempty"`
+}
+
+//SessionStatus describes SelenosisSession status
+type SessionStatus struct {
+	Phase     Phase  `json:"phase,omitempty"`
+	SessionID string `json:"sessionId,omitempty"`
+	URL       string `json:"url,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+//Config ...
+type Config struct {
+	Namespace    string
+	ResyncPeriod time.Duration
+}
+
+//Operator reconciles SelenosisSession custom resources with browser pods
+type Operator struct {
+	logger   *log.Logger
+	client   platform.Platform
+	browsers *config.BrowsersConfig
+	dynamic  dynamic.Interface
+	ns       string
+	resync   time.Duration
+	inflight sync.Map
+}
+
+//NewDynamicClient returns in cluster dynamic client
+func NewDynamicClient() (dynamic.Interface, error) {
+	conf, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build cluster config: %v", err)
+	}
+
+	client, err := dynamic.NewForConfig(conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build dynamic client: %v", err)
+	}
+	return client, nil
+}
+
+//New ...
+func New(logger *log.Logger, client platform.Platform, browsers *config.BrowsersConfig, dynamic dynamic.Interface, cfg Config) *Operator {
+	return &Operator{
+		logger:   logger,
+		client:   client,
+		browsers: browsers,
+		dynamic:  dynamic,
+		ns:       cfg.Namespace,
+		resync:   cfg.ResyncPeriod,
+	}
+}
+
+//Run starts SelenosisSession informer and blocks until stop is closed
+func (o *Operator) Run(stop <-chan struct{}) {
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(o.dynamic, o.resync, o.ns, nil)
+	informer := factory.ForResource(SessionResource).Informer()
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if u, ok := obj.(*unstructured.Unstructured); ok {
+				go o.reconcile(u)
+			}
+		},
+		UpdateFunc: func(_ interface{}, obj interface{}) {
+			if u, ok := obj.(*unstructured.Unstructured); ok {
+				go o.reconcile(u)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if u, ok := obj.(*unstructured.Unstructured); ok {
+				o.teardown(u)
+			}
+		},
+	})
+
+	factory.Start(stop)
+	<-stop
+}
+
+func (o *Operator) reconcile(obj *unstructured.Unstructured) {
+	logger := o.logger.WithField("selenosis_session", obj.GetName())
+
+	status, err := getStatus(obj)
+	if err != nil {
+		logger.Errorf("failed to read status: %v", err)
+		return
+	}
+
+	if status.Phase != "" {
+		return
+	}
+
+	key := obj.GetNamespace() + "/" + obj.GetName()
+	if _, loaded := o.inflight.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+	defer o.inflight.Delete(key)
+
+	spec, err := getSpec(obj)
+	if err != nil {
+		o.setStatus(obj, SessionStatus{Phase: Failed, Message: err.Error()})
+		return
+	}
+
+	caps, err := spec.capabilities()
+	if err != nil {
+		o.setStatus(obj, SessionStatus{Phase: Failed, Message: err.Error()})
+		return
+	}
+
+	browser, err := o.browsers.FindOnPlatform(caps.GetBrowserName(), caps.BrowserVersion, caps.Platform)
+	if err == nil {
+		browser, err = browser.ForArch(caps.Architecture)
+	}
+	if err != nil {
+		o.setStatus(obj, SessionStatus{Phase: Failed, Message: err.Error()})
+		return
+	}
+
+	obj, err = o.setStatus(obj, SessionStatus{Phase: Pending})
+	if err != nil {
+		return
+	}
+
+	logger.Infof("starting browser from image: %s", browser.Image)
+
+	service, err := o.client.Service().Create(platform.ServiceSpec{
+		SessionID:             fmt.Sprintf("%s-%s", obj.GetName(), uuid.New()),
+		RequestedCapabilities: caps,
+		Template:              browser,
+	})
+	if err != nil {
+		logger.Errorf("failed to start browser: %v", err)
+		o.setStatus(obj, SessionStatus{Phase: Failed, Message: err.Error()})
+		return
+	}
+
+	if _, err = o.setStatus(obj, SessionStatus{Phase: Running, SessionID: service.SessionID, URL: service.URL.String()}); err != nil {
+		service.CancelFunc()
+		return
+	}
+
+	logger.Infof("browser sessionId: %s", service.SessionID)
+}
+
+func (o *Operator) teardown(obj *unstructured.Unstructured) {
+	status, err := getStatus(obj)
+	if err != nil || status.SessionID == "" {
+		return
+	}
+
+	if err := o.client.Service().Delete(status.SessionID); err != nil {
+		o.logger.WithField("selenosis_session", obj.GetName()).Errorf("failed to delete session %s: %v", status.SessionID, err)
+		return
+	}
+	o.logger.WithField("selenosis_session", obj.GetName()).Infof("session %s deleted", status.SessionID)
+}
+
+func (o *Operator) setStatus(obj *unstructured.Unstructured, status SessionStatus) (*unstructured.Unstructured, error) {
+	content, err := toUnstructured(status)
+	if err != nil {
+		return nil, err
+	}
+
+	obj = obj.DeepCopy()
+	if err := unstructured.SetNestedMap(obj.Object, content, "status"); err != nil {
+		return nil, err
+	}
+
+	result, err := o.dynamic.Resource(SessionResource).Namespace(obj.GetNamespace()).UpdateStatus(context.Background(), obj, metav1.UpdateOptions{})
+	if err != nil {
+		o.logger.WithField("selenosis_session", obj.GetName()).Errorf("failed to update status: %v", err)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s SessionSpec) capabilities() (selenium.Capabilities, error) {
+	var caps selenium.Capabilities
+	if s.Capabilities != nil {
+		b, err := json.Marshal(s.Capabilities)
+		if err != nil {
+			return caps, fmt.Errorf("invalid capabilities: %v", err)
+		}
+		if err := json.Unmarshal(b, &caps); err != nil {
+			return caps, fmt.Errorf("invalid capabilities: %v", err)
+		}
+	}
+
+	if s.BrowserName != "" {
+		caps.BrowserName = s.BrowserName
+	}
+	if s.BrowserVersion != "" {
+		caps.BrowserVersion = s.BrowserVersion
+	}
+	caps.ValidateCapabilities()
+	return caps, nil
+}
+
+func getSpec(obj *unstructured.Unstructured) (SessionSpec, error) {
+	var spec SessionSpec
+	content, ok, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil || !ok {
+		return spec, fmt.Errorf("spec not found")
+	}
+	err = fromUnstructured(content, &spec)
+	return spec, err
+}
+
+func getStatus(obj *unstructured.Unstructured) (SessionStatus, error) {
+	var status SessionStatus
+	content, ok, err := unstructured.NestedMap(obj.Object, "status")
+	if err != nil {
+		return status, err
+	}
+	if !ok {
+		return status, nil
+	}
+	err = fromUnstructured(content, &status)
+	return status, err
+}
+
+func toUnstructured(v interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	content := make(map[string]interface{})
+	err = json.Unmarshal(b, &content)
+	return content, err
+}
+
+func fromUnstructured(content map[string]interface{}, v interface{}) error {
+	b, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,155 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/alcounit/selenosis/config"
+	"github.com/alcounit/selenosis/platform"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestReconcile(t *testing.T) {
+	tests := map[string]struct {
+		spec   map[string]interface{}
+		err    error
+		status SessionStatus
+	}{
+		"Verify session created for known browser": {
+			spec: map[string]interface{}{
+				"browserName":    "chrome",
+				"browserVersion": "68.0",
+			},
+			status: SessionStatus{
+				Phase:     Running,
+				SessionID: "chrome-68-0-de44c3c4-1a35-412b-b526-f5da80214491",
+				URL:       "http://chrome-68-0-de44c3c4-1a35-412b-b526-f5da80214491.seleniferous:4445",
+			},
+		},
+		"Verify session failed for unknown browser": {
+			spec: map[string]interface{}{
+				"browserName": "amigo",
+			},
+			status: SessionStatus{
+				Phase:   Failed,
+				Message: "unknown browser name amigo",
+			},
+		},
+		"Verify session failed on platform error": {
+			spec: map[string]interface{}{
+				"capabilities": map[string]interface{}{
+					"browserName": "firefox",
+					"version":     "45.0",
+				},
+			},
+			err: errors.New("failed to create pod"),
+			status: SessionStatus{
+				Phase:   Failed,
+				Message: "failed to create pod",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("selenosis.io/v1alpha1")
+		obj.SetKind("SelenosisSession")
+		obj.SetNamespace("selenosis")
+		obj.SetName("debug")
+		obj.Object["spec"] = test.spec
+
+		dynamic := fake.NewSimpleDynamicClient(runtime.NewScheme(), obj)
+		browsers, err := config.NewBrowsersConfig("../config/browsers.yaml")
+		assert.NilError(t, err)
+
+		o := New(&logrus.Logger{}, &platformMock{err: test.err, sessionID: test.status.SessionID}, browsers, dynamic, Config{Namespace: "selenosis"})
+		o.reconcile(obj)
+
+		result, err := dynamic.Resource(SessionResource).Namespace("selenosis").Get(context.Background(), "debug", metav1.GetOptions{})
+		assert.NilError(t, err)
+
+		status, err := getStatus(result)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, test.status, status)
+	}
+}
+
+func TestTeardown(t *testing.T) {
+	obj := &unstructured.Unstructured{}
+	obj.SetName("debug")
+	obj.Object["status"] = map[string]interface{}{
+		"phase":     "Running",
+		"sessionId": "chrome-68-0-de44c3c4-1a35-412b-b526-f5da80214491",
+	}
+
+	client := &platformMock{}
+	o := New(&logrus.Logger{}, client, nil, nil, Config{})
+	o.teardown(obj)
+
+	assert.Equal(t, "chrome-68-0-de44c3c4-1a35-412b-b526-f5da80214491", client.deleted)
+}
+
+type platformMock struct {
+	err       error
+	sessionID string
+	deleted   string
+	state     platform.PlatformState
+}
+
+func (p *platformMock) Service() platform.ServiceInterface {
+	return &serviceMock{p}
+}
+
+func (p *platformMock) Quota() platform.QuotaInterface {
+	return nil
+}
+
+func (p *platformMock) Resources() platform.ResourceInterface {
+	return nil
+}
+
+func (p *platformMock) Artifacts() platform.ArtifactInterface {
+	return nil
+}
+
+func (p *platformMock) State() (platform.PlatformState, error) {
+	return p.state, nil
+}
+
+func (p *platformMock) Watch() <-chan platform.Event {
+	return make(chan platform.Event)
+}
+
+type serviceMock struct {
+	p *platformMock
+}
+
+func (s *serviceMock) Create(platform.ServiceSpec) (platform.Service, error) {
+	if s.p.err != nil {
+		return platform.Service{}, s.p.err
+	}
+	return platform.Service{
+		SessionID:  s.p.sessionID,
+		URL:        &url.URL{Scheme: "http", Host: s.p.sessionID + ".seleniferous:4445"},
+		CancelFunc: func() {},
+	}, nil
+}
+
+func (s *serviceMock) Delete(name string) error {
+	s.p.deleted = name
+	return nil
+}
+
+func (s *serviceMock) Logs(context.Context, string) (io.ReadCloser, error) {
+	return nil, nil
+}
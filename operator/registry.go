@@ -0,0 +1,223 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+//BrowserSessionResource is a BrowserSession custom resource recording browser session running on the platform
+var BrowserSessionResource = schema.GroupVersionResource{
+	Group:    "selenosis.io",
+	Version:  "v1alpha1",
+	Resource: "browsersessions",
+}
+
+//recordLabel marks BrowserSession resources managed by the registry
+const recordLabel = "selenosis.app.sessionRecord"
+
+//BrowserSessionSpec describes BrowserSession spec, capabilities are the ones session was requested with
+type BrowserSessionSpec struct {
+	SessionID      string            `json:"sessionId"`
+	BrowserName    string            `json:"browserName,omitempty"`
+	BrowserVersion string            `json:"browserVersion,omitempty"`
+	Owner          string            `json:"owner,omitempty"`
+	Tenant         string            `json:"tenant,omitempty"`
+	Capabilities   map[string]string `json:"capabilities,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	Relay          bool              `json:"relay,omitempty"`
+}
+
+//BrowserSessionStatus describes BrowserSession status, readyAt is time session was first seen running
+type BrowserSessionStatus struct {
+	Phase     platform.ServiceStatus `json:"phase,omitempty"`
+	URL       string                 `json:"url,omitempty"`
+	StartedAt string                 `json:"startedAt,omitempty"`
+	ReadyAt   string                 `json:"readyAt,omitempty"`
+}
+
+//RegistryConfig ...
+type RegistryConfig struct {
+	Namespace string
+	Interval  time.Duration
+}
+
+//Registry records sessions of the platform as BrowserSession custom resources, records are created
+//alongside browser pods, follow status of sessions and are deleted once sessions are gone. Records
+//outlive selenosis restarts and are reconciled with the platform on start
+type Registry struct {
+	logger   *log.Logger
+	client   platform.Platform
+	dynamic  dynamic.Interface
+	ns       string
+	interval time.Duration
+}
+
+//NewRegistry ...
+func NewRegistry(logger *log.Logger, client platform.Platform, dynamic dynamic.Interface, cfg RegistryConfig) *Registry {
+	return &Registry{
+		logger:   logger,
+		client:   client,
+		dynamic:  dynamic,
+		ns:       cfg.Namespace,
+		interval: cfg.Interval,
+	}
+}
+
+//Run syncs records with the platform every interval and blocks until stop is closed
+func (r *Registry) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if err := r.Sync(); err != nil {
+			r.logger.Errorf("failed to sync browser sessions: %v", err)
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+//Sync creates records of new sessions, updates status of changed ones and deletes records of sessions
+//no longer running, failure of a single record is logged and doesn't stop the others
+func (r *Registry) Sync() error {
+	state, err := r.client.State()
+	if err != nil {
+		return fmt.Errorf("failed to get platform state: %v", err)
+	}
+
+	ctx := context.Background()
+	resource := r.dynamic.Resource(BrowserSessionResource).Namespace(r.ns)
+	list, err := resource.List(ctx, metav1.ListOptions{LabelSelector: recordLabel})
+	if err != nil {
+		return fmt.Errorf("failed to list browser sessions: %v", err)
+	}
+	records := make(map[string]*unstructured.Unstructured, len(list.Items))
+	for i := range list.Items {
+		records[list.Items[i].GetName()] = &list.Items[i]
+	}
+
+	for _, service := range state.Services {
+		logger := r.logger.WithField("session_id", service.SessionID)
+		record, ok := records[service.SessionID]
+		delete(records, service.SessionID)
+
+		if !ok {
+			if err := r.create(service); err != nil {
+				logger.Errorf("failed to create browser session: %v", err)
+			}
+			continue
+		}
+
+		current, err := recordStatus(record)
+		if err != nil {
+			logger.Errorf("failed to read browser session status: %v", err)
+			continue
+		}
+		status := serviceRecordStatus(service, current)
+		if reflect.DeepEqual(current, status) {
+			continue
+		}
+		if err := r.setStatus(record, status); err != nil && !apierrors.IsConflict(err) {
+			logger.Errorf("failed to update browser session status: %v", err)
+		}
+	}
+
+	for name := range records {
+		if err := resource.Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			r.logger.WithField("session_id", name).Errorf("failed to delete browser session: %v", err)
+		}
+	}
+	return nil
+}
+
+//create creates record of the session, status is set separately as it is a subresource
+func (r *Registry) create(service platform.Service) error {
+	spec, err := toUnstructured(serviceRecordSpec(service))
+	if err != nil {
+		return err
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetAPIVersion(BrowserSessionResource.GroupVersion().String())
+	obj.SetKind("BrowserSession")
+	obj.SetName(service.SessionID)
+	obj.SetLabels(map[string]string{recordLabel: "true"})
+
+	created, err := r.dynamic.Resource(BrowserSessionResource).Namespace(r.ns).Create(context.Background(), obj, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return r.setStatus(created, serviceRecordStatus(service, BrowserSessionStatus{}))
+}
+
+func (r *Registry) setStatus(obj *unstructured.Unstructured, status BrowserSessionStatus) error {
+	content, err := toUnstructured(status)
+	if err != nil {
+		return err
+	}
+
+	obj = obj.DeepCopy()
+	if err := unstructured.SetNestedMap(obj.Object, content, "status"); err != nil {
+		return err
+	}
+	_, err = r.dynamic.Resource(BrowserSessionResource).Namespace(r.ns).UpdateStatus(context.Background(), obj, metav1.UpdateOptions{})
+	return err
+}
+
+func serviceRecordSpec(service platform.Service) BrowserSessionSpec {
+	return BrowserSessionSpec{
+		SessionID:      service.SessionID,
+		BrowserName:    service.Labels["browserName"],
+		BrowserVersion: service.Labels["browserVersion"],
+		Owner:          service.Labels["owner"],
+		Tenant:         service.Labels["tenant"],
+		Capabilities:   service.Labels,
+		Labels:         service.SessionLabels,
+		Relay:          service.Relay,
+	}
+}
+
+//serviceRecordStatus returns status of the session, time session became ready is kept from current status
+func serviceRecordStatus(service platform.Service, current BrowserSessionStatus) BrowserSessionStatus {
+	status := BrowserSessionStatus{
+		Phase:   service.Status,
+		ReadyAt: current.ReadyAt,
+	}
+	if service.URL != nil {
+		status.URL = service.URL.String()
+	}
+	if !service.Started.IsZero() {
+		status.StartedAt = service.Started.UTC().Format(time.RFC3339)
+	}
+	if status.ReadyAt == "" && service.Status == platform.Running {
+		status.ReadyAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	return status
+}
+
+func recordStatus(obj *unstructured.Unstructured) (BrowserSessionStatus, error) {
+	var status BrowserSessionStatus
+	content, ok, err := unstructured.NestedMap(obj.Object, "status")
+	if err != nil {
+		return status, err
+	}
+	if !ok {
+		return status, nil
+	}
+	err = fromUnstructured(content, &status)
+	return status, err
+}
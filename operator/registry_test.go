@@ -0,0 +1,137 @@
+package operator
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func browserSession(name string, status map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("selenosis.io/v1alpha1")
+	obj.SetKind("BrowserSession")
+	obj.SetNamespace("selenosis")
+	obj.SetName(name)
+	obj.SetLabels(map[string]string{recordLabel: "true"})
+	obj.Object["spec"] = map[string]interface{}{"sessionId": name}
+	if status != nil {
+		obj.Object["status"] = status
+	}
+	return obj
+}
+
+func TestRegistrySync(t *testing.T) {
+	started := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	service := func(id string, status platform.ServiceStatus) platform.Service {
+		return platform.Service{
+			SessionID: id,
+			URL:       &url.URL{Scheme: "http", Host: id + ".seleniferous:4445"},
+			Labels: map[string]string{
+				"browserName":    "chrome",
+				"browserVersion": "85.0",
+				"owner":          "ci",
+				"tenant":         "team-a",
+			},
+			SessionLabels: map[string]string{"build": "42"},
+			Status:        status,
+			Started:       started,
+		}
+	}
+
+	tests := map[string]struct {
+		records  []runtime.Object
+		services []platform.Service
+		verify   func(t *testing.T, records map[string]*unstructured.Unstructured)
+	}{
+		"Verify record is created for new session": {
+			services: []platform.Service{service("chrome-85-0-1", platform.Pending)},
+			verify: func(t *testing.T, records map[string]*unstructured.Unstructured) {
+				assert.Equal(t, 1, len(records))
+				record := records["chrome-85-0-1"]
+				assert.Equal(t, "true", record.GetLabels()[recordLabel])
+
+				spec, _, err := unstructured.NestedMap(record.Object, "spec")
+				assert.NilError(t, err)
+				var s BrowserSessionSpec
+				assert.NilError(t, fromUnstructured(spec, &s))
+				assert.DeepEqual(t, BrowserSessionSpec{
+					SessionID:      "chrome-85-0-1",
+					BrowserName:    "chrome",
+					BrowserVersion: "85.0",
+					Owner:          "ci",
+					Tenant:         "team-a",
+					Capabilities:   map[string]string{"browserName": "chrome", "browserVersion": "85.0", "owner": "ci", "tenant": "team-a"},
+					Labels:         map[string]string{"build": "42"},
+				}, s)
+
+				status, err := recordStatus(record)
+				assert.NilError(t, err)
+				assert.DeepEqual(t, BrowserSessionStatus{
+					Phase:     platform.Pending,
+					URL:       "http://chrome-85-0-1.seleniferous:4445",
+					StartedAt: "2020-10-01T12:00:00Z",
+				}, status)
+			},
+		},
+		"Verify record status follows running session": {
+			records:  []runtime.Object{browserSession("chrome-85-0-1", map[string]interface{}{"phase": "Pending"})},
+			services: []platform.Service{service("chrome-85-0-1", platform.Running)},
+			verify: func(t *testing.T, records map[string]*unstructured.Unstructured) {
+				status, err := recordStatus(records["chrome-85-0-1"])
+				assert.NilError(t, err)
+				assert.Equal(t, platform.Running, status.Phase)
+				assert.Equal(t, "2020-10-01T12:00:00Z", status.StartedAt)
+				assert.Assert(t, status.ReadyAt != "")
+			},
+		},
+		"Verify time session became ready is kept": {
+			records: []runtime.Object{browserSession("chrome-85-0-1", map[string]interface{}{
+				"phase":     "Running",
+				"url":       "http://chrome-85-0-1.seleniferous:4445",
+				"startedAt": "2020-10-01T12:00:00Z",
+				"readyAt":   "2020-10-01T12:00:05Z",
+			})},
+			services: []platform.Service{service("chrome-85-0-1", platform.Running)},
+			verify: func(t *testing.T, records map[string]*unstructured.Unstructured) {
+				status, err := recordStatus(records["chrome-85-0-1"])
+				assert.NilError(t, err)
+				assert.Equal(t, "2020-10-01T12:00:05Z", status.ReadyAt)
+			},
+		},
+		"Verify record of finished session is deleted": {
+			records:  []runtime.Object{browserSession("chrome-85-0-1", nil), browserSession("chrome-85-0-2", nil)},
+			services: []platform.Service{service("chrome-85-0-2", platform.Running)},
+			verify: func(t *testing.T, records map[string]*unstructured.Unstructured) {
+				assert.Equal(t, 1, len(records))
+				_, ok := records["chrome-85-0-2"]
+				assert.Assert(t, ok)
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		dynamic := fake.NewSimpleDynamicClient(runtime.NewScheme(), test.records...)
+		client := &platformMock{state: platform.PlatformState{Services: test.services}}
+		registry := NewRegistry(&logrus.Logger{}, client, dynamic, RegistryConfig{Namespace: "selenosis"})
+		assert.NilError(t, registry.Sync())
+
+		list, err := dynamic.Resource(BrowserSessionResource).Namespace("selenosis").List(context.Background(), metav1.ListOptions{})
+		assert.NilError(t, err)
+		records := make(map[string]*unstructured.Unstructured)
+		for i := range list.Items {
+			records[list.Items[i].GetName()] = &list.Items[i]
+		}
+		test.verify(t, records)
+	}
+}
@@ -0,0 +1,25 @@
+package selenosis
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alcounit/selenosis/metrics"
+	"github.com/alcounit/selenosis/platform"
+)
+
+//observePhases records durations of session startup phases
+func observePhases(phases []platform.Phase) {
+	for _, p := range phases {
+		metrics.SessionPhaseDuration.WithLabelValues(p.Name).Observe(p.Duration.Seconds())
+	}
+}
+
+//serverTiming formats session startup phases as Server-Timing header value, durations are in milliseconds
+func serverTiming(phases []platform.Phase) string {
+	values := make([]string, 0, len(phases))
+	for _, p := range phases {
+		values = append(values, fmt.Sprintf("%s;dur=%.1f", p.Name, float64(p.Duration.Microseconds())/1000))
+	}
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,51 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+//activeAnnotation keeps time of the last activity of the session recorded by any selenosis replica
+const activeAnnotation = "selenosis.app.activeAt"
+
+//ErrActivityNotSupported is returned by platforms not able to record activity of sessions
+var ErrActivityNotSupported = errors.New("session activity is not supported by the platform")
+
+//ActivityRecorder is implemented by platforms able to record activity of sessions on the browser, so idle reaper
+//of every replica sees sessions used through other replicas
+type ActivityRecorder interface {
+	RecordActivity(sessionID string, at time.Time) error
+}
+
+//RecordActivity records time of the last activity of the session in annotations of browser pod
+func (cl *Client) RecordActivity(sessionID string, at time.Time) error {
+	s, ok := cl.service.(*service)
+	if !ok {
+		return ErrActivityNotSupported
+	}
+
+	patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, activeAnnotation, at.UTC().Format(time.RFC3339)))
+	_, err := s.clientset.CoreV1().Pods(s.ns).Patch(context.Background(), s.podName(sessionID), types.MergePatchType, patch, metav1.PatchOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return ErrSessionNotFound
+	case err != nil:
+		return fmt.Errorf("failed to record session activity: %v", err)
+	}
+	return nil
+}
+
+//activeAt returns time of the last recorded activity of the session from pod annotations
+func activeAt(annotations map[string]string) time.Time {
+	t, err := time.Parse(time.RFC3339, annotations[activeAnnotation])
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
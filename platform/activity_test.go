@@ -0,0 +1,47 @@
+package platform
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRecordActivity(t *testing.T) {
+	tests := map[string]struct {
+		pod *apiv1.Pod
+		err string
+	}{
+		"Verify activity is recorded on browser pod": {
+			pod: &apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "chrome-85-0", Namespace: "selenosis"}},
+		},
+		"Verify activity of unknown pod returns session not found": {
+			pod: &apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "firefox-82-0", Namespace: "selenosis"}},
+			err: "session not found",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		mock := fake.NewSimpleClientset(test.pod)
+		client := &Client{service: &service{ns: "selenosis", clientset: mock}}
+
+		at := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+		err := client.RecordActivity("chrome-85-0", at)
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+
+		pod, err := mock.CoreV1().Pods("selenosis").Get(context.Background(), "chrome-85-0", metav1.GetOptions{})
+		assert.NilError(t, err)
+		service := (&Client{}).podService(pod, Running)
+		assert.Equal(t, at, service.Active)
+	}
+}
@@ -0,0 +1,27 @@
+package platform
+
+import (
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+//AppiumType is type of browsers served by Appium server, e.g. Android emulators running mobile browsers
+const AppiumType = "appium"
+
+//appiumPort is port Appium server listens on
+var appiumPort = intstr.FromString("4723")
+
+//browserPort returns port WebDriver server of the browser listens on
+func browserPort(template BrowserSpec) intstr.IntOrString {
+	if template.Type == AppiumType {
+		return appiumPort
+	}
+	return browserPorts.selenium
+}
+
+//browserPortArgs returns proxy arguments pointing it to Appium server, other browsers use proxy default port
+func browserPortArgs(template BrowserSpec) []string {
+	if template.Type != AppiumType {
+		return nil
+	}
+	return []string{"--browser-port", appiumPort.StrVal}
+}
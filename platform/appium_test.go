@@ -0,0 +1,69 @@
+package platform
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestBuildAppiumPod(t *testing.T) {
+	tests := map[string]struct {
+		template BrowserSpec
+		ports    []apiv1.ContainerPort
+		args     []string
+	}{
+		"Verify browser pod exposes selenium port": {
+			template: BrowserSpec{Image: "selenoid/vnc:chrome_85.0"},
+			ports:    []apiv1.ContainerPort{{Name: "vnc", ContainerPort: 5900}, {Name: "selenium", ContainerPort: 4444}},
+		},
+		"Verify appium pod exposes appium port": {
+			template: BrowserSpec{Image: "budtmo/docker-android-x86-11.0", Type: AppiumType},
+			ports:    []apiv1.ContainerPort{{Name: "vnc", ContainerPort: 5900}, {Name: "appium", ContainerPort: 4723}},
+			args:     []string{"--browser-port", "4723"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		cl := &service{
+			svcPort:    intstr.FromString("4445"),
+			proxyImage: "alcounit/seleniferous:latest",
+		}
+
+		pod, err := cl.buildPod(ServiceSpec{SessionID: "session", Template: test.template})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, test.ports, pod.Spec.Containers[0].Ports)
+		proxy := pod.Spec.Containers[1]
+		assert.DeepEqual(t, test.args, append([]string(nil), proxy.Command[9:]...))
+	}
+}
+
+func TestWaitForAppium(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/wd/hub/status", r.URL.Path)
+		if atomic.AddInt32(&requests, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"value":{"ready":true}}`))
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	template := BrowserSpec{Type: AppiumType, Path: "/wd/hub"}
+
+	assert.NilError(t, waitForBrowser(*u, template, time.Second, nil))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+	atomic.StoreInt32(&requests, -100)
+	assert.Error(t, waitForBrowser(*u, template, 200*time.Millisecond, nil), "no responce after 200ms")
+}
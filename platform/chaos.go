@@ -0,0 +1,182 @@
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+//ErrChaos is returned for failures injected by chaos mode
+var ErrChaos = errors.New("chaos: injected failure")
+
+//ChaosConfig sets probabilities of injected failures, each rate is a value between 0 and 1
+type ChaosConfig struct {
+	CreateErrorRate float64
+	WatchDelayRate  float64
+	WatchDelay      time.Duration
+	DeleteRate      float64
+	DeleteAfter     time.Duration
+}
+
+//Validate ...
+func (c ChaosConfig) Validate() error {
+	for name, rate := range map[string]float64{
+		"create error": c.CreateErrorRate,
+		"watch delay":  c.WatchDelayRate,
+		"delete":       c.DeleteRate,
+	} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s rate should be between 0 and 1", name)
+		}
+	}
+	if c.WatchDelay < 0 || c.DeleteAfter < 0 {
+		return fmt.Errorf("durations should not be negative")
+	}
+	return nil
+}
+
+//Chaos wraps platform and injects failures: session create errors, delayed watch events
+//and deletion of browser pods shortly after they started
+type Chaos struct {
+	Platform
+	cfg ChaosConfig
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+//NewChaos ...
+func NewChaos(p Platform, cfg ChaosConfig) *Chaos {
+	return &Chaos{
+		Platform: p,
+		cfg:      cfg,
+		rnd:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+//hit reports if failure with the rate should be injected
+func (c *Chaos) hit(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rnd.Float64() < rate
+}
+
+func (c *Chaos) duration(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Duration(c.rnd.Int63n(int64(max)))
+}
+
+//Service ...
+func (c *Chaos) Service() ServiceInterface {
+	return &chaosService{
+		ServiceInterface: c.Platform.Service(),
+		c:                c,
+	}
+}
+
+//Watch delays events with configured rate, events are kept in order so later events wait as well
+func (c *Chaos) Watch() <-chan Event {
+	ch := make(chan Event)
+	go func() {
+		for event := range c.Platform.Watch() {
+			if c.hit(c.cfg.WatchDelayRate) {
+				time.Sleep(c.cfg.WatchDelay)
+			}
+			ch <- event
+		}
+		close(ch)
+	}()
+	return ch
+}
+
+//CacheSize ...
+func (c *Chaos) CacheSize() map[string]int {
+	if cs, ok := c.Platform.(CacheSizer); ok {
+		return cs.CacheSize()
+	}
+	return nil
+}
+
+//FillWarmPool ...
+func (c *Chaos) FillWarmPool(templates []BrowserSpec, capacity int) error {
+	if wp, ok := c.Platform.(WarmPooler); ok {
+		return wp.FillWarmPool(templates, capacity)
+	}
+	return nil
+}
+
+//WarmupImages ...
+func (c *Chaos) WarmupImages(templates []BrowserSpec) ([]ImageWarmup, error) {
+	if ip, ok := c.Platform.(ImagePuller); ok {
+		return ip.WarmupImages(templates)
+	}
+	return nil, ErrImageWarmupNotSupported
+}
+
+//ImageWarmups ...
+func (c *Chaos) ImageWarmups() ([]ImageWarmup, error) {
+	if ip, ok := c.Platform.(ImagePuller); ok {
+		return ip.ImageWarmups()
+	}
+	return nil, ErrImageWarmupNotSupported
+}
+
+//Debug ...
+func (c *Chaos) Debug(sessionID string, spec DebugSpec) (DebugContainer, error) {
+	if d, ok := c.Platform.(Debugger); ok {
+		return d.Debug(sessionID, spec)
+	}
+	return DebugContainer{}, ErrDebugNotSupported
+}
+
+//Extend ...
+func (c *Chaos) Extend(sessionID string, max int) (SessionExtension, error) {
+	if e, ok := c.Platform.(Extender); ok {
+		return e.Extend(sessionID, max)
+	}
+	return SessionExtension{}, ErrExtendNotSupported
+}
+
+//ListSessions ...
+func (c *Chaos) ListSessions(opts ListOptions) (SessionList, error) {
+	return ListSessions(c.Platform, opts)
+}
+
+//SetTenantLimit ...
+func (c *Chaos) SetTenantLimit(name string, limit int64) error {
+	if tl, ok := c.Platform.(TenantLimiter); ok {
+		return tl.SetTenantLimit(name, limit)
+	}
+	return fmt.Errorf("tenants are not configured")
+}
+
+type chaosService struct {
+	ServiceInterface
+	c *Chaos
+}
+
+//Create fails with configured rate, created session is deleted within DeleteAfter with configured rate
+func (s *chaosService) Create(spec ServiceSpec) (Service, error) {
+	if s.c.hit(s.c.cfg.CreateErrorRate) {
+		return Service{}, ErrChaos
+	}
+	service, err := s.ServiceInterface.Create(spec)
+	if err != nil {
+		return service, err
+	}
+	if s.c.hit(s.c.cfg.DeleteRate) {
+		time.AfterFunc(s.c.duration(s.c.cfg.DeleteAfter), func() {
+			s.ServiceInterface.Delete(service.SessionID)
+		})
+	}
+	return service, nil
+}
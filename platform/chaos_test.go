@@ -0,0 +1,140 @@
+package platform
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+type platformStub struct {
+	events  chan Event
+	deleted chan string
+}
+
+func (p *platformStub) Service() ServiceInterface {
+	return &serviceStub{p}
+}
+
+func (p *platformStub) Quota() QuotaInterface {
+	return nil
+}
+
+func (p *platformStub) Resources() ResourceInterface {
+	return nil
+}
+
+func (p *platformStub) Artifacts() ArtifactInterface {
+	return nil
+}
+
+func (p *platformStub) State() (PlatformState, error) {
+	return PlatformState{}, nil
+}
+
+func (p *platformStub) Watch() <-chan Event {
+	return p.events
+}
+
+type serviceStub struct {
+	p *platformStub
+}
+
+func (s *serviceStub) Create(spec ServiceSpec) (Service, error) {
+	return Service{SessionID: spec.SessionID}, nil
+}
+
+func (s *serviceStub) Delete(name string) error {
+	s.p.deleted <- name
+	return nil
+}
+
+func (s *serviceStub) Logs(context.Context, string) (io.ReadCloser, error) {
+	return nil, nil
+}
+
+func TestChaosCreate(t *testing.T) {
+	tests := map[string]struct {
+		cfg     ChaosConfig
+		err     error
+		deleted bool
+	}{
+		"Verify session created when chaos rates are zero": {},
+		"Verify create failure injected": {
+			cfg: ChaosConfig{CreateErrorRate: 1},
+			err: ErrChaos,
+		},
+		"Verify created session deleted prematurely": {
+			cfg:     ChaosConfig{DeleteRate: 1, DeleteAfter: 10 * time.Millisecond},
+			deleted: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		stub := &platformStub{deleted: make(chan string, 1)}
+		chaos := NewChaos(stub, test.cfg)
+
+		service, err := chaos.Service().Create(ServiceSpec{SessionID: "chrome-85-0-1"})
+		if test.err != nil {
+			assert.Equal(t, test.err, err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, "chrome-85-0-1", service.SessionID)
+
+		select {
+		case name := <-stub.deleted:
+			assert.Assert(t, test.deleted)
+			assert.Equal(t, "chrome-85-0-1", name)
+		case <-time.After(50 * time.Millisecond):
+			assert.Assert(t, !test.deleted)
+		}
+	}
+}
+
+func TestChaosWatch(t *testing.T) {
+	stub := &platformStub{events: make(chan Event, 2)}
+	chaos := NewChaos(stub, ChaosConfig{WatchDelayRate: 1, WatchDelay: 20 * time.Millisecond})
+
+	stub.events <- Event{Type: Added, PlatformObject: Service{SessionID: "chrome-85-0-1"}}
+	stub.events <- Event{Type: Deleted, PlatformObject: Service{SessionID: "chrome-85-0-1"}}
+	close(stub.events)
+
+	start := time.Now()
+	var types []EventType
+	for event := range chaos.Watch() {
+		types = append(types, event.Type)
+	}
+	assert.DeepEqual(t, []EventType{Added, Deleted}, types)
+	assert.Assert(t, time.Since(start) >= 40*time.Millisecond)
+}
+
+func TestChaosConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		cfg ChaosConfig
+		err string
+	}{
+		"Verify valid config": {
+			cfg: ChaosConfig{CreateErrorRate: 0.1, DeleteRate: 1},
+		},
+		"Verify rate above one is not allowed": {
+			cfg: ChaosConfig{CreateErrorRate: 1.5},
+			err: "create error rate should be between 0 and 1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		err := test.cfg.Validate()
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+	}
+}
@@ -0,0 +1,111 @@
+package platform
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/alcounit/selenosis/selenium"
+)
+
+const (
+	//BrowserStackProvider relays sessions to BrowserStack
+	BrowserStackProvider = "browserstack"
+	//SauceLabsProvider relays sessions to Sauce Labs
+	SauceLabsProvider = "sauce"
+)
+
+//cloudProvider describes how sessions are requested from cloud grid
+type cloudProvider struct {
+	endpoint, options, username, name, build string
+}
+
+var cloudProviders = map[string]cloudProvider{
+	BrowserStackProvider: {
+		endpoint: "https://hub-cloud.browserstack.com/wd/hub",
+		options:  "bstack:options",
+		username: "userName",
+		name:     "sessionName",
+		build:    "buildName",
+	},
+	SauceLabsProvider: {
+		endpoint: "https://ondemand.us-west-1.saucelabs.com/wd/hub",
+		options:  "sauce:options",
+		username: "username",
+		name:     "name",
+		build:    "build",
+	},
+}
+
+//CloudSpec describes cloud grid relayed sessions are created in, credentials secret should have username and accessKey keys,
+//capabilities are sent to cloud grid as is and override capabilities translated from the request
+type CloudSpec struct {
+	Provider          string                 `yaml:"provider" json:"provider"`
+	CredentialsSecret string                 `yaml:"credentialsSecret" json:"credentialsSecret"`
+	Capabilities      map[string]interface{} `yaml:"capabilities,omitempty" json:"capabilities,omitempty"`
+}
+
+//Validate ...
+func (c CloudSpec) Validate() error {
+	if _, ok := cloudProviders[c.Provider]; !ok {
+		return fmt.Errorf("unknown cloud provider %s", c.Provider)
+	}
+	if c.CredentialsSecret == "" {
+		return fmt.Errorf("credentials secret of %s is not set", c.Provider)
+	}
+	return nil
+}
+
+//Endpoint returns WebDriver endpoint of the provider
+func (c CloudSpec) Endpoint() string {
+	return cloudProviders[c.Provider].endpoint
+}
+
+//Payload returns new session request of cloud grid, browser of the spec is requested with
+//test name, run id and credentials put to provider options
+func (c CloudSpec) Payload(browser BrowserSpec, caps selenium.Capabilities, user *url.Userinfo) ([]byte, error) {
+	provider, ok := cloudProviders[c.Provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown cloud provider %s", c.Provider)
+	}
+
+	alwaysMatch := map[string]interface{}{
+		"browserName":    browser.BrowserName,
+		"browserVersion": browser.BrowserVersion,
+	}
+	if caps.Platform != "" {
+		alwaysMatch["platformName"] = caps.Platform
+	}
+
+	options := make(map[string]interface{})
+	for k, v := range c.Capabilities {
+		if k == provider.options {
+			if o, ok := v.(map[string]interface{}); ok {
+				for k, v := range o {
+					options[k] = v
+				}
+			}
+			continue
+		}
+		alwaysMatch[k] = v
+	}
+	if caps.TestName != "" {
+		options[provider.name] = caps.TestName
+	}
+	if caps.RunID != "" {
+		options[provider.build] = caps.RunID
+	}
+	if user != nil {
+		options[provider.username] = user.Username()
+		if key, ok := user.Password(); ok {
+			options["accessKey"] = key
+		}
+	}
+	alwaysMatch[provider.options] = options
+
+	return json.Marshal(map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"alwaysMatch": alwaysMatch,
+		},
+	})
+}
@@ -0,0 +1,58 @@
+package platform
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/alcounit/selenosis/selenium"
+	"gotest.tools/assert"
+)
+
+func TestCloudPayload(t *testing.T) {
+	tests := map[string]struct {
+		cloud   CloudSpec
+		caps    selenium.Capabilities
+		user    *url.Userinfo
+		payload string
+	}{
+		"Verify browserstack credentials and test name are put to options": {
+			cloud: CloudSpec{
+				Provider: BrowserStackProvider,
+				Capabilities: map[string]interface{}{
+					"bstack:options": map[string]interface{}{"os": "OS X", "osVersion": "Big Sur"},
+				},
+			},
+			caps:    selenium.Capabilities{TestName: "login", RunID: "nightly-42"},
+			user:    url.UserPassword("acme", "secret"),
+			payload: `{"capabilities":{"alwaysMatch":{"bstack:options":{"accessKey":"secret","buildName":"nightly-42","os":"OS X","osVersion":"Big Sur","sessionName":"login","userName":"acme"},"browserName":"safari","browserVersion":"14.0"}}}`,
+		},
+		"Verify sauce capabilities override translated ones": {
+			cloud: CloudSpec{
+				Provider:     SauceLabsProvider,
+				Capabilities: map[string]interface{}{"browserVersion": "latest", "platformName": "macOS 11.00"},
+			},
+			caps:    selenium.Capabilities{Platform: "mac", TestName: "login"},
+			user:    url.UserPassword("acme", "secret"),
+			payload: `{"capabilities":{"alwaysMatch":{"browserName":"safari","browserVersion":"latest","platformName":"macOS 11.00","sauce:options":{"accessKey":"secret","name":"login","username":"acme"}}}}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		payload, err := test.cloud.Payload(BrowserSpec{BrowserName: "safari", BrowserVersion: "14.0"}, test.caps, test.user)
+		assert.NilError(t, err)
+
+		var got, expected interface{}
+		assert.NilError(t, json.Unmarshal(payload, &got))
+		assert.NilError(t, json.Unmarshal([]byte(test.payload), &expected))
+		assert.DeepEqual(t, expected, got)
+	}
+}
+
+func TestCloudValidate(t *testing.T) {
+	assert.NilError(t, CloudSpec{Provider: SauceLabsProvider, CredentialsSecret: "sauce"}.Validate())
+	assert.Error(t, CloudSpec{Provider: "lambdatest", CredentialsSecret: "lt"}.Validate(), "unknown cloud provider lambdatest")
+	assert.Error(t, CloudSpec{Provider: BrowserStackProvider}.Validate(), "credentials secret of browserstack is not set")
+}
@@ -0,0 +1,98 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	//ErrDebugNotSupported is returned by platforms not able to attach containers to browsers
+	ErrDebugNotSupported = errors.New("debug containers are not supported by the platform")
+	//ErrSessionNotFound is returned when session has no browser pod
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+//DebugSpec describes ephemeral container attached to browser pod of the session
+type DebugSpec struct {
+	Image   string   `json:"image,omitempty"`
+	Command []string `json:"command,omitempty"`
+}
+
+//DebugContainer is ephemeral container attached to browser pod, it shares process namespace of the
+//browser container and keeps stdin open to be attached to
+type DebugContainer struct {
+	Name      string `json:"name"`
+	Image     string `json:"image"`
+	Pod       string `json:"pod"`
+	Namespace string `json:"namespace"`
+	Attach    string `json:"attach"`
+}
+
+//Debugger is implemented by platforms able to attach debug containers to running browsers
+type Debugger interface {
+	Debug(sessionID string, spec DebugSpec) (DebugContainer, error)
+}
+
+//Debug attaches ephemeral container to browser pod of the session with ephemeralcontainers subresource,
+//the container stays in the pod until the pod is deleted
+func (cl *Client) Debug(sessionID string, spec DebugSpec) (DebugContainer, error) {
+	s, ok := cl.service.(*service)
+	if !ok {
+		return DebugContainer{}, ErrDebugNotSupported
+	}
+	return s.debug(sessionID, spec)
+}
+
+func (cl *service) debug(sessionID string, spec DebugSpec) (DebugContainer, error) {
+	ctx := context.Background()
+	pods := cl.clientset.CoreV1().Pods(cl.ns)
+
+	podName := cl.podName(sessionID)
+	pod, err := pods.Get(ctx, podName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return DebugContainer{}, ErrSessionNotFound
+	}
+	if err != nil {
+		return DebugContainer{}, fmt.Errorf("failed to get pod: %v", err)
+	}
+	if pod.Spec.NodeSelector[osLabel] == WindowsPlatform {
+		return DebugContainer{}, errors.New("debug containers are not supported for windows browsers")
+	}
+	if pod.Status.Phase != apiv1.PodRunning {
+		return DebugContainer{}, fmt.Errorf("pod is %s, debug container needs running pod", pod.Status.Phase)
+	}
+
+	ephemeral, err := pods.GetEphemeralContainers(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return DebugContainer{}, fmt.Errorf("failed to get ephemeral containers: %v", err)
+	}
+	name := fmt.Sprintf("debug-%d", len(ephemeral.EphemeralContainers)+1)
+	ephemeral.EphemeralContainers = append(ephemeral.EphemeralContainers, apiv1.EphemeralContainer{
+		EphemeralContainerCommon: apiv1.EphemeralContainerCommon{
+			Name:                     name,
+			Image:                    spec.Image,
+			Command:                  spec.Command,
+			ImagePullPolicy:          apiv1.PullIfNotPresent,
+			Stdin:                    true,
+			TTY:                      true,
+			TerminationMessagePolicy: apiv1.TerminationMessageReadFile,
+		},
+		TargetContainerName: "browser",
+	})
+	if _, err := pods.UpdateEphemeralContainers(ctx, podName, ephemeral, metav1.UpdateOptions{}); err != nil {
+		return DebugContainer{}, fmt.Errorf("failed to add debug container: %v", err)
+	}
+
+	return DebugContainer{
+		Name:      name,
+		Image:     spec.Image,
+		Pod:       podName,
+		Namespace: cl.ns,
+		Attach:    fmt.Sprintf("kubectl attach -it -n %s %s -c %s", cl.ns, podName, name),
+	}, nil
+}
@@ -0,0 +1,94 @@
+package platform
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestDebug(t *testing.T) {
+	tests := map[string]struct {
+		pod       *apiv1.Pod
+		ephemeral []apiv1.EphemeralContainer
+		container DebugContainer
+		err       string
+	}{
+		"Verify debug container is attached to running browser pod": {
+			pod: &apiv1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "chrome-85-0", Namespace: "selenosis"},
+				Status:     apiv1.PodStatus{Phase: apiv1.PodRunning},
+			},
+			ephemeral: []apiv1.EphemeralContainer{{EphemeralContainerCommon: apiv1.EphemeralContainerCommon{Name: "debug-1"}}},
+			container: DebugContainer{
+				Name:      "debug-2",
+				Image:     "nicolaka/netshoot:latest",
+				Pod:       "chrome-85-0",
+				Namespace: "selenosis",
+				Attach:    "kubectl attach -it -n selenosis chrome-85-0 -c debug-2",
+			},
+		},
+		"Verify debug container is not attached to pending pod": {
+			pod: &apiv1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "chrome-85-0", Namespace: "selenosis"},
+				Status:     apiv1.PodStatus{Phase: apiv1.PodPending},
+			},
+			err: "pod is Pending, debug container needs running pod",
+		},
+		"Verify debug container is not attached to windows pod": {
+			pod: &apiv1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "chrome-85-0", Namespace: "selenosis"},
+				Spec:       apiv1.PodSpec{NodeSelector: map[string]string{osLabel: WindowsPlatform}},
+				Status:     apiv1.PodStatus{Phase: apiv1.PodRunning},
+			},
+			err: "debug containers are not supported for windows browsers",
+		},
+		"Verify debug of unknown pod returns session not found": {
+			pod: &apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "firefox-82-0", Namespace: "selenosis"}},
+			err: "session not found",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		mock := fake.NewSimpleClientset(test.pod)
+		var updated *apiv1.EphemeralContainers
+		mock.PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if action.GetSubresource() != "ephemeralcontainers" {
+				return false, nil, nil
+			}
+			return true, &apiv1.EphemeralContainers{EphemeralContainers: test.ephemeral}, nil
+		})
+		mock.PrependReactor("update", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if action.GetSubresource() != "ephemeralcontainers" {
+				return false, nil, nil
+			}
+			updated = action.(k8stesting.UpdateAction).GetObject().(*apiv1.EphemeralContainers)
+			return true, updated, nil
+		})
+
+		client := &Client{
+			ns:        "selenosis",
+			clientset: mock,
+			service:   &service{ns: "selenosis", clientset: mock},
+		}
+		container, err := client.Debug("chrome-85-0", DebugSpec{Image: "nicolaka/netshoot:latest"})
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.DeepEqual(t, test.container, container)
+
+		last := updated.EphemeralContainers[len(updated.EphemeralContainers)-1]
+		assert.Equal(t, 2, len(updated.EphemeralContainers))
+		assert.Equal(t, "browser", last.TargetContainerName)
+		assert.Equal(t, "nicolaka/netshoot:latest", last.Image)
+		assert.Assert(t, last.Stdin && last.TTY)
+	}
+}
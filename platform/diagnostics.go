@@ -0,0 +1,95 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/fields"
+)
+
+//imagePullReasons are waiting reasons of containers whose image can't be pulled, image is reported instead of message
+var imagePullReasons = map[string]bool{
+	"ErrImagePull":     true,
+	"ImagePullBackOff": true,
+	"InvalidImageName": true,
+}
+
+//startupDiagnostics returns why pod didn't become ready, pod and its events are read from the API, so
+//diagnostics should be gathered before the pod is deleted. Empty string is returned if pod is gone,
+//warning events of session job are returned if job has not created its pod
+func (cl *service) startupDiagnostics(name string) string {
+	ctx := context.Background()
+	var events []apiv1.Event
+	list, err := cl.clientset.CoreV1().Events(cl.ns).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("involvedObject.name", name).String(),
+	})
+	if err == nil {
+		events = list.Items
+	}
+	pod, err := cl.clientset.CoreV1().Pods(cl.ns).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		//job which failed to create pod, e.g. because of quota, has warning events itself
+		if reason, message, ok := lastWarning(events); ok && cl.jobs != nil {
+			return fmt.Sprintf("%s: %s", reason, message)
+		}
+		return ""
+	}
+	return podDiagnostics(pod, events)
+}
+
+//podDiagnostics describes scheduler condition, waiting or terminated containers and the last warning
+//event of the pod, e.g. "browser: ImagePullBackOff: selenoid/vnc:chrome_999"
+func podDiagnostics(pod *apiv1.Pod, events []apiv1.Event) string {
+	var details []string
+	reasons := make(map[string]bool)
+	schedulerMessage := ""
+	if reason, message, ok := unschedulable(pod); ok {
+		details = append(details, fmt.Sprintf("%s: %s", reason, message))
+		schedulerMessage = message
+	}
+
+	statuses := append(append([]apiv1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, s := range statuses {
+		switch {
+		case s.State.Waiting != nil && s.State.Waiting.Reason != "":
+			reason, message := s.State.Waiting.Reason, s.State.Waiting.Message
+			if imagePullReasons[reason] {
+				message = s.Image
+			}
+			detail := s.Name + ": " + reason
+			if message != "" {
+				detail += ": " + message
+			}
+			if last := s.LastTerminationState.Terminated; last != nil {
+				detail += ", last terminated " + terminated(last)
+			}
+			details = append(details, detail)
+			reasons[reason] = true
+		case s.State.Terminated != nil:
+			details = append(details, fmt.Sprintf("%s: %s", s.Name, terminated(s.State.Terminated)))
+			reasons[s.State.Terminated.Reason] = true
+		}
+	}
+
+	//warning event repeating scheduler condition or container state is skipped
+	if reason, message, ok := lastWarning(events); ok && !reasons[reason] && message != schedulerMessage {
+		details = append(details, fmt.Sprintf("%s: %s", reason, message))
+	}
+	return strings.Join(details, "; ")
+}
+
+//terminated describes terminated state of container
+func terminated(state *apiv1.ContainerStateTerminated) string {
+	reason := state.Reason
+	if reason == "" {
+		reason = "Terminated"
+	}
+	description := fmt.Sprintf("%s with exit code %d", reason, state.ExitCode)
+	if message := strings.TrimSpace(state.Message); message != "" {
+		description += ": " + message
+	}
+	return description
+}
@@ -0,0 +1,69 @@
+package platform
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodDiagnostics(t *testing.T) {
+	now := time.Now()
+
+	tests := map[string]struct {
+		status      apiv1.PodStatus
+		events      []apiv1.Event
+		diagnostics string
+	}{
+		"Verify nothing is reported for pod without problems": {
+			status: apiv1.PodStatus{Phase: apiv1.PodPending},
+		},
+		"Verify scheduler condition is reported once": {
+			status: apiv1.PodStatus{
+				Phase: apiv1.PodPending,
+				Conditions: []apiv1.PodCondition{
+					{Type: apiv1.PodScheduled, Status: apiv1.ConditionFalse, Reason: apiv1.PodReasonUnschedulable, Message: "0/3 nodes are available: 3 Insufficient cpu."},
+				},
+			},
+			events: []apiv1.Event{
+				{Type: apiv1.EventTypeWarning, Reason: "FailedScheduling", Message: "0/3 nodes are available: 3 Insufficient cpu.", LastTimestamp: metav1.NewTime(now)},
+			},
+			diagnostics: "Unschedulable: 0/3 nodes are available: 3 Insufficient cpu.",
+		},
+		"Verify crashing container reports last termination": {
+			status: apiv1.PodStatus{
+				Phase: apiv1.PodRunning,
+				ContainerStatuses: []apiv1.ContainerStatus{
+					{
+						Name:                 "browser",
+						State:                apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off 10s restarting failed container"}},
+						LastTerminationState: apiv1.ContainerState{Terminated: &apiv1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}},
+					},
+				},
+			},
+			diagnostics: "browser: CrashLoopBackOff: back-off 10s restarting failed container, last terminated OOMKilled with exit code 137",
+		},
+		"Verify init container and warning event are reported": {
+			status: apiv1.PodStatus{
+				Phase: apiv1.PodPending,
+				InitContainerStatuses: []apiv1.ContainerStatus{
+					{Name: "profile", Image: "selenoid/vnc:chrome_85.0", State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "ErrImagePull", Message: "not found"}}},
+				},
+			},
+			events: []apiv1.Event{
+				{Type: apiv1.EventTypeWarning, Reason: "FailedMount", Message: "secret \"chrome-profile\" not found", LastTimestamp: metav1.NewTime(now)},
+				{Type: apiv1.EventTypeNormal, Reason: "Scheduled", Message: "assigned to node-1", LastTimestamp: metav1.NewTime(now.Add(time.Second))},
+			},
+			diagnostics: "profile: ErrImagePull: selenoid/vnc:chrome_85.0; FailedMount: secret \"chrome-profile\" not found",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		pod := &apiv1.Pod{Status: test.status}
+		assert.Equal(t, test.diagnostics, podDiagnostics(pod, test.events))
+	}
+}
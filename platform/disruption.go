@@ -0,0 +1,39 @@
+package platform
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	//disruptionCondition is set on pods which are about to be deleted by eviction API (e.g. node drain),
+	//scheduler preemption or taint manager, reason of the condition tells which one
+	disruptionCondition apiv1.PodConditionType = "DisruptionTarget"
+	//nodeLostReason is set on pods of node which stopped reporting its status
+	nodeLostReason = "NodeLost"
+	//deadlineExceededReason is set on pods of session jobs stopped after their active deadline
+	deadlineExceededReason = "DeadlineExceeded"
+)
+
+//podDisruption returns reason browser pod was taken away from its session: pod was evicted by kubelet
+//under node pressure, drained from its node, preempted, its node was lost or browser container failed.
+//Pod which wasn't disrupted has empty reason
+func podDisruption(pod *apiv1.Pod) string {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == disruptionCondition && c.Status == apiv1.ConditionTrue {
+			if c.Reason != "" {
+				return c.Reason
+			}
+			return string(disruptionCondition)
+		}
+	}
+
+	switch {
+	case pod.Status.Reason == nodeLostReason:
+		return nodeLostReason
+	case pod.Status.Phase != apiv1.PodFailed, pod.Status.Reason == deadlineExceededReason:
+		return ""
+	case pod.Status.Reason != "":
+		return pod.Status.Reason
+	}
+	return string(apiv1.PodFailed)
+}
@@ -0,0 +1,51 @@
+package platform
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+func TestPodDisruption(t *testing.T) {
+	tests := map[string]struct {
+		status apiv1.PodStatus
+		reason string
+	}{
+		"Verify running pod is not disrupted": {
+			status: apiv1.PodStatus{Phase: apiv1.PodRunning},
+		},
+		"Verify pod evicted under node pressure is disrupted": {
+			status: apiv1.PodStatus{Phase: apiv1.PodFailed, Reason: "Evicted", Message: "The node was low on resource: memory."},
+			reason: "Evicted",
+		},
+		"Verify pod drained from its node is disrupted": {
+			status: apiv1.PodStatus{
+				Phase: apiv1.PodRunning,
+				Conditions: []apiv1.PodCondition{
+					{Type: apiv1.PodReady, Status: apiv1.ConditionTrue},
+					{Type: disruptionCondition, Status: apiv1.ConditionTrue, Reason: "EvictionByEvictionAPI"},
+				},
+			},
+			reason: "EvictionByEvictionAPI",
+		},
+		"Verify pod of lost node is disrupted": {
+			status: apiv1.PodStatus{Phase: apiv1.PodRunning, Reason: nodeLostReason},
+			reason: nodeLostReason,
+		},
+		"Verify failed pod is disrupted": {
+			status: apiv1.PodStatus{Phase: apiv1.PodFailed},
+			reason: "Failed",
+		},
+		"Verify pod of session job stopped after deadline is not disrupted": {
+			status: apiv1.PodStatus{Phase: apiv1.PodFailed, Reason: deadlineExceededReason},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		reason := podDisruption(&apiv1.Pod{Status: test.status})
+		assert.Equal(t, test.reason, reason)
+	}
+}
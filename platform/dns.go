@@ -0,0 +1,170 @@
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/alcounit/selenosis/selenium"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	//maxNameservers and maxSearches are limits of pod DNS config
+	maxNameservers = 3
+	maxSearches    = 6
+)
+
+//SessionDNS lists hostnames sessions can alias and nameservers sessions can use with hostAliases and dns
+//of selenosis:options capability. Hostname pattern *.example.com matches subdomains of example.com and
+//pattern * matches every hostname, sessions can't change name resolution without allowed hosts
+type SessionDNS struct {
+	Hosts       []string
+	Nameservers []*net.IPNet
+}
+
+//ParseSessionDNS returns allowed hostname patterns and nameserver networks, nameservers are ip addresses or CIDRs
+func ParseSessionDNS(hosts, nameservers []string) (SessionDNS, error) {
+	dns := SessionDNS{Hosts: make([]string, 0, len(hosts))}
+	for _, host := range hosts {
+		host = strings.ToLower(strings.TrimSuffix(host, "."))
+		if host == "" || (host != "*" && strings.Contains(strings.TrimPrefix(host, "*."), "*")) {
+			return SessionDNS{}, fmt.Errorf("invalid host pattern %s", host)
+		}
+		dns.Hosts = append(dns.Hosts, host)
+	}
+	for _, ns := range nameservers {
+		if !strings.Contains(ns, "/") {
+			ip := net.ParseIP(ns)
+			if ip == nil {
+				return SessionDNS{}, fmt.Errorf("invalid nameserver %s", ns)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				bits = 8 * net.IPv4len
+			}
+			ns = fmt.Sprintf("%s/%d", ns, bits)
+		}
+		_, network, err := net.ParseCIDR(ns)
+		if err != nil {
+			return SessionDNS{}, fmt.Errorf("invalid nameserver %s: %v", ns, err)
+		}
+		dns.Nameservers = append(dns.Nameservers, network)
+	}
+	return dns, nil
+}
+
+//allowedHost reports if hostname matches any of allowed patterns
+func (d SessionDNS) allowedHost(hostname string) bool {
+	hostname = strings.ToLower(strings.TrimSuffix(hostname, "."))
+	for _, pattern := range d.Hosts {
+		switch {
+		case pattern == "*", pattern == hostname:
+			return true
+		case strings.HasPrefix(pattern, "*.") && strings.HasSuffix(hostname, pattern[1:]):
+			return true
+		}
+	}
+	return false
+}
+
+//allowedNameserver reports if nameserver is within any of allowed networks
+func (d SessionDNS) allowedNameserver(ip net.IP) bool {
+	for _, network := range d.Nameservers {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+//Validate checks host aliases and DNS settings requested with capabilities are allowed
+func (d SessionDNS) Validate(caps selenium.Capabilities) error {
+	for _, alias := range caps.GetHostAliases() {
+		if net.ParseIP(alias.IP) == nil {
+			return fmt.Errorf("invalid host alias ip %s", alias.IP)
+		}
+		if len(alias.Hostnames) == 0 {
+			return fmt.Errorf("host alias of %s has no hostnames", alias.IP)
+		}
+		for _, hostname := range alias.Hostnames {
+			if !d.allowedHost(hostname) {
+				return fmt.Errorf("host alias of %s is not allowed", hostname)
+			}
+		}
+	}
+
+	dns := caps.GetDNS()
+	if dns == nil {
+		return nil
+	}
+	if len(dns.Nameservers) == 0 && len(dns.Searches) == 0 {
+		return errors.New("dns should set nameservers or searches")
+	}
+	if len(dns.Nameservers) > maxNameservers {
+		return fmt.Errorf("no more than %d nameservers are allowed", maxNameservers)
+	}
+	if len(dns.Searches) > maxSearches {
+		return fmt.Errorf("no more than %d search domains are allowed", maxSearches)
+	}
+	for _, ns := range dns.Nameservers {
+		ip := net.ParseIP(ns)
+		if ip == nil {
+			return fmt.Errorf("invalid nameserver %s", ns)
+		}
+		if !d.allowedNameserver(ip) {
+			return fmt.Errorf("nameserver %s is not allowed", ns)
+		}
+	}
+	for _, search := range dns.Searches {
+		if !d.allowedHost(search) {
+			return fmt.Errorf("search domain %s is not allowed", search)
+		}
+	}
+	return nil
+}
+
+//sessionDNS merges host aliases and DNS settings requested with capabilities into browser pod, hostnames
+//of requested ip are added to alias of the template. Requested nameservers replace cluster DNS of the pod
+func sessionDNS(pod *apiv1.Pod, caps selenium.Capabilities) {
+	aliases := make([]apiv1.HostAlias, 0, len(pod.Spec.HostAliases)+len(caps.GetHostAliases()))
+	for _, alias := range pod.Spec.HostAliases {
+		alias.Hostnames = append([]string{}, alias.Hostnames...)
+		aliases = append(aliases, alias)
+	}
+	for _, requested := range caps.GetHostAliases() {
+		merged := false
+		for i := range aliases {
+			if aliases[i].IP == requested.IP {
+				aliases[i].Hostnames = append(aliases[i].Hostnames, requested.Hostnames...)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			aliases = append(aliases, apiv1.HostAlias{IP: requested.IP, Hostnames: requested.Hostnames})
+		}
+	}
+	if len(aliases) > 0 {
+		pod.Spec.HostAliases = aliases
+	}
+
+	dns := caps.GetDNS()
+	if dns == nil {
+		return
+	}
+	config := apiv1.PodDNSConfig{}
+	if pod.Spec.DNSConfig != nil {
+		config = *pod.Spec.DNSConfig.DeepCopy()
+	}
+	if len(dns.Nameservers) > 0 {
+		pod.Spec.DNSPolicy = apiv1.DNSNone
+		config.Nameservers = append([]string{}, dns.Nameservers...)
+	}
+	config.Searches = append(append([]string{}, dns.Searches...), config.Searches...)
+	if len(config.Searches) > maxSearches {
+		config.Searches = config.Searches[:maxSearches]
+	}
+	pod.Spec.DNSConfig = &config
+}
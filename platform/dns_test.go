@@ -0,0 +1,145 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/alcounit/selenosis/selenium"
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestSessionDNSValidate(t *testing.T) {
+	dns, err := ParseSessionDNS([]string{"*.staging.example.com", "api.example.com"}, []string{"10.0.0.0/24", "192.168.1.53"})
+	assert.NilError(t, err)
+
+	tests := map[string]struct {
+		options selenium.SelenosisOptions
+		err     string
+	}{
+		"Verify session without host aliases and dns is allowed": {},
+		"Verify allowed host aliases and nameservers are accepted": {
+			options: selenium.SelenosisOptions{
+				HostAliases: []selenium.HostAlias{{IP: "10.1.2.3", Hostnames: []string{"shop.staging.example.com", "API.example.com"}}},
+				DNS:         &selenium.DNSOptions{Nameservers: []string{"10.0.0.53", "192.168.1.53"}, Searches: []string{"eu.staging.example.com"}},
+			},
+		},
+		"Verify host alias out of allowed patterns is rejected": {
+			options: selenium.SelenosisOptions{HostAliases: []selenium.HostAlias{{IP: "10.1.2.3", Hostnames: []string{"staging.example.com"}}}},
+			err:     "host alias of staging.example.com is not allowed",
+		},
+		"Verify host alias with malformed ip is rejected": {
+			options: selenium.SelenosisOptions{HostAliases: []selenium.HostAlias{{IP: "10.1.2", Hostnames: []string{"api.example.com"}}}},
+			err:     "invalid host alias ip 10.1.2",
+		},
+		"Verify host alias without hostnames is rejected": {
+			options: selenium.SelenosisOptions{HostAliases: []selenium.HostAlias{{IP: "10.1.2.3"}}},
+			err:     "host alias of 10.1.2.3 has no hostnames",
+		},
+		"Verify nameserver out of allowed networks is rejected": {
+			options: selenium.SelenosisOptions{DNS: &selenium.DNSOptions{Nameservers: []string{"8.8.8.8"}}},
+			err:     "nameserver 8.8.8.8 is not allowed",
+		},
+		"Verify search domain out of allowed patterns is rejected": {
+			options: selenium.SelenosisOptions{DNS: &selenium.DNSOptions{Searches: []string{"example.org"}}},
+			err:     "search domain example.org is not allowed",
+		},
+		"Verify too many nameservers are rejected": {
+			options: selenium.SelenosisOptions{DNS: &selenium.DNSOptions{Nameservers: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4"}}},
+			err:     "no more than 3 nameservers are allowed",
+		},
+		"Verify empty dns is rejected": {
+			options: selenium.SelenosisOptions{DNS: &selenium.DNSOptions{}},
+			err:     "dns should set nameservers or searches",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		options := test.options
+		err := dns.Validate(selenium.Capabilities{Options: &options})
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+	}
+
+	err = SessionDNS{}.Validate(selenium.Capabilities{Options: &selenium.SelenosisOptions{
+		HostAliases: []selenium.HostAlias{{IP: "10.1.2.3", Hostnames: []string{"api.example.com"}}},
+	}})
+	assert.Error(t, err, "host alias of api.example.com is not allowed")
+}
+
+func TestParseSessionDNS(t *testing.T) {
+	_, err := ParseSessionDNS([]string{"api.*.example.com"}, nil)
+	assert.Error(t, err, "invalid host pattern api.*.example.com")
+
+	_, err = ParseSessionDNS(nil, []string{"dns.local"})
+	assert.Error(t, err, "invalid nameserver dns.local")
+
+	dns, err := ParseSessionDNS([]string{"*"}, []string{"fd00::53"})
+	assert.NilError(t, err)
+	assert.Assert(t, dns.allowedHost("any.example.org"))
+	assert.Equal(t, "fd00::53/128", dns.Nameservers[0].String())
+}
+
+func TestSessionDNSPod(t *testing.T) {
+	template := BrowserSpec{
+		Image: "selenoid/vnc:chrome_85.0",
+		Spec: Spec{
+			HostAliases: []apiv1.HostAlias{{IP: "10.1.2.3", Hostnames: []string{"app.local"}}},
+			DNSConfig:   apiv1.PodDNSConfig{Searches: []string{"svc.cluster.local"}},
+		},
+	}
+
+	tests := map[string]struct {
+		options   selenium.SelenosisOptions
+		aliases   []apiv1.HostAlias
+		policy    apiv1.DNSPolicy
+		dnsConfig apiv1.PodDNSConfig
+	}{
+		"Verify template dns is kept when nothing is requested": {
+			aliases:   template.Spec.HostAliases,
+			dnsConfig: template.Spec.DNSConfig,
+		},
+		"Verify requested host aliases are merged by ip": {
+			options: selenium.SelenosisOptions{HostAliases: []selenium.HostAlias{
+				{IP: "10.1.2.3", Hostnames: []string{"shop.staging.example.com"}},
+				{IP: "10.1.2.4", Hostnames: []string{"api.staging.example.com"}},
+			}},
+			aliases: []apiv1.HostAlias{
+				{IP: "10.1.2.3", Hostnames: []string{"app.local", "shop.staging.example.com"}},
+				{IP: "10.1.2.4", Hostnames: []string{"api.staging.example.com"}},
+			},
+			dnsConfig: template.Spec.DNSConfig,
+		},
+		"Verify requested nameservers replace cluster dns": {
+			options: selenium.SelenosisOptions{DNS: &selenium.DNSOptions{Nameservers: []string{"10.0.0.53"}, Searches: []string{"staging.example.com"}}},
+			aliases: template.Spec.HostAliases,
+			policy:  apiv1.DNSNone,
+			dnsConfig: apiv1.PodDNSConfig{
+				Nameservers: []string{"10.0.0.53"},
+				Searches:    []string{"staging.example.com", "svc.cluster.local"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		options := test.options
+		cl := &service{svcPort: intstr.FromString("4445"), proxyImage: "seleniferous"}
+		pod, err := cl.buildPod(ServiceSpec{
+			SessionID:             "chrome-85-0",
+			RequestedCapabilities: selenium.Capabilities{Options: &options},
+			Template:              template,
+		})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, test.aliases, pod.Spec.HostAliases)
+		assert.Equal(t, test.policy, pod.Spec.DNSPolicy)
+		assert.DeepEqual(t, test.dnsConfig, *pod.Spec.DNSConfig)
+	}
+	assert.DeepEqual(t, []string{"app.local"}, template.Spec.HostAliases[0].Hostnames)
+}
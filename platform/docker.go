@@ -0,0 +1,846 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	//DockerPlatform runs browsers as containers of Docker daemon
+	DockerPlatform = "docker"
+	//KubernetesPlatform runs browsers as pods of Kubernetes cluster
+	KubernetesPlatform = "kubernetes"
+
+	//dockerShmSize replaces memory backed /dev/shm volume of browser pods, default 64MB crashes browsers
+	dockerShmSize = 1 << 30
+	sidecarSuffix = "-seleniferous"
+)
+
+//dockerWatchRetry is delay before docker events stream is reopened after failure
+var dockerWatchRetry = time.Second
+
+//DockerConfig ...
+type DockerConfig struct {
+	Host             string
+	Network          string
+	ServicePort      string
+	ProxyImage       string
+	ReadinessTimeout time.Duration
+	IdleTimeout      time.Duration
+}
+
+//Docker runs browsers as containers of Docker daemon for local development and CI runners without
+//Kubernetes, sidecar joins network namespace of browser container like containers of browser pod do.
+//Quota and artifact records are kept in memory
+type Docker struct {
+	api       *dockerAPI
+	network   string
+	svcPort   string
+	service   *dockerService
+	quota     *dockerQuota
+	artifacts *dockerArtifacts
+}
+
+//NewDocker ...
+func NewDocker(c DockerConfig) (Platform, error) {
+	api, err := newDockerAPI(c.Host)
+	if err != nil {
+		return nil, err
+	}
+	if err := api.do(context.Background(), http.MethodGet, "/_ping", nil, nil); err != nil {
+		return nil, fmt.Errorf("docker daemon is not reachable: %v", err)
+	}
+
+	quota := &dockerQuota{}
+	return &Docker{
+		api:     api,
+		network: c.Network,
+		svcPort: c.ServicePort,
+		service: &dockerService{
+			api:              api,
+			network:          c.Network,
+			svcPort:          c.ServicePort,
+			proxyImage:       c.ProxyImage,
+			readinessTimeout: c.ReadinessTimeout,
+			idleTimeout:      c.IdleTimeout,
+			quota:            quota,
+		},
+		quota:     quota,
+		artifacts: &dockerArtifacts{records: make(map[string][]Artifact)},
+	}, nil
+}
+
+func (d *Docker) Service() ServiceInterface {
+	return d.service
+}
+
+func (d *Docker) Quota() QuotaInterface {
+	return d.quota
+}
+
+func (d *Docker) Resources() ResourceInterface {
+	return dockerResources{}
+}
+
+func (d *Docker) Artifacts() ArtifactInterface {
+	return d.artifacts
+}
+
+//State ...
+func (d *Docker) State() (PlatformState, error) {
+	containers, err := d.api.containers(context.Background(), label+"=browser")
+	if err != nil {
+		return PlatformState{}, fmt.Errorf("failed to get containers: %v", err)
+	}
+
+	var services []Service
+	for _, c := range containers {
+		services = append(services, d.containerService(c))
+	}
+	return PlatformState{Services: services}, nil
+}
+
+//Watch returns events of browser containers, session is deleted once its browser or sidecar exits
+func (d *Docker) Watch() <-chan Event {
+	ch := make(chan Event)
+	go func() {
+		for {
+			d.watch(ch)
+			time.Sleep(dockerWatchRetry)
+		}
+	}()
+	return ch
+}
+
+func (d *Docker) watch(ch chan<- Event) error {
+	ctx := context.Background()
+	filters, _ := json.Marshal(map[string][]string{"type": {"container"}, "label": {defaultLabels.session}})
+	body, err := d.api.stream(ctx, http.MethodGet, "/events?filters="+url.QueryEscape(string(filters)), nil)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	decoder := json.NewDecoder(body)
+	for {
+		var event dockerEvent
+		if err := decoder.Decode(&event); err != nil {
+			return err
+		}
+		attrs := event.Actor.Attributes
+		sessionID := attrs[defaultLabels.session]
+		if attrs[label] != "browser" {
+			if event.Action == "die" {
+				go d.service.Delete(sessionID)
+			}
+			continue
+		}
+
+		service := Service{
+			SessionID:     sessionID,
+			Labels:        getRequestedCapabilities(attrs),
+			SessionLabels: getSessionLabels(attrs),
+			Started:       time.Unix(event.Time, 0),
+		}
+		switch event.Action {
+		case "create":
+			service.Status = Pending
+			ch <- Event{Type: Added, PlatformObject: service}
+		case "start":
+			containers, err := d.api.containers(ctx, defaultLabels.session+"="+sessionID, label+"=browser")
+			if err != nil || len(containers) == 0 {
+				continue
+			}
+			ch <- Event{Type: Updated, PlatformObject: d.containerService(containers[0])}
+		case "die":
+			service.Status = Unknown
+			ch <- Event{Type: Updated, PlatformObject: service}
+			go d.service.Delete(sessionID)
+		case "destroy":
+			ch <- Event{Type: Deleted, PlatformObject: service}
+		}
+	}
+}
+
+func (d *Docker) containerService(c dockerContainer) Service {
+	sessionID := c.Labels[defaultLabels.session]
+
+	var status ServiceStatus
+	switch c.State {
+	case "running":
+		status = Running
+	case "created", "restarting":
+		status = Pending
+	default:
+		status = Unknown
+	}
+
+	return Service{
+		SessionID: sessionID,
+		URL: &url.URL{
+			Scheme: "http",
+			Host:   net.JoinHostPort(c.ip(d.network), d.svcPort),
+		},
+		Labels:        getRequestedCapabilities(c.Labels),
+		SessionLabels: getSessionLabels(c.Labels),
+		CancelFunc: func() {
+			d.service.Delete(sessionID)
+		},
+		Status:  status,
+		Started: time.Unix(c.Created, 0),
+	}
+}
+
+type dockerService struct {
+	api              *dockerAPI
+	network          string
+	svcPort          string
+	proxyImage       string
+	readinessTimeout time.Duration
+	idleTimeout      time.Duration
+	quota            *dockerQuota
+}
+
+//Create starts browser container and sidecar sharing its network, browser settings which have no
+//docker counterpart (node selection, volumes, pod overlays and patches) are ignored
+func (cl *dockerService) Create(layout ServiceSpec) (Service, error) {
+	caps := layout.RequestedCapabilities
+	switch {
+	case layout.Template.VM != nil:
+		return Service{}, errors.New("virtual machine browsers are not supported by docker platform")
+	case layout.Template.Platform == WindowsPlatform:
+		return Service{}, errors.New("windows browsers are not supported by docker platform")
+	case caps.Video:
+		return Service{}, errors.New("video recording is not supported by docker platform")
+	case caps.Workspace:
+		return Service{}, errors.New("workspaces are not supported by docker platform")
+	case caps.GetCaptureHAR():
+		return Service{}, errors.New("HAR capture is not supported by docker platform")
+	case caps.GetProfileConfigMap() != "" || caps.GetProfileSecret() != "":
+		return Service{}, errors.New("browser profiles are not supported by docker platform")
+	case len(caps.GetFiles()) > 0:
+		return Service{}, errors.New("session files are not supported by docker platform")
+	}
+
+	var phases []Phase
+	phaseStart := time.Now()
+	phase := func(name string) {
+		now := time.Now()
+		phases = append(phases, Phase{Name: name, Duration: now.Sub(phaseStart)})
+		phaseStart = now
+	}
+
+	ctx := context.Background()
+	sessionID := layout.SessionID
+
+	running, err := cl.api.containers(ctx, label+"=browser")
+	if err != nil {
+		return Service{}, fmt.Errorf("failed to get containers: %v", err)
+	}
+	if limit := cl.quota.limit(); limit > 0 && int64(len(running)) >= limit {
+		return Service{}, fmt.Errorf("failed to create container: session limit %d is reached", limit)
+	}
+
+	for _, image := range []string{layout.Template.Image, cl.proxyImage} {
+		if err := cl.api.pull(ctx, image); err != nil {
+			return Service{}, fmt.Errorf("failed to pull image %s: %v", image, err)
+		}
+	}
+	phase("pull")
+
+	browser := browserContainer(layout)
+	browser.HostConfig.NetworkMode = cl.network
+	id, err := cl.api.create(ctx, sessionID, browser)
+	if err != nil {
+		return Service{}, fmt.Errorf("failed to create container %v", err)
+	}
+	cancel := func() {
+		cl.Delete(sessionID)
+	}
+
+	sidecar := dockerContainerSpec{
+		Image: cl.proxyImage,
+		Env:   []string{idleTimeoutEnv + "=" + idleTimeout(layout, cl.idleTimeout).String()},
+		Cmd: append([]string{
+			"/seleniferous", "--listhen-port", cl.svcPort, "--proxy-default-path", path.Join(layout.Template.Path, "session"), "--idle-timeout", idleTimeout(layout, cl.idleTimeout).String(),
+		}, browserPortArgs(layout.Template)...),
+		Labels:     map[string]string{label: "sidecar", defaultLabels.session: sessionID},
+		HostConfig: dockerHostConfig{NetworkMode: "container:" + id},
+	}
+	if err := cl.api.start(ctx, id); err != nil {
+		cancel()
+		return Service{}, fmt.Errorf("failed to start container %v", err)
+	}
+	sidecarID, err := cl.api.create(ctx, sessionID+sidecarSuffix, sidecar)
+	if err == nil {
+		err = cl.api.start(ctx, sidecarID)
+	}
+	if err != nil {
+		cancel()
+		return Service{}, fmt.Errorf("failed to start sidecar %v", err)
+	}
+	phase("create")
+
+	containers, err := cl.api.containers(ctx, defaultLabels.session+"="+sessionID, label+"=browser")
+	if err != nil || len(containers) == 0 || containers[0].State != "running" {
+		cancel()
+		return Service{}, fmt.Errorf("container is not running after start")
+	}
+	container := containers[0]
+	phase("running")
+
+	ip := container.ip(cl.network)
+	u := &url.URL{
+		Scheme: "http",
+		Host:   net.JoinHostPort(ip, browserPort(layout.Template).StrVal),
+	}
+	exec := func(command []string) error {
+		return cl.api.exec(ctx, id, command)
+	}
+	if err := waitForBrowser(*u, layout.Template, layout.Template.Readiness.timeout(cl.readinessTimeout), exec); err != nil {
+		cancel()
+		return Service{}, fmt.Errorf("container service is not ready %v", u.String())
+	}
+	phase("ready")
+
+	u.Host = net.JoinHostPort(ip, cl.svcPort)
+
+	return Service{
+		SessionID:     sessionID,
+		URL:           u,
+		Labels:        getRequestedCapabilities(container.Labels),
+		SessionLabels: getSessionLabels(container.Labels),
+		CancelFunc: func() {
+			cancel()
+		},
+		Status:  Running,
+		Started: time.Unix(container.Created, 0),
+		Phases:  phases,
+	}, nil
+}
+
+//Delete removes sidecar and browser containers of the session
+func (cl *dockerService) Delete(name string) error {
+	ctx := context.Background()
+	if err := cl.api.remove(ctx, name+sidecarSuffix); err != nil && !isDockerNotFound(err) {
+		return err
+	}
+	return cl.api.remove(ctx, name)
+}
+
+//Logs ...
+func (cl *dockerService) Logs(ctx context.Context, name string) (io.ReadCloser, error) {
+	body, err := cl.api.stream(ctx, http.MethodGet, "/containers/"+name+"/logs?follow=1&stdout=1&stderr=1", nil)
+	if err != nil {
+		return nil, err
+	}
+	return demuxLogs(body), nil
+}
+
+//browserContainer returns browser container of the session, requested capabilities are kept in labels
+//like in annotations of browser pod
+func browserContainer(layout ServiceSpec) dockerContainerSpec {
+	caps := layout.RequestedCapabilities
+	template := layout.Template
+
+	capabilities := map[string]string{
+		defaultsAnnotations.browserName:    template.BrowserName,
+		defaultsAnnotations.browserVersion: template.BrowserVersion,
+		defaultsAnnotations.testName:       caps.TestName,
+	}
+	if caps.RunID != "" {
+		capabilities[defaultsAnnotations.runID] = caps.RunID
+	}
+	if layout.Tenant != "" {
+		capabilities[defaultsAnnotations.tenant] = layout.Tenant
+	}
+	if layout.IdleTimeout > 0 {
+		capabilities[defaultsAnnotations.sessionTimeout] = layout.IdleTimeout.String()
+	}
+	if layout.Client != "" {
+		capabilities[defaultsAnnotations.client] = layout.Client
+	}
+	if layout.Owner != "" {
+		capabilities[defaultsAnnotations.owner] = layout.Owner
+	}
+
+	var overrides []apiv1.EnvVar
+	if caps.ScreenResolution != "" {
+		overrides = append(overrides, apiv1.EnvVar{Name: defaultsAnnotations.screenResolution, Value: caps.ScreenResolution})
+	}
+	if caps.VNC {
+		overrides = append(overrides, apiv1.EnvVar{Name: defaultsAnnotations.enableVNC, Value: fmt.Sprintf("%v", caps.VNC)})
+	}
+	if caps.TimeZone != "" {
+		overrides = append(overrides, apiv1.EnvVar{Name: defaultsAnnotations.timeZone, Value: caps.TimeZone})
+	}
+	overrides = append(overrides, optionsEnv(caps)...)
+
+	var env []string
+	for _, v := range mergeEnv(template.Spec.EnvVars, overrides) {
+		if v.ValueFrom != nil {
+			continue
+		}
+		env = append(env, v.Name+"="+v.Value)
+		switch v.Name {
+		case defaultsAnnotations.screenResolution, defaultsAnnotations.enableVNC, defaultsAnnotations.timeZone:
+			capabilities[v.Name] = v.Value
+		}
+	}
+
+	labels := map[string]string{}
+	for k, v := range template.Meta.Labels {
+		labels[k] = v
+	}
+	sessionLabels, _ := RequestedLabels(caps)
+	for k, v := range sessionLabels {
+		labels[k] = v
+	}
+	if data, err := json.Marshal(sessionLabels); err == nil && len(sessionLabels) > 0 {
+		labels[sessionLabelsAnnotation] = string(data)
+	}
+	labels[defaultLabels.serviceType] = "browser"
+	labels[defaultLabels.appType] = "browser"
+	labels[defaultLabels.session] = layout.SessionID
+	if data, err := json.Marshal(capabilities); err == nil {
+		labels["capabilities"] = string(data)
+	}
+
+	//user and group of browser container take precedence over runAs and pod security context as they do in pod
+	secContext := browserSecurityContext(template)
+	podSecContext := getSecurityContext(template.RunAs, template.Spec.PodSecurityContext)
+	uid, gid := podSecContext.RunAsUser, podSecContext.RunAsGroup
+	if secContext.RunAsUser != nil {
+		uid = secContext.RunAsUser
+	}
+	if secContext.RunAsGroup != nil {
+		gid = secContext.RunAsGroup
+	}
+	var user string
+	if uid != nil {
+		user = fmt.Sprint(*uid)
+		if gid != nil {
+			user += fmt.Sprintf(":%d", *gid)
+		}
+	}
+
+	host := dockerHostConfig{ShmSize: dockerShmSize}
+	if secContext.Privileged != nil {
+		host.Privileged = *secContext.Privileged
+	}
+	if secContext.Capabilities != nil {
+		for _, c := range secContext.Capabilities.Add {
+			host.CapAdd = append(host.CapAdd, string(c))
+		}
+		for _, c := range secContext.Capabilities.Drop {
+			host.CapDrop = append(host.CapDrop, string(c))
+		}
+	}
+	if secContext.ReadOnlyRootFilesystem != nil {
+		host.ReadonlyRootfs = *secContext.ReadOnlyRootFilesystem
+	}
+	if secContext.AllowPrivilegeEscalation != nil && !*secContext.AllowPrivilegeEscalation {
+		host.SecurityOpt = append(host.SecurityOpt, "no-new-privileges")
+	}
+	seccomp := secContext.SeccompProfile
+	if seccomp == nil {
+		seccomp = podSecContext.SeccompProfile
+	}
+	if seccomp != nil && seccomp.Type == apiv1.SeccompProfileTypeUnconfined {
+		host.SecurityOpt = append(host.SecurityOpt, "seccomp=unconfined")
+	}
+	for _, alias := range template.Spec.HostAliases {
+		for _, name := range alias.Hostnames {
+			host.ExtraHosts = append(host.ExtraHosts, name+":"+alias.IP)
+		}
+	}
+	for _, alias := range caps.GetHostAliases() {
+		for _, name := range alias.Hostnames {
+			host.ExtraHosts = append(host.ExtraHosts, name+":"+alias.IP)
+		}
+	}
+	if dns := caps.GetDNS(); dns != nil {
+		host.DNS = dns.Nameservers
+		host.DNSSearch = dns.Searches
+	}
+	//requested resources are validated with the session request
+	resources, _ := SessionResources(template, caps)
+	if memory, ok := resources.Limits[apiv1.ResourceMemory]; ok {
+		host.Memory = memory.Value()
+	}
+	if cpu, ok := resources.Limits[apiv1.ResourceCPU]; ok {
+		host.NanoCPUs = cpu.MilliValue() * 1000000
+	}
+
+	return dockerContainerSpec{
+		Image:      template.Image,
+		Hostname:   layout.SessionID,
+		Env:        env,
+		Labels:     labels,
+		User:       user,
+		HostConfig: host,
+	}
+}
+
+//demuxLogs strips stream headers docker prefixes stdout and stderr frames of containers without tty with
+func demuxLogs(body io.ReadCloser) io.ReadCloser {
+	r, w := io.Pipe()
+	go func() {
+		defer body.Close()
+		header := make([]byte, 8)
+		for {
+			if _, err := io.ReadFull(body, header); err != nil {
+				w.CloseWithError(err)
+				return
+			}
+			if _, err := io.CopyN(w, body, int64(binary.BigEndian.Uint32(header[4:]))); err != nil {
+				w.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	return r
+}
+
+type dockerQuota struct {
+	sync.Mutex
+	current *Quota
+}
+
+func (q *dockerQuota) limit() int64 {
+	q.Lock()
+	defer q.Unlock()
+	if q.current == nil {
+		return 0
+	}
+	return q.current.CurrentMaxLimit
+}
+
+//Create ...
+func (q *dockerQuota) Create(limit int64) (Quota, error) {
+	q.Lock()
+	defer q.Unlock()
+	q.current = &Quota{Name: quotaName, CurrentMaxLimit: limit}
+	return *q.current, nil
+}
+
+//Get ...
+func (q *dockerQuota) Get() (Quota, error) {
+	q.Lock()
+	defer q.Unlock()
+	if q.current == nil {
+		return Quota{}, errors.New("quota is not created")
+	}
+	return *q.current, nil
+}
+
+//Update ...
+func (q *dockerQuota) Update(limit int64) (Quota, error) {
+	return q.Create(limit)
+}
+
+//dockerResources reports no auxiliary objects, docker sessions don't create them
+type dockerResources struct{}
+
+func (dockerResources) List() ([]Resource, error) {
+	return nil, nil
+}
+
+func (dockerResources) Delete(r Resource) error {
+	return fmt.Errorf("%s is not supported by docker platform", r.Kind)
+}
+
+type dockerArtifacts struct {
+	sync.Mutex
+	records map[string][]Artifact
+}
+
+//List ...
+func (cl *dockerArtifacts) List(tenant string) ([]Artifact, error) {
+	cl.Lock()
+	defer cl.Unlock()
+	return append([]Artifact{}, cl.records[tenant]...), nil
+}
+
+//Add ...
+func (cl *dockerArtifacts) Add(tenant string, artifact Artifact) error {
+	cl.Lock()
+	defer cl.Unlock()
+	cl.records[tenant] = append(cl.records[tenant], artifact)
+	return nil
+}
+
+//Remove ...
+func (cl *dockerArtifacts) Remove(tenant string, urls []string) error {
+	cl.Lock()
+	defer cl.Unlock()
+	removed := make(map[string]struct{}, len(urls))
+	for _, u := range urls {
+		removed[u] = struct{}{}
+	}
+	var kept []Artifact
+	for _, a := range cl.records[tenant] {
+		if _, ok := removed[a.URL]; !ok {
+			kept = append(kept, a)
+		}
+	}
+	cl.records[tenant] = kept
+	return nil
+}
+
+//Credentials ...
+func (cl *dockerArtifacts) Credentials(_ string, secret string) (map[string]string, error) {
+	return nil, fmt.Errorf("secret %s: secrets are not supported by docker platform", secret)
+}
+
+type dockerContainerSpec struct {
+	Image      string            `json:"Image"`
+	Hostname   string            `json:"Hostname,omitempty"`
+	User       string            `json:"User,omitempty"`
+	Env        []string          `json:"Env,omitempty"`
+	Cmd        []string          `json:"Cmd,omitempty"`
+	Labels     map[string]string `json:"Labels,omitempty"`
+	HostConfig dockerHostConfig  `json:"HostConfig"`
+}
+
+type dockerHostConfig struct {
+	NetworkMode    string   `json:"NetworkMode,omitempty"`
+	Privileged     bool     `json:"Privileged,omitempty"`
+	CapAdd         []string `json:"CapAdd,omitempty"`
+	CapDrop        []string `json:"CapDrop,omitempty"`
+	SecurityOpt    []string `json:"SecurityOpt,omitempty"`
+	ReadonlyRootfs bool     `json:"ReadonlyRootfs,omitempty"`
+	ExtraHosts     []string `json:"ExtraHosts,omitempty"`
+	DNS            []string `json:"Dns,omitempty"`
+	DNSSearch      []string `json:"DnsSearch,omitempty"`
+	ShmSize        int64    `json:"ShmSize,omitempty"`
+	Memory         int64    `json:"Memory,omitempty"`
+	NanoCPUs       int64    `json:"NanoCpus,omitempty"`
+}
+
+type dockerContainer struct {
+	ID              string            `json:"Id"`
+	Labels          map[string]string `json:"Labels"`
+	State           string            `json:"State"`
+	Created         int64             `json:"Created"`
+	NetworkSettings struct {
+		Networks map[string]struct {
+			IPAddress string `json:"IPAddress"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+//ip returns address of container in the network, address in any network is used if network is not set
+func (c dockerContainer) ip(network string) string {
+	if n, ok := c.NetworkSettings.Networks[network]; ok {
+		return n.IPAddress
+	}
+	for _, n := range c.NetworkSettings.Networks {
+		if n.IPAddress != "" {
+			return n.IPAddress
+		}
+	}
+	return ""
+}
+
+type dockerEvent struct {
+	Action string `json:"Action"`
+	Actor  struct {
+		ID         string            `json:"ID"`
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+	Time int64 `json:"time"`
+}
+
+type dockerError struct {
+	code    int
+	message string
+}
+
+func (e *dockerError) Error() string {
+	return fmt.Sprintf("docker responded with %d: %s", e.code, e.message)
+}
+
+func isDockerNotFound(err error) bool {
+	var e *dockerError
+	return errors.As(err, &e) && e.code == http.StatusNotFound
+}
+
+//dockerAPI is minimal client of Docker Engine API
+type dockerAPI struct {
+	base   string
+	client *http.Client
+}
+
+//newDockerAPI returns client of daemon listening on unix socket (unix:///var/run/docker.sock) or tcp (tcp://host:2375)
+func newDockerAPI(host string) (*dockerAPI, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host: %v", err)
+	}
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		}
+		return &dockerAPI{base: "http://docker", client: &http.Client{Transport: transport}}, nil
+	case "tcp", "http":
+		return &dockerAPI{base: "http://" + u.Host, client: &http.Client{}}, nil
+	}
+	return nil, fmt.Errorf("unsupported docker host %s", host)
+}
+
+//stream returns body of successful response, message of docker error is returned otherwise
+func (api *dockerAPI) stream(ctx context.Context, method, path string, in interface{}) (io.ReadCloser, error) {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, api.base+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := api.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		var msg struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&msg)
+		return nil, &dockerError{code: resp.StatusCode, message: msg.Message}
+	}
+	return resp.Body, nil
+}
+
+//do sends request, response is decoded to out if set
+func (api *dockerAPI) do(ctx context.Context, method, path string, in, out interface{}) error {
+	body, err := api.stream(ctx, method, path, in)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	if out != nil {
+		return json.NewDecoder(body).Decode(out)
+	}
+	_, err = io.Copy(ioutil.Discard, body)
+	return err
+}
+
+//containers lists containers with all labels, label is either key or key=value
+func (api *dockerAPI) containers(ctx context.Context, labels ...string) ([]dockerContainer, error) {
+	filters, _ := json.Marshal(map[string][]string{"label": labels})
+	var containers []dockerContainer
+	err := api.do(ctx, http.MethodGet, "/containers/json?all=1&filters="+url.QueryEscape(string(filters)), nil, &containers)
+	return containers, err
+}
+
+func (api *dockerAPI) create(ctx context.Context, name string, spec dockerContainerSpec) (string, error) {
+	var created struct {
+		ID string `json:"Id"`
+	}
+	err := api.do(ctx, http.MethodPost, "/containers/create?name="+url.QueryEscape(name), spec, &created)
+	return created.ID, err
+}
+
+func (api *dockerAPI) start(ctx context.Context, id string) error {
+	return api.do(ctx, http.MethodPost, "/containers/"+id+"/start", nil, nil)
+}
+
+//exec runs command in container and waits for it to exit, non zero exit code is returned as error
+func (api *dockerAPI) exec(ctx context.Context, id string, command []string) error {
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := api.do(ctx, http.MethodPost, "/containers/"+id+"/exec", map[string]interface{}{"Cmd": command}, &created); err != nil {
+		return err
+	}
+	if err := api.do(ctx, http.MethodPost, "/exec/"+created.ID+"/start", map[string]interface{}{"Detach": true}, nil); err != nil {
+		return err
+	}
+	for {
+		var state struct {
+			Running  bool
+			ExitCode int
+		}
+		if err := api.do(ctx, http.MethodGet, "/exec/"+created.ID+"/json", nil, &state); err != nil {
+			return err
+		}
+		if !state.Running {
+			if state.ExitCode != 0 {
+				return fmt.Errorf("command exited with code %d", state.ExitCode)
+			}
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(readinessInterval):
+		}
+	}
+}
+
+func (api *dockerAPI) remove(ctx context.Context, name string) error {
+	return api.do(ctx, http.MethodDelete, "/containers/"+name+"?force=1&v=1", nil, nil)
+}
+
+//pull pulls image if it is not present, image without tag is pulled with latest tag
+func (api *dockerAPI) pull(ctx context.Context, image string) error {
+	err := api.do(ctx, http.MethodGet, "/images/"+image+"/json", nil, nil)
+	if !isDockerNotFound(err) {
+		return err
+	}
+
+	query := url.Values{"fromImage": {image}}
+	if !strings.Contains(image[strings.LastIndex(image, "/")+1:], ":") && !strings.Contains(image, "@") {
+		query.Set("tag", "latest")
+	}
+	body, err := api.stream(ctx, http.MethodPost, "/images/create?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	decoder := json.NewDecoder(body)
+	for {
+		var progress struct {
+			Error string `json:"error"`
+		}
+		if err := decoder.Decode(&progress); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if progress.Error != "" {
+			return errors.New(progress.Error)
+		}
+	}
+}
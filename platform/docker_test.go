@@ -0,0 +1,251 @@
+package platform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/selenium"
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestBrowserContainer(t *testing.T) {
+	uid, gid := int64(1000), int64(2000)
+	privileged := true
+
+	spec := browserContainer(ServiceSpec{
+		SessionID:             "chrome-85-0-1",
+		Tenant:                "qa",
+		Owner:                 "ci",
+		RequestedCapabilities: selenium.Capabilities{TestName: "login", ScreenResolution: "1920x1080x24", RunID: "nightly",
+			Options: &selenium.SelenosisOptions{Env: []string{"LANG=de_DE.UTF-8"}}},
+		Template: BrowserSpec{
+			BrowserName:    "chrome",
+			BrowserVersion: "85.0",
+			Image:          "selenoid/vnc:chrome_85.0",
+			Privileged:     &privileged,
+			Capabilities:   []apiv1.Capability{"SYS_ADMIN"},
+			RunAs:          RunAsOptions{RunAsUser: &uid, RunAsGroup: &gid},
+			Meta:           Meta{Labels: map[string]string{"team": "qa"}},
+			Spec: Spec{
+				EnvVars: []apiv1.EnvVar{
+					{Name: "SCREEN_RESOLUTION", Value: "1280x1024x24"},
+					{Name: "TZ", Value: "UTC"},
+					{Name: "TOKEN", ValueFrom: &apiv1.EnvVarSource{}},
+				},
+				HostAliases: []apiv1.HostAlias{{IP: "10.0.0.1", Hostnames: []string{"app.local", "api.local"}}},
+				Resources: apiv1.ResourceRequirements{Limits: apiv1.ResourceList{
+					apiv1.ResourceCPU:    resource.MustParse("500m"),
+					apiv1.ResourceMemory: resource.MustParse("1Gi"),
+				}},
+			},
+		},
+	})
+
+	assert.Equal(t, "selenoid/vnc:chrome_85.0", spec.Image)
+	assert.Equal(t, "chrome-85-0-1", spec.Hostname)
+	assert.Equal(t, "1000:2000", spec.User)
+	assert.DeepEqual(t, []string{"SCREEN_RESOLUTION=1920x1080x24", "TZ=UTC", "LANG=de_DE.UTF-8"}, spec.Env)
+	assert.DeepEqual(t, dockerHostConfig{
+		Privileged: true,
+		CapAdd:     []string{"SYS_ADMIN"},
+		ExtraHosts: []string{"app.local:10.0.0.1", "api.local:10.0.0.1"},
+		ShmSize:    dockerShmSize,
+		Memory:     1 << 30,
+		NanoCPUs:   500000000,
+	}, spec.HostConfig)
+
+	assert.Equal(t, "qa", spec.Labels["team"])
+	assert.Equal(t, "browser", spec.Labels[label])
+	assert.Equal(t, "chrome-85-0-1", spec.Labels["session"])
+	assert.DeepEqual(t, map[string]string{
+		"browserName":       "chrome",
+		"browserVersion":    "85.0",
+		"testName":          "login",
+		"runId":             "nightly",
+		"tenant":            "qa",
+		"owner":             "ci",
+		"SCREEN_RESOLUTION": "1920x1080x24",
+		"TZ":                "UTC",
+	}, getRequestedCapabilities(spec.Labels))
+}
+
+func TestDockerCreate(t *testing.T) {
+	browser := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer browser.Close()
+	_, port, _ := net.SplitHostPort(browser.Listener.Addr().String())
+	defer func(p intstr.IntOrString) { browserPorts.selenium = p }(browserPorts.selenium)
+	browserPorts.selenium = intstr.FromString(port)
+
+	tests := map[string]struct {
+		caps    selenium.Capabilities
+		limit   int64
+		running int
+		err     string
+	}{
+		"Verify browser and sidecar containers are started": {
+			limit: 2,
+		},
+		"Verify session limit is enforced": {
+			limit:   1,
+			running: 1,
+			err:     "failed to create container: session limit 1 is reached",
+		},
+		"Verify video is not supported": {
+			caps: selenium.Capabilities{Video: true},
+			err:  "video recording is not supported by docker platform",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		daemon := newDockerMock(test.running)
+		srv := httptest.NewServer(daemon)
+
+		client, err := NewDocker(DockerConfig{
+			Host:             srv.URL,
+			Network:          "selenosis",
+			ServicePort:      "4445",
+			ProxyImage:       "alcounit/seleniferous",
+			ReadinessTimeout: time.Second,
+			IdleTimeout:      time.Minute,
+		})
+		assert.NilError(t, err)
+		_, err = client.Quota().Create(test.limit)
+		assert.NilError(t, err)
+
+		service, err := client.Service().Create(ServiceSpec{
+			SessionID:             "chrome-85-0-1",
+			RequestedCapabilities: test.caps,
+			Template:              BrowserSpec{BrowserName: "chrome", BrowserVersion: "85.0", Image: "selenoid/vnc:chrome_85.0", Path: "/"},
+		})
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			srv.Close()
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, "127.0.0.1:4445", service.URL.Host)
+		assert.Equal(t, Running, service.Status)
+		assert.Equal(t, "chrome", service.Labels["browserName"])
+		assert.DeepEqual(t, []string{"alcounit/seleniferous"}, daemon.pulled)
+
+		sidecar := daemon.created["chrome-85-0-1-seleniferous"]
+		assert.Equal(t, "container:chrome-85-0-1", sidecar.HostConfig.NetworkMode)
+		assert.DeepEqual(t, []string{"/seleniferous", "--listhen-port", "4445", "--proxy-default-path", "/session", "--idle-timeout", "1m0s"}, sidecar.Cmd)
+		assert.Equal(t, "selenosis", daemon.created["chrome-85-0-1"].HostConfig.NetworkMode)
+
+		state, err := client.State()
+		assert.NilError(t, err)
+		assert.Equal(t, 1, len(state.Services))
+		assert.Equal(t, "chrome-85-0-1", state.Services[0].SessionID)
+
+		assert.NilError(t, client.Service().Delete("chrome-85-0-1"))
+		assert.Equal(t, 0, len(daemon.created))
+		srv.Close()
+	}
+}
+
+func TestDemuxLogs(t *testing.T) {
+	var stream bytes.Buffer
+	for _, frame := range []struct {
+		kind byte
+		text string
+	}{{1, "started\n"}, {2, "warning\n"}} {
+		stream.Write([]byte{frame.kind, 0, 0, 0, 0, 0, 0, byte(len(frame.text))})
+		stream.WriteString(frame.text)
+	}
+
+	logs, err := ioutil.ReadAll(demuxLogs(ioutil.NopCloser(&stream)))
+	assert.NilError(t, err)
+	assert.Equal(t, "started\nwarning\n", string(logs))
+}
+
+//dockerMock serves Docker Engine API endpoints used by docker platform, containers are running once created
+type dockerMock struct {
+	sync.Mutex
+	created map[string]dockerContainerSpec
+	pulled  []string
+}
+
+func newDockerMock(running int) *dockerMock {
+	m := &dockerMock{created: make(map[string]dockerContainerSpec)}
+	for i := 0; i < running; i++ {
+		m.created[fmt.Sprintf("firefox-%d", i)] = dockerContainerSpec{Labels: map[string]string{label: "browser", "session": fmt.Sprintf("firefox-%d", i)}}
+	}
+	return m
+}
+
+func (m *dockerMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.Lock()
+	defer m.Unlock()
+
+	switch {
+	case r.URL.Path == "/_ping":
+	case r.URL.Path == "/containers/json":
+		var filters map[string][]string
+		json.Unmarshal([]byte(r.URL.Query().Get("filters")), &filters)
+		containers := []map[string]interface{}{}
+		for name, spec := range m.created {
+			if !matchLabels(spec.Labels, filters["label"]) {
+				continue
+			}
+			containers = append(containers, map[string]interface{}{
+				"Id":              name,
+				"Labels":          spec.Labels,
+				"State":           "running",
+				"Created":         time.Now().Unix(),
+				"NetworkSettings": map[string]interface{}{"Networks": map[string]interface{}{"selenosis": map[string]string{"IPAddress": "127.0.0.1"}}},
+			})
+		}
+		json.NewEncoder(w).Encode(containers)
+	case strings.HasPrefix(r.URL.Path, "/images/create"):
+		m.pulled = append(m.pulled, r.URL.Query().Get("fromImage"))
+		w.Write([]byte(`{"status":"Pulling"}` + "\n" + `{"status":"Downloaded"}`))
+	case strings.HasPrefix(r.URL.Path, "/images/"):
+		if strings.Contains(r.URL.Path, "seleniferous") {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"no such image"}`))
+		}
+	case r.URL.Path == "/containers/create":
+		var spec dockerContainerSpec
+		json.NewDecoder(r.Body).Decode(&spec)
+		name := r.URL.Query().Get("name")
+		m.created[name] = spec
+		json.NewEncoder(w).Encode(map[string]string{"Id": name})
+	case strings.HasSuffix(r.URL.Path, "/start"):
+	case r.Method == http.MethodDelete:
+		name := strings.TrimPrefix(r.URL.Path, "/containers/")
+		if _, ok := m.created[name]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"no such container"}`))
+			return
+		}
+		delete(m.created, name)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func matchLabels(labels map[string]string, filters []string) bool {
+	for _, f := range filters {
+		parts := strings.SplitN(f, "=", 2)
+		value, ok := labels[parts[0]]
+		if !ok || len(parts) == 2 && value != parts[1] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	//extensionsAnnotation counts extensions of the session idle timeout
+	extensionsAnnotation = "selenosis.app.extensions"
+	//extendedAnnotation keeps time of the last extension, seleniferous restarts idle countdown when it changes
+	extendedAnnotation = "selenosis.app.extendedAt"
+)
+
+var (
+	//ErrExtendNotSupported is returned by platforms not able to extend sessions
+	ErrExtendNotSupported = errors.New("session extension is not supported by the platform")
+	//ErrExtensionsExhausted is returned when session was already extended max times
+	ErrExtensionsExhausted = errors.New("session can not be extended anymore")
+)
+
+//SessionExtension describes extended session
+type SessionExtension struct {
+	SessionID  string    `json:"sessionId"`
+	Extensions int       `json:"extensions"`
+	ExtendedAt time.Time `json:"extendedAt"`
+}
+
+//Extender is implemented by platforms able to restart idle countdown of running sessions, at most max
+//extensions are allowed for a session, zero max doesn't limit extensions
+type Extender interface {
+	Extend(sessionID string, max int) (SessionExtension, error)
+}
+
+//Extend records extension of the session in annotations of browser pod, so every replica and seleniferous
+//sidecar of the session see it
+func (cl *Client) Extend(sessionID string, max int) (SessionExtension, error) {
+	s, ok := cl.service.(*service)
+	if !ok {
+		return SessionExtension{}, ErrExtendNotSupported
+	}
+	return s.extend(sessionID, max)
+}
+
+func (cl *service) extend(sessionID string, max int) (SessionExtension, error) {
+	ctx := context.Background()
+	pods := cl.clientset.CoreV1().Pods(cl.ns)
+	podName := cl.podName(sessionID)
+
+	extension := SessionExtension{SessionID: sessionID}
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pod, err := pods.Get(ctx, podName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if pod.Status.Phase != apiv1.PodRunning {
+			return fmt.Errorf("pod is %s, only running session can be extended", pod.Status.Phase)
+		}
+		extensions, _ := strconv.Atoi(pod.Annotations[extensionsAnnotation])
+		if max > 0 && extensions >= max {
+			return ErrExtensionsExhausted
+		}
+
+		extension.Extensions, extension.ExtendedAt = extensions+1, time.Now().UTC().Truncate(time.Second)
+		annotations := make(map[string]string, len(pod.Annotations)+2)
+		for k, v := range pod.Annotations {
+			annotations[k] = v
+		}
+		annotations[extensionsAnnotation] = strconv.Itoa(extension.Extensions)
+		annotations[extendedAnnotation] = extension.ExtendedAt.Format(time.RFC3339)
+		pod.Annotations = annotations
+		_, err = pods.Update(ctx, pod, metav1.UpdateOptions{})
+		return err
+	})
+	switch {
+	case apierrors.IsNotFound(err):
+		return SessionExtension{}, ErrSessionNotFound
+	case err == ErrExtensionsExhausted:
+		return SessionExtension{}, err
+	case err != nil:
+		return SessionExtension{}, fmt.Errorf("failed to extend session: %v", err)
+	}
+	return extension, nil
+}
+
+//extendedAt returns time of the last extension of the session from pod annotations
+func extendedAt(annotations map[string]string) time.Time {
+	t, err := time.Parse(time.RFC3339, annotations[extendedAnnotation])
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,77 @@
+package platform
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestExtend(t *testing.T) {
+	tests := map[string]struct {
+		pod        *apiv1.Pod
+		max        int
+		extensions int
+		err        string
+	}{
+		"Verify first extension is recorded on browser pod": {
+			pod: &apiv1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "chrome-85-0", Namespace: "selenosis"},
+				Status:     apiv1.PodStatus{Phase: apiv1.PodRunning},
+			},
+			max:        3,
+			extensions: 1,
+		},
+		"Verify extensions are counted": {
+			pod: &apiv1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "chrome-85-0", Namespace: "selenosis", Annotations: map[string]string{extensionsAnnotation: "4"}},
+				Status:     apiv1.PodStatus{Phase: apiv1.PodRunning},
+			},
+			extensions: 5,
+		},
+		"Verify extension above max is rejected": {
+			pod: &apiv1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "chrome-85-0", Namespace: "selenosis", Annotations: map[string]string{extensionsAnnotation: "3"}},
+				Status:     apiv1.PodStatus{Phase: apiv1.PodRunning},
+			},
+			max: 3,
+			err: "session can not be extended anymore",
+		},
+		"Verify pending session is not extended": {
+			pod: &apiv1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "chrome-85-0", Namespace: "selenosis"},
+				Status:     apiv1.PodStatus{Phase: apiv1.PodPending},
+			},
+			err: "failed to extend session: pod is Pending, only running session can be extended",
+		},
+		"Verify extension of unknown pod returns session not found": {
+			pod: &apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "firefox-82-0", Namespace: "selenosis"}},
+			err: "session not found",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		mock := fake.NewSimpleClientset(test.pod)
+		client := &Client{service: &service{ns: "selenosis", clientset: mock}}
+
+		extension, err := client.Extend("chrome-85-0", test.max)
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, test.extensions, extension.Extensions)
+
+		pod, err := mock.CoreV1().Pods("selenosis").Get(context.Background(), "chrome-85-0", metav1.GetOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, extension.ExtendedAt, extendedAt(pod.Annotations))
+
+		service := (&Client{}).podService(pod, Running)
+		assert.Equal(t, extension.ExtendedAt, service.Extended)
+	}
+}
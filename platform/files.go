@@ -0,0 +1,143 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/alcounit/selenosis/selenium"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	filesVolumeName       = "session-files"
+	filesSourceVolumeName = "session-files-source"
+	filesSourcePath       = "/session-files-source"
+	defaultFilesPath      = "/home/selenium/Downloads"
+	//filesScript downloads url of every name and url argument pair to files directory passed as $0, then copies
+	//files of ConfigMaps mounted to source directory, hidden entries of ConfigMap volumes are skipped
+	filesScript = `set -e; cd "$0"; while [ $# -gt 0 ]; do curl -fsSL --retry 3 -o "$1" "$2"; shift 2; done; ` +
+		`for f in ` + filesSourcePath + `/*/*; do [ -e "$f" ] && cp -L "$f" .; done; true`
+)
+
+//FilesDir returns directory files requested with selenosis:options capability are put to
+func (b BrowserSpec) FilesDir() string {
+	if b.FilesPath != "" {
+		return b.FilesPath
+	}
+	return defaultFilesPath
+}
+
+//validateFiles checks files requested with capabilities, downloaded files are named by the last element of
+//URL path unless name is set
+func validateFiles(files []selenium.SessionFile) error {
+	for _, file := range files {
+		switch {
+		case file.URL != "" && file.ConfigMap != "":
+			return errors.New("url and configMap of session file can not be requested together")
+		case file.ConfigMap != "":
+			continue
+		case file.URL == "":
+			return errors.New("session file should have url or configMap")
+		}
+		u, err := url.Parse(file.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid session file url %s", file.URL)
+		}
+		if name := fileName(file); name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+			return fmt.Errorf("invalid name of session file %s", file.URL)
+		}
+	}
+	return nil
+}
+
+//fileName returns name of downloaded session file
+func fileName(file selenium.SessionFile) string {
+	if file.Name != "" {
+		return file.Name
+	}
+	u, err := url.Parse(file.URL)
+	if err != nil || u.Path == "" {
+		return ""
+	}
+	return path.Base(u.Path)
+}
+
+//filesVolume puts files requested with capabilities to files directory of browser container by init container.
+//Volume already mounted to the directory, e.g. workspace, is filled, empty dir is mounted otherwise
+func (cl *service) filesVolume(pod *apiv1.Pod, layout ServiceSpec) error {
+	files := layout.RequestedCapabilities.GetFiles()
+	if len(files) == 0 {
+		return nil
+	}
+	if cl.filesImage == "" {
+		return errors.New("session files are not configured")
+	}
+	if layout.Template.Platform == WindowsPlatform {
+		return errors.New("session files are not supported for windows browsers")
+	}
+	if err := validateFiles(files); err != nil {
+		return err
+	}
+	dir := layout.Template.FilesDir()
+
+	args := []string{dir}
+	var mounts []apiv1.VolumeMount
+	for i, file := range files {
+		if file.ConfigMap == "" {
+			args = append(args, fileName(file), file.URL)
+			continue
+		}
+		name := fmt.Sprintf("%s-%d", filesSourceVolumeName, i)
+		mounts = append(mounts, apiv1.VolumeMount{Name: name, MountPath: fmt.Sprintf("%s/%d", filesSourcePath, i), ReadOnly: true})
+		pod.Spec.Volumes = append(pod.Spec.Volumes, apiv1.Volume{
+			Name: name,
+			VolumeSource: apiv1.VolumeSource{
+				ConfigMap: &apiv1.ConfigMapVolumeSource{LocalObjectReference: apiv1.LocalObjectReference{Name: file.ConfigMap}},
+			},
+		})
+	}
+
+	browser := &pod.Spec.Containers[0]
+	volume := ""
+	for _, mount := range browser.VolumeMounts {
+		if path.Clean(mount.MountPath) == path.Clean(dir) {
+			volume = mount.Name
+		}
+	}
+	if volume == "" {
+		volume = filesVolumeName
+		browser.VolumeMounts = append(browser.VolumeMounts, apiv1.VolumeMount{Name: volume, MountPath: dir})
+		pod.Spec.Volumes = append(pod.Spec.Volumes, apiv1.Volume{Name: volume, VolumeSource: apiv1.VolumeSource{EmptyDir: &apiv1.EmptyDirVolumeSource{}}})
+	}
+
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, apiv1.Container{
+		Name:            "files",
+		Image:           cl.filesImage,
+		Command:         []string{"sh", "-c", filesScript},
+		Args:            args,
+		VolumeMounts:    append([]apiv1.VolumeMount{{Name: volume, MountPath: dir}}, mounts...),
+		SecurityContext: browser.SecurityContext.DeepCopy(),
+		ImagePullPolicy: apiv1.PullIfNotPresent,
+	})
+	return nil
+}
+
+//ensureFiles checks that ConfigMaps with requested session files exist in session namespace,
+//otherwise browser pod would wait for the volume until pending timeout
+func (cl *service) ensureFiles(layout ServiceSpec) error {
+	context := context.Background()
+	for _, file := range layout.RequestedCapabilities.GetFiles() {
+		if file.ConfigMap == "" {
+			continue
+		}
+		if _, err := cl.clientset.CoreV1().ConfigMaps(cl.ns).Get(context, file.ConfigMap, metav1.GetOptions{}); err != nil {
+			return fmt.Errorf("failed to get session files config map %s: %v", file.ConfigMap, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package platform
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/alcounit/selenosis/selenium"
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestBuildPodFiles(t *testing.T) {
+	tests := map[string]struct {
+		options   string
+		workspace bool
+		template  BrowserSpec
+		image     string
+		args      []string
+		volume    string
+		sources   []string
+		err       string
+	}{
+		"Verify files are downloaded to downloads directory": {
+			options: `{"files": ["https://fixtures.example.com/files/invoice.pdf", {"url": "https://fixtures.example.com/export?id=42", "name": "export.csv"}]}`,
+			image:   "curlimages/curl:7.73.0",
+			args:    []string{"/home/selenium/Downloads", "invoice.pdf", "https://fixtures.example.com/files/invoice.pdf", "export.csv", "https://fixtures.example.com/export?id=42"},
+			volume:  filesVolumeName,
+		},
+		"Verify config map files are copied to files path of browser": {
+			options:  `{"files": [{"configMap": "upload-fixtures"}]}`,
+			template: BrowserSpec{FilesPath: "/tmp/fixtures"},
+			image:    "curlimages/curl:7.73.0",
+			args:     []string{"/tmp/fixtures"},
+			volume:   filesVolumeName,
+			sources:  []string{"upload-fixtures"},
+		},
+		"Verify files are put to workspace mounted to the directory": {
+			options:   `{"files": [{"configMap": "upload-fixtures"}]}`,
+			workspace: true,
+			template:  BrowserSpec{Workspace: &WorkspaceSpec{Size: resource.MustParse("1Gi")}},
+			image:     "curlimages/curl:7.73.0",
+			args:      []string{"/home/selenium/Downloads"},
+			volume:    workspaceVolumeName,
+			sources:   []string{"upload-fixtures"},
+		},
+		"Verify files are rejected if files image is not set": {
+			options: `{"files": ["https://fixtures.example.com/invoice.pdf"]}`,
+			err:     "session files are not configured",
+		},
+		"Verify file url without name is rejected": {
+			options: `{"files": ["https://fixtures.example.com/"]}`,
+			image:   "curlimages/curl:7.73.0",
+			err:     "invalid name of session file https://fixtures.example.com/",
+		},
+		"Verify file url of unsupported scheme is rejected": {
+			options: `{"files": ["file:///etc/passwd"]}`,
+			image:   "curlimages/curl:7.73.0",
+			err:     "invalid session file url file:///etc/passwd",
+		},
+		"Verify file of url and config map is rejected": {
+			options: `{"files": [{"url": "https://fixtures.example.com/invoice.pdf", "configMap": "upload-fixtures"}]}`,
+			image:   "curlimages/curl:7.73.0",
+			err:     "url and configMap of session file can not be requested together",
+		},
+		"Verify files are not supported for windows browsers": {
+			options:  `{"files": ["https://fixtures.example.com/invoice.pdf"]}`,
+			template: BrowserSpec{Platform: WindowsPlatform},
+			image:    "curlimages/curl:7.73.0",
+			err:      "session files are not supported for windows browsers",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		var options selenium.SelenosisOptions
+		assert.NilError(t, json.Unmarshal([]byte(test.options), &options))
+
+		cl := &service{
+			svcPort:           intstr.FromString("4445"),
+			proxyImage:        "alcounit/seleniferous:latest",
+			windowsProxyImage: "alcounit/seleniferous:windows",
+			filesImage:        test.image,
+		}
+		template := test.template
+		template.BrowserName, template.Image = "chrome", "selenoid/vnc:chrome_85.0"
+
+		pod, err := cl.buildPod(ServiceSpec{
+			SessionID:             "session",
+			RequestedCapabilities: selenium.Capabilities{Workspace: test.workspace, Options: &options},
+			Template:              template,
+		})
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+
+		assert.Equal(t, 1, len(pod.Spec.InitContainers))
+		init := pod.Spec.InitContainers[0]
+		assert.Equal(t, test.image, init.Image)
+		assert.DeepEqual(t, []string{"sh", "-c", filesScript}, init.Command)
+		assert.DeepEqual(t, test.args, init.Args)
+		assert.DeepEqual(t, apiv1.VolumeMount{Name: test.volume, MountPath: test.args[0]}, init.VolumeMounts[0])
+		assert.Equal(t, len(test.sources)+1, len(init.VolumeMounts))
+
+		mounted := 0
+		for _, mount := range pod.Spec.Containers[0].VolumeMounts {
+			if mount.MountPath == test.args[0] {
+				assert.Equal(t, test.volume, mount.Name)
+				mounted++
+			}
+		}
+		assert.Equal(t, 1, mounted)
+
+		var sources []string
+		for _, volume := range pod.Spec.Volumes {
+			if volume.ConfigMap != nil {
+				sources = append(sources, volume.ConfigMap.Name)
+			}
+		}
+		assert.DeepEqual(t, test.sources, sources)
+	}
+}
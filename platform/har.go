@@ -0,0 +1,47 @@
+package platform
+
+import (
+	"errors"
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	harContainerName = "har-recorder"
+	harAnnotation    = "har"
+	//HARProxyPort is port of recording proxy browser of the session is configured to use
+	HARProxyPort = 9090
+	//HARPort is port recording proxy serves network archive of the session on, archive is served at /har
+	HARPort = 9091
+)
+
+//harContainer returns recording proxy container of the session requested with captureHAR option, proxy
+//shares network of the pod, so browser reaches it on localhost
+func (cl *service) harContainer(layout ServiceSpec) (apiv1.Container, error) {
+	if cl.harImage == "" {
+		return apiv1.Container{}, errors.New("HAR capture is not configured")
+	}
+	if layout.Template.Platform == WindowsPlatform {
+		return apiv1.Container{}, errors.New("HAR capture is not supported for windows browsers")
+	}
+	return apiv1.Container{
+		Name:  harContainerName,
+		Image: cl.harImage,
+		Env: []apiv1.EnvVar{
+			{Name: "PROXY_PORT", Value: fmt.Sprint(HARProxyPort)},
+			{Name: "HAR_PORT", Value: fmt.Sprint(HARPort)},
+			{Name: "SESSION_ID", Value: layout.SessionID},
+		},
+		Ports: []apiv1.ContainerPort{
+			{Name: "har-proxy", ContainerPort: HARProxyPort},
+			{Name: "har", ContainerPort: HARPort},
+		},
+		ImagePullPolicy: apiv1.PullIfNotPresent,
+	}, nil
+}
+
+//getHAR reports if network archive of the session is recorded from pod annotations
+func getHAR(annotations map[string]string) bool {
+	return annotations[harAnnotation] == "true"
+}
@@ -0,0 +1,58 @@
+package platform
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alcounit/selenosis/selenium"
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestBuildPodHAR(t *testing.T) {
+	tests := map[string]struct {
+		image    string
+		platform string
+		err      error
+	}{
+		"Verify recording proxy is added to pod": {
+			image: "acme/har-recorder:1.0",
+		},
+		"Verify HAR capture is rejected without recorder image": {
+			err: errors.New("HAR capture is not configured"),
+		},
+		"Verify HAR capture is rejected for windows browsers": {
+			image:    "acme/har-recorder:1.0",
+			platform: WindowsPlatform,
+			err:      errors.New("HAR capture is not supported for windows browsers"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		cl := &service{
+			svcPort:           intstr.FromString("4445"),
+			proxyImage:        "alcounit/seleniferous:latest",
+			windowsProxyImage: "alcounit/seleniferous:windows",
+			harImage:          test.image,
+		}
+		pod, err := cl.buildPod(ServiceSpec{
+			SessionID:             "session",
+			RequestedCapabilities: selenium.Capabilities{Options: &selenium.SelenosisOptions{CaptureHAR: true}},
+			Template:              BrowserSpec{Image: "selenoid/vnc:chrome_85.0", Platform: test.platform},
+		})
+		if test.err != nil {
+			assert.Error(t, err, test.err.Error())
+			continue
+		}
+		assert.NilError(t, err)
+
+		recorder := pod.Spec.Containers[len(pod.Spec.Containers)-1]
+		assert.Equal(t, harContainerName, recorder.Name)
+		assert.Equal(t, test.image, recorder.Image)
+		assert.Equal(t, int32(HARProxyPort), recorder.Ports[0].ContainerPort)
+		assert.Equal(t, int32(HARPort), recorder.Ports[1].ContainerPort)
+		assert.Equal(t, true, getHAR(pod.Annotations))
+	}
+}
@@ -0,0 +1,243 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	//imageWarmupName is name of DaemonSet pulling images onto all linux nodes, DaemonSets of images
+	//built for one architecture have the architecture appended
+	imageWarmupName = "selenosis-image-warmup"
+	//imageWarmupLabel marks DaemonSets and pods pulling images, value is name of the DaemonSet
+	imageWarmupLabel = "selenosis.app.imageWarmup"
+)
+
+//ErrImageWarmupNotSupported is returned by platforms not running browsers on cluster nodes
+var ErrImageWarmupNotSupported = errors.New("image warmup is not supported by the platform")
+
+//imageWarmupResources are requested by every container of image warmup pods, so they fit any node
+var imageWarmupResources = apiv1.ResourceRequirements{
+	Requests: apiv1.ResourceList{
+		apiv1.ResourceCPU:    resource.MustParse("10m"),
+		apiv1.ResourceMemory: resource.MustParse("16Mi"),
+	},
+}
+
+//ImageWarmup is DaemonSet pulling images onto nodes of the architecture, onto all linux nodes when
+//architecture is not set. Node has all images pulled once its pod is ready
+type ImageWarmup struct {
+	Name    string   `json:"name"`
+	Arch    string   `json:"arch,omitempty"`
+	Images  []string `json:"images"`
+	Nodes   int32    `json:"nodes"`
+	Updated int32    `json:"updated"`
+	Ready   int32    `json:"ready"`
+}
+
+//ImagePuller is implemented by platforms able to pull browser images onto nodes ahead of sessions
+type ImagePuller interface {
+	WarmupImages(templates []BrowserSpec) ([]ImageWarmup, error)
+	ImageWarmups() ([]ImageWarmup, error)
+}
+
+//WarmupImages creates or updates DaemonSets pulling images of templates, their sidecars, proxy and video
+//images onto every node, DaemonSets of architectures no template needs anymore are deleted
+func (cl *Client) WarmupImages(templates []BrowserSpec) ([]ImageWarmup, error) {
+	s, ok := cl.service.(*service)
+	if !ok {
+		return nil, ErrImageWarmupNotSupported
+	}
+	if err := s.warmupImages(templates); err != nil {
+		return nil, err
+	}
+	return s.imageWarmups()
+}
+
+//ImageWarmups returns state of DaemonSets pulling images
+func (cl *Client) ImageWarmups() ([]ImageWarmup, error) {
+	s, ok := cl.service.(*service)
+	if !ok {
+		return nil, ErrImageWarmupNotSupported
+	}
+	return s.imageWarmups()
+}
+
+//warmupImageSet returns images to pull by architecture, images of windows and relayed browsers are skipped
+func (cl *service) warmupImageSet(templates []BrowserSpec) map[string][]string {
+	set := make(map[string]map[string]struct{})
+	add := func(arch, image string) {
+		if image == "" {
+			return
+		}
+		if set[arch] == nil {
+			set[arch] = make(map[string]struct{})
+		}
+		set[arch][image] = struct{}{}
+	}
+
+	for _, template := range templates {
+		if template.Relay != "" || template.Platform == WindowsPlatform {
+			continue
+		}
+		arch := template.Spec.NodeSelector[archLabel]
+		switch {
+		case arch != "":
+			if spec, err := template.ForArch(arch); err == nil {
+				add(arch, spec.Image)
+			}
+		case len(template.Images) > 0:
+			for a, image := range template.Images {
+				add(a, image)
+			}
+			add("", template.Image)
+		default:
+			add("", template.Image)
+		}
+		for _, sidecar := range template.Spec.Sidecars {
+			add(arch, sidecar.Image)
+		}
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	for _, image := range []string{cl.proxyImage, cl.videoImage, cl.videoUploaderImage} {
+		add("", image)
+	}
+
+	images := make(map[string][]string, len(set))
+	for arch, list := range set {
+		for image := range list {
+			images[arch] = append(images[arch], image)
+		}
+		sort.Strings(images[arch])
+	}
+	return images
+}
+
+func (cl *service) warmupImages(templates []BrowserSpec) error {
+	ctx := context.Background()
+	daemonSets := cl.clientset.AppsV1().DaemonSets(cl.ns)
+
+	wanted := make(map[string]struct{})
+	for arch, images := range cl.warmupImageSet(templates) {
+		ds := cl.imageWarmupDaemonSet(arch, images)
+		wanted[ds.Name] = struct{}{}
+
+		current, err := daemonSets.Get(ctx, ds.Name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			if _, err := daemonSets.Create(ctx, ds, metav1.CreateOptions{}); err != nil {
+				return fmt.Errorf("failed to create %s daemonset: %v", ds.Name, err)
+			}
+		case err != nil:
+			return fmt.Errorf("failed to get %s daemonset: %v", ds.Name, err)
+		default:
+			current.Labels = ds.Labels
+			current.Spec.Template = ds.Spec.Template
+			if _, err := daemonSets.Update(ctx, current, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("failed to update %s daemonset: %v", ds.Name, err)
+			}
+		}
+	}
+
+	list, err := daemonSets.List(ctx, metav1.ListOptions{LabelSelector: imageWarmupLabel})
+	if err != nil {
+		return fmt.Errorf("failed to list image warmup daemonsets: %v", err)
+	}
+	for _, ds := range list.Items {
+		if _, ok := wanted[ds.Name]; ok {
+			continue
+		}
+		if err := daemonSets.Delete(ctx, ds.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %s daemonset: %v", ds.Name, err)
+		}
+	}
+	return nil
+}
+
+//imageWarmupDaemonSet returns DaemonSet pulling images in init containers, init container exits right away,
+//so image needs sh, main container only keeps pod running and the DaemonSet is not restarting pods
+func (cl *service) imageWarmupDaemonSet(arch string, images []string) *appsv1.DaemonSet {
+	name := imageWarmupName
+	selector := map[string]string{osLabel: LinuxPlatform}
+	if arch != "" {
+		name = imageWarmupName + "-" + arch
+		selector[archLabel] = arch
+	}
+	labels := map[string]string{imageWarmupLabel: name}
+
+	var initContainers []apiv1.Container
+	for i, image := range images {
+		initContainers = append(initContainers, apiv1.Container{
+			Name:            fmt.Sprintf("image-%d", i),
+			Image:           image,
+			ImagePullPolicy: apiv1.PullIfNotPresent,
+			Command:         []string{"sh", "-c", "exit 0"},
+			Resources:       imageWarmupResources,
+		})
+	}
+
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: apiv1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: apiv1.PodSpec{
+					NodeSelector:                  selector,
+					Tolerations:                   []apiv1.Toleration{{Operator: apiv1.TolerationOpExists}},
+					ImagePullSecrets:              getImagePullSecretList(cl.imagePullSecretName),
+					InitContainers:                initContainers,
+					TerminationGracePeriodSeconds: pointer.Int64Ptr(0),
+					Containers: []apiv1.Container{
+						{
+							Name:      "pause",
+							Image:     cl.warmupPauseImage,
+							Resources: imageWarmupResources,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (cl *service) imageWarmups() ([]ImageWarmup, error) {
+	list, err := cl.clientset.AppsV1().DaemonSets(cl.ns).List(context.Background(), metav1.ListOptions{LabelSelector: imageWarmupLabel})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list image warmup daemonsets: %v", err)
+	}
+
+	warmups := make([]ImageWarmup, 0, len(list.Items))
+	for _, ds := range list.Items {
+		warmup := ImageWarmup{
+			Name:    ds.Name,
+			Arch:    ds.Spec.Template.Spec.NodeSelector[archLabel],
+			Images:  make([]string, 0, len(ds.Spec.Template.Spec.InitContainers)),
+			Nodes:   ds.Status.DesiredNumberScheduled,
+			Updated: ds.Status.UpdatedNumberScheduled,
+			Ready:   ds.Status.NumberReady,
+		}
+		for _, c := range ds.Spec.Template.Spec.InitContainers {
+			warmup.Images = append(warmup.Images, c.Image)
+		}
+		warmups = append(warmups, warmup)
+	}
+	sort.Slice(warmups, func(i, j int) bool {
+		return warmups[i].Name < warmups[j].Name
+	})
+	return warmups, nil
+}
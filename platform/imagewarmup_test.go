@@ -0,0 +1,67 @@
+package platform
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWarmupImages(t *testing.T) {
+	mock := fake.NewSimpleClientset(&appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{
+		Name:      "selenosis-image-warmup-s390x",
+		Namespace: "selenosis",
+		Labels:    map[string]string{imageWarmupLabel: "selenosis-image-warmup-s390x"},
+	}})
+	client := &Client{
+		ns:        "selenosis",
+		clientset: mock,
+		service: &service{
+			ns:               "selenosis",
+			clientset:        mock,
+			proxyImage:       "alcounit/seleniferous:latest",
+			warmupPauseImage: "registry.k8s.io/pause:3.9",
+		},
+	}
+
+	templates := []BrowserSpec{
+		{
+			BrowserName: "chrome", BrowserVersion: "86.0", Image: "selenoid/vnc:chrome_86.0",
+			Spec: Spec{Sidecars: []apiv1.Container{{Name: "proxy", Image: "envoyproxy/envoy:v1.18"}}},
+		},
+		{BrowserName: "chrome", BrowserVersion: "87.0", Image: "selenoid/vnc:chrome_87.0", Images: map[string]string{"arm64": "seleniarm/chromium:87.0"}},
+		{BrowserName: "firefox", BrowserVersion: "82.0", Image: "seleniarm/firefox:82.0", Spec: Spec{NodeSelector: map[string]string{archLabel: "arm64"}}},
+		{BrowserName: "MicrosoftEdge", BrowserVersion: "91.0", Image: "edge:windows", Platform: WindowsPlatform},
+		{BrowserName: "safari", BrowserVersion: "14.0", Relay: "http://saucelabs.example.com/wd/hub"},
+	}
+
+	warmups, err := client.WarmupImages(templates)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []ImageWarmup{
+		{
+			Name:   "selenosis-image-warmup",
+			Images: []string{"alcounit/seleniferous:latest", "envoyproxy/envoy:v1.18", "selenoid/vnc:chrome_86.0", "selenoid/vnc:chrome_87.0"},
+		},
+		{
+			Name:   "selenosis-image-warmup-arm64",
+			Arch:   "arm64",
+			Images: []string{"seleniarm/chromium:87.0", "seleniarm/firefox:82.0"},
+		},
+	}, warmups)
+
+	ds, err := mock.AppsV1().DaemonSets("selenosis").Get(context.Background(), "selenosis-image-warmup-arm64", metav1.GetOptions{})
+	assert.NilError(t, err)
+	spec := ds.Spec.Template.Spec
+	assert.DeepEqual(t, map[string]string{osLabel: LinuxPlatform, archLabel: "arm64"}, spec.NodeSelector)
+	assert.Equal(t, "registry.k8s.io/pause:3.9", spec.Containers[0].Image)
+	assert.DeepEqual(t, []string{"sh", "-c", "exit 0"}, spec.InitContainers[0].Command)
+
+	warmups, err = client.WarmupImages(templates[:1])
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(warmups))
+	assert.DeepEqual(t, []string{"alcounit/seleniferous:latest", "envoyproxy/envoy:v1.18", "selenoid/vnc:chrome_86.0"}, warmups[0].Images)
+}
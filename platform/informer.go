@@ -0,0 +1,152 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+//cacheSyncTimeout limits wait for the initial list of pods
+var cacheSyncTimeout = time.Minute
+
+//podEvent is change of the watched pod, pod is its last known state
+type podEvent struct {
+	pod     *apiv1.Pod
+	deleted bool
+}
+
+//podCache keeps selenosis pods of the namespace in memory, pods are listed and watched by single shared
+//informer, so state is read without API calls and creating sessions don't open a watch per pod
+type podCache struct {
+	ns       string
+	factory  informers.SharedInformerFactory
+	informer cache.SharedIndexInformer
+	lister   listersv1.PodLister
+	stop     chan struct{}
+
+	lock     sync.Mutex
+	watchers map[string]map[chan podEvent]struct{}
+}
+
+func newPodCache(clientset kubernetes.Interface, ns string) *podCache {
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, 30*time.Second,
+		informers.WithNamespace(ns),
+		informers.WithTweakListOptions(func(list *metav1.ListOptions) {
+			list.LabelSelector = label
+		}),
+	)
+	pods := factory.Core().V1().Pods()
+	c := &podCache{
+		ns:       ns,
+		factory:  factory,
+		informer: pods.Informer(),
+		lister:   pods.Lister(),
+		stop:     make(chan struct{}),
+		watchers: make(map[string]map[chan podEvent]struct{}),
+	}
+	c.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.notify(obj, false)
+		},
+		UpdateFunc: func(old interface{}, new interface{}) {
+			c.notify(new, false)
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.notify(obj, true)
+		},
+	})
+	return c
+}
+
+//start starts informers registered in the factory and waits for pods to be listed, it is safe to call
+//start many times, informers are started once
+func (c *podCache) start() error {
+	c.factory.Start(c.stop)
+	if c.informer.HasSynced() {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cacheSyncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(ctx.Done(), c.informer.HasSynced) {
+		return errors.New("timed out waiting for pods cache to sync")
+	}
+	return nil
+}
+
+//get returns cached pod by name
+func (c *podCache) get(name string) (*apiv1.Pod, bool) {
+	pod, err := c.lister.Pods(c.ns).Get(name)
+	if err != nil {
+		return nil, false
+	}
+	return pod, true
+}
+
+//list returns cached pods sorted by name
+func (c *podCache) list() ([]*apiv1.Pod, error) {
+	pods, err := c.lister.Pods(c.ns).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
+	return pods, nil
+}
+
+//watch returns changes of the pod, only the last change is kept for slow receiver, so receiver always
+//sees the current state of the pod. Pods of session jobs are watched by session. Returned function stops the watch
+func (c *podCache) watch(name string) (<-chan podEvent, func()) {
+	ch := make(chan podEvent, 1)
+	c.lock.Lock()
+	if c.watchers[name] == nil {
+		c.watchers[name] = make(map[chan podEvent]struct{})
+	}
+	c.watchers[name][ch] = struct{}{}
+	c.lock.Unlock()
+
+	return ch, func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		delete(c.watchers[name], ch)
+		if len(c.watchers[name]) == 0 {
+			delete(c.watchers, name)
+		}
+	}
+}
+
+func (c *podCache) notify(obj interface{}, deleted bool) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	pod, ok := obj.(*apiv1.Pod)
+	if !ok {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	names := []string{pod.Name}
+	if session := podSession(pod); session != pod.Name {
+		names = append(names, session)
+	}
+	for _, name := range names {
+		for ch := range c.watchers[name] {
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- podEvent{pod: pod, deleted: deleted}
+		}
+	}
+}
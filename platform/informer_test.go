@@ -0,0 +1,68 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPodCache(t *testing.T) {
+	mock := fake.NewSimpleClientset()
+	for _, name := range []string{"firefox-45-0-1", "chrome-85-0-1"} {
+		_, err := mock.CoreV1().Pods("selenosis").Create(context.Background(), &apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "selenosis", Labels: map[string]string{label: "browser"}},
+			Status:     apiv1.PodStatus{Phase: apiv1.PodPending},
+		}, metav1.CreateOptions{})
+		assert.NilError(t, err)
+	}
+
+	pods := newPodCache(mock, "selenosis")
+	assert.NilError(t, pods.start())
+
+	list, err := pods.list()
+	assert.NilError(t, err)
+	assert.Equal(t, 2, len(list))
+	assert.Equal(t, "chrome-85-0-1", list[0].Name)
+	assert.Equal(t, "firefox-45-0-1", list[1].Name)
+
+	events, stop := pods.watch("chrome-85-0-1")
+	defer stop()
+
+	pod, ok := pods.get("chrome-85-0-1")
+	assert.Assert(t, ok)
+	for _, phase := range []apiv1.PodPhase{apiv1.PodRunning, apiv1.PodFailed} {
+		pod = pod.DeepCopy()
+		pod.Status.Phase = phase
+		_, err = mock.CoreV1().Pods("selenosis").UpdateStatus(context.Background(), pod, metav1.UpdateOptions{})
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, mock.CoreV1().Pods("selenosis").Delete(context.Background(), "firefox-45-0-1", metav1.DeleteOptions{}))
+
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case event := <-events:
+			assert.Assert(t, !event.deleted)
+			if event.pod.Status.Phase != apiv1.PodFailed {
+				continue
+			}
+		case <-deadline:
+			t.Fatal("pod change is not received")
+		}
+		break
+	}
+
+	assert.NilError(t, poll(time.Second, 10*time.Millisecond, func() error {
+		if _, ok := pods.get("firefox-45-0-1"); ok {
+			return errors.New("deleted pod is cached")
+		}
+		return nil
+	}))
+	assert.Equal(t, 0, len(events))
+}
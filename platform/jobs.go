@@ -0,0 +1,116 @@
+package platform
+
+import (
+	"context"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+//SessionJobs runs browser pods of sessions as Jobs, pod is killed by Job controller after active deadline
+//and Job with pod of failed browser is kept for TTL after it finished, so the pod can be inspected
+type SessionJobs struct {
+	ActiveDeadline   time.Duration
+	TTLAfterFinished time.Duration
+}
+
+//buildJob returns Job named by the session which runs browser pod once, Job pod gets generated name,
+//hostname of the pod is kept, so pod is reachable by session id as bare pods are
+func (j *SessionJobs) buildJob(pod *apiv1.Pod) *batchv1.Job {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        pod.Name,
+			Labels:      pod.Labels,
+			Annotations: pod.Annotations,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: pointer.Int32Ptr(0),
+			Template: apiv1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      pod.Labels,
+					Annotations: pod.Annotations,
+				},
+				Spec: pod.Spec,
+			},
+		},
+	}
+	if j.ActiveDeadline > 0 {
+		job.Spec.ActiveDeadlineSeconds = pointer.Int64Ptr(int64(j.ActiveDeadline.Seconds()))
+	}
+	job.Spec.TTLSecondsAfterFinished = pointer.Int32Ptr(int32(j.TTLAfterFinished.Seconds()))
+	return job
+}
+
+//jobOwned reports if pod is created by Job
+func jobOwned(pod *apiv1.Pod) bool {
+	owner := metav1.GetControllerOf(pod)
+	return owner != nil && owner.Kind == "Job"
+}
+
+//podSession returns session of browser pod, pods of session Jobs have generated names, so their session
+//is taken from session label
+func podSession(pod *apiv1.Pod) string {
+	if session := pod.GetLabels()[defaultLabels.session]; session != "" && jobOwned(pod) {
+		return session
+	}
+	return pod.GetName()
+}
+
+//retainedPod reports if pod of finished session Job is kept for inspection, such pod is not a session anymore
+func retainedPod(pod *apiv1.Pod) bool {
+	return jobOwned(pod) && (pod.Status.Phase == apiv1.PodFailed || pod.Status.Phase == apiv1.PodSucceeded)
+}
+
+//jobFailed reports if Job has finished unsuccessfully, e.g. browser crashed or active deadline passed
+func jobFailed(job *batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == apiv1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+//podName returns name of browser pod of the session, pods of session Jobs are looked up in pods cache
+func (cl *service) podName(sessionID string) string {
+	if cl.jobs == nil {
+		return sessionID
+	}
+	pods, err := cl.podCache()
+	if err != nil {
+		return sessionID
+	}
+	list, err := pods.list()
+	if err != nil {
+		return sessionID
+	}
+	for _, pod := range list {
+		if podSession(pod) == sessionID {
+			return pod.GetName()
+		}
+	}
+	return sessionID
+}
+
+//deleteJob deletes Job of the session together with its pod, failed Job is kept until its TTL expires.
+//Bare pod of the session is deleted if session has no Job, e.g. it was started before Jobs were enabled
+func (cl *service) deleteJob(name string) error {
+	ctx := context.Background()
+	jobs := cl.clientset.BatchV1().Jobs(cl.ns)
+	job, err := jobs.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return deletePod(cl.clientset, cl.ns, name)
+	}
+	if err != nil {
+		return err
+	}
+	if jobFailed(job) {
+		return nil
+	}
+	propagation := metav1.DeletePropagationBackground
+	return jobs.Delete(ctx, name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+}
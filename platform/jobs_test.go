@@ -0,0 +1,151 @@
+package platform
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestBuildJob(t *testing.T) {
+	tests := map[string]struct {
+		jobs     SessionJobs
+		deadline *int64
+		ttl      int32
+	}{
+		"Verify job has active deadline and ttl": {
+			jobs:     SessionJobs{ActiveDeadline: time.Hour, TTLAfterFinished: 10 * time.Minute},
+			deadline: func() *int64 { v := int64(3600); return &v }(),
+			ttl:      600,
+		},
+		"Verify job without deadline is deleted right after it finished": {
+			jobs: SessionJobs{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		pod := &apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911",
+				Labels:      map[string]string{defaultLabels.session: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911"},
+				Annotations: map[string]string{"selenosis.app.type": "browser"},
+			},
+			Spec: apiv1.PodSpec{
+				Hostname:      "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911",
+				RestartPolicy: apiv1.RestartPolicyNever,
+				Containers:    []apiv1.Container{{Name: "browser", Image: "selenoid/vnc:chrome_85.0"}},
+			},
+		}
+		job := test.jobs.buildJob(pod)
+
+		assert.Equal(t, pod.Name, job.Name)
+		assert.DeepEqual(t, pod.Labels, job.Spec.Template.Labels)
+		assert.DeepEqual(t, pod.Annotations, job.Spec.Template.Annotations)
+		assert.Equal(t, pod.Spec.Hostname, job.Spec.Template.Spec.Hostname)
+		assert.Equal(t, int32(0), *job.Spec.BackoffLimit)
+		assert.DeepEqual(t, test.deadline, job.Spec.ActiveDeadlineSeconds)
+		assert.Equal(t, test.ttl, *job.Spec.TTLSecondsAfterFinished)
+	}
+}
+
+func TestPodSession(t *testing.T) {
+	controller := true
+	jobOwner := []metav1.OwnerReference{{Kind: "Job", Name: "chrome-85-0-de44c3c4", Controller: &controller}}
+
+	tests := map[string]struct {
+		owners   []metav1.OwnerReference
+		phase    apiv1.PodPhase
+		session  string
+		retained bool
+	}{
+		"Verify bare pod session is pod name": {
+			phase:   apiv1.PodFailed,
+			session: "chrome-85-0-de44c3c4-x7k2p",
+		},
+		"Verify job pod session is taken from label": {
+			owners:  jobOwner,
+			phase:   apiv1.PodRunning,
+			session: "chrome-85-0-de44c3c4",
+		},
+		"Verify failed job pod is retained": {
+			owners:   jobOwner,
+			phase:    apiv1.PodFailed,
+			session:  "chrome-85-0-de44c3c4",
+			retained: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		pod := &apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "chrome-85-0-de44c3c4-x7k2p",
+				Labels:          map[string]string{defaultLabels.session: "chrome-85-0-de44c3c4"},
+				OwnerReferences: test.owners,
+			},
+			Status: apiv1.PodStatus{Phase: test.phase},
+		}
+
+		assert.Equal(t, test.session, podSession(pod))
+		assert.Equal(t, test.retained, retainedPod(pod))
+	}
+}
+
+func TestDeleteJob(t *testing.T) {
+	tests := map[string]struct {
+		job        *batchv1.Job
+		pod        bool
+		jobDeleted bool
+		podDeleted bool
+	}{
+		"Verify running job is deleted": {
+			job:        &batchv1.Job{},
+			jobDeleted: true,
+		},
+		"Verify failed job is kept": {
+			job: &batchv1.Job{
+				Status: batchv1.JobStatus{
+					Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: apiv1.ConditionTrue, Reason: "DeadlineExceeded"}},
+				},
+			},
+		},
+		"Verify bare pod is deleted if session has no job": {
+			pod:        true,
+			podDeleted: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		ns, session := "selenosis", "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911"
+		mock := fake.NewSimpleClientset()
+		ctx := context.Background()
+		if test.job != nil {
+			test.job.Name = session
+			_, err := mock.BatchV1().Jobs(ns).Create(ctx, test.job, metav1.CreateOptions{})
+			assert.NilError(t, err)
+		}
+		if test.pod {
+			_, err := mock.CoreV1().Pods(ns).Create(ctx, &apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: session}}, metav1.CreateOptions{})
+			assert.NilError(t, err)
+		}
+
+		cl := &service{ns: ns, clientset: mock, jobs: &SessionJobs{}}
+		assert.NilError(t, cl.Delete(session))
+
+		_, err := mock.BatchV1().Jobs(ns).Get(ctx, session, metav1.GetOptions{})
+		assert.Equal(t, test.jobDeleted || test.job == nil, apierrors.IsNotFound(err))
+		_, err = mock.CoreV1().Pods(ns).Get(ctx, session, metav1.GetOptions{})
+		assert.Equal(t, test.podDeleted || !test.pod, apierrors.IsNotFound(err))
+	}
+}
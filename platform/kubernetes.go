@@ -10,26 +10,33 @@ import (
 	"net/url"
 	"path"
 	"strconv"
+	"strings"
+	"sync"
+	"text/template"
 	"time"
 
+	"github.com/alcounit/selenosis/selenium"
 	"github.com/alcounit/selenosis/tools"
 	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
-	"k8s.io/apimachinery/pkg/watch"
-	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
-	"k8s.io/kubernetes/pkg/fields"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/utils/pointer"
 )
 
 var (
-	label        = "selenosis.app.type"
-	quotaName    = "selenosis-pod-limit"
-	browserPorts = struct {
+	label         = "selenosis.app.type"
+	sessionLabel  = "selenosis.app.session"
+	quotaName     = "selenosis-pod-limit"
+	artifactsName = "selenosis-artifacts"
+	browserPorts  = struct {
 		selenium, vnc intstr.IntOrString
 	}{
 		selenium: intstr.FromString("4444"),
@@ -37,7 +44,7 @@ var (
 	}
 
 	defaultsAnnotations = struct {
-		testName, browserName, browserVersion, screenResolution, enableVNC, timeZone string
+		testName, browserName, browserVersion, screenResolution, enableVNC, timeZone, runID, tenant, sessionTimeout, client, owner string
 	}{
 		testName:         "testName",
 		browserName:      "browserName",
@@ -45,7 +52,19 @@ var (
 		screenResolution: "SCREEN_RESOLUTION",
 		enableVNC:        "ENABLE_VNC",
 		timeZone:         "TZ",
+		runID:            "runId",
+		tenant:           "tenant",
+		sessionTimeout:   "sessionTimeout",
+		client:           "client",
+		owner:            "owner",
 	}
+	artifactsAnnotation = "artifacts"
+	//startupAnnotation keeps time by which replica creating the pod gives up waiting for the browser,
+	//annotation is removed once browser is ready, so pods still having it past the deadline lost their creator
+	startupAnnotation = "selenosis.app.startupDeadline"
+	//idleTimeoutEnv passes idle timeout of the session to seleniferous
+	idleTimeoutEnv = "IDLE_TIMEOUT"
+
 	defaultLabels = struct {
 		serviceType, appType, session string
 	}{
@@ -62,18 +81,42 @@ type ClientConfig struct {
 	ServicePort         string
 	ImagePullSecretName string
 	ProxyImage          string
+	WindowsProxyImage   string
+	ProxyResources      apiv1.ResourceRequirements
+	VideoImage          string
+	VideoEncoding       VideoEncoding
+	VideoName           *template.Template
+	VideoOverlay        bool
+	VideoUpload         bool
+	VideoUploaderImage  string
+	WarmupPauseImage    string
+	HARImage            string
+	FilesImage          string
+	ProxyTLSSecret      string
+	SessionJobs         *SessionJobs
 	ReadinessTimeout    time.Duration
+	PendingTimeout      time.Duration
 	IdleTimeout         time.Duration
+	NamespacedHosts     bool
+	QPS                 float32
+	Burst               int
 }
 
 //Client ...
 type Client struct {
-	ns        string
-	svc       string
-	svcPort   intstr.IntOrString
-	clientset kubernetes.Interface
-	service   ServiceInterface
-	quota     QuotaInterface
+	ns              string
+	svc             string
+	namespacedHosts bool
+	svcPort         intstr.IntOrString
+	clientset       kubernetes.Interface
+	service         ServiceInterface
+	quota           QuotaInterface
+	resources       ResourceInterface
+	artifacts       ArtifactInterface
+
+	mu     sync.Mutex
+	caches map[string]cache.Store
+	pods   *podCache
 }
 
 //NewClient ...
@@ -83,20 +126,51 @@ func NewClient(c ClientConfig) (Platform, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to build cluster config: %v", err)
 	}
+	if c.QPS > 0 {
+		conf.QPS = c.QPS
+	}
+	if c.Burst > 0 {
+		conf.Burst = c.Burst
+	}
+	if conf.QPS == 0 {
+		conf.QPS = rest.DefaultQPS
+	}
+	if conf.Burst == 0 {
+		conf.Burst = rest.DefaultBurst
+	}
+	conf.RateLimiter = throttleCounter{flowcontrol.NewTokenBucketRateLimiter(conf.QPS, conf.Burst)}
 
 	clientset, err := kubernetes.NewForConfig(conf)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build client: %v", err)
 	}
 
+	pods := newPodCache(clientset, c.Namespace)
+
 	service := &service{
 		ns:                  c.Namespace,
 		clientset:           clientset,
+		pods:                pods,
 		svc:                 c.Service,
+		namespacedHosts:     c.NamespacedHosts,
 		svcPort:             intstr.FromString(c.ServicePort),
 		imagePullSecretName: c.ImagePullSecretName,
 		proxyImage:          c.ProxyImage,
+		windowsProxyImage:   c.WindowsProxyImage,
+		proxyResources:      c.ProxyResources,
+		videoImage:          c.VideoImage,
+		videoEncoding:       c.VideoEncoding,
+		videoName:           c.VideoName,
+		videoOverlay:        c.VideoOverlay,
+		videoUpload:         c.VideoUpload,
+		videoUploaderImage:  c.VideoUploaderImage,
+		warmupPauseImage:    c.WarmupPauseImage,
+		harImage:            c.HARImage,
+		filesImage:          c.FilesImage,
+		proxyTLSSecret:      c.ProxyTLSSecret,
+		jobs:                c.SessionJobs,
 		readinessTimeout:    c.ReadinessTimeout,
+		pendingTimeout:      c.PendingTimeout,
 		idleTimeout:         c.IdleTimeout,
 	}
 
@@ -105,13 +179,27 @@ func NewClient(c ClientConfig) (Platform, error) {
 		clientset: clientset,
 	}
 
-	return &Client{
+	resources := &resources{
+		ns:        c.Namespace,
+		clientset: clientset,
+	}
+
+	artifacts := &artifacts{
 		ns:        c.Namespace,
 		clientset: clientset,
-		svc:       c.Service,
-		svcPort:   intstr.FromString(c.ServicePort),
-		service:   service,
-		quota:     quota,
+	}
+
+	return &Client{
+		ns:              c.Namespace,
+		clientset:       clientset,
+		svc:             c.Service,
+		namespacedHosts: c.NamespacedHosts,
+		svcPort:         intstr.FromString(c.ServicePort),
+		service:         service,
+		quota:           quota,
+		resources:       resources,
+		artifacts:       artifacts,
+		pods:            pods,
 	}, nil
 
 }
@@ -124,11 +212,32 @@ func (cl *Client) Quota() QuotaInterface {
 	return cl.quota
 }
 
-//List ...
-func (cl *Client) State() (PlatformState, error) {
-	context := context.Background()
-	pods, err := cl.clientset.CoreV1().Pods(cl.ns).List(context, metav1.ListOptions{})
+func (cl *Client) Resources() ResourceInterface {
+	return cl.resources
+}
 
+func (cl *Client) Artifacts() ArtifactInterface {
+	return cl.artifacts
+}
+
+//podCache returns started pods cache of the client
+func (cl *Client) podCache() (*podCache, error) {
+	cl.mu.Lock()
+	if cl.pods == nil {
+		cl.pods = newPodCache(cl.clientset, cl.ns)
+	}
+	pods := cl.pods
+	cl.mu.Unlock()
+	return pods, pods.start()
+}
+
+//State returns sessions and workers of the pods cache, cache is filled on the first call
+func (cl *Client) State() (PlatformState, error) {
+	store, err := cl.podCache()
+	if err != nil {
+		return PlatformState{}, fmt.Errorf("failed to get pods: %v", err)
+	}
+	pods, err := store.list()
 	if err != nil {
 		return PlatformState{}, fmt.Errorf("failed to get pods: %v", err)
 	}
@@ -136,7 +245,7 @@ func (cl *Client) State() (PlatformState, error) {
 	var services []Service
 	var workers []Worker
 
-	for _, pod := range pods.Items {
+	for _, pod := range pods {
 		podName := pod.GetName()
 		creationTime := pod.CreationTimestamp.Time
 
@@ -161,19 +270,10 @@ func (cl *Client) State() (PlatformState, error) {
 				})
 
 			case "browser":
-				services = append(services, Service{
-					SessionID: podName,
-					URL: &url.URL{
-						Scheme: "http",
-						Host:   tools.BuildHostPort(podName, cl.svc, cl.svcPort.StrVal),
-					},
-					Labels: getRequestedCapabilities(pod.GetAnnotations()),
-					CancelFunc: func() {
-						deletePod(cl.clientset, cl.ns, podName)
-					},
-					Status:  status,
-					Started: creationTime,
-				})
+				if retainedPod(pod) {
+					continue
+				}
+				services = append(services, cl.podService(pod, status))
 			}
 		}
 	}
@@ -185,15 +285,40 @@ func (cl *Client) State() (PlatformState, error) {
 
 }
 
-//Watch ...
+//podService returns session of browser pod
+func (cl *Client) podService(pod *apiv1.Pod, status ServiceStatus) Service {
+	sessionID := podSession(pod)
+	return Service{
+		SessionID: sessionID,
+		URL: &url.URL{
+			Scheme: "http",
+			Host:   tools.BuildHostPort(sessionID, serviceHost(cl.svc, cl.ns, cl.namespacedHosts), cl.svcPort.StrVal),
+		},
+		Labels:        getRequestedCapabilities(pod.GetAnnotations()),
+		SessionLabels: getSessionLabels(pod.GetAnnotations()),
+		VideoStream:   getVideoStream(pod.GetAnnotations()),
+		HAR:           getHAR(pod.GetAnnotations()),
+		CancelFunc: func() {
+			cl.service.Delete(sessionID)
+		},
+		Status:     status,
+		Started:    pod.CreationTimestamp.Time,
+		Deadline:   startupDeadline(pod),
+		Extended:   extendedAt(pod.GetAnnotations()),
+		Active:     activeAt(pod.GetAnnotations()),
+		Disruption: podDisruption(pod),
+	}
+}
+
+//Watch streams changes of selenosis pods and resource quotas, informers are shared with pods cache of the client
 func (cl *Client) Watch() <-chan Event {
 	ch := make(chan Event)
-	namespace := informers.WithNamespace(cl.ns)
-	labels := informers.WithTweakListOptions(func(list *metav1.ListOptions) {
-		list.LabelSelector = label
-	})
-
-	sharedIformer := informers.NewSharedInformerFactoryWithOptions(cl.clientset, 30*time.Second, namespace, labels)
+	cl.mu.Lock()
+	if cl.pods == nil {
+		cl.pods = newPodCache(cl.clientset, cl.ns)
+	}
+	sharedIformer := cl.pods.factory
+	cl.mu.Unlock()
 
 	podEventFunc := func(obj interface{}, eventType EventType) {
 		if pod, ok := obj.(*apiv1.Pod); ok {
@@ -225,20 +350,8 @@ func (cl *Client) Watch() <-chan Event {
 
 				case "browser":
 					ch <- Event{
-						Type: eventType,
-						PlatformObject: Service{
-							SessionID: podName,
-							URL: &url.URL{
-								Scheme: "http",
-								Host:   tools.BuildHostPort(podName, cl.svc, cl.svcPort.StrVal),
-							},
-							Labels: getRequestedCapabilities(pod.GetAnnotations()),
-							CancelFunc: func() {
-								deletePod(cl.clientset, cl.ns, podName)
-							},
-							Status:  status,
-							Started: creationTime,
-						},
+						Type:           eventType,
+						PlatformObject: cl.podService(pod, status),
 					}
 				}
 			}
@@ -247,12 +360,25 @@ func (cl *Client) Watch() <-chan Event {
 	sharedIformer.Core().V1().Pods().Informer().AddEventHandler(
 		cache.ResourceEventHandlerFuncs{
 			AddFunc: func(obj interface{}) {
+				if pod, ok := obj.(*apiv1.Pod); ok && retainedPod(pod) {
+					return
+				}
 				podEventFunc(obj, Added)
 			},
+			//session ends when pod of session job finishes, the pod is kept for inspection
 			UpdateFunc: func(old interface{}, new interface{}) {
+				if pod, ok := new.(*apiv1.Pod); ok && retainedPod(pod) {
+					if oldPod, ok := old.(*apiv1.Pod); ok && !retainedPod(oldPod) {
+						podEventFunc(new, Deleted)
+					}
+					return
+				}
 				podEventFunc(new, Updated)
 			},
 			DeleteFunc: func(obj interface{}) {
+				if pod, ok := obj.(*apiv1.Pod); ok && retainedPod(pod) {
+					return
+				}
 				podEventFunc(obj, Deleted)
 			},
 		},
@@ -286,36 +412,228 @@ func (cl *Client) Watch() <-chan Event {
 		},
 	)
 
-	var neverStop <-chan struct{} = make(chan struct{})
-	sharedIformer.Start(neverStop)
+	cl.mu.Lock()
+	cl.caches = map[string]cache.Store{
+		"pods":           sharedIformer.Core().V1().Pods().Informer().GetStore(),
+		"resourcequotas": sharedIformer.Core().V1().ResourceQuotas().Informer().GetStore(),
+	}
+	sharedIformer.Start(cl.pods.stop)
+	cl.mu.Unlock()
 	return ch
 }
 
+//CacheSize returns number of objects in informer caches by resource, caches are empty until Watch is called
+func (cl *Client) CacheSize() map[string]int {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	sizes := make(map[string]int, len(cl.caches))
+	for resource, store := range cl.caches {
+		sizes[resource] = len(store.ListKeys())
+	}
+	return sizes
+}
+
 type service struct {
 	ns                  string
 	svc                 string
+	namespacedHosts     bool
 	svcPort             intstr.IntOrString
 	imagePullSecretName string
 	proxyImage          string
+	windowsProxyImage   string
+	proxyResources      apiv1.ResourceRequirements
+	videoImage          string
+	videoEncoding       VideoEncoding
+	videoName           *template.Template
+	videoOverlay        bool
+	videoUpload         bool
+	videoUploaderImage  string
+	warmupPauseImage    string
+	harImage            string
+	filesImage          string
+	proxyTLSSecret      string
+	jobs                *SessionJobs
 	readinessTimeout    time.Duration
+	pendingTimeout      time.Duration
 	idleTimeout         time.Duration
 	clientset           kubernetes.Interface
+	pods                *podCache
+	podsOnce            sync.Once
 }
 
-//Create ...
-func (cl *service) Create(layout ServiceSpec) (Service, error) {
-	annontations := map[string]string{
-		defaultsAnnotations.browserName:    layout.Template.BrowserName,
-		defaultsAnnotations.browserVersion: layout.Template.BrowserVersion,
-		defaultsAnnotations.testName:       layout.RequestedCapabilities.TestName,
+//podCache returns started pods cache of the service
+func (cl *service) podCache() (*podCache, error) {
+	cl.podsOnce.Do(func() {
+		if cl.pods == nil {
+			cl.pods = newPodCache(cl.clientset, cl.ns)
+		}
+	})
+	return cl.pods, cl.pods.start()
+}
+
+//buildPod returns browser pod of the layout, Windows pods are pinned to Windows nodes and run
+//Windows build of the proxy, privileged mode and Linux capabilities are not supported there
+func (cl *service) buildPod(layout ServiceSpec) (*apiv1.Pod, error) {
+	resources, err := SessionResources(layout.Template, layout.RequestedCapabilities)
+	if err != nil {
+		return nil, err
 	}
 
-	labels := map[string]string{
-		defaultLabels.serviceType: "browser",
-		defaultLabels.appType:     "browser",
-		defaultLabels.session:     layout.SessionID,
+	pod := &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        layout.SessionID,
+			Labels:      layout.Template.Meta.Labels,
+			Annotations: layout.Template.Meta.Annotations,
+		},
+		Spec: apiv1.PodSpec{
+			Hostname:  layout.SessionID,
+			Subdomain: cl.svc,
+			Containers: []apiv1.Container{
+				{
+					Name:            "browser",
+					Image:           layout.Template.Image,
+					SecurityContext: browserSecurityContext(layout.Template),
+					Env:             layout.Template.Spec.EnvVars,
+					Ports:           getBrowserPorts(layout.Template),
+					ReadinessProbe:  readinessProbe(layout.Template),
+					Resources:       resources,
+					VolumeMounts:    getVolumeMounts(layout.Template.Spec.VolumeMounts),
+					ImagePullPolicy: apiv1.PullIfNotPresent,
+				},
+				{
+					Name:      "seleniferous",
+					Image:     cl.proxyImage,
+					Ports:     getSidecarPorts(cl.svcPort),
+					Resources: getProxyResources(cl.proxyResources, layout.Template.Spec.ProxyResources),
+					Env:       []apiv1.EnvVar{{Name: idleTimeoutEnv, Value: idleTimeout(layout, cl.idleTimeout).String()}},
+					Command: append([]string{
+						"/seleniferous", "--listhen-port", cl.svcPort.StrVal, "--proxy-default-path", path.Join(layout.Template.Path, "session"), "--idle-timeout", "$(" + idleTimeoutEnv + ")", "--namespace", cl.ns,
+					}, browserPortArgs(layout.Template)...),
+					ImagePullPolicy: apiv1.PullIfNotPresent,
+				},
+			},
+			Volumes:          getVolumes(layout.Template.Volumes),
+			NodeSelector:     layout.Template.Spec.NodeSelector,
+			HostAliases:      layout.Template.Spec.HostAliases,
+			RestartPolicy:    apiv1.RestartPolicyNever,
+			Affinity:         getAffinity(layout.Template.Spec.Affinity, layout.Template.imageArchs()),
+			DNSConfig:        &layout.Template.Spec.DNSConfig,
+			Tolerations:      layout.Template.Spec.Tolerations,
+			ImagePullSecrets: getImagePullSecretList(cl.imagePullSecretName),
+			SecurityContext:  getSecurityContext(layout.Template.RunAs, layout.Template.Spec.PodSecurityContext),
+		},
+	}
+
+	sidecars, err := sidecarContainers(layout.Template.Spec.Sidecars)
+	if err != nil {
+		return nil, err
+	}
+	pod.Spec.Containers = append(pod.Spec.Containers, sidecars...)
+
+	if cl.proxyTLSSecret != "" {
+		proxyTLS(pod, cl.proxyTLSSecret)
+	}
+
+	if layout.RequestedCapabilities.Video {
+		if layout.Template.Platform == WindowsPlatform {
+			return nil, errors.New("video recording is not supported for windows browsers")
+		}
+		recorder, err := cl.videoContainer(layout)
+		if err != nil {
+			return nil, err
+		}
+		pod.Spec.Containers = append(pod.Spec.Containers, recorder)
+		pod.Spec.Containers[1].VolumeMounts = append(pod.Spec.Containers[1].VolumeMounts, apiv1.VolumeMount{Name: videoVolumeName, MountPath: videoOutputDir})
+		pod.Spec.Volumes = append(pod.Spec.Volumes, apiv1.Volume{Name: videoVolumeName, VolumeSource: apiv1.VolumeSource{EmptyDir: &apiv1.EmptyDirVolumeSource{}}})
+
+		uploader, videoURL, err := cl.uploaderContainer(layout)
+		if err != nil {
+			return nil, err
+		}
+		if uploader != nil {
+			pod.Spec.Containers = append(pod.Spec.Containers, *uploader)
+			annotations := map[string]string{videoURLAnnotation: videoURL}
+			for k, v := range pod.Annotations {
+				annotations[k] = v
+			}
+			pod.Annotations = annotations
+		}
+		if video := layout.Template.Video; video != nil && video.Stream != nil {
+			stream, _ := json.Marshal(video.Stream)
+			annotations := map[string]string{videoStreamAnnotation: string(stream)}
+			for k, v := range pod.Annotations {
+				annotations[k] = v
+			}
+			pod.Annotations = annotations
+		}
 	}
 
+	if layout.RequestedCapabilities.GetCaptureHAR() {
+		recorder, err := cl.harContainer(layout)
+		if err != nil {
+			return nil, err
+		}
+		pod.Spec.Containers = append(pod.Spec.Containers, recorder)
+		annotations := map[string]string{harAnnotation: "true"}
+		for k, v := range pod.Annotations {
+			annotations[k] = v
+		}
+		pod.Annotations = annotations
+	}
+
+	sessionDNS(pod, layout.RequestedCapabilities)
+	helperSecurityContexts(pod, layout.Template)
+
+	if layout.RequestedCapabilities.Workspace {
+		if err := workspaceVolume(pod, layout); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := profileVolume(pod, layout); err != nil {
+		return nil, err
+	}
+
+	if err := cl.filesVolume(pod, layout); err != nil {
+		return nil, err
+	}
+
+	if layout.Template.Platform == WindowsPlatform {
+		if err := cl.windowsPod(pod, layout.Template.RunAs); err != nil {
+			return nil, err
+		}
+	}
+
+	pod, err = applyOverlay(pod, layout.Template.PodOverlay)
+	if err != nil {
+		return nil, err
+	}
+	return applyPatches(pod, layout.Template.PodPatches)
+}
+
+//windowsPod moves pod to Windows nodes, Linux security settings are replaced with Windows options
+func (cl *service) windowsPod(pod *apiv1.Pod, runAs RunAsOptions) error {
+	if cl.windowsProxyImage == "" {
+		return errors.New("windows proxy image is not set")
+	}
+	windowsOptions := getWindowsOptions(runAs)
+	pod.Spec.Containers[0].SecurityContext = &apiv1.SecurityContext{WindowsOptions: windowsOptions}
+	pod.Spec.Containers[1].Image = cl.windowsProxyImage
+	pod.Spec.Containers[1].SecurityContext = nil
+	pod.Spec.SecurityContext = &apiv1.PodSecurityContext{WindowsOptions: windowsOptions}
+
+	selector := make(map[string]string, len(pod.Spec.NodeSelector)+1)
+	for k, v := range pod.Spec.NodeSelector {
+		selector[k] = v
+	}
+	selector[osLabel] = WindowsPlatform
+	pod.Spec.NodeSelector = selector
+	return nil
+}
+
+//setEnvAndMeta sets browser environment requested with capabilities and keeps it in annotations of the pod,
+//env of selenosis:options capability is applied last and overrides environment of the template
+func setEnvAndMeta(layout *ServiceSpec, annontations map[string]string) {
 	envVar := func(name string) (i int, b bool) {
 		for i, slice := range layout.Template.Spec.EnvVars {
 			if slice.Name == name {
@@ -370,6 +688,81 @@ func (cl *service) Create(layout ServiceSpec) (Service, error) {
 		}
 	}
 
+	if env := optionsEnv(layout.RequestedCapabilities); len(env) > 0 {
+		layout.Template.Spec.EnvVars = mergeEnv(layout.Template.Spec.EnvVars, env)
+	}
+}
+
+//optionsEnv returns browser environment requested with selenosis:options capability, malformed variables are skipped
+func optionsEnv(caps selenium.Capabilities) []apiv1.EnvVar {
+	var env []apiv1.EnvVar
+	for _, v := range caps.GetEnv() {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			continue
+		}
+		env = append(env, apiv1.EnvVar{Name: kv[0], Value: kv[1]})
+	}
+	return env
+}
+
+//Create ...
+func (cl *service) Create(layout ServiceSpec) (Service, error) {
+	if layout.Template.VM != nil {
+		return Service{}, errors.New("virtual machine browsers require kubevirt backend")
+	}
+	warm := warmEligible(layout)
+
+	annontations := map[string]string{
+		defaultsAnnotations.browserName:    layout.Template.BrowserName,
+		defaultsAnnotations.browserVersion: layout.Template.BrowserVersion,
+		defaultsAnnotations.testName:       layout.RequestedCapabilities.TestName,
+	}
+
+	if layout.RequestedCapabilities.RunID != "" {
+		annontations[defaultsAnnotations.runID] = layout.RequestedCapabilities.RunID
+	}
+
+	if layout.Tenant != "" {
+		annontations[defaultsAnnotations.tenant] = layout.Tenant
+	}
+
+	if layout.IdleTimeout > 0 {
+		annontations[defaultsAnnotations.sessionTimeout] = layout.IdleTimeout.String()
+	}
+
+	if layout.Client != "" {
+		annontations[defaultsAnnotations.client] = layout.Client
+	}
+
+	if layout.Owner != "" {
+		annontations[defaultsAnnotations.owner] = layout.Owner
+	}
+
+	labels := map[string]string{
+		defaultLabels.serviceType: "browser",
+		defaultLabels.appType:     "browser",
+		defaultLabels.session:     layout.SessionID,
+	}
+
+	if owner := sanitizeLabelValue(layout.Owner); owner != "" {
+		labels[ownerLabel] = owner
+	}
+
+	for k, v := range browserLabels(layout.Template) {
+		labels[k] = v
+	}
+
+	sessionLabels, err := RequestedLabels(layout.RequestedCapabilities)
+	if err != nil {
+		return Service{}, err
+	}
+	for k, v := range sessionLabels {
+		labels[k] = v
+	}
+
+	setEnvAndMeta(&layout, annontations)
+
 	if layout.Template.Meta.Labels == nil {
 		layout.Template.Meta.Labels = make(map[string]string)
 	}
@@ -386,92 +779,117 @@ func (cl *service) Create(layout ServiceSpec) (Service, error) {
 		layout.Template.Meta.Annotations["capabilities"] = string(caps)
 	}
 
-	pod := &apiv1.Pod{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:        layout.SessionID,
-			Labels:      layout.Template.Meta.Labels,
-			Annotations: layout.Template.Meta.Annotations,
-		},
-		Spec: apiv1.PodSpec{
-			Hostname:  layout.SessionID,
-			Subdomain: cl.svc,
-			Containers: []apiv1.Container{
-				{
-					Name:  "browser",
-					Image: layout.Template.Image,
-					SecurityContext: &apiv1.SecurityContext{
-						Privileged:   layout.Template.Privileged,
-						Capabilities: getCapabilities(layout.Template.Capabilities),
-					},
-					Env:             layout.Template.Spec.EnvVars,
-					Ports:           getBrowserPorts(),
-					Resources:       layout.Template.Spec.Resources,
-					VolumeMounts:    getVolumeMounts(layout.Template.Spec.VolumeMounts),
-					ImagePullPolicy: apiv1.PullIfNotPresent,
-				},
-				{
-					Name:  "seleniferous",
-					Image: cl.proxyImage,
-					Ports: getSidecarPorts(cl.svcPort),
-					Command: []string{
-						"/seleniferous", "--listhen-port", cl.svcPort.StrVal, "--proxy-default-path", path.Join(layout.Template.Path, "session"), "--idle-timeout", cl.idleTimeout.String(), "--namespace", cl.ns,
-					},
-					ImagePullPolicy: apiv1.PullIfNotPresent,
-				},
-			},
-			Volumes:          getVolumes(layout.Template.Volumes),
-			NodeSelector:     layout.Template.Spec.NodeSelector,
-			HostAliases:      layout.Template.Spec.HostAliases,
-			RestartPolicy:    apiv1.RestartPolicyNever,
-			Affinity:         &layout.Template.Spec.Affinity,
-			DNSConfig:        &layout.Template.Spec.DNSConfig,
-			Tolerations:      layout.Template.Spec.Tolerations,
-			ImagePullSecrets: getImagePullSecretList(cl.imagePullSecretName),
-			SecurityContext:  getSecurityContext(layout.Template.RunAs),
-		},
+	if len(sessionLabels) > 0 {
+		if data, err := json.Marshal(sessionLabels); err == nil {
+			layout.Template.Meta.Annotations[sessionLabelsAnnotation] = string(data)
+		}
 	}
 
-	context := context.Background()
-	pod, err := cl.clientset.CoreV1().Pods(cl.ns).Create(context, pod, metav1.CreateOptions{})
+	if !layout.Artifacts.IsEmpty() {
+		if artifacts, err := json.Marshal(layout.Artifacts.Resolve()); err == nil {
+			layout.Template.Meta.Annotations[artifactsAnnotation] = string(artifacts)
+		}
+	}
+
+	if warm && cl.jobs == nil {
+		if service, ok := cl.claimWarm(layout); ok {
+			return service, nil
+		}
+	}
 
+	pod, err := cl.buildPod(layout)
 	if err != nil {
-		return Service{}, fmt.Errorf("failed to create pod %v", err)
+		return Service{}, err
 	}
 
-	podName := pod.GetName()
-	cancel := func() {
-		cl.Delete(podName)
+	if layout.RequestedCapabilities.Workspace {
+		if err := cl.ensureWorkspace(layout); err != nil {
+			return Service{}, err
+		}
 	}
 
-	w, err := cl.clientset.CoreV1().Pods(cl.ns).Watch(context, metav1.ListOptions{
-		FieldSelector:  fields.OneTermEqualSelector("metadata.name", podName).String(),
-		TimeoutSeconds: pointer.Int64Ptr(cl.readinessTimeout.Milliseconds()),
-	})
+	if err := cl.ensureProfile(layout); err != nil {
+		return Service{}, err
+	}
 
+	if err := cl.ensureFiles(layout); err != nil {
+		return Service{}, err
+	}
+
+	var phases []Phase
+	phaseStart := time.Now()
+	phase := func(name string) {
+		now := time.Now()
+		phases = append(phases, Phase{Name: name, Duration: now.Sub(phaseStart)})
+		phaseStart = now
+	}
+
+	deadline := time.Now().Add(cl.readinessTimeout + layout.Template.Readiness.timeout(cl.readinessTimeout))
+	annotations := map[string]string{startupAnnotation: deadline.UTC().Format(time.RFC3339)}
+	for k, v := range pod.Annotations {
+		annotations[k] = v
+	}
+	pod.Annotations = annotations
+
+	pods, err := cl.podCache()
 	if err != nil {
 		return Service{}, fmt.Errorf("failed to watch pod status: %v", err)
 	}
+	events, stopWatch := pods.watch(pod.Name)
+	defer stopWatch()
 
+	context := context.Background()
+	if cl.jobs != nil {
+		_, err = cl.clientset.BatchV1().Jobs(cl.ns).Create(context, cl.jobs.buildJob(pod), metav1.CreateOptions{})
+		phase("create")
+		if err != nil {
+			return Service{}, fmt.Errorf("failed to create job %v", err)
+		}
+	} else {
+		created, err := cl.clientset.CoreV1().Pods(cl.ns).Create(context, pod, metav1.CreateOptions{})
+		if apierrors.IsForbidden(err) && cl.evictWarm() {
+			created, err = cl.clientset.CoreV1().Pods(cl.ns).Create(context, pod, metav1.CreateOptions{})
+		}
+		phase("create")
+		if err != nil {
+			return Service{}, fmt.Errorf("failed to create pod %v", err)
+		}
+		pod = created
+	}
+
+	sessionID := layout.SessionID
+	cancel := func() {
+		cl.Delete(sessionID)
+	}
+
+	//pod of session job is watched by its session, as pod name is generated by job controller
+	watchedPod := pod
+	if cached, ok := pods.get(pod.GetName()); ok {
+		watchedPod = cached
+	}
+	pendingCancelled := false
 	statusFn := func() error {
-		defer w.Stop()
-		var watchedPod *apiv1.Pod
-
-		for event := range w.ResultChan() {
-			switch event.Type {
-			case watch.Error:
-				return fmt.Errorf("received error while watching pod: %s",
-					event.Object.GetObjectKind().GroupVersionKind().String())
-			case watch.Deleted, watch.Added, watch.Modified:
-				watchedPod = event.Object.(*apiv1.Pod)
-			default:
-				return fmt.Errorf("received unknown event type %s while watching pod", event.Type)
-			}
-			if event.Type == watch.Deleted {
-				return errors.New("pod was deleted before becoming available")
+		scheduled := false
+
+		var pending, timeout <-chan time.Time
+		if cl.pendingTimeout > 0 {
+			timer := time.NewTimer(cl.pendingTimeout)
+			defer timer.Stop()
+			pending = timer.C
+		}
+		if cl.readinessTimeout > 0 {
+			timer := time.NewTimer(cl.readinessTimeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+
+		for {
+			if !scheduled && podScheduled(watchedPod) {
+				scheduled = true
+				phase("schedule")
 			}
 			switch watchedPod.Status.Phase {
-			case apiv1.PodPending:
-				continue
+			case apiv1.PodPending, "":
 			case apiv1.PodSucceeded, apiv1.PodFailed:
 				return fmt.Errorf("pod exited early with status %s", watchedPod.Status.Phase)
 			case apiv1.PodRunning:
@@ -481,48 +899,93 @@ func (cl *service) Create(layout ServiceSpec) (Service, error) {
 			default:
 				return errors.New("pod has unknown status")
 			}
+
+			select {
+			case event := <-events:
+				if event.deleted {
+					return errors.New("pod was deleted before becoming available")
+				}
+				watchedPod = event.pod
+			case <-pending:
+				pendingCancelled = true
+				return cl.cancelPending(watchedPod)
+			case <-timeout:
+				return fmt.Errorf("pod wasn't running after %s", cl.readinessTimeout)
+			}
 		}
-		return fmt.Errorf("pod wasn't running")
 	}
 
 	err = statusFn()
+	pod = watchedPod
+	podName := pod.GetName()
 	if err != nil {
+		if !pendingCancelled {
+			if diagnostics := cl.startupDiagnostics(podName); diagnostics != "" {
+				err = fmt.Errorf("%v: %s", err, diagnostics)
+			}
+		}
 		cancel()
 		return Service{}, fmt.Errorf("pod is not ready after creation: %v", err)
 	}
+	phase("running")
 
 	u := &url.URL{
 		Scheme: "http",
-		Host:   podName + "." + cl.svc + ":" + browserPorts.selenium.StrVal,
+		Host:   sessionID + "." + serviceHost(cl.svc, cl.ns, cl.namespacedHosts) + ":" + browserPort(layout.Template).StrVal,
 	}
 
-	if err := waitForService(*u, cl.readinessTimeout); err != nil {
+	if err := waitForBrowser(*u, layout.Template, layout.Template.Readiness.timeout(cl.readinessTimeout), cl.browserReady(podName)); err != nil {
+		diagnostics := cl.startupDiagnostics(podName)
 		cancel()
+		if diagnostics != "" {
+			return Service{}, fmt.Errorf("container service is not ready %v: %s", u.String(), diagnostics)
+		}
 		return Service{}, fmt.Errorf("container service is not ready %v", u.String())
 	}
+	phase("ready")
+
+	patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:null}}}`, startupAnnotation))
+	if _, err := cl.clientset.CoreV1().Pods(cl.ns).Patch(context, podName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		cancel()
+		return Service{}, fmt.Errorf("failed to mark pod started: %v", err)
+	}
 
-	u.Host = podName + "." + cl.svc + ":" + cl.svcPort.StrVal
+	u.Host = sessionID + "." + serviceHost(cl.svc, cl.ns, cl.namespacedHosts) + ":" + cl.svcPort.StrVal
 
 	return Service{
-		SessionID: podName,
-		URL:       u,
-		Labels:    getRequestedCapabilities(pod.GetAnnotations()),
+		SessionID:     sessionID,
+		URL:           u,
+		Labels:        getRequestedCapabilities(pod.GetAnnotations()),
+		SessionLabels: getSessionLabels(pod.GetAnnotations()),
+		VideoStream:   getVideoStream(pod.GetAnnotations()),
+		HAR:           getHAR(pod.GetAnnotations()),
 		CancelFunc: func() {
 			cancel()
 		},
 		Status:  Running,
 		Started: pod.CreationTimestamp.Time,
+		Phases:  phases,
 	}, nil
 }
 
+//startupDeadline returns time by which creator of the pod should have started browser, zero time is
+//returned for started pods
+func startupDeadline(pod *apiv1.Pod) time.Time {
+	deadline, _ := time.Parse(time.RFC3339, pod.GetAnnotations()[startupAnnotation])
+	return deadline
+}
+
 //Delete ...
 func (cl *service) Delete(name string) error {
+	if cl.jobs != nil {
+		return cl.deleteJob(name)
+	}
 	return deletePod(cl.clientset, cl.ns, name)
 }
 
 //Logs ...
 func (cl *service) Logs(ctx context.Context, name string) (io.ReadCloser, error) {
-	req := cl.clientset.CoreV1().Pods(cl.ns).GetLogs(name, &apiv1.PodLogOptions{
+	req := cl.clientset.CoreV1().Pods(cl.ns).GetLogs(cl.podName(name), &apiv1.PodLogOptions{
 		Container:  "browser",
 		Follow:     true,
 		Previous:   false,
@@ -602,6 +1065,254 @@ func (cl quota) Update(limit int64) (Quota, error) {
 	}, err
 }
 
+type resources struct {
+	ns        string
+	clientset kubernetes.Interface
+}
+
+//List returns auxiliary objects labeled with session id
+func (cl *resources) List() ([]Resource, error) {
+	context := context.Background()
+	opts := metav1.ListOptions{LabelSelector: sessionLabel}
+
+	var result []Resource
+	add := func(kind string, meta metav1.ObjectMeta) {
+		result = append(result, Resource{
+			Kind:      kind,
+			Name:      meta.GetName(),
+			SessionID: meta.GetLabels()[sessionLabel],
+			Created:   meta.GetCreationTimestamp().Time,
+		})
+	}
+
+	secrets, err := cl.clientset.CoreV1().Secrets(cl.ns).List(context, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %v", err)
+	}
+	for _, item := range secrets.Items {
+		add("Secret", item.ObjectMeta)
+	}
+
+	configMaps, err := cl.clientset.CoreV1().ConfigMaps(cl.ns).List(context, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configmaps: %v", err)
+	}
+	for _, item := range configMaps.Items {
+		add("ConfigMap", item.ObjectMeta)
+	}
+
+	services, err := cl.clientset.CoreV1().Services(cl.ns).List(context, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %v", err)
+	}
+	for _, item := range services.Items {
+		add("Service", item.ObjectMeta)
+	}
+
+	claims, err := cl.clientset.CoreV1().PersistentVolumeClaims(cl.ns).List(context, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistentvolumeclaims: %v", err)
+	}
+	for _, item := range claims.Items {
+		add("PersistentVolumeClaim", item.ObjectMeta)
+	}
+
+	workspaces, err := cl.clientset.CoreV1().PersistentVolumeClaims(cl.ns).List(context, metav1.ListOptions{LabelSelector: workspaceLabel})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workspaces: %v", err)
+	}
+	for _, item := range workspaces.Items {
+		result = append(result, Resource{
+			Kind:    "PersistentVolumeClaim",
+			Name:    item.GetName(),
+			RunID:   item.GetAnnotations()[runAnnotation],
+			Created: item.GetCreationTimestamp().Time,
+		})
+	}
+
+	policies, err := cl.clientset.NetworkingV1().NetworkPolicies(cl.ns).List(context, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list networkpolicies: %v", err)
+	}
+	for _, item := range policies.Items {
+		add("NetworkPolicy", item.ObjectMeta)
+	}
+
+	return result, nil
+}
+
+//Delete ...
+func (cl *resources) Delete(r Resource) error {
+	context := context.Background()
+	opts := metav1.DeleteOptions{}
+
+	switch r.Kind {
+	case "Secret":
+		return cl.clientset.CoreV1().Secrets(cl.ns).Delete(context, r.Name, opts)
+	case "ConfigMap":
+		return cl.clientset.CoreV1().ConfigMaps(cl.ns).Delete(context, r.Name, opts)
+	case "Service":
+		return cl.clientset.CoreV1().Services(cl.ns).Delete(context, r.Name, opts)
+	case "PersistentVolumeClaim":
+		return cl.clientset.CoreV1().PersistentVolumeClaims(cl.ns).Delete(context, r.Name, opts)
+	case "NetworkPolicy":
+		return cl.clientset.NetworkingV1().NetworkPolicies(cl.ns).Delete(context, r.Name, opts)
+	}
+	return fmt.Errorf("unsupported resource kind %s", r.Kind)
+}
+
+type artifacts struct {
+	ns        string
+	clientset kubernetes.Interface
+}
+
+//artifactsKey returns config map key artifacts of the tenant are stored with
+func artifactsKey(tenant string) string {
+	if tenant == "" {
+		return "default"
+	}
+	return tenant
+}
+
+//List ...
+func (cl *artifacts) List(tenant string) ([]Artifact, error) {
+	context := context.Background()
+	cm, err := cl.clientset.CoreV1().ConfigMaps(cl.ns).Get(context, artifactsName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artifacts: %v", err)
+	}
+	return decodeArtifacts(cm.Data[artifactsKey(tenant)])
+}
+
+//Add ...
+func (cl *artifacts) Add(tenant string, artifact Artifact) error {
+	return cl.update(tenant, func(list []Artifact) []Artifact {
+		return append(list, artifact)
+	})
+}
+
+//Remove ...
+func (cl *artifacts) Remove(tenant string, urls []string) error {
+	removed := make(map[string]struct{}, len(urls))
+	for _, u := range urls {
+		removed[u] = struct{}{}
+	}
+	return cl.update(tenant, func(list []Artifact) []Artifact {
+		var result []Artifact
+		for _, artifact := range list {
+			if _, ok := removed[artifact.URL]; !ok {
+				result = append(result, artifact)
+			}
+		}
+		return result
+	})
+}
+
+//Credentials returns data of the secret with object storage credentials
+func (cl *artifacts) Credentials(_ string, secret string) (map[string]string, error) {
+	context := context.Background()
+	s, err := cl.clientset.CoreV1().Secrets(cl.ns).Get(context, secret, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret %s: %v", secret, err)
+	}
+	credentials := make(map[string]string, len(s.Data))
+	for k, v := range s.Data {
+		credentials[k] = string(v)
+	}
+	return credentials, nil
+}
+
+func (cl *artifacts) update(tenant string, fn func([]Artifact) []Artifact) error {
+	context := context.Background()
+	key := artifactsKey(tenant)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := cl.clientset.CoreV1().ConfigMaps(cl.ns).Get(context, artifactsName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			data, err := encodeArtifacts(fn(nil))
+			if err != nil {
+				return err
+			}
+			_, err = cl.clientset.CoreV1().ConfigMaps(cl.ns).Create(context, &apiv1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   artifactsName,
+					Labels: map[string]string{label: "artifacts"},
+				},
+				Data: map[string]string{key: data},
+			}, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				return apierrors.NewConflict(apiv1.Resource("configmaps"), artifactsName, err)
+			}
+			return err
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get artifacts: %v", err)
+		}
+
+		list, err := decodeArtifacts(cm.Data[key])
+		if err != nil {
+			return err
+		}
+		data, err := encodeArtifacts(fn(list))
+		if err != nil {
+			return err
+		}
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		cm.Data[key] = data
+		_, err = cl.clientset.CoreV1().ConfigMaps(cl.ns).Update(context, cm, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func decodeArtifacts(data string) ([]Artifact, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var list []Artifact
+	if err := json.Unmarshal([]byte(data), &list); err != nil {
+		return nil, fmt.Errorf("failed to decode artifacts: %v", err)
+	}
+	return list, nil
+}
+
+func encodeArtifacts(list []Artifact) (string, error) {
+	if list == nil {
+		list = []Artifact{}
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode artifacts: %v", err)
+	}
+	return string(data), nil
+}
+
+//podScheduled reports if pod is bound to a node
+func podScheduled(pod *apiv1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == apiv1.PodScheduled && c.Status == apiv1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+//SessionLabels returns labels auxiliary objects of the session should be created with
+func SessionLabels(sessionID string) map[string]string {
+	return map[string]string{sessionLabel: sessionID}
+}
+
+// serviceHost returns name browser pods are reachable by, namespace qualified if selenosis runs in other namespace
+func serviceHost(svc, ns string, namespaced bool) string {
+	if namespaced {
+		return svc + "." + ns
+	}
+	return svc
+}
+
 func deletePod(clientset kubernetes.Interface, namespace, name string) error {
 	context := context.Background()
 
@@ -610,14 +1321,18 @@ func deletePod(clientset kubernetes.Interface, namespace, name string) error {
 	})
 }
 
-func getBrowserPorts() []apiv1.ContainerPort {
+func getBrowserPorts(template BrowserSpec) []apiv1.ContainerPort {
 	port := []apiv1.ContainerPort{}
 	fn := func(name string, value int) {
 		port = append(port, apiv1.ContainerPort{Name: name, ContainerPort: int32(value)})
 	}
 
 	fn("vnc", browserPorts.vnc.IntValue())
-	fn("selenium", browserPorts.selenium.IntValue())
+	if template.Type == AppiumType {
+		fn("appium", appiumPort.IntValue())
+	} else {
+		fn("selenium", browserPorts.selenium.IntValue())
+	}
 
 	return port
 }
@@ -653,6 +1368,36 @@ func getRequestedCapabilities(annotations map[string]string) map[string]string {
 	return nil
 }
 
+//idleTimeout returns idle timeout requested for the session or default one
+func idleTimeout(layout ServiceSpec, def time.Duration) time.Duration {
+	if layout.IdleTimeout > 0 {
+		return layout.IdleTimeout
+	}
+	return def
+}
+
+//getProxyResources returns resources of proxy sidecar, requests and limits of the browser override defaults per resource
+func getProxyResources(defaults, browser apiv1.ResourceRequirements) apiv1.ResourceRequirements {
+	return apiv1.ResourceRequirements{
+		Requests: mergeResources(defaults.Requests, browser.Requests),
+		Limits:   mergeResources(defaults.Limits, browser.Limits),
+	}
+}
+
+func mergeResources(defaults, overrides apiv1.ResourceList) apiv1.ResourceList {
+	if len(defaults) == 0 && len(overrides) == 0 {
+		return nil
+	}
+	resources := make(apiv1.ResourceList, len(defaults)+len(overrides))
+	for name, quantity := range defaults {
+		resources[name] = quantity
+	}
+	for name, quantity := range overrides {
+		resources[name] = quantity
+	}
+	return resources
+}
+
 func getVolumeMounts(mounts []apiv1.VolumeMount) []apiv1.VolumeMount {
 	vm := []apiv1.VolumeMount{
 		{
@@ -690,8 +1435,41 @@ func getCapabilities(caps []apiv1.Capability) *apiv1.Capabilities {
 	return nil
 }
 
-func getSecurityContext(runAsOptions RunAsOptions) *apiv1.PodSecurityContext {
+//getAffinity returns copy of browser affinity, pod has no affinity if none of node, pod and pod anti affinity is set.
+//Pod of multi-arch image is kept on nodes of archs with required node affinity added to every node selector term
+func getAffinity(affinity apiv1.Affinity, archs []string) *apiv1.Affinity {
+	if affinity.NodeAffinity == nil && affinity.PodAffinity == nil && affinity.PodAntiAffinity == nil && len(archs) == 0 {
+		return nil
+	}
+	result := affinity.DeepCopy()
+	if len(archs) == 0 {
+		return result
+	}
+
+	if result.NodeAffinity == nil {
+		result.NodeAffinity = &apiv1.NodeAffinity{}
+	}
+	required := result.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if required == nil {
+		required = &apiv1.NodeSelector{}
+		result.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = required
+	}
+	if len(required.NodeSelectorTerms) == 0 {
+		required.NodeSelectorTerms = []apiv1.NodeSelectorTerm{{}}
+	}
+	for i := range required.NodeSelectorTerms {
+		required.NodeSelectorTerms[i].MatchExpressions = append(required.NodeSelectorTerms[i].MatchExpressions,
+			apiv1.NodeSelectorRequirement{Key: archLabel, Operator: apiv1.NodeSelectorOpIn, Values: archs})
+	}
+	return result
+}
+
+//getSecurityContext returns podSecurityContext of the spec with user and group of runAs options
+func getSecurityContext(runAsOptions RunAsOptions, podSecContext *apiv1.PodSecurityContext) *apiv1.PodSecurityContext {
 	secContext := &apiv1.PodSecurityContext{}
+	if podSecContext != nil {
+		secContext = podSecContext.DeepCopy()
+	}
 	if runAsOptions.RunAsUser != nil {
 		secContext.RunAsUser = runAsOptions.RunAsUser
 	}
@@ -701,7 +1479,58 @@ func getSecurityContext(runAsOptions RunAsOptions) *apiv1.PodSecurityContext {
 	return secContext
 }
 
+func getWindowsOptions(runAsOptions RunAsOptions) *apiv1.WindowsSecurityContextOptions {
+	if runAsOptions.UserName == "" {
+		return nil
+	}
+	return &apiv1.WindowsSecurityContextOptions{RunAsUserName: pointer.StringPtr(runAsOptions.UserName)}
+}
+
+//browserReady returns exec readiness check of the pod, readiness command is run by kubelet as readiness probe
+//of browser container, so check only waits for the container to be reported ready
+func (cl *service) browserReady(name string) func([]string) error {
+	return func([]string) error {
+		pods, err := cl.podCache()
+		if err != nil {
+			return err
+		}
+		pod, ok := pods.get(name)
+		if !ok {
+			return fmt.Errorf("pod %s is not found", name)
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name == "browser" && status.Ready {
+				return nil
+			}
+		}
+		return errors.New("browser container is not ready")
+	}
+}
+
 func waitForService(u url.URL, t time.Duration) error {
+	return pollService(http.MethodHead, u, t, func(int) bool {
+		return true
+	})
+}
+
+//pollService requests u until ready accepts response status code or t is elapsed
+func pollService(method string, u url.URL, t time.Duration, ready func(code int) bool) error {
+	return poll(t, readinessInterval, func() error {
+		req, _ := http.NewRequest(method, u.String(), nil)
+		req.Close = true
+		resp, err := http.DefaultClient.Do(req)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if err == nil && !ready(resp.StatusCode) {
+			err = fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+		return err
+	})
+}
+
+//poll runs check every interval until it succeeds or t is elapsed
+func poll(t, interval time.Duration, check func() error) error {
 	up := make(chan struct{})
 	done := make(chan struct{})
 	go func() {
@@ -712,14 +1541,8 @@ func waitForService(u url.URL, t time.Duration) error {
 			default:
 			}
 
-			req, _ := http.NewRequest(http.MethodHead, u.String(), nil)
-			req.Close = true
-			resp, err := http.DefaultClient.Do(req)
-			if resp != nil {
-				resp.Body.Close()
-			}
-			if err != nil {
-				<-time.After(50 * time.Millisecond)
+			if err := check(); err != nil {
+				<-time.After(interval)
 				continue
 			}
 			up <- struct{}{}
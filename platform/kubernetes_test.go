@@ -7,158 +7,93 @@ import (
 	"net"
 	"net/url"
 	"testing"
+	"time"
 
 	testcore "k8s.io/client-go/testing"
 
 	"github.com/alcounit/selenosis/selenium"
 	"gotest.tools/assert"
 	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
-	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestErrorsOnServiceCreate(t *testing.T) {
+	layout := ServiceSpec{
+		SessionID: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911",
+		RequestedCapabilities: selenium.Capabilities{
+			VNC: true,
+		},
+		Template: BrowserSpec{
+			BrowserName:    "chrome",
+			BrowserVersion: "85.0",
+			Image:          "selenoid/vnc:chrome_85.0",
+			Path:           "/",
+		},
+	}
+
+	setPhase := func(phase apiv1.PodPhase) func(mock *fake.Clientset, pod *apiv1.Pod) {
+		return func(mock *fake.Clientset, pod *apiv1.Pod) {
+			pod.Status.Phase = phase
+			mock.CoreV1().Pods(pod.Namespace).UpdateStatus(context.Background(), pod, metav1.UpdateOptions{})
+		}
+	}
+
 	tests := map[string]struct {
-		ns        string
-		podName   string
-		layout    ServiceSpec
-		eventType watch.EventType
-		podPhase  apiv1.PodPhase
-		err       error
+		podPhase   apiv1.PodPhase
+		containers []apiv1.ContainerStatus
+		update     func(mock *fake.Clientset, pod *apiv1.Pod)
+		err        error
 	}{
 		"Verify platform error on pod startup phase PodSucceeded": {
-			ns:      "selenosis",
-			podName: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911",
-			layout: ServiceSpec{
-				SessionID: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911",
-				RequestedCapabilities: selenium.Capabilities{
-					VNC: true,
-				},
-				Template: BrowserSpec{
-					BrowserName:    "chrome",
-					BrowserVersion: "85.0",
-					Image:          "selenoid/vnc:chrome_85.0",
-					Path:           "/",
-				},
-			},
-			eventType: watch.Added,
-			podPhase:  apiv1.PodSucceeded,
-			err:       errors.New("pod is not ready after creation: pod exited early with status Succeeded"),
+			podPhase: apiv1.PodSucceeded,
+			err:      errors.New("pod is not ready after creation: pod exited early with status Succeeded"),
 		},
 		"Verify platform error on pod startup phase PodFailed": {
-			ns:      "selenosis",
-			podName: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911",
-			layout: ServiceSpec{
-				SessionID: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911",
-				RequestedCapabilities: selenium.Capabilities{
-					VNC: true,
-				},
-				Template: BrowserSpec{
-					BrowserName:    "chrome",
-					BrowserVersion: "85.0",
-					Image:          "selenoid/vnc:chrome_85.0",
-					Path:           "/",
-				},
-			},
-			eventType: watch.Added,
-			podPhase:  apiv1.PodFailed,
-			err:       errors.New("pod is not ready after creation: pod exited early with status Failed"),
+			podPhase: apiv1.PodFailed,
+			err:      errors.New("pod is not ready after creation: pod exited early with status Failed"),
 		},
 		"Verify platform error on pod startup phase PodUnknown": {
-			ns:      "selenosis",
-			podName: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911",
-			layout: ServiceSpec{
-				SessionID: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911",
-				RequestedCapabilities: selenium.Capabilities{
-					VNC: true,
-				},
-				Template: BrowserSpec{
-					BrowserName:    "chrome",
-					BrowserVersion: "85.0",
-					Image:          "selenoid/vnc:chrome_85.0",
-					Path:           "/",
-				},
-			},
-			eventType: watch.Added,
-			podPhase:  apiv1.PodUnknown,
-			err:       errors.New("pod is not ready after creation: couldn't obtain pod state"),
+			podPhase: apiv1.PodUnknown,
+			err:      errors.New("pod is not ready after creation: couldn't obtain pod state"),
 		},
 		"Verify platform error on pod startup phase Unknown": {
-			ns:      "selenosis",
-			podName: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911",
-			layout: ServiceSpec{
-				SessionID: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911",
-				RequestedCapabilities: selenium.Capabilities{
-					VNC: true,
-				},
-				Template: BrowserSpec{
-					BrowserName:    "chrome",
-					BrowserVersion: "85.0",
-					Image:          "selenoid/vnc:chrome_85.0",
-					Path:           "/",
-				},
-			},
-			eventType: watch.Added,
-			err:       errors.New("pod is not ready after creation: pod has unknown status"),
-		},
-		"Verify platform error on pod startup event Error": {
-			ns:      "selenosis",
-			podName: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911",
-			layout: ServiceSpec{
-				SessionID: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911",
-				RequestedCapabilities: selenium.Capabilities{
-					VNC: true,
-				},
-				Template: BrowserSpec{
-					BrowserName:    "chrome",
-					BrowserVersion: "85.0",
-					Image:          "selenoid/vnc:chrome_85.0",
-					Path:           "/",
-				},
+			podPhase: apiv1.PodPhase("Evicted"),
+			err:      errors.New("pod is not ready after creation: pod has unknown status"),
+		},
+		"Verify platform error on pod failed after it was pending": {
+			podPhase: apiv1.PodPending,
+			update:   setPhase(apiv1.PodFailed),
+			err:      errors.New("pod is not ready after creation: pod exited early with status Failed"),
+		},
+		"Verify platform error on pod deleted after it was pending": {
+			podPhase: apiv1.PodPending,
+			update: func(mock *fake.Clientset, pod *apiv1.Pod) {
+				mock.CoreV1().Pods(pod.Namespace).Delete(context.Background(), pod.Name, metav1.DeleteOptions{})
 			},
-			eventType: watch.Error,
-			podPhase:  apiv1.PodUnknown,
-			err:       errors.New("pod is not ready after creation: received error while watching pod: /, Kind="),
-		},
-		"Verify platform error on pod startup event Deleted": {
-			ns:      "selenosis",
-			podName: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911",
-			layout: ServiceSpec{
-				SessionID: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911",
-				RequestedCapabilities: selenium.Capabilities{
-					VNC: true,
-				},
-				Template: BrowserSpec{
-					BrowserName:    "chrome",
-					BrowserVersion: "85.0",
-					Image:          "selenoid/vnc:chrome_85.0",
-					Path:           "/",
-				},
+			err: errors.New("pod is not ready after creation: pod was deleted before becoming available"),
+		},
+		"Verify platform error on pod pending longer than readiness timeout": {
+			podPhase: apiv1.PodPending,
+			err:      errors.New("pod is not ready after creation: pod wasn't running after 200ms"),
+		},
+		"Verify platform error on pod with image pull failure reports image": {
+			podPhase: apiv1.PodPending,
+			containers: []apiv1.ContainerStatus{
+				{Name: "browser", Image: "selenoid/vnc:chrome_999", State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "Back-off pulling image"}}},
+				{Name: "seleniferous", Image: "seleniferous", State: apiv1.ContainerState{Running: &apiv1.ContainerStateRunning{}}},
 			},
-			eventType: watch.Deleted,
-			podPhase:  apiv1.PodUnknown,
-			err:       errors.New("pod is not ready after creation: pod was deleted before becoming available"),
-		},
-		"Verify platform error on pod startup event Unknown": {
-			ns:      "selenosis",
-			podName: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911",
-			layout: ServiceSpec{
-				SessionID: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911",
-				RequestedCapabilities: selenium.Capabilities{
-					VNC: true,
-				},
-				Template: BrowserSpec{
-					BrowserName:    "chrome",
-					BrowserVersion: "85.0",
-					Image:          "selenoid/vnc:chrome_85.0",
-					Path:           "/",
-				},
+			err: errors.New("pod is not ready after creation: pod wasn't running after 200ms: browser: ImagePullBackOff: selenoid/vnc:chrome_999"),
+		},
+		"Verify platform error on failed pod reports container termination": {
+			podPhase: apiv1.PodFailed,
+			containers: []apiv1.ContainerStatus{
+				{Name: "browser", State: apiv1.ContainerState{Terminated: &apiv1.ContainerStateTerminated{Reason: "Error", ExitCode: 1, Message: "Xvfb failed to start\n"}}},
 			},
-			eventType: watch.Bookmark,
-			podPhase:  apiv1.PodSucceeded,
-			err:       errors.New("pod is not ready after creation: received unknown event type BOOKMARK while watching pod"),
+			err: errors.New("pod is not ready after creation: pod exited early with status Failed: browser: Error with exit code 1: Xvfb failed to start"),
 		},
 	}
 
@@ -167,32 +102,300 @@ func TestErrorsOnServiceCreate(t *testing.T) {
 		t.Logf("TC: %s", name)
 
 		mock := fake.NewSimpleClientset()
-		watcher := watch.NewFakeWithChanSize(1, false)
-		mock.PrependWatchReactor("pods", testcore.DefaultWatchReactor(watcher, nil))
-		watcher.Action(test.eventType, &apiv1.Pod{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: test.podName,
-			},
-			Status: apiv1.PodStatus{
-				Phase: test.podPhase,
-			},
+		mock.PrependReactor("create", "pods", func(action testcore.Action) (bool, runtime.Object, error) {
+			pod := action.(testcore.CreateAction).GetObject().(*apiv1.Pod)
+			pod.Namespace = "selenosis"
+			pod.Status.Phase = test.podPhase
+			pod.Status.ContainerStatuses = test.containers
+			if test.update != nil {
+				created := pod.DeepCopy()
+				go func() {
+					time.Sleep(50 * time.Millisecond)
+					test.update(mock, created)
+				}()
+			}
+			return false, nil, nil
 		})
 
 		client := &Client{
-			ns:        test.ns,
+			ns:        "selenosis",
 			clientset: mock,
 			service: &service{
-				ns:        test.ns,
-				clientset: mock,
+				ns:               "selenosis",
+				clientset:        mock,
+				readinessTimeout: 200 * time.Millisecond,
 			},
 		}
 
-		_, err := client.Service().Create(test.layout)
+		_, err := client.Service().Create(layout)
 
 		assert.Equal(t, test.err.Error(), err.Error())
 	}
 }
 
+func TestBuildWindowsPod(t *testing.T) {
+	tests := map[string]struct {
+		template          BrowserSpec
+		windowsProxyImage string
+		proxyImage        string
+		selector          map[string]string
+		err               string
+	}{
+		"Verify linux pod uses default proxy image": {
+			template:          BrowserSpec{Image: "selenoid/vnc:chrome_85.0", Spec: Spec{NodeSelector: map[string]string{"nodeType": "N2D"}}},
+			windowsProxyImage: "alcounit/seleniferous:windows",
+			proxyImage:        "alcounit/seleniferous:latest",
+			selector:          map[string]string{"nodeType": "N2D"},
+		},
+		"Verify windows pod is pinned to windows nodes": {
+			template:          BrowserSpec{Image: "acme/edge:18", Platform: WindowsPlatform, Spec: Spec{NodeSelector: map[string]string{"nodeType": "N2D"}}},
+			windowsProxyImage: "alcounit/seleniferous:windows",
+			proxyImage:        "alcounit/seleniferous:windows",
+			selector:          map[string]string{"nodeType": "N2D", osLabel: WindowsPlatform},
+		},
+		"Verify windows pod requires windows proxy image": {
+			template: BrowserSpec{Image: "acme/edge:18", Platform: WindowsPlatform},
+			err:      "windows proxy image is not set",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		cl := &service{
+			ns:                "selenosis",
+			svc:               "seleniferous",
+			svcPort:           intstr.FromString("4445"),
+			proxyImage:        "alcounit/seleniferous:latest",
+			windowsProxyImage: test.windowsProxyImage,
+		}
+		privileged := true
+		test.template.Privileged = &privileged
+		test.template.RunAs = RunAsOptions{UserName: "ContainerUser"}
+
+		pod, err := cl.buildPod(ServiceSpec{SessionID: "session", Template: test.template})
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, test.proxyImage, pod.Spec.Containers[1].Image)
+		assert.DeepEqual(t, test.selector, pod.Spec.NodeSelector)
+
+		if test.template.Platform == WindowsPlatform {
+			assert.Assert(t, pod.Spec.Containers[0].SecurityContext.Privileged == nil)
+			assert.Equal(t, "ContainerUser", *pod.Spec.SecurityContext.WindowsOptions.RunAsUserName)
+		} else {
+			assert.Equal(t, true, *pod.Spec.Containers[0].SecurityContext.Privileged)
+		}
+	}
+}
+
+func TestBuildPodProxyResources(t *testing.T) {
+	tests := map[string]struct {
+		defaults  apiv1.ResourceRequirements
+		browser   apiv1.ResourceRequirements
+		resources apiv1.ResourceRequirements
+	}{
+		"Verify proxy container has no resources by default": {},
+		"Verify default proxy resources are set": {
+			defaults: apiv1.ResourceRequirements{
+				Requests: apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("100m"), apiv1.ResourceMemory: resource.MustParse("64Mi")},
+			},
+			resources: apiv1.ResourceRequirements{
+				Requests: apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("100m"), apiv1.ResourceMemory: resource.MustParse("64Mi")},
+			},
+		},
+		"Verify browser proxy resources override defaults": {
+			defaults: apiv1.ResourceRequirements{
+				Requests: apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("100m"), apiv1.ResourceMemory: resource.MustParse("64Mi")},
+			},
+			browser: apiv1.ResourceRequirements{
+				Requests: apiv1.ResourceList{apiv1.ResourceMemory: resource.MustParse("128Mi")},
+				Limits:   apiv1.ResourceList{apiv1.ResourceMemory: resource.MustParse("256Mi")},
+			},
+			resources: apiv1.ResourceRequirements{
+				Requests: apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("100m"), apiv1.ResourceMemory: resource.MustParse("128Mi")},
+				Limits:   apiv1.ResourceList{apiv1.ResourceMemory: resource.MustParse("256Mi")},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		cl := &service{
+			svcPort:        intstr.FromString("4445"),
+			proxyImage:     "alcounit/seleniferous:latest",
+			proxyResources: test.defaults,
+		}
+		template := BrowserSpec{Image: "selenoid/vnc:chrome_85.0", Spec: Spec{ProxyResources: test.browser}}
+
+		pod, err := cl.buildPod(ServiceSpec{SessionID: "session", Template: template})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, test.resources, pod.Spec.Containers[1].Resources)
+	}
+}
+
+func TestBuildPodIdleTimeout(t *testing.T) {
+	tests := map[string]struct {
+		requested time.Duration
+		timeout   string
+	}{
+		"Verify proxy gets default idle timeout": {
+			timeout: "5m0s",
+		},
+		"Verify proxy gets idle timeout requested for session": {
+			requested: 2 * time.Hour,
+			timeout:   "2h0m0s",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		cl := &service{
+			svcPort:     intstr.FromString("4445"),
+			proxyImage:  "alcounit/seleniferous:latest",
+			idleTimeout: 5 * time.Minute,
+		}
+		template := BrowserSpec{Image: "selenoid/vnc:chrome_85.0"}
+
+		pod, err := cl.buildPod(ServiceSpec{SessionID: "session", Template: template, IdleTimeout: test.requested})
+		assert.NilError(t, err)
+		proxy := pod.Spec.Containers[1]
+		assert.DeepEqual(t, []apiv1.EnvVar{{Name: idleTimeoutEnv, Value: test.timeout}}, proxy.Env)
+		assert.Equal(t, "$(IDLE_TIMEOUT)", proxy.Command[6])
+	}
+}
+
+func TestSetEnvAndMeta(t *testing.T) {
+	tests := map[string]struct {
+		caps        selenium.Capabilities
+		env         []apiv1.EnvVar
+		annotations map[string]string
+	}{
+		"Verify template env is kept": {
+			env:         []apiv1.EnvVar{{Name: "TZ", Value: "UTC"}, {Name: "LANG", Value: "en_US.UTF-8"}},
+			annotations: map[string]string{"TZ": "UTC"},
+		},
+		"Verify capabilities override template env": {
+			caps:        selenium.Capabilities{TimeZone: "Europe/Berlin", ScreenResolution: "1920x1080x24"},
+			env:         []apiv1.EnvVar{{Name: "TZ", Value: "Europe/Berlin"}, {Name: "LANG", Value: "en_US.UTF-8"}, {Name: "SCREEN_RESOLUTION", Value: "1920x1080x24"}},
+			annotations: map[string]string{"TZ": "Europe/Berlin", "SCREEN_RESOLUTION": "1920x1080x24"},
+		},
+		"Verify env of selenosis options is merged": {
+			caps:        selenium.Capabilities{Options: &selenium.SelenosisOptions{Env: []string{"LANG=de_DE.UTF-8", "DEBUG=1", "MALFORMED"}}},
+			env:         []apiv1.EnvVar{{Name: "TZ", Value: "UTC"}, {Name: "LANG", Value: "de_DE.UTF-8"}, {Name: "DEBUG", Value: "1"}},
+			annotations: map[string]string{"TZ": "UTC"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		layout := ServiceSpec{
+			RequestedCapabilities: test.caps,
+			Template: BrowserSpec{Spec: Spec{EnvVars: []apiv1.EnvVar{
+				{Name: "TZ", Value: "UTC"},
+				{Name: "LANG", Value: "en_US.UTF-8"},
+			}}},
+		}
+		annotations := make(map[string]string)
+		setEnvAndMeta(&layout, annotations)
+
+		assert.DeepEqual(t, test.env, layout.Template.Spec.EnvVars)
+		assert.DeepEqual(t, test.annotations, annotations)
+	}
+}
+
+func TestBuildPodAffinity(t *testing.T) {
+	antiAffinity := &apiv1.PodAntiAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []apiv1.WeightedPodAffinityTerm{
+			{
+				Weight: 100,
+				PodAffinityTerm: apiv1.PodAffinityTerm{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"selenosis.app.type": "browser"}},
+					TopologyKey:   "topology.kubernetes.io/zone",
+				},
+			},
+		},
+	}
+	nodeAffinity := &apiv1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &apiv1.NodeSelector{
+			NodeSelectorTerms: []apiv1.NodeSelectorTerm{
+				{
+					MatchExpressions: []apiv1.NodeSelectorRequirement{
+						{Key: "cloud.google.com/gke-nodepool", Operator: apiv1.NodeSelectorOpIn, Values: []string{"gpu"}},
+					},
+				},
+			},
+		},
+	}
+
+	archs := apiv1.NodeSelectorRequirement{Key: "kubernetes.io/arch", Operator: apiv1.NodeSelectorOpIn, Values: []string{"amd64", "arm64"}}
+	images := map[string]string{"arm64": "seleniarm/vnc:chrome_85.0", "amd64": "selenoid/vnc:chrome_85.0"}
+
+	tests := map[string]struct {
+		affinity apiv1.Affinity
+		images   map[string]string
+		selector map[string]string
+		expected *apiv1.Affinity
+	}{
+		"Verify pod has no affinity by default": {},
+		"Verify pod anti affinity is set": {
+			affinity: apiv1.Affinity{PodAntiAffinity: antiAffinity},
+			expected: &apiv1.Affinity{PodAntiAffinity: antiAffinity},
+		},
+		"Verify node and pod anti affinity are set": {
+			affinity: apiv1.Affinity{NodeAffinity: nodeAffinity, PodAntiAffinity: antiAffinity},
+			expected: &apiv1.Affinity{NodeAffinity: nodeAffinity, PodAntiAffinity: antiAffinity},
+		},
+		"Verify multi-arch pod is kept on nodes of image architectures": {
+			images: images,
+			expected: &apiv1.Affinity{NodeAffinity: &apiv1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &apiv1.NodeSelector{
+					NodeSelectorTerms: []apiv1.NodeSelectorTerm{{MatchExpressions: []apiv1.NodeSelectorRequirement{archs}}},
+				},
+			}},
+		},
+		"Verify image architectures are required by every node selector term": {
+			affinity: apiv1.Affinity{NodeAffinity: nodeAffinity},
+			images:   images,
+			expected: &apiv1.Affinity{NodeAffinity: &apiv1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &apiv1.NodeSelector{
+					NodeSelectorTerms: []apiv1.NodeSelectorTerm{{MatchExpressions: []apiv1.NodeSelectorRequirement{
+						nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions[0],
+						archs,
+					}}},
+				},
+			}},
+		},
+		"Verify pod pinned to architecture has no architecture affinity": {
+			images:   images,
+			selector: map[string]string{"kubernetes.io/arch": "arm64"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		cl := &service{
+			svcPort:    intstr.FromString("4445"),
+			proxyImage: "alcounit/seleniferous:latest",
+		}
+		template := BrowserSpec{Image: "selenoid/vnc:chrome_85.0", Images: test.images, Spec: Spec{Affinity: test.affinity, NodeSelector: test.selector}}
+
+		pod, err := cl.buildPod(ServiceSpec{SessionID: "session", Template: template})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, test.expected, pod.Spec.Affinity)
+		assert.Equal(t, 1, len(nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions))
+		if pod.Spec.Affinity != nil && pod.Spec.Affinity.PodAntiAffinity != nil {
+			assert.Assert(t, pod.Spec.Affinity.PodAntiAffinity != template.Spec.Affinity.PodAntiAffinity)
+		}
+	}
+}
+
 func TestPodDelete(t *testing.T) {
 	tests := map[string]struct {
 		ns           string
@@ -356,3 +559,145 @@ func TestListPods(t *testing.T) {
 		}
 	}
 }
+
+func TestSessionResources(t *testing.T) {
+	tests := map[string]struct {
+		ns        string
+		sessionID string
+		delete    Resource
+		resources []Resource
+		err       error
+	}{
+		"Verify platform lists and deletes session resources": {
+			ns:        "selenosis",
+			sessionID: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911",
+			delete:    Resource{Kind: "Secret", Name: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911"},
+			resources: []Resource{
+				{Kind: "ConfigMap", Name: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911", SessionID: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911"},
+			},
+		},
+		"Verify platform delete return error on unknown kind": {
+			ns:        "selenosis",
+			sessionID: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911",
+			delete:    Resource{Kind: "Deployment", Name: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911"},
+			resources: []Resource{
+				{Kind: "Secret", Name: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911", SessionID: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911"},
+				{Kind: "ConfigMap", Name: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911", SessionID: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da802144911"},
+			},
+			err: errors.New("unsupported resource kind Deployment"),
+		},
+	}
+
+	for name, test := range tests {
+
+		t.Logf("TC: %s", name)
+
+		mock := fake.NewSimpleClientset()
+		client := &Client{
+			ns:        test.ns,
+			clientset: mock,
+			resources: &resources{
+				ns:        test.ns,
+				clientset: mock,
+			},
+		}
+
+		ctx := context.Background()
+		meta := metav1.ObjectMeta{
+			Name:   test.sessionID,
+			Labels: SessionLabels(test.sessionID),
+		}
+		if _, err := mock.CoreV1().Secrets(test.ns).Create(ctx, &apiv1.Secret{ObjectMeta: meta}, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("failed to create fake secret: %v", err)
+		}
+		if _, err := mock.CoreV1().ConfigMaps(test.ns).Create(ctx, &apiv1.ConfigMap{ObjectMeta: meta}, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("failed to create fake configmap: %v", err)
+		}
+		if _, err := mock.CoreV1().ConfigMaps(test.ns).Create(ctx, &apiv1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "selenosis-config"}}, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("failed to create fake configmap: %v", err)
+		}
+
+		err := client.Resources().Delete(test.delete)
+		if test.err != nil {
+			assert.Equal(t, test.err.Error(), err.Error())
+		} else {
+			assert.NilError(t, err)
+		}
+
+		resources, err := client.Resources().List()
+		assert.NilError(t, err)
+		assert.DeepEqual(t, test.resources, resources)
+	}
+}
+
+func TestArtifactsLedger(t *testing.T) {
+	mock := fake.NewSimpleClientset()
+	_, err := mock.CoreV1().Secrets("selenosis").Create(context.Background(), &apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "contractors-s3"},
+		Data:       map[string][]byte{"AWS_ACCESS_KEY_ID": []byte("key")},
+	}, metav1.CreateOptions{})
+	assert.NilError(t, err)
+
+	client := &artifacts{ns: "selenosis", clientset: mock}
+
+	list, err := client.List("contractors")
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(list))
+
+	for _, a := range []Artifact{{URL: "s3://contractors/1.mp4", Size: 10}, {URL: "s3://contractors/2.mp4", Size: 20}} {
+		assert.NilError(t, client.Add("contractors", a))
+	}
+	assert.NilError(t, client.Add("", Artifact{URL: "s3://default/1.mp4", Size: 30}))
+	assert.NilError(t, client.Remove("contractors", []string{"s3://contractors/1.mp4"}))
+
+	list, err = client.List("contractors")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []Artifact{{URL: "s3://contractors/2.mp4", Size: 20}}, list)
+
+	list, err = client.List("")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []Artifact{{URL: "s3://default/1.mp4", Size: 30}}, list)
+
+	cm, err := mock.CoreV1().ConfigMaps("selenosis").Get(context.Background(), artifactsName, metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, "artifacts", cm.GetLabels()[label])
+
+	credentials, err := client.Credentials("contractors", "contractors-s3")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, map[string]string{"AWS_ACCESS_KEY_ID": "key"}, credentials)
+}
+
+func TestStateStartupDeadline(t *testing.T) {
+	deadline := time.Now().Add(time.Minute).UTC().Truncate(time.Second)
+	tests := map[string]struct {
+		annotations map[string]string
+		deadline    time.Time
+	}{
+		"Verify starting pod has startup deadline": {
+			annotations: map[string]string{startupAnnotation: deadline.Format(time.RFC3339)},
+			deadline:    deadline,
+		},
+		"Verify started pod has no startup deadline": {},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		mock := fake.NewSimpleClientset()
+		_, err := mock.CoreV1().Pods("selenosis").Create(context.Background(), &apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "chrome-85-0-starting",
+				Labels:      map[string]string{label: "browser"},
+				Annotations: test.annotations,
+			},
+			Status: apiv1.PodStatus{Phase: apiv1.PodRunning},
+		}, metav1.CreateOptions{})
+		assert.NilError(t, err)
+
+		client := &Client{ns: "selenosis", svc: "selenosis", svcPort: intstr.FromString("4445"), clientset: mock}
+		state, err := client.State()
+		assert.NilError(t, err)
+		assert.Equal(t, 1, len(state.Services))
+		assert.Assert(t, test.deadline.Equal(state.Services[0].Deadline))
+	}
+}
@@ -0,0 +1,86 @@
+package platform
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/alcounit/selenosis/selenium"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+//sessionLabelsAnnotation keeps labels requested with selenosis:options capability, so they are told apart
+//from labels of browser template and selenosis own labels
+const sessionLabelsAnnotation = "sessionLabels"
+
+//ownerLabel selects browser pods by owner of the session, value is sanitized so it is lossy for names
+//with characters not allowed in labels, exact owner is kept in capabilities annotation and used to authorize
+const ownerLabel = "selenosis.app.owner"
+
+//maxSessionLabels is how many labels session can request
+const maxSessionLabels = 16
+
+//invalidLabelValue matches characters not allowed in label values
+var invalidLabelValue = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+//RequestedLabels returns labels requested with selenosis:options capability. Keys should be valid label
+//keys not used by selenosis and kubernetes, values are sanitized: characters not allowed in label values
+//are replaced with -, value is cut to 63 characters and should start and end with alphanumeric character
+func RequestedLabels(caps selenium.Capabilities) (map[string]string, error) {
+	requested := caps.GetLabels()
+	if len(requested) == 0 {
+		return nil, nil
+	}
+	if len(requested) > maxSessionLabels {
+		return nil, fmt.Errorf("too many labels: %d, max %d", len(requested), maxSessionLabels)
+	}
+
+	labels := make(map[string]string, len(requested))
+	for k, v := range requested {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid label %s: %s", k, strings.Join(errs, ", "))
+		}
+		if reservedLabel(k) {
+			return nil, fmt.Errorf("label %s is reserved", k)
+		}
+		labels[k] = sanitizeLabelValue(v)
+	}
+	return labels, nil
+}
+
+func reservedLabel(key string) bool {
+	if key == defaultLabels.serviceType || key == defaultLabels.session || strings.HasPrefix(key, "selenosis.app.") {
+		return true
+	}
+	if i := strings.Index(key, "/"); i > 0 {
+		prefix := key[:i]
+		for _, domain := range []string{"kubernetes.io", "k8s.io"} {
+			if prefix == domain || strings.HasSuffix(prefix, "."+domain) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sanitizeLabelValue(value string) string {
+	value = invalidLabelValue.ReplaceAllString(value, "-")
+	if len(value) > validation.LabelValueMaxLength {
+		value = value[:validation.LabelValueMaxLength]
+	}
+	return strings.TrimFunc(value, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+}
+
+//getSessionLabels returns labels requested by the session from annotations of browser pod or labels of container
+func getSessionLabels(annotations map[string]string) map[string]string {
+	if v, ok := annotations[sessionLabelsAnnotation]; ok {
+		labels := make(map[string]string)
+		if err := json.Unmarshal([]byte(v), &labels); err == nil {
+			return labels
+		}
+	}
+	return nil
+}
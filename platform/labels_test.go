@@ -0,0 +1,85 @@
+package platform
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alcounit/selenosis/selenium"
+	"gotest.tools/assert"
+)
+
+func TestRequestedLabels(t *testing.T) {
+	tooMany := make(map[string]string)
+	for i := 0; i <= maxSessionLabels; i++ {
+		tooMany[fmt.Sprintf("label-%d", i)] = "value"
+	}
+
+	tests := map[string]struct {
+		labels    map[string]string
+		requested map[string]string
+		err       string
+	}{
+		"Verify no labels are requested": {},
+		"Verify valid labels are kept as is": {
+			labels:    map[string]string{"build": "1234", "example.com/suite": "smoke_tests"},
+			requested: map[string]string{"build": "1234", "example.com/suite": "smoke_tests"},
+		},
+		"Verify label values are sanitized": {
+			labels: map[string]string{
+				"ticket": "JIRA 123/login",
+				"suite":  "--checkout flow--",
+				"long":   strings.Repeat("a", 70),
+			},
+			requested: map[string]string{
+				"ticket": "JIRA-123-login",
+				"suite":  "checkout-flow",
+				"long":   strings.Repeat("a", 63),
+			},
+		},
+		"Verify invalid label key is rejected": {
+			labels: map[string]string{"test suite": "smoke"},
+			err:    "invalid label test suite: name part must consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyName',  or 'my.name',  or '123-abc', regex used for validation is '([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]')",
+		},
+		"Verify selenosis label is reserved": {
+			labels: map[string]string{"selenosis.app.type": "worker"},
+			err:    "label selenosis.app.type is reserved",
+		},
+		"Verify kubernetes label is reserved": {
+			labels: map[string]string{"node.kubernetes.io/instance-type": "m5.large"},
+			err:    "label node.kubernetes.io/instance-type is reserved",
+		},
+		"Verify too many labels are rejected": {
+			labels: tooMany,
+			err:    "too many labels: 17, max 16",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		caps := selenium.Capabilities{Options: &selenium.SelenosisOptions{Labels: test.labels}}
+		requested, err := RequestedLabels(caps)
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.DeepEqual(t, test.requested, requested)
+	}
+}
+
+func TestSessionLabelsOfContainer(t *testing.T) {
+	layout := ServiceSpec{
+		SessionID: "chrome-85-0",
+		RequestedCapabilities: selenium.Capabilities{
+			Options: &selenium.SelenosisOptions{Labels: map[string]string{"build": "1234"}},
+		},
+		Template: BrowserSpec{BrowserName: "chrome", BrowserVersion: "85.0", Image: "selenoid/vnc:chrome_85.0"},
+	}
+
+	spec := browserContainer(layout)
+	assert.Equal(t, "1234", spec.Labels["build"])
+	assert.Equal(t, "browser", spec.Labels[defaultLabels.serviceType])
+	assert.DeepEqual(t, map[string]string{"build": "1234"}, getSessionLabels(spec.Labels))
+}
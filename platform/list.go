@@ -0,0 +1,194 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+const (
+	//browserNameLabel and browserVersionLabel let browser pods be listed by browser with label selector
+	browserNameLabel    = "selenosis.app.browserName"
+	browserVersionLabel = "selenosis.app.browserVersion"
+)
+
+//ErrInvalidContinue is returned when continue token is malformed or expired
+var ErrInvalidContinue = errors.New("invalid or expired continue token")
+
+//ListOptions selects running sessions listed page by page, label selectors are key=value or key alone for
+//sessions having the label with any value. Continue is token of the next page returned with previous one
+type ListOptions struct {
+	BrowserName    string
+	BrowserVersion string
+	Labels         []string
+	Limit          int64
+	Continue       string
+}
+
+//Validate checks label selectors and limit
+func (o ListOptions) Validate() error {
+	if o.Limit < 0 {
+		return fmt.Errorf("invalid limit %d", o.Limit)
+	}
+	_, err := o.selector()
+	return err
+}
+
+//selector returns label selector of browser pods matching the options
+func (o ListOptions) selector() (labels.Selector, error) {
+	selector := labels.SelectorFromSet(labels.Set{label: "browser"})
+	add := func(key string, op selection.Operator, values ...string) error {
+		requirement, err := labels.NewRequirement(key, op, values)
+		if err != nil {
+			return fmt.Errorf("invalid label selector %s: %v", strings.Join(append([]string{key}, values...), "="), err)
+		}
+		selector = selector.Add(*requirement)
+		return nil
+	}
+	if o.BrowserName != "" {
+		if err := add(browserNameLabel, selection.Equals, sanitizeLabelValue(o.BrowserName)); err != nil {
+			return nil, err
+		}
+	}
+	if o.BrowserVersion != "" {
+		if err := add(browserVersionLabel, selection.Equals, sanitizeLabelValue(o.BrowserVersion)); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range o.Labels {
+		kv := strings.SplitN(s, "=", 2)
+		var err error
+		if len(kv) == 2 {
+			err = add(kv[0], selection.Equals, kv[1])
+		} else {
+			err = add(kv[0], selection.Exists)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return selector, nil
+}
+
+//SessionList is page of running sessions, Continue is set when more sessions are left
+type SessionList struct {
+	Services []Service
+	Continue string
+}
+
+//SessionLister is implemented by platforms filtering and paginating sessions where they are stored
+type SessionLister interface {
+	ListSessions(opts ListOptions) (SessionList, error)
+}
+
+//ListSessions returns page of running sessions of the platform, platforms not implementing SessionLister
+//have sessions of their state filtered and paginated in memory
+func ListSessions(p Platform, opts ListOptions) (SessionList, error) {
+	if lister, ok := p.(SessionLister); ok {
+		return lister.ListSessions(opts)
+	}
+	state, err := p.State()
+	if err != nil {
+		return SessionList{}, err
+	}
+	return PageSessions(state.Services, opts)
+}
+
+//PageSessions filters running sessions by options and returns page of them sorted by session id,
+//continue token is offset of the next page
+func PageSessions(services []Service, opts ListOptions) (SessionList, error) {
+	offset := 0
+	if opts.Continue != "" {
+		var err error
+		if offset, err = strconv.Atoi(opts.Continue); err != nil || offset < 0 {
+			return SessionList{}, ErrInvalidContinue
+		}
+	}
+
+	var matched []Service
+	for _, s := range services {
+		if s.Status != Running {
+			continue
+		}
+		if opts.BrowserName != "" && s.Labels[defaultsAnnotations.browserName] != opts.BrowserName {
+			continue
+		}
+		if opts.BrowserVersion != "" && s.Labels[defaultsAnnotations.browserVersion] != opts.BrowserVersion {
+			continue
+		}
+		if !MatchLabels(s.SessionLabels, opts.Labels) {
+			continue
+		}
+		matched = append(matched, s)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].SessionID < matched[j].SessionID
+	})
+
+	if offset > len(matched) {
+		offset = len(matched)
+	}
+	list := SessionList{Services: matched[offset:]}
+	if opts.Limit > 0 && int64(len(list.Services)) > opts.Limit {
+		list.Services = list.Services[:opts.Limit]
+		list.Continue = strconv.Itoa(offset + int(opts.Limit))
+	}
+	return list, nil
+}
+
+//MatchLabels reports if labels match every selector, selector is key=value or key alone for label
+//of any value
+func MatchLabels(labels map[string]string, selectors []string) bool {
+	for _, selector := range selectors {
+		kv := strings.SplitN(selector, "=", 2)
+		value, ok := labels[kv[0]]
+		if !ok || len(kv) == 2 && value != kv[1] {
+			return false
+		}
+	}
+	return true
+}
+
+//ListSessions lists running browser pods with label selector and pagination of API server, so large
+//namespaces are not listed at once
+func (cl *Client) ListSessions(opts ListOptions) (SessionList, error) {
+	selector, err := opts.selector()
+	if err != nil {
+		return SessionList{}, err
+	}
+	pods, err := cl.clientset.CoreV1().Pods(cl.ns).List(context.Background(), metav1.ListOptions{
+		LabelSelector: selector.String(),
+		FieldSelector: "status.phase=" + string(apiv1.PodRunning),
+		Limit:         opts.Limit,
+		Continue:      opts.Continue,
+	})
+	if apierrors.IsResourceExpired(err) || apierrors.IsBadRequest(err) && opts.Continue != "" {
+		return SessionList{}, ErrInvalidContinue
+	}
+	if err != nil {
+		return SessionList{}, fmt.Errorf("failed to list pods: %v", err)
+	}
+
+	list := SessionList{Continue: pods.Continue}
+	for i := range pods.Items {
+		list.Services = append(list.Services, cl.podService(&pods.Items[i], Running))
+	}
+	return list, nil
+}
+
+//browserLabels returns labels of browser name and version of the template
+func browserLabels(template BrowserSpec) map[string]string {
+	return map[string]string{
+		browserNameLabel:    sanitizeLabelValue(template.BrowserName),
+		browserVersionLabel: sanitizeLabelValue(template.BrowserVersion),
+	}
+}
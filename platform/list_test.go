@@ -0,0 +1,168 @@
+package platform
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	testcore "k8s.io/client-go/testing"
+)
+
+type statePlatform struct {
+	platformStub
+	services []Service
+}
+
+func (p *statePlatform) State() (PlatformState, error) {
+	return PlatformState{Services: p.services}, nil
+}
+
+func runningSessions(ids ...string) []Service {
+	var services []Service
+	for _, id := range ids {
+		services = append(services, Service{SessionID: id, Status: Running})
+	}
+	return services
+}
+
+func sessionIDs(services []Service) []string {
+	ids := make([]string, 0)
+	for _, s := range services {
+		ids = append(ids, s.SessionID)
+	}
+	return ids
+}
+
+func TestListOptionsSelector(t *testing.T) {
+	tests := map[string]struct {
+		opts     ListOptions
+		selector string
+		err      string
+	}{
+		"Verify browser pods are selected without filters": {
+			selector: "selenosis.app.type=browser",
+		},
+		"Verify browser name and version are selected by labels": {
+			opts:     ListOptions{BrowserName: "chrome", BrowserVersion: "85.0"},
+			selector: "selenosis.app.browserName=chrome,selenosis.app.browserVersion=85.0,selenosis.app.type=browser",
+		},
+		"Verify session labels are selected by value and by key": {
+			opts:     ListOptions{Labels: []string{"build=1234", "ticket"}},
+			selector: "build=1234,selenosis.app.type=browser,ticket",
+		},
+		"Verify invalid label selector is rejected": {
+			opts: ListOptions{Labels: []string{"test suite=smoke"}},
+			err:  "invalid label selector test suite=smoke",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		selector, err := test.opts.selector()
+		if test.err != "" {
+			assert.Assert(t, err != nil && strings.HasPrefix(err.Error(), test.err), err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, test.selector, selector.String())
+	}
+}
+
+func TestPageSessions(t *testing.T) {
+	services := append(runningSessions("chrome-85-0-c", "chrome-85-0-a", "chrome-85-0-b"), Service{SessionID: "chrome-85-0-pending", Status: Pending})
+
+	tests := map[string]struct {
+		opts     ListOptions
+		sessions []string
+		cont     string
+		err      error
+	}{
+		"Verify running sessions are listed sorted": {
+			sessions: []string{"chrome-85-0-a", "chrome-85-0-b", "chrome-85-0-c"},
+		},
+		"Verify first page returns continue token": {
+			opts:     ListOptions{Limit: 2},
+			sessions: []string{"chrome-85-0-a", "chrome-85-0-b"},
+			cont:     "2",
+		},
+		"Verify last page has no continue token": {
+			opts:     ListOptions{Limit: 2, Continue: "2"},
+			sessions: []string{"chrome-85-0-c"},
+		},
+		"Verify invalid continue token is rejected": {
+			opts: ListOptions{Continue: "-1"},
+			err:  ErrInvalidContinue,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		list, err := PageSessions(services, test.opts)
+		if test.err != nil {
+			assert.Equal(t, test.err, err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.DeepEqual(t, test.sessions, sessionIDs(list.Services))
+		assert.Equal(t, test.cont, list.Continue)
+	}
+}
+
+func TestClientListSessions(t *testing.T) {
+	mock := fake.NewSimpleClientset()
+	for name, labels := range map[string]map[string]string{
+		"chrome-85-0-a":  {label: "browser", browserNameLabel: "chrome", browserVersionLabel: "85.0", "build": "1234"},
+		"chrome-85-0-b":  {label: "browser", browserNameLabel: "chrome", browserVersionLabel: "85.0"},
+		"firefox-81-0-c": {label: "browser", browserNameLabel: "firefox", browserVersionLabel: "81.0"},
+	} {
+		_, err := mock.CoreV1().Pods("selenosis").Create(context.Background(), &apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Status:     apiv1.PodStatus{Phase: apiv1.PodRunning},
+		}, metav1.CreateOptions{})
+		assert.NilError(t, err)
+	}
+	var restrictions testcore.ListRestrictions
+	mock.PrependReactor("list", "pods", func(action testcore.Action) (bool, runtime.Object, error) {
+		restrictions = action.(testcore.ListAction).GetListRestrictions()
+		return false, nil, nil
+	})
+
+	client := newTenantsClient(mock, "selenosis", false)
+	list, err := client.ListSessions(ListOptions{BrowserName: "chrome", Labels: []string{"build"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"chrome-85-0-a"}, sessionIDs(list.Services))
+	assert.Equal(t, Running, list.Services[0].Status)
+	assert.Equal(t, "status.phase=Running", restrictions.Fields.String())
+}
+
+func TestTenantsListSessions(t *testing.T) {
+	tenants := NewTenants(&statePlatform{services: runningSessions("default-a", "default-b")})
+	tenants.tenants["team-b"] = &statePlatform{services: runningSessions("team-b-a")}
+	tenants.tenants["team-a"] = &statePlatform{services: runningSessions("team-a-a", "team-a-b")}
+
+	var ids []string
+	var pages int
+	opts := ListOptions{Limit: 2}
+	for {
+		list, err := tenants.ListSessions(opts)
+		assert.NilError(t, err)
+		ids = append(ids, sessionIDs(list.Services)...)
+		pages++
+		if list.Continue == "" {
+			break
+		}
+		opts.Continue = list.Continue
+	}
+	assert.DeepEqual(t, []string{"default-a", "default-b", "team-a-a", "team-a-b", "team-b-a"}, ids)
+	assert.Equal(t, 3, pages)
+
+	_, err := tenants.ListSessions(ListOptions{Continue: "5/"})
+	assert.Equal(t, ErrInvalidContinue, err)
+}
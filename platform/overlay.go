@@ -0,0 +1,45 @@
+package platform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+//applyOverlay strategically merges overlay over generated pod, lists with merge patch strategy
+//(containers, env, volumes, volume mounts...) are merged by their patch merge key, other lists are
+//replaced and null removes the field. $patch directives replace or delete objects and list items
+func applyOverlay(pod *apiv1.Pod, overlay map[string]interface{}) (*apiv1.Pod, error) {
+	if len(overlay) == 0 {
+		return pod, nil
+	}
+
+	original, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert pod: %v", err)
+	}
+
+	merged, err := strategicpatch.StrategicMergeMapPatch(original, overlay, apiv1.Pod{})
+	if err != nil {
+		return nil, fmt.Errorf("invalid pod overlay: %v", err)
+	}
+	if len(merged) == 0 {
+		return nil, fmt.Errorf("invalid pod overlay: pod can not be deleted")
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal pod: %v", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	result := &apiv1.Pod{}
+	if err := decoder.Decode(result); err != nil {
+		return nil, fmt.Errorf("invalid pod overlay: %v", err)
+	}
+	return result, nil
+}
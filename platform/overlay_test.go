@@ -0,0 +1,123 @@
+package platform
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+func TestApplyOverlay(t *testing.T) {
+	tests := map[string]struct {
+		overlay string
+		verify  func(t *testing.T, pod *apiv1.Pod)
+		err     string
+	}{
+		"Verify containers and env are merged by name": {
+			overlay: `
+spec:
+  containers:
+  - name: browser
+    env:
+    - name: TZ
+      value: Europe/Berlin
+    - name: LANG
+      value: de_DE.UTF-8
+  - name: video
+    image: selenoid/video-recorder:latest
+`,
+			verify: func(t *testing.T, pod *apiv1.Pod) {
+				containers := make(map[string]apiv1.Container)
+				for _, c := range pod.Spec.Containers {
+					containers[c.Name] = c
+				}
+				assert.Equal(t, 3, len(containers))
+				assert.Equal(t, "selenoid/vnc:chrome_85.0", containers["browser"].Image)
+				assert.DeepEqual(t, []apiv1.EnvVar{{Name: "TZ", Value: "Europe/Berlin"}, {Name: "LANG", Value: "de_DE.UTF-8"}}, containers["browser"].Env)
+				assert.Assert(t, containers["seleniferous"].Image != "")
+				assert.Equal(t, "selenoid/video-recorder:latest", containers["video"].Image)
+			},
+		},
+		"Verify lists without merge strategy are replaced and null removes field": {
+			overlay: `
+spec:
+  tolerations:
+  - key: dedicated
+    operator: Exists
+  nodeSelector: null
+  priorityClassName: browsers
+`,
+			verify: func(t *testing.T, pod *apiv1.Pod) {
+				assert.DeepEqual(t, []apiv1.Toleration{{Key: "dedicated", Operator: apiv1.TolerationOpExists}}, pod.Spec.Tolerations)
+				assert.Assert(t, pod.Spec.NodeSelector == nil)
+				assert.Equal(t, "browsers", pod.Spec.PriorityClassName)
+			},
+		},
+		"Verify unknown field is rejected": {
+			overlay: `
+spec:
+  containers:
+  - name: browser
+    imagePolicy: Always
+`,
+			err: `invalid pod overlay: json: unknown field "imagePolicy"`,
+		},
+		"Verify patch directives replace and delete": {
+			overlay: `
+spec:
+  nodeSelector:
+    $patch: replace
+    pool: browsers
+  containers:
+  - name: browser
+    env:
+    - $patch: replace
+    - name: LANG
+      value: de_DE.UTF-8
+  - name: seleniferous
+    $patch: delete
+`,
+			verify: func(t *testing.T, pod *apiv1.Pod) {
+				assert.DeepEqual(t, map[string]string{"pool": "browsers"}, pod.Spec.NodeSelector)
+				assert.Equal(t, 1, len(pod.Spec.Containers))
+				assert.DeepEqual(t, []apiv1.EnvVar{{Name: "LANG", Value: "de_DE.UTF-8"}}, pod.Spec.Containers[0].Env)
+			},
+		},
+		"Verify unknown patch directive is rejected": {
+			overlay: `
+spec:
+  securityContext:
+    $patch: merge
+`,
+			err: `invalid pod overlay: json: unknown field "$patch"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		var overlay map[string]interface{}
+		assert.NilError(t, yaml.NewYAMLOrJSONDecoder(strings.NewReader(test.overlay), 1000).Decode(&overlay))
+
+		pod := &apiv1.Pod{
+			Spec: apiv1.PodSpec{
+				Containers: []apiv1.Container{
+					{Name: "browser", Image: "selenoid/vnc:chrome_85.0", Env: []apiv1.EnvVar{{Name: "TZ", Value: "UTC"}}},
+					{Name: "seleniferous", Image: "alcounit/seleniferous:latest"},
+				},
+				NodeSelector: map[string]string{"nodeType": "N2D"},
+				Tolerations:  []apiv1.Toleration{{Key: "browsers", Operator: apiv1.TolerationOpExists}},
+			},
+		}
+
+		result, err := applyOverlay(pod, overlay)
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+		test.verify(t, result)
+	}
+}
@@ -0,0 +1,255 @@
+package platform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+//PatchOperation is JSON patch (RFC 6902) operation applied to generated browser pod
+type PatchOperation struct {
+	Op    string      `yaml:"op" json:"op"`
+	Path  string      `yaml:"path" json:"path"`
+	From  string      `yaml:"from,omitempty" json:"from,omitempty"`
+	Value interface{} `yaml:"value" json:"value"`
+}
+
+//ValidateTemplate builds pod of browser template without creating it, template with invalid
+//overlay or patches is reported
+func ValidateTemplate(template BrowserSpec) error {
+	cl := &service{
+		svcPort:           intstr.FromString("4445"),
+		proxyImage:        "seleniferous",
+		windowsProxyImage: "seleniferous",
+	}
+	_, err := cl.buildPod(ServiceSpec{SessionID: "validation", Template: template})
+	return err
+}
+
+//applyPatches applies JSON patch operations to pod in order, pod is not changed if any operation fails
+func applyPatches(pod *apiv1.Pod, patches []PatchOperation) (*apiv1.Pod, error) {
+	if len(patches) == 0 {
+		return pod, nil
+	}
+
+	data, err := json.Marshal(pod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal pod: %v", err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pod: %v", err)
+	}
+
+	for i, patch := range patches {
+		doc, err = patch.apply(doc)
+		if err != nil {
+			return nil, fmt.Errorf("pod patch %d: %s %s: %v", i, patch.Op, patch.Path, err)
+		}
+	}
+
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal pod: %v", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	result := &apiv1.Pod{}
+	if err := decoder.Decode(result); err != nil {
+		return nil, fmt.Errorf("invalid pod patches: %v", err)
+	}
+	return result, nil
+}
+
+func (p PatchOperation) apply(doc interface{}) (interface{}, error) {
+	path, err := jsonPointer(p.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.Op {
+	case "add":
+		return addValue(doc, path, p.Value)
+	case "remove":
+		return removeValue(doc, path)
+	case "replace":
+		if _, err := getValue(doc, path); err != nil {
+			return nil, err
+		}
+		if doc, err = removeValue(doc, path); err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, p.Value)
+	case "move", "copy":
+		from, err := jsonPointer(p.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := getValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if p.Op == "move" {
+			if doc, err = removeValue(doc, from); err != nil {
+				return nil, err
+			}
+		} else if value, err = deepCopy(value); err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, value)
+	case "test":
+		value, err := getValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(value, p.Value) {
+			return nil, fmt.Errorf("value is %v", value)
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown operation")
+}
+
+//jsonPointer splits JSON pointer (RFC 6901) to reference tokens
+func jsonPointer(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("path should start with /")
+	}
+	tokens := strings.Split(path[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func getValue(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch c := doc.(type) {
+		case map[string]interface{}:
+			value, ok := c[token]
+			if !ok {
+				return nil, fmt.Errorf("field %s not found", token)
+			}
+			doc = value
+		case []interface{}:
+			i, err := index(token, len(c)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = c[i]
+		default:
+			return nil, fmt.Errorf("%s is not a field or index", token)
+		}
+	}
+	return doc, nil
+}
+
+func addValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(doc, path, func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			c[token] = value
+			return c, nil
+		case []interface{}:
+			if token == "-" {
+				return append(c, value), nil
+			}
+			i, err := index(token, len(c))
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		}
+		return nil, fmt.Errorf("%s is not a field or index", token)
+	})
+}
+
+func removeValue(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("pod can't be removed")
+	}
+	return update(doc, path, func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			if _, ok := c[token]; !ok {
+				return nil, fmt.Errorf("field %s not found", token)
+			}
+			delete(c, token)
+			return c, nil
+		case []interface{}:
+			i, err := index(token, len(c)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(c[:i], c[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("%s is not a field or index", token)
+	})
+}
+
+//update applies fn to container of the last path token, updated containers are put back to their parents
+func update(doc interface{}, path []string, fn func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+
+	token := path[0]
+	switch c := doc.(type) {
+	case map[string]interface{}:
+		child, ok := c[token]
+		if !ok {
+			return nil, fmt.Errorf("field %s not found", token)
+		}
+		updated, err := update(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		c[token] = updated
+		return c, nil
+	case []interface{}:
+		i, err := index(token, len(c)-1)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := update(c[i], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = updated
+		return c, nil
+	}
+	return nil, fmt.Errorf("%s is not a field or index", token)
+}
+
+func index(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max {
+		return 0, fmt.Errorf("index %s is out of range", token)
+	}
+	return i, nil
+}
+
+func deepCopy(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
@@ -0,0 +1,84 @@
+package platform
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApplyPatches(t *testing.T) {
+	tests := map[string]struct {
+		patches []PatchOperation
+		verify  func(t *testing.T, pod *apiv1.Pod)
+		err     string
+	}{
+		"Verify operations are applied in order": {
+			patches: []PatchOperation{
+				{Op: "add", Path: "/spec/containers/1/env", Value: []interface{}{}},
+				{Op: "add", Path: "/spec/containers/1/env/-", Value: map[string]interface{}{"name": "VIDEO_CODEC", "value": "libx264"}},
+				{Op: "replace", Path: "/spec/containers/0/image", Value: "acme/chrome:85.0"},
+				{Op: "copy", From: "/spec/containers/1/env/0", Path: "/spec/containers/0/env/0"},
+				{Op: "remove", Path: "/spec/tolerations"},
+				{Op: "add", Path: "/metadata/annotations/acme.io~1team", Value: "qa"},
+			},
+			verify: func(t *testing.T, pod *apiv1.Pod) {
+				assert.Equal(t, "acme/chrome:85.0", pod.Spec.Containers[0].Image)
+				assert.DeepEqual(t, []apiv1.EnvVar{{Name: "VIDEO_CODEC", Value: "libx264"}, {Name: "TZ", Value: "UTC"}}, pod.Spec.Containers[0].Env)
+				assert.DeepEqual(t, []apiv1.EnvVar{{Name: "VIDEO_CODEC", Value: "libx264"}}, pod.Spec.Containers[1].Env)
+				assert.Assert(t, pod.Spec.Tolerations == nil)
+				assert.Equal(t, "qa", pod.Annotations["acme.io/team"])
+			},
+		},
+		"Verify failed test operation rejects patches": {
+			patches: []PatchOperation{
+				{Op: "test", Path: "/spec/containers/0/name", Value: "video"},
+			},
+			err: "pod patch 0: test /spec/containers/0/name: value is browser",
+		},
+		"Verify missing path is rejected": {
+			patches: []PatchOperation{
+				{Op: "add", Path: "/spec/containers/2/env/-", Value: map[string]interface{}{"name": "TZ"}},
+			},
+			err: "pod patch 0: add /spec/containers/2/env/-: index 2 is out of range",
+		},
+		"Verify patched pod with unknown field is rejected": {
+			patches: []PatchOperation{
+				{Op: "add", Path: "/spec/containers/0/imagePolicy", Value: "Always"},
+			},
+			err: `invalid pod patches: json: unknown field "imagePolicy"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		pod := &apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"testName": "login"}},
+			Spec: apiv1.PodSpec{
+				Containers: []apiv1.Container{
+					{Name: "browser", Image: "selenoid/vnc:chrome_85.0", Env: []apiv1.EnvVar{{Name: "TZ", Value: "UTC"}}},
+					{Name: "seleniferous", Image: "alcounit/seleniferous:latest"},
+				},
+				Tolerations: []apiv1.Toleration{{Key: "browsers", Operator: apiv1.TolerationOpExists}},
+			},
+		}
+
+		result, err := applyPatches(pod, test.patches)
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+		test.verify(t, result)
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	template := BrowserSpec{Image: "selenoid/vnc:chrome_85.0"}
+	assert.NilError(t, ValidateTemplate(template))
+
+	template.PodPatches = []PatchOperation{{Op: "replace", Path: "/spec/containers/2/image", Value: "selenoid/video-recorder"}}
+	assert.Error(t, ValidateTemplate(template), "pod patch 0: replace /spec/containers/2/image: index 2 is out of range")
+}
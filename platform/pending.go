@@ -0,0 +1,81 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alcounit/selenosis/metrics"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/fields"
+)
+
+//pendingReason returns why pod is stuck pending
+func (cl *service) pendingReason(pod *apiv1.Pod) (string, string) {
+	var events []apiv1.Event
+	list, err := cl.clientset.CoreV1().Events(cl.ns).List(context.Background(), metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("involvedObject.name", pod.GetName()).String(),
+	})
+	if err == nil {
+		events = list.Items
+	}
+	return podPendingReason(pod, events)
+}
+
+//podPendingReason checks scheduler condition first, then the last warning event of the pod
+//(e.g. volume attach failures) and waiting containers
+func podPendingReason(pod *apiv1.Pod, events []apiv1.Event) (string, string) {
+	if reason, message, ok := unschedulable(pod); ok {
+		return reason, message
+	}
+	if reason, message, ok := lastWarning(events); ok {
+		return reason, message
+	}
+	if reason, message, ok := containerWaiting(pod); ok {
+		return reason, message
+	}
+	return "Unknown", "no reason reported"
+}
+
+func unschedulable(pod *apiv1.Pod) (string, string, bool) {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == apiv1.PodScheduled && c.Status == apiv1.ConditionFalse {
+			return c.Reason, c.Message, c.Reason != ""
+		}
+	}
+	return "", "", false
+}
+
+func lastWarning(events []apiv1.Event) (string, string, bool) {
+	var last *apiv1.Event
+	for i := range events {
+		e := &events[i]
+		if e.Type != apiv1.EventTypeWarning {
+			continue
+		}
+		if last == nil || !e.LastTimestamp.Before(&last.LastTimestamp) {
+			last = e
+		}
+	}
+	if last == nil {
+		return "", "", false
+	}
+	return last.Reason, last.Message, true
+}
+
+func containerWaiting(pod *apiv1.Pod) (string, string, bool) {
+	statuses := append(append([]apiv1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, s := range statuses {
+		if s.State.Waiting != nil && s.State.Waiting.Reason != "" {
+			return s.State.Waiting.Reason, s.State.Waiting.Message, true
+		}
+	}
+	return "", "", false
+}
+
+//cancelPending returns error of pod stuck pending, pod is deleted by the caller
+func (cl *service) cancelPending(pod *apiv1.Pod) error {
+	reason, message := cl.pendingReason(pod)
+	metrics.PendingPodsCancelled.WithLabelValues(reason).Inc()
+	return fmt.Errorf("pod is pending longer than %s: %s: %s", cl.pendingTimeout, reason, message)
+}
@@ -0,0 +1,74 @@
+package platform
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodPendingReason(t *testing.T) {
+	now := time.Now()
+
+	tests := map[string]struct {
+		status  apiv1.PodStatus
+		events  []apiv1.Event
+		reason  string
+		message string
+	}{
+		"Verify scheduler reason is reported": {
+			status: apiv1.PodStatus{
+				Phase: apiv1.PodPending,
+				Conditions: []apiv1.PodCondition{
+					{Type: apiv1.PodScheduled, Status: apiv1.ConditionFalse, Reason: apiv1.PodReasonUnschedulable, Message: "0/3 nodes are available: 3 Insufficient cpu."},
+				},
+			},
+			events: []apiv1.Event{
+				{Type: apiv1.EventTypeWarning, Reason: "FailedScheduling", Message: "0/3 nodes are available: 3 Insufficient cpu.", LastTimestamp: metav1.NewTime(now)},
+			},
+			reason:  "Unschedulable",
+			message: "0/3 nodes are available: 3 Insufficient cpu.",
+		},
+		"Verify last warning event is reported for scheduled pod": {
+			status: apiv1.PodStatus{
+				Phase:      apiv1.PodPending,
+				Conditions: []apiv1.PodCondition{{Type: apiv1.PodScheduled, Status: apiv1.ConditionTrue}},
+				ContainerStatuses: []apiv1.ContainerStatus{
+					{Name: "browser", State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "ContainerCreating"}}},
+				},
+			},
+			events: []apiv1.Event{
+				{Type: apiv1.EventTypeWarning, Reason: "FailedAttachVolume", Message: "AttachVolume.Attach failed for volume \"downloads\"", LastTimestamp: metav1.NewTime(now)},
+				{Type: apiv1.EventTypeNormal, Reason: "Scheduled", Message: "Successfully assigned", LastTimestamp: metav1.NewTime(now.Add(time.Second))},
+				{Type: apiv1.EventTypeWarning, Reason: "FailedScheduling", Message: "persistentvolumeclaim not found", LastTimestamp: metav1.NewTime(now.Add(-time.Minute))},
+			},
+			reason:  "FailedAttachVolume",
+			message: "AttachVolume.Attach failed for volume \"downloads\"",
+		},
+		"Verify waiting container is reported without events": {
+			status: apiv1.PodStatus{
+				Phase: apiv1.PodPending,
+				ContainerStatuses: []apiv1.ContainerStatus{
+					{Name: "browser", State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "Back-off pulling image"}}},
+				},
+			},
+			reason:  "ImagePullBackOff",
+			message: "Back-off pulling image",
+		},
+		"Verify unknown reason": {
+			status:  apiv1.PodStatus{Phase: apiv1.PodPending},
+			reason:  "Unknown",
+			message: "no reason reported",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		reason, message := podPendingReason(&apiv1.Pod{Status: test.status}, test.events)
+		assert.Equal(t, test.reason, reason)
+		assert.Equal(t, test.message, message)
+	}
+}
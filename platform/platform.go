@@ -2,8 +2,11 @@ package platform
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net/url"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/alcounit/selenosis/selenium"
@@ -18,51 +21,281 @@ type Meta struct {
 
 //Spec describes specification for Service
 type Spec struct {
-	Resources    apiv1.ResourceRequirements `yaml:"resources,omitempty" json:"resources,omitempty"`
-	HostAliases  []apiv1.HostAlias          `yaml:"hostAliases,omitempty" json:"hostAliases,omitempty"`
-	EnvVars      []apiv1.EnvVar             `yaml:"env,omitempty" json:"env,omitempty"`
-	NodeSelector map[string]string          `yaml:"nodeSelector,omitempty" json:"nodeSelector,omitempty"`
-	Affinity     apiv1.Affinity             `yaml:"affinity,omitempty" json:"affinity,omitempty"`
-	DNSConfig    apiv1.PodDNSConfig         `yaml:"dnsConfig,omitempty" json:"dnsConfig,omitempty"`
-	Tolerations  []apiv1.Toleration         `yaml:"tolerations,omitempty" json:"tolerations,omitempty"`
-	VolumeMounts []apiv1.VolumeMount        `yaml:"volumeMounts,omitempty" json:"volumeMounts,omitempty"`
+	Resources      apiv1.ResourceRequirements `yaml:"resources,omitempty" json:"resources,omitempty"`
+	ProxyResources apiv1.ResourceRequirements `yaml:"proxyResources,omitempty" json:"proxyResources,omitempty"`
+	HostAliases    []apiv1.HostAlias          `yaml:"hostAliases,omitempty" json:"hostAliases,omitempty"`
+	EnvVars        []apiv1.EnvVar             `yaml:"env,omitempty" json:"env,omitempty"`
+	NodeSelector   map[string]string          `yaml:"nodeSelector,omitempty" json:"nodeSelector,omitempty"`
+	Affinity       apiv1.Affinity             `yaml:"affinity,omitempty" json:"affinity,omitempty"`
+	DNSConfig      apiv1.PodDNSConfig         `yaml:"dnsConfig,omitempty" json:"dnsConfig,omitempty"`
+	Tolerations    []apiv1.Toleration         `yaml:"tolerations,omitempty" json:"tolerations,omitempty"`
+	VolumeMounts   []apiv1.VolumeMount        `yaml:"volumeMounts,omitempty" json:"volumeMounts,omitempty"`
+	Sidecars       []apiv1.Container          `yaml:"sidecars,omitempty" json:"sidecars,omitempty"`
+	//SecurityContext of browser container, ProxySecurityContext of seleniferous and other containers selenosis adds
+	SecurityContext      *apiv1.SecurityContext    `yaml:"securityContext,omitempty" json:"securityContext,omitempty"`
+	ProxySecurityContext *apiv1.SecurityContext    `yaml:"proxySecurityContext,omitempty" json:"proxySecurityContext,omitempty"`
+	PodSecurityContext   *apiv1.PodSecurityContext `yaml:"podSecurityContext,omitempty" json:"podSecurityContext,omitempty"`
 }
 type RunAsOptions struct {
 	RunAsUser  *int64 `yaml:"uid,omitempty" json:"uid,omitempty"`
 	RunAsGroup *int64 `yaml:"gid,omitempty" json:"gid,omitempty"`
+	UserName   string `yaml:"userName,omitempty" json:"userName,omitempty"`
 }
 
 //BrowserSpec describes settings for Service
 type BrowserSpec struct {
-	BrowserName    string             `yaml:"-" json:"-"`
-	BrowserVersion string             `yaml:"-" json:"-"`
-	Image          string             `yaml:"image" json:"image"`
-	Path           string             `yaml:"path" json:"path"`
-	Privileged     *bool              `yaml:"privileged" json:"privileged"`
-	Meta           Meta               `yaml:"meta" json:"meta"`
-	Spec           Spec               `yaml:"spec" json:"spec"`
-	Volumes        []apiv1.Volume     `yaml:"volumes,omitempty" json:"volumes,omitempty"`
-	Capabilities   []apiv1.Capability `yaml:"kernelCaps,omitempty" json:"kernelCaps,omitempty"`
-	RunAs          RunAsOptions       `yaml:"runAs,omitempty" json:"runAs,omitempty"`
+	BrowserName    string                 `yaml:"-" json:"-"`
+	BrowserVersion string                 `yaml:"-" json:"-"`
+	Image          string                 `yaml:"image" json:"image"`
+	Images         map[string]string      `yaml:"images,omitempty" json:"images,omitempty"`
+	Path           string                 `yaml:"path" json:"path"`
+	Privileged     *bool                  `yaml:"privileged" json:"privileged"`
+	Meta           Meta                   `yaml:"meta" json:"meta"`
+	Spec           Spec                   `yaml:"spec" json:"spec"`
+	Volumes        []apiv1.Volume         `yaml:"volumes,omitempty" json:"volumes,omitempty"`
+	Capabilities   []apiv1.Capability     `yaml:"kernelCaps,omitempty" json:"kernelCaps,omitempty"`
+	RunAs          RunAsOptions           `yaml:"runAs,omitempty" json:"runAs,omitempty"`
+	Platform       string                 `yaml:"platform,omitempty" json:"platform,omitempty"`
+	Type           string                 `yaml:"type,omitempty" json:"type,omitempty"`
+	Relay          string                 `yaml:"relay,omitempty" json:"relay,omitempty"`
+	Cloud          *CloudSpec             `yaml:"cloud,omitempty" json:"cloud,omitempty"`
+	VM             *VMSpec                `yaml:"vm,omitempty" json:"vm,omitempty"`
+	Video          *VideoSpec             `yaml:"video,omitempty" json:"video,omitempty"`
+	Workspace      *WorkspaceSpec         `yaml:"workspace,omitempty" json:"workspace,omitempty"`
+	ProfilePath    string                 `yaml:"profilePath,omitempty" json:"profilePath,omitempty"`
+	FilesPath      string                 `yaml:"filesPath,omitempty" json:"filesPath,omitempty"`
+	PodOverlay     map[string]interface{} `yaml:"podOverlay,omitempty" json:"podOverlay,omitempty"`
+	PodOverride    string                 `yaml:"podOverride,omitempty" json:"podOverride,omitempty"`
+	PodPatches     []PatchOperation       `yaml:"podPatches,omitempty" json:"podPatches,omitempty"`
+	RetryCount     int                    `yaml:"retryCount,omitempty" json:"retryCount,omitempty"`
+	RetryDelay     string                 `yaml:"retryDelay,omitempty" json:"retryDelay,omitempty"`
+	WarmPool       int                    `yaml:"warmPool,omitempty" json:"warmPool,omitempty"`
+	MaxSessions    int                    `yaml:"maxSessions,omitempty" json:"maxSessions,omitempty"`
+	Readiness      *ReadinessSpec         `yaml:"readiness,omitempty" json:"readiness,omitempty"`
+	Burst          bool                   `yaml:"-" json:"-"`
+}
+
+//maxRetryBackoff caps exponential backoff between browser start attempts
+const maxRetryBackoff = time.Minute
+
+//RetryBackoff returns wait before next browser start attempt after failed attempt number attempt,
+//retryDelay is doubled after every failed attempt
+func (b BrowserSpec) RetryBackoff(attempt int) time.Duration {
+	delay, err := time.ParseDuration(b.RetryDelay)
+	if err != nil || delay <= 0 {
+		return 0
+	}
+	for i := 1; i < attempt && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return delay
+}
+
+const (
+	//LinuxPlatform is default platform of browser pods
+	LinuxPlatform = "linux"
+	//WindowsPlatform runs browser pods on Windows nodes
+	WindowsPlatform = "windows"
+	//AndroidPlatform is platform of Android devices served by Appium, their pods run on Linux nodes
+	AndroidPlatform = "android"
+)
+
+//requestedPlatform returns platform of platformName capability, Selenium platform names of Windows
+//versions, e.g. WIN10 or VISTA, mean windows and empty or ANY platform means any platform
+func requestedPlatform(platformName string) string {
+	name := strings.ToLower(platformName)
+	switch {
+	case name == "" || name == "any":
+		return ""
+	case strings.HasPrefix(name, "win"), name == "vista", name == "xp":
+		return WindowsPlatform
+	}
+	return name
+}
+
+//MatchPlatform reports if browser runs on platform requested with platformName capability, browsers
+//without platform run on linux, Appium devices without platform are android ones
+func (b BrowserSpec) MatchPlatform(platformName string) bool {
+	requested := requestedPlatform(platformName)
+	if requested == "" {
+		return true
+	}
+	switch {
+	case b.Platform != "":
+		return b.Platform == requested
+	case b.Type == AppiumType:
+		return requested == AndroidPlatform
+	}
+	return requested == LinuxPlatform
+}
+
+const (
+	//archLabel is well-known node label with node cpu architecture
+	archLabel = "kubernetes.io/arch"
+	//osLabel is well-known node label with node operating system
+	osLabel = "kubernetes.io/os"
+)
+
+//ForArch returns spec with image of the architecture and pins pod to nodes of the architecture,
+//architecture of spec node selector is used if not requested. Image is kept as is when there
+//are no per-architecture images, or when architecture is not set and default image is present
+func (b BrowserSpec) ForArch(arch string) (BrowserSpec, error) {
+	if arch == "" {
+		arch = b.Spec.NodeSelector[archLabel]
+	}
+
+	if len(b.Images) > 0 {
+		if arch == "" && b.Image != "" {
+			return b, nil
+		}
+		if arch == "" {
+			archs := make([]string, 0, len(b.Images))
+			for a := range b.Images {
+				archs = append(archs, a)
+			}
+			sort.Strings(archs)
+			arch = archs[0]
+		}
+		image, ok := b.Images[arch]
+		if !ok {
+			return BrowserSpec{}, fmt.Errorf("no image for architecture %s", arch)
+		}
+		b.Image = image
+	}
+
+	if arch == "" {
+		return b, nil
+	}
+	selector := make(map[string]string, len(b.Spec.NodeSelector)+1)
+	for k, v := range b.Spec.NodeSelector {
+		selector[k] = v
+	}
+	selector[archLabel] = arch
+	b.Spec.NodeSelector = selector
+	return b, nil
+}
+
+//imageArchs returns architectures of per-architecture images when pod is not pinned to one of them,
+//default image is expected to be multi-arch manifest of these architectures only
+func (b BrowserSpec) imageArchs() []string {
+	if len(b.Images) == 0 || b.Spec.NodeSelector[archLabel] != "" {
+		return nil
+	}
+	archs := make([]string, 0, len(b.Images))
+	for arch := range b.Images {
+		archs = append(archs, arch)
+	}
+	sort.Strings(archs)
+	return archs
+}
+
+//ArtifactDestination describes object storage location and credentials session artifacts are uploaded with
+type ArtifactDestination struct {
+	URL               string `yaml:"url" json:"url,omitempty"`
+	CredentialsSecret string `yaml:"credentialsSecret" json:"credentialsSecret,omitempty"`
+}
+
+//Artifacts describes default destination of session artifacts and overrides per artifact kind
+type Artifacts struct {
+	ArtifactDestination `yaml:",inline"`
+	Videos              *ArtifactDestination `yaml:"videos,omitempty" json:"videos,omitempty"`
+	Logs                *ArtifactDestination `yaml:"logs,omitempty" json:"logs,omitempty"`
+	Downloads           *ArtifactDestination `yaml:"downloads,omitempty" json:"downloads,omitempty"`
+	MaxBytes            int64                `yaml:"maxBytes,omitempty" json:"maxBytes,omitempty"`
+	OverLimit           string               `yaml:"overLimit,omitempty" json:"overLimit,omitempty"`
+}
+
+const (
+	//RejectOverLimit rejects sessions recording video when artifact storage quota is exceeded
+	RejectOverLimit = "reject"
+	//RotateOverLimit deletes oldest artifacts when artifact storage quota is exceeded
+	RotateOverLimit = "rotate"
+)
+
+//Validate ...
+func (a Artifacts) Validate() error {
+	switch a.OverLimit {
+	case "", RejectOverLimit, RotateOverLimit:
+	default:
+		return fmt.Errorf("unknown over limit action %s", a.OverLimit)
+	}
+	if a.MaxBytes < 0 {
+		return fmt.Errorf("max bytes should not be negative")
+	}
+	return nil
+}
+
+//Destination returns destination of the artifact kind
+func (a Artifacts) Destination(kind string) (ArtifactDestination, bool) {
+	dst, ok := a.Resolve()[kind]
+	return dst, ok
+}
+
+//IsEmpty ...
+func (a Artifacts) IsEmpty() bool {
+	return len(a.Resolve()) == 0
+}
+
+//Resolve returns destination of every artifact kind, kinds without destination are omitted
+func (a Artifacts) Resolve() map[string]ArtifactDestination {
+	destinations := make(map[string]ArtifactDestination)
+	kinds := map[string]*ArtifactDestination{
+		"videos":    a.Videos,
+		"logs":      a.Logs,
+		"downloads": a.Downloads,
+	}
+	for kind, dst := range kinds {
+		switch {
+		case dst != nil && dst.URL != "":
+			destinations[kind] = *dst
+		case a.URL != "":
+			destinations[kind] = a.ArtifactDestination
+		}
+	}
+	return destinations
 }
 
 //ServiceSpec describes data requred for creating service
 type ServiceSpec struct {
 	SessionID             string
+	Tenant                string
+	Artifacts             Artifacts
 	RequestedCapabilities selenium.Capabilities
 	Template              BrowserSpec
+	IdleTimeout           time.Duration
+	Client                string
+	Owner                 string
 }
 
-//Service ...
+//Service is a browser session, Deadline is set while replica creating the session waits for the browser
 type Service struct {
-	SessionID  string            `json:"id"`
-	URL        *url.URL          `json:"-"`
-	Labels     map[string]string `json:"labels"`
-	OnTimeout  chan struct{}     `json:"-"`
-	CancelFunc func()            `json:"-"`
-	Status     ServiceStatus     `json:"-"`
-	Started    time.Time         `json:"started"`
-	Uptime     string            `json:"uptime"`
+	SessionID     string            `json:"id"`
+	URL           *url.URL          `json:"-"`
+	Labels        map[string]string `json:"labels"`
+	SessionLabels map[string]string `json:"sessionLabels,omitempty"`
+	VideoStream   *VideoStreamSpec  `json:"videoStream,omitempty"`
+	HAR           bool              `json:"har,omitempty"`
+	OnTimeout     chan struct{}     `json:"-"`
+	CancelFunc    func()            `json:"-"`
+	Status        ServiceStatus     `json:"-"`
+	Started       time.Time         `json:"started"`
+	Uptime        string            `json:"uptime"`
+	Phases        []Phase           `json:"-"`
+	Relay         bool              `json:"relay,omitempty"`
+	Burst         bool              `json:"burst,omitempty"`
+	Deadline      time.Time         `json:"-"`
+	Extended      time.Time         `json:"-"`
+	Active        time.Time         `json:"-"`
+	Disruption    string            `json:"disruption,omitempty"`
+}
+
+//Phase is a step of session startup
+type Phase struct {
+	Name     string
+	Duration time.Duration
 }
 
 type Quota struct {
@@ -83,6 +316,24 @@ type Worker struct {
 	Uptime  string            `json:"uptime"`
 }
 
+//Resource describes auxiliary object created for session
+type Resource struct {
+	Kind      string    `json:"kind"`
+	Name      string    `json:"name"`
+	SessionID string    `json:"sessionId"`
+	RunID     string    `json:"runId,omitempty"`
+	Created   time.Time `json:"created"`
+}
+
+//Artifact describes file uploaded to artifact storage
+type Artifact struct {
+	SessionID string    `json:"sessionId"`
+	Kind      string    `json:"kind"`
+	URL       string    `json:"url"`
+	Size      int64     `json:"size"`
+	Created   time.Time `json:"created"`
+}
+
 //ServiceStatus ...
 type ServiceStatus string
 
@@ -109,10 +360,28 @@ const (
 type Platform interface {
 	Service() ServiceInterface
 	Quota() QuotaInterface
+	Resources() ResourceInterface
+	Artifacts() ArtifactInterface
 	State() (PlatformState, error)
 	Watch() <-chan Event
 }
 
+//CacheSizer is implemented by platforms keeping informer caches
+type CacheSizer interface {
+	CacheSize() map[string]int
+}
+
+//TenantLimiter is implemented by platforms running sessions of tenants in their own namespaces
+type TenantLimiter interface {
+	SetTenantLimit(name string, limit int64) error
+}
+
+//Relayer is implemented by platforms forwarding sessions to external WebDriver servers
+type Relayer interface {
+	Bind(sessionID, remoteSessionID string) (Service, error)
+	Release(sessionID string)
+}
+
 type ServiceInterface interface {
 	Create(ServiceSpec) (Service, error)
 	Delete(string) error
@@ -124,3 +393,17 @@ type QuotaInterface interface {
 	Get() (Quota, error)
 	Update(int64) (Quota, error)
 }
+
+//ResourceInterface manages auxiliary objects labeled with session id
+type ResourceInterface interface {
+	List() ([]Resource, error)
+	Delete(Resource) error
+}
+
+//ArtifactInterface keeps record of uploaded artifacts per tenant, empty tenant is used for sessions without tenant
+type ArtifactInterface interface {
+	List(tenant string) ([]Artifact, error)
+	Add(tenant string, artifact Artifact) error
+	Remove(tenant string, urls []string) error
+	Credentials(tenant, secret string) (map[string]string, error)
+}
@@ -0,0 +1,150 @@
+package platform
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestArtifactsResolve(t *testing.T) {
+	tests := map[string]struct {
+		artifacts    Artifacts
+		destinations map[string]ArtifactDestination
+	}{
+		"Verify no destinations without configuration": {
+			destinations: map[string]ArtifactDestination{},
+		},
+		"Verify default destination used for all kinds": {
+			artifacts: Artifacts{
+				ArtifactDestination: ArtifactDestination{URL: "s3://artifacts/qa", CredentialsSecret: "qa-s3"},
+			},
+			destinations: map[string]ArtifactDestination{
+				"videos":    {URL: "s3://artifacts/qa", CredentialsSecret: "qa-s3"},
+				"logs":      {URL: "s3://artifacts/qa", CredentialsSecret: "qa-s3"},
+				"downloads": {URL: "s3://artifacts/qa", CredentialsSecret: "qa-s3"},
+			},
+		},
+		"Verify kind destination overrides default": {
+			artifacts: Artifacts{
+				ArtifactDestination: ArtifactDestination{URL: "s3://artifacts/qa", CredentialsSecret: "qa-s3"},
+				Videos:              &ArtifactDestination{URL: "gs://videos/qa", CredentialsSecret: "qa-gcs"},
+			},
+			destinations: map[string]ArtifactDestination{
+				"videos":    {URL: "gs://videos/qa", CredentialsSecret: "qa-gcs"},
+				"logs":      {URL: "s3://artifacts/qa", CredentialsSecret: "qa-s3"},
+				"downloads": {URL: "s3://artifacts/qa", CredentialsSecret: "qa-s3"},
+			},
+		},
+		"Verify only kinds with destination are resolved": {
+			artifacts: Artifacts{
+				Logs: &ArtifactDestination{URL: "s3://logs/qa"},
+			},
+			destinations: map[string]ArtifactDestination{
+				"logs": {URL: "s3://logs/qa"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		assert.DeepEqual(t, test.destinations, test.artifacts.Resolve())
+		assert.Equal(t, len(test.destinations) == 0, test.artifacts.IsEmpty())
+	}
+}
+
+func TestBrowserSpecForArch(t *testing.T) {
+	images := map[string]string{
+		"amd64": "selenoid/vnc:chrome_85.0",
+		"arm64": "seleniarm/vnc:chrome_85.0",
+	}
+	tests := map[string]struct {
+		spec     BrowserSpec
+		arch     string
+		image    string
+		selector map[string]string
+		err      string
+	}{
+		"Verify spec without per-arch images is kept": {
+			spec:  BrowserSpec{Image: "selenoid/vnc:chrome_85.0"},
+			image: "selenoid/vnc:chrome_85.0",
+		},
+		"Verify requested arch pins spec without per-arch images": {
+			spec:     BrowserSpec{Image: "selenoid/vnc:chrome_85.0"},
+			arch:     "arm64",
+			image:    "selenoid/vnc:chrome_85.0",
+			selector: map[string]string{archLabel: "arm64"},
+		},
+		"Verify image of requested arch is used": {
+			spec:     BrowserSpec{Image: "selenoid/vnc:chrome_85.0", Images: images, Spec: Spec{NodeSelector: map[string]string{"nodeType": "N2D"}}},
+			arch:     "arm64",
+			image:    "seleniarm/vnc:chrome_85.0",
+			selector: map[string]string{"nodeType": "N2D", archLabel: "arm64"},
+		},
+		"Verify arch of node selector is used": {
+			spec:     BrowserSpec{Images: images, Spec: Spec{NodeSelector: map[string]string{archLabel: "arm64"}}},
+			image:    "seleniarm/vnc:chrome_85.0",
+			selector: map[string]string{archLabel: "arm64"},
+		},
+		"Verify default image is kept when arch is not set": {
+			spec:  BrowserSpec{Image: "selenoid/vnc:chrome_85.0-multiarch", Images: images},
+			image: "selenoid/vnc:chrome_85.0-multiarch",
+		},
+		"Verify first arch is used without default image": {
+			spec:     BrowserSpec{Images: images},
+			image:    "selenoid/vnc:chrome_85.0",
+			selector: map[string]string{archLabel: "amd64"},
+		},
+		"Verify unknown arch is rejected": {
+			spec: BrowserSpec{Images: images},
+			arch: "s390x",
+			err:  "no image for architecture s390x",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		spec, err := test.spec.ForArch(test.arch)
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, test.image, spec.Image)
+		assert.DeepEqual(t, test.selector, spec.Spec.NodeSelector)
+	}
+}
+
+func TestBrowserSpecRetryBackoff(t *testing.T) {
+	tests := map[string]struct {
+		delay   string
+		attempt int
+		backoff time.Duration
+	}{
+		"Verify no backoff without retry delay": {
+			attempt: 1,
+		},
+		"Verify first retry waits retry delay": {
+			delay:   "2s",
+			attempt: 1,
+			backoff: 2 * time.Second,
+		},
+		"Verify retry delay is doubled after every attempt": {
+			delay:   "2s",
+			attempt: 3,
+			backoff: 8 * time.Second,
+		},
+		"Verify backoff is capped": {
+			delay:   "10s",
+			attempt: 10,
+			backoff: maxRetryBackoff,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+		assert.Equal(t, test.backoff, BrowserSpec{RetryDelay: test.delay}.RetryBackoff(test.attempt))
+	}
+}
@@ -0,0 +1,93 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	profileVolumeName       = "profile"
+	profileSourceVolumeName = "profile-source"
+	profileSourcePath       = "/profile-source"
+	defaultProfilePath      = "/tmp/profile"
+)
+
+//ProfileDir returns directory browser profile requested with selenosis:options capability is copied to
+func (b BrowserSpec) ProfileDir() string {
+	if b.ProfilePath != "" {
+		return b.ProfilePath
+	}
+	return defaultProfilePath
+}
+
+//profileSource returns volume source of browser profile requested with capabilities, nil if no profile is requested
+func profileSource(layout ServiceSpec) (*apiv1.VolumeSource, error) {
+	caps := layout.RequestedCapabilities
+	configMap, secret := caps.GetProfileConfigMap(), caps.GetProfileSecret()
+	switch {
+	case configMap != "" && secret != "":
+		return nil, errors.New("profileConfigMap and profileSecret can not be requested together")
+	case configMap != "":
+		return &apiv1.VolumeSource{
+			ConfigMap: &apiv1.ConfigMapVolumeSource{LocalObjectReference: apiv1.LocalObjectReference{Name: configMap}},
+		}, nil
+	case secret != "":
+		return &apiv1.VolumeSource{
+			Secret: &apiv1.SecretVolumeSource{SecretName: secret},
+		}, nil
+	}
+	return nil, nil
+}
+
+//profileVolume copies browser profile from ConfigMap or Secret to writable profile directory of browser
+//container, browser image copies it in init container as browser locks and updates profile files
+func profileVolume(pod *apiv1.Pod, layout ServiceSpec) error {
+	source, err := profileSource(layout)
+	if err != nil || source == nil {
+		return err
+	}
+	if layout.Template.Platform == WindowsPlatform {
+		return errors.New("browser profiles are not supported for windows browsers")
+	}
+	dir := layout.Template.ProfileDir()
+
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, apiv1.Container{
+		Name:    "profile",
+		Image:   layout.Template.Image,
+		Command: []string{"sh", "-c", fmt.Sprintf("cp -RL %s/. %s", profileSourcePath, dir)},
+		VolumeMounts: []apiv1.VolumeMount{
+			{Name: profileSourceVolumeName, MountPath: profileSourcePath, ReadOnly: true},
+			{Name: profileVolumeName, MountPath: dir},
+		},
+		SecurityContext: pod.Spec.Containers[0].SecurityContext.DeepCopy(),
+		ImagePullPolicy: apiv1.PullIfNotPresent,
+	})
+	pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, apiv1.VolumeMount{Name: profileVolumeName, MountPath: dir})
+	pod.Spec.Volumes = append(pod.Spec.Volumes,
+		apiv1.Volume{Name: profileSourceVolumeName, VolumeSource: *source},
+		apiv1.Volume{Name: profileVolumeName, VolumeSource: apiv1.VolumeSource{EmptyDir: &apiv1.EmptyDirVolumeSource{}}},
+	)
+	return nil
+}
+
+//ensureProfile checks that ConfigMap or Secret with requested browser profile exists in session namespace,
+//otherwise browser pod would wait for the volume until pending timeout
+func (cl *service) ensureProfile(layout ServiceSpec) error {
+	caps := layout.RequestedCapabilities
+	context := context.Background()
+	if name := caps.GetProfileConfigMap(); name != "" {
+		if _, err := cl.clientset.CoreV1().ConfigMaps(cl.ns).Get(context, name, metav1.GetOptions{}); err != nil {
+			return fmt.Errorf("failed to get profile config map %s: %v", name, err)
+		}
+	}
+	if name := caps.GetProfileSecret(); name != "" {
+		if _, err := cl.clientset.CoreV1().Secrets(cl.ns).Get(context, name, metav1.GetOptions{}); err != nil {
+			return fmt.Errorf("failed to get profile secret %s: %v", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package platform
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alcounit/selenosis/selenium"
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestBuildPodProfile(t *testing.T) {
+	tests := map[string]struct {
+		options  *selenium.SelenosisOptions
+		template BrowserSpec
+		source   apiv1.VolumeSource
+		dir      string
+		err      string
+	}{
+		"Verify profile of config map is copied to browser": {
+			options: &selenium.SelenosisOptions{ProfileConfigMap: "firefox-profile"},
+			source: apiv1.VolumeSource{
+				ConfigMap: &apiv1.ConfigMapVolumeSource{LocalObjectReference: apiv1.LocalObjectReference{Name: "firefox-profile"}},
+			},
+			dir: "/tmp/profile",
+		},
+		"Verify profile of secret is copied to profile path of browser": {
+			options:  &selenium.SelenosisOptions{ProfileSecret: "firefox-certs"},
+			template: BrowserSpec{ProfilePath: "/home/selenium/profile"},
+			source:   apiv1.VolumeSource{Secret: &apiv1.SecretVolumeSource{SecretName: "firefox-certs"}},
+			dir:      "/home/selenium/profile",
+		},
+		"Verify profile of config map and secret is rejected": {
+			options: &selenium.SelenosisOptions{ProfileConfigMap: "firefox-profile", ProfileSecret: "firefox-certs"},
+			err:     "profileConfigMap and profileSecret can not be requested together",
+		},
+		"Verify profile is not supported for windows browsers": {
+			options:  &selenium.SelenosisOptions{ProfileConfigMap: "firefox-profile"},
+			template: BrowserSpec{Platform: WindowsPlatform},
+			err:      "browser profiles are not supported for windows browsers",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		cl := &service{
+			svcPort:           intstr.FromString("4445"),
+			proxyImage:        "alcounit/seleniferous:latest",
+			windowsProxyImage: "alcounit/seleniferous:windows",
+		}
+		template := test.template
+		template.BrowserName, template.Image = "firefox", "selenoid/vnc:firefox_81.0"
+
+		pod, err := cl.buildPod(ServiceSpec{
+			SessionID:             "session",
+			RequestedCapabilities: selenium.Capabilities{Options: test.options},
+			Template:              template,
+		})
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+
+		assert.Equal(t, 1, len(pod.Spec.InitContainers))
+		init := pod.Spec.InitContainers[0]
+		assert.Equal(t, "selenoid/vnc:firefox_81.0", init.Image)
+		assert.DeepEqual(t, []string{"sh", "-c", "cp -RL /profile-source/. " + test.dir}, init.Command)
+
+		mounts := pod.Spec.Containers[0].VolumeMounts
+		assert.DeepEqual(t, apiv1.VolumeMount{Name: profileVolumeName, MountPath: test.dir}, mounts[len(mounts)-1])
+		volumes := pod.Spec.Volumes
+		assert.DeepEqual(t, apiv1.Volume{Name: profileSourceVolumeName, VolumeSource: test.source}, volumes[len(volumes)-2])
+		assert.Equal(t, profileVolumeName, volumes[len(volumes)-1].Name)
+	}
+}
+
+func TestEnsureProfile(t *testing.T) {
+	tests := map[string]struct {
+		options *selenium.SelenosisOptions
+		err     string
+	}{
+		"Verify session without profile is created": {},
+		"Verify existing profile config map is accepted": {
+			options: &selenium.SelenosisOptions{ProfileConfigMap: "firefox-profile"},
+		},
+		"Verify missing profile secret is rejected": {
+			options: &selenium.SelenosisOptions{ProfileSecret: "firefox-certs"},
+			err:     `failed to get profile secret firefox-certs: secrets "firefox-certs" not found`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		mock := fake.NewSimpleClientset()
+		_, err := mock.CoreV1().ConfigMaps("selenosis").Create(context.Background(), &apiv1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "firefox-profile"},
+		}, metav1.CreateOptions{})
+		assert.NilError(t, err)
+
+		cl := &service{ns: "selenosis", clientset: mock}
+		err = cl.ensureProfile(ServiceSpec{RequestedCapabilities: selenium.Capabilities{Options: test.options}})
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+	}
+}
@@ -0,0 +1,30 @@
+package platform
+
+import (
+	"path"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	proxyTLSVolumeName = "proxy-tls"
+	//proxyTLSDir is directory secret with certificate of seleniferous is mounted to
+	proxyTLSDir = "/etc/seleniferous/tls"
+)
+
+//proxyTLS mounts secret with certificate of seleniferous into proxy container and passes its files with env,
+//secret is expected in kubernetes.io/tls format with ca.crt verifying client certificate of selenosis, e.g.
+//one issued by cert-manager. Kubelet syncs rotated secret into running pods
+func proxyTLS(pod *apiv1.Pod, secret string) {
+	proxy := &pod.Spec.Containers[1]
+	proxy.Env = append(proxy.Env,
+		apiv1.EnvVar{Name: "TLS_CERT_FILE", Value: path.Join(proxyTLSDir, apiv1.TLSCertKey)},
+		apiv1.EnvVar{Name: "TLS_KEY_FILE", Value: path.Join(proxyTLSDir, apiv1.TLSPrivateKeyKey)},
+		apiv1.EnvVar{Name: "TLS_CLIENT_CA_FILE", Value: path.Join(proxyTLSDir, "ca.crt")},
+	)
+	proxy.VolumeMounts = append(proxy.VolumeMounts, apiv1.VolumeMount{Name: proxyTLSVolumeName, MountPath: proxyTLSDir, ReadOnly: true})
+	pod.Spec.Volumes = append(pod.Spec.Volumes, apiv1.Volume{
+		Name:         proxyTLSVolumeName,
+		VolumeSource: apiv1.VolumeSource{Secret: &apiv1.SecretVolumeSource{SecretName: secret}},
+	})
+}
@@ -0,0 +1,52 @@
+package platform
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestBuildPodProxyTLS(t *testing.T) {
+	tests := map[string]struct {
+		secret string
+		mounts int
+	}{
+		"Verify proxy gets no certificate without secret": {},
+		"Verify proxy certificate secret is mounted to proxy container": {
+			secret: "seleniferous-tls",
+			mounts: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		cl := &service{
+			svcPort:        intstr.FromString("4445"),
+			proxyImage:     "alcounit/seleniferous:latest",
+			proxyTLSSecret: test.secret,
+		}
+		pod, err := cl.buildPod(ServiceSpec{SessionID: "session", Template: BrowserSpec{Image: "selenoid/vnc:chrome_85.0"}})
+		assert.NilError(t, err)
+
+		proxy := pod.Spec.Containers[1]
+		assert.Equal(t, test.mounts, len(proxy.VolumeMounts))
+		if test.secret == "" {
+			assert.Equal(t, 1, len(proxy.Env))
+			continue
+		}
+
+		assert.DeepEqual(t, apiv1.VolumeMount{Name: proxyTLSVolumeName, MountPath: "/etc/seleniferous/tls", ReadOnly: true}, proxy.VolumeMounts[0])
+		assert.DeepEqual(t, []apiv1.EnvVar{
+			{Name: idleTimeoutEnv, Value: "0s"},
+			{Name: "TLS_CERT_FILE", Value: "/etc/seleniferous/tls/tls.crt"},
+			{Name: "TLS_KEY_FILE", Value: "/etc/seleniferous/tls/tls.key"},
+			{Name: "TLS_CLIENT_CA_FILE", Value: "/etc/seleniferous/tls/ca.crt"},
+		}, proxy.Env)
+		volume := pod.Spec.Volumes[len(pod.Spec.Volumes)-1]
+		assert.Equal(t, proxyTLSVolumeName, volume.Name)
+		assert.Equal(t, test.secret, volume.Secret.SecretName)
+	}
+}
@@ -0,0 +1,171 @@
+package platform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"reflect"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	//TCPReadiness waits until browser port accepts connections
+	TCPReadiness = "tcp"
+	//HTTPReadiness waits until status endpoint of the driver answers with expected value
+	HTTPReadiness = "http"
+	//ExecReadiness waits until command run in browser container exits with zero code
+	ExecReadiness = "exec"
+)
+
+//readinessInterval is default delay between readiness checks of the browser
+var readinessInterval = 50 * time.Millisecond
+
+//readinessDialTimeout limits connection attempt of single tcp readiness check
+var readinessDialTimeout = time.Second
+
+//ReadinessSpec describes how browser is checked before session is passed to it, some images open browser port
+//long before the driver is able to create session. Path of http check defaults to status endpoint of the driver,
+//value keys should match keys of status response value, e.g. ready: true
+type ReadinessSpec struct {
+	Type     string                 `yaml:"type" json:"type"`
+	Path     string                 `yaml:"path,omitempty" json:"path,omitempty"`
+	Value    map[string]interface{} `yaml:"value,omitempty" json:"value,omitempty"`
+	Command  []string               `yaml:"command,omitempty" json:"command,omitempty"`
+	Timeout  string                 `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	Interval string                 `yaml:"interval,omitempty" json:"interval,omitempty"`
+}
+
+//Validate ...
+func (r ReadinessSpec) Validate() error {
+	switch r.Type {
+	case TCPReadiness, HTTPReadiness:
+	case ExecReadiness:
+		if len(r.Command) == 0 {
+			return errors.New("command of exec readiness is not set")
+		}
+	default:
+		return fmt.Errorf("unknown readiness type %s", r.Type)
+	}
+	if r.Timeout != "" {
+		if d, err := time.ParseDuration(r.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("invalid readiness timeout %s", r.Timeout)
+		}
+	}
+	if r.Interval != "" {
+		if d, err := time.ParseDuration(r.Interval); err != nil || d <= 0 {
+			return fmt.Errorf("invalid readiness interval %s", r.Interval)
+		}
+	}
+	return nil
+}
+
+//timeout returns time browser has to become ready, t is used when spec has no timeout
+func (r *ReadinessSpec) timeout(t time.Duration) time.Duration {
+	if r == nil || r.Timeout == "" {
+		return t
+	}
+	d, _ := time.ParseDuration(r.Timeout)
+	return d
+}
+
+//interval returns delay between readiness checks
+func (r *ReadinessSpec) interval() time.Duration {
+	if r == nil || r.Interval == "" {
+		return readinessInterval
+	}
+	d, _ := time.ParseDuration(r.Interval)
+	return d
+}
+
+//readinessProbe returns probe of browser container for exec readiness, command is run by kubelet and
+//browser container becomes ready when it succeeds
+func readinessProbe(template BrowserSpec) *apiv1.Probe {
+	r := template.Readiness
+	if r == nil || r.Type != ExecReadiness {
+		return nil
+	}
+	return &apiv1.Probe{
+		Handler: apiv1.Handler{
+			Exec: &apiv1.ExecAction{Command: r.Command},
+		},
+		PeriodSeconds: int32(math.Max(1, math.Ceil(r.interval().Seconds()))),
+	}
+}
+
+//waitForBrowser waits for WebDriver server of the browser at u. Without readiness spec browser port is polled,
+//Appium server is ready when its status endpoint answers, as emulator starts after the server is already listening.
+//Exec readiness command is passed to exec of the platform
+func waitForBrowser(u url.URL, template BrowserSpec, t time.Duration, exec func(command []string) error) error {
+	r := template.Readiness
+	if r == nil {
+		if template.Type != AppiumType {
+			return waitForService(u, t)
+		}
+		u.Path = path.Join("/", template.Path, "status")
+		return pollService(http.MethodGet, u, t, func(code int) bool {
+			return code == http.StatusOK
+		})
+	}
+
+	switch r.Type {
+	case TCPReadiness:
+		return poll(t, r.interval(), func() error {
+			conn, err := net.DialTimeout("tcp", u.Host, readinessDialTimeout)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		})
+	case HTTPReadiness:
+		u.Path = r.Path
+		if u.Path == "" {
+			u.Path = path.Join("/", template.Path, "status")
+		}
+		return poll(t, r.interval(), func() error {
+			return checkStatus(u, r.Value)
+		})
+	case ExecReadiness:
+		return poll(t, r.interval(), func() error {
+			return exec(r.Command)
+		})
+	}
+	return fmt.Errorf("unknown readiness type %s", r.Type)
+}
+
+//checkStatus requests status endpoint at u, response should be successful and its value should have all
+//keys of expected value
+func checkStatus(u url.URL, expected map[string]interface{}) error {
+	req, _ := http.NewRequest(http.MethodGet, u.String(), nil)
+	req.Close = true
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	if len(expected) == 0 {
+		return nil
+	}
+
+	var status struct {
+		Value map[string]interface{} `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return fmt.Errorf("failed to parse status: %v", err)
+	}
+	for k, v := range expected {
+		if !reflect.DeepEqual(v, status.Value[k]) {
+			return fmt.Errorf("unexpected status %s: %v", k, status.Value[k])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package platform
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestReadinessValidate(t *testing.T) {
+	tests := map[string]struct {
+		spec ReadinessSpec
+		err  string
+	}{
+		"Verify tcp readiness is valid": {
+			spec: ReadinessSpec{Type: TCPReadiness},
+		},
+		"Verify http readiness with timeout and interval is valid": {
+			spec: ReadinessSpec{Type: HTTPReadiness, Timeout: "1m", Interval: "500ms"},
+		},
+		"Verify unknown readiness type is rejected": {
+			spec: ReadinessSpec{Type: "grpc"},
+			err:  "unknown readiness type grpc",
+		},
+		"Verify exec readiness without command is rejected": {
+			spec: ReadinessSpec{Type: ExecReadiness},
+			err:  "command of exec readiness is not set",
+		},
+		"Verify invalid timeout is rejected": {
+			spec: ReadinessSpec{Type: TCPReadiness, Timeout: "1"},
+			err:  "invalid readiness timeout 1",
+		},
+		"Verify negative interval is rejected": {
+			spec: ReadinessSpec{Type: TCPReadiness, Interval: "-1s"},
+			err:  "invalid readiness interval -1s",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		err := test.spec.Validate()
+		if test.err == "" {
+			assert.NilError(t, err)
+			continue
+		}
+		assert.Error(t, err, test.err)
+	}
+}
+
+func TestWaitForBrowserReadiness(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/wd/hub/status", r.URL.Path)
+		if atomic.AddInt32(&requests, 1) < 3 {
+			w.Write([]byte(`{"value":{"ready":false,"message":"starting"}}`))
+			return
+		}
+		w.Write([]byte(`{"value":{"ready":true,"message":"ready"}}`))
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+
+	var commands int32
+	exec := func(command []string) error {
+		assert.DeepEqual(t, []string{"/opt/bin/ready.sh"}, command)
+		if atomic.AddInt32(&commands, 1) < 3 {
+			return errors.New("command exited with code 1")
+		}
+		return nil
+	}
+
+	tests := map[string]struct {
+		url       url.URL
+		readiness *ReadinessSpec
+		err       string
+	}{
+		"Verify tcp readiness waits for open port": {
+			url:       *u,
+			readiness: &ReadinessSpec{Type: TCPReadiness},
+		},
+		"Verify tcp readiness fails on closed port": {
+			url:       url.URL{Scheme: "http", Host: "127.0.0.1:1"},
+			readiness: &ReadinessSpec{Type: TCPReadiness},
+			err:       "no responce after 200ms",
+		},
+		"Verify http readiness waits for status value": {
+			url:       *u,
+			readiness: &ReadinessSpec{Type: HTTPReadiness, Value: map[string]interface{}{"ready": true}, Interval: "10ms"},
+		},
+		"Verify http readiness fails on unexpected status value": {
+			url:       *u,
+			readiness: &ReadinessSpec{Type: HTTPReadiness, Value: map[string]interface{}{"message": "idle"}},
+			err:       "no responce after 200ms",
+		},
+		"Verify exec readiness waits for command success": {
+			url:       *u,
+			readiness: &ReadinessSpec{Type: ExecReadiness, Command: []string{"/opt/bin/ready.sh"}, Interval: "10ms"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		atomic.StoreInt32(&requests, 0)
+		atomic.StoreInt32(&commands, 0)
+		template := BrowserSpec{Path: "/wd/hub", Readiness: test.readiness}
+		err := waitForBrowser(test.url, template, 200*time.Millisecond, exec)
+		if test.err == "" {
+			assert.NilError(t, err)
+			continue
+		}
+		assert.Error(t, err, test.err)
+	}
+}
+
+func TestReadinessProbe(t *testing.T) {
+	cl := &service{
+		svcPort:    intstr.FromString("4445"),
+		proxyImage: "alcounit/seleniferous:latest",
+	}
+
+	pod, err := cl.buildPod(ServiceSpec{SessionID: "session", Template: BrowserSpec{Image: "selenoid/vnc:chrome_85.0"}})
+	assert.NilError(t, err)
+	assert.Assert(t, pod.Spec.Containers[0].ReadinessProbe == nil)
+
+	template := BrowserSpec{
+		Image:     "selenoid/vnc:chrome_85.0",
+		Readiness: &ReadinessSpec{Type: ExecReadiness, Command: []string{"/opt/bin/ready.sh"}, Interval: "2500ms"},
+	}
+	pod, err = cl.buildPod(ServiceSpec{SessionID: "session", Template: template})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, &apiv1.Probe{
+		Handler:       apiv1.Handler{Exec: &apiv1.ExecAction{Command: []string{"/opt/bin/ready.sh"}}},
+		PeriodSeconds: 3,
+	}, pod.Spec.Containers[0].ReadinessProbe)
+}
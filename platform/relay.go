@@ -0,0 +1,309 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
+	"time"
+)
+
+//Relay wraps platform and forwards sessions of browsers with relay endpoint to external WebDriver servers
+//instead of creating browser pods, relayed sessions are reported in state and events like pod sessions
+type Relay struct {
+	Platform
+	httpClient *http.Client
+
+	mu       sync.Mutex
+	sessions map[string]Service
+	events   chan Event
+}
+
+//NewRelay ...
+func NewRelay(p Platform) *Relay {
+	return &Relay{
+		Platform:   p,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		sessions:   make(map[string]Service),
+		events:     make(chan Event),
+	}
+}
+
+//Service ...
+func (r *Relay) Service() ServiceInterface {
+	return &relayService{
+		ServiceInterface: r.Platform.Service(),
+		r:                r,
+	}
+}
+
+//State returns state of wrapped platform with relayed sessions
+func (r *Relay) State() (PlatformState, error) {
+	state, err := r.Platform.State()
+	if err != nil {
+		return PlatformState{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, service := range r.sessions {
+		state.Services = append(state.Services, service)
+	}
+	return state, nil
+}
+
+//Watch returns events of wrapped platform and events of relayed sessions
+func (r *Relay) Watch() <-chan Event {
+	ch := make(chan Event)
+	go func() {
+		for event := range r.Platform.Watch() {
+			ch <- event
+		}
+	}()
+	go func() {
+		for event := range r.events {
+			ch <- event
+		}
+	}()
+	return ch
+}
+
+//Bind marks relayed session as running once external server created it, commands of the session
+//are forwarded to remote session id
+func (r *Relay) Bind(sessionID, remoteSessionID string) (Service, error) {
+	r.mu.Lock()
+	service, ok := r.sessions[sessionID]
+	if !ok {
+		r.mu.Unlock()
+		return Service{}, fmt.Errorf("unknown relayed session %s", sessionID)
+	}
+	u := *service.URL
+	u.Path = path.Join(u.Path, "session", remoteSessionID)
+	service.URL = &u
+	service.Status = Running
+	r.sessions[sessionID] = service
+	r.mu.Unlock()
+
+	go func() {
+		r.events <- Event{Type: Added, PlatformObject: service}
+	}()
+	return service, nil
+}
+
+//Release forgets relayed session which remote session was already deleted
+func (r *Relay) Release(sessionID string) {
+	r.release(sessionID)
+}
+
+func (r *Relay) release(sessionID string) (Service, bool) {
+	r.mu.Lock()
+	service, ok := r.sessions[sessionID]
+	delete(r.sessions, sessionID)
+	r.mu.Unlock()
+
+	if ok && service.Status == Running {
+		go func() {
+			r.events <- Event{Type: Deleted, PlatformObject: service}
+		}()
+	}
+	return service, ok
+}
+
+//CacheSize ...
+func (r *Relay) CacheSize() map[string]int {
+	if cs, ok := r.Platform.(CacheSizer); ok {
+		return cs.CacheSize()
+	}
+	return nil
+}
+
+//FillWarmPool ...
+func (r *Relay) FillWarmPool(templates []BrowserSpec, capacity int) error {
+	if wp, ok := r.Platform.(WarmPooler); ok {
+		return wp.FillWarmPool(templates, capacity)
+	}
+	return nil
+}
+
+//WarmupImages ...
+func (r *Relay) WarmupImages(templates []BrowserSpec) ([]ImageWarmup, error) {
+	if ip, ok := r.Platform.(ImagePuller); ok {
+		return ip.WarmupImages(templates)
+	}
+	return nil, ErrImageWarmupNotSupported
+}
+
+//ImageWarmups ...
+func (r *Relay) ImageWarmups() ([]ImageWarmup, error) {
+	if ip, ok := r.Platform.(ImagePuller); ok {
+		return ip.ImageWarmups()
+	}
+	return nil, ErrImageWarmupNotSupported
+}
+
+//Debug attaches debug container to session of wrapped platform, relayed sessions have no pod
+func (r *Relay) Debug(sessionID string, spec DebugSpec) (DebugContainer, error) {
+	r.mu.Lock()
+	_, ok := r.sessions[sessionID]
+	r.mu.Unlock()
+
+	if ok {
+		return DebugContainer{}, errors.New("debug containers are not available for relayed sessions")
+	}
+	if d, ok := r.Platform.(Debugger); ok {
+		return d.Debug(sessionID, spec)
+	}
+	return DebugContainer{}, ErrDebugNotSupported
+}
+
+//Extend extends session of wrapped platform, relayed sessions have no sidecar to extend
+func (r *Relay) Extend(sessionID string, max int) (SessionExtension, error) {
+	r.mu.Lock()
+	_, ok := r.sessions[sessionID]
+	r.mu.Unlock()
+
+	if ok {
+		return SessionExtension{}, errors.New("session extension is not available for relayed sessions")
+	}
+	if e, ok := r.Platform.(Extender); ok {
+		return e.Extend(sessionID, max)
+	}
+	return SessionExtension{}, ErrExtendNotSupported
+}
+
+//ListSessions lists sessions of wrapped platform, matching relayed sessions are added to its last page
+func (r *Relay) ListSessions(opts ListOptions) (SessionList, error) {
+	list, err := ListSessions(r.Platform, opts)
+	if err != nil || list.Continue != "" {
+		return list, err
+	}
+	r.mu.Lock()
+	relayed := make([]Service, 0, len(r.sessions))
+	for _, service := range r.sessions {
+		relayed = append(relayed, service)
+	}
+	r.mu.Unlock()
+
+	matched, err := PageSessions(relayed, ListOptions{
+		BrowserName:    opts.BrowserName,
+		BrowserVersion: opts.BrowserVersion,
+		Labels:         opts.Labels,
+	})
+	if err != nil {
+		return SessionList{}, err
+	}
+	list.Services = append(list.Services, matched.Services...)
+	return list, nil
+}
+
+//SetTenantLimit ...
+func (r *Relay) SetTenantLimit(name string, limit int64) error {
+	if tl, ok := r.Platform.(TenantLimiter); ok {
+		return tl.SetTenantLimit(name, limit)
+	}
+	return fmt.Errorf("tenants are not configured")
+}
+
+type relayService struct {
+	ServiceInterface
+	r *Relay
+}
+
+//Create registers pending relayed session, browsers without relay endpoint are created by wrapped platform
+func (s *relayService) Create(spec ServiceSpec) (Service, error) {
+	if spec.Template.Relay == "" {
+		return s.ServiceInterface.Create(spec)
+	}
+
+	u, err := url.Parse(spec.Template.Relay)
+	if err != nil {
+		return Service{}, fmt.Errorf("invalid relay endpoint: %v", err)
+	}
+	if cloud := spec.Template.Cloud; cloud != nil {
+		credentials, err := s.r.Platform.Artifacts().Credentials(spec.Tenant, cloud.CredentialsSecret)
+		if err != nil {
+			return Service{}, fmt.Errorf("failed to get %s credentials: %v", cloud.Provider, err)
+		}
+		u.User = url.UserPassword(credentials["username"], credentials["accessKey"])
+	}
+
+	labels := map[string]string{
+		defaultsAnnotations.browserName:    spec.Template.BrowserName,
+		defaultsAnnotations.browserVersion: spec.Template.BrowserVersion,
+		defaultsAnnotations.testName:       spec.RequestedCapabilities.TestName,
+	}
+	if spec.RequestedCapabilities.RunID != "" {
+		labels[defaultsAnnotations.runID] = spec.RequestedCapabilities.RunID
+	}
+	if spec.Tenant != "" {
+		labels[defaultsAnnotations.tenant] = spec.Tenant
+	}
+	if spec.IdleTimeout > 0 {
+		labels[defaultsAnnotations.sessionTimeout] = spec.IdleTimeout.String()
+	}
+	if spec.Client != "" {
+		labels[defaultsAnnotations.client] = spec.Client
+	}
+	if spec.Owner != "" {
+		labels[defaultsAnnotations.owner] = spec.Owner
+	}
+
+	sessionID := spec.SessionID
+	service := Service{
+		SessionID: sessionID,
+		URL:       u,
+		Labels:    labels,
+		CancelFunc: func() {
+			s.Delete(sessionID)
+		},
+		Status:  Pending,
+		Started: time.Now(),
+		Relay:   true,
+		Burst:   spec.Template.Burst,
+	}
+
+	s.r.mu.Lock()
+	s.r.sessions[sessionID] = service
+	s.r.mu.Unlock()
+
+	created := service
+	created.URL = &url.URL{Scheme: u.Scheme, User: u.User, Host: u.Host, Path: u.Path}
+	return created, nil
+}
+
+//Delete closes remote session of relayed session, sessions of wrapped platform are deleted by it
+func (s *relayService) Delete(sessionID string) error {
+	service, ok := s.r.release(sessionID)
+	if !ok {
+		return s.ServiceInterface.Delete(sessionID)
+	}
+	if service.Status != Running {
+		return nil
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, service.URL.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.r.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete remote session: %v", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+//Logs ...
+func (s *relayService) Logs(ctx context.Context, sessionID string) (io.ReadCloser, error) {
+	s.r.mu.Lock()
+	_, ok := s.r.sessions[sessionID]
+	s.r.mu.Unlock()
+
+	if ok {
+		return nil, errors.New("logs are not available for relayed sessions")
+	}
+	return s.ServiceInterface.Logs(ctx, sessionID)
+}
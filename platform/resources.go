@@ -0,0 +1,107 @@
+package platform
+
+import (
+	"fmt"
+
+	"github.com/alcounit/selenosis/selenium"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+//ResourceBounds are min and max cpu and memory sessions can request with selenosis:options capability,
+//resource without bound is not limited
+type ResourceBounds struct {
+	Min apiv1.ResourceList
+	Max apiv1.ResourceList
+}
+
+//ParseResourceList returns list of cpu and memory quantities, empty value leaves resource out of the list
+func ParseResourceList(cpu, memory string) (apiv1.ResourceList, error) {
+	list := make(apiv1.ResourceList)
+	for name, value := range map[apiv1.ResourceName]string{apiv1.ResourceCPU: cpu, apiv1.ResourceMemory: memory} {
+		if value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %s: %v", name, value, err)
+		}
+		list[name] = quantity
+	}
+	return list, nil
+}
+
+//requestedResources returns cpu and memory requests and limits requested with capabilities
+func requestedResources(caps selenium.Capabilities) (apiv1.ResourceRequirements, error) {
+	var requirements apiv1.ResourceRequirements
+	for _, r := range []struct {
+		option string
+		value  string
+		list   *apiv1.ResourceList
+		name   apiv1.ResourceName
+	}{
+		{"cpuRequest", caps.GetCPURequest(), &requirements.Requests, apiv1.ResourceCPU},
+		{"memoryRequest", caps.GetMemoryRequest(), &requirements.Requests, apiv1.ResourceMemory},
+		{"cpuLimit", caps.GetCPULimit(), &requirements.Limits, apiv1.ResourceCPU},
+		{"memoryLimit", caps.GetMemoryLimit(), &requirements.Limits, apiv1.ResourceMemory},
+	} {
+		if r.value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(r.value)
+		if err != nil {
+			return requirements, fmt.Errorf("invalid %s %s: %v", r.option, r.value, err)
+		}
+		if *r.list == nil {
+			*r.list = make(apiv1.ResourceList)
+		}
+		(*r.list)[r.name] = quantity
+	}
+	return requirements, nil
+}
+
+//SessionResources returns resources of browser container, requests and limits requested with capabilities
+//override the ones of browser template per resource
+func SessionResources(template BrowserSpec, caps selenium.Capabilities) (apiv1.ResourceRequirements, error) {
+	requested, err := requestedResources(caps)
+	if err != nil {
+		return apiv1.ResourceRequirements{}, err
+	}
+	if len(requested.Requests) == 0 && len(requested.Limits) == 0 {
+		return template.Spec.Resources, nil
+	}
+	return apiv1.ResourceRequirements{
+		Requests: mergeResources(template.Spec.Resources.Requests, requested.Requests),
+		Limits:   mergeResources(template.Spec.Resources.Limits, requested.Limits),
+	}, nil
+}
+
+//Validate checks resources requested with capabilities are within the bounds and resources of browser
+//container are valid, request can't exceed limit of the same resource
+func (b ResourceBounds) Validate(template BrowserSpec, caps selenium.Capabilities) error {
+	requested, err := requestedResources(caps)
+	if err != nil {
+		return err
+	}
+	for _, list := range []apiv1.ResourceList{requested.Requests, requested.Limits} {
+		for name, quantity := range list {
+			if min, ok := b.Min[name]; ok && quantity.Cmp(min) < 0 {
+				return fmt.Errorf("requested %s %s is less than %s", name, quantity.String(), min.String())
+			}
+			if max, ok := b.Max[name]; ok && quantity.Cmp(max) > 0 {
+				return fmt.Errorf("requested %s %s exceeds %s", name, quantity.String(), max.String())
+			}
+		}
+	}
+
+	resources, err := SessionResources(template, caps)
+	if err != nil {
+		return err
+	}
+	for name, request := range resources.Requests {
+		if limit, ok := resources.Limits[name]; ok && request.Cmp(limit) > 0 {
+			return fmt.Errorf("%s request %s exceeds %s limit %s", name, request.String(), name, limit.String())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/alcounit/selenosis/selenium"
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestRequestedSessionResources(t *testing.T) {
+	template := BrowserSpec{
+		Image: "selenoid/vnc:chrome_85.0",
+		Spec: Spec{Resources: apiv1.ResourceRequirements{
+			Requests: apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("500m"), apiv1.ResourceMemory: resource.MustParse("512Mi")},
+			Limits:   apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("1"), apiv1.ResourceMemory: resource.MustParse("1Gi")},
+		}},
+	}
+	bounds := ResourceBounds{
+		Min: apiv1.ResourceList{apiv1.ResourceMemory: resource.MustParse("256Mi")},
+		Max: apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("2"), apiv1.ResourceMemory: resource.MustParse("4Gi")},
+	}
+
+	tests := map[string]struct {
+		options   selenium.SelenosisOptions
+		resources apiv1.ResourceRequirements
+		err       string
+	}{
+		"Verify template resources are used when nothing is requested": {
+			resources: template.Spec.Resources,
+		},
+		"Verify requested resources override template per resource": {
+			options: selenium.SelenosisOptions{MemoryRequest: "2Gi", MemoryLimit: "3Gi"},
+			resources: apiv1.ResourceRequirements{
+				Requests: apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("500m"), apiv1.ResourceMemory: resource.MustParse("2Gi")},
+				Limits:   apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("1"), apiv1.ResourceMemory: resource.MustParse("3Gi")},
+			},
+		},
+		"Verify resource above max is rejected": {
+			options: selenium.SelenosisOptions{CPULimit: "4"},
+			err:     "requested cpu 4 exceeds 2",
+		},
+		"Verify resource below min is rejected": {
+			options: selenium.SelenosisOptions{MemoryRequest: "128Mi"},
+			err:     "requested memory 128Mi is less than 256Mi",
+		},
+		"Verify request above limit is rejected": {
+			options: selenium.SelenosisOptions{MemoryRequest: "2Gi"},
+			err:     "memory request 2Gi exceeds memory limit 1Gi",
+		},
+		"Verify invalid quantity is rejected": {
+			options: selenium.SelenosisOptions{CPURequest: "fast"},
+			err:     "invalid cpuRequest fast: quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		options := test.options
+		caps := selenium.Capabilities{Options: &options}
+		err := bounds.Validate(template, caps)
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+
+		cl := &service{svcPort: intstr.FromString("4445"), proxyImage: "seleniferous"}
+		pod, err := cl.buildPod(ServiceSpec{SessionID: "chrome-85-0", RequestedCapabilities: caps, Template: template})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, test.resources, pod.Spec.Containers[0].Resources)
+	}
+}
+
+func TestParseResourceList(t *testing.T) {
+	list, err := ParseResourceList("250m", "")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("250m")}, list)
+
+	_, err = ParseResourceList("", "lots")
+	assert.Error(t, err, "invalid memory lots: quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'")
+}
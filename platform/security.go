@@ -0,0 +1,36 @@
+package platform
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+)
+
+//browserSecurityContext returns security context of browser container, privileged and kernelCaps of the template
+//fill privileged and capabilities not set by securityContext of the spec
+func browserSecurityContext(template BrowserSpec) *apiv1.SecurityContext {
+	secContext := &apiv1.SecurityContext{}
+	if template.Spec.SecurityContext != nil {
+		secContext = template.Spec.SecurityContext.DeepCopy()
+	}
+	if secContext.Privileged == nil {
+		secContext.Privileged = template.Privileged
+	}
+	if secContext.Capabilities == nil {
+		secContext.Capabilities = getCapabilities(template.Capabilities)
+	}
+	return secContext
+}
+
+//helperSecurityContexts sets proxySecurityContext of the spec to seleniferous and other containers selenosis
+//adds to browser pod, sidecars of the spec keep their own security context
+func helperSecurityContexts(pod *apiv1.Pod, template BrowserSpec) {
+	if template.Spec.ProxySecurityContext == nil {
+		return
+	}
+	for i := range pod.Spec.Containers {
+		container := &pod.Spec.Containers[i]
+		if container.Name == "browser" || !reservedContainerNames[container.Name] || container.SecurityContext != nil {
+			continue
+		}
+		container.SecurityContext = template.Spec.ProxySecurityContext.DeepCopy()
+	}
+}
@@ -0,0 +1,110 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/alcounit/selenosis/selenium"
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+)
+
+func TestBuildPodSecurityContext(t *testing.T) {
+	uid := int64(2000)
+	restricted := &apiv1.SecurityContext{
+		AllowPrivilegeEscalation: pointer.BoolPtr(false),
+		Capabilities:             &apiv1.Capabilities{Drop: []apiv1.Capability{"ALL"}},
+	}
+
+	tests := map[string]struct {
+		template  BrowserSpec
+		caps      selenium.Capabilities
+		browser   *apiv1.SecurityContext
+		proxy     *apiv1.SecurityContext
+		recorder  *apiv1.SecurityContext
+		podSecCtx *apiv1.PodSecurityContext
+	}{
+		"Verify privileged and kernel caps are used without security context": {
+			template: BrowserSpec{
+				Image:        "selenoid/vnc:chrome_85.0",
+				Privileged:   pointer.BoolPtr(true),
+				Capabilities: []apiv1.Capability{"SYS_ADMIN"},
+				RunAs:        RunAsOptions{RunAsUser: &uid},
+			},
+			browser:   &apiv1.SecurityContext{Privileged: pointer.BoolPtr(true), Capabilities: &apiv1.Capabilities{Add: []apiv1.Capability{"SYS_ADMIN"}}},
+			podSecCtx: &apiv1.PodSecurityContext{RunAsUser: &uid},
+		},
+		"Verify security contexts of spec are set to containers and pod": {
+			template: BrowserSpec{
+				Image:        "selenoid/vnc:chrome_85.0",
+				Capabilities: []apiv1.Capability{"SYS_ADMIN"},
+				RunAs:        RunAsOptions{RunAsUser: &uid},
+				Spec: Spec{
+					SecurityContext: &apiv1.SecurityContext{
+						ReadOnlyRootFilesystem: pointer.BoolPtr(true),
+						Capabilities:           &apiv1.Capabilities{Drop: []apiv1.Capability{"ALL"}},
+					},
+					ProxySecurityContext: restricted,
+					PodSecurityContext: &apiv1.PodSecurityContext{
+						RunAsNonRoot:   pointer.BoolPtr(true),
+						RunAsUser:      pointer.Int64Ptr(1000),
+						SeccompProfile: &apiv1.SeccompProfile{Type: apiv1.SeccompProfileTypeRuntimeDefault},
+					},
+				},
+			},
+			caps: selenium.Capabilities{Video: true},
+			browser: &apiv1.SecurityContext{
+				ReadOnlyRootFilesystem: pointer.BoolPtr(true),
+				Capabilities:           &apiv1.Capabilities{Drop: []apiv1.Capability{"ALL"}},
+			},
+			proxy:    restricted,
+			recorder: restricted,
+			podSecCtx: &apiv1.PodSecurityContext{
+				RunAsNonRoot:   pointer.BoolPtr(true),
+				RunAsUser:      &uid,
+				SeccompProfile: &apiv1.SeccompProfile{Type: apiv1.SeccompProfileTypeRuntimeDefault},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		cl := &service{svcPort: intstr.FromString("4445"), proxyImage: "seleniferous", videoImage: "selenoid/video-recorder"}
+		pod, err := cl.buildPod(ServiceSpec{SessionID: "chrome-85-0", RequestedCapabilities: test.caps, Template: test.template})
+		assert.NilError(t, err)
+
+		assert.DeepEqual(t, test.browser, pod.Spec.Containers[0].SecurityContext)
+		assert.DeepEqual(t, test.proxy, pod.Spec.Containers[1].SecurityContext)
+		assert.DeepEqual(t, test.podSecCtx, pod.Spec.SecurityContext)
+		if test.recorder != nil {
+			assert.DeepEqual(t, test.recorder, pod.Spec.Containers[2].SecurityContext)
+		}
+	}
+}
+
+func TestBrowserContainerSecurityContext(t *testing.T) {
+	spec := browserContainer(ServiceSpec{
+		SessionID: "chrome-85-0-1",
+		Template: BrowserSpec{
+			Image: "selenoid/vnc:chrome_85.0",
+			RunAs: RunAsOptions{RunAsGroup: pointer.Int64Ptr(2000)},
+			Spec: Spec{
+				SecurityContext: &apiv1.SecurityContext{
+					RunAsUser:                pointer.Int64Ptr(1000),
+					AllowPrivilegeEscalation: pointer.BoolPtr(false),
+					ReadOnlyRootFilesystem:   pointer.BoolPtr(true),
+					Capabilities:             &apiv1.Capabilities{Add: []apiv1.Capability{"NET_ADMIN"}, Drop: []apiv1.Capability{"ALL"}},
+				},
+				PodSecurityContext: &apiv1.PodSecurityContext{SeccompProfile: &apiv1.SeccompProfile{Type: apiv1.SeccompProfileTypeUnconfined}},
+			},
+		},
+	})
+
+	assert.Equal(t, "1000:2000", spec.User)
+	assert.DeepEqual(t, []string{"NET_ADMIN"}, spec.HostConfig.CapAdd)
+	assert.DeepEqual(t, []string{"ALL"}, spec.HostConfig.CapDrop)
+	assert.Equal(t, true, spec.HostConfig.ReadonlyRootfs)
+	assert.DeepEqual(t, []string{"no-new-privileges", "seccomp=unconfined"}, spec.HostConfig.SecurityOpt)
+}
@@ -0,0 +1,43 @@
+package platform
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+//reservedContainerNames are names of containers selenosis adds to browser pod itself
+var reservedContainerNames = map[string]bool{
+	"browser":             true,
+	"seleniferous":        true,
+	videoContainerName:    true,
+	uploaderContainerName: true,
+	harContainerName:      true,
+}
+
+//sidecarContainers returns additional containers of browser pod declared in browser spec, e.g.
+//proxies or certificate trust helpers. Images are pulled if not present unless policy is set
+func sidecarContainers(sidecars []apiv1.Container) ([]apiv1.Container, error) {
+	names := make(map[string]bool, len(sidecars))
+	containers := make([]apiv1.Container, 0, len(sidecars))
+	for _, sidecar := range sidecars {
+		if sidecar.Name == "" {
+			return nil, fmt.Errorf("sidecar name is not set")
+		}
+		if reservedContainerNames[sidecar.Name] {
+			return nil, fmt.Errorf("sidecar %s: name is reserved", sidecar.Name)
+		}
+		if names[sidecar.Name] {
+			return nil, fmt.Errorf("sidecar %s: duplicate name", sidecar.Name)
+		}
+		if sidecar.Image == "" {
+			return nil, fmt.Errorf("sidecar %s: image is not set", sidecar.Name)
+		}
+		names[sidecar.Name] = true
+		if sidecar.ImagePullPolicy == "" {
+			sidecar.ImagePullPolicy = apiv1.PullIfNotPresent
+		}
+		containers = append(containers, sidecar)
+	}
+	return containers, nil
+}
@@ -0,0 +1,81 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/alcounit/selenosis/selenium"
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestBuildPodSidecars(t *testing.T) {
+	proxy := apiv1.Container{
+		Name:         "corporate-proxy",
+		Image:        "squid:4",
+		Env:          []apiv1.EnvVar{{Name: "UPSTREAM", Value: "proxy.corp:3128"}},
+		VolumeMounts: []apiv1.VolumeMount{{Name: "certs", MountPath: "/etc/ssl/corp"}},
+	}
+
+	tests := map[string]struct {
+		sidecars   []apiv1.Container
+		caps       selenium.Capabilities
+		containers []string
+		err        string
+	}{
+		"Verify sidecars are added after selenosis containers": {
+			sidecars:   []apiv1.Container{proxy},
+			containers: []string{"browser", "seleniferous", "corporate-proxy"},
+		},
+		"Verify sidecars are added before video recorder": {
+			sidecars:   []apiv1.Container{proxy},
+			caps:       selenium.Capabilities{Video: true},
+			containers: []string{"browser", "seleniferous", "corporate-proxy", "video-recorder"},
+		},
+		"Verify sidecar without name is rejected": {
+			sidecars: []apiv1.Container{{Image: "squid:4"}},
+			err:      "sidecar name is not set",
+		},
+		"Verify sidecar without image is rejected": {
+			sidecars: []apiv1.Container{{Name: "corporate-proxy"}},
+			err:      "sidecar corporate-proxy: image is not set",
+		},
+		"Verify sidecar can't replace selenosis container": {
+			sidecars: []apiv1.Container{{Name: "seleniferous", Image: "squid:4"}},
+			err:      "sidecar seleniferous: name is reserved",
+		},
+		"Verify sidecar names are unique": {
+			sidecars: []apiv1.Container{proxy, proxy},
+			err:      "sidecar corporate-proxy: duplicate name",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		cl := &service{
+			svcPort:    intstr.FromString("4445"),
+			proxyImage: "alcounit/seleniferous:latest",
+			videoImage: "selenoid/video-recorder:latest-release",
+		}
+		template := BrowserSpec{Image: "selenoid/vnc:chrome_85.0", Spec: Spec{Sidecars: test.sidecars}}
+
+		pod, err := cl.buildPod(ServiceSpec{SessionID: "session", RequestedCapabilities: test.caps, Template: template})
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+
+		var containers []string
+		for _, c := range pod.Spec.Containers {
+			containers = append(containers, c.Name)
+		}
+		assert.DeepEqual(t, test.containers, containers)
+
+		sidecar := pod.Spec.Containers[2]
+		assert.Equal(t, apiv1.PullIfNotPresent, sidecar.ImagePullPolicy)
+		assert.DeepEqual(t, proxy.Env, sidecar.Env)
+		assert.DeepEqual(t, proxy.VolumeMounts, sidecar.VolumeMounts)
+	}
+}
@@ -0,0 +1,348 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//Tenants routes sessions to platforms of their tenants, sessions without tenant use default platform
+type Tenants struct {
+	def      Platform
+	tenants  map[string]Platform
+	sessions sync.Map
+}
+
+//NewTenants ...
+func NewTenants(def Platform) *Tenants {
+	return &Tenants{
+		def:     def,
+		tenants: make(map[string]Platform),
+	}
+}
+
+//Add registers platform of the tenant and sets session limit of its namespace quota
+func (t *Tenants) Add(name string, p Platform, limit int64) error {
+	if err := setLimit(p, limit); err != nil {
+		return err
+	}
+	t.tenants[name] = p
+	return nil
+}
+
+//SetTenantLimit updates session limit of tenant namespace quota
+func (t *Tenants) SetTenantLimit(name string, limit int64) error {
+	p, ok := t.tenants[name]
+	if !ok {
+		return fmt.Errorf("unknown tenant %s", name)
+	}
+	return setLimit(p, limit)
+}
+
+func setLimit(p Platform, limit int64) error {
+	quota, err := p.Quota().Get()
+	if err != nil {
+		if _, err := p.Quota().Create(limit); err != nil {
+			return fmt.Errorf("failed to create quota resource: %v", err)
+		}
+	} else if quota.CurrentMaxLimit != limit {
+		if _, err := p.Quota().Update(limit); err != nil {
+			return fmt.Errorf("failed to update quota resource: %v", err)
+		}
+	}
+	return nil
+}
+
+func (t *Tenants) tenant(name string) Platform {
+	if p, ok := t.tenants[name]; ok {
+		return p
+	}
+	return t.def
+}
+
+func (t *Tenants) session(sessionID string) (Platform, bool) {
+	p, ok := t.sessions.Load(sessionID)
+	if !ok {
+		return nil, false
+	}
+	return p.(Platform), true
+}
+
+func (t *Tenants) platforms() []Platform {
+	platforms := []Platform{t.def}
+	for _, p := range t.tenants {
+		platforms = append(platforms, p)
+	}
+	return platforms
+}
+
+//Service ...
+func (t *Tenants) Service() ServiceInterface {
+	return &tenantsService{t}
+}
+
+//Quota returns quota of default platform
+func (t *Tenants) Quota() QuotaInterface {
+	return t.def.Quota()
+}
+
+//Resources ...
+func (t *Tenants) Resources() ResourceInterface {
+	return &tenantsResources{t}
+}
+
+//Artifacts keeps records of all tenants in default platform, credentials are read from tenant platform
+func (t *Tenants) Artifacts() ArtifactInterface {
+	return &tenantsArtifacts{t}
+}
+
+//State returns sessions of all tenants and workers of default platform
+func (t *Tenants) State() (PlatformState, error) {
+	state, err := t.def.State()
+	if err != nil {
+		return PlatformState{}, err
+	}
+	for _, p := range t.tenants {
+		s, err := p.State()
+		if err != nil {
+			return PlatformState{}, err
+		}
+		for _, service := range s.Services {
+			t.sessions.Store(service.SessionID, p)
+		}
+		state.Services = append(state.Services, s.Services...)
+	}
+	return state, nil
+}
+
+//CacheSize sums informer cache sizes of all tenants by resource
+func (t *Tenants) CacheSize() map[string]int {
+	sizes := make(map[string]int)
+	for _, p := range t.platforms() {
+		if c, ok := p.(CacheSizer); ok {
+			for resource, size := range c.CacheSize() {
+				sizes[resource] += size
+			}
+		}
+	}
+	return sizes
+}
+
+//FillWarmPool keeps warm pods in default namespace, sessions of tenants are always started in new pods
+func (t *Tenants) FillWarmPool(templates []BrowserSpec, capacity int) error {
+	if wp, ok := t.def.(WarmPooler); ok {
+		return wp.FillWarmPool(templates, capacity)
+	}
+	return nil
+}
+
+//WarmupImages pulls images with DaemonSets of default namespace, nodes are shared by namespaces of tenants
+func (t *Tenants) WarmupImages(templates []BrowserSpec) ([]ImageWarmup, error) {
+	if ip, ok := t.def.(ImagePuller); ok {
+		return ip.WarmupImages(templates)
+	}
+	return nil, ErrImageWarmupNotSupported
+}
+
+//ImageWarmups ...
+func (t *Tenants) ImageWarmups() ([]ImageWarmup, error) {
+	if ip, ok := t.def.(ImagePuller); ok {
+		return ip.ImageWarmups()
+	}
+	return nil, ErrImageWarmupNotSupported
+}
+
+//Debug attaches debug container to session on platform it was created on, default platform otherwise
+func (t *Tenants) Debug(sessionID string, spec DebugSpec) (DebugContainer, error) {
+	p, ok := t.session(sessionID)
+	if !ok {
+		p = t.def
+	}
+	if d, ok := p.(Debugger); ok {
+		return d.Debug(sessionID, spec)
+	}
+	return DebugContainer{}, ErrDebugNotSupported
+}
+
+//Extend extends session on platform of its tenant
+func (t *Tenants) Extend(sessionID string, max int) (SessionExtension, error) {
+	p, ok := t.session(sessionID)
+	if !ok {
+		p = t.def
+	}
+	if e, ok := p.(Extender); ok {
+		return e.Extend(sessionID, max)
+	}
+	return SessionExtension{}, ErrExtendNotSupported
+}
+
+//ListSessions lists sessions of default platform and then of tenants sorted by name, continue token
+//is index of the platform and continue token of its list
+func (t *Tenants) ListSessions(opts ListOptions) (SessionList, error) {
+	names := make([]string, 0, len(t.tenants))
+	for name := range t.tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	platforms := []Platform{t.def}
+	for _, name := range names {
+		platforms = append(platforms, t.tenants[name])
+	}
+
+	index := 0
+	if opts.Continue != "" {
+		parts := strings.SplitN(opts.Continue, "/", 2)
+		i, err := strconv.Atoi(parts[0])
+		if len(parts) != 2 || err != nil || i < 0 || i >= len(platforms) {
+			return SessionList{}, ErrInvalidContinue
+		}
+		index, opts.Continue = i, parts[1]
+	}
+
+	var list SessionList
+	for ; index < len(platforms); index++ {
+		page, err := ListSessions(platforms[index], opts)
+		if err != nil {
+			return SessionList{}, err
+		}
+		list.Services = append(list.Services, page.Services...)
+		if page.Continue != "" {
+			list.Continue = fmt.Sprintf("%d/%s", index, page.Continue)
+			return list, nil
+		}
+		opts.Continue = ""
+		if opts.Limit > 0 {
+			if opts.Limit -= int64(len(page.Services)); opts.Limit == 0 {
+				if index+1 < len(platforms) {
+					list.Continue = fmt.Sprintf("%d/", index+1)
+				}
+				return list, nil
+			}
+		}
+	}
+	return list, nil
+}
+
+//Watch returns events of default platform and session events of tenants
+func (t *Tenants) Watch() <-chan Event {
+	ch := make(chan Event)
+	go func() {
+		for event := range t.def.Watch() {
+			ch <- event
+		}
+	}()
+	for _, p := range t.tenants {
+		go func(p Platform) {
+			for event := range p.Watch() {
+				service, ok := event.PlatformObject.(Service)
+				if !ok {
+					continue
+				}
+				switch event.Type {
+				case Added, Updated:
+					t.sessions.Store(service.SessionID, p)
+				case Deleted:
+					t.sessions.Delete(service.SessionID)
+				}
+				ch <- event
+			}
+		}(p)
+	}
+	return ch
+}
+
+type tenantsService struct {
+	t *Tenants
+}
+
+//Create ...
+func (s *tenantsService) Create(spec ServiceSpec) (Service, error) {
+	p := s.t.tenant(spec.Tenant)
+	service, err := p.Service().Create(spec)
+	if err != nil {
+		return Service{}, err
+	}
+	s.t.sessions.Store(service.SessionID, p)
+	return service, nil
+}
+
+//Delete deletes session on platform it was created on, all platforms are tried if session is unknown
+func (s *tenantsService) Delete(name string) error {
+	if p, ok := s.t.session(name); ok {
+		return p.Service().Delete(name)
+	}
+	var err error
+	for _, p := range s.t.platforms() {
+		if err = p.Service().Delete(name); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+//Logs ...
+func (s *tenantsService) Logs(ctx context.Context, name string) (io.ReadCloser, error) {
+	if p, ok := s.t.session(name); ok {
+		return p.Service().Logs(ctx, name)
+	}
+	return s.t.def.Service().Logs(ctx, name)
+}
+
+type tenantsResources struct {
+	t *Tenants
+}
+
+//List ...
+func (r *tenantsResources) List() ([]Resource, error) {
+	var resources []Resource
+	for _, p := range r.t.platforms() {
+		list, err := p.Resources().List()
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, list...)
+	}
+	return resources, nil
+}
+
+//Delete ...
+func (r *tenantsResources) Delete(resource Resource) error {
+	if p, ok := r.t.session(resource.SessionID); ok {
+		return p.Resources().Delete(resource)
+	}
+	var err error
+	for _, p := range r.t.platforms() {
+		if err = p.Resources().Delete(resource); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+type tenantsArtifacts struct {
+	t *Tenants
+}
+
+//List ...
+func (a *tenantsArtifacts) List(tenant string) ([]Artifact, error) {
+	return a.t.def.Artifacts().List(tenant)
+}
+
+//Add ...
+func (a *tenantsArtifacts) Add(tenant string, artifact Artifact) error {
+	return a.t.def.Artifacts().Add(tenant, artifact)
+}
+
+//Remove ...
+func (a *tenantsArtifacts) Remove(tenant string, urls []string) error {
+	return a.t.def.Artifacts().Remove(tenant, urls)
+}
+
+//Credentials ...
+func (a *tenantsArtifacts) Credentials(tenant, secret string) (map[string]string, error) {
+	return a.t.tenant(tenant).Artifacts().Credentials(tenant, secret)
+}
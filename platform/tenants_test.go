@@ -0,0 +1,148 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	testcore "k8s.io/client-go/testing"
+)
+
+func newTenantsClient(clientset kubernetes.Interface, ns string, namespaced bool) *Client {
+	return &Client{
+		ns:              ns,
+		svc:             "seleniferous",
+		svcPort:         intstr.FromString("4445"),
+		namespacedHosts: namespaced,
+		clientset:       clientset,
+		service: &service{
+			ns:        ns,
+			clientset: clientset,
+		},
+		quota: &quota{
+			ns:        ns,
+			clientset: clientset,
+		},
+		resources: &resources{
+			ns:        ns,
+			clientset: clientset,
+		},
+	}
+}
+
+func createBrowserPod(t *testing.T, clientset kubernetes.Interface, ns, name string) {
+	_, err := clientset.CoreV1().Pods(ns).Create(context.Background(), &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{label: "browser"},
+		},
+		Status: apiv1.PodStatus{Phase: apiv1.PodRunning},
+	}, metav1.CreateOptions{})
+	assert.NilError(t, err)
+}
+
+func TestTenantsState(t *testing.T) {
+	mock := fake.NewSimpleClientset()
+	createBrowserPod(t, mock, "selenosis", "chrome-85-0-default")
+	createBrowserPod(t, mock, "selenosis-contractors", "chrome-85-0-contractors")
+
+	tenants := NewTenants(newTenantsClient(mock, "selenosis", false))
+	err := tenants.Add("contractors", newTenantsClient(mock, "selenosis-contractors", true), 5)
+	assert.NilError(t, err)
+
+	quota, err := tenants.tenants["contractors"].Quota().Get()
+	assert.NilError(t, err)
+	assert.Equal(t, int64(5), quota.CurrentMaxLimit)
+
+	state, err := tenants.State()
+	assert.NilError(t, err)
+
+	hosts := make(map[string]string)
+	for _, s := range state.Services {
+		hosts[s.SessionID] = s.URL.Host
+	}
+	assert.DeepEqual(t, map[string]string{
+		"chrome-85-0-default":     "chrome-85-0-default.seleniferous:4445",
+		"chrome-85-0-contractors": "chrome-85-0-contractors.seleniferous.selenosis-contractors:4445",
+	}, hosts)
+}
+
+func TestTenantsDelete(t *testing.T) {
+	tests := map[string]struct {
+		state   bool
+		podName string
+		err     string
+	}{
+		"Verify known tenant session deleted in its namespace": {
+			state:   true,
+			podName: "chrome-85-0-contractors",
+		},
+		"Verify unknown session deleted in any namespace": {
+			podName: "chrome-85-0-contractors",
+		},
+		"Verify error when session is not found": {
+			podName: "chrome-85-0-unknown",
+			err:     `pods "chrome-85-0-unknown" not found`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		mock := fake.NewSimpleClientset()
+		createBrowserPod(t, mock, "selenosis-contractors", "chrome-85-0-contractors")
+
+		tenants := NewTenants(newTenantsClient(mock, "selenosis", false))
+		err := tenants.Add("contractors", newTenantsClient(mock, "selenosis-contractors", true), 5)
+		assert.NilError(t, err)
+
+		if test.state {
+			_, err := tenants.State()
+			assert.NilError(t, err)
+		}
+
+		err = tenants.Service().Delete(test.podName)
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+
+		pods, err := mock.CoreV1().Pods("selenosis-contractors").List(context.Background(), metav1.ListOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, 0, len(pods.Items))
+	}
+}
+
+func TestTenantsCreate(t *testing.T) {
+	mock := fake.NewSimpleClientset()
+	var created *apiv1.Pod
+	mock.PrependReactor("create", "pods", func(action testcore.Action) (bool, runtime.Object, error) {
+		created = action.(testcore.CreateAction).GetObject().(*apiv1.Pod)
+		return true, nil, errors.New("quota exceeded")
+	})
+
+	tenants := NewTenants(newTenantsClient(mock, "selenosis", false))
+	err := tenants.Add("contractors", newTenantsClient(mock, "selenosis-contractors", true), 5)
+	assert.NilError(t, err)
+
+	_, err = tenants.Service().Create(ServiceSpec{
+		SessionID: "chrome-85-0-contractors",
+		Tenant:    "contractors",
+		Artifacts: Artifacts{
+			ArtifactDestination: ArtifactDestination{URL: "s3://contractors/selenosis", CredentialsSecret: "contractors-s3"},
+		},
+	})
+	assert.Error(t, err, "failed to create pod quota exceeded")
+
+	assert.Equal(t, "chrome-85-0-contractors", created.GetName())
+	assert.Equal(t, `{"browserName":"","browserVersion":"","tenant":"contractors","testName":""}`, created.GetAnnotations()["capabilities"])
+	assert.Equal(t, `{"downloads":{"url":"s3://contractors/selenosis","credentialsSecret":"contractors-s3"},"logs":{"url":"s3://contractors/selenosis","credentialsSecret":"contractors-s3"},"videos":{"url":"s3://contractors/selenosis","credentialsSecret":"contractors-s3"}}`, created.GetAnnotations()["artifacts"])
+}
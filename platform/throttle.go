@@ -0,0 +1,38 @@
+package platform
+
+import (
+	"context"
+	"time"
+
+	"github.com/alcounit/selenosis/metrics"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+//throttleThreshold is the rate limiter wait considered as throttling, shorter waits are token bookkeeping
+const throttleThreshold = time.Millisecond
+
+//throttleCounter counts kubernetes api requests delayed by client side rate limiter
+type throttleCounter struct {
+	flowcontrol.RateLimiter
+}
+
+func (t throttleCounter) Accept() {
+	start := time.Now()
+	t.RateLimiter.Accept()
+	observeThrottle(time.Since(start))
+}
+
+func (t throttleCounter) Wait(ctx context.Context) error {
+	start := time.Now()
+	err := t.RateLimiter.Wait(ctx)
+	observeThrottle(time.Since(start))
+	return err
+}
+
+func observeThrottle(d time.Duration) {
+	if d < throttleThreshold {
+		return
+	}
+	metrics.APIThrottled.Inc()
+	metrics.APIThrottleDuration.Add(d.Seconds())
+}
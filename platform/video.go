@@ -0,0 +1,343 @@
+package platform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/alcounit/selenosis/selenium"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	videoContainerName    = "video-recorder"
+	uploaderContainerName = "video-uploader"
+	videoVolumeName       = "video"
+	videoOutputDir        = "/data"
+	videoURLAnnotation    = "videoUrl"
+	videoStreamAnnotation = "videoStream"
+	videoStreamPortName   = "video-stream"
+)
+
+//uploadScript waits for termination of the pod, lets recorder finish the file and copies it to
+//object storage with aws cli, gs:// locations are reached through S3 compatible API with HMAC keys
+const uploadScript = `upload() {
+  file="` + videoOutputDir + `/$FILE_NAME"
+  size=-1
+  while [ -f "$file" ] && [ "$(stat -c %s "$file")" != "$size" ]; do size=$(stat -c %s "$file"); sleep 1; done
+  endpoint="${AWS_ENDPOINT:-$DEFAULT_ENDPOINT}"
+  [ -f "$file" ] && aws s3 cp --no-progress ${endpoint:+--endpoint-url "$endpoint"} "$file" "$UPLOAD_URL"
+  exit 0
+}
+trap upload TERM INT
+while true; do sleep 1; done`
+
+var (
+	videoCodecs  = []string{"libx264", "libx265", "libvpx-vp9"}
+	videoPresets = []string{"ultrafast", "superfast", "veryfast", "faster", "fast", "medium", "slow", "slower", "veryslow"}
+)
+
+//VideoEncoding describes default video encoding settings, capabilities can override them within limits,
+//zero values leave recorder defaults
+type VideoEncoding struct {
+	Codec        string
+	Preset       string
+	CRF          int
+	FrameRate    uint16
+	MaxFrameRate uint16
+}
+
+//Validate ...
+func (e VideoEncoding) Validate() error {
+	if e.Codec != "" && !contains(videoCodecs, e.Codec) {
+		return fmt.Errorf("unsupported video codec %s, supported: %s", e.Codec, strings.Join(videoCodecs, ", "))
+	}
+	if e.Preset != "" && !contains(videoPresets, e.Preset) {
+		return fmt.Errorf("unsupported video preset %s, supported: %s", e.Preset, strings.Join(videoPresets, ", "))
+	}
+	if e.CRF < 0 || e.CRF > 51 {
+		return fmt.Errorf("video crf should be between 0 and 51")
+	}
+	if e.MaxFrameRate > 0 && e.FrameRate > e.MaxFrameRate {
+		return fmt.Errorf("video frame rate %d exceeds max frame rate %d", e.FrameRate, e.MaxFrameRate)
+	}
+	return nil
+}
+
+//resolve returns encoding of the session, capabilities override defaults and are rejected if not supported or above limits
+func (e VideoEncoding) resolve(caps selenium.Capabilities) (VideoEncoding, error) {
+	if caps.VideoCodec != "" {
+		e.Codec = caps.VideoCodec
+	}
+	if caps.VideoPreset != "" {
+		e.Preset = caps.VideoPreset
+	}
+	if caps.VideoCRF > 0 {
+		e.CRF = caps.VideoCRF
+	}
+	if caps.VideoFrameRate > 0 {
+		e.FrameRate = caps.VideoFrameRate
+	}
+	if err := e.Validate(); err != nil {
+		return VideoEncoding{}, err
+	}
+	return e, nil
+}
+
+func (e VideoEncoding) env() []apiv1.EnvVar {
+	var env []apiv1.EnvVar
+	if e.FrameRate > 0 {
+		env = append(env, apiv1.EnvVar{Name: "FRAME_RATE", Value: fmt.Sprint(e.FrameRate)})
+	}
+	if e.Codec != "" {
+		env = append(env, apiv1.EnvVar{Name: "CODEC", Value: e.Codec})
+	}
+	var preset []string
+	if e.Preset != "" {
+		preset = append(preset, "-preset", e.Preset)
+	}
+	if e.CRF > 0 {
+		preset = append(preset, "-crf", fmt.Sprint(e.CRF))
+	}
+	if len(preset) > 0 {
+		env = append(env, apiv1.EnvVar{Name: "PRESET", Value: strings.Join(preset, " ")})
+	}
+	return env
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+//VideoName is data of video name template
+type VideoName struct {
+	SessionID      string
+	BrowserName    string
+	BrowserVersion string
+	TestName       string
+	Timestamp      time.Time
+}
+
+//ParseVideoName parses video name template, template is checked against sample session
+func ParseVideoName(text string) (*template.Template, error) {
+	tmpl, err := template.New("video").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse video name template: %v", err)
+	}
+	sample := VideoName{SessionID: "session", BrowserName: "chrome", BrowserVersion: "85.0", TestName: "test", Timestamp: time.Now()}
+	if _, err := videoName(tmpl, sample); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+//videoName executes video name template, names with path separators are rejected as recorder
+//writes videos to flat directory
+func videoName(tmpl *template.Template, data VideoName) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute video name template: %v", err)
+	}
+	name := strings.TrimSpace(buf.String())
+	if name == "" || strings.ContainsAny(name, "/\\") {
+		return "", fmt.Errorf("invalid video name %q", name)
+	}
+	return name, nil
+}
+
+//videoOverlay returns drawtext filter burning session metadata into recording, timestamp is
+//rendered by ffmpeg for each frame
+func videoOverlay(layout ServiceSpec) string {
+	caps := layout.RequestedCapabilities
+	escape := strings.NewReplacer("'", "", "\\", "", ":", "\\:", "%", "\\%")
+
+	var text []string
+	if caps.TestName != "" {
+		text = append(text, escape.Replace(caps.TestName))
+	}
+	if browser := strings.TrimSpace(caps.GetBrowserName() + " " + caps.BrowserVersion); browser != "" {
+		text = append(text, escape.Replace(browser))
+	}
+	text = append(text, layout.SessionID, "%{gmtime} UTC")
+
+	return fmt.Sprintf("drawtext=text='%s':fontcolor=white:fontsize=16:box=1:boxcolor=black@0.5:x=10:y=h-th-10", strings.Join(text, " | "))
+}
+
+//VideoSpec describes video recorder container of browser pod, recorder gets only its own env,
+//not env of the browser
+type VideoSpec struct {
+	Image     string                     `yaml:"image,omitempty" json:"image,omitempty"`
+	Resources apiv1.ResourceRequirements `yaml:"resources,omitempty" json:"resources,omitempty"`
+	Env       []apiv1.EnvVar             `yaml:"env,omitempty" json:"env,omitempty"`
+	Stream    *VideoStreamSpec           `yaml:"stream,omitempty" json:"stream,omitempty"`
+}
+
+//VideoStreamSpec describes live stream, e.g. MJPEG or HLS, served over HTTP by recorder images supporting it
+//while the session is recorded. Port is passed to recorder in STREAM_PORT env, path is the stream url path
+type VideoStreamSpec struct {
+	Port int    `yaml:"port" json:"port"`
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+}
+
+//getVideoStream returns live stream of the session recorder from pod annotations
+func getVideoStream(annotations map[string]string) *VideoStreamSpec {
+	value, ok := annotations[videoStreamAnnotation]
+	if !ok {
+		return nil
+	}
+	var stream VideoStreamSpec
+	if err := json.Unmarshal([]byte(value), &stream); err != nil || stream.Port == 0 {
+		return nil
+	}
+	return &stream
+}
+
+//videoContainer returns recorder container of the session, video settings of requested capabilities
+//and default encoding are passed to recorder env, env of catalog overrides them
+func (cl *service) videoContainer(layout ServiceSpec) (apiv1.Container, error) {
+	caps := layout.RequestedCapabilities
+
+	var spec VideoSpec
+	if layout.Template.Video != nil {
+		spec = *layout.Template.Video
+	}
+	image := spec.Image
+	if image == "" {
+		image = cl.videoImage
+	}
+
+	fileName, err := cl.videoFileName(layout)
+	if err != nil {
+		return apiv1.Container{}, err
+	}
+	env := []apiv1.EnvVar{
+		{Name: "BROWSER_CONTAINER_NAME", Value: "localhost"},
+		{Name: "FILE_NAME", Value: fileName},
+	}
+	size := caps.VideoScreenSize
+	if parts := strings.Split(caps.ScreenResolution, "x"); size == "" && len(parts) >= 2 {
+		size = parts[0] + "x" + parts[1]
+	}
+	if size != "" {
+		env = append(env, apiv1.EnvVar{Name: "VIDEO_SIZE", Value: size})
+	}
+	encoding, err := cl.videoEncoding.resolve(caps)
+	if err != nil {
+		return apiv1.Container{}, err
+	}
+	env = append(env, encoding.env()...)
+	if cl.videoOverlay || caps.VideoOverlay {
+		env = append(env, apiv1.EnvVar{Name: "DRAWTEXT", Value: videoOverlay(layout)})
+	}
+	var ports []apiv1.ContainerPort
+	if spec.Stream != nil {
+		if spec.Stream.Port <= 0 || spec.Stream.Port > 65535 {
+			return apiv1.Container{}, fmt.Errorf("invalid video stream port %d", spec.Stream.Port)
+		}
+		env = append(env, apiv1.EnvVar{Name: "STREAM_PORT", Value: fmt.Sprint(spec.Stream.Port)})
+		ports = append(ports, apiv1.ContainerPort{Name: videoStreamPortName, ContainerPort: int32(spec.Stream.Port)})
+	}
+
+	return apiv1.Container{
+		Name:            videoContainerName,
+		Image:           image,
+		Env:             mergeEnv(env, spec.Env),
+		Ports:           ports,
+		Resources:       spec.Resources,
+		VolumeMounts:    []apiv1.VolumeMount{{Name: videoVolumeName, MountPath: videoOutputDir}},
+		ImagePullPolicy: apiv1.PullIfNotPresent,
+	}, nil
+}
+
+//videoFileName returns name of the recording, videoName capability is preferred to name template
+func (cl *service) videoFileName(layout ServiceSpec) (string, error) {
+	caps := layout.RequestedCapabilities
+	if caps.VideoName != "" {
+		return caps.VideoName, nil
+	}
+	if cl.videoName != nil {
+		return videoName(cl.videoName, VideoName{
+			SessionID:      layout.SessionID,
+			BrowserName:    caps.GetBrowserName(),
+			BrowserVersion: caps.BrowserVersion,
+			TestName:       caps.TestName,
+			Timestamp:      time.Now(),
+		})
+	}
+	return layout.SessionID + ".mp4", nil
+}
+
+//uploaderContainer returns container copying recording to videos destination of the session once pod
+//is terminated and url the recording is uploaded to. uploadVideo capability overrides destination url,
+//credentials of videos destination are used. Nil container is returned when upload is not requested
+func (cl *service) uploaderContainer(layout ServiceSpec) (*apiv1.Container, string, error) {
+	caps := layout.RequestedCapabilities
+	dst, ok := layout.Artifacts.Destination("videos")
+	switch {
+	case caps.UploadVideo != "":
+		dst.URL = caps.UploadVideo
+	case !cl.videoUpload || !ok:
+		return nil, "", nil
+	}
+
+	u, err := url.Parse(dst.URL)
+	if err != nil || (u.Scheme != "s3" && u.Scheme != "gs") || u.Host == "" {
+		return nil, "", fmt.Errorf("unsupported video upload url %s, s3:// and gs:// are supported", dst.URL)
+	}
+	fileName, err := cl.videoFileName(layout)
+	if err != nil {
+		return nil, "", err
+	}
+	u.Path = path.Join("/", u.Path, fileName)
+	videoURL := u.String()
+
+	var defaultEndpoint string
+	if u.Scheme == "gs" {
+		defaultEndpoint = "https://storage.googleapis.com"
+		u.Scheme = "s3"
+	}
+	container := &apiv1.Container{
+		Name:    uploaderContainerName,
+		Image:   cl.videoUploaderImage,
+		Command: []string{"/bin/sh", "-c", uploadScript},
+		Env: []apiv1.EnvVar{
+			{Name: "FILE_NAME", Value: fileName},
+			{Name: "UPLOAD_URL", Value: u.String()},
+			{Name: "DEFAULT_ENDPOINT", Value: defaultEndpoint},
+		},
+		VolumeMounts:    []apiv1.VolumeMount{{Name: videoVolumeName, MountPath: videoOutputDir, ReadOnly: true}},
+		ImagePullPolicy: apiv1.PullIfNotPresent,
+	}
+	if dst.CredentialsSecret != "" {
+		container.EnvFrom = []apiv1.EnvFromSource{{SecretRef: &apiv1.SecretEnvSource{LocalObjectReference: apiv1.LocalObjectReference{Name: dst.CredentialsSecret}}}}
+	}
+	return container, videoURL, nil
+}
+
+//mergeEnv returns env with variables of overrides replacing variables with the same name
+func mergeEnv(env, overrides []apiv1.EnvVar) []apiv1.EnvVar {
+	merged := append([]apiv1.EnvVar{}, env...)
+	for _, v := range overrides {
+		replaced := false
+		for i := range merged {
+			if merged[i].Name == v.Name {
+				merged[i] = v
+				replaced = true
+			}
+		}
+		if !replaced {
+			merged = append(merged, v)
+		}
+	}
+	return merged
+}
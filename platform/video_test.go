@@ -0,0 +1,311 @@
+package platform
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alcounit/selenosis/selenium"
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestBuildPodVideo(t *testing.T) {
+	tests := map[string]struct {
+		caps      selenium.Capabilities
+		video     *VideoSpec
+		encoding  VideoEncoding
+		name      string
+		overlay   bool
+		platform  string
+		image     string
+		env       []apiv1.EnvVar
+		resources apiv1.ResourceRequirements
+		ports     []apiv1.ContainerPort
+		stream    string
+		err       string
+	}{
+		"Verify recorder is configured from capabilities": {
+			caps:  selenium.Capabilities{Video: true, ScreenResolution: "1920x1080x24", VideoFrameRate: 24},
+			image: "selenoid/video-recorder:latest-release",
+			env: []apiv1.EnvVar{
+				{Name: "BROWSER_CONTAINER_NAME", Value: "localhost"},
+				{Name: "FILE_NAME", Value: "session.mp4"},
+				{Name: "VIDEO_SIZE", Value: "1920x1080"},
+				{Name: "FRAME_RATE", Value: "24"},
+			},
+		},
+		"Verify catalog video settings override defaults": {
+			caps: selenium.Capabilities{Video: true, VideoName: "login.mp4", VideoCodec: "libx264"},
+			video: &VideoSpec{
+				Image:     "selenoid/video-recorder:7.1",
+				Resources: apiv1.ResourceRequirements{Requests: apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("1")}},
+				Env:       []apiv1.EnvVar{{Name: "CODEC", Value: "libx265"}, {Name: "PRESET", Value: "-preset ultrafast"}},
+			},
+			image: "selenoid/video-recorder:7.1",
+			env: []apiv1.EnvVar{
+				{Name: "BROWSER_CONTAINER_NAME", Value: "localhost"},
+				{Name: "FILE_NAME", Value: "login.mp4"},
+				{Name: "CODEC", Value: "libx265"},
+				{Name: "PRESET", Value: "-preset ultrafast"},
+			},
+			resources: apiv1.ResourceRequirements{Requests: apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("1")}},
+		},
+		"Verify default encoding is overridden by capabilities": {
+			caps:     selenium.Capabilities{Video: true, VideoPreset: "veryfast", VideoFrameRate: 15},
+			encoding: VideoEncoding{Codec: "libx264", Preset: "ultrafast", CRF: 28, FrameRate: 12, MaxFrameRate: 30},
+			image:    "selenoid/video-recorder:latest-release",
+			env: []apiv1.EnvVar{
+				{Name: "BROWSER_CONTAINER_NAME", Value: "localhost"},
+				{Name: "FILE_NAME", Value: "session.mp4"},
+				{Name: "FRAME_RATE", Value: "15"},
+				{Name: "CODEC", Value: "libx264"},
+				{Name: "PRESET", Value: "-preset veryfast -crf 28"},
+			},
+		},
+		"Verify frame rate above limit is rejected": {
+			caps:     selenium.Capabilities{Video: true, VideoFrameRate: 60},
+			encoding: VideoEncoding{MaxFrameRate: 30},
+			err:      "video frame rate 60 exceeds max frame rate 30",
+		},
+		"Verify unsupported codec is rejected": {
+			caps: selenium.Capabilities{Video: true, VideoCodec: "mpeg4"},
+			err:  "unsupported video codec mpeg4, supported: libx264, libx265, libvpx-vp9",
+		},
+		"Verify video name is built from template": {
+			caps:  selenium.Capabilities{Video: true, BrowserName: "chrome", BrowserVersion: "85.0", TestName: "login"},
+			name:  "{{.BrowserName}}-{{.BrowserVersion}}-{{.TestName}}-{{.SessionID}}.mp4",
+			image: "selenoid/video-recorder:latest-release",
+			env: []apiv1.EnvVar{
+				{Name: "BROWSER_CONTAINER_NAME", Value: "localhost"},
+				{Name: "FILE_NAME", Value: "chrome-85.0-login-session.mp4"},
+			},
+		},
+		"Verify video name capability overrides template": {
+			caps:  selenium.Capabilities{Video: true, VideoName: "login.mp4"},
+			name:  "{{.SessionID}}.webm",
+			image: "selenoid/video-recorder:latest-release",
+			env: []apiv1.EnvVar{
+				{Name: "BROWSER_CONTAINER_NAME", Value: "localhost"},
+				{Name: "FILE_NAME", Value: "login.mp4"},
+			},
+		},
+		"Verify video name with path separator is rejected": {
+			caps: selenium.Capabilities{Video: true, TestName: "auth/login"},
+			name: "{{.TestName}}.mp4",
+			err:  `invalid video name "auth/login.mp4"`,
+		},
+		"Verify metadata overlay is configured from capability": {
+			caps:  selenium.Capabilities{Video: true, VideoOverlay: true, BrowserName: "chrome", BrowserVersion: "85.0", TestName: "it's 100% login: admin"},
+			image: "selenoid/video-recorder:latest-release",
+			env: []apiv1.EnvVar{
+				{Name: "BROWSER_CONTAINER_NAME", Value: "localhost"},
+				{Name: "FILE_NAME", Value: "session.mp4"},
+				{Name: "DRAWTEXT", Value: `drawtext=text='its 100\% login\: admin | chrome 85.0 | session | %{gmtime} UTC':fontcolor=white:fontsize=16:box=1:boxcolor=black@0.5:x=10:y=h-th-10`},
+			},
+		},
+		"Verify metadata overlay is enabled by default": {
+			caps:    selenium.Capabilities{Video: true},
+			overlay: true,
+			image:   "selenoid/video-recorder:latest-release",
+			env: []apiv1.EnvVar{
+				{Name: "BROWSER_CONTAINER_NAME", Value: "localhost"},
+				{Name: "FILE_NAME", Value: "session.mp4"},
+				{Name: "DRAWTEXT", Value: "drawtext=text='session | %{gmtime} UTC':fontcolor=white:fontsize=16:box=1:boxcolor=black@0.5:x=10:y=h-th-10"},
+			},
+		},
+		"Verify recorder serves live stream on configured port": {
+			caps:  selenium.Capabilities{Video: true},
+			video: &VideoSpec{Image: "example/video-streamer:1.0", Stream: &VideoStreamSpec{Port: 8090, Path: "/live/stream.m3u8"}},
+			image: "example/video-streamer:1.0",
+			env: []apiv1.EnvVar{
+				{Name: "BROWSER_CONTAINER_NAME", Value: "localhost"},
+				{Name: "FILE_NAME", Value: "session.mp4"},
+				{Name: "STREAM_PORT", Value: "8090"},
+			},
+			ports:  []apiv1.ContainerPort{{Name: "video-stream", ContainerPort: 8090}},
+			stream: `{"port":8090,"path":"/live/stream.m3u8"}`,
+		},
+		"Verify invalid stream port is rejected": {
+			caps:  selenium.Capabilities{Video: true},
+			video: &VideoSpec{Stream: &VideoStreamSpec{Port: 70000}},
+			err:   "invalid video stream port 70000",
+		},
+		"Verify video is not supported for windows browsers": {
+			caps:     selenium.Capabilities{Video: true},
+			platform: WindowsPlatform,
+			err:      "video recording is not supported for windows browsers",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		cl := &service{
+			svcPort:           intstr.FromString("4445"),
+			proxyImage:        "alcounit/seleniferous:latest",
+			windowsProxyImage: "alcounit/seleniferous:windows",
+			videoImage:        "selenoid/video-recorder:latest-release",
+			videoEncoding:     test.encoding,
+			videoOverlay:      test.overlay,
+		}
+		if test.name != "" {
+			tmpl, err := ParseVideoName(test.name)
+			assert.NilError(t, err)
+			cl.videoName = tmpl
+		}
+		template := BrowserSpec{
+			Image:    "selenoid/vnc:chrome_85.0",
+			Platform: test.platform,
+			Spec:     Spec{EnvVars: []apiv1.EnvVar{{Name: "ENABLE_VNC", Value: "true"}}},
+			Video:    test.video,
+		}
+
+		pod, err := cl.buildPod(ServiceSpec{SessionID: "session", RequestedCapabilities: test.caps, Template: template})
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, 3, len(pod.Spec.Containers))
+
+		recorder := pod.Spec.Containers[2]
+		assert.Equal(t, test.image, recorder.Image)
+		assert.DeepEqual(t, test.env, recorder.Env)
+		assert.DeepEqual(t, test.resources, recorder.Resources)
+		assert.DeepEqual(t, test.ports, recorder.Ports)
+		assert.Equal(t, test.stream, pod.Annotations[videoStreamAnnotation])
+		assert.DeepEqual(t, []apiv1.VolumeMount{{Name: videoVolumeName, MountPath: videoOutputDir}}, pod.Spec.Containers[1].VolumeMounts)
+	}
+}
+
+func TestGetVideoStream(t *testing.T) {
+	tests := map[string]struct {
+		annotations map[string]string
+		stream      *VideoStreamSpec
+	}{
+		"Verify stream is read from annotation": {
+			annotations: map[string]string{videoStreamAnnotation: `{"port":8090,"path":"/stream.mjpeg"}`},
+			stream:      &VideoStreamSpec{Port: 8090, Path: "/stream.mjpeg"},
+		},
+		"Verify session without stream annotation has no stream": {},
+		"Verify malformed annotation is ignored": {
+			annotations: map[string]string{videoStreamAnnotation: `8090`},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+		assert.DeepEqual(t, test.stream, getVideoStream(test.annotations))
+	}
+}
+
+func TestParseVideoName(t *testing.T) {
+	tests := map[string]struct {
+		text string
+		err  string
+	}{
+		"Verify template with timestamp is parsed": {
+			text: `{{.Timestamp.Format "20060102-150405"}}-{{.SessionID}}.mp4`,
+		},
+		"Verify template with unknown field is rejected": {
+			text: "{{.Session}}.mp4",
+			err:  "failed to execute video name template",
+		},
+		"Verify template with syntax error is rejected": {
+			text: "{{.SessionID}.mp4",
+			err:  "failed to parse video name template",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		_, err := ParseVideoName(test.text)
+		if test.err != "" {
+			assert.Assert(t, err != nil && strings.HasPrefix(err.Error(), test.err), err)
+			continue
+		}
+		assert.NilError(t, err)
+	}
+}
+
+func TestBuildPodVideoUpload(t *testing.T) {
+	videos := Artifacts{ArtifactDestination: ArtifactDestination{URL: "s3://artifacts/selenosis", CredentialsSecret: "artifacts-s3"}}
+
+	tests := map[string]struct {
+		caps      selenium.Capabilities
+		upload    bool
+		artifacts Artifacts
+		url       string
+		env       []apiv1.EnvVar
+		secret    string
+		err       string
+	}{
+		"Verify recording is uploaded to videos destination": {
+			caps:      selenium.Capabilities{Video: true},
+			upload:    true,
+			artifacts: videos,
+			url:       "s3://artifacts/selenosis/session.mp4",
+			env: []apiv1.EnvVar{
+				{Name: "FILE_NAME", Value: "session.mp4"},
+				{Name: "UPLOAD_URL", Value: "s3://artifacts/selenosis/session.mp4"},
+				{Name: "DEFAULT_ENDPOINT", Value: ""},
+			},
+			secret: "artifacts-s3",
+		},
+		"Verify capability overrides destination url": {
+			caps:      selenium.Capabilities{Video: true, VideoName: "login.mp4", UploadVideo: "gs://qa-videos/nightly/"},
+			artifacts: videos,
+			url:       "gs://qa-videos/nightly/login.mp4",
+			env: []apiv1.EnvVar{
+				{Name: "FILE_NAME", Value: "login.mp4"},
+				{Name: "UPLOAD_URL", Value: "s3://qa-videos/nightly/login.mp4"},
+				{Name: "DEFAULT_ENDPOINT", Value: "https://storage.googleapis.com"},
+			},
+			secret: "artifacts-s3",
+		},
+		"Verify recording is not uploaded without destination": {
+			caps:   selenium.Capabilities{Video: true},
+			upload: true,
+		},
+		"Verify unsupported upload url is rejected": {
+			caps: selenium.Capabilities{Video: true, UploadVideo: "ftp://videos"},
+			err:  "unsupported video upload url ftp://videos, s3:// and gs:// are supported",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		cl := &service{
+			svcPort:            intstr.FromString("4445"),
+			proxyImage:         "alcounit/seleniferous:latest",
+			videoImage:         "selenoid/video-recorder:latest-release",
+			videoUpload:        test.upload,
+			videoUploaderImage: "amazon/aws-cli:2.2.0",
+		}
+		template := BrowserSpec{Image: "selenoid/vnc:chrome_85.0"}
+
+		pod, err := cl.buildPod(ServiceSpec{SessionID: "session", RequestedCapabilities: test.caps, Artifacts: test.artifacts, Template: template})
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+		if test.url == "" {
+			assert.Equal(t, 3, len(pod.Spec.Containers))
+			assert.Equal(t, "", pod.Annotations[videoURLAnnotation])
+			continue
+		}
+		assert.Equal(t, 4, len(pod.Spec.Containers))
+		assert.Equal(t, test.url, pod.Annotations[videoURLAnnotation])
+
+		uploader := pod.Spec.Containers[3]
+		assert.Equal(t, "amazon/aws-cli:2.2.0", uploader.Image)
+		assert.DeepEqual(t, test.env, uploader.Env)
+		assert.Equal(t, test.secret, uploader.EnvFrom[0].SecretRef.Name)
+		assert.DeepEqual(t, []apiv1.VolumeMount{{Name: videoVolumeName, MountPath: videoOutputDir, ReadOnly: true}}, uploader.VolumeMounts)
+	}
+}
@@ -0,0 +1,532 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alcounit/selenosis/tools"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+//VirtualMachineInstanceResource is KubeVirt virtual machine instance browsers with vm settings are started in
+var VirtualMachineInstanceResource = schema.GroupVersionResource{
+	Group:    "kubevirt.io",
+	Version:  "v1",
+	Resource: "virtualmachineinstances",
+}
+
+//vmLabel marks virtual machines of browser sessions, virt-launcher pods inherit labels of the machine,
+//so machines are not labeled with selenosis.app.type and their pods are not taken for browser pods
+const vmLabel = "selenosis.app.vm"
+
+const (
+	defaultVMCPU    = 2
+	defaultVMMemory = "4Gi"
+)
+
+//VMSpec describes virtual machine the browser is started in instead of pod, browser image is container disk
+//of the machine booting the browser, its driver and seleniferous listening on service port
+type VMSpec struct {
+	CPU      int    `yaml:"cpu,omitempty" json:"cpu,omitempty"`
+	Memory   string `yaml:"memory,omitempty" json:"memory,omitempty"`
+	Firmware string `yaml:"firmware,omitempty" json:"firmware,omitempty"`
+}
+
+//Validate ...
+func (v VMSpec) Validate() error {
+	if v.CPU < 0 {
+		return fmt.Errorf("invalid cpu count %d", v.CPU)
+	}
+	if v.Memory != "" {
+		if _, err := resource.ParseQuantity(v.Memory); err != nil {
+			return fmt.Errorf("invalid memory %s: %v", v.Memory, err)
+		}
+	}
+	switch v.Firmware {
+	case "", "bios", "efi":
+	default:
+		return fmt.Errorf("unknown firmware %s", v.Firmware)
+	}
+	return nil
+}
+
+//VirtualMachinesConfig ...
+type VirtualMachinesConfig struct {
+	Namespace        string
+	Service          string
+	ServicePort      string
+	ReadinessTimeout time.Duration
+}
+
+//VirtualMachines wraps platform and starts browsers with vm settings in KubeVirt virtual machines instead of
+//pods, machines join headless service of browser pods, so their sessions are proxied like pod sessions
+type VirtualMachines struct {
+	Platform
+	client   dynamic.Interface
+	cfg      VirtualMachinesConfig
+	interval time.Duration
+
+	mu      sync.Mutex
+	started map[string]bool
+	events  chan Event
+}
+
+//NewVirtualMachines ...
+func NewVirtualMachines(p Platform, client dynamic.Interface, cfg VirtualMachinesConfig) *VirtualMachines {
+	return &VirtualMachines{
+		Platform: p,
+		client:   client,
+		cfg:      cfg,
+		interval: time.Second,
+		started:  make(map[string]bool),
+		events:   make(chan Event),
+	}
+}
+
+func (v *VirtualMachines) resource() dynamic.ResourceInterface {
+	return v.client.Resource(VirtualMachineInstanceResource).Namespace(v.cfg.Namespace)
+}
+
+//Service ...
+func (v *VirtualMachines) Service() ServiceInterface {
+	return &vmService{
+		ServiceInterface: v.Platform.Service(),
+		v:                v,
+	}
+}
+
+//State returns state of wrapped platform with sessions of virtual machines
+func (v *VirtualMachines) State() (PlatformState, error) {
+	state, err := v.Platform.State()
+	if err != nil {
+		return PlatformState{}, err
+	}
+	services, err := v.list()
+	if err != nil {
+		return PlatformState{}, err
+	}
+	state.Services = append(state.Services, services...)
+	return state, nil
+}
+
+//Watch returns events of wrapped platform and events of virtual machines started and deleted by the replica
+func (v *VirtualMachines) Watch() <-chan Event {
+	ch := make(chan Event)
+	go func() {
+		for event := range v.Platform.Watch() {
+			ch <- event
+		}
+	}()
+	go func() {
+		for event := range v.events {
+			ch <- event
+		}
+	}()
+	return ch
+}
+
+func (v *VirtualMachines) list() ([]Service, error) {
+	list, err := v.resource().List(context.Background(), metav1.ListOptions{LabelSelector: vmLabel})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get virtual machines: %v", err)
+	}
+	services := make([]Service, 0, len(list.Items))
+	for i := range list.Items {
+		vmi := &list.Items[i]
+		status := Pending
+		if vmReady(vmi) {
+			status = Running
+		}
+		services = append(services, v.vmService(vmi, status))
+	}
+	return services, nil
+}
+
+func (v *VirtualMachines) vmService(vmi *unstructured.Unstructured, status ServiceStatus) Service {
+	name := vmi.GetName()
+	return Service{
+		SessionID: name,
+		URL: &url.URL{
+			Scheme: "http",
+			Host:   tools.BuildHostPort(name, v.cfg.Service, v.cfg.ServicePort),
+		},
+		Labels:        getRequestedCapabilities(vmi.GetAnnotations()),
+		SessionLabels: getSessionLabels(vmi.GetAnnotations()),
+		CancelFunc: func() {
+			v.Service().Delete(name)
+		},
+		Status:  status,
+		Started: vmi.GetCreationTimestamp().Time,
+	}
+}
+
+//buildVM returns virtual machine instance of the session, machine gets hostname and subdomain of browser
+//pods and readiness probe of seleniferous port, so it is ready once seleniferous is listening
+func (v *VirtualMachines) buildVM(spec ServiceSpec) (*unstructured.Unstructured, error) {
+	vm := *spec.Template.VM
+	if vm.CPU == 0 {
+		vm.CPU = defaultVMCPU
+	}
+	if vm.Memory == "" {
+		vm.Memory = defaultVMMemory
+	}
+	port, err := strconv.Atoi(v.cfg.ServicePort)
+	if err != nil {
+		return nil, fmt.Errorf("invalid service port %s", v.cfg.ServicePort)
+	}
+
+	annotations := map[string]string{
+		defaultsAnnotations.browserName:    spec.Template.BrowserName,
+		defaultsAnnotations.browserVersion: spec.Template.BrowserVersion,
+		defaultsAnnotations.testName:       spec.RequestedCapabilities.TestName,
+	}
+	if spec.RequestedCapabilities.RunID != "" {
+		annotations[defaultsAnnotations.runID] = spec.RequestedCapabilities.RunID
+	}
+	if spec.Tenant != "" {
+		annotations[defaultsAnnotations.tenant] = spec.Tenant
+	}
+	if spec.IdleTimeout > 0 {
+		annotations[defaultsAnnotations.sessionTimeout] = spec.IdleTimeout.String()
+	}
+	if spec.Client != "" {
+		annotations[defaultsAnnotations.client] = spec.Client
+	}
+	if spec.Owner != "" {
+		annotations[defaultsAnnotations.owner] = spec.Owner
+	}
+	meta := make(map[string]string)
+	for k, val := range spec.Template.Meta.Annotations {
+		meta[k] = val
+	}
+	if caps, err := json.Marshal(annotations); err == nil {
+		meta["capabilities"] = string(caps)
+	}
+
+	sessionLabels, err := RequestedLabels(spec.RequestedCapabilities)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessionLabels) > 0 {
+		if data, err := json.Marshal(sessionLabels); err == nil {
+			meta[sessionLabelsAnnotation] = string(data)
+		}
+	}
+
+	labels := make(map[string]string)
+	for k, val := range spec.Template.Meta.Labels {
+		labels[k] = val
+	}
+	for k, val := range sessionLabels {
+		labels[k] = val
+	}
+	for k, val := range browserLabels(spec.Template) {
+		labels[k] = val
+	}
+	labels[defaultLabels.serviceType] = "browser"
+	labels[defaultLabels.session] = spec.SessionID
+	labels[vmLabel] = "browser"
+	if owner := sanitizeLabelValue(spec.Owner); owner != "" {
+		labels[ownerLabel] = owner
+	}
+
+	domain := map[string]interface{}{
+		"cpu": map[string]interface{}{"cores": int64(vm.CPU)},
+		"resources": map[string]interface{}{
+			"requests": map[string]interface{}{"memory": vm.Memory},
+		},
+		"devices": map[string]interface{}{
+			"disks": []interface{}{
+				map[string]interface{}{"name": "browser", "disk": map[string]interface{}{"bus": "sata"}},
+			},
+			"interfaces": []interface{}{
+				map[string]interface{}{"name": "default", "masquerade": map[string]interface{}{}},
+			},
+		},
+	}
+	if vm.Firmware == "efi" {
+		domain["firmware"] = map[string]interface{}{
+			"bootloader": map[string]interface{}{"efi": map[string]interface{}{"secureBoot": false}},
+		}
+	}
+
+	vmi := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"hostname":                      spec.SessionID,
+			"subdomain":                     v.cfg.Service,
+			"terminationGracePeriodSeconds": int64(0),
+			"domain":                        domain,
+			"networks": []interface{}{
+				map[string]interface{}{"name": "default", "pod": map[string]interface{}{}},
+			},
+			"volumes": []interface{}{
+				map[string]interface{}{
+					"name":          "browser",
+					"containerDisk": map[string]interface{}{"image": spec.Template.Image},
+				},
+			},
+			"readinessProbe": map[string]interface{}{
+				"tcpSocket":     map[string]interface{}{"port": int64(port)},
+				"periodSeconds": int64(2),
+			},
+		},
+	}}
+	vmi.SetAPIVersion(VirtualMachineInstanceResource.GroupVersion().String())
+	vmi.SetKind("VirtualMachineInstance")
+	vmi.SetName(spec.SessionID)
+	vmi.SetNamespace(v.cfg.Namespace)
+	vmi.SetLabels(labels)
+	vmi.SetAnnotations(meta)
+	return vmi, nil
+}
+
+//vmReady reports if Ready condition of virtual machine instance is true
+func vmReady(vmi *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(vmi.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if ok && condition["type"] == "Ready" && condition["status"] == "True" {
+			return true
+		}
+	}
+	return false
+}
+
+//waitReady polls virtual machine instance until it is ready, failed or timeout is over
+func (v *VirtualMachines) waitReady(name string, timeout time.Duration) error {
+	ticker := time.NewTicker(v.interval)
+	defer ticker.Stop()
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	for {
+		vmi, err := v.resource().Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get virtual machine: %v", err)
+		}
+		if vmReady(vmi) {
+			return nil
+		}
+		switch phase, _, _ := unstructured.NestedString(vmi.Object, "status", "phase"); phase {
+		case "Failed", "Succeeded":
+			return fmt.Errorf("virtual machine stopped in %s phase", phase)
+		}
+		select {
+		case <-deadline:
+			return fmt.Errorf("virtual machine is not ready in %s", timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+//isVM reports if session runs in virtual machine
+func (v *VirtualMachines) isVM(sessionID string) (bool, error) {
+	_, err := v.resource().Get(context.Background(), sessionID, metav1.GetOptions{})
+	switch {
+	case err == nil:
+		return true, nil
+	case apierrors.IsNotFound(err):
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to get virtual machine: %v", err)
+	}
+}
+
+//CacheSize ...
+func (v *VirtualMachines) CacheSize() map[string]int {
+	if cs, ok := v.Platform.(CacheSizer); ok {
+		return cs.CacheSize()
+	}
+	return nil
+}
+
+//FillWarmPool ...
+func (v *VirtualMachines) FillWarmPool(templates []BrowserSpec, capacity int) error {
+	if wp, ok := v.Platform.(WarmPooler); ok {
+		return wp.FillWarmPool(templates, capacity)
+	}
+	return nil
+}
+
+//WarmupImages ...
+func (v *VirtualMachines) WarmupImages(templates []BrowserSpec) ([]ImageWarmup, error) {
+	if ip, ok := v.Platform.(ImagePuller); ok {
+		return ip.WarmupImages(templates)
+	}
+	return nil, ErrImageWarmupNotSupported
+}
+
+//ImageWarmups ...
+func (v *VirtualMachines) ImageWarmups() ([]ImageWarmup, error) {
+	if ip, ok := v.Platform.(ImagePuller); ok {
+		return ip.ImageWarmups()
+	}
+	return nil, ErrImageWarmupNotSupported
+}
+
+//Debug attaches debug container to session of wrapped platform, virtual machines have no browser pod
+func (v *VirtualMachines) Debug(sessionID string, spec DebugSpec) (DebugContainer, error) {
+	vm, err := v.isVM(sessionID)
+	if err != nil {
+		return DebugContainer{}, err
+	}
+	if vm {
+		return DebugContainer{}, errors.New("debug containers are not available for virtual machine sessions")
+	}
+	if d, ok := v.Platform.(Debugger); ok {
+		return d.Debug(sessionID, spec)
+	}
+	return DebugContainer{}, ErrDebugNotSupported
+}
+
+//Extend extends session of wrapped platform, virtual machines have no sidecar to extend
+func (v *VirtualMachines) Extend(sessionID string, max int) (SessionExtension, error) {
+	vm, err := v.isVM(sessionID)
+	if err != nil {
+		return SessionExtension{}, err
+	}
+	if vm {
+		return SessionExtension{}, errors.New("session extension is not available for virtual machine sessions")
+	}
+	if e, ok := v.Platform.(Extender); ok {
+		return e.Extend(sessionID, max)
+	}
+	return SessionExtension{}, ErrExtendNotSupported
+}
+
+//ListSessions lists sessions of wrapped platform, matching sessions of virtual machines are added to its last page
+func (v *VirtualMachines) ListSessions(opts ListOptions) (SessionList, error) {
+	list, err := ListSessions(v.Platform, opts)
+	if err != nil || list.Continue != "" {
+		return list, err
+	}
+	services, err := v.list()
+	if err != nil {
+		return SessionList{}, err
+	}
+	matched, err := PageSessions(services, ListOptions{
+		BrowserName:    opts.BrowserName,
+		BrowserVersion: opts.BrowserVersion,
+		Labels:         opts.Labels,
+	})
+	if err != nil {
+		return SessionList{}, err
+	}
+	list.Services = append(list.Services, matched.Services...)
+	return list, nil
+}
+
+//SetTenantLimit ...
+func (v *VirtualMachines) SetTenantLimit(name string, limit int64) error {
+	if tl, ok := v.Platform.(TenantLimiter); ok {
+		return tl.SetTenantLimit(name, limit)
+	}
+	return fmt.Errorf("tenants are not configured")
+}
+
+type vmService struct {
+	ServiceInterface
+	v *VirtualMachines
+}
+
+//Create starts virtual machine of browser with vm settings and waits until it is ready, other browsers
+//are created by wrapped platform
+func (s *vmService) Create(spec ServiceSpec) (Service, error) {
+	if spec.Template.VM == nil {
+		return s.ServiceInterface.Create(spec)
+	}
+	if spec.RequestedCapabilities.GetCaptureHAR() {
+		return Service{}, errors.New("HAR capture is not supported for virtual machine browsers")
+	}
+
+	vmi, err := s.v.buildVM(spec)
+	if err != nil {
+		return Service{}, err
+	}
+
+	var phases []Phase
+	phaseStart := time.Now()
+	phase := func(name string) {
+		now := time.Now()
+		phases = append(phases, Phase{Name: name, Duration: now.Sub(phaseStart)})
+		phaseStart = now
+	}
+
+	created, err := s.v.resource().Create(context.Background(), vmi, metav1.CreateOptions{})
+	if err != nil {
+		return Service{}, fmt.Errorf("failed to create virtual machine: %v", err)
+	}
+	phase("create")
+
+	timeout := spec.Template.Readiness.timeout(s.v.cfg.ReadinessTimeout)
+	if err := s.v.waitReady(created.GetName(), timeout); err != nil {
+		s.v.resource().Delete(context.Background(), created.GetName(), metav1.DeleteOptions{})
+		return Service{}, err
+	}
+	phase("boot")
+
+	service := s.v.vmService(created, Running)
+	service.Phases = phases
+
+	s.v.mu.Lock()
+	s.v.started[service.SessionID] = true
+	s.v.mu.Unlock()
+	go func() {
+		s.v.events <- Event{Type: Added, PlatformObject: service}
+	}()
+	return service, nil
+}
+
+//Delete deletes virtual machine of the session, sessions of wrapped platform are deleted by it
+func (s *vmService) Delete(sessionID string) error {
+	vm, err := s.v.isVM(sessionID)
+	if err != nil {
+		return err
+	}
+	if !vm {
+		return s.ServiceInterface.Delete(sessionID)
+	}
+
+	err = s.v.resource().Delete(context.Background(), sessionID, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete virtual machine: %v", err)
+	}
+
+	s.v.mu.Lock()
+	started := s.v.started[sessionID]
+	delete(s.v.started, sessionID)
+	s.v.mu.Unlock()
+	if started {
+		go func() {
+			s.v.events <- Event{Type: Deleted, PlatformObject: Service{SessionID: sessionID}}
+		}()
+	}
+	return nil
+}
+
+//Logs ...
+func (s *vmService) Logs(ctx context.Context, sessionID string) (io.ReadCloser, error) {
+	vm, err := s.v.isVM(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if vm {
+		return nil, errors.New("logs are not available for virtual machine sessions")
+	}
+	return s.ServiceInterface.Logs(ctx, sessionID)
+}
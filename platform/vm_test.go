@@ -0,0 +1,152 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestVirtualMachinesCreate(t *testing.T) {
+	edge := BrowserSpec{
+		BrowserName:    "MicrosoftEdge",
+		BrowserVersion: "18.0",
+		Image:          "acme/edge-vm:18.0",
+		Platform:       WindowsPlatform,
+		VM:             &VMSpec{Memory: "8Gi", Firmware: "efi"},
+	}
+
+	tests := map[string]struct {
+		template BrowserSpec
+		status   map[string]interface{}
+		err      error
+		vm       bool
+	}{
+		"Verify browser without vm settings is created by wrapped platform": {
+			template: BrowserSpec{BrowserName: "chrome", BrowserVersion: "85.0", Image: "selenoid/vnc:chrome_85.0"},
+		},
+		"Verify browser with vm settings is started in virtual machine": {
+			template: edge,
+			status: map[string]interface{}{
+				"phase":      "Running",
+				"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "True"}},
+			},
+			vm: true,
+		},
+		"Verify failed virtual machine is deleted": {
+			template: edge,
+			status:   map[string]interface{}{"phase": "Failed"},
+			err:      errors.New("virtual machine stopped in Failed phase"),
+		},
+		"Verify virtual machine not ready in time is deleted": {
+			template: edge,
+			status:   map[string]interface{}{"phase": "Scheduling"},
+			err:      errors.New("virtual machine is not ready in 50ms"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+		vms := NewVirtualMachines(&platformStub{}, client, VirtualMachinesConfig{
+			Namespace:        "selenosis",
+			Service:          "seleniferous",
+			ServicePort:      "4445",
+			ReadinessTimeout: 50 * time.Millisecond,
+		})
+		vms.interval = 5 * time.Millisecond
+		resource := client.Resource(VirtualMachineInstanceResource).Namespace("selenosis")
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if test.status == nil {
+				return
+			}
+			for {
+				vmi, err := resource.Get(context.Background(), "edge-1", metav1.GetOptions{})
+				if err == nil {
+					vmi.Object["status"] = test.status
+					resource.Update(context.Background(), vmi, metav1.UpdateOptions{})
+					return
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}()
+
+		service, err := vms.Service().Create(ServiceSpec{
+			SessionID:   "edge-1",
+			Template:    test.template,
+			IdleTimeout: time.Minute,
+			Owner:       "ci",
+		})
+		<-done
+		if test.err != nil {
+			assert.Error(t, err, test.err.Error())
+		} else {
+			assert.NilError(t, err)
+		}
+
+		list, lerr := resource.List(context.Background(), metav1.ListOptions{})
+		assert.NilError(t, lerr)
+		assert.Equal(t, test.vm, len(list.Items) == 1)
+		if !test.vm {
+			continue
+		}
+		assert.Equal(t, Running, service.Status)
+		assert.Equal(t, "http://edge-1.seleniferous:4445", service.URL.String())
+		assert.Equal(t, "ci", service.Labels["owner"])
+
+		vmi := list.Items[0]
+		_, ok := vmi.GetLabels()[label]
+		assert.Assert(t, !ok)
+		assert.Equal(t, "edge-1", vmi.GetLabels()[defaultLabels.session])
+		assert.Equal(t, "ci", vmi.GetLabels()[ownerLabel])
+		hostname, _, _ := unstructured.NestedString(vmi.Object, "spec", "hostname")
+		assert.Equal(t, "edge-1", hostname)
+		subdomain, _, _ := unstructured.NestedString(vmi.Object, "spec", "subdomain")
+		assert.Equal(t, "seleniferous", subdomain)
+		memory, _, _ := unstructured.NestedString(vmi.Object, "spec", "domain", "resources", "requests", "memory")
+		assert.Equal(t, "8Gi", memory)
+		volumes, _, _ := unstructured.NestedSlice(vmi.Object, "spec", "volumes")
+		image, _, _ := unstructured.NestedString(volumes[0].(map[string]interface{}), "containerDisk", "image")
+		assert.Equal(t, "acme/edge-vm:18.0", image)
+
+		state, err := vms.State()
+		assert.NilError(t, err)
+		assert.Equal(t, 1, len(state.Services))
+		assert.Equal(t, "edge-1", state.Services[0].SessionID)
+		assert.Equal(t, "MicrosoftEdge", state.Services[0].Labels["browserName"])
+	}
+}
+
+func TestVirtualMachinesDelete(t *testing.T) {
+	vmi := &unstructured.Unstructured{}
+	vmi.SetAPIVersion("kubevirt.io/v1")
+	vmi.SetKind("VirtualMachineInstance")
+	vmi.SetNamespace("selenosis")
+	vmi.SetName("edge-1")
+	vmi.SetLabels(map[string]string{vmLabel: "browser"})
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), vmi)
+	stub := &platformStub{deleted: make(chan string, 1)}
+	vms := NewVirtualMachines(stub, client, VirtualMachinesConfig{Namespace: "selenosis", Service: "seleniferous", ServicePort: "4445"})
+
+	assert.NilError(t, vms.Service().Delete("edge-1"))
+	_, err := client.Resource(VirtualMachineInstanceResource).Namespace("selenosis").Get(context.Background(), "edge-1", metav1.GetOptions{})
+	assert.Assert(t, err != nil)
+	assert.Equal(t, 0, len(stub.deleted))
+
+	assert.NilError(t, vms.Service().Delete("chrome-1"))
+	assert.Equal(t, "chrome-1", <-stub.deleted)
+
+	_, err = vms.Service().Logs(context.Background(), "edge-1")
+	assert.NilError(t, err)
+}
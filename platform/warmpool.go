@@ -0,0 +1,254 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alcounit/selenosis/metrics"
+	"github.com/google/uuid"
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//warmLabel is value of app type label of idle pre-started browser pods, State and Watch don't
+//report them as sessions until pod is claimed and relabeled as browser
+const warmLabel = "warm"
+
+//warmTemplateAnnotation keeps browser name and version warm pod is started for
+const warmTemplateAnnotation = "warmTemplate"
+
+//warmClaimTimeout is how long claimed warm pod is given to answer before next one is tried
+var warmClaimTimeout = 2 * time.Second
+
+//WarmPooler is implemented by platforms able to keep idle browsers started ahead of sessions
+type WarmPooler interface {
+	FillWarmPool(templates []BrowserSpec, capacity int) error
+}
+
+//FillWarmPool starts missing warm pods of templates and deletes warm pods not matching any template,
+//capacity caps total number of idle warm pods
+func (cl *Client) FillWarmPool(templates []BrowserSpec, capacity int) error {
+	s, ok := cl.service.(*service)
+	if !ok {
+		return nil
+	}
+	return s.fillWarmPool(templates, capacity)
+}
+
+func warmKey(template BrowserSpec) string {
+	return template.BrowserName + ":" + template.BrowserVersion
+}
+
+//warmMatches reports if warm pod is started from the template as it is configured now
+func warmMatches(pod apiv1.Pod, template BrowserSpec) bool {
+	return pod.Annotations[warmTemplateAnnotation] == warmKey(template) &&
+		len(pod.Spec.Containers) > 0 && pod.Spec.Containers[0].Image == template.Image
+}
+
+func (cl *service) warmPods() ([]apiv1.Pod, error) {
+	pods, err := cl.clientset.CoreV1().Pods(cl.ns).List(context.Background(), metav1.ListOptions{
+		LabelSelector: label + "=" + warmLabel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list warm pods: %v", err)
+	}
+
+	var warm []apiv1.Pod
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		warm = append(warm, pod)
+	}
+	sort.Slice(warm, func(i, j int) bool {
+		return warm[i].CreationTimestamp.Before(&warm[j].CreationTimestamp)
+	})
+	return warm, nil
+}
+
+func (cl *service) fillWarmPool(templates []BrowserSpec, capacity int) error {
+	//sessions of session jobs never claim warm pods
+	if cl.jobs != nil {
+		return nil
+	}
+	pods, err := cl.warmPods()
+	if err != nil {
+		return err
+	}
+
+	pooled := make(map[string][]apiv1.Pod)
+	var stale []apiv1.Pod
+	for _, pod := range pods {
+		if pod.Status.Phase == apiv1.PodFailed || pod.Status.Phase == apiv1.PodSucceeded {
+			stale = append(stale, pod)
+			continue
+		}
+		matched := false
+		for _, template := range templates {
+			key := warmKey(template)
+			if warmMatches(pod, template) && len(pooled[key]) < template.WarmPool {
+				pooled[key] = append(pooled[key], pod)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			stale = append(stale, pod)
+		}
+	}
+
+	var errs []string
+	for _, pod := range stale {
+		if err := cl.Delete(pod.Name); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Sprintf("failed to delete warm pod %s: %v", pod.Name, err))
+		}
+	}
+
+	idle := len(pods) - len(stale)
+	for _, template := range templates {
+		for i := len(pooled[warmKey(template)]); i < template.WarmPool && idle < capacity; i++ {
+			pod, err := cl.warmPod(template)
+			if err == nil {
+				_, err = cl.clientset.CoreV1().Pods(cl.ns).Create(context.Background(), pod, metav1.CreateOptions{})
+			}
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("failed to start warm pod of %s: %v", warmKey(template), err))
+				break
+			}
+			idle++
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+var podNameRegexp = regexp.MustCompile("[^a-zA-Z0-9]+")
+
+//warmPod builds idle browser pod of the template, pod is named like session pods so its name
+//becomes id of the session it is handed out to
+func (cl *service) warmPod(template BrowserSpec) (*apiv1.Pod, error) {
+	fragments := strings.Split(template.Image, "/")
+	name := fmt.Sprintf("%s-%s", podNameRegexp.ReplaceAllString(fragments[len(fragments)-1], "-"), uuid.New())
+
+	labels := map[string]string{
+		defaultLabels.serviceType: "browser",
+		defaultLabels.appType:     warmLabel,
+		defaultLabels.session:     name,
+	}
+	for k, v := range browserLabels(template) {
+		labels[k] = v
+	}
+	for k, v := range template.Meta.Labels {
+		if _, ok := labels[k]; !ok {
+			labels[k] = v
+		}
+	}
+	annotations := map[string]string{warmTemplateAnnotation: warmKey(template)}
+	for k, v := range template.Meta.Annotations {
+		annotations[k] = v
+	}
+	template.Meta = Meta{Labels: labels, Annotations: annotations}
+
+	return cl.buildPod(ServiceSpec{SessionID: name, Template: template})
+}
+
+//warmEligible reports if session can be served by warm pod, sessions changing browser environment,
+//idle timeout or pod containers need own pod, layout is checked before capabilities are applied to template
+func warmEligible(layout ServiceSpec) bool {
+	caps := layout.RequestedCapabilities
+	if caps.Video || caps.Workspace || caps.GetCaptureHAR() || caps.ScreenResolution != "" || caps.TimeZone != "" || len(caps.GetEnv()) > 0 || layout.IdleTimeout > 0 {
+		return false
+	}
+	if caps.GetProfileConfigMap() != "" || caps.GetProfileSecret() != "" || len(caps.GetHostAliases()) > 0 || caps.GetDNS() != nil || len(caps.GetFiles()) > 0 {
+		return false
+	}
+	if caps.GetCPURequest() != "" || caps.GetMemoryRequest() != "" || caps.GetCPULimit() != "" || caps.GetMemoryLimit() != "" {
+		return false
+	}
+	if caps.VNC {
+		for _, env := range layout.Template.Spec.EnvVars {
+			if env.Name == defaultsAnnotations.enableVNC {
+				return env.Value == "true"
+			}
+		}
+		return false
+	}
+	return true
+}
+
+//claimWarm hands out the oldest running warm pod of session browser, pod gets session annotations and
+//browser app type. Update is conditional on resource version, so pod can't be claimed twice
+func (cl *service) claimWarm(layout ServiceSpec) (Service, bool) {
+	start := time.Now()
+
+	pods, err := cl.warmPods()
+	if err != nil {
+		return Service{}, false
+	}
+
+	for _, pod := range pods {
+		if pod.Status.Phase != apiv1.PodRunning || !warmMatches(pod, layout.Template) {
+			continue
+		}
+
+		claimed := pod.DeepCopy()
+		claimed.Labels[label] = "browser"
+		sessionLabels, _ := RequestedLabels(layout.RequestedCapabilities)
+		for k, v := range sessionLabels {
+			claimed.Labels[k] = v
+		}
+		for _, k := range []string{"capabilities", artifactsAnnotation, sessionLabelsAnnotation} {
+			if v, ok := layout.Template.Meta.Annotations[k]; ok {
+				claimed.Annotations[k] = v
+			}
+		}
+		claimed, err = cl.clientset.CoreV1().Pods(cl.ns).Update(context.Background(), claimed, metav1.UpdateOptions{})
+		if err != nil {
+			continue
+		}
+
+		podName := claimed.GetName()
+		u := &url.URL{
+			Scheme: "http",
+			Host:   podName + "." + serviceHost(cl.svc, cl.ns, cl.namespacedHosts) + ":" + browserPort(layout.Template).StrVal,
+		}
+		if err := waitForBrowser(*u, layout.Template, warmClaimTimeout, cl.browserReady(podName)); err != nil {
+			cl.Delete(podName)
+			continue
+		}
+		u.Host = podName + "." + serviceHost(cl.svc, cl.ns, cl.namespacedHosts) + ":" + cl.svcPort.StrVal
+
+		metrics.WarmPoolClaims.WithLabelValues(layout.Template.BrowserName).Inc()
+		return Service{
+			SessionID:     podName,
+			URL:           u,
+			Labels:        getRequestedCapabilities(claimed.GetAnnotations()),
+			SessionLabels: getSessionLabels(claimed.GetAnnotations()),
+			CancelFunc: func() {
+				cl.Delete(podName)
+			},
+			Status:  Running,
+			Started: claimed.CreationTimestamp.Time,
+			Phases:  []Phase{{Name: "claim", Duration: time.Since(start)}},
+		}, true
+	}
+	return Service{}, false
+}
+
+//evictWarm deletes the oldest warm pod to give its place in namespace quota to session pod
+func (cl *service) evictWarm() bool {
+	pods, err := cl.warmPods()
+	if err != nil || len(pods) == 0 {
+		return false
+	}
+	return cl.Delete(pods[0].Name) == nil
+}
@@ -0,0 +1,129 @@
+package platform
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/selenium"
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWarmEligible(t *testing.T) {
+	vnc := BrowserSpec{Spec: Spec{EnvVars: []apiv1.EnvVar{{Name: "ENABLE_VNC", Value: "true"}}}}
+
+	tests := map[string]struct {
+		layout   ServiceSpec
+		eligible bool
+	}{
+		"Verify plain session is served by warm pod": {
+			eligible: true,
+		},
+		"Verify video session needs own pod": {
+			layout: ServiceSpec{RequestedCapabilities: selenium.Capabilities{Video: true}},
+		},
+		"Verify screen resolution session needs own pod": {
+			layout: ServiceSpec{RequestedCapabilities: selenium.Capabilities{ScreenResolution: "1920x1080x24"}},
+		},
+		"Verify session with own browser env needs own pod": {
+			layout: ServiceSpec{RequestedCapabilities: selenium.Capabilities{Options: &selenium.SelenosisOptions{Env: []string{"LANG=de_DE.UTF-8"}}}},
+		},
+		"Verify session with profile of config map needs own pod": {
+			layout: ServiceSpec{RequestedCapabilities: selenium.Capabilities{Options: &selenium.SelenosisOptions{ProfileConfigMap: "firefox-profile"}}},
+		},
+		"Verify session with own idle timeout needs own pod": {
+			layout: ServiceSpec{IdleTimeout: time.Hour},
+		},
+		"Verify VNC session needs own pod of image without VNC": {
+			layout: ServiceSpec{RequestedCapabilities: selenium.Capabilities{VNC: true}},
+		},
+		"Verify VNC session is served by warm pod of VNC image": {
+			layout:   ServiceSpec{Template: vnc, RequestedCapabilities: selenium.Capabilities{VNC: true}},
+			eligible: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+		assert.Equal(t, test.eligible, warmEligible(test.layout))
+	}
+}
+
+func TestFillWarmPool(t *testing.T) {
+	chrome := BrowserSpec{BrowserName: "chrome", BrowserVersion: "85.0", Image: "selenoid/vnc:chrome_85.0", WarmPool: 2}
+	firefox := BrowserSpec{BrowserName: "firefox", BrowserVersion: "80.0", Image: "selenoid/vnc:firefox_80.0", WarmPool: 2}
+
+	warm := func(name string, template BrowserSpec) *apiv1.Pod {
+		return &apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Labels:      map[string]string{label: warmLabel},
+				Annotations: map[string]string{warmTemplateAnnotation: warmKey(template)},
+			},
+			Spec: apiv1.PodSpec{Containers: []apiv1.Container{{Image: template.Image}}},
+		}
+	}
+	outdated := chrome
+	outdated.Image = "selenoid/vnc:chrome_84.0"
+
+	tests := map[string]struct {
+		pods      []*apiv1.Pod
+		templates []BrowserSpec
+		capacity  int
+		images    []string
+	}{
+		"Verify missing warm pods are started": {
+			templates: []BrowserSpec{chrome, firefox},
+			capacity:  10,
+			images:    []string{chrome.Image, chrome.Image, firefox.Image, firefox.Image},
+		},
+		"Verify warm pods are capped by capacity": {
+			templates: []BrowserSpec{chrome, firefox},
+			capacity:  3,
+			images:    []string{chrome.Image, chrome.Image, firefox.Image},
+		},
+		"Verify warm pods of changed image are replaced": {
+			pods:      []*apiv1.Pod{warm("chrome-old", outdated), warm("chrome-new", chrome)},
+			templates: []BrowserSpec{chrome},
+			capacity:  10,
+			images:    []string{chrome.Image, chrome.Image},
+		},
+		"Verify warm pods of removed browsers are deleted": {
+			pods:     []*apiv1.Pod{warm("chrome-1", chrome), warm("firefox-1", firefox)},
+			capacity: 10,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		mock := fake.NewSimpleClientset()
+		for _, pod := range test.pods {
+			_, err := mock.CoreV1().Pods("selenosis").Create(context.Background(), pod, metav1.CreateOptions{})
+			assert.NilError(t, err)
+		}
+		cl := &service{
+			ns:         "selenosis",
+			clientset:  mock,
+			svcPort:    intstr.FromString("4445"),
+			proxyImage: "alcounit/seleniferous:latest",
+		}
+
+		assert.NilError(t, cl.fillWarmPool(test.templates, test.capacity))
+
+		pods, err := mock.CoreV1().Pods("selenosis").List(context.Background(), metav1.ListOptions{})
+		assert.NilError(t, err)
+		var images []string
+		for _, pod := range pods.Items {
+			assert.Equal(t, warmLabel, pod.Labels[label])
+			images = append(images, pod.Spec.Containers[0].Image)
+		}
+		sort.Strings(images)
+		assert.DeepEqual(t, test.images, images)
+	}
+}
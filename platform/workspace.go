@@ -0,0 +1,119 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	workspaceVolumeName = "workspace"
+	workspaceMountPath  = "/home/selenium/Downloads"
+	//workspaceLabel marks workspaces shared by sessions of a run
+	workspaceLabel = "selenosis.app.workspace"
+	//runAnnotation keeps run id of shared workspace, run id is not always a valid label value
+	runAnnotation = "selenosis.app.run"
+)
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+//WorkspaceSpec describes persistent volume mounted to browser container of sessions with
+//enableWorkspace capability, e.g. for downloads or browser profile
+type WorkspaceSpec struct {
+	StorageClassName string                             `yaml:"storageClassName,omitempty" json:"storageClassName,omitempty"`
+	Size             resource.Quantity                  `yaml:"size" json:"size"`
+	AccessModes      []apiv1.PersistentVolumeAccessMode `yaml:"accessModes,omitempty" json:"accessModes,omitempty"`
+	MountPath        string                             `yaml:"mountPath,omitempty" json:"mountPath,omitempty"`
+}
+
+//workspaceClaim returns name of persistent volume claim of the session workspace, sessions of the
+//same run share the claim if shareWorkspace capability is set
+func workspaceClaim(layout ServiceSpec) (string, error) {
+	caps := layout.RequestedCapabilities
+	if layout.Template.Workspace == nil {
+		return "", fmt.Errorf("workspace is not configured for browser %s", layout.Template.BrowserName)
+	}
+	if !caps.ShareWorkspace {
+		return layout.SessionID + "-workspace", nil
+	}
+	if caps.RunID == "" {
+		return "", errors.New("shareWorkspace capability requires runId capability")
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(caps.RunID))
+	name := strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(caps.RunID), "-"), "-")
+	if len(name) > 40 {
+		name = strings.TrimRight(name[:40], "-")
+	}
+	return fmt.Sprintf("workspace-%s-%08x", name, h.Sum32()), nil
+}
+
+//workspaceVolume mounts workspace claim to browser container
+func workspaceVolume(pod *apiv1.Pod, layout ServiceSpec) error {
+	claim, err := workspaceClaim(layout)
+	if err != nil {
+		return err
+	}
+	mountPath := layout.Template.Workspace.MountPath
+	if mountPath == "" {
+		mountPath = workspaceMountPath
+	}
+
+	pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, apiv1.VolumeMount{Name: workspaceVolumeName, MountPath: mountPath})
+	pod.Spec.Volumes = append(pod.Spec.Volumes, apiv1.Volume{
+		Name: workspaceVolumeName,
+		VolumeSource: apiv1.VolumeSource{
+			PersistentVolumeClaim: &apiv1.PersistentVolumeClaimVolumeSource{ClaimName: claim},
+		},
+	})
+	return nil
+}
+
+//ensureWorkspace creates persistent volume claim of the session workspace, claim of session workspace
+//is labeled with session id to be deleted with the session, shared claim of a run is reused if exists
+func (cl *service) ensureWorkspace(layout ServiceSpec) error {
+	claim, err := workspaceClaim(layout)
+	if err != nil {
+		return err
+	}
+	spec := layout.Template.Workspace
+
+	accessModes := spec.AccessModes
+	if len(accessModes) == 0 {
+		accessModes = []apiv1.PersistentVolumeAccessMode{apiv1.ReadWriteOnce}
+	}
+	pvc := &apiv1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   claim,
+			Labels: SessionLabels(layout.SessionID),
+		},
+		Spec: apiv1.PersistentVolumeClaimSpec{
+			AccessModes: accessModes,
+			Resources: apiv1.ResourceRequirements{
+				Requests: apiv1.ResourceList{apiv1.ResourceStorage: spec.Size},
+			},
+		},
+	}
+	if spec.StorageClassName != "" {
+		pvc.Spec.StorageClassName = &spec.StorageClassName
+	}
+	if layout.RequestedCapabilities.ShareWorkspace {
+		pvc.Labels = map[string]string{workspaceLabel: "run"}
+		pvc.Annotations = map[string]string{runAnnotation: layout.RequestedCapabilities.RunID}
+	}
+
+	_, err = cl.clientset.CoreV1().PersistentVolumeClaims(cl.ns).Create(context.Background(), pvc, metav1.CreateOptions{})
+	if err != nil && !(layout.RequestedCapabilities.ShareWorkspace && apierrors.IsAlreadyExists(err)) {
+		return fmt.Errorf("failed to create workspace: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/alcounit/selenosis/selenium"
+	"gotest.tools/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestBuildPodWorkspace(t *testing.T) {
+	tests := map[string]struct {
+		caps      selenium.Capabilities
+		workspace *WorkspaceSpec
+		claim     string
+		mountPath string
+		err       string
+	}{
+		"Verify session workspace is mounted to browser": {
+			caps:      selenium.Capabilities{Workspace: true},
+			workspace: &WorkspaceSpec{Size: resource.MustParse("1Gi")},
+			claim:     "session-workspace",
+			mountPath: "/home/selenium/Downloads",
+		},
+		"Verify workspace is shared by sessions of run": {
+			caps:      selenium.Capabilities{Workspace: true, ShareWorkspace: true, RunID: "Nightly Build #42"},
+			workspace: &WorkspaceSpec{Size: resource.MustParse("1Gi"), MountPath: "/home/selenium/profile"},
+			claim:     "workspace-nightly-build-42-68ce8f05",
+			mountPath: "/home/selenium/profile",
+		},
+		"Verify shared workspace requires run id": {
+			caps:      selenium.Capabilities{Workspace: true, ShareWorkspace: true},
+			workspace: &WorkspaceSpec{Size: resource.MustParse("1Gi")},
+			err:       "shareWorkspace capability requires runId capability",
+		},
+		"Verify workspace is not mounted to browser without workspace": {
+			caps: selenium.Capabilities{Workspace: true},
+			err:  "workspace is not configured for browser chrome",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		cl := &service{
+			svcPort:    intstr.FromString("4445"),
+			proxyImage: "alcounit/seleniferous:latest",
+		}
+		template := BrowserSpec{
+			BrowserName: "chrome",
+			Image:       "selenoid/vnc:chrome_85.0",
+			Workspace:   test.workspace,
+		}
+
+		pod, err := cl.buildPod(ServiceSpec{SessionID: "session", RequestedCapabilities: test.caps, Template: template})
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+
+		mounts := pod.Spec.Containers[0].VolumeMounts
+		assert.DeepEqual(t, apiv1.VolumeMount{Name: workspaceVolumeName, MountPath: test.mountPath}, mounts[len(mounts)-1])
+		volume := pod.Spec.Volumes[len(pod.Spec.Volumes)-1]
+		assert.Equal(t, workspaceVolumeName, volume.Name)
+		assert.Equal(t, test.claim, volume.PersistentVolumeClaim.ClaimName)
+	}
+}
@@ -0,0 +1,90 @@
+package selenosis
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"time"
+
+	"github.com/alcounit/selenosis/tools"
+	"github.com/gorilla/mux"
+)
+
+//playwrightPort is port of Playwright server started in browser container of playwright compatible images
+var playwrightPort = "3000"
+
+//HandlePlaywright tunnels Playwright server websocket of the session to browser pod, so session can be
+//driven with browserType.connect(). Session is kept alive while websocket is open as Playwright protocol
+//does not pass through sidecar and would not reset its idle timer otherwise
+func (app *App) HandlePlaywright(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := mux.Vars(r)["sessionId"]
+	if !ok || !isValidSession(sessionID) {
+		app.logger.WithField("request", fmt.Sprintf("%s %s", r.Method, r.URL.Path)).Errorf("%s is not valid session id", sessionID)
+		tools.JSONError(w, "session id not found", http.StatusBadRequest)
+		return
+	}
+	if _, ok := app.relayedSession(sessionID); ok {
+		tools.JSONError(w, fmt.Sprintf("playwright is not available for relayed session %s", sessionID), http.StatusNotFound)
+		return
+	}
+
+	logger := app.requestLogger(r, sessionID)
+
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		logger.Error("playwright request is not a websocket upgrade")
+		tools.JSONError(w, "websocket upgrade required", http.StatusBadRequest)
+		return
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go app.keepAlive(sessionID, done)
+
+	(&httputil.ReverseProxy{
+		Transport: transport,
+		Director: func(r *http.Request) {
+			r.URL.Scheme = "http"
+			r.Host = app.sessionHost(sessionID, playwrightPort)
+			r.URL.Host = r.Host
+			r.URL.Path = "/"
+			r.URL.RawPath = ""
+			r.Header.Set("X-Forwarded-Selenosis", app.selenosisHost)
+			logger.Info("proxying playwright")
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logger.Errorf("playwright proxying error: %v", err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}).ServeHTTP(w, r)
+	logger.Info("playwright client disconnected")
+}
+
+//keepAlive marks session active and pings it through sidecar twice per idle timeout until done is closed
+func (app *App) keepAlive(sessionID string, done <-chan struct{}) {
+	app.recordActivity(sessionID, time.Now())
+
+	service, _ := app.stats.Sessions().Get(sessionID)
+	timeout := app.idleTimeoutOf(service)
+	if timeout <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			app.recordActivity(sessionID, time.Now())
+			u := fmt.Sprintf("http://%s/wd/hub/session/%s/timeouts", app.sessionHost(sessionID, app.sidecarPort), sessionID)
+			resp, err := httpClient.Get(u)
+			if err != nil {
+				app.logger.WithField("session_id", sessionID).Warnf("failed to keep session alive: %v", err)
+				continue
+			}
+			resp.Body.Close()
+		}
+	}
+}
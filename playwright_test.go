@@ -0,0 +1,118 @@
+package selenosis
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/gorilla/mux"
+	"golang.org/x/net/websocket"
+	"gotest.tools/assert"
+)
+
+func TestHandlePlaywright(t *testing.T) {
+	const sessionID = "chromium-1-9-de44c3c4-1a35-412b-b526-f5da80214491"
+
+	var path string
+	server := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		path = ws.Request().URL.RequestURI()
+		for {
+			var message string
+			if err := websocket.Message.Receive(ws, &message); err != nil {
+				return
+			}
+			websocket.Message.Send(ws, "echo: "+message)
+		}
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	defer func(port string) { playwrightPort = port }(playwrightPort)
+	playwrightPort = port
+
+	var pings int32
+	sidecar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/wd/hub/session/"+sessionID+"/timeouts" {
+			atomic.AddInt32(&pings, 1)
+		}
+	}))
+	defer sidecar.Close()
+	u, _ := url.Parse(sidecar.URL)
+
+	app := initApp(&PlatformMock{})
+	_, app.sidecarPort, _ = net.SplitHostPort(u.Host)
+	app.stats.Sessions().Put(sessionID, platform.Service{SessionID: sessionID, URL: u, Status: platform.Running})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/wd/hub/session/{sessionId}/playwright", app.HandlePlaywright).Methods(http.MethodGet)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	ws, err := websocket.Dial("ws"+srv.URL[len("http"):]+"/wd/hub/session/"+sessionID+"/playwright?browser=chromium", "", srv.URL)
+	assert.NilError(t, err)
+
+	assert.NilError(t, websocket.Message.Send(ws, `{"id":1,"guid":"","method":"initialize"}`))
+	var reply string
+	assert.NilError(t, websocket.Message.Receive(ws, &reply))
+	assert.Equal(t, `echo: {"id":1,"guid":"","method":"initialize"}`, reply)
+	assert.Equal(t, "/?browser=chromium", path)
+
+	time.Sleep(app.sessionIdleTimeout + 100*time.Millisecond)
+	assert.NilError(t, websocket.Message.Send(ws, "ping"))
+	assert.NilError(t, websocket.Message.Receive(ws, &reply))
+	ws.Close()
+
+	assert.Assert(t, atomic.LoadInt32(&pings) >= 1)
+	last, ok := app.stats.Activity().Get(sessionID)
+	assert.Assert(t, ok)
+	assert.Assert(t, time.Since(last) < app.sessionIdleTimeout)
+}
+
+func TestHandlePlaywrightErrors(t *testing.T) {
+	const sessionID = "chromium-1-9-de44c3c4-1a35-412b-b526-f5da80214491"
+
+	tests := map[string]struct {
+		sessionID string
+		relay     bool
+		code      int
+		body      string
+	}{
+		"Verify invalid session id is rejected": {
+			sessionID: "session",
+			code:      http.StatusBadRequest,
+			body:      `{"code":400,"value":{"message":"session id not found"}}`,
+		},
+		"Verify relayed session has no playwright": {
+			sessionID: sessionID,
+			relay:     true,
+			code:      http.StatusNotFound,
+			body:      `{"code":404,"value":{"message":"playwright is not available for relayed session ` + sessionID + `"}}`,
+		},
+		"Verify plain http request is rejected": {
+			sessionID: sessionID,
+			code:      http.StatusBadRequest,
+			body:      `{"code":400,"value":{"message":"websocket upgrade required"}}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		u, _ := url.Parse("https://hub.example.com/wd/hub/session/0a4a5c4c")
+		app.stats.Sessions().Put(sessionID, platform.Service{SessionID: sessionID, URL: u, Relay: test.relay})
+
+		router := mux.NewRouter()
+		router.HandleFunc("/wd/hub/session/{sessionId}/playwright", app.HandlePlaywright).Methods(http.MethodGet)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/wd/hub/session/"+test.sessionID+"/playwright", nil))
+		assert.Equal(t, test.code, rr.Code)
+		assert.Equal(t, test.body, strings.TrimSpace(rr.Body.String()))
+	}
+}
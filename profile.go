@@ -0,0 +1,63 @@
+package selenosis
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/selenium"
+)
+
+//profileArgs return driver arguments starting browser with profile in dir by browser name
+var profileArgs = map[string]func(dir string) []string{
+	"chrome":        chromiumProfileArgs,
+	"msedge":        chromiumProfileArgs,
+	"microsoftedge": chromiumProfileArgs,
+	"firefox": func(dir string) []string {
+		return []string{"-profile", dir}
+	},
+}
+
+func chromiumProfileArgs(dir string) []string {
+	return []string{"--user-data-dir=" + dir}
+}
+
+//injectProfile points browser to profile requested with selenosis:options capability. Profile of ConfigMap or
+//Secret is copied to profile directory of the browser pod and passed with driver arguments, inline profile is
+//passed to geckodriver as is, so it is supported by Firefox only
+func injectProfile(body []byte, caps selenium.Capabilities, browser platform.BrowserSpec) ([]byte, error) {
+	configMap, secret, inline := caps.GetProfileConfigMap(), caps.GetProfileSecret(), caps.GetProfile()
+	requested := 0
+	for _, v := range []string{configMap, secret, inline} {
+		if v != "" {
+			requested++
+		}
+	}
+	if requested == 0 {
+		return body, nil
+	}
+	if requested > 1 {
+		return nil, errors.New("only one of profileConfigMap, profileSecret and profile can be requested")
+	}
+
+	browserName := strings.ToLower(caps.GetBrowserName())
+	if inline != "" {
+		if browserName != "firefox" {
+			return nil, fmt.Errorf("inline profile is not supported for browser %s", caps.GetBrowserName())
+		}
+		if _, err := base64.StdEncoding.DecodeString(inline); err != nil {
+			return nil, fmt.Errorf("invalid profile, base64 encoded zip is expected: %v", err)
+		}
+		return updateDriverOptions(body, browserName, func(options map[string]interface{}) {
+			options["profile"] = inline
+		})
+	}
+
+	args, ok := profileArgs[browserName]
+	if !ok {
+		return nil, fmt.Errorf("browser profile is not supported for browser %s", caps.GetBrowserName())
+	}
+	return injectArgs(body, browserName, args(browser.ProfileDir()))
+}
@@ -0,0 +1,73 @@
+package selenosis
+
+import (
+	"testing"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/selenium"
+	"gotest.tools/assert"
+)
+
+func TestInjectProfile(t *testing.T) {
+	tests := map[string]struct {
+		body     string
+		caps     selenium.Capabilities
+		template platform.BrowserSpec
+		result   string
+		err      string
+	}{
+		"Verify request without profile is not changed": {
+			body:   `{"capabilities":{"alwaysMatch":{"browserName":"firefox"}}}`,
+			caps:   selenium.Capabilities{BrowserName: "firefox"},
+			result: `{"capabilities":{"alwaysMatch":{"browserName":"firefox"}}}`,
+		},
+		"Verify firefox is started with profile of config map": {
+			body:   `{"capabilities":{"alwaysMatch":{"browserName":"firefox"}}}`,
+			caps:   selenium.Capabilities{BrowserName: "firefox", Options: &selenium.SelenosisOptions{ProfileConfigMap: "firefox-profile"}},
+			result: `{"capabilities":{"alwaysMatch":{"browserName":"firefox","moz:firefoxOptions":{"args":["-profile","/tmp/profile"]}}}}`,
+		},
+		"Verify chrome is started with profile of secret at profile path": {
+			body:     `{"desiredCapabilities":{"browserName":"chrome"}}`,
+			caps:     selenium.Capabilities{BrowserName: "chrome", Options: &selenium.SelenosisOptions{ProfileSecret: "chrome-profile"}},
+			template: platform.BrowserSpec{ProfilePath: "/home/selenium/profile"},
+			result:   `{"desiredCapabilities":{"browserName":"chrome","goog:chromeOptions":{"args":["--user-data-dir=/home/selenium/profile"]}}}`,
+		},
+		"Verify inline profile is passed to geckodriver": {
+			body:   `{"capabilities":{"alwaysMatch":{"browserName":"firefox"}}}`,
+			caps:   selenium.Capabilities{BrowserName: "firefox", Options: &selenium.SelenosisOptions{Profile: "UEsFBgAAAAAAAAAAAAAAAAAAAAAAAA=="}},
+			result: `{"capabilities":{"alwaysMatch":{"browserName":"firefox","moz:firefoxOptions":{"profile":"UEsFBgAAAAAAAAAAAAAAAAAAAAAAAA=="}}}}`,
+		},
+		"Verify inline profile of chrome is rejected": {
+			body: `{"capabilities":{"alwaysMatch":{"browserName":"chrome"}}}`,
+			caps: selenium.Capabilities{BrowserName: "chrome", Options: &selenium.SelenosisOptions{Profile: "UEsFBgAAAAAAAAAAAAAAAAAAAAAAAA=="}},
+			err:  "inline profile is not supported for browser chrome",
+		},
+		"Verify invalid inline profile is rejected": {
+			body: `{"capabilities":{"alwaysMatch":{"browserName":"firefox"}}}`,
+			caps: selenium.Capabilities{BrowserName: "firefox", Options: &selenium.SelenosisOptions{Profile: "not a zip"}},
+			err:  "invalid profile, base64 encoded zip is expected: illegal base64 data at input byte 3",
+		},
+		"Verify several profiles are rejected": {
+			body: `{"capabilities":{"alwaysMatch":{"browserName":"firefox"}}}`,
+			caps: selenium.Capabilities{BrowserName: "firefox", Options: &selenium.SelenosisOptions{ProfileConfigMap: "firefox-profile", ProfileSecret: "firefox-certs"}},
+			err:  "only one of profileConfigMap, profileSecret and profile can be requested",
+		},
+		"Verify profile of unknown browser is rejected": {
+			body: `{"capabilities":{"alwaysMatch":{"browserName":"opera"}}}`,
+			caps: selenium.Capabilities{BrowserName: "opera", Options: &selenium.SelenosisOptions{ProfileConfigMap: "opera-profile"}},
+			err:  "browser profile is not supported for browser opera",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		result, err := injectProfile([]byte(test.body), test.caps, test.template)
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, test.result, string(result))
+	}
+}
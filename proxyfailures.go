@@ -0,0 +1,70 @@
+package selenosis
+
+import (
+	"sync"
+
+	"github.com/alcounit/selenosis/metrics"
+)
+
+//proxyFailures counts consecutive failures to reach browsers of sessions, any response of the browser
+//resets the count of its session
+type proxyFailures struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func newProxyFailures() *proxyFailures {
+	return &proxyFailures{counts: make(map[string]int)}
+}
+
+//add returns number of consecutive failures of the session including the new one
+func (f *proxyFailures) add(sessionID string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.counts[sessionID]++
+	return f.counts[sessionID]
+}
+
+func (f *proxyFailures) reset(sessionID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.counts, sessionID)
+}
+
+//prune forgets failures of sessions which are gone
+func (f *proxyFailures) prune(present map[string]struct{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for sessionID := range f.counts {
+		if _, ok := present[sessionID]; !ok {
+			delete(f.counts, sessionID)
+		}
+	}
+}
+
+//proxyFailed records command which never reached browser of the session and deletes the pod once the
+//session is lost: delete session command failed, so nobody else deletes the pod, or commands failed
+//proxyFailureLimit times in a row. Relayed sessions have no pod and are left to janitor
+func (app *App) proxyFailed(sessionID string, deleteSession bool) {
+	if app.proxyFailureLimit <= 0 {
+		return
+	}
+	if _, relayed := app.relayedSession(sessionID); relayed {
+		return
+	}
+
+	failures := app.proxyFailures.add(sessionID)
+	if !deleteSession && failures < app.proxyFailureLimit {
+		return
+	}
+
+	logger := app.logger.WithField("session_id", sessionID)
+	if err := app.client.Service().Delete(sessionID); err != nil {
+		logger.Errorf("failed to delete pod of unreachable session: %v", err)
+		return
+	}
+	app.proxyFailures.reset(sessionID)
+	app.stats.Sessions().Delete(sessionID)
+	metrics.OrphansReaped.WithLabelValues("proxy").Inc()
+	logger.Warnf("pod of unreachable session deleted after %d failed commands", failures)
+}
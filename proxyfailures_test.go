@@ -0,0 +1,104 @@
+package selenosis
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/gorilla/mux"
+	"gotest.tools/assert"
+)
+
+func TestProxyFailed(t *testing.T) {
+	tests := map[string]struct {
+		limit         int
+		failures      int
+		deleteSession bool
+		relayed       bool
+		deleted       []string
+	}{
+		"Verify pod is not deleted before failure limit": {
+			limit:    3,
+			failures: 2,
+		},
+		"Verify pod is deleted when failure limit is reached": {
+			limit:    3,
+			failures: 3,
+			deleted:  []string{"chrome-85-0-unreachable"},
+		},
+		"Verify pod is deleted after failed delete session command": {
+			limit:         3,
+			failures:      1,
+			deleteSession: true,
+			deleted:       []string{"chrome-85-0-unreachable"},
+		},
+		"Verify pod is not deleted when cleanup is disabled": {
+			failures:      5,
+			deleteSession: true,
+		},
+		"Verify relayed session is left to janitor": {
+			limit:         3,
+			failures:      5,
+			deleteSession: true,
+			relayed:       true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		client := &PlatformMock{}
+		app := initApp(client)
+		app.proxyFailureLimit = test.limit
+		service := platform.Service{SessionID: "chrome-85-0-unreachable", Status: platform.Running}
+		if test.relayed {
+			service.Relay = true
+			service.URL = &url.URL{Scheme: "https", Host: "hub.example.com"}
+		}
+		app.stats.Sessions().Put(service.SessionID, service)
+
+		for i := 1; i <= test.failures; i++ {
+			app.proxyFailed(service.SessionID, test.deleteSession && i == test.failures)
+		}
+
+		assert.DeepEqual(t, test.deleted, client.deleted)
+		_, ok := app.stats.Sessions().Get(service.SessionID)
+		assert.Equal(t, len(test.deleted) == 0, ok)
+	}
+}
+
+func TestProxyFailuresReset(t *testing.T) {
+	client := &PlatformMock{}
+	app := initApp(client)
+	app.proxyFailureLimit = 2
+	app.stats.Sessions().Put("chrome-85-0-flaky", platform.Service{SessionID: "chrome-85-0-flaky", Status: platform.Running})
+
+	app.proxyFailed("chrome-85-0-flaky", false)
+	app.proxyFailures.reset("chrome-85-0-flaky")
+	app.proxyFailed("chrome-85-0-flaky", false)
+	assert.Equal(t, 0, len(client.deleted))
+
+	app.proxyFailures.prune(map[string]struct{}{})
+	app.proxyFailed("chrome-85-0-flaky", false)
+	assert.Equal(t, 0, len(client.deleted))
+	app.proxyFailed("chrome-85-0-flaky", false)
+	assert.DeepEqual(t, []string{"chrome-85-0-flaky"}, client.deleted)
+}
+
+func TestHandleProxyUnreachableDelete(t *testing.T) {
+	client := &PlatformMock{}
+	app := initApp(client)
+	app.proxyFailureLimit = 3
+	sessionID := "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491"
+	app.stats.Sessions().Put(sessionID, platform.Service{SessionID: sessionID, Status: platform.Running})
+
+	req := httptest.NewRequest(http.MethodDelete, "/wd/hub/session/"+sessionID, nil)
+	req = mux.SetURLVars(req, map[string]string{"sessionId": sessionID})
+	rr := httptest.NewRecorder()
+	app.HandleProxy(rr, req)
+
+	assert.Assert(t, rr.Code >= http.StatusBadRequest)
+	assert.DeepEqual(t, []string{sessionID}, client.deleted)
+}
@@ -0,0 +1,122 @@
+package selenosis
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+//ProxyTransport configures connections WebDriver commands are proxied to browser pods with
+type ProxyTransport struct {
+	//H2C proxies commands over HTTP/2 without TLS negotiation, commands of a session share one connection
+	H2C bool
+	//IdleConnsPerPod is number of idle connections kept open to each browser pod, not used with H2C
+	IdleConnsPerPod int
+	//IdleConnTimeout is time idle connection is kept open for
+	IdleConnTimeout time.Duration
+	//KeepAlive is period of TCP keep-alive probes, or of HTTP/2 pings of idle connection with H2C
+	KeepAlive time.Duration
+}
+
+//newProxyTransport returns transport of session proxy, zero settings keep defaults of http.DefaultTransport
+func newProxyTransport(cfg ProxyTransport) http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: cfg.KeepAlive}
+	if cfg.KeepAlive == 0 {
+		dialer.KeepAlive = 30 * time.Second
+	}
+	t.DialContext = countingDial(dialer.DialContext)
+	if cfg.IdleConnsPerPod > 0 {
+		t.MaxIdleConnsPerHost = cfg.IdleConnsPerPod
+		t.MaxIdleConns = 0
+	}
+	if cfg.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+	if !cfg.H2C {
+		return t
+	}
+
+	//HTTP/1 transport only carries idle connection timeout of HTTP/2 connections, it never dials
+	t2, err := http2.ConfigureTransports(&http.Transport{IdleConnTimeout: t.IdleConnTimeout})
+	if err != nil {
+		//only transport already configured for HTTP/2 is rejected
+		return t
+	}
+	t2.AllowHTTP = true
+	t2.ReadIdleTimeout = cfg.KeepAlive
+	t2.ConnPool = &h2cConnPool{t: t2, dial: t.DialContext, conns: make(map[string]*http2.ClientConn)}
+	return t2
+}
+
+//h2cConnPool keeps one HTTP/2 connection per browser pod, connections are dialed with context of the request,
+//so dialing is cancelled together with the request
+type h2cConnPool struct {
+	t     *http2.Transport
+	dial  func(ctx context.Context, network, addr string) (net.Conn, error)
+	mu    sync.Mutex
+	conns map[string]*http2.ClientConn
+}
+
+//GetClientConn returns connection to addr which can take the request, new connection is dialed otherwise
+func (p *h2cConnPool) GetClientConn(req *http.Request, addr string) (*http2.ClientConn, error) {
+	p.mu.Lock()
+	cc, ok := p.conns[addr]
+	p.mu.Unlock()
+	if ok && cc.CanTakeNewRequest() {
+		return cc, nil
+	}
+
+	conn, err := p.dial(req.Context(), "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	cc, err = p.t.NewClientConn(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	p.mu.Lock()
+	p.conns[addr] = cc
+	p.mu.Unlock()
+	return cc, nil
+}
+
+//MarkDead forgets connection which is closed or going away
+func (p *h2cConnPool) MarkDead(cc *http2.ClientConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for addr, c := range p.conns {
+		if c == cc {
+			delete(p.conns, addr)
+		}
+	}
+}
+
+//streamBody passes request body to the browser without buffering, request can be retried only when nothing was
+//read from its body yet, e.g. when host of the browser wasn't resolved. Closing the body is left to the server
+type streamBody struct {
+	io.Reader
+	read int64
+}
+
+func (b *streamBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	atomic.AddInt64(&b.read, int64(n))
+	return n, err
+}
+
+func (b *streamBody) Close() error {
+	return nil
+}
+
+//consumed reports if any part of the body was sent
+func (b *streamBody) consumed() bool {
+	return atomic.LoadInt64(&b.read) > 0
+}
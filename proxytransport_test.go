@@ -0,0 +1,124 @@
+package selenosis
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/gorilla/mux"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"gotest.tools/assert"
+)
+
+func TestProxyTransport(t *testing.T) {
+	tests := map[string]struct {
+		transport ProxyTransport
+		proto     int
+	}{
+		"Verify commands are proxied over HTTP/1.1 by default": {
+			proto: 1,
+		},
+		"Verify commands are proxied over HTTP/2 with h2c": {
+			transport: ProxyTransport{H2C: true, KeepAlive: 10 * time.Second},
+			proto:     2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		payload := strings.Repeat("a", 4<<20)
+		var proto, received int
+		s := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proto = r.ProtoMajor
+			b, _ := ioutil.ReadAll(r.Body)
+			received = len(b)
+			w.Write([]byte(`{"value":null}`))
+		}), &http2.Server{}))
+		defer s.Close()
+
+		u, _ := url.Parse(s.URL)
+		_, port, _ := net.SplitHostPort(u.Host)
+		app := initApp(&PlatformMock{})
+		app.sidecarPort = port
+		app.proxyTransport = newProxyTransport(test.transport)
+		app.stats.Sessions().Put("chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491", platform.Service{SessionID: "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491", URL: u})
+
+		req := httptest.NewRequest(http.MethodPost, "/wd/hub/session/chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491/execute/sync", bytes.NewBufferString(payload))
+		req = mux.SetURLVars(req, map[string]string{"sessionId": "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491"})
+		rr := httptest.NewRecorder()
+		app.HandleProxy(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `{"value":null}`, rr.Body.String())
+		assert.Equal(t, test.proto, proto)
+		assert.Equal(t, len(payload), received)
+	}
+}
+
+func TestProxyTransportPooling(t *testing.T) {
+	transport := newProxyTransport(ProxyTransport{IdleConnsPerPod: 32, IdleConnTimeout: time.Minute}).(*http.Transport)
+	assert.Equal(t, 32, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 0, transport.MaxIdleConns)
+	assert.Equal(t, time.Minute, transport.IdleConnTimeout)
+
+	transport = newProxyTransport(ProxyTransport{}).(*http.Transport)
+	assert.Equal(t, http.DefaultTransport.(*http.Transport).MaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, http.DefaultTransport.(*http.Transport).IdleConnTimeout, transport.IdleConnTimeout)
+}
+
+func TestProxyTransportH2CIdleConns(t *testing.T) {
+	s := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"value":null}`))
+	}), &http2.Server{}))
+	defer s.Close()
+
+	transport := newProxyTransport(ProxyTransport{H2C: true, IdleConnTimeout: 50 * time.Millisecond}).(*http2.Transport)
+	pool := transport.ConnPool.(*h2cConnPool)
+
+	for i := 0; i < 2; i++ {
+		resp, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, s.URL, http.NoBody))
+		assert.NilError(t, err)
+		ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	pool.mu.Lock()
+	assert.Equal(t, 1, len(pool.conns))
+	pool.mu.Unlock()
+
+	time.Sleep(200 * time.Millisecond)
+	pool.mu.Lock()
+	assert.Equal(t, 0, len(pool.conns))
+	pool.mu.Unlock()
+}
+
+func TestProxyTransportH2CDialCancel(t *testing.T) {
+	transport := newProxyTransport(ProxyTransport{H2C: true})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "http://chrome-85-0-1.selenosis:4445/wd/hub/status", http.NoBody).WithContext(ctx)
+	_, err := transport.RoundTrip(req)
+	assert.Assert(t, err != nil && strings.Contains(err.Error(), "operation was canceled"), err)
+}
+
+func TestStreamBody(t *testing.T) {
+	body := &streamBody{Reader: strings.NewReader("payload")}
+	assert.Assert(t, !body.consumed())
+
+	b, err := ioutil.ReadAll(body)
+	assert.NilError(t, err)
+	assert.Equal(t, "payload", string(b))
+	assert.Assert(t, body.consumed())
+	assert.NilError(t, body.Close())
+	assert.Equal(t, int64(7), body.read)
+}
@@ -0,0 +1,223 @@
+package selenosis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/alcounit/selenosis/audit"
+	"github.com/alcounit/selenosis/tools"
+)
+
+//defaultTenant is name sessions without tenant are referred by in admin API
+const defaultTenant = "default"
+
+//retainedData lists data purge doesn't delete: audit events are shipped to sinks and are not stored by selenosis
+var retainedData = []string{"audit"}
+
+//peerTimeout limits purge request sent to every selenosis replica
+var peerTimeout = 30 * time.Second
+
+type purgeResult struct {
+	Tenant    string     `json:"tenant"`
+	Before    *time.Time `json:"before,omitempty"`
+	Artifacts int        `json:"artifacts"`
+	HARs      int        `json:"hars"`
+	Bytes     int64      `json:"bytes"`
+	Partial   bool       `json:"partial,omitempty"`
+	Retained  []string   `json:"retained,omitempty"`
+}
+
+//HandlePurgeData deletes stored artifacts and HAR archives of the tenant created before the date, purge itself is
+//audited. HAR archives are kept in memory of the replica captured them, so purge of archives is sent to every replica
+//found by peers address, request with local parameter purges archives of the replica handling it only. Session
+//records are deleted together with their sessions and audit events are not stored, both are not purged
+func (app *App) HandlePurgeData(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	name := query.Get("tenant")
+	if name == "" {
+		tools.JSONError(w, "tenant is required", http.StatusBadRequest)
+		return
+	}
+	tenant := name
+	if tenant == defaultTenant {
+		tenant = ""
+	}
+	policy, ok := app.artifactsPolicy(tenant)
+	if !ok {
+		tools.JSONError(w, fmt.Sprintf("tenant %s not found", name), http.StatusNotFound)
+		return
+	}
+
+	result := purgeResult{Tenant: name}
+	if v := query.Get("before"); v != "" {
+		before, err := parseDate(v)
+		if err != nil {
+			tools.JSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		result.Before = &before
+	}
+
+	logger := app.logger.WithField("tenant", name)
+
+	var before time.Time
+	if result.Before != nil {
+		before = *result.Before
+	}
+
+	if query.Get("local") == "true" {
+		result.HARs, result.Bytes = app.stats.HARs().Purge(tenant, before)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+
+	list, err := app.client.Artifacts().List(tenant)
+	if err != nil {
+		logger.Errorf("failed to list artifacts: %v", err)
+		tools.JSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var removed, failed []string
+	deleter := app.artifactDeleter(tenant, policy)
+	for _, artifact := range list {
+		if result.Before != nil && !artifact.Created.Before(*result.Before) {
+			continue
+		}
+		if err := deleter.delete(r.Context(), artifact); err != nil {
+			logger.Errorf("failed to delete artifact %s: %v", artifact.URL, err)
+			failed = append(failed, artifact.URL)
+			continue
+		}
+		removed = append(removed, artifact.URL)
+		result.Artifacts++
+		result.Bytes += artifact.Size
+	}
+
+	if len(removed) > 0 {
+		if err := app.client.Artifacts().Remove(tenant, removed); err != nil {
+			logger.Errorf("failed to remove artifact records: %v", err)
+			tools.JSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	hars, size, partial := app.purgeHARs(r, tenant, before)
+	result.HARs = hars
+	result.Bytes += size
+	result.Partial = partial
+	result.Retained = retainedData
+
+	app.auditRequest(r, audit.Event{
+		Type:    audit.DataPurged,
+		Tenant:  tenant,
+		Message: purgeMessage(result, len(failed)),
+	})
+
+	if len(failed) > 0 {
+		tools.JSONError(w, fmt.Sprintf("failed to delete artifacts: %s", strings.Join(failed, ", ")), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Infof("data purged, artifacts: %d, hars: %d, bytes: %d", result.Artifacts, result.HARs, result.Bytes)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func purgeMessage(result purgeResult, failed int) string {
+	msg := fmt.Sprintf("artifacts deleted: %d, hars deleted: %d, bytes: %d, failed: %d", result.Artifacts, result.HARs, result.Bytes, failed)
+	if result.Before != nil {
+		msg += ", before: " + result.Before.Format(time.RFC3339)
+	}
+	if result.Partial {
+		msg += ", partial: true"
+	}
+	return msg
+}
+
+//purgeHARs deletes HAR archives of the tenant on every replica resolved from peers address and returns number and
+//size of deleted archives. Purge is partial when some replica failed, or when peers are not set and replicas elect
+//leader, so other replicas may keep archives
+func (app *App) purgeHARs(r *http.Request, tenant string, before time.Time) (int, int64, bool) {
+	logger := app.logger.WithField("tenant", tenant)
+	if app.peers == "" {
+		hars, size := app.stats.HARs().Purge(tenant, before)
+		return hars, size, app.leader != nil
+	}
+
+	host, port, err := net.SplitHostPort(app.peers)
+	if err == nil {
+		var addrs []string
+		if addrs, err = net.DefaultResolver.LookupHost(r.Context(), host); err == nil {
+			var hars int
+			var size int64
+			var partial bool
+			for _, addr := range addrs {
+				result, err := app.purgePeer(r, net.JoinHostPort(addr, port))
+				if err != nil {
+					logger.Errorf("failed to purge HAR archives of replica %s: %v", addr, err)
+					partial = true
+					continue
+				}
+				hars += result.HARs
+				size += result.Bytes
+			}
+			return hars, size, partial
+		}
+	}
+	logger.Errorf("failed to resolve replicas %s: %v", app.peers, err)
+	hars, size := app.stats.HARs().Purge(tenant, before)
+	return hars, size, true
+}
+
+//purgePeer sends purge request to the replica with credentials of the original request, replica purges its own HAR
+//archives only
+func (app *App) purgePeer(r *http.Request, addr string) (purgeResult, error) {
+	ctx, cancel := context.WithTimeout(r.Context(), peerTimeout)
+	defer cancel()
+
+	query := r.URL.Query()
+	query.Set("local", "true")
+	u := url.URL{Scheme: "http", Host: addr, Path: r.URL.Path, RawQuery: query.Encode()}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return purgeResult{}, err
+	}
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return purgeResult{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return purgeResult{}, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	var result purgeResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return purgeResult{}, err
+	}
+	return result, nil
+}
+
+//parseDate accepts RFC3339 timestamp or date in 2006-01-02 form
+func parseDate(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %s, RFC3339 timestamp or YYYY-MM-DD date expected", v)
+	}
+	return t, nil
+}
@@ -0,0 +1,151 @@
+package selenosis
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/storage"
+	"gotest.tools/assert"
+)
+
+func TestHandlePurgeData(t *testing.T) {
+	recorded := []platform.Artifact{
+		{URL: "s3://bucket/1.mp4", Size: 10, Created: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{URL: "s3://bucket/2.mp4", Size: 20, Created: time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{URL: "s3://bucket/3.mp4", Size: 30, Created: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	tests := map[string]struct {
+		query      string
+		deleteErr  error
+		statusCode int
+		respBody   string
+		remaining  []string
+	}{
+		"Verify artifacts created before date are purged": {
+			query:      "?tenant=default&before=2021-02-15",
+			statusCode: http.StatusOK,
+			respBody:   `{"tenant":"default","before":"2021-02-15T00:00:00Z","artifacts":2,"hars":1,"bytes":40,"retained":["audit"]}`,
+			remaining:  []string{"s3://bucket/3.mp4"},
+		},
+		"Verify all artifacts are purged without date": {
+			query:      "?tenant=default",
+			statusCode: http.StatusOK,
+			respBody:   `{"tenant":"default","artifacts":3,"hars":2,"bytes":80,"retained":["audit"]}`,
+		},
+		"Verify tenant is required": {
+			query:      "?before=2021-02-15",
+			statusCode: http.StatusBadRequest,
+			respBody:   `{"code":400,"value":{"message":"tenant is required"}}`,
+			remaining:  []string{"s3://bucket/1.mp4", "s3://bucket/2.mp4", "s3://bucket/3.mp4"},
+		},
+		"Verify unknown tenant": {
+			query:      "?tenant=contractors",
+			statusCode: http.StatusNotFound,
+			respBody:   `{"code":404,"value":{"message":"tenant contractors not found"}}`,
+			remaining:  []string{"s3://bucket/1.mp4", "s3://bucket/2.mp4", "s3://bucket/3.mp4"},
+		},
+		"Verify invalid date": {
+			query:      "?tenant=default&before=yesterday",
+			statusCode: http.StatusBadRequest,
+			respBody:   `{"code":400,"value":{"message":"invalid date yesterday, RFC3339 timestamp or YYYY-MM-DD date expected"}}`,
+			remaining:  []string{"s3://bucket/1.mp4", "s3://bucket/2.mp4", "s3://bucket/3.mp4"},
+		},
+		"Verify records are kept when artifacts are not deleted": {
+			query:      "?tenant=default&before=2021-01-15T00:00:00Z",
+			deleteErr:  errors.New("access denied"),
+			statusCode: http.StatusInternalServerError,
+			respBody:   `{"code":500,"value":{"message":"failed to delete artifacts: s3://bucket/1.mp4"}}`,
+			remaining:  []string{"s3://bucket/1.mp4", "s3://bucket/2.mp4", "s3://bucket/3.mp4"},
+		},
+	}
+
+	defer func(d func(context.Context, string, map[string]string) error) { deleteArtifact = d }(deleteArtifact)
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		deleteArtifact = func(context.Context, string, map[string]string) error {
+			return test.deleteErr
+		}
+
+		p := &PlatformMock{artifacts: map[string][]platform.Artifact{"": append([]platform.Artifact{}, recorded...)}}
+		app := initApp(p)
+		app.stats.HARs().Put("chrome-85-0-1", storage.HAR{Content: []byte(`{"log":{}}`), Captured: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)})
+		app.stats.HARs().Put("chrome-85-0-2", storage.HAR{Content: []byte(`{"log":{}}`), Captured: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)})
+		app.stats.HARs().Put("chrome-85-0-3", storage.HAR{Content: []byte(`{"log":{}}`), Tenant: "contractors", Captured: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)})
+
+		req, err := http.NewRequest(http.MethodDelete, "/admin/data"+test.query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		app.HandlePurgeData(rr, req)
+
+		assert.Equal(t, test.statusCode, rr.Code)
+		assert.Equal(t, test.respBody, string(bytes.TrimSpace(rr.Body.Bytes())))
+
+		var remaining []string
+		for _, artifact := range p.artifacts[""] {
+			remaining = append(remaining, artifact.URL)
+		}
+		assert.DeepEqual(t, test.remaining, remaining)
+	}
+}
+
+func TestHandlePurgeDataOfReplicas(t *testing.T) {
+	tests := map[string]struct {
+		peers    func(replica string) string
+		leader   Leader
+		respBody string
+		local    int
+	}{
+		"Verify archives of every replica are purged": {
+			peers:    func(replica string) string { return replica },
+			respBody: `{"tenant":"default","artifacts":0,"hars":1,"bytes":10,"retained":["audit"]}`,
+			local:    1,
+		},
+		"Verify purge is partial when replica fails": {
+			peers:    func(string) string { return "127.0.0.1:1" },
+			respBody: `{"tenant":"default","artifacts":0,"hars":0,"bytes":0,"partial":true,"retained":["audit"]}`,
+			local:    1,
+		},
+		"Verify purge is partial when replicas are not known": {
+			peers:    func(string) string { return "" },
+			leader:   leaderMock(true),
+			respBody: `{"tenant":"default","artifacts":0,"hars":1,"bytes":10,"partial":true,"retained":["audit"]}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		replica := initApp(&PlatformMock{})
+		replica.stats.HARs().Put("chrome-85-0-1", storage.HAR{Content: []byte(`{"log":{}}`), Captured: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)})
+		srv := httptest.NewServer(http.HandlerFunc(replica.HandlePurgeData))
+
+		app := initApp(&PlatformMock{})
+		app.peers = test.peers(srv.Listener.Addr().String())
+		app.leader = test.leader
+		app.stats.HARs().Put("chrome-85-0-2", storage.HAR{Content: []byte(`{"log":{}}`), Captured: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)})
+
+		req, err := http.NewRequest(http.MethodDelete, "/admin/data?tenant=default", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		app.HandlePurgeData(rr, req)
+		srv.Close()
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, test.respBody, string(bytes.TrimSpace(rr.Body.Bytes())))
+		assert.Equal(t, test.local, app.stats.HARs().Len())
+	}
+}
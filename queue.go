@@ -0,0 +1,152 @@
+package selenosis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/alcounit/selenosis/metrics"
+	"github.com/alcounit/selenosis/platform"
+)
+
+//queueCheckInterval is how often the head of session queue checks for free capacity
+var queueCheckInterval = 500 * time.Millisecond
+
+var (
+	errQueueFull    = errors.New("session queue is full")
+	errQueueTimeout = errors.New("no free capacity within session queue wait time")
+)
+
+//sessionQueue keeps new session requests waiting for free capacity in arrival order, capacity
+//granted to request is reserved until pod of its session is registered or browser failed to start
+type sessionQueue struct {
+	sync.Mutex
+	size     int
+	wait     time.Duration
+	waiting  []uint64
+	next     uint64
+	reserved map[*reservation]struct{}
+}
+
+func newSessionQueue(size int, wait time.Duration) *sessionQueue {
+	return &sessionQueue{size: size, wait: wait, reserved: make(map[*reservation]struct{})}
+}
+
+//acquire returns when free reports capacity for reserved sessions plus one and all requests queued
+//earlier are served, reservation should be bound to the started session and released after browser is
+//started. Queue of zero size never waits
+func (q *sessionQueue) acquire(ctx context.Context, free func(reserved []*reservation) bool) (*reservation, error) {
+	if q.size <= 0 {
+		return &reservation{release: func() {}}, nil
+	}
+
+	q.Lock()
+	if len(q.waiting) == 0 && free(q.reservations()) {
+		r := q.reserve()
+		q.Unlock()
+		return r, nil
+	}
+	if len(q.waiting) >= q.size {
+		q.Unlock()
+		metrics.QueueRejected.WithLabelValues("full").Inc()
+		return nil, errQueueFull
+	}
+	ticket := q.next
+	q.next++
+	q.waiting = append(q.waiting, ticket)
+	q.Unlock()
+	metrics.QueuedSessions.Inc()
+	defer metrics.QueuedSessions.Dec()
+
+	var timeout <-chan time.Time
+	if q.wait > 0 {
+		timer := time.NewTimer(q.wait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	ticker := time.NewTicker(queueCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		q.Lock()
+		if q.waiting[0] == ticket && free(q.reservations()) {
+			q.waiting = q.waiting[1:]
+			r := q.reserve()
+			q.Unlock()
+			return r, nil
+		}
+		q.Unlock()
+
+		select {
+		case <-ctx.Done():
+			q.remove(ticket)
+			return nil, ctx.Err()
+		case <-timeout:
+			q.remove(ticket)
+			metrics.QueueRejected.WithLabelValues("timeout").Inc()
+			return nil, errQueueTimeout
+		case <-ticker.C:
+		}
+	}
+}
+
+//reserve grants capacity to request, lock should be held
+func (q *sessionQueue) reserve() *reservation {
+	r := &reservation{}
+	r.release = func() {
+		q.Lock()
+		defer q.Unlock()
+		delete(q.reserved, r)
+	}
+	q.reserved[r] = struct{}{}
+	return r
+}
+
+//reservations returns capacity granted to requests, lock should be held
+func (q *sessionQueue) reservations() []*reservation {
+	reserved := make([]*reservation, 0, len(q.reserved))
+	for r := range q.reserved {
+		reserved = append(reserved, r)
+	}
+	return reserved
+}
+
+func (q *sessionQueue) remove(ticket uint64) {
+	q.Lock()
+	defer q.Unlock()
+	for i, t := range q.waiting {
+		if t == ticket {
+			q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+//Len returns number of queued requests
+func (q *sessionQueue) Len() int {
+	q.Lock()
+	defer q.Unlock()
+	return len(q.waiting)
+}
+
+//freeCapacity reports if session limit has room for reserved sessions plus one, reservation whose pod is
+//registered is counted once as pending session. Relayed sessions don't create pods and are not counted
+func (app *App) freeCapacity(reserved []*reservation) bool {
+	sessions := app.stats.Sessions().List()
+	used := 0
+	for _, r := range reserved {
+		if !r.counted(sessions) {
+			used++
+		}
+	}
+	for _, s := range sessions {
+		if s.Relay {
+			continue
+		}
+		if s.Status == platform.Running || s.Status == platform.Pending {
+			used++
+		}
+	}
+	return used < app.sessionLimit
+}
@@ -0,0 +1,153 @@
+package selenosis
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"gotest.tools/assert"
+)
+
+func TestSessionQueue(t *testing.T) {
+	queueCheckInterval = 10 * time.Millisecond
+
+	tests := map[string]struct {
+		size    int
+		wait    time.Duration
+		limit   int
+		waiting int
+		free    time.Duration
+		err     error
+	}{
+		"Verify request is served at once when capacity is free": {
+			size:  1,
+			limit: 1,
+		},
+		"Verify request waits for free capacity": {
+			size:  1,
+			wait:  time.Second,
+			limit: 0,
+			free:  50 * time.Millisecond,
+		},
+		"Verify request is rejected when queue is full": {
+			size:    1,
+			wait:    time.Second,
+			waiting: 1,
+			err:     errQueueFull,
+		},
+		"Verify request is rejected after queue wait": {
+			size: 1,
+			wait: 50 * time.Millisecond,
+			err:  errQueueTimeout,
+		},
+		"Verify disabled queue never waits": {},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		q := newSessionQueue(test.size, test.wait)
+		for i := 0; i < test.waiting; i++ {
+			q.waiting = append(q.waiting, q.next)
+			q.next++
+		}
+		limit := make(chan int, 1)
+		limit <- test.limit
+		if test.free > 0 {
+			go func() {
+				time.Sleep(test.free)
+				<-limit
+				limit <- 1
+			}()
+		}
+		free := func(reserved []*reservation) bool {
+			l := <-limit
+			limit <- l
+			return len(reserved) < l
+		}
+
+		slot, err := q.acquire(context.Background(), free)
+		assert.Equal(t, test.err, err)
+		if err != nil {
+			assert.Equal(t, test.waiting, q.Len())
+			continue
+		}
+		slot.release()
+		assert.Equal(t, 0, q.Len())
+		assert.Equal(t, 0, len(q.reserved))
+	}
+}
+
+func TestSessionQueueOrder(t *testing.T) {
+	queueCheckInterval = 10 * time.Millisecond
+
+	q := newSessionQueue(2, time.Second)
+	held := q.reserve()
+	free := func(reserved []*reservation) bool {
+		return len(reserved) < 1
+	}
+
+	served := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		go func(i int) {
+			slot, err := q.acquire(context.Background(), free)
+			assert.NilError(t, err)
+			served <- i
+			time.Sleep(20 * time.Millisecond)
+			slot.release()
+		}(i)
+		for q.Len() != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	held.release()
+
+	assert.Equal(t, 0, <-served)
+	assert.Equal(t, 1, <-served)
+}
+
+func TestSessionQueueLimit(t *testing.T) {
+	queueCheckInterval = 10 * time.Millisecond
+
+	app := initApp(&PlatformMock{})
+	app.sessionLimit = 1
+	app.queue = newSessionQueue(1, 50*time.Millisecond)
+	app.stats.Sessions().Put("chrome-85-0-1", platform.Service{SessionID: "chrome-85-0-1", Status: platform.Running})
+	app.stats.Sessions().Put("chrome-85-0-2", platform.Service{SessionID: "chrome-85-0-2", Status: platform.Running, Relay: true})
+
+	assert.Assert(t, !app.freeCapacity(nil))
+
+	rr := httptest.NewRecorder()
+	app.HandleSession(rr, httptest.NewRequest(http.MethodPost, "/wd/hub/session", bytes.NewReader([]byte(`{"desiredCapabilities":{"browserName":"chrome"}}`))))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, `{"value":{"error":"timeout","message":"no free capacity within session queue wait time","stacktrace":""}}`, string(bytes.TrimSpace(rr.Body.Bytes())))
+
+	app.stats.Sessions().Delete("chrome-85-0-1")
+	assert.Assert(t, app.freeCapacity(nil))
+	assert.Assert(t, !app.freeCapacity([]*reservation{{}}))
+}
+
+func TestSessionQueueSlotOfStartingSession(t *testing.T) {
+	const sessionID = "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491"
+
+	app := initApp(&PlatformMock{})
+	app.sessionLimit = 2
+	app.queue = newSessionQueue(1, time.Millisecond)
+
+	slot, err := app.queue.acquire(context.Background(), app.freeCapacity)
+	assert.NilError(t, err)
+	slot.bind(sessionID)
+	app.stats.Sessions().Put(sessionID, platform.Service{SessionID: sessionID, Status: platform.Pending})
+
+	second, err := app.queue.acquire(context.Background(), app.freeCapacity)
+	assert.NilError(t, err)
+	defer second.release()
+
+	_, err = app.queue.acquire(context.Background(), app.freeCapacity)
+	assert.Error(t, err, errQueueTimeout.Error())
+	slot.release()
+}
@@ -0,0 +1,285 @@
+package selenosis
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/alcounit/selenosis/auth"
+	"github.com/alcounit/selenosis/config"
+	"github.com/alcounit/selenosis/metrics"
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/selenium"
+)
+
+//clientLabel keeps client the session is counted for by client quotas
+const clientLabel = "client"
+
+const (
+	browserQuota = "browser"
+	versionQuota = "version"
+	clientQuota  = "client"
+)
+
+type quotaUsage struct {
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	Limit int    `json:"limit"`
+	Used  int    `json:"used"`
+}
+
+func (u quotaUsage) key() string {
+	return u.Kind + "/" + u.Name
+}
+
+//quotaExceeded is returned when session would exceed one of quotas
+type quotaExceeded struct {
+	quotaUsage
+}
+
+func (e *quotaExceeded) Error() string {
+	return fmt.Sprintf("%s quota exceeded: %s has %d of %d sessions", e.Kind, e.Name, e.Used, e.Limit)
+}
+
+//reservation is granted to new session request until its browser is started or failed. Request binds
+//reservation to session it is starting, once pod of the session is in registry the pod is counted instead
+type reservation struct {
+	sync.Mutex
+	keys      []string
+	sessionID string
+	release   func()
+}
+
+//bind ties reservation to session the request is starting, every browser start attempt has new session
+func (r *reservation) bind(sessionID string) {
+	r.Lock()
+	defer r.Unlock()
+	r.sessionID = sessionID
+}
+
+//counted reports if pod of the bound session is already counted as running or pending session
+func (r *reservation) counted(sessions map[string]platform.Service) bool {
+	r.Lock()
+	sessionID := r.sessionID
+	r.Unlock()
+	s, ok := sessions[sessionID]
+	return ok && (s.Status == platform.Running || s.Status == platform.Pending)
+}
+
+//quotaManager enforces session limits per browser, browser version and client on top of session
+//limit, max sessions of browser version is its version quota. Quota granted to request is reserved until its
+//browser is started or failed
+type quotaManager struct {
+	sync.Mutex
+	quotas    *config.QuotasConfig
+	reserved  map[*reservation]struct{}
+	published map[string]quotaUsage
+}
+
+func newQuotaManager(quotas *config.QuotasConfig) *quotaManager {
+	return &quotaManager{quotas: quotas, reserved: make(map[*reservation]struct{}), published: make(map[string]quotaUsage)}
+}
+
+//client returns client identity of the request, header configured in client quotas or authenticated principal,
+//basic auth user identifies tenant users when authentication is disabled
+func (m *quotaManager) client(r *http.Request, principal auth.Principal) string {
+	if m.quotas == nil {
+		return ""
+	}
+	if header := m.quotas.Get().Clients.Header; header != "" {
+		return r.Header.Get(header)
+	}
+	if principal.Name != "" {
+		return principal.Name
+	}
+	user, _, _ := r.BasicAuth()
+	return user
+}
+
+//limits returns quotas applied to session of the browser requested by the client, max sessions of browser
+//version in browsers config is its version quota unless quotas config sets lower one
+func (m *quotaManager) limits(browser, version, client string, maxSessions int) []quotaUsage {
+	var b config.BrowserQuota
+	var clientLimit int
+	if m.quotas != nil {
+		quotas := m.quotas.Get()
+		b = quotas.Browsers[browser]
+		if client != "" {
+			clientLimit = quotas.Clients.Limit(client)
+		}
+	}
+	versionLimit := b.Versions[version]
+	if maxSessions > 0 && (versionLimit <= 0 || maxSessions < versionLimit) {
+		versionLimit = maxSessions
+	}
+
+	var limits []quotaUsage
+	if b.Limit > 0 {
+		limits = append(limits, quotaUsage{Kind: browserQuota, Name: browser, Limit: b.Limit})
+	}
+	if versionLimit > 0 {
+		limits = append(limits, quotaUsage{Kind: versionQuota, Name: browser + "/" + version, Limit: versionLimit})
+	}
+	if clientLimit > 0 {
+		limits = append(limits, quotaUsage{Kind: clientQuota, Name: client, Limit: clientLimit})
+	}
+	return limits
+}
+
+//acquire reserves quotas of new session, reservation should be bound to the started session and released
+//after browser is started
+func (m *quotaManager) acquire(sessions map[string]platform.Service, browser, version, client string, maxSessions int) (*reservation, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	limits := m.limits(browser, version, client, maxSessions)
+	for _, limit := range limits {
+		limit.Used = m.used(sessions, limit)
+		if limit.Used >= limit.Limit {
+			metrics.QuotaRejected.WithLabelValues(limit.Kind).Inc()
+			return nil, &quotaExceeded{limit}
+		}
+	}
+	r := &reservation{}
+	for _, limit := range limits {
+		r.keys = append(r.keys, limit.key())
+	}
+	r.release = func() {
+		m.Lock()
+		defer m.Unlock()
+		delete(m.reserved, r)
+	}
+	m.reserved[r] = struct{}{}
+	return r, nil
+}
+
+//usage returns usage of configured quotas, of max sessions of browser versions and of default client quota
+//by clients having sessions
+func (m *quotaManager) usage(sessions map[string]platform.Service, browsers []platform.BrowserSpec) []quotaUsage {
+	versions := make(map[string]int)
+	for _, browser := range browsers {
+		if browser.MaxSessions > 0 {
+			versions[browser.BrowserName+"/"+browser.BrowserVersion] = browser.MaxSessions
+		}
+	}
+
+	var limits []quotaUsage
+	if m.quotas != nil {
+		quotas := m.quotas.Get()
+		for browser, b := range quotas.Browsers {
+			if b.Limit > 0 {
+				limits = append(limits, quotaUsage{Kind: browserQuota, Name: browser, Limit: b.Limit})
+			}
+			for version, limit := range b.Versions {
+				name := browser + "/" + version
+				if max, ok := versions[name]; limit > 0 && (!ok || limit < max) {
+					versions[name] = limit
+				}
+			}
+		}
+		clients := make(map[string]struct{})
+		for client := range quotas.Clients.Limits {
+			clients[client] = struct{}{}
+		}
+		for _, s := range sessions {
+			if client := s.Labels[clientLabel]; client != "" {
+				clients[client] = struct{}{}
+			}
+		}
+		for client := range clients {
+			if limit := quotas.Clients.Limit(client); limit > 0 {
+				limits = append(limits, quotaUsage{Kind: clientQuota, Name: client, Limit: limit})
+			}
+		}
+	}
+	for name, limit := range versions {
+		limits = append(limits, quotaUsage{Kind: versionQuota, Name: name, Limit: limit})
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	for i := range limits {
+		limits[i].Used = m.used(sessions, limits[i])
+	}
+	sort.Slice(limits, func(i, j int) bool {
+		if limits[i].Kind != limits[j].Kind {
+			return limits[i].Kind < limits[j].Kind
+		}
+		return limits[i].Name < limits[j].Name
+	})
+	return limits
+}
+
+//publish updates quota usage gauges, gauges of quotas which no longer apply are removed
+func (m *quotaManager) publish(sessions map[string]platform.Service, browsers []platform.BrowserSpec) {
+	limits := m.usage(sessions, browsers)
+
+	m.Lock()
+	defer m.Unlock()
+	current := make(map[string]quotaUsage)
+	for _, limit := range limits {
+		current[limit.key()] = limit
+		metrics.QuotaUsed.WithLabelValues(limit.Kind, limit.Name).Set(float64(limit.Used))
+	}
+	for key, limit := range m.published {
+		if _, ok := current[key]; !ok {
+			metrics.QuotaUsed.DeleteLabelValues(limit.Kind, limit.Name)
+		}
+	}
+	m.published = current
+}
+
+//publishQuotaUsage updates quota usage gauges with sessions of the registry
+func (app *App) publishQuotaUsage() {
+	app.quotas.publish(app.stats.Sessions().List(), app.browsers.Browsers())
+}
+
+//used counts running and pending sessions matching the quota and reserved ones whose pods are not counted
+//yet, lock should be held
+func (m *quotaManager) used(sessions map[string]platform.Service, limit quotaUsage) int {
+	used := 0
+	for r := range m.reserved {
+		for _, key := range r.keys {
+			if key == limit.key() && !r.counted(sessions) {
+				used++
+			}
+		}
+	}
+	for _, s := range sessions {
+		if s.Status != platform.Running && s.Status != platform.Pending {
+			continue
+		}
+		var match bool
+		switch limit.Kind {
+		case browserQuota:
+			match = s.Labels["browserName"] == limit.Name
+		case versionQuota:
+			match = s.Labels["browserName"]+"/"+s.Labels["browserVersion"] == limit.Name
+		case clientQuota:
+			match = s.Labels[clientLabel] == limit.Name
+		}
+		if match {
+			used++
+		}
+	}
+	return used
+}
+
+//quotaError writes session not created error with the quota session would exceed
+func quotaError(w http.ResponseWriter, err *quotaExceeded) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(
+		map[string]interface{}{
+			"value": struct {
+				selenium.Error
+				Quota quotaUsage `json:"quota"`
+			}{
+				Error: selenium.Error{Error: selenium.ErrSessionNotCreated, Message: err.Error()},
+				Quota: err.quotaUsage,
+			},
+		},
+	)
+}
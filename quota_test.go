@@ -0,0 +1,300 @@
+package selenosis
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/alcounit/selenosis/auth"
+	"github.com/alcounit/selenosis/config"
+	"github.com/alcounit/selenosis/metrics"
+	"github.com/alcounit/selenosis/platform"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+const quotasConfig = `browsers:
+  chrome:
+    limit: 3
+    versions:
+      "68.0": 1
+clients:
+  default: 2
+  limits:
+    nightly: 1
+`
+
+func newQuotas(t *testing.T, content string) *config.QuotasConfig {
+	tmp, err := ioutil.TempFile("", "quotas.yaml")
+	assert.NilError(t, err)
+	defer os.Remove(tmp.Name())
+	tmp.WriteString(content)
+	tmp.Close()
+
+	quotas, err := config.NewQuotasConfig(tmp.Name())
+	assert.NilError(t, err)
+	return quotas
+}
+
+func TestNewSessionQuota(t *testing.T) {
+	running := func(id, version, client string) platform.Service {
+		return platform.Service{SessionID: id, Status: platform.Running, Labels: map[string]string{"browserName": "chrome", "browserVersion": version, clientLabel: client}}
+	}
+
+	tests := map[string]struct {
+		header   string
+		user     string
+		token    string
+		version  string
+		sessions []platform.Service
+		created  int
+		respCode int
+		respBody string
+	}{
+		"Verify session within quotas is started": {
+			version:  "86.0",
+			created:  2,
+			respCode: http.StatusInternalServerError,
+			respBody: `{"value":{"error":"session not created","message":"failed to start browser: failed to create pod","stacktrace":""}}`,
+		},
+		"Verify browser quota is enforced": {
+			version:  "86.0",
+			sessions: []platform.Service{running("s1", "86.0", ""), running("s2", "86.0", ""), running("s3", "68.0", "")},
+			respCode: http.StatusTooManyRequests,
+			respBody: `{"value":{"error":"session not created","message":"browser quota exceeded: chrome has 3 of 3 sessions","stacktrace":"","quota":{"kind":"browser","name":"chrome","limit":3,"used":3}}}`,
+		},
+		"Verify browser version quota is enforced": {
+			version:  "68.0",
+			sessions: []platform.Service{running("s1", "68.0", "")},
+			respCode: http.StatusTooManyRequests,
+			respBody: `{"value":{"error":"session not created","message":"version quota exceeded: chrome/68.0 has 1 of 1 sessions","stacktrace":"","quota":{"kind":"version","name":"chrome/68.0","limit":1,"used":1}}}`,
+		},
+		"Verify client limit is enforced": {
+			user:     "nightly",
+			version:  "86.0",
+			sessions: []platform.Service{running("s1", "86.0", "nightly")},
+			respCode: http.StatusTooManyRequests,
+			respBody: `{"value":{"error":"session not created","message":"client quota exceeded: nightly has 1 of 1 sessions","stacktrace":"","quota":{"kind":"client","name":"nightly","limit":1,"used":1}}}`,
+		},
+		"Verify default client limit is enforced": {
+			user:     "alice",
+			version:  "86.0",
+			sessions: []platform.Service{running("s1", "86.0", "alice"), running("s2", "86.0", "alice")},
+			respCode: http.StatusTooManyRequests,
+			respBody: `{"value":{"error":"session not created","message":"client quota exceeded: alice has 2 of 2 sessions","stacktrace":"","quota":{"kind":"client","name":"alice","limit":2,"used":2}}}`,
+		},
+		"Verify sessions of other clients are not counted": {
+			user:     "alice",
+			version:  "86.0",
+			sessions: []platform.Service{running("s1", "86.0", "bob"), running("s2", "86.0", "bob")},
+			created:  2,
+			respCode: http.StatusInternalServerError,
+			respBody: `{"value":{"error":"session not created","message":"failed to start browser: failed to create pod","stacktrace":""}}`,
+		},
+		"Verify client is identified by header instead of user": {
+			header:   "X-Auth-User",
+			user:     "alice",
+			version:  "86.0",
+			sessions: []platform.Service{running("s1", "86.0", "nightly")},
+			respCode: http.StatusTooManyRequests,
+			respBody: `{"value":{"error":"session not created","message":"client quota exceeded: nightly has 1 of 1 sessions","stacktrace":"","quota":{"kind":"client","name":"nightly","limit":1,"used":1}}}`,
+		},
+		"Verify client is identified by bearer token principal": {
+			token:    "nightly-token",
+			version:  "86.0",
+			sessions: []platform.Service{running("s1", "86.0", "nightly")},
+			respCode: http.StatusTooManyRequests,
+			respBody: `{"value":{"error":"session not created","message":"client quota exceeded: nightly has 1 of 1 sessions","stacktrace":"","quota":{"kind":"client","name":"nightly","limit":1,"used":1}}}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		content := quotasConfig
+		if test.header != "" {
+			content += "  header: " + test.header + "\n"
+		}
+
+		p := &PlatformMock{err: errors.New("failed to create pod")}
+		app := initApp(p)
+		app.quotas = newQuotaManager(newQuotas(t, content))
+		if test.token != "" {
+			app.auth = auth.New(auth.Config{Tokens: map[string]string{"nightly": test.token}})
+		}
+		for _, s := range test.sessions {
+			app.stats.Sessions().Put(s.SessionID, s)
+		}
+
+		req, err := http.NewRequest(http.MethodPost, session, bytes.NewReader([]byte(`{"desiredCapabilities":{"browserName":"chrome","browserVersion":"`+test.version+`"}}`)))
+		assert.NilError(t, err)
+		if test.header != "" {
+			req.Header.Set(test.header, "nightly")
+		}
+		if test.user != "" {
+			req.SetBasicAuth(test.user, "")
+		}
+		if test.token != "" {
+			req.Header.Set("Authorization", "Bearer "+test.token)
+		}
+
+		rr := httptest.NewRecorder()
+		app.HandleSession(rr, req)
+
+		assert.Equal(t, test.respCode, rr.Code)
+		assert.Equal(t, test.respBody, string(bytes.TrimSpace(rr.Body.Bytes())))
+		assert.Equal(t, test.created, p.created)
+	}
+}
+
+func TestQuotaReservation(t *testing.T) {
+	m := newQuotaManager(newQuotas(t, quotasConfig))
+	sessions := map[string]platform.Service{}
+
+	reserved, err := m.acquire(sessions, "chrome", "68.0", "", 0)
+	assert.NilError(t, err)
+
+	_, err = m.acquire(sessions, "chrome", "68.0", "", 0)
+	assert.Error(t, err, "version quota exceeded: chrome/68.0 has 1 of 1 sessions")
+
+	reserved.release()
+	reserved, err = m.acquire(sessions, "chrome", "68.0", "", 0)
+	assert.NilError(t, err)
+	reserved.release()
+	assert.Equal(t, 0, len(m.reserved))
+
+	reserved, err = newQuotaManager(nil).acquire(sessions, "chrome", "68.0", "alice", 0)
+	assert.NilError(t, err)
+	reserved.release()
+}
+
+func TestQuotaReservationOfStartingSession(t *testing.T) {
+	m := newQuotaManager(nil)
+	sessions := map[string]platform.Service{}
+
+	starting, err := m.acquire(sessions, "chrome", "86.0", "", 2)
+	assert.NilError(t, err)
+	starting.bind("chrome-86-0-1")
+	sessions["chrome-86-0-1"] = platform.Service{SessionID: "chrome-86-0-1", Status: platform.Pending, Labels: map[string]string{"browserName": "chrome", "browserVersion": "86.0"}}
+
+	second, err := m.acquire(sessions, "chrome", "86.0", "", 2)
+	assert.NilError(t, err)
+
+	_, err = m.acquire(sessions, "chrome", "86.0", "", 2)
+	assert.Error(t, err, "version quota exceeded: chrome/86.0 has 2 of 2 sessions")
+
+	second.release()
+	starting.release()
+	assert.Equal(t, 0, len(m.reserved))
+}
+
+func TestBrowserMaxSessions(t *testing.T) {
+	running := func(id, version string) platform.Service {
+		return platform.Service{SessionID: id, Status: platform.Running, Labels: map[string]string{"browserName": "chrome", "browserVersion": version}}
+	}
+
+	tests := map[string]struct {
+		quotas      *config.QuotasConfig
+		version     string
+		maxSessions int
+		sessions    map[string]platform.Service
+		err         string
+	}{
+		"Verify version within max sessions is started": {
+			version:     "86.0",
+			maxSessions: 2,
+			sessions:    map[string]platform.Service{"s1": running("s1", "86.0"), "s2": running("s2", "85.0")},
+		},
+		"Verify max sessions of version is enforced without quotas config": {
+			version:     "86.0",
+			maxSessions: 2,
+			sessions:    map[string]platform.Service{"s1": running("s1", "86.0"), "s2": running("s2", "86.0")},
+			err:         "version quota exceeded: chrome/86.0 has 2 of 2 sessions",
+		},
+		"Verify lower version quota of quotas config wins": {
+			quotas:      newQuotas(t, quotasConfig),
+			version:     "68.0",
+			maxSessions: 5,
+			sessions:    map[string]platform.Service{"s1": running("s1", "68.0")},
+			err:         "version quota exceeded: chrome/68.0 has 1 of 1 sessions",
+		},
+		"Verify lower max sessions wins over quotas config": {
+			quotas:      newQuotas(t, `browsers: {chrome: {versions: {"86.0": 5}}}`),
+			version:     "86.0",
+			maxSessions: 1,
+			sessions:    map[string]platform.Service{"s1": running("s1", "86.0")},
+			err:         "version quota exceeded: chrome/86.0 has 1 of 1 sessions",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		reserved, err := newQuotaManager(test.quotas).acquire(test.sessions, "chrome", test.version, "", test.maxSessions)
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+		reserved.release()
+	}
+
+	m := newQuotaManager(newQuotas(t, quotasConfig))
+	usage := m.usage(map[string]platform.Service{"s1": running("s1", "86.0")}, []platform.BrowserSpec{
+		{BrowserName: "chrome", BrowserVersion: "68.0", MaxSessions: 4},
+		{BrowserName: "chrome", BrowserVersion: "86.0", MaxSessions: 2},
+		{BrowserName: "firefox", BrowserVersion: "82.0"},
+	})
+	assert.DeepEqual(t, []quotaUsage{
+		{Kind: "browser", Name: "chrome", Limit: 3, Used: 1},
+		{Kind: "client", Name: "nightly", Limit: 1, Used: 0},
+		{Kind: "version", Name: "chrome/68.0", Limit: 1, Used: 0},
+		{Kind: "version", Name: "chrome/86.0", Limit: 2, Used: 1},
+	}, usage)
+}
+
+func TestQuotaUsagePublished(t *testing.T) {
+	m := newQuotaManager(newQuotas(t, quotasConfig))
+	browsers := []platform.BrowserSpec{{BrowserName: "chrome", BrowserVersion: "86.0", MaxSessions: 2}}
+	alice := platform.Service{SessionID: "s1", Status: platform.Running, Labels: map[string]string{"browserName": "chrome", "browserVersion": "86.0", clientLabel: "alice"}}
+
+	m.publish(map[string]platform.Service{"s1": alice}, browsers)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.QuotaUsed.WithLabelValues("browser", "chrome")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.QuotaUsed.WithLabelValues("client", "alice")))
+
+	reserved, err := m.acquire(map[string]platform.Service{"s1": alice}, "chrome", "86.0", "", 0)
+	assert.NilError(t, err)
+	m.publish(map[string]platform.Service{"s1": alice}, browsers)
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.QuotaUsed.WithLabelValues("browser", "chrome")))
+
+	reserved.release()
+	m.publish(map[string]platform.Service{}, browsers)
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.QuotaUsed.WithLabelValues("browser", "chrome")))
+	assert.Assert(t, !metrics.QuotaUsed.DeleteLabelValues("client", "alice"), "gauge of client without sessions is not removed")
+}
+
+func TestHandleQuotaUsage(t *testing.T) {
+	app := initApp(&PlatformMock{})
+	app.quotas = newQuotaManager(newQuotas(t, quotasConfig))
+	app.stats.Sessions().Put("s1", platform.Service{SessionID: "s1", Status: platform.Running, Labels: map[string]string{"browserName": "chrome", "browserVersion": "68.0", clientLabel: "alice"}})
+	app.stats.Sessions().Put("s2", platform.Service{SessionID: "s2", Status: platform.Pending, Labels: map[string]string{"browserName": "chrome", "browserVersion": "86.0"}})
+
+	req, err := http.NewRequest(http.MethodGet, "/quota", nil)
+	assert.NilError(t, err)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(app.HandleQuota).ServeHTTP(rr, req)
+
+	var info quotaInfo
+	assert.NilError(t, json.NewDecoder(rr.Body).Decode(&info))
+	assert.DeepEqual(t, []quotaUsage{
+		{Kind: "browser", Name: "chrome", Limit: 3, Used: 2},
+		{Kind: "client", Name: "alice", Limit: 2, Used: 1},
+		{Kind: "client", Name: "nightly", Limit: 1, Used: 0},
+		{Kind: "version", Name: "chrome/68.0", Limit: 1, Used: 1},
+	}, info.Quotas)
+}
@@ -0,0 +1,161 @@
+package selenosis
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alcounit/selenosis/auth"
+	"github.com/alcounit/selenosis/config"
+)
+
+//rateLimiterPruneInterval is time between drops of buckets clients don't use anymore
+const rateLimiterPruneInterval = time.Minute
+
+//RateLimit allows Rate sessions per second with bursts of up to Burst sessions, zero rate doesn't limit sessions
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+//ParseRateLimit returns rate limit of rate[:burst] value, burst defaults to rate rounded up
+func ParseRateLimit(value string) (RateLimit, error) {
+	var limit RateLimit
+	rate, burst := value, ""
+	if i := strings.Index(value, ":"); i >= 0 {
+		rate, burst = value[:i], value[i+1:]
+	}
+	var err error
+	if limit.Rate, err = strconv.ParseFloat(rate, 64); err != nil || limit.Rate < 0 {
+		return limit, fmt.Errorf("invalid rate limit %s: rate should be non negative number", value)
+	}
+	if burst == "" {
+		limit.Burst = int(math.Ceil(limit.Rate))
+		return limit, nil
+	}
+	if limit.Burst, err = strconv.Atoi(burst); err != nil || limit.Burst < 1 {
+		return limit, fmt.Errorf("invalid rate limit %s: burst should be positive integer", value)
+	}
+	return limit, nil
+}
+
+//ParseRateLimits returns rate limits of clients from client=rate[:burst] values
+func ParseRateLimits(values []string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit, len(values))
+	for _, value := range values {
+		i := strings.Index(value, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid rate limit %s: client=rate[:burst] expected", value)
+		}
+		limit, err := ParseRateLimit(value[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		limits[value[:i]] = limit
+	}
+	return limits, nil
+}
+
+type tokenBucket struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+//take takes token from the bucket, time until the next token is returned if the bucket is empty
+func (b *tokenBucket) take(now time.Time) (bool, time.Duration) {
+	b.tokens = math.Min(float64(b.limit.Burst), b.tokens+now.Sub(b.last).Seconds()*b.limit.Rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / b.limit.Rate * float64(time.Second))
+}
+
+//full reports if the bucket is refilled by now, so dropping it doesn't change the limit
+func (b *tokenBucket) full(now time.Time) bool {
+	return b.tokens+now.Sub(b.last).Seconds()*b.limit.Rate >= float64(b.limit.Burst)
+}
+
+//rateLimiter keeps token bucket of every client creating sessions, clients having own rate limit
+//use it instead of the default one
+type rateLimiter struct {
+	limit   RateLimit
+	limits  map[string]RateLimit
+	now     func() time.Time
+	lock    sync.Mutex
+	buckets map[string]*tokenBucket
+	pruned  time.Time
+}
+
+//newRateLimiter returns nil if neither default nor client rate limits are set
+func newRateLimiter(limit RateLimit, limits map[string]RateLimit) *rateLimiter {
+	if limit.Rate <= 0 && len(limits) == 0 {
+		return nil
+	}
+	return &rateLimiter{
+		limit:   limit,
+		limits:  limits,
+		now:     time.Now,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+//allow takes token of the client, time after which client may retry is returned if its bucket is empty
+func (l *rateLimiter) allow(client string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	limit, ok := l.limits[client]
+	if !ok {
+		limit = l.limit
+	}
+	if limit.Rate <= 0 {
+		return true, 0
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	now := l.now()
+	if now.Sub(l.pruned) >= rateLimiterPruneInterval {
+		for key, bucket := range l.buckets {
+			if bucket.full(now) {
+				delete(l.buckets, key)
+			}
+		}
+		l.pruned = now
+	}
+
+	bucket, ok := l.buckets[client]
+	if !ok || bucket.limit != limit {
+		bucket = &tokenBucket{limit: limit, tokens: float64(limit.Burst), last: now}
+		l.buckets[client] = bucket
+	}
+	return bucket.take(now)
+}
+
+//rateLimitClient returns client session creation is limited for, clients are told apart by authenticated
+//user, tenant and address of the request in that order
+func rateLimitClient(r *http.Request, principal auth.Principal, tenant config.Tenant) string {
+	if principal.Name != "" {
+		return principal.Name
+	}
+	if tenant.Name != "" {
+		return tenant.Name
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+//retryAfter returns Retry-After header value of the delay, in whole seconds
+func retryAfter(delay time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(delay.Seconds())))
+}
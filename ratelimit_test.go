@@ -0,0 +1,119 @@
+package selenosis
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	tests := map[string]struct {
+		values []string
+		limits map[string]RateLimit
+		err    string
+	}{
+		"Verify burst defaults to rate": {
+			values: []string{"ci=0.5", "nightly=2"},
+			limits: map[string]RateLimit{"ci": {Rate: 0.5, Burst: 1}, "nightly": {Rate: 2, Burst: 2}},
+		},
+		"Verify burst is parsed": {
+			values: []string{"ci=1:10"},
+			limits: map[string]RateLimit{"ci": {Rate: 1, Burst: 10}},
+		},
+		"Verify client is required": {
+			values: []string{"=1"},
+			err:    "invalid rate limit =1: client=rate[:burst] expected",
+		},
+		"Verify negative rate is rejected": {
+			values: []string{"ci=-1"},
+			err:    "invalid rate limit -1: rate should be non negative number",
+		},
+		"Verify zero burst is rejected": {
+			values: []string{"ci=1:0"},
+			err:    "invalid rate limit 1:0: burst should be positive integer",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		limits, err := ParseRateLimits(test.values)
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.DeepEqual(t, test.limits, limits)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(RateLimit{Rate: 1, Burst: 2}, map[string]RateLimit{"nightly": {Rate: 0.5, Burst: 1}, "admin": {}})
+	limiter.now = func() time.Time {
+		return now
+	}
+
+	tests := []struct {
+		name    string
+		client  string
+		advance time.Duration
+		allowed bool
+		retry   time.Duration
+	}{
+		{name: "Verify burst is allowed", client: "ci", allowed: true},
+		{name: "Verify burst is allowed up to its size", client: "ci", allowed: true},
+		{name: "Verify client exceeding burst is limited", client: "ci", retry: time.Second},
+		{name: "Verify other client has own bucket", client: "10.0.0.1", allowed: true},
+		{name: "Verify client limit is used instead of default one", client: "nightly", allowed: true},
+		{name: "Verify client limit is enforced", client: "nightly", retry: 2 * time.Second},
+		{name: "Verify client with zero rate is not limited", client: "admin", allowed: true},
+		{name: "Verify client with zero rate is never limited", client: "admin", allowed: true},
+		{name: "Verify bucket is refilled with rate", client: "ci", advance: 500 * time.Millisecond, retry: 500 * time.Millisecond},
+		{name: "Verify refilled token is taken", client: "ci", advance: 500 * time.Millisecond, allowed: true},
+	}
+
+	for _, test := range tests {
+		t.Logf("TC: %s", test.name)
+
+		now = now.Add(test.advance)
+		allowed, retry := limiter.allow(test.client)
+		assert.Equal(t, test.allowed, allowed)
+		assert.Equal(t, test.retry, retry)
+	}
+
+	now = now.Add(rateLimiterPruneInterval)
+	limiter.allow("ci")
+	assert.Equal(t, 1, len(limiter.buckets))
+
+	var disabled *rateLimiter
+	allowed, _ := disabled.allow("ci")
+	assert.Assert(t, allowed)
+	assert.Assert(t, newRateLimiter(RateLimit{}, nil) == nil)
+}
+
+func TestNewSessionRateLimited(t *testing.T) {
+	app := initApp(&PlatformMock{err: errors.New("failed to create pod")})
+	app.sessionRetryCount = 1
+	app.rateLimiter = newRateLimiter(RateLimit{Rate: 0.1, Burst: 1}, nil)
+
+	codes := []int{http.StatusInternalServerError, http.StatusTooManyRequests}
+	for i, code := range codes {
+		req, err := http.NewRequest(http.MethodPost, session, bytes.NewReader([]byte(`{"capabilities":{"alwaysMatch":{"browserName":"chrome","browserVersion":"86.0"}}}`)))
+		assert.NilError(t, err)
+		req.RemoteAddr = "10.0.0.1:52000"
+		rr := httptest.NewRecorder()
+		app.HandleSession(rr, req)
+
+		assert.Equal(t, code, rr.Code)
+		if i == len(codes)-1 {
+			assert.Equal(t, "10", rr.Header().Get("Retry-After"))
+			assert.Assert(t, bytes.Contains(rr.Body.Bytes(), []byte("session rate limit of 10.0.0.1 exceeded, retry in ")))
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package selenosis
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/tools"
+	"github.com/gorilla/mux"
+)
+
+//activityRecordInterval is min time between records of activity of the session on browser pod
+var activityRecordInterval = time.Minute
+
+//activityRecords keeps when activity of sessions was last recorded on browser pods by this replica
+type activityRecords struct {
+	mu       sync.Mutex
+	recorded map[string]time.Time
+}
+
+func newActivityRecords() *activityRecords {
+	return &activityRecords{recorded: make(map[string]time.Time)}
+}
+
+//due reports if activity of the session should be recorded, recorded is time of the last record seen on the pod
+func (a *activityRecords) due(sessionID string, at, recorded time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.recorded[sessionID].After(recorded) {
+		recorded = a.recorded[sessionID]
+	}
+	if at.Sub(recorded) < activityRecordInterval {
+		return false
+	}
+	a.recorded[sessionID] = at
+	return true
+}
+
+//prune forgets records of sessions which are gone
+func (a *activityRecords) prune(present map[string]struct{}) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for sessionID := range a.recorded {
+		if _, ok := present[sessionID]; !ok {
+			delete(a.recorded, sessionID)
+		}
+	}
+}
+
+//recordActivity keeps time of the last activity of the session, activity is also recorded on browser pod at most once
+//per activity record interval, so idle reaper of the leader sees sessions used through other replicas
+func (app *App) recordActivity(sessionID string, at time.Time) {
+	app.stats.Activity().Put(sessionID, at)
+
+	recorder, ok := app.client.(platform.ActivityRecorder)
+	if !ok {
+		return
+	}
+	service, ok := app.stats.Sessions().Get(sessionID)
+	if !ok || service.Relay || !app.activityRecords.due(sessionID, at, service.Active) {
+		return
+	}
+	go func() {
+		if err := recorder.RecordActivity(sessionID, at); err != nil && err != platform.ErrSessionNotFound {
+			app.logger.WithField("session_id", sessionID).Warnf("failed to record session activity: %v", err)
+		}
+	}()
+}
+
+//sessionIdle reports if browser pod got no proxied commands, heartbeats or extensions for longer than idle reaper timeout,
+//sessions requested longer idle timeout are kept for their timeout. Activity is counted from start of the session
+//or of selenosis, whichever is later, activity recorded on the pod by other replicas lags behind by at most activity
+//record interval
+func (app *App) sessionIdle(service platform.Service) bool {
+	if app.idleReaperTimeout <= 0 || service.Relay || service.Status != platform.Running {
+		return false
+	}
+	timeout := app.idleReaperTimeout
+	if requested := idleTimeoutOf(service, 0); requested > timeout {
+		timeout = requested
+	}
+
+	last := service.Started
+	if app.startTime.After(last) {
+		last = app.startTime
+	}
+	if t, ok := app.stats.Activity().Get(service.SessionID); ok && t.After(last) {
+		last = t
+	}
+	if service.Extended.After(last) {
+		last = service.Extended
+	}
+	if service.Active.After(last) {
+		last = service.Active
+	}
+	return time.Since(last) > timeout
+}
+
+//HandleHeartbeat records activity of the session, sidecar reports sessions which are used without
+//commands passing through selenosis, e.g. by VNC clients
+func (app *App) HandleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	sessionID := mux.Vars(r)["sessionId"]
+	if _, ok := app.stats.Sessions().Get(sessionID); !ok {
+		tools.JSONError(w, fmt.Sprintf("unknown session %s", sessionID), http.StatusNotFound)
+		return
+	}
+	app.recordActivity(sessionID, time.Now())
+	w.WriteHeader(http.StatusNoContent)
+}
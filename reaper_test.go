@@ -0,0 +1,114 @@
+package selenosis
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/gorilla/mux"
+	"gotest.tools/assert"
+)
+
+func TestHandleHeartbeat(t *testing.T) {
+	tests := map[string]struct {
+		sessionID string
+		respCode  int
+		activity  bool
+	}{
+		"Verify heartbeat updates session activity": {
+			sessionID: "chrome-85-0-known",
+			respCode:  http.StatusNoContent,
+			activity:  true,
+		},
+		"Verify heartbeat of unknown session is rejected": {
+			sessionID: "chrome-85-0-unknown",
+			respCode:  http.StatusNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		app.stats.Sessions().Put("chrome-85-0-known", platform.Service{SessionID: "chrome-85-0-known"})
+
+		req := httptest.NewRequest(http.MethodPost, "/sessions/"+test.sessionID+"/heartbeat", nil)
+		req = mux.SetURLVars(req, map[string]string{"sessionId": test.sessionID})
+		rr := httptest.NewRecorder()
+		app.HandleHeartbeat(rr, req)
+
+		assert.Equal(t, test.respCode, rr.Code)
+		_, ok := app.stats.Activity().Get(test.sessionID)
+		assert.Equal(t, test.activity, ok)
+	}
+}
+
+type activityRecorderMock struct {
+	*PlatformMock
+	recorded chan time.Time
+}
+
+func (p *activityRecorderMock) RecordActivity(sessionID string, at time.Time) error {
+	p.recorded <- at
+	return nil
+}
+
+func TestRecordActivity(t *testing.T) {
+	const sessionID = "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491"
+
+	tests := map[string]struct {
+		service  platform.Service
+		activity []time.Duration
+		recorded int
+	}{
+		"Verify activity is recorded on the pod": {
+			service:  platform.Service{SessionID: sessionID},
+			activity: []time.Duration{0},
+			recorded: 1,
+		},
+		"Verify activity is recorded once per interval": {
+			service:  platform.Service{SessionID: sessionID},
+			activity: []time.Duration{0, 10 * time.Second, 2 * time.Minute},
+			recorded: 2,
+		},
+		"Verify activity recorded by another replica is not recorded again": {
+			service:  platform.Service{SessionID: sessionID, Active: time.Now().Add(-10 * time.Second)},
+			activity: []time.Duration{0},
+		},
+		"Verify activity of relayed session is not recorded": {
+			service:  platform.Service{SessionID: sessionID, Relay: true},
+			activity: []time.Duration{0},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		mock := &activityRecorderMock{PlatformMock: &PlatformMock{}, recorded: make(chan time.Time, len(test.activity))}
+		app.client = mock
+		app.stats.Sessions().Put(sessionID, test.service)
+
+		now := time.Now()
+		for _, d := range test.activity {
+			app.recordActivity(sessionID, now.Add(d))
+		}
+
+		for i := 0; i < test.recorded; i++ {
+			select {
+			case <-mock.recorded:
+			case <-time.After(time.Second):
+				t.Fatalf("activity was recorded %d times, expected %d", i, test.recorded)
+			}
+		}
+		select {
+		case at := <-mock.recorded:
+			t.Fatalf("unexpected activity record: %v", at)
+		case <-time.After(50 * time.Millisecond):
+		}
+		last, _ := app.stats.Activity().Get(sessionID)
+		assert.Equal(t, now.Add(test.activity[len(test.activity)-1]), last)
+	}
+}
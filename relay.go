@@ -0,0 +1,84 @@
+package selenosis
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+)
+
+//bindRelay replaces session id created by external WebDriver server in new session response
+//with selenosis session id and binds relayed session to remote one
+func (app *App) bindRelay(service platform.Service, msg map[string]interface{}) (platform.Service, error) {
+	relayer, ok := app.client.(platform.Relayer)
+	if !ok {
+		return platform.Service{}, errors.New("platform does not relay sessions")
+	}
+
+	var remoteID string
+	if value, ok := msg["value"].(map[string]interface{}); ok {
+		if id, ok := value["sessionId"].(string); ok && id != "" {
+			remoteID = id
+			value["sessionId"] = service.SessionID
+		}
+	}
+	if id, ok := msg["sessionId"].(string); ok && id != "" {
+		remoteID = id
+		msg["sessionId"] = service.SessionID
+	}
+	if remoteID == "" {
+		return platform.Service{}, errors.New("session id not found in relay response")
+	}
+
+	bound, err := relayer.Bind(service.SessionID, remoteID)
+	if err != nil {
+		return platform.Service{}, err
+	}
+	app.stats.Sessions().Put(bound.SessionID, bound)
+	return bound, nil
+}
+
+//releaseRelay forgets relayed session after client deleted it on external WebDriver server
+func (app *App) releaseRelay(sessionID string) {
+	if relayer, ok := app.client.(platform.Relayer); ok {
+		relayer.Release(sessionID)
+	}
+	app.stats.Sessions().Delete(sessionID)
+}
+
+//relayedSession returns session if it is forwarded to external WebDriver server
+func (app *App) relayedSession(sessionID string) (platform.Service, bool) {
+	service, ok := app.stats.Sessions().Get(sessionID)
+	if !ok || !service.Relay || service.URL == nil {
+		return platform.Service{}, false
+	}
+	return service, true
+}
+
+//relayURL returns command url on external WebDriver server, path up to selenosis session id is replaced with remote session path
+func relayURL(service platform.Service, u *url.URL) *url.URL {
+	target := *u
+	target.Scheme = service.URL.Scheme
+	target.Host = service.URL.Host
+	prefix := "/session/" + service.SessionID
+	if i := strings.Index(u.Path, prefix); i >= 0 {
+		target.Path = service.URL.Path + u.Path[i+len(prefix):]
+	}
+	return &target
+}
+
+//relayIdle reports if relayed session got no commands for session idle timeout, there is no
+//sidecar to stop idle relayed sessions so janitor does it
+func (app *App) relayIdle(service platform.Service) bool {
+	timeout := app.idleTimeoutOf(service)
+	if !service.Relay || timeout <= 0 {
+		return false
+	}
+	last := service.Started
+	if t, ok := app.stats.Activity().Get(service.SessionID); ok {
+		last = t
+	}
+	return time.Since(last) > timeout
+}
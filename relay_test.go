@@ -0,0 +1,101 @@
+package selenosis
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/config"
+	"github.com/alcounit/selenosis/platform"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+)
+
+func TestRelaySession(t *testing.T) {
+	const remoteID = "0a4a5c4c-4f7a-4c36-9a3b-3a4e3d5f5b6c"
+
+	var requests []string
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method {
+		case http.MethodPost:
+			w.Write([]byte(`{"value":{"sessionId":"` + remoteID + `","capabilities":{"browserName":"safari"}}}`))
+		default:
+			w.Write([]byte(`{"value":"Blank"}`))
+		}
+	}))
+	defer remote.Close()
+
+	cfgFile := filepath.Join(t.TempDir(), "browsers.json")
+	ioutil.WriteFile(cfgFile, []byte(`{"safari":{"defaultVersion":"14.0","path":"/","versions":{"14.0":{"relay":"`+remote.URL+`/wd/hub"}}}}`), 0644)
+	browsers, err := config.NewBrowsersConfig(cfgFile)
+	assert.NilError(t, err)
+
+	client := platform.NewRelay(&PlatformMock{})
+	app := New(&logrus.Logger{}, client, browsers, Configuration{
+		SelenosisHost:      "hostname",
+		SidecarPort:        "4445",
+		BrowserWaitTimeout: time.Second,
+		SessionRetryCount:  2,
+	})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/wd/hub/session", app.HandleSession).Methods(http.MethodPost)
+	router.PathPrefix("/wd/hub/session/{sessionId}").HandlerFunc(app.HandleProxy)
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, session, bytes.NewReader([]byte(`{"capabilities":{"alwaysMatch":{"browserName":"safari"}}}`)))
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var created struct {
+		Value struct {
+			SessionID string `json:"sessionId"`
+		} `json:"value"`
+	}
+	assert.NilError(t, json.NewDecoder(rr.Body).Decode(&created))
+	sessionID := created.Value.SessionID
+	assert.Assert(t, sessionID != remoteID)
+
+	service, ok := app.stats.Sessions().Get(sessionID)
+	assert.Assert(t, ok)
+	assert.Assert(t, service.Relay)
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, session+"/"+sessionID+"/title", nil)
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"value":"Blank"}`, rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodDelete, session+"/"+sessionID, nil)
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	assert.DeepEqual(t, []string{
+		"POST /wd/hub/session",
+		"GET /wd/hub/session/" + remoteID + "/title",
+		"DELETE /wd/hub/session/" + remoteID,
+	}, requests)
+
+	_, ok = app.stats.Sessions().Get(sessionID)
+	assert.Assert(t, !ok)
+
+	state, err := client.State()
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(state.Services))
+}
+
+func TestRelayURL(t *testing.T) {
+	service := platform.Service{SessionID: "browser-de44c3c4-1a35-412b-b526-f5da80214491", Relay: true}
+	service.URL, _ = service.URL.Parse("https://mac-mini-1:4444/wd/hub/session/0a4a5c4c")
+
+	u, _ := service.URL.Parse("http://selenosis:4444/wd/hub/session/browser-de44c3c4-1a35-412b-b526-f5da80214491/element/1/click")
+	assert.Equal(t, "https://mac-mini-1:4444/wd/hub/session/0a4a5c4c/element/1/click", relayURL(service, u).String())
+}
@@ -0,0 +1,83 @@
+package selenosis
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/alcounit/selenosis/metrics"
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/tools"
+)
+
+type reloadResult struct {
+	Reloaded []string `json:"reloaded"`
+}
+
+//Reload rereads browsers catalog, tenants, quotas, auth and artifacts configs, every config is validated before it replaces
+//the current one so invalid config keeps previous one in use. Reloaded session limits of tenants
+//are applied to their namespace quotas
+func (app *App) Reload() ([]string, error) {
+	logger := app.logger.WithField("component", "reload")
+
+	reloaded := make([]string, 0)
+	var errs []string
+	reload := func(name string, fn func() error) {
+		if err := fn(); err != nil {
+			logger.Errorf("%s config reload failed: %v", name, err)
+			metrics.ConfigReloads.WithLabelValues(name, "failed").Inc()
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			return
+		}
+		logger.Infof("%s config reloaded", name)
+		metrics.ConfigReloads.WithLabelValues(name, "reloaded").Inc()
+		reloaded = append(reloaded, name)
+	}
+
+	reload("browsers", app.browsers.Reload)
+	if app.tenants != nil {
+		reload("tenants", func() error {
+			if err := app.tenants.Reload(); err != nil {
+				return err
+			}
+			limiter, ok := app.client.(platform.TenantLimiter)
+			if !ok {
+				return nil
+			}
+			for _, tenant := range app.tenants.List() {
+				if err := limiter.SetTenantLimit(tenant.Name, int64(tenant.SessionLimit)); err != nil {
+					return fmt.Errorf("tenant %s: %v", tenant.Name, err)
+				}
+			}
+			return nil
+		})
+	}
+
+	if app.quotas.quotas != nil {
+		reload("quotas", app.quotas.quotas.Reload)
+	}
+	if app.auth != nil {
+		reload("auth", app.auth.Reload)
+	}
+	if app.artifactsConfig != nil {
+		reload("artifacts", app.artifactsConfig.Reload)
+	}
+
+	if len(errs) > 0 {
+		return reloaded, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return reloaded, nil
+}
+
+//HandleReload reloads configs on demand, same as SIGHUP
+func (app *App) HandleReload(w http.ResponseWriter, _ *http.Request) {
+	reloaded, err := app.Reload()
+	if err != nil {
+		tools.JSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reloadResult{Reloaded: reloaded})
+}
@@ -0,0 +1,124 @@
+package selenosis
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/alcounit/selenosis/auth"
+	"github.com/alcounit/selenosis/config"
+	"gotest.tools/assert"
+)
+
+type tenantLimiterMock struct {
+	*PlatformMock
+	limits map[string]int64
+}
+
+func (p *tenantLimiterMock) SetTenantLimit(name string, limit int64) error {
+	p.limits[name] = limit
+	return nil
+}
+
+func TestReload(t *testing.T) {
+	tests := map[string]struct {
+		tenants    string
+		statusCode int
+		respBody   string
+		password   string
+		limits     map[string]int64
+	}{
+		"Verify tenants are reloaded": {
+			tenants:    "contractors:\n  namespace: selenosis-contractors\n  sessionLimit: 8\n  users:\n    acme: rotated\n",
+			statusCode: http.StatusOK,
+			respBody:   `{"reloaded":["browsers","tenants"]}`,
+			password:   "rotated",
+			limits:     map[string]int64{"contractors": 8},
+		},
+		"Verify namespace change keeps previous tenants": {
+			tenants:    "contractors:\n  namespace: selenosis-acme\n  sessionLimit: 8\n  users:\n    acme: rotated\n",
+			statusCode: http.StatusBadRequest,
+			respBody:   `{"code":400,"value":{"message":"tenants: tenant contractors: changing namespace requires restart"}}`,
+			password:   "secret",
+			limits:     map[string]int64{},
+		},
+		"Verify invalid tenants keep previous tenants": {
+			tenants:    "contractors:\n  namespace: selenosis-contractors\n  sessionLimit: 0\n",
+			statusCode: http.StatusBadRequest,
+			respBody:   `{"code":400,"value":{"message":"tenants: tenant contractors: session limit should be greater than 0"}}`,
+			password:   "secret",
+			limits:     map[string]int64{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		tmp, err := ioutil.TempFile("", "tenants.yaml")
+		assert.NilError(t, err)
+		tmp.WriteString("contractors:\n  namespace: selenosis-contractors\n  sessionLimit: 5\n  users:\n    acme: secret\n")
+		tmp.Close()
+
+		tenants, err := config.NewTenantsConfig(tmp.Name())
+		assert.NilError(t, err)
+
+		client := &tenantLimiterMock{PlatformMock: &PlatformMock{}, limits: map[string]int64{}}
+		app := initApp(client.PlatformMock)
+		app.client = client
+		app.tenants = tenants
+
+		assert.NilError(t, ioutil.WriteFile(tmp.Name(), []byte(test.tenants), 0644))
+
+		rr := httptest.NewRecorder()
+		app.HandleReload(rr, httptest.NewRequest(http.MethodPost, "/admin/reload", nil))
+		os.Remove(tmp.Name())
+
+		assert.Equal(t, test.statusCode, rr.Code)
+		assert.Equal(t, test.respBody, string(bytes.TrimSpace(rr.Body.Bytes())))
+		assert.DeepEqual(t, test.limits, client.limits)
+
+		tenant, ok := tenants.Authenticate("acme", test.password)
+		assert.Assert(t, ok)
+		assert.Equal(t, "selenosis-contractors", tenant.Namespace)
+	}
+}
+
+func TestReloadAuthAndArtifacts(t *testing.T) {
+	authFile, err := ioutil.TempFile("", "auth.yaml")
+	assert.NilError(t, err)
+	defer os.Remove(authFile.Name())
+	authFile.WriteString("tokens:\n  ci: ci-token\n")
+	authFile.Close()
+
+	artifactsFile, err := ioutil.TempFile("", "artifacts.yaml")
+	assert.NilError(t, err)
+	defer os.Remove(artifactsFile.Name())
+	artifactsFile.WriteString("url: s3://artifacts\n")
+	artifactsFile.Close()
+
+	app := initApp(&PlatformMock{})
+	app.auth, err = auth.NewFromFile(authFile.Name())
+	assert.NilError(t, err)
+	app.artifactsConfig, err = config.NewArtifactsConfig(artifactsFile.Name())
+	assert.NilError(t, err)
+
+	assert.NilError(t, ioutil.WriteFile(authFile.Name(), []byte("tokens:\n  qa: qa-token\n"), 0644))
+	assert.NilError(t, ioutil.WriteFile(artifactsFile.Name(), []byte("url: s3://rotated\n"), 0644))
+
+	rr := httptest.NewRecorder()
+	app.HandleReload(rr, httptest.NewRequest(http.MethodPost, "/admin/reload", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"reloaded":["browsers","auth","artifacts"]}`, string(bytes.TrimSpace(rr.Body.Bytes())))
+
+	req := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+	req.Header.Set("Authorization", "Bearer qa-token")
+	principal, err := app.principal(req)
+	assert.NilError(t, err)
+	assert.Equal(t, "qa", principal.Name)
+
+	policy, _ := app.artifactsPolicy("")
+	assert.Equal(t, "s3://rotated", policy.URL)
+}
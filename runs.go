@@ -0,0 +1,129 @@
+package selenosis
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/alcounit/selenosis/audit"
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/storage"
+	"github.com/alcounit/selenosis/tools"
+	"github.com/alcounit/selenosis/webhook"
+	"github.com/gorilla/mux"
+)
+
+//runIDKey is a key of runId capability in session labels
+const runIDKey = "runId"
+
+type runInfo struct {
+	RunID         string        `json:"id"`
+	Total         int           `json:"total"`
+	Running       int           `json:"running"`
+	Pending       int           `json:"pending"`
+	Commands      int           `json:"commands"`
+	CommandErrors int           `json:"commandErrors"`
+	Sessions      []sessionInfo `json:"sessions"`
+}
+
+type runDeleted struct {
+	RunID   string   `json:"id"`
+	Deleted []string `json:"deleted"`
+}
+
+func runSessions(stats *storage.Storage, runID string) []platform.Service {
+	var sessions []platform.Service
+	for _, s := range stats.Sessions().List() {
+		if s.Labels[runIDKey] == runID {
+			sessions = append(sessions, s)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Started.Before(sessions[j].Started)
+	})
+	return sessions
+}
+
+//notifyRunFinished sends webhook if deleted session was the last session of its run
+func notifyRunFinished(stats *storage.Storage, notifier *webhook.Notifier, service platform.Service) {
+	runID := service.Labels[runIDKey]
+	if runID == "" || len(runSessions(stats, runID)) > 0 {
+		return
+	}
+	notifier.Notify(webhook.Event{
+		Type:      webhook.RunFinished,
+		RunID:     runID,
+		SessionID: service.SessionID,
+	})
+}
+
+//HandleRun shows sessions and aggregate stats of the run
+func (app *App) HandleRun(w http.ResponseWriter, r *http.Request) {
+	runID := mux.Vars(r)["runId"]
+
+	sessions := runSessions(app.stats, runID)
+	if len(sessions) == 0 {
+		tools.JSONError(w, fmt.Sprintf("run %s not found", runID), http.StatusNotFound)
+		return
+	}
+
+	run := runInfo{RunID: runID, Sessions: make([]sessionInfo, 0, len(sessions))}
+	for _, s := range sessions {
+		info := app.sessionInfo(s)
+		run.Total++
+		switch s.Status {
+		case platform.Running:
+			run.Running++
+		case platform.Pending:
+			run.Pending++
+		}
+		run.Commands += info.Commands
+		run.CommandErrors += info.Errors
+		run.Sessions = append(run.Sessions, info)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(run)
+}
+
+//HandleDeleteRun deletes all sessions of the run
+func (app *App) HandleDeleteRun(w http.ResponseWriter, r *http.Request) {
+	runID := mux.Vars(r)["runId"]
+	logger := app.logger.WithField("run_id", runID)
+
+	sessions := runSessions(app.stats, runID)
+	if len(sessions) == 0 {
+		tools.JSONError(w, fmt.Sprintf("run %s not found", runID), http.StatusNotFound)
+		return
+	}
+	//run is deleted only by principal allowed to delete each of its sessions
+	for _, s := range sessions {
+		if code, err := app.authorizeSession(r, s.SessionID); err != nil {
+			authError(w, err, code)
+			return
+		}
+	}
+
+	result := runDeleted{RunID: runID, Deleted: make([]string, 0, len(sessions))}
+	var failed []string
+	for _, s := range sessions {
+		if err := app.client.Service().Delete(s.SessionID); err != nil {
+			logger.Errorf("failed to delete session %s: %v", s.SessionID, err)
+			failed = append(failed, s.SessionID)
+			continue
+		}
+		app.auditSessionRequest(r, audit.SessionDeleteRequested, s.SessionID, fmt.Sprintf("run %s deleted", runID))
+		result.Deleted = append(result.Deleted, s.SessionID)
+	}
+
+	if len(failed) > 0 {
+		tools.JSONError(w, fmt.Sprintf("failed to delete sessions: %s", strings.Join(failed, ", ")), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Infof("run deleted, sessions: %d", len(result.Deleted))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
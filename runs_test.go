@@ -0,0 +1,159 @@
+package selenosis
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/webhook"
+	"github.com/gorilla/mux"
+	"gotest.tools/assert"
+)
+
+var runServices = []platform.Service{
+	{SessionID: "chrome-85-0-1", Status: platform.Running, Started: time.Now().Add(-time.Minute), Labels: map[string]string{"runId": "build-42"}},
+	{SessionID: "chrome-85-0-2", Status: platform.Pending, Started: time.Now(), Labels: map[string]string{"runId": "build-42"}},
+	{SessionID: "chrome-85-0-3", Status: platform.Running, Started: time.Now(), Labels: map[string]string{"runId": "build-43"}},
+}
+
+func TestHandleRun(t *testing.T) {
+	tests := map[string]struct {
+		runID      string
+		statusCode int
+		run        runInfo
+	}{
+		"Verify run with sessions": {
+			runID:      "build-42",
+			statusCode: http.StatusOK,
+			run:        runInfo{RunID: "build-42", Total: 2, Running: 1, Pending: 1, Commands: 2, CommandErrors: 1},
+		},
+		"Verify unknown run": {
+			runID:      "build-44",
+			statusCode: http.StatusNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		for _, s := range runServices {
+			app.stats.Sessions().Put(s.SessionID, s)
+		}
+		app.stats.Commands().Add("chrome-85-0-1", time.Second, false)
+		app.stats.Commands().Add("chrome-85-0-1", time.Second, true)
+
+		req, err := http.NewRequest(http.MethodGet, "/runs/"+test.runID, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req = mux.SetURLVars(req, map[string]string{"runId": test.runID})
+
+		rr := httptest.NewRecorder()
+		app.HandleRun(rr, req)
+
+		assert.Equal(t, test.statusCode, rr.Code)
+		if test.statusCode != http.StatusOK {
+			continue
+		}
+
+		var run runInfo
+		err = json.NewDecoder(rr.Body).Decode(&run)
+		assert.NilError(t, err)
+		assert.Equal(t, 2, len(run.Sessions))
+		assert.Equal(t, "chrome-85-0-1", run.Sessions[0].SessionID)
+		run.Sessions = nil
+		assert.DeepEqual(t, test.run, run)
+	}
+}
+
+func TestHandleDeleteRun(t *testing.T) {
+	tests := map[string]struct {
+		runID      string
+		statusCode int
+		deleted    []string
+	}{
+		"Verify run sessions deleted": {
+			runID:      "build-42",
+			statusCode: http.StatusOK,
+			deleted:    []string{"chrome-85-0-1", "chrome-85-0-2"},
+		},
+		"Verify unknown run": {
+			runID:      "build-44",
+			statusCode: http.StatusNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		client := &PlatformMock{}
+		app := initApp(client)
+		for _, s := range runServices {
+			app.stats.Sessions().Put(s.SessionID, s)
+		}
+
+		req, err := http.NewRequest(http.MethodDelete, "/runs/"+test.runID, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req = mux.SetURLVars(req, map[string]string{"runId": test.runID})
+
+		rr := httptest.NewRecorder()
+		app.HandleDeleteRun(rr, req)
+
+		assert.Equal(t, test.statusCode, rr.Code)
+		assert.DeepEqual(t, test.deleted, client.deleted)
+	}
+}
+
+func TestNotifyRunFinished(t *testing.T) {
+	tests := map[string]struct {
+		service platform.Service
+		notify  bool
+	}{
+		"Verify webhook is sent after last session of run": {
+			service: platform.Service{SessionID: "chrome-85-0-3", Labels: map[string]string{"runId": "build-43"}},
+			notify:  true,
+		},
+		"Verify webhook is not sent while run has sessions": {
+			service: platform.Service{SessionID: "chrome-85-0-2", Labels: map[string]string{"runId": "build-42"}},
+		},
+		"Verify webhook is not sent for session without run": {
+			service: platform.Service{SessionID: "chrome-85-0-4"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		events := make(chan webhook.Event, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var event webhook.Event
+			json.NewDecoder(r.Body).Decode(&event)
+			events <- event
+		}))
+
+		app := initApp(&PlatformMock{})
+		for _, s := range runServices {
+			if s.SessionID != test.service.SessionID {
+				app.stats.Sessions().Put(s.SessionID, s)
+			}
+		}
+
+		notifyRunFinished(app.stats, webhook.New(app.logger, webhook.Config{URLs: []string{srv.URL}}), test.service)
+
+		select {
+		case event := <-events:
+			assert.Assert(t, test.notify)
+			assert.Equal(t, webhook.RunFinished, event.Type)
+			assert.Equal(t, test.service.Labels["runId"], event.RunID)
+		case <-time.After(200 * time.Millisecond):
+			assert.Assert(t, !test.notify)
+		}
+		srv.Close()
+	}
+}
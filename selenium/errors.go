@@ -0,0 +1,17 @@
+package selenium
+
+//WebDriver error codes of error responses, see https://www.w3.org/TR/webdriver/#errors
+const (
+	ErrInvalidArgument   = "invalid argument"
+	ErrInvalidSessionID  = "invalid session id"
+	ErrSessionNotCreated = "session not created"
+	ErrTimeout           = "timeout"
+	ErrUnknown           = "unknown error"
+)
+
+//Error is value of WebDriver error response
+type Error struct {
+	Error      string `json:"error"`
+	Message    string `json:"message"`
+	Stacktrace string `json:"stacktrace"`
+}
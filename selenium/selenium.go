@@ -1,9 +1,12 @@
 package selenium
 
+import "encoding/json"
+
 //Capabilities ...
 type Capabilities struct {
 	BrowserName           string            `json:"browserName,omitempty"`
 	DeviceName            string            `json:"deviceName,omitempty"`
+	AppiumDeviceName      string            `json:"appium:deviceName,omitempty"`
 	BrowserVersion        string            `json:"version,omitempty"`
 	W3CBrowserVersion     string            `json:"browserVersion,omitempty"`
 	Platform              string            `json:"platform,omitempty"`
@@ -17,6 +20,12 @@ type Capabilities struct {
 	VideoScreenSize       string            `json:"videoScreenSize,omitempty"`
 	VideoFrameRate        uint16            `json:"videoFrameRate,omitempty"`
 	VideoCodec            string            `json:"videoCodec,omitempty"`
+	VideoPreset           string            `json:"videoPreset,omitempty"`
+	VideoCRF              int               `json:"videoCrf,omitempty"`
+	VideoOverlay          bool              `json:"videoOverlay,omitempty"`
+	UploadVideo           string            `json:"uploadVideo,omitempty"`
+	Workspace             bool              `json:"enableWorkspace,omitempty"`
+	ShareWorkspace        bool              `json:"shareWorkspace,omitempty"`
 	LogName               string            `json:"logName,omitempty"`
 	TestName              string            `json:"name,omitempty"`
 	TimeZone              string            `json:"timeZone,omitempty"`
@@ -28,6 +37,60 @@ type Capabilities struct {
 	DNSServers            []string          `json:"dnsServers,omitempty"`
 	Labels                map[string]string `json:"labels,omitempty"`
 	SessionTimeout        string            `json:"sessionTimeout,omitempty"`
+	RunID                 string            `json:"runId,omitempty"`
+	Architecture          string            `json:"architecture,omitempty"`
+	Options               *SelenosisOptions `json:"selenosis:options,omitempty"`
+}
+
+//SelenosisOptions are vendor capabilities of selenosis
+type SelenosisOptions struct {
+	Namespace        string            `json:"namespace,omitempty"`
+	SessionTimeout   string            `json:"sessionTimeout,omitempty"`
+	Env              []string          `json:"env,omitempty"`
+	Args             []string          `json:"args,omitempty"`
+	ProfileConfigMap string            `json:"profileConfigMap,omitempty"`
+	ProfileSecret    string            `json:"profileSecret,omitempty"`
+	Profile          string            `json:"profile,omitempty"`
+	CPURequest       string            `json:"cpuRequest,omitempty"`
+	MemoryRequest    string            `json:"memoryRequest,omitempty"`
+	CPULimit         string            `json:"cpuLimit,omitempty"`
+	MemoryLimit      string            `json:"memoryLimit,omitempty"`
+	Labels           map[string]string `json:"labels,omitempty"`
+	CaptureHAR       bool              `json:"captureHAR,omitempty"`
+	HostAliases      []HostAlias       `json:"hostAliases,omitempty"`
+	DNS              *DNSOptions       `json:"dns,omitempty"`
+	Files            []SessionFile     `json:"files,omitempty"`
+}
+
+//HostAlias maps hostnames to ip in hosts file of the browser
+type HostAlias struct {
+	IP        string   `json:"ip"`
+	Hostnames []string `json:"hostnames"`
+}
+
+//DNSOptions are nameservers and search domains the browser resolves names with
+type DNSOptions struct {
+	Nameservers []string `json:"nameservers,omitempty"`
+	Searches    []string `json:"searches,omitempty"`
+}
+
+//SessionFile is file put into files directory of the browser before it starts, file is downloaded from URL
+//or all keys of ConfigMap are copied. Plain string is read as URL
+type SessionFile struct {
+	URL       string `json:"url,omitempty"`
+	Name      string `json:"name,omitempty"`
+	ConfigMap string `json:"configMap,omitempty"`
+}
+
+//UnmarshalJSON reads session file from URL string or file object
+func (f *SessionFile) UnmarshalJSON(b []byte) error {
+	var u string
+	if err := json.Unmarshal(b, &u); err == nil {
+		*f = SessionFile{URL: u}
+		return nil
+	}
+	type file SessionFile
+	return json.Unmarshal(b, (*file)(f))
 }
 
 //ValidateCapabilities ...
@@ -41,11 +104,142 @@ func (c *Capabilities) ValidateCapabilities() {
 	}
 }
 
+//GetNamespace returns namespace requested with selenosis:options capability
+func (c *Capabilities) GetNamespace() string {
+	if c.Options == nil {
+		return ""
+	}
+	return c.Options.Namespace
+}
+
+//GetSessionTimeout returns idle timeout requested with selenosis:options capability
+func (c *Capabilities) GetSessionTimeout() string {
+	if c.Options == nil {
+		return ""
+	}
+	return c.Options.SessionTimeout
+}
+
+//GetEnv returns browser environment requested with selenosis:options capability, variables are in NAME=value form
+func (c *Capabilities) GetEnv() []string {
+	if c.Options == nil {
+		return nil
+	}
+	return c.Options.Env
+}
+
+//GetArgs returns browser arguments requested with selenosis:options capability
+func (c *Capabilities) GetArgs() []string {
+	if c.Options == nil {
+		return nil
+	}
+	return c.Options.Args
+}
+
+//GetProfileConfigMap returns name of ConfigMap with browser profile requested with selenosis:options capability
+func (c *Capabilities) GetProfileConfigMap() string {
+	if c.Options == nil {
+		return ""
+	}
+	return c.Options.ProfileConfigMap
+}
+
+//GetProfileSecret returns name of Secret with browser profile requested with selenosis:options capability
+func (c *Capabilities) GetProfileSecret() string {
+	if c.Options == nil {
+		return ""
+	}
+	return c.Options.ProfileSecret
+}
+
+//GetProfile returns base64 encoded zip of browser profile passed inline with selenosis:options capability
+func (c *Capabilities) GetProfile() string {
+	if c.Options == nil {
+		return ""
+	}
+	return c.Options.Profile
+}
+
+//GetCPURequest returns cpu request of browser container requested with selenosis:options capability
+func (c *Capabilities) GetCPURequest() string {
+	if c.Options == nil {
+		return ""
+	}
+	return c.Options.CPURequest
+}
+
+//GetMemoryRequest returns memory request of browser container requested with selenosis:options capability
+func (c *Capabilities) GetMemoryRequest() string {
+	if c.Options == nil {
+		return ""
+	}
+	return c.Options.MemoryRequest
+}
+
+//GetCPULimit returns cpu limit of browser container requested with selenosis:options capability
+func (c *Capabilities) GetCPULimit() string {
+	if c.Options == nil {
+		return ""
+	}
+	return c.Options.CPULimit
+}
+
+//GetMemoryLimit returns memory limit of browser container requested with selenosis:options capability
+func (c *Capabilities) GetMemoryLimit() string {
+	if c.Options == nil {
+		return ""
+	}
+	return c.Options.MemoryLimit
+}
+
+//GetLabels returns browser pod labels requested with selenosis:options capability
+func (c *Capabilities) GetLabels() map[string]string {
+	if c.Options == nil {
+		return nil
+	}
+	return c.Options.Labels
+}
+
+//GetCaptureHAR reports if network archive of the session is requested with selenosis:options capability
+func (c *Capabilities) GetCaptureHAR() bool {
+	if c.Options == nil {
+		return false
+	}
+	return c.Options.CaptureHAR
+}
+
+//GetHostAliases returns host aliases requested with selenosis:options capability
+func (c *Capabilities) GetHostAliases() []HostAlias {
+	if c.Options == nil {
+		return nil
+	}
+	return c.Options.HostAliases
+}
+
+//GetDNS returns DNS settings requested with selenosis:options capability
+func (c *Capabilities) GetDNS() *DNSOptions {
+	if c.Options == nil {
+		return nil
+	}
+	return c.Options.DNS
+}
+
+//GetFiles returns files requested with selenosis:options capability
+func (c *Capabilities) GetFiles() []SessionFile {
+	if c.Options == nil {
+		return nil
+	}
+	return c.Options.Files
+}
+
 //GetBrowserName ...
 func (c *Capabilities) GetBrowserName() string {
 	browserName := c.BrowserName
 	if browserName != "" {
 		return browserName
 	}
-	return c.DeviceName
+	if c.DeviceName != "" {
+		return c.DeviceName
+	}
+	return c.AppiumDeviceName
 }
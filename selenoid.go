@@ -0,0 +1,112 @@
+package selenosis
+
+import (
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+)
+
+const (
+	//SelenosisStatusFormat renders /status in selenosis format
+	SelenosisStatusFormat = "selenosis"
+	//SelenoidStatusFormat renders /status in selenoid format expected by selenoid-ui and ggr
+	SelenoidStatusFormat = "selenoid"
+)
+
+//selenoidQuota is the quota sessions without tenant are reported under, the same selenoid uses for
+//sessions of unknown users
+const selenoidQuota = "unknown"
+
+type selenoidState struct {
+	Total    int              `json:"total"`
+	Used     int              `json:"used"`
+	Queued   int              `json:"queued"`
+	Pending  int              `json:"pending"`
+	Browsers selenoidBrowsers `json:"browsers"`
+}
+
+//selenoidBrowsers are sessions by browser name, version and quota
+type selenoidBrowsers map[string]map[string]map[string]*selenoidSessions
+
+type selenoidSessions struct {
+	Count    int               `json:"count"`
+	Sessions []selenoidSession `json:"sessions"`
+}
+
+type selenoidSession struct {
+	ID      string       `json:"id"`
+	VNC     bool         `json:"vnc"`
+	Screen  string       `json:"screen"`
+	Caps    selenoidCaps `json:"caps"`
+	Started time.Time    `json:"started"`
+}
+
+type selenoidCaps struct {
+	BrowserName      string `json:"browserName"`
+	Version          string `json:"version"`
+	ScreenResolution string `json:"screenResolution"`
+	VNC              bool   `json:"enableVNC"`
+	TestName         string `json:"name"`
+}
+
+//selenoidStatus returns registry in selenoid status format, every configured browser version is listed,
+//sessions are grouped by tenant as selenoid groups them by quota. Pending sessions are counted as used
+func (app *App) selenoidStatus() selenoidState {
+	state := selenoidState{
+		Total:    app.sessionLimit,
+		Queued:   app.queue.Len(),
+		Browsers: make(selenoidBrowsers),
+	}
+	for name, versions := range app.browsers.GetBrowserVersions() {
+		state.Browsers[name] = make(map[string]map[string]*selenoidSessions, len(versions))
+		for _, version := range versions {
+			state.Browsers[name][version] = make(map[string]*selenoidSessions)
+		}
+	}
+
+	for _, s := range app.stats.Sessions().List() {
+		switch s.Status {
+		case platform.Running:
+		case platform.Pending:
+			state.Pending++
+		default:
+			continue
+		}
+		state.Used++
+
+		browserName, version := s.Labels["browserName"], s.Labels["browserVersion"]
+		if state.Browsers[browserName] == nil {
+			state.Browsers[browserName] = make(map[string]map[string]*selenoidSessions)
+		}
+		if state.Browsers[browserName][version] == nil {
+			state.Browsers[browserName][version] = make(map[string]*selenoidSessions)
+		}
+		quota := s.Labels["tenant"]
+		if quota == "" {
+			quota = selenoidQuota
+		}
+		sessions, ok := state.Browsers[browserName][version][quota]
+		if !ok {
+			sessions = &selenoidSessions{}
+			state.Browsers[browserName][version][quota] = sessions
+		}
+
+		vnc := s.Labels["ENABLE_VNC"] == "true"
+		screen := s.Labels["SCREEN_RESOLUTION"]
+		sessions.Count++
+		sessions.Sessions = append(sessions.Sessions, selenoidSession{
+			ID:     s.SessionID,
+			VNC:    vnc,
+			Screen: screen,
+			Caps: selenoidCaps{
+				BrowserName:      browserName,
+				Version:          version,
+				ScreenResolution: screen,
+				VNC:              vnc,
+				TestName:         s.Labels["testName"],
+			},
+			Started: s.Started,
+		})
+	}
+	return state
+}
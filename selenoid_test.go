@@ -0,0 +1,53 @@
+package selenosis
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"gotest.tools/assert"
+)
+
+func TestHandleSelenoidStatus(t *testing.T) {
+	started := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		sessions []platform.Service
+		respBody string
+	}{
+		"Verify configured browsers are listed without sessions": {
+			respBody: `{"total":10,"used":0,"queued":0,"pending":0,"browsers":{"chrome":{"68.0":{},"86.0":{}},"firefox":{"45.0":{},"47.0":{}},"opera":{"66.0":{},"71.0":{}}}}`,
+		},
+		"Verify sessions are grouped by browser, version and tenant": {
+			sessions: []platform.Service{
+				{SessionID: "chrome-86-0-running", Status: platform.Running, Started: started,
+					Labels: map[string]string{"browserName": "chrome", "browserVersion": "86.0", "ENABLE_VNC": "true", "SCREEN_RESOLUTION": "1920x1080x24", "testName": "login"}},
+				{SessionID: "firefox-47-0-pending", Status: platform.Pending, Started: started,
+					Labels: map[string]string{"browserName": "firefox", "browserVersion": "47.0", "tenant": "contractors"}},
+				{SessionID: "chrome-86-0-failed", Status: platform.Unknown, Started: started,
+					Labels: map[string]string{"browserName": "chrome", "browserVersion": "86.0"}},
+			},
+			respBody: `{"total":10,"used":2,"queued":0,"pending":1,"browsers":{"chrome":{"68.0":{},"86.0":{"unknown":{"count":1,"sessions":[{"id":"chrome-86-0-running","vnc":true,"screen":"1920x1080x24","caps":{"browserName":"chrome","version":"86.0","screenResolution":"1920x1080x24","enableVNC":true,"name":"login"},"started":"2020-10-01T12:00:00Z"}]}}},` +
+				`"firefox":{"45.0":{},"47.0":{"contractors":{"count":1,"sessions":[{"id":"firefox-47-0-pending","vnc":false,"screen":"","caps":{"browserName":"firefox","version":"47.0","screenResolution":"","enableVNC":false,"name":""},"started":"2020-10-01T12:00:00Z"}]}}},"opera":{"66.0":{},"71.0":{}}}}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		app.sessionLimit = 10
+		app.statusFormat = SelenoidStatusFormat
+		for _, s := range test.sessions {
+			app.stats.Sessions().Put(s.SessionID, s)
+		}
+
+		rr := httptest.NewRecorder()
+		app.HandleStatus(rr, httptest.NewRequest(http.MethodGet, status, nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, test.respBody, string(bytes.TrimSpace(rr.Body.Bytes())))
+	}
+}
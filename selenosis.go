@@ -1,11 +1,17 @@
 package selenosis
 
 import (
+	"crypto/tls"
+	"net/http"
 	"time"
 
+	"github.com/alcounit/selenosis/audit"
+	"github.com/alcounit/selenosis/auth"
 	"github.com/alcounit/selenosis/config"
+	"github.com/alcounit/selenosis/metrics"
 	"github.com/alcounit/selenosis/platform"
 	"github.com/alcounit/selenosis/storage"
+	"github.com/alcounit/selenosis/webhook"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -18,7 +24,47 @@ type Configuration struct {
 	SessionRetryCount  int
 	BrowserWaitTimeout time.Duration
 	SessionIdleTimeout time.Duration
+	MaxSessionTimeout  time.Duration
 	BuildVersion       string
+	JanitorInterval    time.Duration
+	OrphanGracePeriod  time.Duration
+	IdleReaperTimeout  time.Duration
+	MaxExtensions      int
+	MaxSessionLifetime time.Duration
+	Webhook            webhook.Config
+	Tenants            *config.TenantsConfig
+	Auth               *auth.Authenticator
+	Quotas             *config.QuotasConfig
+	Artifacts          platform.Artifacts
+	ArtifactsConfig    *config.ArtifactsConfig
+	Audit              *audit.Auditor
+	SoakInterval       time.Duration
+	SoakWindow         int
+	SoakAlertGrowth    float64
+	BurstEndpoint      string
+	BurstThreshold     float64
+	BurstQueueWait     time.Duration
+	WorkspaceRetention time.Duration
+	QueueSize          int
+	QueueWait          time.Duration
+	WarmPoolInterval   time.Duration
+	Leader             Leader
+	StatusFormat       string
+	GgrRegion          string
+	GgrHost            string
+	AdmissionHooks     []AdmissionHook
+	ResourceBounds     platform.ResourceBounds
+	DebugImage         string
+	ProxyFailureLimit  int
+	ProxyTransport     ProxyTransport
+	ProxyTLS           *tls.Config
+	SessionRateLimit   RateLimit
+	SessionRateLimits  map[string]RateLimit
+	HARRetention       time.Duration
+	Peers              string
+	SessionDNS         platform.SessionDNS
+	BatchWorkers       int
+	BatchMaxSessions   int
 }
 
 //App ...
@@ -32,9 +78,54 @@ type App struct {
 	sessionLimit       int
 	sessionRetryCount  int
 	sessionIdleTimeout time.Duration
+	maxSessionTimeout  time.Duration
 	browserWaitTimeout time.Duration
 	buildVersion       string
+	janitorInterval    time.Duration
+	orphanGracePeriod  time.Duration
+	idleReaperTimeout  time.Duration
+	maxExtensions      int
+	maxSessionLifetime time.Duration
+	startTime          time.Time
+	notifier           *webhook.Notifier
+	tenants            *config.TenantsConfig
+	auth               *auth.Authenticator
+	artifacts          platform.Artifacts
+	artifactsConfig    *config.ArtifactsConfig
+	auditor            *audit.Auditor
 	stats              *storage.Storage
+	soakInterval       time.Duration
+	soakWindow         int
+	soakAlertGrowth    float64
+	burstEndpoint      string
+	burstThreshold     float64
+	burstQueueWait     time.Duration
+	workspaceRetention time.Duration
+	idleRuns           map[string]time.Time
+	queue              *sessionQueue
+	quotas             *quotaManager
+	warmPoolInterval   time.Duration
+	leader             Leader
+	statusFormat       string
+	ggrRegion          string
+	ggrHostPort        string
+	admissionHooks     []AdmissionHook
+	sessionEvents      *sessionEvents
+	resourceBounds     platform.ResourceBounds
+	debugImage         string
+	proxyFailureLimit  int
+	proxyTransport     http.RoundTripper
+	proxyFailures      *proxyFailures
+	activityRecords    *activityRecords
+	inflight           *inflightSessions
+	rateLimiter        *rateLimiter
+	harRetention       time.Duration
+	peers              string
+	sessionDNS         platform.SessionDNS
+	batchWorkers       int
+	batchMaxSessions   int
+	unhealthy          int32
+	draining           int32
 }
 
 //New ...
@@ -55,6 +146,10 @@ func New(logger *log.Logger, client platform.Platform, browsers *config.Browsers
 		storage.Workers().Put(worker.Name, worker)
 	}
 
+	if cfg.ProxyTLS != nil {
+		setProxyTLS(cfg.SidecarPort, cfg.ProxyTLS)
+	}
+
 	limit := cfg.SessionLimit
 	currentTotal := func() int64 {
 		return int64(storage.Workers().Len() + limit)
@@ -78,6 +173,15 @@ func New(logger *log.Logger, client platform.Platform, browsers *config.Browsers
 
 	logger.Infof("current cluster state: sessions - %d, workers - %d, session limit - %d", storage.Sessions().Len(), storage.Workers().Len(), limit)
 
+	notifier := webhook.New(logger, cfg.Webhook)
+	events := newSessionEvents()
+	quotas := newQuotaManager(cfg.Quotas)
+	quotas.publish(storage.Sessions().List(), browsers.Browsers())
+	//every replica watches the same pods, webhooks and audit events derived from the watch are sent by the leader only
+	leading := func() bool {
+		return cfg.Leader == nil || cfg.Leader.IsLeader()
+	}
+
 	ch := client.Watch()
 	go func() {
 		for {
@@ -89,11 +193,30 @@ func New(logger *log.Logger, client platform.Platform, browsers *config.Browsers
 					switch event.Type {
 					case platform.Added:
 						storage.Sessions().Put(service.SessionID, service)
+						if service.Burst {
+							metrics.BurstActiveSessions.Inc()
+						}
 					case platform.Updated:
+						previous, _ := storage.Sessions().Get(service.SessionID)
+						recordLostSession(logger, storage, notifier, previous, service, leading())
 						storage.Sessions().Put(service.SessionID, service)
 					case platform.Deleted:
+						previous, _ := storage.Sessions().Get(service.SessionID)
+						recordLostSession(logger, storage, notifier, previous, service, leading())
 						storage.Sessions().Delete(service.SessionID)
+						if leading() {
+							notifySessionDeleted(storage, notifier, service, cfg.SessionIdleTimeout)
+							notifyRunFinished(storage, notifier, service)
+							cfg.Audit.Log(sessionEvent(audit.SessionTerminated, service))
+						}
+						storage.Activity().Delete(service.SessionID)
+						storage.Commands().Delete(service.SessionID)
+						if service.Burst {
+							metrics.BurstActiveSessions.Dec()
+						}
 					}
+					quotas.publish(storage.Sessions().List(), browsers.Browsers())
+					events.publish(event)
 
 				case platform.Worker:
 					worker := event.PlatformObject.(platform.Worker)
@@ -163,7 +286,50 @@ func New(logger *log.Logger, client platform.Platform, browsers *config.Browsers
 		sessionRetryCount:  cfg.SessionRetryCount,
 		browserWaitTimeout: cfg.BrowserWaitTimeout,
 		sessionIdleTimeout: cfg.SessionIdleTimeout,
+		maxSessionTimeout:  cfg.MaxSessionTimeout,
 		buildVersion:       cfg.BuildVersion,
+		janitorInterval:    cfg.JanitorInterval,
+		orphanGracePeriod:  cfg.OrphanGracePeriod,
+		idleReaperTimeout:  cfg.IdleReaperTimeout,
+		maxExtensions:      cfg.MaxExtensions,
+		maxSessionLifetime: cfg.MaxSessionLifetime,
+		startTime:          time.Now(),
+		notifier:           notifier,
+		tenants:            cfg.Tenants,
+		auth:               cfg.Auth,
+		artifacts:          cfg.Artifacts,
+		artifactsConfig:    cfg.ArtifactsConfig,
+		auditor:            cfg.Audit,
 		stats:              storage,
+		soakInterval:       cfg.SoakInterval,
+		soakWindow:         cfg.SoakWindow,
+		soakAlertGrowth:    cfg.SoakAlertGrowth,
+		burstEndpoint:      cfg.BurstEndpoint,
+		burstThreshold:     cfg.BurstThreshold,
+		burstQueueWait:     cfg.BurstQueueWait,
+		workspaceRetention: cfg.WorkspaceRetention,
+		idleRuns:           make(map[string]time.Time),
+		queue:              newSessionQueue(cfg.QueueSize, cfg.QueueWait),
+		quotas:             quotas,
+		warmPoolInterval:   cfg.WarmPoolInterval,
+		leader:             cfg.Leader,
+		statusFormat:       cfg.StatusFormat,
+		ggrRegion:          cfg.GgrRegion,
+		ggrHostPort:        cfg.GgrHost,
+		admissionHooks:     cfg.AdmissionHooks,
+		sessionEvents:      events,
+		resourceBounds:     cfg.ResourceBounds,
+		debugImage:         cfg.DebugImage,
+		proxyFailureLimit:  cfg.ProxyFailureLimit,
+		proxyTransport:     newProxyTransport(cfg.ProxyTransport),
+		proxyFailures:      newProxyFailures(),
+		activityRecords:    newActivityRecords(),
+		inflight:           newInflightSessions(),
+		rateLimiter:        newRateLimiter(cfg.SessionRateLimit, cfg.SessionRateLimits),
+		harRetention:       cfg.HARRetention,
+		peers:              cfg.Peers,
+		sessionDNS:         cfg.SessionDNS,
+		batchWorkers:       cfg.BatchWorkers,
+		batchMaxSessions:   cfg.BatchMaxSessions,
 	}
 }
@@ -0,0 +1,189 @@
+package selenosis
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alcounit/selenosis/metrics"
+	"github.com/alcounit/selenosis/platform"
+)
+
+//upstreamConns counts open connections of proxy transport
+var upstreamConns int64
+
+//countingTransport returns copy of default transport counting its open connections
+func countingTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = countingDial(t.DialContext)
+	return t
+}
+
+//countingDial wraps dial of proxy transport, connections to seleniferous get TLS if it is enabled and
+//open connections are counted
+func countingDial(dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		if conn, err = dialProxy(ctx, conn, addr); err != nil {
+			return nil, err
+		}
+		atomic.AddInt64(&upstreamConns, 1)
+		return &countedConn{Conn: conn}, nil
+	}
+}
+
+type countedConn struct {
+	net.Conn
+	once sync.Once
+}
+
+func (c *countedConn) Close() error {
+	c.once.Do(func() {
+		atomic.AddInt64(&upstreamConns, -1)
+	})
+	return c.Conn.Close()
+}
+
+//RunSoakMonitor periodically samples sizes of subsystems prone to leaks until stop is closed
+func (app *App) RunSoakMonitor(stop <-chan struct{}) {
+	if app.soakInterval <= 0 {
+		return
+	}
+
+	detector := newGrowthDetector(app.soakWindow, app.soakAlertGrowth)
+
+	ticker := time.NewTicker(app.soakInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			app.sampleSoak(detector)
+		}
+	}
+}
+
+func (app *App) sampleSoak(detector *growthDetector) {
+	logger := app.logger.WithField("component", "soak")
+
+	series := app.soakSeries()
+	if detector.growth <= 0 {
+		return
+	}
+
+	names := make([]string, 0, len(series))
+	for name := range series {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if detector.add(name, series[name]) {
+			metrics.GrowthAlerts.WithLabelValues(name).Inc()
+			logger.Warnf("%s keeps growing: %.0f, baseline: %.0f", name, series[name], detector.baseline[name])
+		}
+	}
+}
+
+//soakSeries samples subsystem sizes and exports them as metrics
+func (app *App) soakSeries() map[string]float64 {
+	series := make(map[string]float64)
+
+	goroutines := float64(runtime.NumGoroutine())
+	metrics.Goroutines.Set(goroutines)
+	series["goroutines"] = goroutines
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	metrics.HeapBytes.Set(float64(mem.HeapAlloc))
+	series["heap_bytes"] = float64(mem.HeapAlloc)
+
+	conns := float64(atomic.LoadInt64(&upstreamConns))
+	metrics.UpstreamConnections.Set(conns)
+	series["upstream_connections"] = conns
+
+	for registry, size := range map[string]int{
+		"sessions": app.stats.Sessions().Len(),
+		"workers":  app.stats.Workers().Len(),
+		"activity": app.stats.Activity().Len(),
+		"commands": app.stats.Commands().Len(),
+	} {
+		metrics.RegistryEntries.WithLabelValues(registry).Set(float64(size))
+		series["registry_"+registry] = float64(size)
+	}
+
+	if c, ok := app.client.(platform.CacheSizer); ok {
+		for resource, size := range c.CacheSize() {
+			metrics.InformerCacheObjects.WithLabelValues(resource).Set(float64(size))
+			series["informer_"+resource] = float64(size)
+		}
+	}
+
+	return series
+}
+
+//growthDetector flags series which minimum over the latest window exceeds baseline by growth ratio,
+//baseline is the minimum over the first window, minimums are compared so load spikes are not taken for leaks
+type growthDetector struct {
+	window   int
+	growth   float64
+	samples  map[string][]float64
+	baseline map[string]float64
+	alerted  map[string]bool
+}
+
+func newGrowthDetector(window int, growth float64) *growthDetector {
+	if window < 1 {
+		window = 1
+	}
+	return &growthDetector{
+		window:   window,
+		growth:   growth,
+		samples:  make(map[string][]float64),
+		baseline: make(map[string]float64),
+		alerted:  make(map[string]bool),
+	}
+}
+
+//add records sample of the series and reports if series started growing, series is reported once until it shrinks
+func (d *growthDetector) add(series string, value float64) bool {
+	samples := append(d.samples[series], value)
+	if len(samples) > d.window {
+		samples = samples[len(samples)-d.window:]
+	}
+	d.samples[series] = samples
+	if len(samples) < d.window {
+		return false
+	}
+
+	min := samples[0]
+	for _, v := range samples[1:] {
+		min = math.Min(min, v)
+	}
+
+	baseline, ok := d.baseline[series]
+	if !ok {
+		d.baseline[series] = min
+		return false
+	}
+
+	if min <= math.Max(baseline, 1)*(1+d.growth) {
+		d.alerted[series] = false
+		return false
+	}
+	if d.alerted[series] {
+		return false
+	}
+	d.alerted[series] = true
+	return true
+}
@@ -0,0 +1,56 @@
+package selenosis
+
+import (
+	"testing"
+
+	"github.com/alcounit/selenosis/platform"
+	"gotest.tools/assert"
+)
+
+func TestGrowthDetector(t *testing.T) {
+	tests := map[string]struct {
+		samples []float64
+		alerts  []bool
+	}{
+		"Verify no alert before baseline window is filled": {
+			samples: []float64{10, 100},
+			alerts:  []bool{false, false},
+		},
+		"Verify spikes are not reported": {
+			samples: []float64{10, 10, 100, 10, 100, 10},
+			alerts:  []bool{false, false, false, false, false, false},
+		},
+		"Verify sustained growth is reported once": {
+			samples: []float64{10, 10, 30, 30, 40, 40},
+			alerts:  []bool{false, false, false, true, false, false},
+		},
+		"Verify growth is reported again after series shrinks": {
+			samples: []float64{10, 10, 30, 30, 10, 30, 30},
+			alerts:  []bool{false, false, false, true, false, false, true},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		detector := newGrowthDetector(2, 0.5)
+		var alerts []bool
+		for _, v := range test.samples {
+			alerts = append(alerts, detector.add("goroutines", v))
+		}
+		assert.DeepEqual(t, test.alerts, alerts)
+	}
+}
+
+func TestSoakSeries(t *testing.T) {
+	app := initApp(&PlatformMock{})
+	app.stats.Sessions().Put("chrome-85-0-running", platform.Service{SessionID: "chrome-85-0-running"})
+	app.stats.Commands().Add("chrome-85-0-running", 0, false)
+
+	series := app.soakSeries()
+
+	assert.Equal(t, float64(1), series["registry_sessions"])
+	assert.Equal(t, float64(1), series["registry_commands"])
+	assert.Equal(t, float64(0), series["registry_activity"])
+	assert.Assert(t, series["goroutines"] > 0)
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"sync"
+	"time"
 
 	"github.com/alcounit/selenosis/platform"
 	"github.com/alcounit/selenosis/tools"
@@ -9,10 +10,13 @@ import (
 
 type sessions struct {
 	m map[string]platform.Service
+	sync.RWMutex
 }
 
 //Put ...
 func (s *sessions) Put(sessionID string, service platform.Service) {
+	s.Lock()
+	defer s.Unlock()
 	if sessionID != "" {
 		s.m[sessionID] = service
 	}
@@ -20,26 +24,46 @@ func (s *sessions) Put(sessionID string, service platform.Service) {
 
 //Delete ...
 func (s *sessions) Delete(sessionID string) {
+	s.Lock()
+	defer s.Unlock()
 	delete(s.m, sessionID)
 }
 
-//List ...
-func (s *sessions) List() map[string]platform.Service {
-	return s.m
+//Get ...
+func (s *sessions) Get(sessionID string) (platform.Service, bool) {
+	s.RLock()
+	defer s.RUnlock()
+	service, ok := s.m[sessionID]
+	return service, ok
+}
 
+//List returns copy of stored sessions
+func (s *sessions) List() map[string]platform.Service {
+	s.RLock()
+	defer s.RUnlock()
+	m := make(map[string]platform.Service, len(s.m))
+	for k, v := range s.m {
+		m[k] = v
+	}
+	return m
 }
 
 //Len ...
 func (s *sessions) Len() int {
+	s.RLock()
+	defer s.RUnlock()
 	return len(s.m)
 }
 
 type workers struct {
 	m map[string]platform.Worker
+	sync.RWMutex
 }
 
 //Put ...
 func (w *workers) Put(name string, worker platform.Worker) {
+	w.Lock()
+	defer w.Unlock()
 	if name != "" {
 		w.m[name] = worker
 	}
@@ -47,11 +71,15 @@ func (w *workers) Put(name string, worker platform.Worker) {
 
 //Delete ...
 func (w *workers) Delete(name string) {
+	w.Lock()
+	defer w.Unlock()
 	delete(w.m, name)
 }
 
 //List ...
 func (w *workers) List() []platform.Worker {
+	w.RLock()
+	defer w.RUnlock()
 	var l []platform.Worker
 	for _, w := range w.m {
 		w.Uptime = tools.TimeElapsed(w.Started)
@@ -64,21 +92,235 @@ func (w *workers) List() []platform.Worker {
 
 //Len ...
 func (s *workers) Len() int {
+	s.RLock()
+	defer s.RUnlock()
+	return len(s.m)
+}
+
+type activity struct {
+	m map[string]time.Time
+	sync.RWMutex
+}
+
+//Put ...
+func (a *activity) Put(sessionID string, t time.Time) {
+	a.Lock()
+	defer a.Unlock()
+	if sessionID != "" {
+		a.m[sessionID] = t
+	}
+}
+
+//Get returns time of the last proxied command of the session
+func (a *activity) Get(sessionID string) (time.Time, bool) {
+	a.RLock()
+	defer a.RUnlock()
+	t, ok := a.m[sessionID]
+	return t, ok
+}
+
+//Delete ...
+func (a *activity) Delete(sessionID string) {
+	a.Lock()
+	defer a.Unlock()
+	delete(a.m, sessionID)
+}
+
+//Len ...
+func (a *activity) Len() int {
+	a.RLock()
+	defer a.RUnlock()
+	return len(a.m)
+}
+
+//StrandedSession is a session of unhealthy backend or a session which lost its browser pod, it can be
+//retried on healthy backend. Lost is reason browser pod of the session was lost
+type StrandedSession struct {
+	platform.Service
+	Since time.Time
+	Lost  string
+}
+
+type stranded struct {
+	m map[string]StrandedSession
+	sync.RWMutex
+}
+
+//Put ...
+func (s *stranded) Put(sessionID string, session StrandedSession) {
+	s.Lock()
+	defer s.Unlock()
+	if sessionID != "" {
+		s.m[sessionID] = session
+	}
+}
+
+//Get ...
+func (s *stranded) Get(sessionID string) (StrandedSession, bool) {
+	s.RLock()
+	defer s.RUnlock()
+	session, ok := s.m[sessionID]
+	return session, ok
+}
+
+//Delete ...
+func (s *stranded) Delete(sessionID string) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.m, sessionID)
+}
+
+//List ...
+func (s *stranded) List() map[string]StrandedSession {
+	s.RLock()
+	defer s.RUnlock()
+	m := make(map[string]StrandedSession, len(s.m))
+	for k, v := range s.m {
+		m[k] = v
+	}
+	return m
+}
+
+//Len ...
+func (s *stranded) Len() int {
+	s.RLock()
+	defer s.RUnlock()
 	return len(s.m)
 }
 
+//HAR is network archive of finished session captured before its browser was deleted
+type HAR struct {
+	Content  []byte
+	Owner    string
+	Tenant   string
+	Captured time.Time
+}
+
+type hars struct {
+	m map[string]HAR
+	sync.RWMutex
+}
+
+//Put ...
+func (h *hars) Put(sessionID string, har HAR) {
+	h.Lock()
+	defer h.Unlock()
+	if sessionID != "" {
+		h.m[sessionID] = har
+	}
+}
+
+//Get ...
+func (h *hars) Get(sessionID string) (HAR, bool) {
+	h.RLock()
+	defer h.RUnlock()
+	har, ok := h.m[sessionID]
+	return har, ok
+}
+
+//Prune deletes archives captured before the time
+func (h *hars) Prune(before time.Time) {
+	h.Lock()
+	defer h.Unlock()
+	for sessionID, har := range h.m {
+		if har.Captured.Before(before) {
+			delete(h.m, sessionID)
+		}
+	}
+}
+
+//Purge deletes archives of the tenant captured before the time, zero time deletes all archives of the tenant.
+//Number and size of deleted archives are returned
+func (h *hars) Purge(tenant string, before time.Time) (int, int64) {
+	h.Lock()
+	defer h.Unlock()
+	var count int
+	var size int64
+	for sessionID, har := range h.m {
+		if har.Tenant != tenant || (!before.IsZero() && !har.Captured.Before(before)) {
+			continue
+		}
+		delete(h.m, sessionID)
+		count++
+		size += int64(len(har.Content))
+	}
+	return count, size
+}
+
+//Len ...
+func (h *hars) Len() int {
+	h.RLock()
+	defer h.RUnlock()
+	return len(h.m)
+}
+
+//CommandStats describes WebDriver commands proxied to session
+type CommandStats struct {
+	Commands int
+	Errors   int
+	Duration time.Duration
+}
+
+type commands struct {
+	m map[string]CommandStats
+	sync.RWMutex
+}
+
+//Add records proxied command of the session
+func (c *commands) Add(sessionID string, duration time.Duration, failed bool) {
+	c.Lock()
+	defer c.Unlock()
+	if sessionID == "" {
+		return
+	}
+	stats := c.m[sessionID]
+	stats.Commands++
+	stats.Duration += duration
+	if failed {
+		stats.Errors++
+	}
+	c.m[sessionID] = stats
+}
+
+//Get ...
+func (c *commands) Get(sessionID string) (CommandStats, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	stats, ok := c.m[sessionID]
+	return stats, ok
+}
+
+//Delete ...
+func (c *commands) Delete(sessionID string) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.m, sessionID)
+}
+
+//Len ...
+func (c *commands) Len() int {
+	c.RLock()
+	defer c.RUnlock()
+	return len(c.m)
+}
+
 type quota struct {
 	w *workers
 	q platform.Quota
+	sync.RWMutex
 }
 
 //Put ...
 func (q *quota) Put(quota platform.Quota) {
+	q.Lock()
+	defer q.Unlock()
 	q.q = quota
 }
 
 //Put ...
 func (q *quota) Get() platform.Quota {
+	q.RLock()
+	defer q.RUnlock()
 	return q.q
 }
 
@@ -87,6 +329,10 @@ type Storage struct {
 	sessions *sessions
 	workers  *workers
 	quota    *quota
+	activity *activity
+	commands *commands
+	stranded *stranded
+	hars     *hars
 	sync.RWMutex
 }
 
@@ -95,10 +341,18 @@ func New() *Storage {
 	sessions := &sessions{m: make(map[string]platform.Service)}
 	workers := &workers{m: make(map[string]platform.Worker)}
 	quota := &quota{w: workers}
+	activity := &activity{m: make(map[string]time.Time)}
+	commands := &commands{m: make(map[string]CommandStats)}
+	stranded := &stranded{m: make(map[string]StrandedSession)}
+	hars := &hars{m: make(map[string]HAR)}
 	return &Storage{
 		sessions: sessions,
 		workers:  workers,
 		quota:    quota,
+		activity: activity,
+		commands: commands,
+		stranded: stranded,
+		hars:     hars,
 	}
 }
 
@@ -122,3 +376,31 @@ func (s *Storage) Quota() *quota {
 	defer s.Unlock()
 	return s.quota
 }
+
+//Activity ...
+func (s *Storage) Activity() *activity {
+	s.Lock()
+	defer s.Unlock()
+	return s.activity
+}
+
+//Commands ...
+func (s *Storage) Commands() *commands {
+	s.Lock()
+	defer s.Unlock()
+	return s.commands
+}
+
+//Stranded ...
+func (s *Storage) Stranded() *stranded {
+	s.Lock()
+	defer s.Unlock()
+	return s.stranded
+}
+
+//HARs ...
+func (s *Storage) HARs() *hars {
+	s.Lock()
+	defer s.Unlock()
+	return s.hars
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"testing"
+	"time"
 
 	"github.com/alcounit/selenosis/platform"
 	"gotest.tools/assert"
@@ -125,3 +126,141 @@ func TestList(t *testing.T) {
 		assert.Equal(t, test.strg.Sessions().Len(), test.len)
 	}
 }
+
+func TestActivity(t *testing.T) {
+	tests := map[string]struct {
+		strg    *Storage
+		session string
+		touched time.Time
+		found   bool
+	}{
+		"Verify session activity stored": {
+			strg:    New(),
+			session: "selenoid-vnc-chrome-85-0-c3fa5fa2-ea17-4b16-adec-97f7d535ee93",
+			touched: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			found:   true,
+		},
+		"Verify activity is not stored for empty session": {
+			strg:    New(),
+			session: "",
+			touched: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		test.strg.Activity().Put(test.session, test.touched)
+
+		touched, ok := test.strg.Activity().Get(test.session)
+		assert.Equal(t, test.found, ok)
+		if ok {
+			assert.Equal(t, test.touched, touched)
+		}
+
+		test.strg.Activity().Delete(test.session)
+		_, ok = test.strg.Activity().Get(test.session)
+		assert.Equal(t, false, ok)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	tests := map[string]struct {
+		durations []time.Duration
+		failed    []bool
+		stats     CommandStats
+	}{
+		"Verify successful commands counted": {
+			durations: []time.Duration{time.Second, 2 * time.Second},
+			failed:    []bool{false, false},
+			stats:     CommandStats{Commands: 2, Duration: 3 * time.Second},
+		},
+		"Verify failed commands counted": {
+			durations: []time.Duration{time.Second, time.Second, time.Second},
+			failed:    []bool{false, true, true},
+			stats:     CommandStats{Commands: 3, Errors: 2, Duration: 3 * time.Second},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		strg := New()
+		session := "selenoid-vnc-chrome-85-0-c3fa5fa2-ea17-4b16-adec-97f7d535ee93"
+		for i, d := range test.durations {
+			strg.Commands().Add(session, d, test.failed[i])
+		}
+
+		stats, ok := strg.Commands().Get(session)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, test.stats, stats)
+
+		strg.Commands().Delete(session)
+		_, ok = strg.Commands().Get(session)
+		assert.Equal(t, false, ok)
+	}
+}
+
+func TestHARs(t *testing.T) {
+	strg := New()
+	captured := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	strg.HARs().Put("chrome-85-0-1", HAR{Content: []byte(`{"log":{}}`), Captured: captured})
+	strg.HARs().Put("chrome-85-0-2", HAR{Content: []byte(`{"log":{}}`), Captured: captured.Add(time.Hour)})
+
+	strg.HARs().Prune(captured.Add(time.Minute))
+
+	_, ok := strg.HARs().Get("chrome-85-0-1")
+	assert.Equal(t, false, ok)
+	har, ok := strg.HARs().Get("chrome-85-0-2")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, `{"log":{}}`, string(har.Content))
+	assert.Equal(t, 1, strg.HARs().Len())
+}
+
+func TestPurgeHARs(t *testing.T) {
+	captured := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		tenant    string
+		before    time.Time
+		count     int
+		size      int64
+		remaining []string
+	}{
+		"Verify archives of the tenant captured before the time are purged": {
+			tenant:    "team-a",
+			before:    captured.Add(time.Minute),
+			count:     1,
+			size:      10,
+			remaining: []string{"chrome-85-0-2", "chrome-85-0-3"},
+		},
+		"Verify all archives of the tenant are purged without time": {
+			tenant:    "team-a",
+			count:     2,
+			size:      20,
+			remaining: []string{"chrome-85-0-3"},
+		},
+		"Verify archives of sessions without tenant are purged": {
+			count:     1,
+			size:      10,
+			remaining: []string{"chrome-85-0-1", "chrome-85-0-2"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		strg := New()
+		strg.HARs().Put("chrome-85-0-1", HAR{Content: []byte(`{"log":{}}`), Tenant: "team-a", Captured: captured})
+		strg.HARs().Put("chrome-85-0-2", HAR{Content: []byte(`{"log":{}}`), Tenant: "team-a", Captured: captured.Add(time.Hour)})
+		strg.HARs().Put("chrome-85-0-3", HAR{Content: []byte(`{"log":{}}`), Captured: captured})
+
+		count, size := strg.HARs().Purge(test.tenant, test.before)
+		assert.Equal(t, test.count, count)
+		assert.Equal(t, test.size, size)
+		for _, sessionID := range test.remaining {
+			_, ok := strg.HARs().Get(sessionID)
+			assert.Assert(t, ok, sessionID)
+		}
+		assert.Equal(t, len(test.remaining), strg.HARs().Len())
+	}
+}
@@ -0,0 +1,85 @@
+package selenosis
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/alcounit/selenosis/config"
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/tools"
+)
+
+//tenant returns tenant of authenticated user, empty tenant is returned for anonymous requests and for
+//users of auth config
+func (app *App) tenant(r *http.Request) (config.Tenant, error) {
+	if app.tenants == nil {
+		return config.Tenant{}, nil
+	}
+
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return config.Tenant{}, nil
+	}
+
+	tenant, ok := app.tenants.Authenticate(user, password)
+	if !ok {
+		if app.auth != nil {
+			if _, err := app.auth.Authenticate(r); err == nil {
+				return config.Tenant{}, nil
+			}
+		}
+		return config.Tenant{}, errors.New("invalid credentials")
+	}
+	return tenant, nil
+}
+
+var errNamespaceForbidden = errors.New("namespace is not allowed")
+
+//namespaceTenant returns tenant owning namespace requested with selenosis:options capability. Tenants
+//having users are available to their users only, tenants without users can be selected by anyone
+func (app *App) namespaceTenant(tenant config.Tenant, namespace string) (config.Tenant, error) {
+	if namespace == "" || namespace == tenant.Namespace {
+		return tenant, nil
+	}
+	if app.tenants == nil {
+		return config.Tenant{}, errors.New("namespace selection requires tenants config")
+	}
+
+	selected, ok := app.tenants.ByNamespace(namespace)
+	if !ok {
+		return config.Tenant{}, fmt.Errorf("unknown namespace %s", namespace)
+	}
+	if len(selected.Users) > 0 {
+		return config.Tenant{}, fmt.Errorf("%w: %s", errNamespaceForbidden, namespace)
+	}
+	return selected, nil
+}
+
+//artifactsOf returns destinations of session artifacts, tenant destinations replace defaults to keep tenant data separated
+func (app *App) artifactsOf(tenant config.Tenant) platform.Artifacts {
+	if !tenant.Artifacts.IsEmpty() {
+		return tenant.Artifacts
+	}
+	return app.defaultArtifacts()
+}
+
+//defaultArtifacts returns artifacts settings of sessions without own destinations, settings of artifacts config
+//replace ones of flags
+func (app *App) defaultArtifacts() platform.Artifacts {
+	if app.artifactsConfig != nil {
+		return app.artifactsConfig.Get()
+	}
+	return app.artifacts
+}
+
+//sessionHost returns host:port of session pod, known sessions are resolved from their url as pods of tenants run in other namespaces
+func (app *App) sessionHost(sessionID, port string) string {
+	if service, ok := app.stats.Sessions().Get(sessionID); ok && service.URL != nil {
+		if host, _, err := net.SplitHostPort(service.URL.Host); err == nil {
+			return net.JoinHostPort(host, port)
+		}
+	}
+	return tools.BuildHostPort(sessionID, app.serviceName, port)
+}
@@ -0,0 +1,170 @@
+package selenosis
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/alcounit/selenosis/config"
+	"github.com/alcounit/selenosis/platform"
+	"gotest.tools/assert"
+)
+
+func TestNewSessionTenantAuth(t *testing.T) {
+	tests := map[string]struct {
+		user       string
+		password   string
+		statusCode int
+		respBody   string
+	}{
+		"Verify session is not created with invalid credentials": {
+			user:       "acme",
+			password:   "wrong",
+			statusCode: http.StatusUnauthorized,
+			respBody:   `{"value":{"error":"session not created","message":"invalid credentials","stacktrace":""}}`,
+		},
+		"Verify session is not created for unknown user": {
+			user:       "unknown",
+			password:   "secret",
+			statusCode: http.StatusUnauthorized,
+			respBody:   `{"value":{"error":"session not created","message":"invalid credentials","stacktrace":""}}`,
+		},
+	}
+
+	tmp, err := ioutil.TempFile("", "tenants.yaml")
+	assert.NilError(t, err)
+	defer os.Remove(tmp.Name())
+	tmp.WriteString("contractors:\n  namespace: selenosis-contractors\n  sessionLimit: 5\n  users:\n    acme: secret\n")
+	tmp.Close()
+
+	tenants, err := config.NewTenantsConfig(tmp.Name())
+	assert.NilError(t, err)
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		app.tenants = tenants
+
+		req, err := http.NewRequest(http.MethodPost, session, bytes.NewReader([]byte(`{"desiredCapabilities":{"browserName":"chrome"}}`)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.SetBasicAuth(test.user, test.password)
+
+		rr := httptest.NewRecorder()
+		app.HandleSession(rr, req)
+
+		assert.Equal(t, test.statusCode, rr.Code)
+		assert.Equal(t, `Basic realm="selenosis"`, rr.Header().Get("WWW-Authenticate"))
+		assert.Equal(t, test.respBody, string(bytes.TrimSpace(rr.Body.Bytes())))
+	}
+}
+
+func TestNamespaceTenant(t *testing.T) {
+	tests := map[string]struct {
+		user      string
+		namespace string
+		tenant    string
+		err       string
+	}{
+		"Verify tenant of authenticated user is kept without namespace capability": {
+			user:   "acme",
+			tenant: "contractors",
+		},
+		"Verify tenant user can request own namespace": {
+			user:      "acme",
+			namespace: "selenosis-contractors",
+			tenant:    "contractors",
+		},
+		"Verify anonymous session selects namespace of tenant without users": {
+			namespace: "selenosis-qa",
+			tenant:    "qa",
+		},
+		"Verify tenant user can select namespace of tenant without users": {
+			user:      "acme",
+			namespace: "selenosis-qa",
+			tenant:    "qa",
+		},
+		"Verify anonymous session can't select namespace of tenant with users": {
+			namespace: "selenosis-contractors",
+			err:       "namespace is not allowed: selenosis-contractors",
+		},
+		"Verify unknown namespace is rejected": {
+			namespace: "kube-system",
+			err:       "unknown namespace kube-system",
+		},
+	}
+
+	tmp, err := ioutil.TempFile("", "tenants.yaml")
+	assert.NilError(t, err)
+	defer os.Remove(tmp.Name())
+	tmp.WriteString("contractors:\n  namespace: selenosis-contractors\n  sessionLimit: 5\n  users:\n    acme: secret\nqa:\n  namespace: selenosis-qa\n  sessionLimit: 2\n")
+	tmp.Close()
+
+	tenants, err := config.NewTenantsConfig(tmp.Name())
+	assert.NilError(t, err)
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		app.tenants = tenants
+
+		var tenant config.Tenant
+		if test.user != "" {
+			tenant, _ = tenants.Authenticate(test.user, "secret")
+		}
+
+		selected, err := app.namespaceTenant(tenant, test.namespace)
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, test.tenant, selected.Name)
+	}
+}
+
+func TestNewSessionNamespaceWithoutTenants(t *testing.T) {
+	app := initApp(&PlatformMock{})
+
+	rr := httptest.NewRecorder()
+	app.HandleSession(rr, httptest.NewRequest(http.MethodPost, session, bytes.NewReader([]byte(`{"desiredCapabilities":{"browserName":"chrome","selenosis:options":{"namespace":"selenosis-qa"}}}`))))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, `{"value":{"error":"invalid argument","message":"namespace selection requires tenants config","stacktrace":""}}`, string(bytes.TrimSpace(rr.Body.Bytes())))
+}
+
+func TestSessionHost(t *testing.T) {
+	tests := map[string]struct {
+		service *platform.Service
+		host    string
+	}{
+		"Verify host of unknown session": {
+			host: "chrome-85-0-1.selenosis:5900",
+		},
+		"Verify host of tenant session": {
+			service: &platform.Service{
+				SessionID: "chrome-85-0-1",
+				URL:       &url.URL{Scheme: "http", Host: "chrome-85-0-1.seleniferous.selenosis-contractors:4445"},
+			},
+			host: "chrome-85-0-1.seleniferous.selenosis-contractors:5900",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		if test.service != nil {
+			app.stats.Sessions().Put(test.service.SessionID, *test.service)
+		}
+
+		assert.Equal(t, test.host, app.sessionHost("chrome-85-0-1", "5900"))
+	}
+}
@@ -0,0 +1,41 @@
+package selenosis
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/selenium"
+)
+
+//sessionTimeoutLabel keeps idle timeout requested for the session
+const sessionTimeoutLabel = "sessionTimeout"
+
+//sessionTimeout returns idle timeout requested with selenosis:options capability, timeout is capped
+//by max session timeout if it is set. Zero is returned when timeout is not requested
+func (app *App) sessionTimeout(caps selenium.Capabilities) (time.Duration, error) {
+	requested := caps.GetSessionTimeout()
+	if requested == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(requested)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid session timeout %s", requested)
+	}
+	if app.maxSessionTimeout > 0 && timeout > app.maxSessionTimeout {
+		return app.maxSessionTimeout, nil
+	}
+	return timeout, nil
+}
+
+//idleTimeoutOf returns idle timeout of the session, timeout requested for the session replaces default one
+func (app *App) idleTimeoutOf(service platform.Service) time.Duration {
+	return idleTimeoutOf(service, app.sessionIdleTimeout)
+}
+
+func idleTimeoutOf(service platform.Service, def time.Duration) time.Duration {
+	if timeout, err := time.ParseDuration(service.Labels[sessionTimeoutLabel]); err == nil && timeout > 0 {
+		return timeout
+	}
+	return def
+}
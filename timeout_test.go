@@ -0,0 +1,74 @@
+package selenosis
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/selenium"
+	"gotest.tools/assert"
+)
+
+func TestSessionTimeout(t *testing.T) {
+	tests := map[string]struct {
+		requested string
+		max       time.Duration
+		timeout   time.Duration
+		err       string
+	}{
+		"Verify default timeout is used when not requested": {},
+		"Verify requested timeout is used": {
+			requested: "30m",
+			max:       time.Hour,
+			timeout:   30 * time.Minute,
+		},
+		"Verify requested timeout is capped": {
+			requested: "3h",
+			max:       time.Hour,
+			timeout:   time.Hour,
+		},
+		"Verify requested timeout is not capped without max": {
+			requested: "3h",
+			timeout:   3 * time.Hour,
+		},
+		"Verify invalid timeout is rejected": {
+			requested: "forever",
+			err:       "invalid session timeout forever",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		app.maxSessionTimeout = test.max
+
+		timeout, err := app.sessionTimeout(selenium.Capabilities{Options: &selenium.SelenosisOptions{SessionTimeout: test.requested}})
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, test.timeout, timeout)
+	}
+}
+
+func TestNewSessionInvalidTimeout(t *testing.T) {
+	app := initApp(&PlatformMock{})
+
+	rr := httptest.NewRecorder()
+	app.HandleSession(rr, httptest.NewRequest(http.MethodPost, session, bytes.NewReader([]byte(`{"desiredCapabilities":{"browserName":"chrome","selenosis:options":{"sessionTimeout":"-1m"}}}`))))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, `{"value":{"error":"invalid argument","message":"invalid session timeout -1m","stacktrace":""}}`, string(bytes.TrimSpace(rr.Body.Bytes())))
+}
+
+func TestIdleTimeoutOf(t *testing.T) {
+	app := initApp(&PlatformMock{})
+
+	assert.Equal(t, 600*time.Millisecond, app.idleTimeoutOf(platform.Service{}))
+	assert.Equal(t, 2*time.Hour, app.idleTimeoutOf(platform.Service{Labels: map[string]string{sessionTimeoutLabel: "2h0m0s"}}))
+}
@@ -0,0 +1,201 @@
+package selenosis
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//CertReloader keeps certificate, key and CA bundle read from files, files are reread once any of them
+//changes, so certificates rotated by cert-manager or kubelet secret sync are used without restart
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	lock    sync.RWMutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	modTime time.Time
+}
+
+//NewCertReloader reads certificate and key files, CA bundle is optional
+func NewCertReloader(certFile, keyFile, caFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile, caFile: caFile}
+	if _, err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+//Run checks files every interval until stop is closed, onReload is called with result of every reload
+//of changed files. Failed reload keeps previous certificate in use
+func (r *CertReloader) Run(stop <-chan struct{}, interval time.Duration, onReload func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if reloaded, err := r.reload(); reloaded || err != nil {
+				onReload(err)
+			}
+		}
+	}
+}
+
+//reload rereads files if any of them was modified since the last read, result tells whether files were read
+func (r *CertReloader) reload() (bool, error) {
+	var modTime time.Time
+	for _, file := range []string{r.certFile, r.keyFile, r.caFile} {
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return false, fmt.Errorf("failed to read %s: %v", file, err)
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+
+	r.lock.RLock()
+	unchanged := r.cert != nil && modTime.Equal(r.modTime)
+	r.lock.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load certificate: %v", err)
+	}
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		bundle, err := ioutil.ReadFile(r.caFile)
+		if err != nil {
+			return false, fmt.Errorf("failed to read %s: %v", r.caFile, err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(bundle) {
+			return false, fmt.Errorf("no certificates found in %s", r.caFile)
+		}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.cert = &cert
+	r.pool = pool
+	r.modTime = modTime
+	return true, nil
+}
+
+func (r *CertReloader) certificate() *tls.Certificate {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.cert
+}
+
+func (r *CertReloader) caPool() *x509.CertPool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.pool
+}
+
+//ServerConfig returns TLS config of server presenting the current certificate
+func (r *CertReloader) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return r.certificate(), nil
+		},
+	}
+}
+
+//ClientConfig returns TLS config of client presenting the current certificate and verifying server
+//certificate against the current CA bundle, system roots are used without bundle. Server certificates
+//are verified for serverName, host client connects to if it is empty
+func (r *CertReloader) ClientConfig(serverName string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return r.certificate(), nil
+		},
+		//static RootCAs can't follow rotated bundle, so server certificate is verified by VerifyConnection
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("server presented no certificate")
+			}
+			name := serverName
+			if name == "" {
+				name = cs.ServerName
+			}
+			opts := x509.VerifyOptions{
+				DNSName:       name,
+				Roots:         r.caPool(),
+				Intermediates: x509.NewCertPool(),
+			}
+			for _, cert := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+			_, err := cs.PeerCertificates[0].Verify(opts)
+			return err
+		},
+	}
+}
+
+//proxyTLS holds *proxyTLSConfig of connections to seleniferous once mTLS is enabled
+var proxyTLS atomic.Value
+
+type proxyTLSConfig struct {
+	port   string
+	config *tls.Config
+}
+
+//setProxyTLS enables TLS for connections to the port of seleniferous
+func setProxyTLS(port string, config *tls.Config) {
+	proxyTLS.Store(&proxyTLSConfig{port: port, config: config})
+}
+
+//dialProxy wraps connection made by proxy transport with TLS if it is made to seleniferous port and mTLS
+//is enabled, connections to other ports of browser pods, e.g. video stream, are left as they are
+func dialProxy(ctx context.Context, conn net.Conn, addr string) (net.Conn, error) {
+	cfg, ok := proxyTLS.Load().(*proxyTLSConfig)
+	if !ok {
+		return conn, nil
+	}
+	return cfg.wrap(ctx, conn, addr)
+}
+
+func (cfg *proxyTLSConfig) wrap(ctx context.Context, conn net.Conn, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || port != cfg.port {
+		return conn, nil
+	}
+
+	config := cfg.config.Clone()
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+	tlsConn := tls.Client(conn, config)
+	if deadline, ok := ctx.Deadline(); ok {
+		tlsConn.SetDeadline(deadline)
+	}
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("tls handshake with %s failed: %v", addr, err)
+	}
+	tlsConn.SetDeadline(time.Time{})
+	return tlsConn, nil
+}
@@ -0,0 +1,210 @@
+package selenosis
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func issueCert(t *testing.T, name string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	assert.NilError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NilError(t, err)
+	return &testCert{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func writeCert(t *testing.T, dir string, c *testCert, modTime time.Time) {
+	key, err := x509.MarshalECPrivateKey(c.key)
+	assert.NilError(t, err)
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "tls.crt"), c.pem, 0600))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "tls.key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: key}), 0600))
+	for _, file := range []string{"tls.crt", "tls.key"} {
+		assert.NilError(t, os.Chtimes(filepath.Join(dir, file), modTime, modTime))
+	}
+}
+
+func TestCertReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certs")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := issueCert(t, "ca", nil)
+	first := issueCert(t, "first", ca)
+	writeCert(t, dir, first, time.Now().Add(-time.Minute))
+
+	reloader, err := NewCertReloader(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), "")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, first.cert.Raw, reloader.certificate().Certificate[0])
+
+	tests := []struct {
+		name     string
+		write    func()
+		reloaded bool
+		err      string
+		cert     *testCert
+	}{
+		{
+			name:  "Verify unchanged files are not reread",
+			write: func() {},
+			cert:  first,
+		},
+		{
+			name: "Verify rotated certificate is used",
+			write: func() {
+				writeCert(t, dir, issueCert(t, "second", ca), time.Now())
+			},
+			reloaded: true,
+		},
+		{
+			name: "Verify invalid certificate keeps previous one",
+			write: func() {
+				assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "tls.crt"), []byte("invalid"), 0600))
+				assert.NilError(t, os.Chtimes(filepath.Join(dir, "tls.crt"), time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+			},
+			err: "failed to load certificate: tls: failed to find any PEM data in certificate input",
+		},
+	}
+
+	for _, test := range tests {
+		t.Logf("TC: %s", test.name)
+		before := reloader.certificate()
+		test.write()
+
+		reloaded, err := reloader.reload()
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			assert.Equal(t, before, reloader.certificate())
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, test.reloaded, reloaded)
+		if test.reloaded {
+			assert.Assert(t, before != reloader.certificate())
+		} else {
+			assert.Equal(t, before, reloader.certificate())
+		}
+	}
+}
+
+func TestProxyTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certs")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := issueCert(t, "ca", nil)
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "ca.crt"), ca.pem, 0600))
+	writeCert(t, dir, issueCert(t, "selenosis", ca), time.Now())
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	server := issueCert(t, "seleniferous", ca)
+	serverKey := tls.Certificate{Certificate: [][]byte{server.cert.Raw}, PrivateKey: server.key}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverKey},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	srv.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+	addr := srv.Listener.Addr().String()
+	_, port, _ := net.SplitHostPort(addr)
+
+	reloader, err := NewCertReloader(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt"))
+	assert.NilError(t, err)
+
+	tests := map[string]struct {
+		port       string
+		serverName string
+		body       string
+		err        string
+	}{
+		"Verify connection to proxy port is made with client certificate": {
+			port:       port,
+			serverName: "seleniferous",
+			body:       "selenosis",
+		},
+		"Verify proxy certificate is verified for server name": {
+			port:       port,
+			serverName: "other",
+			err:        "x509: certificate is valid for seleniferous, not other",
+		},
+		"Verify connection to other port is left as is": {
+			port: "4445",
+			body: "Client sent an HTTP request to an HTTPS server.\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		cfg := &proxyTLSConfig{port: test.port, config: reloader.ClientConfig(test.serverName)}
+		client := &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+				if err != nil {
+					return nil, err
+				}
+				return cfg.wrap(ctx, conn, addr)
+			},
+		}}
+
+		resp, err := client.Get("http://" + addr + "/")
+		if test.err != "" {
+			assert.Assert(t, err != nil && strings.Contains(err.Error(), test.err), "%v", err)
+			continue
+		}
+		assert.NilError(t, err)
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NilError(t, err)
+		assert.Equal(t, test.body, string(body))
+	}
+}
@@ -0,0 +1,289 @@
+package selenosis
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/alcounit/selenosis/tools"
+	"github.com/gorilla/mux"
+)
+
+//uiVideos is number of the latest recordings listed in ui
+const uiVideos = 20
+
+//novncAssets holds noVNC release vendored by assets/vendor.sh, viewer imports its modules from selenosis
+//go:embed assets/novnc
+var novncAssets embed.FS
+
+//novncFS is file system noVNC modules are served from
+var novncFS fs.FS = novncAssets
+
+const novncDir = "assets/novnc"
+
+type uiSession struct {
+	ID           string
+	Browser      string
+	Version      string
+	Status       platform.ServiceStatus
+	Age          string
+	Capabilities []string
+	VNC          bool
+	Relay        bool
+}
+
+type uiVideo struct {
+	Tenant    string
+	SessionID string
+	URL       string
+	Link      bool
+	Created   string
+}
+
+type uiPage struct {
+	Version  string
+	Limit    int
+	Sessions []uiSession
+	Videos   []uiVideo
+}
+
+var uiTemplates = template.Must(template.New("sessions").Parse(uiSessionsPage))
+
+func init() {
+	template.Must(uiTemplates.New("vnc").Parse(uiVNCPage))
+	template.Must(uiTemplates.New("logs").Parse(uiLogsPage))
+}
+
+//HandleUI renders dashboard of active sessions with links to their VNC and logs and the latest recordings
+func (app *App) HandleUI(w http.ResponseWriter, _ *http.Request) {
+	page := uiPage{
+		Version:  app.buildVersion,
+		Limit:    app.sessionLimit,
+		Sessions: make([]uiSession, 0),
+		Videos:   app.uiVideos(),
+	}
+
+	var services []platform.Service
+	for _, s := range app.stats.Sessions().List() {
+		if s.Status == platform.Running || s.Status == platform.Pending {
+			services = append(services, s)
+		}
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Started.Before(services[j].Started)
+	})
+	for _, s := range services {
+		var caps []string
+		for k, v := range s.Labels {
+			if v != "" {
+				caps = append(caps, fmt.Sprintf("%s=%s", k, v))
+			}
+		}
+		sort.Strings(caps)
+		page.Sessions = append(page.Sessions, uiSession{
+			ID:           s.SessionID,
+			Browser:      s.Labels["browserName"],
+			Version:      s.Labels["browserVersion"],
+			Status:       s.Status,
+			Age:          tools.TimeElapsed(s.Started),
+			Capabilities: caps,
+			VNC:          !s.Relay && s.Labels["ENABLE_VNC"] == "true",
+			Relay:        s.Relay,
+		})
+	}
+
+	app.renderUI(w, "sessions", page)
+}
+
+//uiVideos returns the latest recordings of all tenants from artifacts ledger
+func (app *App) uiVideos() []uiVideo {
+	names := []string{""}
+	if app.tenants != nil {
+		for _, tenant := range app.tenants.List() {
+			names = append(names, tenant.Name)
+		}
+	}
+
+	var artifacts []platform.Artifact
+	tenants := make(map[string]string)
+	for _, name := range names {
+		list, err := app.client.Artifacts().List(name)
+		if err != nil {
+			app.logger.Warnf("failed to list artifacts of tenant %q: %v", name, err)
+			continue
+		}
+		for _, artifact := range list {
+			if artifact.Kind == "videos" {
+				artifacts = append(artifacts, artifact)
+				tenants[artifact.URL] = name
+			}
+		}
+	}
+	sort.Slice(artifacts, func(i, j int) bool {
+		return artifacts[i].Created.After(artifacts[j].Created)
+	})
+	if len(artifacts) > uiVideos {
+		artifacts = artifacts[:uiVideos]
+	}
+
+	videos := make([]uiVideo, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		videos = append(videos, uiVideo{
+			Tenant:    tenants[artifact.URL],
+			SessionID: artifact.SessionID,
+			URL:       artifact.URL,
+			Link:      strings.HasPrefix(artifact.URL, "http://") || strings.HasPrefix(artifact.URL, "https://"),
+			Created:   artifact.Created.UTC().Format("2006-01-02 15:04:05"),
+		})
+	}
+	return videos
+}
+
+//HandleUIVNC renders noVNC viewer of the session connected to /vnc/{sessionId}
+func (app *App) HandleUIVNC(w http.ResponseWriter, r *http.Request) {
+	if _, err := fs.Stat(novncFS, novncDir+"/core/rfb.js"); err != nil {
+		app.logger.Errorf("novnc is not available: %v", err)
+		tools.JSONError(w, "VNC viewer is not available: noVNC is not vendored into the binary", http.StatusServiceUnavailable)
+		return
+	}
+	app.handleUISession(w, r, "vnc")
+}
+
+//HandleNoVNC serves noVNC modules imported by VNC viewer
+func (app *App) HandleNoVNC(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/ui/novnc/")
+	if !fs.ValidPath(name) || !strings.HasSuffix(name, ".js") {
+		tools.JSONError(w, fmt.Sprintf("unknown noVNC module %s", name), http.StatusNotFound)
+		return
+	}
+	module, err := fs.ReadFile(novncFS, novncDir+"/"+name)
+	if err != nil {
+		tools.JSONError(w, fmt.Sprintf("unknown noVNC module %s", name), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/javascript")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Write(module)
+}
+
+//HandleUILogs renders logs of the session streamed from /logs/{sessionId}
+func (app *App) HandleUILogs(w http.ResponseWriter, r *http.Request) {
+	app.handleUISession(w, r, "logs")
+}
+
+func (app *App) handleUISession(w http.ResponseWriter, r *http.Request, name string) {
+	sessionID := mux.Vars(r)["sessionId"]
+	if _, ok := app.stats.Sessions().Get(sessionID); !ok {
+		tools.JSONError(w, fmt.Sprintf("unknown session %s", sessionID), http.StatusNotFound)
+		return
+	}
+	app.renderUI(w, name, struct{ ID string }{sessionID})
+}
+
+func (app *App) renderUI(w http.ResponseWriter, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := uiTemplates.ExecuteTemplate(w, name, data); err != nil {
+		app.logger.Errorf("failed to render ui: %v", err)
+	}
+}
+
+const uiStyle = `<style>
+      body { font-family: sans-serif; margin: 2em; color: #222; }
+      table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+      th, td { text-align: left; padding: 6px 10px; border-bottom: 1px solid #ddd; vertical-align: top; }
+      th { background: #f4f4f4; }
+      code { font-size: 0.85em; }
+      .caps { font-size: 0.8em; color: #555; }
+      pre { background: #111; color: #ddd; padding: 1em; white-space: pre-wrap; }
+    </style>`
+
+const uiSessionsPage = `<!DOCTYPE html>
+<html>
+  <head>
+    <title>selenosis</title>
+    <meta charset="utf-8"/>
+    <meta http-equiv="refresh" content="10">
+    ` + uiStyle + `
+  </head>
+  <body>
+    <h2>selenosis {{.Version}}</h2>
+    <p>{{len .Sessions}} of {{.Limit}} sessions</p>
+    <table>
+      <tr><th>Session</th><th>Browser</th><th>Status</th><th>Age</th><th>Capabilities</th><th></th></tr>
+      {{range .Sessions}}
+      <tr>
+        <td><code>{{.ID}}</code></td>
+        <td>{{.Browser}} {{.Version}}</td>
+        <td>{{.Status}}{{if .Relay}} (relay){{end}}</td>
+        <td>{{.Age}}</td>
+        <td class="caps">{{range .Capabilities}}{{.}}<br/>{{end}}</td>
+        <td>{{if .VNC}}<a href="/ui/vnc/{{.ID}}">VNC</a> {{end}}{{if not .Relay}}<a href="/ui/logs/{{.ID}}">logs</a>{{end}}</td>
+      </tr>
+      {{else}}
+      <tr><td colspan="6">No active sessions</td></tr>
+      {{end}}
+    </table>
+    {{if .Videos}}
+    <h3>Recent videos</h3>
+    <table>
+      <tr><th>Session</th><th>Tenant</th><th>Uploaded</th><th>Video</th></tr>
+      {{range .Videos}}
+      <tr>
+        <td><code>{{.SessionID}}</code></td>
+        <td>{{.Tenant}}</td>
+        <td>{{.Created}}</td>
+        <td>{{if .Link}}<a href="{{.URL}}">{{.URL}}</a>{{else}}<code>{{.URL}}</code>{{end}}</td>
+      </tr>
+      {{end}}
+    </table>
+    {{end}}
+  </body>
+</html>
+`
+
+const uiVNCPage = `<!DOCTYPE html>
+<html>
+  <head>
+    <title>{{.ID}} - VNC</title>
+    <meta charset="utf-8"/>
+    <style>body { margin: 0; background: #333; } #screen { width: 100vw; height: 100vh; }</style>
+  </head>
+  <body>
+    <div id="screen"></div>
+    <script type="module">
+      import RFB from "/ui/novnc/core/rfb.js";
+      const scheme = location.protocol === "https:" ? "wss" : "ws";
+      const rfb = new RFB(document.getElementById("screen"), scheme + "://" + location.host + "/vnc/" + {{.ID}}, {credentials: {password: "selenoid"}});
+      rfb.scaleViewport = true;
+      rfb.viewOnly = true;
+    </script>
+  </body>
+</html>
+`
+
+const uiLogsPage = `<!DOCTYPE html>
+<html>
+  <head>
+    <title>{{.ID}} - logs</title>
+    <meta charset="utf-8"/>
+    ` + uiStyle + `
+  </head>
+  <body>
+    <h3><code>{{.ID}}</code></h3>
+    <pre id="logs"></pre>
+    <script>
+      const scheme = location.protocol === "https:" ? "wss" : "ws";
+      const ws = new WebSocket(scheme + "://" + location.host + "/logs/" + {{.ID}});
+      const logs = document.getElementById("logs");
+      ws.binaryType = "arraybuffer";
+      ws.onmessage = (e) => { logs.textContent += typeof e.data === "string" ? e.data : new TextDecoder().decode(e.data); };
+      ws.onclose = () => { logs.textContent += "\n-- stream closed --"; };
+    </script>
+  </body>
+</html>
+`
@@ -0,0 +1,206 @@
+package selenosis
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/gorilla/mux"
+	"gotest.tools/assert"
+)
+
+func TestHandleUI(t *testing.T) {
+	const sessionID = "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491"
+
+	tests := map[string]struct {
+		sessions  map[string]platform.Service
+		artifacts map[string][]platform.Artifact
+		contains  []string
+		excludes  []string
+	}{
+		"Verify empty dashboard": {
+			contains: []string{"0 of 0 sessions", "No active sessions"},
+			excludes: []string{"Recent videos"},
+		},
+		"Verify running session links to vnc and logs": {
+			sessions: map[string]platform.Service{
+				sessionID: {SessionID: sessionID, Status: platform.Running, Started: time.Now(), Labels: map[string]string{"browserName": "chrome", "browserVersion": "85.0", "ENABLE_VNC": "true"}},
+			},
+			contains: []string{"1 of 0 sessions", "chrome 85.0", "ENABLE_VNC=true", `href="/ui/vnc/` + sessionID + `"`, `href="/ui/logs/` + sessionID + `"`},
+		},
+		"Verify relayed session has no links": {
+			sessions: map[string]platform.Service{
+				sessionID: {SessionID: sessionID, Status: platform.Running, Relay: true, Started: time.Now(), Labels: map[string]string{"browserName": "chrome", "ENABLE_VNC": "true"}},
+			},
+			contains: []string{"Running (relay)"},
+			excludes: []string{"/ui/vnc/", "/ui/logs/"},
+		},
+		"Verify sessions in unknown state are not listed": {
+			sessions: map[string]platform.Service{
+				sessionID: {SessionID: sessionID, Status: platform.Unknown, Started: time.Now()},
+			},
+			contains: []string{"No active sessions"},
+			excludes: []string{sessionID},
+		},
+		"Verify recorded videos are listed": {
+			artifacts: map[string][]platform.Artifact{
+				"": {
+					{SessionID: sessionID, Kind: "videos", URL: "https://videos.example.com/" + sessionID + ".mp4", Created: time.Now()},
+					{SessionID: sessionID, Kind: "logs", URL: "https://logs.example.com/" + sessionID + ".log", Created: time.Now()},
+				},
+			},
+			contains: []string{"Recent videos", `<a href="https://videos.example.com/` + sessionID + `.mp4">`},
+			excludes: []string{"logs.example.com"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{artifacts: test.artifacts})
+		for id, service := range test.sessions {
+			app.stats.Sessions().Put(id, service)
+		}
+
+		req, err := http.NewRequest(http.MethodGet, "/ui", nil)
+		assert.NilError(t, err)
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(app.HandleUI).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+		for _, s := range test.contains {
+			assert.Assert(t, strings.Contains(rr.Body.String(), s), s)
+		}
+		for _, s := range test.excludes {
+			assert.Assert(t, !strings.Contains(rr.Body.String(), s), s)
+		}
+	}
+}
+
+func TestHandleUISession(t *testing.T) {
+	const sessionID = "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491"
+
+	tests := map[string]struct {
+		url      string
+		novnc    fstest.MapFS
+		code     int
+		contains string
+	}{
+		"Verify vnc viewer connects to session websocket": {
+			url:      "/ui/vnc/" + sessionID,
+			code:     http.StatusOK,
+			contains: `"/vnc/" + "` + sessionID + `"`,
+		},
+		"Verify logs page streams session logs": {
+			url:      "/ui/logs/" + sessionID,
+			code:     http.StatusOK,
+			contains: `"/logs/" + "` + sessionID + `"`,
+		},
+		"Verify vnc viewer imports noVNC from selenosis": {
+			url:      "/ui/vnc/" + sessionID,
+			code:     http.StatusOK,
+			contains: `import RFB from "/ui/novnc/core/rfb.js";`,
+		},
+		"Verify vnc viewer is not served without noVNC": {
+			url:      "/ui/vnc/" + sessionID,
+			novnc:    fstest.MapFS{},
+			code:     http.StatusServiceUnavailable,
+			contains: "noVNC is not vendored into the binary",
+		},
+		"Verify unknown session is not found": {
+			url:      "/ui/vnc/unknown",
+			code:     http.StatusNotFound,
+			contains: `{"code":404,"value":{"message":"unknown session unknown"}}`,
+		},
+	}
+
+	defer func(f fs.FS) { novncFS = f }(novncFS)
+
+	app := initApp(&PlatformMock{})
+	app.stats.Sessions().Put(sessionID, platform.Service{SessionID: sessionID, Status: platform.Running})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/ui/vnc/{sessionId}", app.HandleUIVNC)
+	router.HandleFunc("/ui/logs/{sessionId}", app.HandleUILogs)
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		novncFS = testNoVNC
+		if test.novnc != nil {
+			novncFS = test.novnc
+		}
+
+		req, err := http.NewRequest(http.MethodGet, test.url, nil)
+		assert.NilError(t, err)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, test.code, rr.Code)
+		assert.Assert(t, strings.Contains(rr.Body.String(), test.contains), rr.Body.String())
+	}
+}
+
+var testNoVNC = fstest.MapFS{
+	"assets/novnc/core/rfb.js":                     &fstest.MapFile{Data: []byte(`import * as Log from './util/logging.js';`)},
+	"assets/novnc/vendor/pako/lib/zlib/inflate.js": &fstest.MapFile{Data: []byte("export function inflate() {}")},
+	"assets/novnc/VERSION":                         &fstest.MapFile{Data: []byte("1.2.0")},
+}
+
+func TestHandleNoVNC(t *testing.T) {
+	tests := map[string]struct {
+		url      string
+		code     int
+		contains string
+	}{
+		"Verify noVNC module is served": {
+			url:      "/ui/novnc/core/rfb.js",
+			code:     http.StatusOK,
+			contains: "./util/logging.js",
+		},
+		"Verify vendored pako module is served": {
+			url:      "/ui/novnc/vendor/pako/lib/zlib/inflate.js",
+			code:     http.StatusOK,
+			contains: "inflate",
+		},
+		"Verify unknown module is not found": {
+			url:      "/ui/novnc/core/unknown.js",
+			code:     http.StatusNotFound,
+			contains: "unknown noVNC module core/unknown.js",
+		},
+		"Verify files which are not modules are not served": {
+			url:      "/ui/novnc/VERSION",
+			code:     http.StatusNotFound,
+			contains: "unknown noVNC module VERSION",
+		},
+		"Verify path out of noVNC directory is not served": {
+			url:      "/ui/novnc/../../openapi.js",
+			code:     http.StatusNotFound,
+			contains: "unknown noVNC module",
+		},
+	}
+
+	defer func(f fs.FS) { novncFS = f }(novncFS)
+	novncFS = testNoVNC
+
+	app := initApp(&PlatformMock{})
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		rr := httptest.NewRecorder()
+		app.HandleNoVNC(rr, httptest.NewRequest(http.MethodGet, test.url, nil))
+
+		assert.Equal(t, test.code, rr.Code)
+		assert.Assert(t, strings.Contains(rr.Body.String(), test.contains), rr.Body.String())
+		if test.code == http.StatusOK {
+			assert.Equal(t, "application/javascript", rr.Header().Get("Content-Type"))
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package selenosis
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"path"
+	"strconv"
+
+	"github.com/alcounit/selenosis/tools"
+	"github.com/gorilla/mux"
+)
+
+//HandleVideoStream proxies live stream of video recorder of the session, stream path is configured per
+//browser. Files next to the stream, e.g. HLS segments, are requested by name under the stream path
+func (app *App) HandleVideoStream(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := app.containerSession(w, r, "video stream is not available for relayed session %s")
+	if !ok {
+		return
+	}
+	service, ok := app.stats.Sessions().Get(sessionID)
+	if !ok {
+		tools.JSONError(w, fmt.Sprintf("unknown session %s", sessionID), http.StatusNotFound)
+		return
+	}
+	stream := service.VideoStream
+	if stream == nil {
+		tools.JSONError(w, fmt.Sprintf("video stream is not available for session %s", sessionID), http.StatusNotFound)
+		return
+	}
+
+	logger := app.requestLogger(r, sessionID)
+
+	streamPath := path.Join("/", stream.Path)
+	if file := mux.Vars(r)["file"]; file != "" {
+		streamPath = path.Join(path.Dir(streamPath), path.Base(file))
+	}
+
+	(&httputil.ReverseProxy{
+		Transport:     transport,
+		FlushInterval: -1,
+		Director: func(r *http.Request) {
+			r.URL.Scheme = "http"
+			r.Host = app.sessionHost(sessionID, strconv.Itoa(stream.Port))
+			r.URL.Host = r.Host
+			r.URL.Path = streamPath
+			r.URL.RawPath = ""
+			r.Header.Set("X-Forwarded-Selenosis", app.selenosisHost)
+			logger.Info("proxying video stream")
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logger.Errorf("video stream proxying error: %v", err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}).ServeHTTP(w, r)
+}
@@ -0,0 +1,85 @@
+package selenosis
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/gorilla/mux"
+	"gotest.tools/assert"
+)
+
+func TestHandleVideoStream(t *testing.T) {
+	recorder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer recorder.Close()
+	host, port, err := net.SplitHostPort(recorder.Listener.Addr().String())
+	assert.NilError(t, err)
+	streamPort, err := strconv.Atoi(port)
+	assert.NilError(t, err)
+
+	tests := map[string]struct {
+		stream   *platform.VideoStreamSpec
+		relay    bool
+		unknown  bool
+		file     string
+		code     int
+		respBody string
+	}{
+		"Verify stream is proxied from recorder": {
+			stream:   &platform.VideoStreamSpec{Port: streamPort, Path: "/live/stream.m3u8"},
+			code:     http.StatusOK,
+			respBody: "/live/stream.m3u8",
+		},
+		"Verify file next to stream is proxied from recorder": {
+			stream:   &platform.VideoStreamSpec{Port: streamPort, Path: "/live/stream.m3u8"},
+			file:     "segment1.ts",
+			code:     http.StatusOK,
+			respBody: "/live/segment1.ts",
+		},
+		"Verify session without stream is not found": {
+			code:     http.StatusNotFound,
+			respBody: `{"code":404,"value":{"message":"video stream is not available for session chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491"}}`,
+		},
+		"Verify relayed session has no stream": {
+			relay:    true,
+			code:     http.StatusNotFound,
+			respBody: `{"code":404,"value":{"message":"video stream is not available for relayed session chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491"}}`,
+		},
+		"Verify unknown session is not found": {
+			unknown:  true,
+			code:     http.StatusNotFound,
+			respBody: `{"code":404,"value":{"message":"unknown session chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491"}}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		sessionID := "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491"
+		if !test.unknown {
+			app.stats.Sessions().Put(sessionID, platform.Service{
+				SessionID:   sessionID,
+				URL:         &url.URL{Scheme: "http", Host: net.JoinHostPort(host, "4445")},
+				Relay:       test.relay,
+				VideoStream: test.stream,
+			})
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/video/"+sessionID+"/stream", nil)
+		req = mux.SetURLVars(req, map[string]string{"sessionId": sessionID, "file": test.file})
+		rr := httptest.NewRecorder()
+		app.HandleVideoStream(rr, req)
+
+		assert.Equal(t, test.code, rr.Code)
+		assert.Equal(t, test.respBody, strings.TrimSpace(rr.Body.String()))
+	}
+}
@@ -0,0 +1,49 @@
+package selenosis
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/alcounit/selenosis/tools"
+	"github.com/gorilla/mux"
+	"golang.org/x/net/websocket"
+)
+
+//vncProtocol is websocket subprotocol of raw VNC stream used by websockify and noVNC
+const vncProtocol = "binary"
+
+//vncPort is VNC server port of browser container
+var vncPort = "5900"
+
+//HandleVNCWebSocket checks session before websocket upgrade and serves VNC stream of the browser pod
+//websockify style, so noVNC can watch live session through selenosis without reaching pod address
+func (app *App) HandleVNCWebSocket(w http.ResponseWriter, r *http.Request) {
+	sessionID := mux.Vars(r)["sessionId"]
+	if !isValidSession(sessionID) {
+		tools.JSONError(w, fmt.Sprintf("%s is not valid session id", sessionID), http.StatusBadRequest)
+		return
+	}
+	if _, ok := app.relayedSession(sessionID); ok {
+		tools.JSONError(w, fmt.Sprintf("vnc is not available for relayed session %s", sessionID), http.StatusNotFound)
+		return
+	}
+
+	websocket.Server{
+		Handshake: vncHandshake,
+		Handler:   app.HandleVNC(),
+	}.ServeHTTP(w, r)
+}
+
+//vncHandshake accepts connections of any origin and selects binary subprotocol if client offers it,
+//other subprotocols are not supported
+func vncHandshake(config *websocket.Config, _ *http.Request) error {
+	offered := config.Protocol
+	config.Protocol = nil
+	for _, protocol := range offered {
+		if protocol == vncProtocol {
+			config.Protocol = []string{vncProtocol}
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package selenosis
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/alcounit/selenosis/platform"
+	"github.com/gorilla/mux"
+	"golang.org/x/net/websocket"
+	"gotest.tools/assert"
+)
+
+func TestHandleVNCWebSocket(t *testing.T) {
+	const sessionID = "chrome-85-0-de44c3c4-1a35-412b-b526-f5da80214491"
+
+	vnc, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer vnc.Close()
+	go func() {
+		conn, err := vnc.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("RFB 003.008\n"))
+		buf := make([]byte, 12)
+		n, _ := conn.Read(buf)
+		conn.Write(buf[:n])
+	}()
+	defer func(port string) { vncPort = port }(vncPort)
+	_, vncPort, _ = net.SplitHostPort(vnc.Addr().String())
+
+	app := initApp(&PlatformMock{})
+	app.stats.Sessions().Put(sessionID, platform.Service{SessionID: sessionID, URL: &url.URL{Scheme: "http", Host: "127.0.0.1:4445"}, Status: platform.Running})
+
+	router := mux.NewRouter()
+	router.PathPrefix("/vnc/{sessionId}").HandlerFunc(app.HandleVNCWebSocket)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	config, err := websocket.NewConfig("ws"+srv.URL[len("http"):]+"/vnc/"+sessionID, srv.URL)
+	assert.NilError(t, err)
+	config.Protocol = []string{"base64", "binary"}
+	ws, err := websocket.DialConfig(config)
+	assert.NilError(t, err)
+	defer ws.Close()
+	assert.DeepEqual(t, []string{"binary"}, ws.Config().Protocol)
+
+	version := make([]byte, 12)
+	_, err = ws.Read(version)
+	assert.NilError(t, err)
+	assert.Equal(t, "RFB 003.008\n", string(version))
+
+	_, err = ws.Write([]byte("RFB 003.008\n"))
+	assert.NilError(t, err)
+	reply := make([]byte, 12)
+	_, err = ws.Read(reply)
+	assert.NilError(t, err)
+	assert.Equal(t, "RFB 003.008\n", string(reply))
+}
+
+func TestHandleVNCWebSocketErrors(t *testing.T) {
+	const sessionID = "safari-14-0-de44c3c4-1a35-412b-b526-f5da80214491"
+
+	tests := map[string]struct {
+		sessionID string
+		relay     bool
+		code      int
+		body      string
+	}{
+		"Verify invalid session id is rejected": {
+			sessionID: "session",
+			code:      http.StatusBadRequest,
+			body:      `{"code":400,"value":{"message":"session is not valid session id"}}`,
+		},
+		"Verify relayed session has no vnc": {
+			sessionID: sessionID,
+			relay:     true,
+			code:      http.StatusNotFound,
+			body:      `{"code":404,"value":{"message":"vnc is not available for relayed session ` + sessionID + `"}}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		if test.relay {
+			u, _ := url.Parse("https://hub.example.com/wd/hub/session/0a4a5c4c")
+			app.stats.Sessions().Put(sessionID, platform.Service{SessionID: sessionID, URL: u, Relay: true})
+		}
+
+		router := mux.NewRouter()
+		router.PathPrefix("/vnc/{sessionId}").HandlerFunc(app.HandleVNCWebSocket)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/vnc/"+test.sessionID, nil))
+		assert.Equal(t, test.code, rr.Code)
+		assert.Equal(t, test.body, strings.TrimSpace(rr.Body.String()))
+	}
+}
@@ -0,0 +1,54 @@
+package selenosis
+
+import (
+	"time"
+
+	"github.com/alcounit/selenosis/platform"
+)
+
+//RunWarmPool periodically refills warm pools of browsers until stop is closed
+func (app *App) RunWarmPool(stop <-chan struct{}) {
+	pooler, ok := app.client.(platform.WarmPooler)
+	if app.warmPoolInterval <= 0 || !ok {
+		return
+	}
+
+	ticker := time.NewTicker(app.warmPoolInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			app.fillWarmPool(pooler)
+		}
+	}
+}
+
+func (app *App) fillWarmPool(pooler platform.WarmPooler) {
+	if app.Draining() || !app.leading() {
+		return
+	}
+	if err := pooler.FillWarmPool(app.browsers.WarmPools(), app.warmCapacity()); err != nil {
+		app.logger.WithField("component", "warmPool").Errorf("failed to fill warm pool: %v", err)
+	}
+}
+
+//warmCapacity returns number of idle warm pods session limit has room for, running, pending and
+//queued sessions go first
+func (app *App) warmCapacity() int {
+	used := app.queue.Len()
+	for _, s := range app.stats.Sessions().List() {
+		if s.Relay {
+			continue
+		}
+		if s.Status == platform.Running || s.Status == platform.Pending {
+			used++
+		}
+	}
+	if used >= app.sessionLimit {
+		return 0
+	}
+	return app.sessionLimit - used
+}
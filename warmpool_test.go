@@ -0,0 +1,82 @@
+package selenosis
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alcounit/selenosis/platform"
+	"gotest.tools/assert"
+)
+
+type warmPoolMock struct {
+	*PlatformMock
+	templates []platform.BrowserSpec
+	capacity  int
+	err       error
+}
+
+func (p *warmPoolMock) FillWarmPool(templates []platform.BrowserSpec, capacity int) error {
+	p.templates = templates
+	p.capacity = capacity
+	return p.err
+}
+
+func TestFillWarmPool(t *testing.T) {
+	running := func(id string, relay bool) platform.Service {
+		return platform.Service{SessionID: id, Status: platform.Running, Relay: relay}
+	}
+
+	tests := map[string]struct {
+		limit    int
+		sessions []platform.Service
+		queued   int
+		capacity int
+		err      error
+	}{
+		"Verify free session limit is filled": {
+			limit:    5,
+			sessions: []platform.Service{running("s1", false)},
+			capacity: 4,
+		},
+		"Verify relayed sessions are not counted": {
+			limit:    5,
+			sessions: []platform.Service{running("s1", false), running("s2", true)},
+			capacity: 4,
+		},
+		"Verify queued sessions go first": {
+			limit:    5,
+			sessions: []platform.Service{running("s1", false)},
+			queued:   4,
+			capacity: 0,
+		},
+		"Verify exhausted session limit leaves no capacity": {
+			limit:    1,
+			sessions: []platform.Service{running("s1", false), running("s2", false)},
+			capacity: 0,
+		},
+		"Verify fill error is not fatal": {
+			limit:    2,
+			capacity: 2,
+			err:      errors.New("failed to list warm pods"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		app := initApp(&PlatformMock{})
+		app.sessionLimit = test.limit
+		for _, s := range test.sessions {
+			app.stats.Sessions().Put(s.SessionID, s)
+		}
+		for i := 0; i < test.queued; i++ {
+			app.queue.waiting = append(app.queue.waiting, app.queue.next)
+			app.queue.next++
+		}
+
+		pooler := &warmPoolMock{capacity: -1, err: test.err}
+		app.fillWarmPool(pooler)
+		assert.Equal(t, test.capacity, pooler.capacity)
+		assert.DeepEqual(t, app.browsers.WarmPools(), pooler.templates)
+	}
+}
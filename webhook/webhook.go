@@ -0,0 +1,198 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+//EventType ...
+type EventType string
+
+const (
+	//RunFinished is sent when the last session of a run is deleted
+	RunFinished EventType = "run.finished"
+	//BackendUnhealthy is sent when cluster api of the backend stops responding
+	BackendUnhealthy EventType = "backend.unhealthy"
+	//BackendRecovered is sent when cluster api of unhealthy backend responds again
+	BackendRecovered EventType = "backend.recovered"
+	//SessionStranded is sent for every session of unhealthy backend, session can be retried on healthy backend
+	SessionStranded EventType = "session.stranded"
+	//SelenosisDraining is sent when selenosis instance is shutting down, sessions lists registry of the instance
+	SelenosisDraining EventType = "selenosis.draining"
+	//SessionCreated is sent when browser session is started
+	SessionCreated EventType = "session.created"
+	//SessionFailed is sent when new session request for configured browser is not fulfilled
+	SessionFailed EventType = "session.failed"
+	//SessionDeleted is sent when browser of the session is gone
+	SessionDeleted EventType = "session.deleted"
+	//SessionTimedOut is sent instead of SessionDeleted when session was idle for its idle timeout before browser was gone
+	SessionTimedOut EventType = "session.timeout"
+	//SessionLost is sent when browser pod of running session was evicted, drained from its node or failed
+	SessionLost EventType = "session.lost"
+	//VideoUploaded is sent when video recording of the session is stored
+	VideoUploaded EventType = "video.uploaded"
+)
+
+//SignatureHeader keeps hex encoded HMAC-SHA256 of request body signed with webhook secret
+const SignatureHeader = "X-Selenosis-Signature"
+
+//Event is a payload posted to webhook endpoint
+type Event struct {
+	Type      EventType `json:"type"`
+	Time      time.Time `json:"time"`
+	RunID     string    `json:"runId,omitempty"`
+	SessionID string    `json:"sessionId,omitempty"`
+	Tenant    string    `json:"tenant,omitempty"`
+	Browser   string    `json:"browser,omitempty"`
+	Version   string    `json:"version,omitempty"`
+	URL       string    `json:"url,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	Sessions  []string  `json:"sessions,omitempty"`
+}
+
+//Config ...
+type Config struct {
+	URLs       []string
+	Secret     string
+	Retries    int
+	RetryDelay time.Duration
+}
+
+//Notifier posts events to webhook endpoints
+type Notifier struct {
+	urls       []string
+	secret     []byte
+	retries    int
+	retryDelay time.Duration
+	timeout    time.Duration
+	httpClient *http.Client
+	logger     *log.Logger
+}
+
+//New returns notifier, events are dropped if no urls set
+func New(logger *log.Logger, c Config) *Notifier {
+	var urls []string
+	for _, u := range c.URLs {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	retryDelay := c.RetryDelay
+	if retryDelay <= 0 {
+		retryDelay = time.Second
+	}
+	return &Notifier{
+		urls:       urls,
+		secret:     []byte(c.Secret),
+		retries:    c.Retries,
+		retryDelay: retryDelay,
+		timeout:    10 * time.Second,
+		httpClient: http.DefaultClient,
+		logger:     logger,
+	}
+}
+
+//Enabled ...
+func (n *Notifier) Enabled() bool {
+	return n != nil && len(n.urls) > 0
+}
+
+//Notify sends event in background
+func (n *Notifier) Notify(event Event) {
+	if !n.Enabled() {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	go func() {
+		if err := n.Send(context.Background(), event); err != nil {
+			n.logger.WithField("component", "webhook").Errorf("failed to send %s event: %v", event.Type, err)
+		}
+	}()
+}
+
+//Send posts event to every webhook endpoint, failed request is retried with doubled delay until retries
+//are exhausted or ctx is done. Errors of all endpoints are returned together
+func (n *Notifier) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %v", err)
+	}
+
+	var errs []string
+	for _, url := range n.urls {
+		if err := n.deliver(ctx, url, body); err != nil {
+			if len(n.urls) == 1 {
+				return err
+			}
+			errs = append(errs, fmt.Sprintf("%s: %v", url, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+//deliver posts body to url, request is retried on failure
+func (n *Notifier) deliver(ctx context.Context, url string, body []byte) error {
+	delay := n.retryDelay
+	for attempt := 0; ; attempt++ {
+		err := n.post(ctx, url, body)
+		if err == nil || attempt >= n.retries {
+			return err
+		}
+		n.logger.WithField("component", "webhook").Warnf("failed to post event to %s, retrying in %s: %v", url, delay, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+//post sends single request, body is signed if secret is set
+func (n *Notifier) post(ctx context.Context, url string, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(n.secret) > 0 {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(n.secret, body))
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("endpoint responded with %d", resp.StatusCode)
+	}
+	return nil
+}
+
+//Sign returns hex encoded HMAC-SHA256 of body, receivers compare it with signature header
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
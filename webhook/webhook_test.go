@@ -0,0 +1,124 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+)
+
+func TestSend(t *testing.T) {
+	tests := map[string]struct {
+		statusCode int
+		err        string
+	}{
+		"Verify event delivered": {
+			statusCode: http.StatusOK,
+		},
+		"Verify error on unexpected status code": {
+			statusCode: http.StatusInternalServerError,
+			err:        "endpoint responded with 500",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		var received Event
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewDecoder(r.Body).Decode(&received)
+			w.WriteHeader(test.statusCode)
+		}))
+
+		event := Event{Type: RunFinished, Time: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), RunID: "build-42"}
+		err := New(log.New(), Config{URLs: []string{srv.URL}}).Send(context.Background(), event)
+		srv.Close()
+
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.DeepEqual(t, event, received)
+	}
+}
+
+func TestSendRetriesAndSigns(t *testing.T) {
+	tests := map[string]struct {
+		failures int32
+		retries  int
+		attempts int32
+		err      string
+	}{
+		"Verify event is delivered after retries": {
+			failures: 2,
+			retries:  3,
+			attempts: 3,
+		},
+		"Verify error when retries are exhausted": {
+			failures: 5,
+			retries:  1,
+			attempts: 2,
+			err:      "endpoint responded with 503",
+		},
+	}
+
+	for name, test := range tests {
+		t.Logf("TC: %s", name)
+
+		var attempts int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			assert.Equal(t, "sha256="+Sign([]byte("s3cr3t"), body), r.Header.Get(SignatureHeader))
+			if atomic.AddInt32(&attempts, 1) <= test.failures {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+
+		n := New(log.New(), Config{URLs: []string{srv.URL}, Secret: "s3cr3t", Retries: test.retries, RetryDelay: time.Millisecond})
+		err := n.Send(context.Background(), Event{Type: SessionCreated, SessionID: "chrome-85-0-1"})
+		srv.Close()
+
+		assert.Equal(t, test.attempts, atomic.LoadInt32(&attempts))
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+		assert.NilError(t, err)
+	}
+}
+
+func TestSendToEndpoints(t *testing.T) {
+	var delivered int32
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "", r.Header.Get(SignatureHeader))
+		atomic.AddInt32(&delivered, 1)
+	}))
+	defer ok.Close()
+	failed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer failed.Close()
+
+	n := New(log.New(), Config{URLs: []string{ok.URL, failed.URL, " "}})
+	err := n.Send(context.Background(), Event{Type: SessionDeleted, SessionID: "chrome-85-0-1"})
+	assert.Error(t, err, failed.URL+": endpoint responded with 400")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&delivered))
+}
+
+func TestSign(t *testing.T) {
+	assert.Equal(t, "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8", Sign([]byte("key"), []byte("The quick brown fox jumps over the lazy dog")))
+}
+
+func TestNotifyWithoutURL(t *testing.T) {
+	var n *Notifier
+	n.Notify(Event{Type: RunFinished})
+	New(log.New(), Config{}).Notify(Event{Type: RunFinished})
+}